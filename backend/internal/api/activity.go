@@ -32,7 +32,7 @@ func (s *Server) getActivity(c echo.Context) error {
 
 	// Get recent downloads
 	var downloads []db.Download
-	s.db.Order("added_at DESC").Limit(limit).Find(&downloads)
+	s.db.Scopes(s.ofVisibleBooks(c, "downloads.book_id", "books.id")).Order("added_at DESC").Limit(limit).Find(&downloads)
 
 	activities := make([]ActivityEvent, 0)
 
@@ -57,7 +57,7 @@ func (s *Server) getActivity(c echo.Context) error {
 
 	// Get recent imports (media files)
 	var mediaFiles []db.MediaFile
-	s.db.Preload("Book").Order("imported_at DESC").Limit(limit).Find(&mediaFiles)
+	s.db.Scopes(s.ofVisibleBooks(c, "media_files.book_id", "books.id")).Preload("Book").Order("imported_at DESC").Limit(limit).Find(&mediaFiles)
 
 	for _, mf := range mediaFiles {
 		activities = append(activities, ActivityEvent{
@@ -100,8 +100,9 @@ func (s *Server) getActivityHistory(c echo.Context) error {
 	var downloads []db.Download
 	var total int64
 	
-	s.db.Model(&db.Download{}).Count(&total)
-	s.db.Order("added_at DESC").Offset(offset).Limit(pageSize).Find(&downloads)
+	visible := s.ofVisibleBooks(c, "downloads.book_id", "books.id")
+	s.db.Model(&db.Download{}).Scopes(visible).Count(&total)
+	s.db.Scopes(visible).Order("added_at DESC").Offset(offset).Limit(pageSize).Find(&downloads)
 
 	activities := make([]ActivityEvent, 0, len(downloads))
 	for _, d := range downloads {
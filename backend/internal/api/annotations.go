@@ -0,0 +1,290 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// Annotations. The reader and player save a user's bookmarks and highlights, each with an
+// optional note: at a locator such as a CFI in an ebook, or at a time in an audiobook.
+// Users export them as Markdown or JSON, for a book or their whole library.
+
+// Annotation types
+const (
+	annotationBookmark  = "bookmark"
+	annotationHighlight = "highlight"
+)
+
+// AnnotationRequest creates an annotation
+type AnnotationRequest struct {
+	MediaFileID uint   `json:"mediaFileId" validate:"required"`
+	Type        string `json:"type" validate:"required,oneof=bookmark highlight"`
+	Locator     string `json:"locator" validate:"max=4000"` // Required in an ebook
+	Position    int    `json:"position" validate:"min=0"`   // Seconds into an audiobook, or the page
+	EndPosition int    `json:"endPosition" validate:"min=0"`
+	Chapter     string `json:"chapter" validate:"max=500"`
+	Text        string `json:"text" validate:"max=20000"`
+	Note        string `json:"note" validate:"max=20000"`
+	Color       string `json:"color" validate:"max=20"`
+}
+
+// UpdateAnnotationRequest changes an annotation's note, color or highlighted text
+type UpdateAnnotationRequest struct {
+	Text  *string `json:"text,omitempty" validate:"max=20000"`
+	Note  *string `json:"note,omitempty" validate:"max=20000"`
+	Color *string `json:"color,omitempty" validate:"max=20"`
+}
+
+// AnnotationResponse is an annotation in the API
+type AnnotationResponse struct {
+	ID          uint      `json:"id"`
+	MediaFileID uint      `json:"mediaFileId"`
+	BookID      uint      `json:"bookId"`
+	MediaType   string    `json:"mediaType"`
+	Type        string    `json:"type"`
+	Locator     string    `json:"locator,omitempty"`
+	Position    int       `json:"position"`
+	EndPosition int       `json:"endPosition,omitempty"`
+	Chapter     string    `json:"chapter,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	Color       string    `json:"color,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// annotationQuery returns the current user's annotations, narrowed by ?bookId=,
+// ?mediaFileId= and ?type=, in reading order
+func (s *Server) annotationQuery(c echo.Context) ([]db.Annotation, error) {
+	query := s.db.Preload("MediaFile").Where("user_id = ?", currentUserID(c))
+	if bookID := c.QueryParam("bookId"); bookID != "" {
+		query = query.Where("book_id = ?", bookID)
+	}
+	if mediaFileID := c.QueryParam("mediaFileId"); mediaFileID != "" {
+		query = query.Where("media_file_id = ?", mediaFileID)
+	}
+	if annotationType := c.QueryParam("type"); annotationType != "" {
+		query = query.Where("type = ?", annotationType)
+	}
+
+	var annotations []db.Annotation
+	err := query.Order("book_id, media_file_id, position, created_at").Find(&annotations).Error
+	return annotations, err
+}
+
+// getAnnotations returns the current user's annotations
+func (s *Server) getAnnotations(c echo.Context) error {
+	annotations, err := s.annotationQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load annotations"})
+	}
+
+	responses := make([]AnnotationResponse, len(annotations))
+	for i, a := range annotations {
+		responses[i] = toAnnotationResponse(a)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// createAnnotation saves a bookmark or highlight in a media file the user can see
+func (s *Server) createAnnotation(c echo.Context) error {
+	var req AnnotationRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var file db.MediaFile
+	if err := s.db.First(&file, req.MediaFileID).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+	if file.MediaType == db.MediaTypeEbook && strings.TrimSpace(req.Locator) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "An ebook annotation needs a locator"})
+	}
+	if req.EndPosition != 0 && req.EndPosition < req.Position {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "endPosition can't be before position"})
+	}
+
+	annotation := db.Annotation{
+		UserID:      currentUserID(c),
+		MediaFileID: file.ID,
+		MediaFile:   file,
+		BookID:      file.BookID,
+		Type:        req.Type,
+		Locator:     req.Locator,
+		Position:    req.Position,
+		EndPosition: req.EndPosition,
+		Chapter:     req.Chapter,
+		Text:        req.Text,
+		Note:        req.Note,
+		Color:       req.Color,
+	}
+	if err := s.db.Omit("MediaFile").Create(&annotation).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save annotation"})
+	}
+
+	return c.JSON(http.StatusCreated, toAnnotationResponse(annotation))
+}
+
+// updateAnnotation changes one of the current user's annotations
+func (s *Server) updateAnnotation(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var annotation db.Annotation
+	if err := s.db.Preload("MediaFile").Where("user_id = ?", currentUserID(c)).First(&annotation, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Annotation not found"})
+	}
+
+	var req UpdateAnnotationRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if req.Text != nil {
+		annotation.Text = *req.Text
+	}
+	if req.Note != nil {
+		annotation.Note = *req.Note
+	}
+	if req.Color != nil {
+		annotation.Color = *req.Color
+	}
+
+	if err := s.db.Omit("MediaFile").Save(&annotation).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update annotation"})
+	}
+
+	return c.JSON(http.StatusOK, toAnnotationResponse(annotation))
+}
+
+// deleteAnnotation removes one of the current user's annotations
+func (s *Server) deleteAnnotation(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	result := s.db.Where("user_id = ?", currentUserID(c)).Delete(&db.Annotation{}, id)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete annotation"})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Annotation not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// exportAnnotations downloads the current user's annotations, narrowed as getAnnotations
+// does, as Markdown grouped by book, or JSON with ?format=json
+func (s *Server) exportAnnotations(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "markdown" && format != "json" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be markdown or json"})
+	}
+
+	annotations, err := s.annotationQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load annotations"})
+	}
+
+	filename := "shelfarr-annotations-" + time.Now().Format("2006-01-02")
+	if format == "json" {
+		responses := make([]AnnotationResponse, len(annotations))
+		for i, a := range annotations {
+			responses[i] = toAnnotationResponse(a)
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		return c.JSON(http.StatusOK, responses)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.md"`, filename))
+	return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", []byte(s.annotationsMarkdown(annotations)))
+}
+
+// annotationsMarkdown writes annotations as Markdown, a section per book
+func (s *Server) annotationsMarkdown(annotations []db.Annotation) string {
+	bookIDs := make([]uint, 0)
+	for i, a := range annotations {
+		if i == 0 || a.BookID != annotations[i-1].BookID {
+			bookIDs = append(bookIDs, a.BookID)
+		}
+	}
+	var books []db.Book
+	s.db.Unscoped().Preload("Author").Where("id IN ?", bookIDs).Find(&books)
+	titles := make(map[uint]string, len(books))
+	for _, book := range books {
+		titles[book.ID] = book.Title
+		if book.Author.Name != "" {
+			titles[book.ID] += " by " + book.Author.Name
+		}
+	}
+
+	var sb strings.Builder
+	for i, a := range annotations {
+		if i == 0 || a.BookID != annotations[i-1].BookID {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			fmt.Fprintf(&sb, "# %s\n\n", titles[a.BookID])
+		}
+
+		where := a.Chapter
+		if a.MediaFile.MediaType == db.MediaTypeAudiobook {
+			at := formatTimestamp(a.Position)
+			if a.EndPosition > a.Position {
+				at += "–" + formatTimestamp(a.EndPosition)
+			}
+			where = strings.TrimPrefix(where+", "+at, ", ")
+		} else if a.Position > 0 {
+			where = strings.TrimPrefix(fmt.Sprintf("%s, page %d", where, a.Position), ", ")
+		}
+
+		switch {
+		case a.Text != "":
+			fmt.Fprintf(&sb, "> %s\n\n", strings.ReplaceAll(strings.TrimSpace(a.Text), "\n", "\n> "))
+		case a.Type == annotationBookmark:
+			sb.WriteString("- Bookmark\n\n")
+		default:
+			sb.WriteString("- Highlight\n\n")
+		}
+		if a.Note != "" {
+			fmt.Fprintf(&sb, "%s\n\n", strings.TrimSpace(a.Note))
+		}
+		if where != "" {
+			fmt.Fprintf(&sb, "*%s*\n\n", where)
+		}
+	}
+	return sb.String()
+}
+
+// formatTimestamp formats seconds into an audiobook as h:mm:ss
+func formatTimestamp(seconds int) string {
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+func toAnnotationResponse(a db.Annotation) AnnotationResponse {
+	return AnnotationResponse{
+		ID:          a.ID,
+		MediaFileID: a.MediaFileID,
+		BookID:      a.BookID,
+		MediaType:   string(a.MediaFile.MediaType),
+		Type:        a.Type,
+		Locator:     a.Locator,
+		Position:    a.Position,
+		EndPosition: a.EndPosition,
+		Chapter:     a.Chapter,
+		Text:        a.Text,
+		Note:        a.Note,
+		Color:       a.Color,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// apiKeySetting stores the API key other *arr applications (Prowlarr) authenticate with
+const apiKeySetting = "api_key"
+
+// getAPIKey returns the API key, generating it on first use
+func (s *Server) getAPIKey(c echo.Context) error {
+	key, err := loadAPIKey(s.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate API key"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"apiKey": key})
+}
+
+// regenerateAPIKey replaces the API key, so applications using the old one must be updated
+func (s *Server) regenerateAPIKey(c echo.Context) error {
+	key, err := saveNewAPIKey(s.db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate API key"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"apiKey": key})
+}
+
+// apiKeyOr authenticates requests carrying an API key in the X-Api-Key header or apikey
+// query parameter, the way *arr applications call each other, and passes the rest to
+// the fallback authentication. The key stands for the *arr application rather than a
+// user: it isn't an admin, and is only taken on the routes *arr applications call.
+func (s *Server) apiKeyOr(fallback echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withFallback := fallback(next)
+		return func(c echo.Context) error {
+			given := givenAPIKey(c)
+			if given == "" {
+				return withFallback(c)
+			}
+			if !s.validAPIKey(given) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+			}
+
+			c.Set("apiKey", true)
+			return next(c)
+		}
+	}
+}
+
+// givenAPIKey returns the API key a request carries in the X-Api-Key header or apikey
+// query parameter, if any
+func givenAPIKey(c echo.Context) string {
+	if given := c.Request().Header.Get("X-Api-Key"); given != "" {
+		return given
+	}
+	return c.QueryParam("apikey")
+}
+
+// validAPIKey reports whether a key is the API key
+func (s *Server) validAPIKey(given string) bool {
+	key, err := loadAPIKey(s.db)
+	return err == nil && subtle.ConstantTimeCompare([]byte(given), []byte(key)) == 1
+}
+
+// loadAPIKey reads the API key, generating one if none is set
+func loadAPIKey(gdb *gorm.DB) (string, error) {
+	var setting db.Setting
+	if err := gdb.Where("key = ?", apiKeySetting).First(&setting).Error; err == nil && setting.Value != "" {
+		return setting.Value, nil
+	}
+	return saveNewAPIKey(gdb)
+}
+
+func saveNewAPIKey(gdb *gorm.DB) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	setting := db.Setting{Key: apiKeySetting, Value: hex.EncodeToString(b)}
+	if err := gdb.Where("key = ?", apiKeySetting).Assign(setting).FirstOrCreate(&setting).Error; err != nil {
+		return "", err
+	}
+	return setting.Value, nil
+}
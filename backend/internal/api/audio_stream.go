@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// Audio streaming. Players that can play an audiobook's format stream the file itself,
+// seeking with range requests. Those that can't, like browsers without M4B support, ask
+// for it transcoded to MP3 or AAC with ?transcode=, which ffmpeg produces as it's sent.
+// A transcoded stream can't seek by range, so players seek by asking for another one
+// from ?start= seconds.
+
+// streamTranscoded streams an audio media file transcoded to format, from ?start= for
+// ?duration= seconds (to the end if left out) at ?bitrate= kbps
+func (s *Server) streamTranscoded(c echo.Context, file db.MediaFile, format string) error {
+	contentType, ok := media.TranscodeFormats[format]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "transcode must be mp3 or aac"})
+	}
+	if file.MediaType != db.MediaTypeAudiobook {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only audiobooks can be transcoded"})
+	}
+
+	opts := media.TranscodeOptions{Format: format}
+	for _, param := range []struct {
+		name  string
+		value *float64
+	}{{"start", &opts.Start}, {"duration", &opts.Duration}} {
+		if raw := c.QueryParam(param.name); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || v < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": param.name + " must be a number of seconds"})
+			}
+			*param.value = v
+		}
+	}
+	if raw := c.QueryParam("bitrate"); raw != "" {
+		bitrate, err := strconv.Atoi(raw)
+		if err != nil || bitrate < 32 || bitrate > 320 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "bitrate must be between 32 and 320 kbps"})
+		}
+		opts.Bitrate = bitrate
+	}
+	if file.Duration > 0 && opts.Start >= float64(file.Duration) {
+		return c.JSON(http.StatusRequestedRangeNotSatisfiable, map[string]string{"error": "start is past the end of the audio"})
+	}
+
+	processor := media.NewAudiobookProcessor()
+	if !processor.IsAvailable() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "ffmpeg not found - please install FFmpeg"})
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set("Accept-Ranges", "none")
+	header.Set("Cache-Control", "no-store")
+	if file.Duration > 0 {
+		// Lets players show the length of a stream they can't measure
+		remaining := float64(file.Duration) - opts.Start
+		if opts.Duration > 0 && opts.Duration < remaining {
+			remaining = opts.Duration
+		}
+		header.Set("X-Content-Duration", strconv.FormatFloat(remaining, 'f', 3, 64))
+	}
+	c.Response().WriteHeader(http.StatusOK)
+
+	err := processor.Transcode(c.Request().Context(), file.FilePath, c.Response(), opts)
+	if err != nil && c.Request().Context().Err() == nil {
+		libraryLog.Warn("Audio transcode failed", "mediaFile", file.ID, "format", format, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"gorm.io/gorm"
+)
+
+const jobAudiobookMerge = "audiobook_merge"
+
+// audiobookMergePayload is the job payload for merging MP3s into an M4B
+type audiobookMergePayload struct {
+	BookID          uint `json:"bookId"`
+	Bitrate         int  `json:"bitrate,omitempty"`
+	RemoveOriginals bool `json:"removeOriginals,omitempty"`
+}
+
+// MergeAudiobookRequest represents a request to merge a book's MP3 files into an M4B
+type MergeAudiobookRequest struct {
+	Bitrate         int  `json:"bitrate,omitempty" validate:"min=32,max=320"` // kbps, defaults to 128
+	RemoveOriginals bool `json:"removeOriginals,omitempty"`                   // Delete the MP3 files after a successful merge
+}
+
+// mergeAudiobook queues a merge of a book's imported MP3 files into a single M4B
+func (s *Server) mergeAudiobook(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var req MergeAudiobookRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	if !media.NewAudiobookProcessor().IsAvailable() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "ffmpeg not found - please install FFmpeg"})
+	}
+
+	_, inputs := s.mp3FilesForBook(book.ID)
+	if len(inputs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Book has no imported MP3 files"})
+	}
+
+	job, err := s.jobs.Enqueue(jobAudiobookMerge, audiobookMergePayload{
+		BookID:          book.ID,
+		Bitrate:         req.Bitrate,
+		RemoveOriginals: req.RemoveOriginals,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// mp3FilesForBook returns a book's MP3 media files and the audio file paths they cover.
+// Folder imports are expanded to the MP3s inside them.
+func (s *Server) mp3FilesForBook(bookID uint) ([]db.MediaFile, []string) {
+	var files []db.MediaFile
+	s.db.Where("book_id = ? AND media_type = ?", bookID, db.MediaTypeAudiobook).Find(&files)
+
+	var sources []db.MediaFile
+	var paths []string
+	for _, f := range files {
+		info, err := os.Stat(f.FilePath)
+		if err != nil {
+			continue
+		}
+
+		if !info.IsDir() {
+			if strings.EqualFold(filepath.Ext(f.FilePath), ".mp3") {
+				sources = append(sources, f)
+				paths = append(paths, f.FilePath)
+			}
+			continue
+		}
+
+		var found []string
+		filepath.Walk(f.FilePath, func(path string, fi os.FileInfo, err error) error {
+			if err == nil && !fi.IsDir() && strings.EqualFold(filepath.Ext(path), ".mp3") {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if len(found) > 0 {
+			sources = append(sources, f)
+			paths = append(paths, found...)
+		}
+	}
+
+	sort.Strings(paths)
+	return sources, paths
+}
+
+// runAudiobookMergeJob merges a book's MP3s into an M4B tagged from the book record
+// and imports it as a new media file
+func (s *Server) runAudiobookMergeJob(ctx context.Context, job *db.Job, progress jobs.ProgressFunc) (interface{}, error) {
+	var payload audiobookMergePayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return nil, err
+	}
+
+	var book db.Book
+	if err := s.db.Preload("Author").Preload("Series").Preload("Genres").First(&book, payload.BookID).Error; err != nil {
+		return nil, fmt.Errorf("book %d not found", payload.BookID)
+	}
+
+	sources, inputs := s.mp3FilesForBook(book.ID)
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("book has no imported MP3 files")
+	}
+
+	paths := media.NewPathBuilder(s.importRoots(&book))
+	paths.SetNaming(s.namingOptions())
+	outputPath := paths.BuildPath("audiobook", namingValues(&book, "m4b"))
+	if _, err := os.Stat(outputPath); err == nil {
+		return nil, fmt.Errorf("%s already exists", outputPath)
+	}
+
+	opts := &media.M4BConversionOptions{
+		Title:    book.Title,
+		Author:   book.Author.Name,
+		Album:    book.Title,
+		Bitrate:  payload.Bitrate,
+		Progress: ffmpegJobProgress(progress, fmt.Sprintf("Encoding %d files", len(inputs)), 5, 95),
+	}
+	if book.Series != nil {
+		opts.Album = book.Series.Name
+		if book.SeriesIndex != nil {
+			opts.Album = fmt.Sprintf("%s #%g", book.Series.Name, *book.SeriesIndex)
+		}
+	}
+	if len(book.Genres) > 0 {
+		opts.Genre = book.Genres[0].Name
+	}
+	if book.ReleaseYear > 0 {
+		opts.Year = strconv.Itoa(book.ReleaseYear)
+	}
+
+	// Use Audible chapter markers when they line up with the files; otherwise
+	// ConvertToM4B generates one chapter per input file
+	var totalSeconds float64
+	processor := media.NewAudiobookProcessor()
+	for _, path := range inputs {
+		if info, err := processor.GetAudioInfo(ctx, path); err == nil {
+			totalSeconds += info.Duration
+		}
+	}
+	progress(1, "Looking up chapters")
+	opts.Chapters = s.audnexusChapters(ctx, book.ID, totalSeconds)
+
+	progress(2, "Fetching cover")
+	if coverPath, err := s.downloadCover(ctx, &book); err == nil {
+		defer os.Remove(coverPath)
+		opts.CoverPath = coverPath
+	}
+
+	result, err := processor.ConvertToM4B(ctx, inputs, outputPath, opts)
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+
+	progress(95, "Adding M4B to library")
+	mediaFile := db.MediaFile{
+		BookID:     book.ID,
+		FilePath:   outputPath,
+		FileName:   filepath.Base(outputPath),
+		Format:     "m4b",
+		MediaType:  db.MediaTypeAudiobook,
+		ImportedAt: time.Now(),
+	}
+	if info, err := os.Stat(outputPath); err == nil {
+		mediaFile.FileSize = info.Size()
+	}
+	if audioInfo, err := processor.GetAudioInfo(ctx, outputPath); err == nil {
+		mediaFile.Bitrate = audioInfo.Bitrate
+		mediaFile.Duration = int(audioInfo.Duration)
+	}
+	if len(sources) > 0 {
+		mediaFile.EditionName = sources[0].EditionName
+	}
+	if err := s.db.Create(&mediaFile).Error; err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("failed to save merged file: %w", err)
+	}
+
+	if payload.RemoveOriginals {
+		for _, src := range sources {
+			if err := s.removeMergedMP3s(src); err != nil {
+				libraryLog.Warn("Failed to remove file after merge", "path", src.FilePath, "error", err)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"mediaFileId": mediaFile.ID,
+		"filePath":    outputPath,
+		"inputFiles":  len(inputs),
+		"duration":    result.Duration.Seconds(),
+	}, nil
+}
+
+// removeMergedMP3s removes a source's MP3s after a merge, through the recycle bin. A
+// folder import is split into a media file per MP3 first, so only they go: the folder
+// holds the new M4B, and may hold other files such as a cover.
+func (s *Server) removeMergedMP3s(src db.MediaFile) error {
+	info, err := os.Stat(src.FilePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return s.removeMediaFile(&src)
+	}
+
+	var mp3s []db.MediaFile
+	filepath.Walk(src.FilePath, func(path string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() && strings.EqualFold(filepath.Ext(path), ".mp3") {
+			mp3s = append(mp3s, db.MediaFile{
+				BookID:      src.BookID,
+				FilePath:    path,
+				FileName:    filepath.Base(path),
+				FileSize:    fi.Size(),
+				Format:      "mp3",
+				MediaType:   db.MediaTypeAudiobook,
+				EditionName: src.EditionName,
+				ImportedAt:  src.ImportedAt,
+			})
+		}
+		return nil
+	})
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if len(mp3s) > 0 {
+			if err := tx.Create(&mp3s).Error; err != nil {
+				return err
+			}
+		}
+		return db.DeleteMediaFile(tx, &src)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range mp3s {
+		if err := s.removeMediaFile(&mp3s[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadCover copies a book's cover from the cover cache to a temp file for embedding
+func (s *Server) downloadCover(ctx context.Context, book *db.Book) (string, error) {
+	cached, err := s.covers.Fetch(ctx, book.ID, bookCover(book))
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(cached)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "shelfarr-cover-*"+filepath.Ext(cached))
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
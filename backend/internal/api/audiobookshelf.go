@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/audiobookshelf"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// AudiobookshelfSettings represents the Audiobookshelf server connection
+type AudiobookshelfSettings struct {
+	URL    string `json:"url"`
+	APIKey string `json:"apiKey"`
+}
+
+// AudiobookshelfSettingsRequest represents the request body for updating the connection
+type AudiobookshelfSettingsRequest struct {
+	URL    *string `json:"url,omitempty" validate:"url"`
+	APIKey *string `json:"apiKey,omitempty"`
+}
+
+// getAudiobookshelfSettings returns the Audiobookshelf connection settings
+func (s *Server) getAudiobookshelfSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadAudiobookshelfSettings(s.db))
+}
+
+// updateAudiobookshelfSettings updates the Audiobookshelf connection settings
+func (s *Server) updateAudiobookshelfSettings(c echo.Context) error {
+	var req AudiobookshelfSettingsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	updates := map[string]*string{
+		"audiobookshelf_url":     req.URL,
+		"audiobookshelf_api_key": req.APIKey,
+	}
+	for key, valuePtr := range updates {
+		if valuePtr != nil {
+			setting := db.Setting{Key: key, Value: strings.TrimSpace(*valuePtr)}
+			s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Settings updated"})
+}
+
+// getAudiobookshelfLibraries lists the server's libraries and their folders, for
+// choosing where a root folder's audiobooks go. It doubles as a connection test.
+func (s *Server) getAudiobookshelfLibraries(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	libraries, err := newAudiobookshelfClient(s.db).Libraries(ctx)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, libraries)
+}
+
+// loadAudiobookshelfSettings reads the Audiobookshelf connection from the database
+func loadAudiobookshelfSettings(gdb *gorm.DB) AudiobookshelfSettings {
+	var settings AudiobookshelfSettings
+
+	var dbSettings []db.Setting
+	gdb.Where("key LIKE ?", "audiobookshelf_%").Find(&dbSettings)
+
+	for _, setting := range dbSettings {
+		switch setting.Key {
+		case "audiobookshelf_url":
+			settings.URL = setting.Value
+		case "audiobookshelf_api_key":
+			settings.APIKey = setting.Value
+		}
+	}
+	return settings
+}
+
+func newAudiobookshelfClient(gdb *gorm.DB) *audiobookshelf.Client {
+	settings := loadAudiobookshelfSettings(gdb)
+	return audiobookshelf.NewClient(settings.URL, settings.APIKey)
+}
+
+// syncToAudiobookshelf tells Audiobookshelf about an audiobook imported to path, as
+// configured on the root folder it was imported into. It runs in the background, since
+// uploads can take a while, and failures are only logged.
+func (s *Server) syncToAudiobookshelf(book *db.Book, path string) {
+	rootFolder := s.rootFolderFor(path)
+	if rootFolder == nil || rootFolder.AudiobookshelfMode == "" {
+		return
+	}
+	client := newAudiobookshelfClient(s.db)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		var err error
+		switch rootFolder.AudiobookshelfMode {
+		case audiobookshelf.ModeScan:
+			err = client.ScanLibrary(ctx, rootFolder.AudiobookshelfLibraryID)
+		case audiobookshelf.ModeUpload:
+			upload := audiobookshelf.Upload{
+				LibraryID: rootFolder.AudiobookshelfLibraryID,
+				FolderID:  rootFolder.AudiobookshelfFolderID,
+				Title:     book.Title,
+				Author:    book.Author.Name,
+			}
+			if book.Series != nil {
+				upload.Series = book.Series.Name
+			}
+			if upload.Files, err = audiobookFiles(path); err == nil {
+				err = client.Upload(ctx, upload)
+			}
+		}
+		if err != nil {
+			notificationsLog.Warn("Audiobookshelf update failed", "mode", rootFolder.AudiobookshelfMode, "book", book.Title, "error", err)
+		}
+	}()
+}
+
+// rootFolderFor returns the root folder containing path, preferring the most specific
+func (s *Server) rootFolderFor(path string) *db.RootFolder {
+	var rootFolders []db.RootFolder
+	s.db.Find(&rootFolders)
+
+	path = filepath.Clean(path)
+	var match *db.RootFolder
+	for i, rf := range rootFolders {
+		root := filepath.Clean(rf.Path)
+		if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+			continue
+		}
+		if match == nil || len(root) > len(filepath.Clean(match.Path)) {
+			match = &rootFolders[i]
+		}
+	}
+	return match
+}
+
+// audiobookFiles lists the files of an imported audiobook, which is either a single file
+// or a folder
+func audiobookFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			files = append(files, p)
+		}
+		return err
+	})
+	return files, err
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/auth"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// AuditEntryResponse is an entry in the audit log
+type AuditEntryResponse struct {
+	ID       uint      `json:"id"`
+	Time     time.Time `json:"time"`
+	UserID   uint      `json:"userId"`
+	Username string    `json:"username"`
+	Action   string    `json:"action"`
+	Summary  string    `json:"summary"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Status   int       `json:"status"`
+	IP       string    `json:"ip"`
+}
+
+// AuditLogResponse is a page of the audit log, newest first
+type AuditLogResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	Total   int64                `json:"total"`
+}
+
+// setAudit describes the change a request made, for its audit log entry. Requests
+// that don't call it are recorded with a generic action.
+func setAudit(c echo.Context, action, summary string) {
+	c.Set("auditAction", action)
+	c.Set("auditSummary", summary)
+}
+
+// auditRequests records requests that change something in the audit log once they've
+// succeeded, and logins whether or not they succeeded
+func (s *Server) auditRequests(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		method := c.Request().Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			return next(c)
+		}
+
+		err := next(c)
+
+		status := c.Response().Status
+		if he, ok := err.(*echo.HTTPError); ok {
+			status = he.Code
+		}
+		action, _ := c.Get("auditAction").(string)
+		if status >= 400 && action != "auth.login_failed" {
+			return err
+		}
+
+		summary, _ := c.Get("auditSummary").(string)
+		if action == "" {
+			action, summary = defaultAudit(method, c.Path())
+		}
+
+		userID, username := auditUser(c, s.db)
+		s.db.Create(&db.AuditEntry{
+			UserID:   userID,
+			Username: username,
+			Action:   action,
+			Summary:  summary,
+			Method:   method,
+			Path:     c.Request().URL.Path,
+			Status:   status,
+			IP:       c.RealIP(),
+		})
+		return err
+	}
+}
+
+// defaultAudit returns the action and summary of a request whose handler didn't set
+// them: settings changes by the settings they're for, and anything else by its route
+func defaultAudit(method, route string) (string, string) {
+	route = strings.TrimPrefix(route, "/api/v1")
+	if name, ok := strings.CutPrefix(route, "/settings/"); ok && method != http.MethodPost {
+		name, _, _ = strings.Cut(name, "/")
+		return "settings.update", "Changed " + strings.ReplaceAll(name, "-", " ") + " settings"
+	}
+	return "request", method + " " + route
+}
+
+// auditUser returns who made a request
+func auditUser(c echo.Context, gdb *gorm.DB) (uint, string) {
+	if claims, ok := c.Get("user").(*auth.Claims); ok {
+		return claims.UserID, claims.Username
+	}
+	if username, ok := c.Get("auditUsername").(string); ok {
+		return currentUserID(c), username
+	}
+	if usedAPIKey, _ := c.Get("apiKey").(bool); usedAPIKey {
+		return 0, "API key"
+	}
+
+	userID := currentUserID(c)
+	if userID == 0 {
+		return 0, ""
+	}
+	var user db.User
+	gdb.Select("username").First(&user, userID)
+	return userID, user.Username
+}
+
+// getAuditLog returns the audit log, newest first. Query parameters: userId, action
+// (prefix, such as "book."), search (summary, path or username), since (RFC 3339), and
+// limit (default 100, at most 500) and offset for paging.
+func (s *Server) getAuditLog(c echo.Context) error {
+	query := s.db.Model(&db.AuditEntry{})
+
+	if userID := c.QueryParam("userId"); userID != "" {
+		id, err := strconv.ParseUint(userID, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid userId"})
+		}
+		query = query.Where("user_id = ?", id)
+	}
+	if action := c.QueryParam("action"); action != "" {
+		query = query.Where("action LIKE ?", action+"%")
+	}
+	if search := c.QueryParam("search"); search != "" {
+		like, op := "%"+search+"%", db.Like(s.db)
+		query = query.Where("summary "+op+" ? OR path "+op+" ? OR username "+op+" ?", like, like, like)
+	}
+	if since := c.QueryParam("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid since; use RFC 3339"})
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+
+	limit, offset := 100, 0
+	if n, err := strconv.Atoi(c.QueryParam("limit")); err == nil && n > 0 {
+		limit = min(n, 500)
+	}
+	if n, err := strconv.Atoi(c.QueryParam("offset")); err == nil && n > 0 {
+		offset = n
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var entries []db.AuditEntry
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load audit log"})
+	}
+
+	response := AuditLogResponse{Entries: make([]AuditEntryResponse, len(entries)), Total: total}
+	for i, e := range entries {
+		response.Entries[i] = AuditEntryResponse{
+			ID:       e.ID,
+			Time:     e.CreatedAt,
+			UserID:   e.UserID,
+			Username: e.Username,
+			Action:   e.Action,
+			Summary:  e.Summary,
+			Method:   e.Method,
+			Path:     e.Path,
+			Status:   e.Status,
+			IP:       e.IP,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/shelfarr/shelfarr/internal/audnexus"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// enrichAudiobookEditions fills in runtimes, narrators and the abridged flag for a book's
+// audiobook editions from Audible, and the series position when Hardcover has none.
+// Returns the number of editions updated.
+func (s *Server) enrichAudiobookEditions(ctx context.Context, book *db.Book) int {
+	var editions []db.Edition
+	s.db.Where("book_id = ? AND format = ? AND asin != ''", book.ID, hardcover.FormatAudiobook).Find(&editions)
+
+	provider := audnexus.NewProvider(s.audnexus)
+	updated := 0
+	longest := book.AudioDuration
+	for _, edition := range editions {
+		meta, err := provider.GetAudiobook(ctx, edition.ASIN)
+		if err != nil {
+			metadataLog.Debug("Audnexus lookup failed", "asin", edition.ASIN, "error", err)
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"narrators": strings.Join(meta.Narrators, ", "),
+			"abridged":  meta.Abridged,
+		}
+		if meta.RuntimeSeconds > 0 {
+			updates["audio_seconds"] = meta.RuntimeSeconds
+			if meta.RuntimeSeconds > longest {
+				longest = meta.RuntimeSeconds
+			}
+		}
+		if err := s.db.Model(&edition).Updates(updates).Error; err == nil {
+			updated++
+		}
+
+		if book.SeriesIndex == nil && book.SeriesID != nil && meta.SeriesPosition != nil && !lockedFields(book)["series"] {
+			book.SeriesIndex = meta.SeriesPosition
+			s.db.Model(book).Update("series_index", *meta.SeriesPosition)
+		}
+	}
+
+	if longest != book.AudioDuration {
+		book.AudioDuration = longest
+		s.db.Model(book).Update("audio_duration", longest)
+	}
+
+	return updated
+}
+
+// audnexusChapters returns Audible chapter markers for a book when one of its audiobook
+// editions has an ASIN whose runtime matches the audio being merged (within 1%).
+// Returns nil when no suitable chapter list is found.
+func (s *Server) audnexusChapters(ctx context.Context, bookID uint, totalSeconds float64) []media.Chapter {
+	var editions []db.Edition
+	s.db.Where("book_id = ? AND format = ? AND asin != ''", bookID, hardcover.FormatAudiobook).Find(&editions)
+
+	for _, edition := range editions {
+		data, err := s.audnexus.GetChapters(ctx, edition.ASIN)
+		if err != nil || len(data.Chapters) < 2 {
+			continue
+		}
+
+		runtime := float64(data.RuntimeLengthMs) / 1000
+		if totalSeconds > 0 && math.Abs(runtime-totalSeconds) > totalSeconds*0.01 {
+			metadataLog.Debug("Skipping Audnexus chapters whose runtime doesn't match the audio", "asin", edition.ASIN, "runtimeSeconds", int(runtime), "audioSeconds", int(totalSeconds))
+			continue
+		}
+
+		chapters := make([]media.Chapter, len(data.Chapters))
+		for i, ch := range data.Chapters {
+			start := float64(ch.StartOffsetMs) / 1000
+			chapters[i] = media.Chapter{
+				Title:     ch.Title,
+				StartTime: start,
+				EndTime:   start + float64(ch.LengthMs)/1000,
+			}
+		}
+		return chapters
+	}
+
+	return nil
+}
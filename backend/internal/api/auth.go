@@ -22,18 +22,18 @@ func NewAuthHandlers(authService *auth.AuthService) *AuthHandlers {
 // Login handles user login
 func (h *AuthHandlers) Login(c echo.Context) error {
 	var req auth.LoginRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.Username == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Username and password are required"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
+	c.Set("auditUsername", req.Username)
 	resp, err := h.authService.Login(req.Username, req.Password)
 	if err != nil {
+		setAudit(c, "auth.login_failed", "Failed login as "+req.Username)
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid username or password"})
 	}
+	c.Set("userId", resp.User.ID)
+	setAudit(c, "auth.login", "Logged in")
 
 	return c.JSON(http.StatusOK, resp)
 }
@@ -77,8 +77,8 @@ func (h *AuthHandlers) GetCurrentUser(c echo.Context) error {
 
 // ChangePasswordRequest represents a password change request
 type ChangePasswordRequest struct {
-	OldPassword string `json:"oldPassword"`
-	NewPassword string `json:"newPassword"`
+	OldPassword string `json:"oldPassword" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=6"`
 }
 
 // ChangePassword changes the user's password
@@ -89,16 +89,8 @@ func (h *AuthHandlers) ChangePassword(c echo.Context) error {
 	}
 
 	var req ChangePasswordRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.OldPassword == "" || req.NewPassword == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Both old and new passwords are required"})
-	}
-
-	if len(req.NewPassword) < 6 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "New password must be at least 6 characters"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	if err := h.authService.ChangePassword(claims.UserID, req.OldPassword, req.NewPassword); err != nil {
@@ -110,3 +102,17 @@ func (h *AuthHandlers) ChangePassword(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Password changed successfully"})
 }
+
+// currentUserID returns the authenticated user's ID from the request context, or 0 if unknown
+func currentUserID(c echo.Context) uint {
+	if id, ok := c.Get("userId").(uint); ok {
+		return id
+	}
+	return 0
+}
+
+// currentUserIsAdmin reports whether the authenticated user is an admin
+func currentUserIsAdmin(c echo.Context) bool {
+	isAdmin, _ := c.Get("isAdmin").(bool)
+	return isAdmin
+}
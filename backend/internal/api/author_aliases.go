@@ -0,0 +1,257 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// findHardcoverAuthor finds the library author for a Hardcover author: by Hardcover
+// ID, or else by name. A same-named author with a different Hardcover ID is taken to
+// be another person, unless the name was recorded as one of their aliases, such as by
+// merging the two. An author found by name without a Hardcover ID is given this one.
+func (s *Server) findHardcoverAuthor(hardcoverID, name string) (*db.Author, bool) {
+	var author db.Author
+	if hardcoverID != "" && s.db.Where("hardcover_id = ?", hardcoverID).First(&author).Error == nil {
+		return &author, true
+	}
+
+	found, isAlias, err := db.FindAuthorByName(s.db, name)
+	if err != nil {
+		return nil, false
+	}
+	if found.HardcoverID == "" && hardcoverID != "" {
+		if err := s.db.Model(found).Update("hardcover_id", hardcoverID).Error; err != nil {
+			slog.Warn("Could not link author to Hardcover", "author", found.Name, "error", err)
+		}
+		return found, true
+	}
+	if found.HardcoverID == hardcoverID || isAlias {
+		return found, true
+	}
+	return nil, false
+}
+
+// alternateNamesJSON encodes Hardcover's alternate names for Author.AlternateNames,
+// which become the author's aliases when they're created
+func alternateNamesJSON(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(names)
+	return string(data)
+}
+
+// addAuthorAliases records an author's alternate names, logging failures
+func (s *Server) addAuthorAliases(authorID uint, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	if err := db.AddAuthorAliases(s.db, authorID, names...); err != nil {
+		slog.Warn("Could not record author aliases", "authorId", authorID, "error", err)
+	}
+}
+
+// AliasResponse is a name an author is known by
+type AliasResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// getAuthorAliases lists the names an author is matched by, other than their own
+func (s *Server) getAuthorAliases(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author ID"})
+	}
+
+	var author db.Author
+	if err := s.db.First(&author, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+	return c.JSON(http.StatusOK, s.authorAliases(&author))
+}
+
+func (s *Server) authorAliases(author *db.Author) []AliasResponse {
+	var aliases []db.AuthorAlias
+	s.db.Where("author_id = ?", author.ID).Order("name").Find(&aliases)
+
+	own := db.NormalizeAuthorName(author.Name)
+	responses := make([]AliasResponse, 0, len(aliases))
+	for _, alias := range aliases {
+		if alias.NormalizedName != own {
+			responses = append(responses, AliasResponse{ID: alias.ID, Name: alias.Name})
+		}
+	}
+	return responses
+}
+
+// AddAuthorAliasRequest adds a name an author is known by
+type AddAuthorAliasRequest struct {
+	Name string `json:"name" validate:"required,max=200"`
+}
+
+// addAuthorAlias records another name for an author, so books credited to that name
+// are matched to them
+func (s *Server) addAuthorAlias(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author ID"})
+	}
+
+	var author db.Author
+	if err := s.db.First(&author, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+
+	var req AddAuthorAliasRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(req.Name)
+	if db.NormalizeAuthorName(name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	// A name can only match one author; another author with it should be merged instead
+	if other, _, err := db.FindAuthorByName(s.db, name); err == nil && other.ID != author.ID {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "'" + name + "' is already a name of " + other.Name + "; merge the authors instead"})
+	}
+
+	if err := db.AddAuthorAliases(s.db, author.ID, name); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add alias"})
+	}
+	return c.JSON(http.StatusCreated, s.authorAliases(&author))
+}
+
+// deleteAuthorAlias removes one of an author's alternate names
+func (s *Server) deleteAuthorAlias(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author ID"})
+	}
+
+	var author db.Author
+	if err := s.db.First(&author, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+
+	var alias db.AuthorAlias
+	if err := s.db.Where("id = ? AND author_id = ?", c.Param("aliasId"), author.ID).First(&alias).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Alias not found"})
+	}
+	if alias.NormalizedName == db.NormalizeAuthorName(author.Name) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "An author's own name cannot be removed"})
+	}
+
+	if err := s.db.Unscoped().Delete(&alias).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete alias"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// MergeAuthorsRequest names the authors to merge into another
+type MergeAuthorsRequest struct {
+	AuthorIDs []uint `json:"authorIds" validate:"required"`
+}
+
+// mergeAuthors merges duplicate authors, such as one under a pen name or another
+// spelling, into the author in the path. Their books, contributions and series move
+// over, their names become aliases so future matches find the merged author, and the
+// duplicates are deleted.
+func (s *Server) mergeAuthors(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author ID"})
+	}
+
+	var target db.Author
+	if err := s.db.First(&target, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+
+	var req MergeAuthorsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var sources []db.Author
+	if err := s.db.Where("id IN ? AND id <> ?", req.AuthorIDs, target.ID).Find(&sources).Error; err != nil || len(sources) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "No authors to merge"})
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, source := range sources {
+			if err := mergeAuthor(tx, &target, &source); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&target).Error
+	})
+	if err != nil {
+		slog.Error("Failed to merge authors", "into", target.Name, "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to merge authors"})
+	}
+
+	for _, source := range sources {
+		s.photos.Remove(source.ID)
+		slog.Info("Merged author", "author", source.Name, "into", target.Name)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":  "Authors merged",
+		"authorId": target.ID,
+		"merged":   len(sources),
+		"aliases":  s.authorAliases(&target),
+	})
+}
+
+// mergeAuthor moves everything of source's onto target and deletes source. Details
+// target is missing, such as a biography or Hardcover ID, are taken from source.
+func mergeAuthor(tx *gorm.DB, target, source *db.Author) error {
+	if err := tx.Model(&db.Book{}).Where("author_id = ?", source.ID).Update("author_id", target.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&db.Series{}).Where("author_id = ?", source.ID).Update("author_id", target.ID).Error; err != nil {
+		return err
+	}
+
+	// A book crediting both authors in the same role would list the merged one twice
+	if err := tx.Where("author_id = ? AND EXISTS (SELECT 1 FROM contributors c WHERE c.book_id = contributors.book_id AND c.role = contributors.role AND c.author_id = ? AND c.deleted_at IS NULL)", source.ID, target.ID).
+		Delete(&db.Contributor{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&db.Contributor{}).Where("author_id = ?", source.ID).Update("author_id", target.ID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&db.AuthorAlias{}).Where("author_id = ?", source.ID).Update("author_id", target.ID).Error; err != nil {
+		return err
+	}
+	if err := db.AuthorTags.Add(tx, []uint{target.ID}, db.AuthorTags.IDs(tx, source.ID)[source.ID]); err != nil {
+		return err
+	}
+	if err := db.AuthorTags.Set(tx, source.ID, nil); err != nil {
+		return err
+	}
+
+	if target.HardcoverID == "" {
+		target.HardcoverID = source.HardcoverID
+	}
+	if target.Biography == "" {
+		target.Biography = source.Biography
+	}
+	if target.ImageURL == "" {
+		target.ImageURL = source.ImageURL
+	}
+	target.Monitored = target.Monitored || source.Monitored
+
+	// Deleted for good, so its Hardcover ID can move to target
+	return tx.Unscoped().Delete(source).Error
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,11 +17,14 @@ type AuthorDetailResponse struct {
 	SortName        string            `json:"sortName"`
 	Biography       string            `json:"biography"`
 	ImageURL        string            `json:"imageUrl"`
+	CustomImage     bool              `json:"customImage,omitempty"` // Photo uploaded by the user
 	Monitored       bool              `json:"monitored"`
 	Books           []AuthorBookEntry `json:"books"`
 	TotalBooks      int               `json:"totalBooks"`      // Total books from Hardcover
 	InLibrary       int               `json:"inLibrary"`       // Books added to library
 	DownloadedCount int               `json:"downloadedCount"` // Books with files downloaded
+	Aliases         []AliasResponse   `json:"aliases"`         // Other names the author is matched by
+	Tags            []uint            `json:"tags"`
 }
 
 // AuthorBookEntry represents a book by an author (may or may not be in library)
@@ -63,11 +65,20 @@ func (s *Server) getAuthors(c echo.Context) error {
 	if monitored := c.QueryParam("monitored"); monitored != "" {
 		query = query.Where("monitored = ?", monitored == "true")
 	}
+	if tags := parseTagIDs(c.QueryParam("tags")); len(tags) > 0 {
+		query = query.Scopes(db.AuthorTags.Tagged(tags))
+	}
 
 	if err := query.Find(&authors).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	authorIDs := make([]uint, len(authors))
+	for i, author := range authors {
+		authorIDs[i] = author.ID
+	}
+	tags := db.AuthorTags.IDs(s.db, authorIDs...)
+
 	responses := make([]AuthorResponse, len(authors))
 	for i, author := range authors {
 		var bookCount int64
@@ -82,11 +93,13 @@ func (s *Server) getAuthors(c echo.Context) error {
 			HardcoverID:     author.HardcoverID,
 			Name:            author.Name,
 			SortName:        author.SortName,
-			ImageURL:        author.ImageURL,
+			ImageURL:        authorImageURL(&author),
+			CustomImage:     author.CustomImage != "",
 			Monitored:       author.Monitored,
 			BookCount:       int(bookCount),
 			TotalBooksCount: author.TotalBooksCount, // Cached from Hardcover
 			DownloadedCount: int(downloadedCount),
+			Tags:            tags[author.ID],
 		}
 	}
 
@@ -115,9 +128,9 @@ func (s *Server) getAuthor(c echo.Context) error {
 		client, err := s.getHardcoverClient()
 		if err == nil {
 			languages := s.GetPreferredLanguages()
-			result, err := client.GetBooksByAuthorWithCounts(author.HardcoverID, languages)
+			result, err := client.GetBooksByAuthorWithCounts(c.Request().Context(), author.HardcoverID, languages)
 			if err == nil {
-				log.Printf("[DEBUG] getAuthor: fetched %d books from Hardcover for author '%s' (languages: %v)", len(result.Books), author.Name, languages)
+				metadataLog.Debug("getAuthor: fetched books from Hardcover", "books", len(result.Books), "author", author.Name, "languages", languages)
 
 				now := time.Now()
 				author.TotalBooksCount = result.TotalCount
@@ -137,7 +150,7 @@ func (s *Server) getAuthor(c echo.Context) error {
 				libraryBooksByHardcoverID := make(map[string]db.Book)
 				if len(hardcoverIDs) > 0 {
 					var libraryBooks []db.Book
-					s.db.Preload("Series").Preload("MediaFiles").
+					s.db.Scopes(s.visibleBooks(c)).Preload("Series").Preload("MediaFiles").
 						Where("hardcover_id IN ?", hardcoverIDs).
 						Find(&libraryBooks)
 
@@ -146,7 +159,7 @@ func (s *Server) getAuthor(c echo.Context) error {
 							libraryBooksByHardcoverID[book.HardcoverID] = book
 						}
 					}
-					log.Printf("[DEBUG] getAuthor: found %d library books matching Hardcover IDs for author '%s'", len(libraryBooks), author.Name)
+					metadataLog.Debug("getAuthor: found library books matching Hardcover IDs", "books", len(libraryBooks), "author", author.Name)
 				}
 
 				for _, hcBook := range result.Books {
@@ -181,7 +194,7 @@ func (s *Server) getAuthor(c echo.Context) error {
 					totalBooks++
 				}
 			} else {
-				log.Printf("[DEBUG] getAuthor: failed to fetch from Hardcover: %v", err)
+				metadataLog.Debug("getAuthor: failed to fetch from Hardcover", "author", author.Name, "error", err)
 			}
 		}
 	}
@@ -189,18 +202,18 @@ func (s *Server) getAuthor(c echo.Context) error {
 	// If we didn't get Hardcover data, fall back to library-only view (query by author_id)
 	if len(entries) == 0 {
 		var libraryBooks []db.Book
-		s.db.Preload("Series").Preload("MediaFiles").
+		s.db.Scopes(s.visibleBooks(c)).Preload("Series").Preload("MediaFiles").
 			Where("author_id = ?", author.ID).
 			Find(&libraryBooks)
 
 		if len(libraryBooks) > 0 {
-			log.Printf("[DEBUG] getAuthor: using library-only view for author '%s' (%d books)", author.Name, len(libraryBooks))
+			metadataLog.Debug("getAuthor: using library-only view", "author", author.Name, "books", len(libraryBooks))
 			for _, book := range libraryBooks {
 				resp := bookToResponse(book)
 				entry := AuthorBookEntry{
 					HardcoverID: book.HardcoverID,
 					Title:       book.Title,
-					CoverURL:    book.CoverURL,
+					CoverURL:    coverImageURL(&book),
 					AuthorName:  author.Name,
 					Rating:      book.Rating,
 					InLibrary:   true,
@@ -230,12 +243,15 @@ func (s *Server) getAuthor(c echo.Context) error {
 		Name:            author.Name,
 		SortName:        author.SortName,
 		Biography:       author.Biography,
-		ImageURL:        author.ImageURL,
+		ImageURL:        authorImageURL(&author),
+		CustomImage:     author.CustomImage != "",
 		Monitored:       author.Monitored,
 		Books:           entries,
 		TotalBooks:      totalBooks,
 		InLibrary:       inLibraryCount,
 		DownloadedCount: downloadedCount,
+		Aliases:         s.authorAliases(&author),
+		Tags:            tagIDsOrEmpty(db.AuthorTags.IDs(s.db, author.ID)[author.ID]),
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -244,12 +260,8 @@ func (s *Server) getAuthor(c echo.Context) error {
 // addAuthor adds a new author from Hardcover.app
 func (s *Server) addAuthor(c echo.Context) error {
 	var req AddAuthorRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.HardcoverID == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "hardcoverId is required"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	// Check if author already exists
@@ -263,29 +275,46 @@ func (s *Server) addAuthor(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Hardcover API key not configured"})
 	}
-	authorData, err := client.GetAuthor(req.HardcoverID)
+	authorData, err := client.GetAuthor(c.Request().Context(), req.HardcoverID)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch author from Hardcover: " + err.Error()})
-	}
-
-	// Create the author
-	author := db.Author{
-		HardcoverID: authorData.ID,
-		Name:        authorData.Name,
-		SortName:    authorData.SortName,
-		Biography:   authorData.Biography,
-		ImageURL:    authorData.ImageURL,
-		Monitored:   req.Monitored,
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch author from Hardcover: " + err.Error()})
 	}
 
-	if err := s.db.Create(&author).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create author"})
+	// An author already in the library under this name or one of its aliases, but
+	// not yet linked to Hardcover, is linked rather than added twice
+	var author db.Author
+	if found, ok := s.findHardcoverAuthor(authorData.ID, authorData.Name); ok {
+		author = *found
+		author.Monitored = author.Monitored || req.Monitored
+		if author.Biography == "" {
+			author.Biography = authorData.Biography
+		}
+		if author.ImageURL == "" {
+			author.ImageURL = authorData.ImageURL
+		}
+		if err := s.db.Save(&author).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update author"})
+		}
+		s.addAuthorAliases(author.ID, authorData.AlternateNames)
+	} else {
+		author = db.Author{
+			HardcoverID:    authorData.ID,
+			Name:           authorData.Name,
+			SortName:       authorData.SortName,
+			Biography:      authorData.Biography,
+			ImageURL:       authorData.ImageURL,
+			AlternateNames: alternateNamesJSON(authorData.AlternateNames),
+			Monitored:      req.Monitored,
+		}
+		if err := s.db.Create(&author).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create author"})
+		}
 	}
 
 	// Optionally add all books by this author
 	if req.AddAllBooks {
 		languages := s.GetPreferredLanguages()
-		result, err := client.GetBooksByAuthor(req.HardcoverID, languages)
+		result, err := client.GetBooksByAuthor(c.Request().Context(), req.HardcoverID, languages)
 		if err == nil {
 			for _, bookData := range result.Books {
 				var existingBook db.Book
@@ -306,7 +335,9 @@ func (s *Server) addAuthor(c echo.Context) error {
 						Status:      db.StatusMissing,
 						Monitored:   req.Monitored,
 					}
-					s.db.Create(&book)
+					if s.db.Create(&book).Error == nil {
+						s.covers.Queue(book.ID, book.CoverURL)
+					}
 				}
 			}
 		}
@@ -317,7 +348,7 @@ func (s *Server) addAuthor(c echo.Context) error {
 		HardcoverID: author.HardcoverID,
 		Name:        author.Name,
 		SortName:    author.SortName,
-		ImageURL:    author.ImageURL,
+		ImageURL:    authorImageURL(&author),
 		Monitored:   author.Monitored,
 	})
 }
@@ -335,8 +366,8 @@ func (s *Server) updateAuthor(c echo.Context) error {
 	}
 
 	var req UpdateAuthorRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	author.Monitored = req.Monitored
@@ -353,7 +384,8 @@ func (s *Server) updateAuthor(c echo.Context) error {
 		HardcoverID: author.HardcoverID,
 		Name:        author.Name,
 		SortName:    author.SortName,
-		ImageURL:    author.ImageURL,
+		ImageURL:    authorImageURL(&author),
+		CustomImage: author.CustomImage != "",
 		Monitored:   author.Monitored,
 		BookCount:   int(bookCount),
 	})
@@ -375,6 +407,8 @@ func (s *Server) deleteAuthor(c echo.Context) error {
 	if err := s.db.Delete(&author).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete author"})
 	}
+	s.db.Unscoped().Where("author_id = ?", author.ID).Delete(&db.AuthorAlias{})
+	s.photos.Remove(author.ID)
 
 	return c.NoContent(http.StatusNoContent)
 }
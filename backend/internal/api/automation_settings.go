@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// AutomationSettingsResponse represents the automation settings
+type AutomationSettingsResponse struct {
+	DryRun             bool `json:"dryRun"`             // Search and score releases but never hand them to a download client
+	LoadBalanceClients bool `json:"loadBalanceClients"` // Take turns between download clients of equal priority
+}
+
+// AutomationSettingsRequest represents the request body for updating automation settings
+type AutomationSettingsRequest struct {
+	DryRun             *bool `json:"dryRun,omitempty"`
+	LoadBalanceClients *bool `json:"loadBalanceClients,omitempty"`
+}
+
+// getAutomationSettings returns the current automation settings
+func (s *Server) getAutomationSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, AutomationSettingsResponse{
+		DryRun:             s.isAutomationDryRun(),
+		LoadBalanceClients: s.isLoadBalancingClients(),
+	})
+}
+
+// updateAutomationSettings updates automation settings
+func (s *Server) updateAutomationSettings(c echo.Context) error {
+	var req AutomationSettingsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	if req.DryRun != nil {
+		value := "false"
+		if *req.DryRun {
+			value = "true"
+		}
+		setting := db.Setting{Key: "automation_dry_run", Value: value}
+		s.db.Where("key = ?", "automation_dry_run").Assign(setting).FirstOrCreate(&setting)
+	}
+	if req.LoadBalanceClients != nil {
+		setting := db.Setting{Key: "automation_load_balance_clients", Value: strconv.FormatBool(*req.LoadBalanceClients)}
+		s.db.Where("key = ?", "automation_load_balance_clients").Assign(setting).FirstOrCreate(&setting)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Settings updated"})
+}
+
+// isAutomationDryRun reports whether automation should only log the grabs it would make
+func (s *Server) isAutomationDryRun() bool {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "automation_dry_run").First(&setting).Error; err != nil {
+		return false
+	}
+	return setting.Value == "true"
+}
+
+// isLoadBalancingClients reports whether grabs should rotate between download clients
+// of equal priority rather than always going to the first
+func (s *Server) isLoadBalancingClients() bool {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "automation_load_balance_clients").First(&setting).Error; err != nil {
+		return false
+	}
+	return setting.Value == "true"
+}
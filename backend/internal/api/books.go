@@ -1,13 +1,17 @@
 package api
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
 	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"gorm.io/gorm"
 )
 
 // AddBookRequest represents the request body for adding a book
@@ -19,14 +23,40 @@ type AddBookRequest struct {
 // UpdateBookRequest represents the request body for updating a book
 type UpdateBookRequest struct {
 	Monitored bool   `json:"monitored"`
-	Status    string `json:"status,omitempty"`
+	Status    string `json:"status,omitempty" validate:"oneof=missing downloading downloaded unmonitored unreleased"`
+
+	// Root folders to import into; 0 goes back to the default
+	EbookRootFolderID     *uint `json:"ebookRootFolderId,omitempty"`
+	AudiobookRootFolderID *uint `json:"audiobookRootFolderId,omitempty"`
+}
+
+// bookSortColumns maps getBooks' sortBy values to columns
+var bookSortColumns = map[string]string{
+	"title":       "books.title",
+	"sortTitle":   "books.sort_title",
+	"rating":      "books.rating",
+	"releaseDate": "books.release_date",
+	"created_at":  "books.created_at",
+	"createdAt":   "books.created_at",
+	"updatedAt":   "books.updated_at",
+	"status":      "books.status",
+	"id":          "books.id",
 }
 
-// getBooks returns all books with optional filtering
+// getBooks returns books with optional filtering. Large libraries can page through
+// them with limit and offset (the total is in the X-Total-Count header), sort with
+// sortBy and sortOrder, and pass light=true to leave out descriptions and media
+// files, keeping only whether a book has an ebook and an audiobook.
 func (s *Server) getBooks(c echo.Context) error {
 	var books []db.Book
 
-	query := s.db.Preload("Author").Preload("Series").Preload("MediaFiles")
+	light := c.QueryParam("light") == "true"
+	query := s.db.Model(&db.Book{}).Preload("Author").Preload("Series")
+	if light {
+		query = query.Omit("description")
+	} else {
+		query = query.Preload("MediaFiles")
+	}
 
 	// Optional filters
 	if monitored := c.QueryParam("monitored"); monitored != "" {
@@ -35,6 +65,38 @@ func (s *Server) getBooks(c echo.Context) error {
 	if status := c.QueryParam("status"); status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if tags := parseTagIDs(c.QueryParam("tags")); len(tags) > 0 {
+		query = query.Scopes(db.BooksTagged(tags))
+	}
+	filter, ok := s.savedFilterScope(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Filter not found"})
+	}
+	query = query.Scopes(filter, s.visibleBooks(c))
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+	c.Response().Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+	column, ok := bookSortColumns[c.QueryParam("sortBy")]
+	if !ok {
+		column = "books.id"
+	}
+	order := " ASC"
+	if c.QueryParam("sortOrder") == "desc" {
+		order = " DESC"
+	}
+	query = query.Order(column + order)
+	if column != "books.id" {
+		query = query.Order("books.id")
+	}
+
+	if limit, err := strconv.Atoi(c.QueryParam("limit")); err == nil && limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset, err := strconv.Atoi(c.QueryParam("offset")); err == nil && offset > 0 {
+		query = query.Offset(offset)
+	}
 
 	if err := query.Find(&books).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -44,10 +106,44 @@ func (s *Server) getBooks(c echo.Context) error {
 	for i, book := range books {
 		responses[i] = bookToResponse(book)
 	}
+	s.addBookTags(responses)
+	if light {
+		s.addBookMediaTypes(responses)
+	}
 
 	return c.JSON(http.StatusOK, responses)
 }
 
+// addBookMediaTypes sets whether each book has an ebook and an audiobook, for
+// responses built without their media files
+func (s *Server) addBookMediaTypes(responses []BookResponse) {
+	ids := make([]uint, len(responses))
+	for i, resp := range responses {
+		ids[i] = resp.ID
+	}
+
+	var rows []struct {
+		BookID    uint
+		MediaType db.MediaType
+	}
+	s.db.Model(&db.MediaFile{}).Select("DISTINCT book_id, media_type").Where("book_id IN ?", ids).Scan(&rows)
+
+	types := make(map[uint][]db.MediaType)
+	for _, row := range rows {
+		types[row.BookID] = append(types[row.BookID], row.MediaType)
+	}
+	for i := range responses {
+		for _, mediaType := range types[responses[i].ID] {
+			switch mediaType {
+			case db.MediaTypeEbook:
+				responses[i].HasEbook = true
+			case db.MediaTypeAudiobook:
+				responses[i].HasAudiobook = true
+			}
+		}
+	}
+}
+
 // getBook returns a single book by ID
 func (s *Server) getBook(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -56,22 +152,23 @@ func (s *Server) getBook(c echo.Context) error {
 	}
 
 	var book db.Book
-	if err := s.db.Preload("Author").Preload("Series").Preload("MediaFiles").First(&book, id).Error; err != nil {
+	if err := s.db.Scopes(s.visibleBooks(c)).Preload("Author").Preload("Series").Preload("MediaFiles").Preload("Genres").
+		Preload("Contributors", "role = ?", db.RoleNarrator).Preload("Contributors.Author").
+		Preload("Editions", "format = ? AND narrators != ''", hardcover.FormatAudiobook).
+		First(&book, id).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
 	}
 
-	return c.JSON(http.StatusOK, bookToResponse(book))
+	response := []BookResponse{bookToResponse(book)}
+	s.addBookTags(response)
+	return c.JSON(http.StatusOK, response[0])
 }
 
 // addBook adds a new book from Hardcover.app
 func (s *Server) addBook(c echo.Context) error {
 	var req AddBookRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.HardcoverID == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "hardcoverId is required"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	var existing db.Book
@@ -82,20 +179,24 @@ func (s *Server) addBook(c echo.Context) error {
 				"status":     db.StatusMissing,
 				"monitored":  req.Monitored,
 			}).Error; err != nil {
-				log.Printf("[ERROR] addBook: failed to restore book: %v", err)
+				slog.Error("addBook: failed to restore book", "error", err)
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore book"})
 			}
 			s.db.Preload("Author").Preload("Series").First(&existing, existing.ID)
-			log.Printf("[DEBUG] addBook: restored soft-deleted book '%s' (ID: %d)", existing.Title, existing.ID)
+			slog.Debug("addBook: restored soft-deleted book", "book", existing.Title, "bookId", existing.ID)
+			setAudit(c, "book.add", "Restored "+existing.Title)
 			return c.JSON(http.StatusCreated, bookToResponse(existing))
 		}
 		return c.JSON(http.StatusConflict, map[string]string{"error": "Book already exists"})
 	}
 
-	client := hardcover.NewClient(s.config.HardcoverAPIURL)
-	bookData, err := client.GetBook(req.HardcoverID)
+	client, err := s.getHardcoverClient()
+	if err != nil {
+		return err
+	}
+	bookData, err := client.GetBook(c.Request().Context(), req.HardcoverID)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch book from Hardcover: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch book from Hardcover: " + err.Error()})
 	}
 
 	// Create or find author
@@ -103,7 +204,7 @@ func (s *Server) addBook(c echo.Context) error {
 	if bookData.AuthorID != "" {
 		if err := s.db.Where("hardcover_id = ?", bookData.AuthorID).First(&author).Error; err != nil {
 			// Create new author
-			authorData, err := client.GetAuthor(bookData.AuthorID)
+			authorData, err := client.GetAuthor(c.Request().Context(), bookData.AuthorID)
 			if err == nil {
 				author = db.Author{
 					HardcoverID: authorData.ID,
@@ -158,9 +259,11 @@ func (s *Server) addBook(c echo.Context) error {
 	if err := s.db.Create(&book).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create book"})
 	}
+	s.covers.Queue(book.ID, book.CoverURL)
 
 	// Reload with associations
 	s.db.Preload("Author").Preload("Series").First(&book, book.ID)
+	setAudit(c, "book.add", "Added "+book.Title)
 
 	return c.JSON(http.StatusCreated, bookToResponse(book))
 }
@@ -178,14 +281,24 @@ func (s *Server) updateBook(c echo.Context) error {
 	}
 
 	var req UpdateBookRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	book.Monitored = req.Monitored
 	if req.Status != "" {
 		book.Status = db.BookStatus(req.Status)
 	}
+	if req.EbookRootFolderID != nil {
+		if book.EbookRootFolderID, err = s.assignableRootFolder(*req.EbookRootFolderID, db.MediaTypeEbook); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.AudiobookRootFolderID != nil {
+		if book.AudiobookRootFolderID, err = s.assignableRootFolder(*req.AudiobookRootFolderID, db.MediaTypeAudiobook); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
 
 	if err := s.db.Save(&book).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update book"})
@@ -226,39 +339,43 @@ func (s *Server) deleteBook(c echo.Context) error {
 		SeriesID:    book.SeriesID,
 	}
 
-	// Soft delete media files (move to recycle bin)
+	// deleteFiles deletes the book's files too, through the recycle bin if enabled;
+	// otherwise they stay on disk
+	if c.QueryParam("deleteFiles") == "true" {
+		s.removeBookFiles([]uint{book.ID})
+	}
 	s.db.Where("book_id = ?", id).Delete(&db.MediaFile{})
 
 	// Delete the book
 	if err := s.db.Delete(&book).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete book"})
 	}
+	s.covers.Remove(book.ID)
+	setAudit(c, "book.delete", "Deleted "+book.Title)
 
 	return c.JSON(http.StatusOK, response)
 }
 
 // BulkUpdateRequest represents a request to update multiple books
 type BulkUpdateRequest struct {
-	BookIDs   []uint `json:"bookIds"`
-	Monitored *bool  `json:"monitored,omitempty"`
-	Status    string `json:"status,omitempty"`
+	BookIDs    []uint `json:"bookIds" validate:"required"`
+	Monitored  *bool  `json:"monitored,omitempty"`
+	Status     string `json:"status,omitempty" validate:"oneof=missing downloading downloaded unmonitored unreleased"`
+	AddTags    []uint `json:"addTags,omitempty"`
+	RemoveTags []uint `json:"removeTags,omitempty"`
 }
 
 // BulkDeleteRequest represents a request to delete multiple books
 type BulkDeleteRequest struct {
-	BookIDs     []uint `json:"bookIds"`
+	BookIDs     []uint `json:"bookIds" validate:"required"`
 	DeleteFiles bool   `json:"deleteFiles"`
 }
 
 // bulkUpdateBooks updates multiple books at once
 func (s *Server) bulkUpdateBooks(c echo.Context) error {
 	var req BulkUpdateRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if len(req.BookIDs) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No book IDs provided"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	updates := make(map[string]interface{})
@@ -269,38 +386,52 @@ func (s *Server) bulkUpdateBooks(c echo.Context) error {
 		updates["status"] = req.Status
 	}
 
-	if len(updates) == 0 {
+	if len(updates) == 0 && len(req.AddTags) == 0 && len(req.RemoveTags) == 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No updates provided"})
 	}
+	if !s.validTagIDs(req.AddTags) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown tag"})
+	}
 
-	result := s.db.Model(&db.Book{}).Where("id IN ?", req.BookIDs).Updates(updates)
-	if result.Error != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update books"})
+	updated := int64(len(req.BookIDs))
+	if len(updates) > 0 {
+		result := s.db.Model(&db.Book{}).Where("id IN ?", req.BookIDs).Updates(updates)
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update books"})
+		}
+		updated = result.RowsAffected
 	}
 
-	return c.JSON(http.StatusOK, map[string]int64{"updated": result.RowsAffected})
+	if err := db.BookTags.Add(s.db, req.BookIDs, uniqueIDs(req.AddTags)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to tag books"})
+	}
+	if err := db.BookTags.Remove(s.db, req.BookIDs, req.RemoveTags); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to untag books"})
+	}
+
+	setAudit(c, "book.update", fmt.Sprintf("Updated %d books", updated))
+	return c.JSON(http.StatusOK, map[string]int64{"updated": updated})
 }
 
 func (s *Server) bulkDeleteBooks(c echo.Context) error {
 	var req BulkDeleteRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if len(req.BookIDs) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No book IDs provided"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	if req.DeleteFiles {
-		s.db.Where("book_id IN ?", req.BookIDs).Delete(&db.MediaFile{})
-	} else {
-		s.db.Where("book_id IN ?", req.BookIDs).Delete(&db.MediaFile{})
+		s.removeBookFiles(req.BookIDs)
 	}
+	s.db.Where("book_id IN ?", req.BookIDs).Delete(&db.MediaFile{})
 
 	result := s.db.Where("id IN ?", req.BookIDs).Delete(&db.Book{})
 	if result.Error != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete books"})
 	}
+	for _, id := range req.BookIDs {
+		s.covers.Remove(id)
+	}
+	setAudit(c, "book.delete", fmt.Sprintf("Deleted %d books", result.RowsAffected))
 
 	return c.JSON(http.StatusOK, map[string]int64{"deleted": result.RowsAffected})
 }
@@ -336,6 +467,8 @@ func (s *Server) getBookEditions(c echo.Context) error {
 		PublisherName string `json:"publisherName,omitempty"`
 		PageCount     int    `json:"pageCount,omitempty"`
 		AudioSeconds  int    `json:"audioSeconds,omitempty"`
+		Narrators     string `json:"narrators,omitempty"`
+		Abridged      bool   `json:"abridged,omitempty"`
 		ReleaseDate   string `json:"releaseDate,omitempty"`
 		CoverURL      string `json:"coverUrl,omitempty"`
 	}
@@ -357,6 +490,8 @@ func (s *Server) getBookEditions(c echo.Context) error {
 			PublisherName: ed.PublisherName,
 			PageCount:     ed.PageCount,
 			AudioSeconds:  ed.AudioSeconds,
+			Narrators:     ed.Narrators,
+			Abridged:      ed.Abridged,
 			CoverURL:      ed.CoverURL,
 		}
 		if ed.ReleaseDate != nil {
@@ -403,7 +538,7 @@ func (s *Server) getBookContributors(c echo.Context) error {
 			ID:          c.ID,
 			AuthorID:    c.AuthorID,
 			AuthorName:  c.Author.Name,
-			AuthorImage: c.Author.ImageURL,
+			AuthorImage: authorImageURL(&c.Author),
 			Role:        string(c.Role),
 			Position:    c.Position,
 		})
@@ -435,10 +570,11 @@ func (s *Server) refreshBookMetadata(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create Hardcover client"})
 	}
+	client = client.BypassCache() // A refresh must see current data
 
-	bookData, err := client.GetBook(book.HardcoverID)
+	bookData, err := client.GetBook(c.Request().Context(), book.HardcoverID)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch book from Hardcover: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch book from Hardcover: " + err.Error()})
 	}
 
 	s.updateBookFromHardcover(&book, bookData)
@@ -450,6 +586,13 @@ func (s *Server) refreshBookMetadata(c echo.Context) error {
 	s.syncGenres(&book, bookData.Genres)
 	s.syncEditions(&book, bookData)
 	s.syncContributors(&book, bookData)
+	s.syncIdentifiers(&book, db.BookIdentifiers{})
+
+	// Hardcover audio durations are often missing or rounded; prefer Audible's runtime
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+	s.enrichAudiobookEditions(ctx, &book)
+	go s.writeBookMetadataFiles(book.ID)
 
 	return c.JSON(http.StatusOK, map[string]any{
 		"message":  "Metadata refreshed",
@@ -489,23 +632,42 @@ func (s *Server) getGenres(c echo.Context) error {
 	return c.JSON(http.StatusOK, responses)
 }
 
+// updateBookFromHardcover copies refreshed metadata onto a book, except for the fields
+// the user has locked
 func (s *Server) updateBookFromHardcover(book *db.Book, data *hardcover.BookData) {
-	book.Title = data.Title
-	book.Subtitle = data.Subtitle
+	locked := lockedFields(book)
+	if !locked["title"] {
+		book.Title = data.Title
+	}
+	if !locked["subtitle"] {
+		book.Subtitle = data.Subtitle
+	}
 	book.Headline = data.Headline
 	book.Slug = data.Slug
-	book.ISBN = data.ISBN
-	book.ISBN13 = data.ISBN13
-	book.Description = data.Description
+	if !locked["isbn"] {
+		book.ISBN = data.ISBN
+	}
+	if !locked["isbn13"] {
+		book.ISBN13 = data.ISBN13
+	}
+	if !locked["description"] {
+		book.Description = data.Description
+	}
 	book.CoverURL = data.CoverURL
 	book.Rating = data.Rating
 	book.RatingsCount = data.RatingsCount
 	book.ReviewsCount = data.ReviewsCount
-	book.ReleaseDate = data.ReleaseDate
-	book.ReleaseYear = data.ReleaseYear
-	book.PageCount = data.PageCount
-	book.LanguageCode = data.LanguageCode
-	book.Language = data.Language
+	if !locked["releaseDate"] {
+		book.ReleaseDate = data.ReleaseDate
+		book.ReleaseYear = data.ReleaseYear
+	}
+	if !locked["pageCount"] {
+		book.PageCount = data.PageCount
+	}
+	if !locked["language"] {
+		book.LanguageCode = data.LanguageCode
+		book.Language = data.Language
+	}
 	book.AudioDuration = data.AudioDuration
 	book.HasEbook = data.HasEbook
 	book.HasAudiobook = data.HasAudiobook
@@ -520,4 +682,5 @@ func (s *Server) updateBookFromHardcover(book *db.Book, data *hardcover.BookData
 
 	now := timeNow()
 	book.LastSyncedAt = &now
+	s.covers.Queue(book.ID, bookCover(book))
 }
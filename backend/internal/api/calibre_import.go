@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/calibre"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"github.com/shelfarr/shelfarr/internal/metadata"
+)
+
+const jobCalibreImport = "calibre_import"
+
+// CalibreImportRequest represents a request to import a Calibre library
+type CalibreImportRequest struct {
+	Path      string `json:"path" validate:"required"` // Calibre library folder, containing metadata.db
+	Monitored bool   `json:"monitored"`                // Monitor the imported books for upgrades and missing formats
+}
+
+// CalibreImportResult summarizes a finished Calibre import
+type CalibreImportResult struct {
+	Added    int      `json:"added"`
+	Existing int      `json:"existing"` // Matched to books already in the library
+	Files    int      `json:"files"`    // Files linked to library books
+	Failed   []string `json:"failed"`
+}
+
+// importCalibre queues an import of a Calibre library. Files are linked where they are,
+// not copied.
+func (s *Server) importCalibre(c echo.Context) error {
+	var req CalibreImportRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	req.Path = filepath.Clean(req.Path)
+
+	library, err := calibre.Open(req.Path)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	library.Close()
+
+	job, err := s.jobs.Enqueue(jobCalibreImport, req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// runCalibreImportJob adds each book in a Calibre library, or matches it to a library
+// book by its identifiers, and links its files
+func (s *Server) runCalibreImportJob(ctx context.Context, job *db.Job, progress jobs.ProgressFunc) (interface{}, error) {
+	var payload CalibreImportRequest
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return nil, err
+	}
+
+	library, err := calibre.Open(payload.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer library.Close()
+
+	progress(0, "Reading Calibre library")
+	books, err := library.Books()
+	if err != nil {
+		return nil, err
+	}
+
+	result := CalibreImportResult{Failed: []string{}}
+	for i, cb := range books {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		progress(float64(i)*100/float64(len(books)), fmt.Sprintf("Importing %s (%d/%d)", cb.Title, i+1, len(books)))
+
+		ids := calibreIdentifiers(&cb)
+		book, err := db.FindBookByIdentifiers(s.db, ids)
+		if err == nil {
+			if err := db.SaveBookIdentifiers(s.db, book.ID, ids); err != nil {
+				libraryLog.Warn("Calibre import: failed to record identifiers", "bookId", book.ID, "error", err)
+			}
+			result.Existing++
+		} else {
+			book, err = s.createCalibreBook(&cb, ids, payload.Monitored)
+			if err != nil {
+				libraryLog.Warn("Calibre import: failed to add book", "book", cb.Title, "error", err)
+				result.Failed = append(result.Failed, cb.Title)
+				continue
+			}
+			result.Added++
+		}
+
+		result.Files += s.linkCalibreFiles(book, cb.Files)
+	}
+
+	progress(100, fmt.Sprintf("Added %d books, matched %d, linked %d files", result.Added, result.Existing, result.Files))
+	return result, nil
+}
+
+// calibreIdentifiers maps a Calibre book's identifiers to library identifier columns
+func calibreIdentifiers(cb *calibre.Book) db.BookIdentifiers {
+	ids := db.BookIdentifiers{CalibreUUID: cb.UUID}
+	for kind, value := range cb.Identifiers {
+		switch kind {
+		case "isbn":
+			isbn := strings.ReplaceAll(value, "-", "")
+			if len(isbn) == 13 {
+				ids.ISBN13 = isbn
+			} else if len(isbn) == 10 {
+				ids.ISBN10 = isbn
+			}
+		case "amazon", "mobi-asin":
+			if ids.ASIN == "" {
+				ids.ASIN = value
+			}
+		case "goodreads":
+			ids.GoodreadsID = value
+		case "google":
+			ids.GoogleVolumeID = value
+		case "openlibrary":
+			// Calibre usually stores the edition key, but accept work keys too
+			if strings.HasSuffix(value, "W") {
+				ids.OLWorkID = value
+			} else {
+				ids.OLEditionID = value
+			}
+		}
+	}
+	return ids
+}
+
+// createCalibreBook adds a Calibre book to the library from its Calibre metadata.
+// Every Calibre author becomes a contributor; the first is the primary author.
+func (s *Server) createCalibreBook(cb *calibre.Book, ids db.BookIdentifiers, monitored bool) (*db.Book, error) {
+	var authorIDs []uint
+	for _, name := range cb.Authors {
+		if id := s.getOrCreateAuthorByName(&hardcover.BookData{AuthorName: name}); id != 0 {
+			authorIDs = append(authorIDs, id)
+		}
+	}
+	if len(authorIDs) == 0 {
+		return nil, fmt.Errorf("book has no author")
+	}
+
+	var seriesID *uint
+	if cb.Series != "" {
+		seriesID = s.getOrCreateSeriesByName(cb.Series, authorIDs[0])
+	}
+
+	now := timeNow()
+	book := db.Book{
+		Title:        cb.Title,
+		SortTitle:    cb.SortTitle,
+		ISBN:         ids.ISBN10,
+		ISBN13:       ids.ISBN13,
+		Description:  cb.Description,
+		ReleaseDate:  cb.PubDate,
+		AuthorID:     authorIDs[0],
+		SeriesID:     seriesID,
+		Status:       db.StatusMissing,
+		Monitored:    monitored,
+		LastSyncedAt: &now,
+	}
+	if cb.PubDate != nil {
+		book.ReleaseYear = cb.PubDate.Year()
+	}
+	if seriesID != nil {
+		book.SeriesIndex = &cb.SeriesIndex
+	}
+	if len(cb.Languages) > 0 {
+		book.LanguageCode = metadata.LanguageCode(cb.Languages[0])
+	}
+
+	if err := s.db.Create(&book).Error; err != nil {
+		return nil, err
+	}
+	s.covers.Queue(book.ID, book.CoverURL)
+
+	for i, authorID := range authorIDs {
+		s.db.Create(&db.Contributor{BookID: book.ID, AuthorID: authorID, Role: db.RoleAuthor, Position: i})
+	}
+	s.syncGenres(&book, cb.Tags)
+	s.syncIdentifiers(&book, ids)
+
+	libraryLog.Debug("Calibre import: added book", "book", book.Title, "bookId", book.ID)
+	return &book, nil
+}
+
+// getOrCreateSeriesByName finds a series by name, creating it without a Hardcover ID
+// when there is none
+func (s *Server) getOrCreateSeriesByName(name string, authorID uint) *uint {
+	var series db.Series
+	if err := s.db.Where("LOWER(name) = LOWER(?)", name).First(&series).Error; err != nil {
+		series = db.Series{Name: name, AuthorID: &authorID}
+		if err := s.db.Create(&series).Error; err != nil {
+			libraryLog.Error("getOrCreateSeriesByName: failed to create series", "series", name, "error", err)
+			return nil
+		}
+	}
+	return &series.ID
+}
+
+// linkCalibreFiles records a Calibre book's files as media files of a library book,
+// leaving them in place. Returns how many were linked.
+func (s *Server) linkCalibreFiles(book *db.Book, files []calibre.File) int {
+	scanner := media.NewScanner()
+	linked := 0
+	for _, f := range files {
+		mediaType := scanner.DetectMediaType("." + f.Format)
+		if mediaType == "" || f.Size == 0 {
+			continue // Unsupported format, or missing from disk
+		}
+
+		var count int64
+		s.db.Model(&db.MediaFile{}).Unscoped().Where("file_path = ?", f.Path).Count(&count)
+		if count > 0 {
+			continue
+		}
+
+		mediaFile := db.MediaFile{
+			BookID:     book.ID,
+			FilePath:   f.Path,
+			FileName:   filepath.Base(f.Path),
+			FileSize:   f.Size,
+			Format:     f.Format,
+			MediaType:  db.MediaType(mediaType),
+			PageCount:  pageCount(f.Path),
+			ImportedAt: time.Now(),
+		}
+		if err := s.db.Create(&mediaFile).Error; err != nil {
+			libraryLog.Warn("Calibre import: failed to link file", "path", f.Path, "error", err)
+			continue
+		}
+		linked++
+	}
+
+	if linked > 0 {
+		s.db.Model(book).Update("status", db.StatusDownloaded)
+	}
+	return linked
+}
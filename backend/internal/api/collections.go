@@ -0,0 +1,291 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// CollectionRequest creates or updates a collection
+type CollectionRequest struct {
+	Name        string `json:"name" validate:"max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	OPDS        bool   `json:"opds"`
+}
+
+// CollectionResponse represents a collection in API responses
+type CollectionResponse struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	OPDS        bool           `json:"opds"`
+	Shared      bool           `json:"shared"` // Shared with the user by an admin, so read-only
+	BookCount   int            `json:"bookCount"`
+	Books       []BookResponse `json:"books,omitempty"`
+}
+
+// CollectionBooksRequest names books to add to a collection, or all of its books in
+// their new order
+type CollectionBooksRequest struct {
+	BookIDs []uint `json:"bookIds" validate:"required"`
+}
+
+// getCollections returns the current user's collections, and those shared with them
+func (s *Server) getCollections(c echo.Context) error {
+	var collections []db.Collection
+	if err := s.db.Scopes(visibleCollections(c)).Order("name ASC").Find(&collections).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]CollectionResponse, len(collections))
+	for i, collection := range collections {
+		responses[i] = s.collectionToResponse(collection)
+		responses[i].Shared = collection.UserID != currentUserID(c)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// getCollection returns one of the current user's collections, or one shared with
+// them, with the books they can see in order
+func (s *Server) getCollection(c echo.Context) error {
+	var collection db.Collection
+	if err := s.db.Scopes(visibleCollections(c)).First(&collection, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	var books []db.Book
+	err := s.db.Scopes(s.visibleBooks(c)).Joins("JOIN collection_books ON collection_books.book_id = books.id").
+		Where("collection_books.collection_id = ?", collection.ID).
+		Order("collection_books.position").
+		Preload("Author").Preload("Series").Preload("MediaFiles").
+		Find(&books).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	response := s.collectionToResponse(collection)
+	response.Shared = collection.UserID != currentUserID(c)
+	response.Books = make([]BookResponse, len(books))
+	for i, book := range books {
+		response.Books[i] = bookToResponse(book)
+	}
+	s.addBookTags(response.Books)
+	return c.JSON(http.StatusOK, response)
+}
+
+// createCollection adds a collection for the current user
+func (s *Server) createCollection(c echo.Context) error {
+	var req CollectionRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	collection := db.Collection{
+		UserID:      currentUserID(c),
+		Name:        req.Name,
+		Description: strings.TrimSpace(req.Description),
+		OPDS:        req.OPDS,
+	}
+	if err := s.db.Create(&collection).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create collection"})
+	}
+	return c.JSON(http.StatusCreated, s.collectionToResponse(collection))
+}
+
+// updateCollection renames a collection or changes whether it's in the OPDS catalog
+func (s *Server) updateCollection(c echo.Context) error {
+	collection, ok := s.findCollection(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	var req CollectionRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	collection.Name = req.Name
+	collection.Description = strings.TrimSpace(req.Description)
+	collection.OPDS = req.OPDS
+	if err := s.db.Save(collection).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update collection"})
+	}
+	return c.JSON(http.StatusOK, s.collectionToResponse(*collection))
+}
+
+// deleteCollection removes a collection; its books stay in the library
+func (s *Server) deleteCollection(c echo.Context) error {
+	collection, ok := s.findCollection(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&db.CollectionBook{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete collection"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// addCollectionBooks appends books to the end of a collection, skipping those already
+// in it
+func (s *Server) addCollectionBooks(c echo.Context) error {
+	collection, ok := s.findCollection(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	var req CollectionBooksRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var count int64
+	s.db.Model(&db.Book{}).Where("id IN ?", req.BookIDs).Count(&count)
+	if int(count) != len(uniqueIDs(req.BookIDs)) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		tx.Model(&db.CollectionBook{}).Where("collection_id = ?", collection.ID).Pluck("book_id", &existing)
+		in := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			in[id] = true
+		}
+
+		var last struct{ Position *int }
+		tx.Model(&db.CollectionBook{}).Select("MAX(position) AS position").Where("collection_id = ?", collection.ID).Scan(&last)
+		position := 0
+		if last.Position != nil {
+			position = *last.Position + 1
+		}
+
+		for _, bookID := range uniqueIDs(req.BookIDs) {
+			if in[bookID] {
+				continue
+			}
+			entry := db.CollectionBook{CollectionID: collection.ID, BookID: bookID, Position: position}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			position++
+		}
+		return nil
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add books"})
+	}
+	return c.JSON(http.StatusOK, s.collectionToResponse(*collection))
+}
+
+// removeCollectionBook takes a book out of a collection
+func (s *Server) removeCollectionBook(c echo.Context) error {
+	collection, ok := s.findCollection(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	bookID, err := strconv.ParseUint(c.Param("bookId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+
+	result := s.db.Where("collection_id = ? AND book_id = ?", collection.ID, bookID).Delete(&db.CollectionBook{})
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to remove book"})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not in collection"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// reorderCollectionBooks puts a collection's books in the given order. Books missing
+// from the request keep their relative order after those listed.
+func (s *Server) reorderCollectionBooks(c echo.Context) error {
+	collection, ok := s.findCollection(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	var req CollectionBooksRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var entries []db.CollectionBook
+		if err := tx.Where("collection_id = ?", collection.ID).Order("position").Find(&entries).Error; err != nil {
+			return err
+		}
+
+		order := make(map[uint]int, len(req.BookIDs))
+		for i, bookID := range uniqueIDs(req.BookIDs) {
+			order[bookID] = i
+		}
+		rest := len(order)
+		for _, entry := range entries {
+			position, listed := order[entry.BookID]
+			if !listed {
+				position = rest
+				rest++
+			}
+			if err := tx.Model(&entry).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to reorder books"})
+	}
+	return s.getCollection(c)
+}
+
+// findCollection loads the current user's collection named by the id path parameter
+func (s *Server) findCollection(c echo.Context) (*db.Collection, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, false
+	}
+
+	var collection db.Collection
+	if err := s.db.Where("user_id = ?", currentUserID(c)).First(&collection, id).Error; err != nil {
+		return nil, false
+	}
+	return &collection, true
+}
+
+func (s *Server) collectionToResponse(collection db.Collection) CollectionResponse {
+	var count int64
+	s.db.Model(&db.CollectionBook{}).
+		Joins("JOIN books ON books.id = collection_books.book_id AND books.deleted_at IS NULL").
+		Where("collection_books.collection_id = ?", collection.ID).
+		Count(&count)
+
+	return CollectionResponse{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		OPDS:        collection.OPDS,
+		BookCount:   int(count),
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/configfile"
+	"github.com/shelfarr/shelfarr/internal/logging"
+)
+
+// ConfigValue is one of the settings Shelfarr was started with
+type ConfigValue struct {
+	Name   string `json:"name"`
+	Env    string `json:"env"`    // Environment variable that sets it
+	Value  string `json:"value"`  // Secrets are masked
+	Source string `json:"source"` // env, file or default
+}
+
+// EffectiveConfigResponse lists the settings Shelfarr was started with and where
+// they came from
+type EffectiveConfigResponse struct {
+	ConfigFile      string        `json:"configFile"` // "" when there's none
+	ConfigFileError string        `json:"configFileError,omitempty"`
+	Values          []ConfigValue `json:"values"`
+}
+
+// logConfigFile logs whether a config file was read
+func logConfigFile() {
+	path, err := configfile.Loaded()
+	switch {
+	case err != nil:
+		slog.Error("Ignoring config file", "error", err)
+	case path != "":
+		slog.Info("Read config file", "path", path)
+	}
+}
+
+// getConfig returns the settings in effect from the config file, environment and
+// defaults, leaving out secrets
+func (s *Server) getConfig(c echo.Context) error {
+	path, err := configfile.Loaded()
+	response := EffectiveConfigResponse{ConfigFile: path}
+	if err != nil {
+		response.ConfigFileError = err.Error()
+	}
+
+	add := func(name, env, value string) {
+		response.Values = append(response.Values, ConfigValue{
+			Name:   name,
+			Env:    env,
+			Value:  value,
+			Source: configfile.Source(env),
+		})
+	}
+	add("Listen address", "SHELFARR_LISTEN_ADDR", s.config.ListenAddr)
+	add("URL base", "SHELFARR_URL_BASE", currentURLBase())
+	add("Shutdown timeout", "SHELFARR_SHUTDOWN_TIMEOUT", shutdownTimeout().String())
+	add("Trusted proxies", "SHELFARR_TRUSTED_PROXIES", os.Getenv("SHELFARR_TRUSTED_PROXIES"))
+	add("Config folder", "SHELFARR_CONFIG_PATH", s.config.ConfigPath)
+	add("Books folder", "SHELFARR_BOOKS_PATH", s.config.BooksPath)
+	add("Audiobooks folder", "SHELFARR_AUDIOBOOKS_PATH", s.config.AudiobooksPath)
+	add("Downloads folder", "SHELFARR_DOWNLOADS_PATH", s.config.DownloadsPath)
+	add("Scripts folder", "SHELFARR_SCRIPTS_PATH", scriptsPath)
+	add("Database driver", "SHELFARR_DB_DRIVER", s.db.Dialector.Name())
+	add("Database connection", "SHELFARR_DB_DSN", masked(os.Getenv("SHELFARR_DB_DSN")))
+	add("Most open connections", "SHELFARR_DB_MAX_OPEN_CONNS", os.Getenv("SHELFARR_DB_MAX_OPEN_CONNS"))
+	add("Most idle connections", "SHELFARR_DB_MAX_IDLE_CONNS", os.Getenv("SHELFARR_DB_MAX_IDLE_CONNS"))
+	add("Connection lifetime", "SHELFARR_DB_CONN_MAX_LIFETIME", os.Getenv("SHELFARR_DB_CONN_MAX_LIFETIME"))
+	add("Log level", "SHELFARR_LOG_LEVEL", logging.Level())
+	add("HTTP proxy", "HTTP_PROXY", redactProxy(os.Getenv("HTTP_PROXY")))
+	add("HTTPS proxy", "HTTPS_PROXY", redactProxy(os.Getenv("HTTPS_PROXY")))
+	add("No proxy for", "NO_PROXY", os.Getenv("NO_PROXY"))
+	add("Hardcover API", "HARDCOVER_API_URL", s.config.HardcoverAPIURL)
+	add("Hardcover API key", "HARDCOVER_API_KEY", masked(s.config.HardcoverAPIKey))
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// secretMask stands in for a secret that's set
+const secretMask = "********"
+
+// masked hides a secret, showing only whether it's set
+func masked(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// redactProxy masks the password in a proxy URL
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return u.Redacted()
+}
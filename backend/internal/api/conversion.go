@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+const jobEbookConvert = "ebook_convert"
+
+// ebookConvertPayload is the job payload for ebook conversions
+type ebookConvertPayload struct {
+	MediaFileID uint   `json:"mediaFileId"`
+	Format      string `json:"format"`
+}
+
+// ConvertRequest represents a request to convert an ebook to another format
+type ConvertRequest struct {
+	Format string `json:"format" validate:"required"` // epub, mobi, azw3, pdf
+}
+
+// convertMediaFile queues a Calibre conversion of an ebook. The result is added as a new
+// media file next to the original, so a PDF or MOBI can be upgraded to EPUB (or the reverse for Kindle)
+func (s *Server) convertMediaFile(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var req ConvertRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	format := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(req.Format), "."))
+	if format == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format is required"})
+	}
+
+	var file db.MediaFile
+	if err := s.db.First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+	if file.MediaType != db.MediaTypeEbook {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only ebooks can be converted"})
+	}
+
+	sourceFormat := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.FilePath)), ".")
+	if sourceFormat == format {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "File is already in " + strings.ToUpper(format) + " format"})
+	}
+
+	converter := media.NewEbookConverter()
+	if !converter.IsAvailable() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "ebook-convert not found - please install Calibre"})
+	}
+	if !converter.CanConvert(sourceFormat, format) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Cannot convert " + strings.ToUpper(sourceFormat) + " to " + strings.ToUpper(format)})
+	}
+
+	outputPath := strings.TrimSuffix(file.FilePath, filepath.Ext(file.FilePath)) + "." + format
+	var existing int64
+	s.db.Model(&db.MediaFile{}).Where("file_path = ?", outputPath).Count(&existing)
+	if existing > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "A " + strings.ToUpper(format) + " version of this file already exists"})
+	}
+
+	job, err := s.jobs.Enqueue(jobEbookConvert, ebookConvertPayload{MediaFileID: file.ID, Format: format})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// runEbookConvertJob converts an ebook with Calibre and adds the result as a new media file
+func (s *Server) runEbookConvertJob(ctx context.Context, job *db.Job, progress jobs.ProgressFunc) (interface{}, error) {
+	var payload ebookConvertPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return nil, err
+	}
+
+	var file db.MediaFile
+	if err := s.db.Preload("Book").Preload("Book.Author").Preload("Book.Series").First(&file, payload.MediaFileID).Error; err != nil {
+		return nil, fmt.Errorf("media file %d not found", payload.MediaFileID)
+	}
+
+	opts := &media.ConversionOptions{
+		Title:          file.Book.Title,
+		Author:         file.Book.Author.Name,
+		PreserveCovers: true,
+	}
+	if file.Book.Series != nil {
+		opts.Series = file.Book.Series.Name
+		if file.Book.SeriesIndex != nil {
+			opts.SeriesIndex = float64(*file.Book.SeriesIndex)
+		}
+	}
+
+	// ebook-convert doesn't report progress, so only the start and end are known
+	progress(5, "Converting "+file.FileName+" to "+strings.ToUpper(payload.Format))
+
+	result, err := media.NewEbookConverter().ConvertToFormat(ctx, file.FilePath, payload.Format, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(result.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("converted file not found")
+	}
+
+	progress(95, "Adding converted file to library")
+	converted := db.MediaFile{
+		BookID:      file.BookID,
+		FilePath:    result.OutputPath,
+		FileName:    filepath.Base(result.OutputPath),
+		FileSize:    info.Size(),
+		Format:      payload.Format,
+		MediaType:   db.MediaTypeEbook,
+		PageCount:   pageCount(result.OutputPath),
+		EditionName: file.EditionName,
+		ImportedAt:  time.Now(),
+	}
+	if err := s.db.Create(&converted).Error; err != nil {
+		os.Remove(result.OutputPath)
+		return nil, fmt.Errorf("failed to save converted file: %w", err)
+	}
+
+	return map[string]interface{}{
+		"mediaFileId": converted.ID,
+		"filePath":    converted.FilePath,
+		"duration":    result.Duration.Seconds(),
+	}, nil
+}
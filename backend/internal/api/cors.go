@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// CORSSettings are the cross-origin options in the general settings, for a frontend
+// or other browser app hosted on a different origin from the API
+type CORSSettings struct {
+	AllowedOrigins   []string `json:"corsAllowedOrigins"`   // Such as https://books.example.com, or * for any
+	AllowCredentials bool     `json:"corsAllowCredentials"` // Let browsers send cookies and auth headers cross-origin
+}
+
+// corsSettings returns the CORS options stored in the general settings. Any origin is
+// allowed until they're set; an empty list allows none, so only the same origin works.
+func (s *Server) corsSettings() CORSSettings {
+	settings := CORSSettings{AllowedOrigins: []string{"*"}}
+
+	var dbSettings []db.Setting
+	s.db.Where("key LIKE ?", "general_cors_%").Find(&dbSettings)
+	for _, setting := range dbSettings {
+		switch setting.Key {
+		case "general_cors_allowed_origins":
+			settings.AllowedOrigins = []string{}
+			for _, origin := range strings.Split(setting.Value, ",") {
+				if origin = strings.TrimSpace(origin); origin != "" {
+					settings.AllowedOrigins = append(settings.AllowedOrigins, origin)
+				}
+			}
+		case "general_cors_allow_credentials":
+			settings.AllowCredentials = setting.Value == "true"
+		}
+	}
+	return settings
+}
+
+// validateCORSSettings checks the allowed origins are * or scheme://host[:port], where
+// the host may start with a *. wildcard
+func validateCORSSettings(settings CORSSettings) error {
+	for _, origin := range settings.AllowedOrigins {
+		if origin == "*" {
+			if settings.AllowCredentials {
+				return fmt.Errorf("credentials can't be allowed for any origin; list the origins instead of *")
+			}
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "*.", "wildcard.", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("invalid origin %q; use a scheme and host, such as https://books.example.com", origin)
+		}
+	}
+	return nil
+}
+
+// updateCORSSettings checks and saves the CORS options, and starts using them
+func (s *Server) updateCORSSettings(req GeneralSettingsRequest) error {
+	settings := s.corsSettings()
+	if req.CORSAllowedOrigins != nil {
+		settings.AllowedOrigins = settings.AllowedOrigins[:0]
+		for _, origin := range req.CORSAllowedOrigins {
+			if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+				settings.AllowedOrigins = append(settings.AllowedOrigins, origin)
+			}
+		}
+	}
+	if req.CORSAllowCredentials != nil {
+		settings.AllowCredentials = *req.CORSAllowCredentials
+	}
+	if err := validateCORSSettings(settings); err != nil {
+		return err
+	}
+
+	values := map[string]string{
+		"general_cors_allowed_origins":   strings.Join(settings.AllowedOrigins, ","),
+		"general_cors_allow_credentials": strconv.FormatBool(settings.AllowCredentials),
+	}
+	for key, value := range values {
+		setting := db.Setting{Key: key, Value: value}
+		s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+	}
+	s.applyCORSSettings()
+	return nil
+}
+
+// applyCORSSettings starts using the CORS policy in the general settings
+func (s *Server) applyCORSSettings() {
+	settings := s.corsSettings()
+	if len(settings.AllowedOrigins) == 0 {
+		// Echo's CORS middleware allows any origin when given none
+		var policy echo.MiddlewareFunc = func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+		s.corsPolicy.Store(&policy)
+		return
+	}
+	origins := make([]string, len(settings.AllowedOrigins))
+	for i, origin := range settings.AllowedOrigins {
+		origins[i] = strings.TrimSuffix(origin, "/")
+	}
+	policy := middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     origins,
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-Api-Key"},
+		ExposeHeaders:    []string{"Retry-After"},
+		AllowCredentials: settings.AllowCredentials,
+	})
+	s.corsPolicy.Store(&policy)
+}
+
+// cors applies the current CORS policy, which changes with the settings
+func (s *Server) cors(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return (*s.corsPolicy.Load())(next)(c)
+	}
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/images"
+)
+
+// bookCover returns the cover shown for a book: the user's choice if they made one,
+// otherwise the metadata provider's
+func bookCover(book *db.Book) string {
+	if book.CustomCover != "" {
+		return book.CustomCover
+	}
+	return book.CoverURL
+}
+
+// authorImage returns the photo shown for an author, preferring the user's choice
+func authorImage(author *db.Author) string {
+	if author.CustomImage != "" {
+		return author.CustomImage
+	}
+	return author.ImageURL
+}
+
+// coverKey signs cover URLs. Covers are loaded by <img> tags and e-readers, which
+// don't send the user's token, so rather than checking who asks for a cover, a cover
+// is only served at the signed URL handed out with a book the user can see.
+var coverKey atomic.Pointer[[]byte]
+
+// setCoverKey derives the key cover URLs are signed with from the JWT secret, so they
+// stay valid, and cached, across restarts
+func setCoverKey(secret string) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("cover urls"))
+	key := mac.Sum(nil)
+	coverKey.Store(&key)
+}
+
+// coverSignature signs a version of a book's cover URL
+func coverSignature(bookID uint, version string) string {
+	var key []byte
+	if k := coverKey.Load(); k != nil {
+		key = *k
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d:%s", bookID, version)
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// coverImageURL returns the URL a library book's cover is served at from the local
+// cache, or "" if it has no cover. The version changes with the cover URL, so clients
+// can cache the image indefinitely.
+func coverImageURL(book *db.Book) string {
+	cover := bookCover(book)
+	if cover == "" {
+		return ""
+	}
+	version := images.Version(cover)
+	return fmt.Sprintf("%s/api/images/covers/%d?v=%s&sig=%s", currentURLBase(), book.ID, version, coverSignature(book.ID, version))
+}
+
+// authorImageURL returns the URL a library author's photo is served at, or ""
+func authorImageURL(author *db.Author) string {
+	image := authorImage(author)
+	if image == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/images/authors/%d?v=%s", currentURLBase(), author.ID, images.Version(image))
+}
+
+// getCoverImage serves a book's cover from the local cache, downloading it on first
+// request. ?width= scales it down for thumbnails. If the cover can't be cached, the
+// client is redirected to the remote image instead.
+func (s *Server) getCoverImage(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	// Only URLs from coverImageURL, so restricted users can't fetch hidden books' covers
+	if !hmac.Equal([]byte(c.QueryParam("sig")), []byte(coverSignature(uint(id), c.QueryParam("v")))) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Cover not found"})
+	}
+
+	var book db.Book
+	if err := s.db.Select("id", "cover_url", "custom_cover").First(&book, id).Error; err != nil || bookCover(&book) == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Cover not found"})
+	}
+
+	width, _ := strconv.Atoi(c.QueryParam("width"))
+	return s.serveCover(c, &book, width)
+}
+
+// getAuthorImage serves an author's photo from the local cache, like getCoverImage
+func (s *Server) getAuthorImage(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var author db.Author
+	if err := s.db.Select("id", "image_url", "custom_image").First(&author, id).Error; err != nil || authorImage(&author) == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Image not found"})
+	}
+
+	width, _ := strconv.Atoi(c.QueryParam("width"))
+	return s.serveImage(c, s.photos, author.ID, authorImage(&author), width)
+}
+
+// serveCover writes a book's cached cover, falling back to a redirect to the remote one
+func (s *Server) serveCover(c echo.Context, book *db.Book, width int) error {
+	return s.serveImage(c, s.covers, book.ID, bookCover(book), width)
+}
+
+func (s *Server) serveImage(c echo.Context, cache *images.CoverCache, id uint, url string, width int) error {
+	path, err := cache.Resized(c.Request().Context(), id, url, width)
+	if err != nil {
+		metadataLog.Warn("Could not cache image", "url", url, "error", err)
+		if images.IsUpload(url) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Image not found"})
+		}
+		return c.Redirect(http.StatusFound, url)
+	}
+
+	// Versioned URLs never change; unversioned ones are revalidated daily
+	if c.QueryParam("v") != "" {
+		c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		c.Response().Header().Set("Cache-Control", "public, max-age=86400")
+	}
+	return c.File(path)
+}
+
+// readUploadedImage reads the image posted as the "file" form field
+func readUploadedImage(c echo.Context) ([]byte, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("image file is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file")
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, images.MaxUploadSize+1))
+}
+
+// uploadBookCover replaces a book's cover with an uploaded image
+func (s *Server) uploadBookCover(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	data, err := readUploadedImage(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	cover, err := s.covers.Store(book.ID, data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return s.setCustomCover(c, &book, cover)
+}
+
+// SetCoverRequest picks a book's cover from one of its editions or a URL
+type SetCoverRequest struct {
+	EditionID uint   `json:"editionId,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// setBookCover replaces a book's cover with an edition's cover or an image URL
+func (s *Server) setBookCover(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	var req SetCoverRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	cover := strings.TrimSpace(req.URL)
+	if req.EditionID != 0 {
+		var edition db.Edition
+		if err := s.db.Where("id = ? AND book_id = ?", req.EditionID, book.ID).First(&edition).Error; err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Edition not found"})
+		}
+		if edition.CoverURL == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Edition has no cover"})
+		}
+		cover = edition.CoverURL
+	}
+	if !strings.HasPrefix(cover, "http://") && !strings.HasPrefix(cover, "https://") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "editionId or an http(s) url is required"})
+	}
+
+	// Cache it now, so a URL that isn't an image is rejected rather than saved
+	if _, err := s.covers.Fetch(c.Request().Context(), book.ID, cover); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not fetch cover: " + err.Error()})
+	}
+	return s.setCustomCover(c, &book, cover)
+}
+
+// resetBookCover goes back to the metadata provider's cover
+func (s *Server) resetBookCover(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+	s.covers.Queue(book.ID, book.CoverURL)
+	return s.setCustomCover(c, &book, "")
+}
+
+func (s *Server) setCustomCover(c echo.Context, book *db.Book, cover string) error {
+	if err := s.db.Model(book).Update("custom_cover", cover).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update cover"})
+	}
+	s.db.Preload("Author").Preload("Series").First(book, book.ID)
+	return c.JSON(http.StatusOK, bookToResponse(*book))
+}
+
+// uploadAuthorImage replaces an author's photo with an uploaded image
+func (s *Server) uploadAuthorImage(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author ID"})
+	}
+	var author db.Author
+	if err := s.db.First(&author, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+
+	data, err := readUploadedImage(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	image, err := s.photos.Store(author.ID, data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return s.setCustomImage(c, &author, image)
+}
+
+// resetAuthorImage goes back to the metadata provider's photo
+func (s *Server) resetAuthorImage(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid author ID"})
+	}
+	var author db.Author
+	if err := s.db.First(&author, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+	return s.setCustomImage(c, &author, "")
+}
+
+func (s *Server) setCustomImage(c echo.Context, author *db.Author, image string) error {
+	if err := s.db.Model(author).Update("custom_image", image).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update image"})
+	}
+	return c.JSON(http.StatusOK, AuthorResponse{
+		ID:          author.ID,
+		HardcoverID: author.HardcoverID,
+		Name:        author.Name,
+		SortName:    author.SortName,
+		ImageURL:    authorImageURL(author),
+		CustomImage: author.CustomImage != "",
+		Monitored:   author.Monitored,
+	})
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// databaseMaintenanceInterval is how often the database is checked and compacted
+const databaseMaintenanceInterval = 7 * 24 * time.Hour
+
+// runDatabaseMaintenance checks and compacts the database on the scheduler, keeping the
+// result for the health checks
+func (s *Server) runDatabaseMaintenance(ctx context.Context) error {
+	result, err := db.Maintain(s.db)
+	if result == nil && err == nil {
+		return nil
+	}
+
+	values := map[string]string{
+		"db_maintenance_at":    time.Now().UTC().Format(time.RFC3339),
+		"db_maintenance_error": "",
+	}
+	if err != nil {
+		values["db_maintenance_error"] = err.Error()
+	} else {
+		encoded, _ := json.Marshal(result)
+		values["db_maintenance_result"] = string(encoded)
+		slog.Info("Database maintenance finished", "duration", result.Duration.Round(time.Millisecond),
+			"sizeBefore", result.SizeBefore, "sizeAfter", result.SizeAfter,
+			"integrityProblems", len(result.Integrity), "foreignKeyProblems", result.ForeignKeyProblems)
+	}
+	for key, value := range values {
+		setting := db.Setting{Key: key, Value: value}
+		s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+	}
+
+	s.runHealthChecks()
+	return err
+}
+
+// checkDatabase reports problems the last database maintenance found
+func (s *Server) checkDatabase() []HealthCheck {
+	var settings []db.Setting
+	s.db.Where("key LIKE ?", "db_maintenance_%").Find(&settings)
+
+	var result db.MaintenanceResult
+	var maintenanceErr string
+	for _, setting := range settings {
+		switch setting.Key {
+		case "db_maintenance_result":
+			json.Unmarshal([]byte(setting.Value), &result)
+		case "db_maintenance_error":
+			maintenanceErr = setting.Value
+		}
+	}
+
+	var checks []HealthCheck
+	if maintenanceErr != "" {
+		checks = append(checks, HealthCheck{
+			Source:   "Database maintenance",
+			Severity: SeverityWarning,
+			Message:  "Database maintenance failed: " + maintenanceErr,
+			Help:     "Check there's free space next to the database, which VACUUM needs to rebuild it, then run Database Maintenance from System > Tasks.",
+		})
+	}
+	if len(result.Integrity) > 0 {
+		problems := result.Integrity
+		if len(problems) > 3 {
+			problems = append(problems[:3:3], fmt.Sprintf("and %d more", len(result.Integrity)-3))
+		}
+		checks = append(checks, HealthCheck{
+			Source:   "Database integrity",
+			Severity: SeverityError,
+			Message:  "The database is damaged: " + strings.Join(problems, "; "),
+			Help:     "Stop Shelfarr and restore the database from a backup. Until then it isn't compacted, so nothing more is rewritten.",
+		})
+	}
+	if result.ForeignKeyProblems > 0 {
+		checks = append(checks, HealthCheck{
+			Source:   "Database references",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%d database rows refer to rows that no longer exist", result.ForeignKeyProblems),
+			Help:     "They're left over from before foreign keys were enforced and are usually harmless, but changing them may fail. Restoring a backup or asking for help on GitHub can clear them.",
+		})
+	}
+	return checks
+}
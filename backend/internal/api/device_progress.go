@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm/clause"
+)
+
+// Progress across devices. Each device a user reads or listens on keeps its own last
+// position, with when it was there, so listening on a phone and a tablet at once doesn't
+// silently overwrite either. The user's progress is resolved across their devices, by
+// default from the one used last; users who'd rather never lose their place pick the one
+// furthest in. Players ask where to resume from when they open a book, and hear when
+// another device has moved on.
+
+// Progress strategies
+const (
+	progressLatest   = "latest"
+	progressFurthest = "furthest"
+)
+
+// ResumeResponse is where to resume a media file from
+type ResumeResponse struct {
+	Progress   float32                  `json:"progress"`
+	Position   int                      `json:"position"`
+	Device     string                   `json:"device,omitempty"` // Whose position it is
+	LastReadAt *time.Time               `json:"lastReadAt,omitempty"`
+	Strategy   string                   `json:"strategy"`
+	Conflict   bool                     `json:"conflict"` // The asking device was somewhere else
+	Devices    []DeviceProgressResponse `json:"devices"`
+}
+
+// DeviceProgressResponse is where a user last was on one device
+type DeviceProgressResponse struct {
+	Device     string    `json:"device"`
+	Progress   float32   `json:"progress"`
+	Position   int       `json:"position"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// progressStrategy returns how a user's positions on different devices are resolved
+func (s *Server) progressStrategy(userID uint) string {
+	var user db.User
+	s.db.Select("id", "progress_strategy").First(&user, userID)
+	if user.ProgressStrategy == progressFurthest {
+		return progressFurthest
+	}
+	return progressLatest
+}
+
+// deviceProgress returns a user's positions in a media file on each device, most recent first
+func (s *Server) deviceProgress(userID, mediaFileID uint) []db.DeviceProgress {
+	var devices []db.DeviceProgress
+	s.db.Where("user_id = ? AND media_file_id = ?", userID, mediaFileID).Order("recorded_at DESC").Find(&devices)
+	return devices
+}
+
+// saveDeviceProgress saves where a device was in a media file, unless it has already
+// reported being somewhere since, as a late sync does. It returns where the device was
+// before, if it had a position.
+func (s *Server) saveDeviceProgress(update db.DeviceProgress) (*db.DeviceProgress, bool, error) {
+	var previous db.DeviceProgress
+	found := s.db.Where("user_id = ? AND media_file_id = ? AND device = ?", update.UserID, update.MediaFileID, update.Device).
+		First(&previous).Error == nil
+	if found && update.RecordedAt.Before(previous.RecordedAt) {
+		return &previous, false, nil
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "media_file_id"}, {Name: "device"}},
+		DoUpdates: clause.AssignmentColumns([]string{"progress", "position", "recorded_at", "updated_at"}),
+	}).Create(&update).Error
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return nil, true, nil
+	}
+	return &previous, true, nil
+}
+
+// resolveProgress sets a user's progress from their devices' positions by the strategy,
+// returning the position it's from
+func resolveProgress(progress *db.ReadProgress, devices []db.DeviceProgress, strategy string) *db.DeviceProgress {
+	winner := pickDeviceProgress(devices, strategy)
+	if winner == nil {
+		return nil
+	}
+	progress.Progress = winner.Progress
+	progress.Position = winner.Position
+	progress.LastReadAt = devices[0].RecordedAt
+	return winner
+}
+
+// pickDeviceProgress returns the position to resume from: the most recent, or the one
+// furthest in. devices are most recent first.
+func pickDeviceProgress(devices []db.DeviceProgress, strategy string) *db.DeviceProgress {
+	if len(devices) == 0 {
+		return nil
+	}
+	winner := &devices[0]
+	if strategy != progressFurthest {
+		return winner
+	}
+	for i := range devices[1:] {
+		d := &devices[i+1]
+		if d.Progress > winner.Progress || (d.Progress == winner.Progress && d.Position > winner.Position) {
+			winner = d
+		}
+	}
+	return winner
+}
+
+// resumeResponse describes where to resume a media file from, for a device
+func resumeResponse(devices []db.DeviceProgress, strategy, device string) ResumeResponse {
+	response := ResumeResponse{Strategy: strategy, Devices: make([]DeviceProgressResponse, 0, len(devices))}
+	for _, d := range devices {
+		response.Devices = append(response.Devices, DeviceProgressResponse{
+			Device:     d.Device,
+			Progress:   d.Progress,
+			Position:   d.Position,
+			RecordedAt: d.RecordedAt,
+		})
+	}
+
+	winner := pickDeviceProgress(devices, strategy)
+	if winner == nil {
+		return response
+	}
+	response.Progress = winner.Progress
+	response.Position = winner.Position
+	response.Device = winner.Device
+	response.LastReadAt = &devices[0].RecordedAt
+	if device != "" && winner.Device != device {
+		for _, d := range devices {
+			if d.Device == device {
+				response.Conflict = d.Position != winner.Position || d.Progress != winner.Progress
+			}
+		}
+	}
+	return response
+}
+
+// getResume returns where to resume a media file from, across the user's devices.
+// ?device= names the asking device, to hear whether another has moved on; ?strategy=
+// overrides the user's strategy.
+func (s *Server) getResume(c echo.Context) error {
+	mediaFileID, err := strconv.ParseUint(c.Param("mediaFileId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid media file ID"})
+	}
+
+	userID := currentUserID(c)
+	strategy := c.QueryParam("strategy")
+	switch strategy {
+	case "":
+		strategy = s.progressStrategy(userID)
+	case progressLatest, progressFurthest:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "strategy must be latest or furthest"})
+	}
+
+	return c.JSON(http.StatusOK, resumeResponse(s.deviceProgress(userID, uint(mediaFileID)), strategy, c.QueryParam("device")))
+}
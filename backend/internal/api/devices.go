@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/kindle"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// deviceFormats lists the formats each device type reads natively, best first.
+// A device's PreferredFormat overrides the first entry.
+var deviceFormats = map[string][]string{
+	"kindle":     {"epub", "pdf", "txt", "rtf", "doc", "docx"},
+	"kobo":       {"kepub", "epub", "pdf", "cbz"},
+	"pocketbook": {"epub", "pdf", "fb2", "mobi", "azw3", "djvu", "cbz"},
+	"other":      nil,
+}
+
+// DeviceRequest represents a request to create or update a device
+type DeviceRequest struct {
+	Name            string `json:"name" validate:"max=100"`
+	Type            string `json:"type" validate:"required"` // kindle, kobo, pocketbook, other
+	Email           string `json:"email"`
+	PreferredFormat string `json:"preferredFormat"`
+}
+
+// DeviceResponse represents a device in API responses
+type DeviceResponse struct {
+	ID              uint   `json:"id"`
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	Email           string `json:"email"`
+	PreferredFormat string `json:"preferredFormat"`
+	KoboSync        bool   `json:"koboSync"` // Kobo store sync is enabled
+}
+
+func toDeviceResponse(d db.Device) DeviceResponse {
+	return DeviceResponse{
+		ID:              d.ID,
+		Name:            d.Name,
+		Type:            d.Type,
+		Email:           d.Email,
+		PreferredFormat: d.PreferredFormat,
+		KoboSync:        d.KoboSyncToken != "",
+	}
+}
+
+// validateDeviceRequest checks a device request and fills in defaults
+func validateDeviceRequest(req *DeviceRequest) error {
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	req.Email = strings.TrimSpace(req.Email)
+	req.PreferredFormat = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(req.PreferredFormat), "."))
+
+	if _, ok := deviceFormats[req.Type]; !ok {
+		return fmt.Errorf("device type must be one of: kindle, kobo, pocketbook, other")
+	}
+	// Kobos can sync instead of receiving email, so their email is optional
+	if (req.Email != "" || req.Type != "kobo") && !strings.Contains(req.Email, "@") {
+		return fmt.Errorf("a valid device email is required")
+	}
+	if req.Type == "kindle" && !kindle.ValidateKindleEmail(req.Email) {
+		return fmt.Errorf("Kindle email must end with @kindle.com")
+	}
+	if req.Name == "" {
+		req.Name = req.Type
+	}
+	if req.PreferredFormat == "" && len(deviceFormats[req.Type]) > 0 {
+		req.PreferredFormat = deviceFormats[req.Type][0]
+	}
+	return nil
+}
+
+// getDevices returns the current user's devices
+func (s *Server) getDevices(c echo.Context) error {
+	var devices []db.Device
+	if err := s.db.Where("user_id = ?", currentUserID(c)).Order("name ASC").Find(&devices).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]DeviceResponse, len(devices))
+	for i, d := range devices {
+		responses[i] = toDeviceResponse(d)
+	}
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// addDevice registers a new device for the current user
+func (s *Server) addDevice(c echo.Context) error {
+	var req DeviceRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if err := validateDeviceRequest(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	device := db.Device{
+		UserID:          currentUserID(c),
+		Name:            req.Name,
+		Type:            req.Type,
+		Email:           req.Email,
+		PreferredFormat: req.PreferredFormat,
+	}
+
+	if err := s.db.Create(&device).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create device"})
+	}
+
+	return c.JSON(http.StatusCreated, toDeviceResponse(device))
+}
+
+// updateDevice updates one of the current user's devices
+func (s *Server) updateDevice(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var device db.Device
+	if err := s.db.Where("user_id = ?", currentUserID(c)).First(&device, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Device not found"})
+	}
+
+	var req DeviceRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if err := validateDeviceRequest(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	device.Name = req.Name
+	device.Type = req.Type
+	device.Email = req.Email
+	device.PreferredFormat = req.PreferredFormat
+
+	if err := s.db.Save(&device).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update device"})
+	}
+
+	return c.JSON(http.StatusOK, toDeviceResponse(device))
+}
+
+// deleteDevice removes one of the current user's devices
+func (s *Server) deleteDevice(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	if err := s.db.Where("user_id = ?", currentUserID(c)).Delete(&db.Device{}, id).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete device"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// sendToDevice emails a media file to one of the current user's devices,
+// converting it to the device's preferred format when needed
+func (s *Server) sendToDevice(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+	deviceID, err := strconv.ParseUint(c.QueryParam("deviceId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "deviceId is required"})
+	}
+
+	var file db.MediaFile
+	if err := s.db.First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+	if file.MediaType != db.MediaTypeEbook {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only ebooks can be sent to a device"})
+	}
+
+	userID := currentUserID(c)
+	var device db.Device
+	if err := s.db.Where("user_id = ?", userID).First(&device, deviceID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Device not found"})
+	}
+
+	smtp := loadSMTPConfig(s.db)
+	if !smtp.Valid() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "SMTP server is not configured"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	sourceFormat := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.FilePath)), ".")
+	targetFormat := chooseDeviceFormat(device, sourceFormat)
+
+	sendPath := file.FilePath
+	converted := false
+	if targetFormat != sourceFormat {
+		outputPath, cleanup, convErr := convertForDevice(ctx, file.FilePath, targetFormat)
+		if convErr != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": convErr.Error()})
+		}
+		defer cleanup()
+		sendPath = outputPath
+		converted = true
+	}
+
+	sender := kindle.NewKindleSender(kindle.SMTPConfig{
+		Host:     smtp.Host,
+		Port:     smtp.Port,
+		Username: smtp.Username,
+		Password: smtp.Password,
+		From:     smtp.From,
+		UseTLS:   smtp.UseTLS,
+	})
+
+	var result *kindle.SendResult
+	var sendErr error
+	if device.Type == "kindle" {
+		result, sendErr = sender.Send(sendPath, device.Email)
+	} else {
+		result, sendErr = sender.SendFile(sendPath, device.Email)
+	}
+
+	history := db.SendHistory{
+		MediaFileID: file.ID,
+		UserID:      userID,
+		DeviceID:    &device.ID,
+		ToEmail:     device.Email,
+		FileName:    filepath.Base(sendPath),
+		Format:      result.Format,
+		FileSize:    result.Size,
+		Converted:   converted,
+		Status:      "sent",
+	}
+	if sendErr != nil {
+		history.Status = "failed"
+		history.Error = sendErr.Error()
+	}
+	s.db.Create(&history)
+
+	if sendErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": sendErr.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "Sent to " + device.Name,
+		"toEmail":   device.Email,
+		"format":    result.Format,
+		"converted": converted,
+	})
+}
+
+// chooseDeviceFormat picks the format to send: the device's preferred format if we can
+// produce it, otherwise the source format when the device reads it natively
+func chooseDeviceFormat(device db.Device, sourceFormat string) string {
+	preferred := device.PreferredFormat
+	native := deviceFormats[device.Type]
+	if preferred == "" && len(native) > 0 {
+		preferred = native[0]
+	}
+	if preferred == "" || preferred == sourceFormat {
+		return sourceFormat
+	}
+
+	// EPUB to KEPUB is done natively, no Calibre needed
+	if preferred == "kepub" && sourceFormat == "epub" {
+		return preferred
+	}
+
+	converter := media.NewEbookConverter()
+	if converter.IsAvailable() && converter.CanConvert(sourceFormat, preferred) {
+		return preferred
+	}
+
+	// Can't convert; fall back to sending as-is if the device can read it
+	for _, f := range native {
+		if f == sourceFormat {
+			return sourceFormat
+		}
+	}
+
+	// Kindle accepts EPUB for anything else we can convert
+	if device.Type == "kindle" {
+		return "epub"
+	}
+	return preferred
+}
+
+// convertForDevice converts a file into a temp directory. The returned cleanup removes it.
+func convertForDevice(ctx context.Context, inputPath, format string) (string, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "shelfarr-send-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	if format == "kepub" {
+		outputPath := filepath.Join(tmpDir, media.KepubFileName(inputPath))
+		if err := media.NewKepubConverter().Convert(inputPath, outputPath); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("conversion to KEPUB failed: %w", err)
+		}
+		return outputPath, cleanup, nil
+	}
+
+	converter := media.NewEbookConverter()
+	if !converter.IsAvailable() {
+		cleanup()
+		return "", nil, fmt.Errorf("converting to %s requires ebook-convert (Calibre)", strings.ToUpper(format))
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	outputPath := filepath.Join(tmpDir, baseName+"."+format)
+
+	if _, err := converter.Convert(ctx, inputPath, outputPath, &media.ConversionOptions{OutputFormat: format}); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("conversion to %s failed: %w", strings.ToUpper(format), err)
+	}
+
+	return outputPath, cleanup, nil
+}
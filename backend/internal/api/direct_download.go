@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/downloader"
+	"github.com/shelfarr/shelfarr/internal/indexer"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+)
+
+const jobDirectDownload = "direct_download"
+
+// clientTypeDirect marks downloads Shelfarr fetches itself rather than through a client
+const clientTypeDirect = "direct"
+
+// directDownloadPayload is the job payload for direct downloads
+type directDownloadPayload struct {
+	DownloadID  uint   `json:"downloadId"`
+	IndexerName string `json:"indexer"`
+	Format      string `json:"format"`
+}
+
+// startDirectDownload records a download of a direct download result (Anna's Archive)
+// and queues a job that fetches it into the downloads folder and imports it
+func (s *Server) startDirectDownload(book *db.Book, result indexer.SearchResult, mediaType string) (*db.Download, error) {
+	download := db.Download{
+		BookID:      book.ID,
+		ClientType:  clientTypeDirect,
+		MediaType:   mediaType,
+		Indexer:     result.Indexer,
+		Title:       result.Title,
+		DownloadURL: result.DownloadURL,
+		Size:        result.Size,
+		Status:      "queued",
+		AddedAt:     time.Now().Unix(),
+	}
+	if err := s.db.Create(&download).Error; err != nil {
+		return nil, fmt.Errorf("failed to save download: %w", err)
+	}
+
+	job, err := s.jobs.Enqueue(jobDirectDownload, directDownloadPayload{
+		DownloadID:  download.ID,
+		IndexerName: result.Indexer,
+		Format:      result.Format,
+	})
+	if err != nil {
+		s.db.Delete(&download)
+		return nil, err
+	}
+
+	// The job ID lets deleting the download cancel it
+	download.ExternalID = strconv.FormatUint(uint64(job.ID), 10)
+	s.db.Model(&download).Update("external_id", download.ExternalID)
+
+	s.db.Model(book).Update("status", "downloading")
+	s.events.DownloadStarted(download.ID, book.ID, download.Title)
+	return &download, nil
+}
+
+// cancelDirectDownload stops the job fetching a direct download
+func (s *Server) cancelDirectDownload(download *db.Download) {
+	if id, err := strconv.ParseUint(download.ExternalID, 10, 32); err == nil {
+		s.jobs.Cancel(uint(id))
+	}
+}
+
+// runDirectDownloadJob resolves a direct download's file URL through its indexer,
+// fetches the file with a direct or aria2 client and imports it
+func (s *Server) runDirectDownloadJob(ctx context.Context, job *db.Job, progress jobs.ProgressFunc) (interface{}, error) {
+	var payload directDownloadPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return nil, err
+	}
+
+	var download db.Download
+	if err := s.db.First(&download, payload.DownloadID).Error; err != nil {
+		return nil, fmt.Errorf("download %d not found", payload.DownloadID)
+	}
+
+	var book db.Book
+	if err := s.db.Preload("Author").Preload("Series").First(&book, download.BookID).Error; err != nil {
+		return nil, s.failDirectDownload(&download, nil, fmt.Errorf("book %d not found", download.BookID))
+	}
+
+	var dbIndexer db.Indexer
+	if err := s.db.Where("name = ?", payload.IndexerName).First(&dbIndexer).Error; err != nil {
+		return nil, s.failDirectDownload(&download, &book, fmt.Errorf("indexer %s not found", payload.IndexerName))
+	}
+	idx := createIndexerFromDB(dbIndexer)
+	if idx == nil {
+		return nil, s.failDirectDownload(&download, &book, fmt.Errorf("unknown indexer type: %s", dbIndexer.Type))
+	}
+
+	s.db.Model(&download).Updates(map[string]interface{}{"status": "downloading", "error_message": ""})
+	progress(0, "Finding download link")
+
+	resolveCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	fileURL, err := idx.Download(resolveCtx, indexer.SearchResult{DownloadURL: download.DownloadURL})
+	cancel()
+	if err != nil {
+		return nil, s.failDirectDownload(&download, &book, err)
+	}
+
+	client, clientID := s.directDownloadClient()
+	fileName := download.Title
+	if payload.Format != "" {
+		fileName += "." + strings.ToLower(payload.Format)
+	}
+	id, err := client.AddDownload(ctx, fileURL, &downloader.DownloadOptions{FileName: fileName})
+	if err != nil {
+		return nil, s.failDirectDownload(&download, &book, err)
+	}
+	// Retried jobs pick up a failed download where it stopped
+	client.ResumeDownload(ctx, id)
+	s.db.Model(&download).Update("client_id", clientID)
+
+	progress(0, "Downloading "+download.Title)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var path string
+	for path == "" {
+		select {
+		case <-ctx.Done():
+			// Cancelled, usually by deleting the download
+			client.RemoveDownload(context.Background(), id, true)
+			s.db.Model(&download).Updates(map[string]interface{}{"status": "failed", "error_message": "Cancelled"})
+			s.events.DownloadFailed(download.ID, download.BookID, download.Title, "Cancelled")
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, err := client.GetDownload(ctx, id)
+		if err != nil {
+			return nil, s.failDirectDownload(&download, &book, err)
+		}
+		updates := map[string]interface{}{"downloaded": info.Downloaded, "progress": info.Progress * 100}
+		if info.Size > 0 {
+			updates["size"] = info.Size
+		}
+		s.db.Model(&download).Updates(updates)
+
+		switch info.Status {
+		case downloader.StatusCompleted:
+			path = filepath.Join(info.SavePath, info.Name)
+		case downloader.StatusFailed:
+			if info.Error == "" {
+				info.Error = "download failed"
+			}
+			return nil, s.failDirectDownload(&download, &book, errors.New(info.Error))
+		default:
+			// Leave the last few percent for the import
+			progress(info.Progress*95, "Downloading "+download.Title)
+			s.events.DownloadProgress(download.ID, download.BookID, string(info.Status), info.Progress*100, info.DownloadSpeed)
+		}
+	}
+
+	s.db.Model(&download).Updates(map[string]interface{}{
+		"status":      "importing",
+		"progress":    100,
+		"output_path": path,
+	})
+	progress(95, "Importing "+download.Title)
+	s.events.DownloadCompleted(download.ID, download.BookID, download.Title, path)
+
+	result, err := s.importToLibrary(&book, path, download.MediaType, "")
+	if err != nil {
+		s.db.Model(&download).Updates(map[string]interface{}{"status": "failed", "error_message": "Import failed: " + err.Error()})
+		s.events.ImportFailed(book.ID, download.Title, err.Error())
+		return nil, err
+	}
+	s.events.ImportCompleted(book.ID, result.MediaFileID, result.NewPath)
+
+	s.db.Model(&download).Updates(map[string]interface{}{
+		"status":       "completed",
+		"completed_at": time.Now().Unix(),
+	})
+	client.RemoveDownload(ctx, id, false)
+
+	return map[string]interface{}{
+		"downloadId":  download.ID,
+		"mediaFileId": result.MediaFileID,
+		"filePath":    result.NewPath,
+	}, nil
+}
+
+// directDownloadClient returns the highest priority enabled client that takes direct
+// downloads (direct or aria2) and its ID, or a direct client for the downloads folder
+// (ID 0) if none is configured
+func (s *Server) directDownloadClient() (downloader.Client, uint) {
+	var clients []db.DownloadClient
+	s.db.Where("enabled = ?", true).Order("priority ASC").Find(&clients)
+	for _, dc := range clients {
+		if !downloader.HandlesDirect(dc.Type) {
+			continue
+		}
+		if client, err := s.newDownloadClient(dc); err == nil {
+			return client, dc.ID
+		}
+	}
+	return downloader.NewDirectClient(s.config.DownloadsPath, 0), 0
+}
+
+// failDirectDownload marks a direct download failed and sends the failure notification
+func (s *Server) failDirectDownload(download *db.Download, book *db.Book, err error) error {
+	s.db.Model(download).Updates(map[string]interface{}{"status": "failed", "error_message": err.Error()})
+	s.events.DownloadFailed(download.ID, download.BookID, download.Title, err.Error())
+	if book != nil {
+		s.notifier.SendNotification("failure", map[string]interface{}{
+			"title":   book.Title,
+			"author":  book.Author.Name,
+			"message": "Download failed: " + err.Error(),
+			"bookId":  book.ID,
+		})
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+)
+
+// getDiscoverTrending returns books trending on Hardcover over the last month
+func (s *Server) getDiscoverTrending(c echo.Context) error {
+	return s.discoverBooks(c, (*hardcover.Client).GetTrendingBooks)
+}
+
+// getDiscoverPopular returns books popular on Hardcover this week
+func (s *Server) getDiscoverPopular(c echo.Context) error {
+	return s.discoverBooks(c, (*hardcover.Client).GetPopularThisWeek)
+}
+
+// getDiscoverRecent returns the most read books released in the last month
+func (s *Server) getDiscoverRecent(c echo.Context) error {
+	return s.discoverBooks(c, (*hardcover.Client).GetRecentReleases)
+}
+
+// discoverBooks fetches a Hardcover discovery list in the preferred languages,
+// leaving out books already in the library
+func (s *Server) discoverBooks(c echo.Context, fetch func(*hardcover.Client, context.Context, []string) (*hardcover.FilteredBooksResult, error)) error {
+	client, err := s.getHardcoverClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := fetch(client, c.Request().Context(), s.GetPreferredLanguages())
+	if err != nil {
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch books from Hardcover: " + err.Error()})
+	}
+
+	results := make([]SearchResult, 0, len(result.Books))
+	for _, book := range result.Books {
+		if _, inLibrary := s.findLibraryBook("hardcover", &book, nil); inLibrary {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			ID:          book.ID,
+			Title:       book.Title,
+			Author:      book.AuthorName,
+			AuthorID:    book.AuthorID,
+			CoverURL:    book.CoverURL,
+			Rating:      book.Rating,
+			ReleaseYear: book.ReleaseYear,
+			ISBN:        book.ISBN,
+			Description: book.Description,
+			Provider:    "hardcover",
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
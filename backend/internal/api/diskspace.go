@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// defaultMinimumFreeSpaceMB is the space a grab must leave free in the downloads folder
+// when no minimum has been set
+const defaultMinimumFreeSpaceMB = 100
+
+// DiskSpaceResponse reports the space on the disk holding a folder Shelfarr writes to
+type DiskSpaceResponse struct {
+	Path       string `json:"path"`
+	Label      string `json:"label"`
+	Type       string `json:"type"` // rootFolder, books, audiobooks or downloads
+	FreeSpace  int64  `json:"freeSpace"`
+	TotalSpace int64  `json:"totalSpace"`
+	Accessible bool   `json:"accessible"`
+}
+
+// getDiskSpaces returns free and total space for each root folder and the configured
+// books, audiobooks and downloads folders. A folder listed twice is reported once.
+func (s *Server) getDiskSpaces(c echo.Context) error {
+	var rootFolders []db.RootFolder
+	if err := s.db.Order("path").Find(&rootFolders).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := []DiskSpaceResponse{}
+	seen := make(map[string]bool)
+	add := func(path, label, pathType string) {
+		if path == "" || seen[path] {
+			return
+		}
+		seen[path] = true
+		free, total, accessible := getDiskSpace(path)
+		responses = append(responses, DiskSpaceResponse{
+			Path:       path,
+			Label:      label,
+			Type:       pathType,
+			FreeSpace:  free,
+			TotalSpace: total,
+			Accessible: accessible,
+		})
+	}
+
+	for _, rf := range rootFolders {
+		label := rf.Name
+		if label == "" {
+			label = rf.Path
+		}
+		add(rf.Path, label, "rootFolder")
+	}
+	add(s.config.BooksPath, "Books", "books")
+	add(s.config.AudiobooksPath, "Audiobooks", "audiobooks")
+	add(s.config.DownloadsPath, "Downloads", "downloads")
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// minimumFreeSpace returns the space, in bytes, a grab must leave free in the downloads
+// folder. 0 turns the check off.
+func (s *Server) minimumFreeSpace() int64 {
+	var setting db.Setting
+	if s.db.Where("key = ?", "media_minimum_free_space_mb").First(&setting).Error == nil {
+		if mb, err := strconv.ParseInt(setting.Value, 10, 64); err == nil && mb >= 0 {
+			return mb * 1024 * 1024
+		}
+	}
+	return defaultMinimumFreeSpaceMB * 1024 * 1024
+}
+
+// checkDownloadSpace returns an error if grabbing a release of size bytes would leave
+// less than the minimum free space in the downloads folder. A folder whose space can't
+// be read isn't held against the grab; the download client will report a full disk.
+func (s *Server) checkDownloadSpace(size int64) error {
+	minimum := s.minimumFreeSpace()
+	if minimum == 0 || s.config.DownloadsPath == "" {
+		return nil
+	}
+	free, _, accessible := getDiskSpace(s.config.DownloadsPath)
+	if !accessible {
+		return nil
+	}
+	if size < 0 {
+		size = 0
+	}
+	if free-size < minimum {
+		return fmt.Errorf("not enough free space in %s: %d MB free, the release needs %d MB and %d MB must be left free",
+			s.config.DownloadsPath, free/(1024*1024), size/(1024*1024), minimum/(1024*1024))
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/downloader"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"github.com/shelfarr/shelfarr/internal/torrent"
+)
+
+// monitorDownloads syncs downloads with their download clients, imports them once they
+// complete, and removes completed torrents from their client when their seeding goals
+// are met. Direct downloads are tracked by their own job.
+func (s *Server) monitorDownloads(ctx context.Context) error {
+	var downloads []db.Download
+	if err := s.db.Where("client_type <> ? AND removed = ? AND status IN ?", clientTypeDirect, false,
+		[]string{"queued", "downloading", "paused", "completed"}).Find(&downloads).Error; err != nil {
+		return err
+	}
+
+	clients := make(map[uint]*monitoredClient)
+	for i := range downloads {
+		download := &downloads[i]
+
+		mc, ok := clients[download.ClientID]
+		if !ok {
+			mc = s.monitoredClient(download.ClientID)
+			clients[download.ClientID] = mc
+		}
+		if mc == nil {
+			continue
+		}
+
+		info, err := s.lookupDownload(ctx, download, mc.client)
+		if err != nil {
+			if download.Status != "completed" {
+				downloadsLog.Warn("Download monitor: could not get download", "download", download.Title, "client", mc.config.Name, "error", err)
+			}
+			continue
+		}
+
+		if download.Status == "completed" {
+			s.checkSeedGoals(ctx, download, mc, info)
+		} else {
+			s.syncDownload(download, info)
+		}
+	}
+
+	return nil
+}
+
+// monitoredClient is a download client and its settings, shared by the downloads in it
+type monitoredClient struct {
+	config db.DownloadClient
+	client downloader.Client
+}
+
+// monitoredClient loads a download client, or returns nil if it no longer exists
+func (s *Server) monitoredClient(id uint) *monitoredClient {
+	var dc db.DownloadClient
+	if err := s.db.First(&dc, id).Error; err != nil {
+		return nil
+	}
+	client, err := s.newDownloadClient(dc)
+	if err != nil {
+		downloadsLog.Warn("Download monitor: could not create client", "client", dc.Name, "error", err)
+		return nil
+	}
+	return &monitoredClient{config: dc, client: client}
+}
+
+// lookupDownload gets a download from its client. Torrents that were added by URL, before
+// their hash was known, are looked up by their info hash instead, which then replaces the
+// URL as their ID.
+func (s *Server) lookupDownload(ctx context.Context, download *db.Download, client downloader.Client) (*downloader.DownloadInfo, error) {
+	info, err := client.GetDownload(ctx, download.ExternalID)
+	if err == nil {
+		return info, nil
+	}
+
+	hash := download.InfoHash
+	if hash == "" && torrent.IsMagnet(download.ExternalID) {
+		if magnet, err := torrent.ParseMagnet(download.ExternalID); err == nil {
+			hash = magnet.InfoHash
+		}
+	}
+	if hash == "" || strings.EqualFold(hash, download.ExternalID) {
+		return nil, err
+	}
+
+	info, hashErr := client.GetDownload(ctx, hash)
+	if hashErr != nil {
+		return nil, err
+	}
+	download.ExternalID, download.InfoHash = info.ID, hash
+	s.db.Model(download).Updates(map[string]interface{}{"external_id": info.ID, "info_hash": hash})
+	return info, nil
+}
+
+// syncDownload updates a download from its client and imports it once it completes
+func (s *Server) syncDownload(download *db.Download, info *downloader.DownloadInfo) {
+	updates := map[string]interface{}{
+		"progress":   info.Progress * 100,
+		"downloaded": info.Downloaded,
+	}
+	if info.Size > 0 {
+		updates["size"] = info.Size
+	}
+
+	switch info.Status {
+	case downloader.StatusCompleted:
+		s.db.Model(download).Updates(updates)
+		s.importDownload(download, downloadOutputPath(info))
+		return
+	case downloader.StatusFailed:
+		updates["status"] = "failed"
+		updates["error_message"] = info.Error
+		s.db.Model(download).Updates(updates)
+		s.events.DownloadFailed(download.ID, download.BookID, download.Title, info.Error)
+		return
+	case downloader.StatusPaused:
+		updates["status"] = "paused"
+	case downloader.StatusQueued:
+		updates["status"] = "queued"
+	default:
+		updates["status"] = "downloading"
+	}
+	s.db.Model(download).Updates(updates)
+	s.events.DownloadProgress(download.ID, download.BookID, updates["status"].(string), info.Progress*100, info.DownloadSpeed)
+}
+
+// importDownload imports a completed download, saved at path, into the library.
+// Torrents are imported with hardlinks, so they keep seeding from the download folder.
+func (s *Server) importDownload(download *db.Download, path string) {
+	s.db.Model(download).Updates(map[string]interface{}{
+		"status":      "importing",
+		"progress":    100,
+		"output_path": path,
+	})
+	s.events.DownloadCompleted(download.ID, download.BookID, download.Title, path)
+
+	var book db.Book
+	if err := s.db.Preload("Author").Preload("Series").First(&book, download.BookID).Error; err != nil {
+		s.db.Model(download).Updates(map[string]interface{}{"status": "failed", "error_message": "Book not found"})
+		return
+	}
+
+	mediaType := download.MediaType
+	if mediaType == "" {
+		mediaType = "ebook"
+	}
+	if mediaType == "ebook" {
+		// The importer treats folders as audiobooks; use the book file inside
+		path = findEbookFile(path)
+	}
+
+	result, err := s.importToLibrary(&book, path, mediaType, "")
+	if err != nil {
+		downloadsLog.Warn("Download monitor: import failed", "download", download.Title, "error", err)
+		s.db.Model(download).Updates(map[string]interface{}{"status": "failed", "error_message": "Import failed: " + err.Error()})
+		s.events.ImportFailed(book.ID, download.Title, err.Error())
+		return
+	}
+	s.events.ImportCompleted(book.ID, result.MediaFileID, result.NewPath)
+
+	s.db.Model(download).Updates(map[string]interface{}{
+		"status":       "completed",
+		"completed_at": time.Now().Unix(),
+	})
+}
+
+// checkSeedGoals removes a completed torrent from its client once it has reached its
+// seed ratio or seed time. The indexer's goals take precedence over the client's.
+func (s *Server) checkSeedGoals(ctx context.Context, download *db.Download, mc *monitoredClient, info *downloader.DownloadInfo) {
+	if downloader.ClientProtocol(mc.config.Type) != "torrent" || info.Status != downloader.StatusCompleted {
+		return
+	}
+
+	ratio, seedTime := mc.config.SeedRatio, mc.config.SeedTime
+	if download.Indexer != "" {
+		var idx db.Indexer
+		if err := s.db.Where("name = ?", download.Indexer).First(&idx).Error; err == nil {
+			if idx.SeedRatio > 0 {
+				ratio = idx.SeedRatio
+			}
+			if idx.SeedTime > 0 {
+				seedTime = idx.SeedTime
+			}
+		}
+	}
+	if ratio <= 0 && seedTime <= 0 {
+		return // Seed forever
+	}
+
+	seeded := time.Since(time.Unix(download.CompletedAt, 0))
+	ratioMet := ratio > 0 && info.Ratio >= ratio
+	timeMet := seedTime > 0 && download.CompletedAt > 0 && seeded >= time.Duration(seedTime)*time.Minute
+	if !ratioMet && !timeMet {
+		return
+	}
+
+	if err := mc.client.RemoveDownload(ctx, download.ExternalID, mc.config.DeleteSeededFiles); err != nil {
+		downloadsLog.Warn("Download monitor: could not remove seeded torrent", "download", download.Title, "error", err)
+		return
+	}
+	downloadsLog.Info("Removed torrent after seeding", "download", download.Title, "client", mc.config.Name, "ratio", info.Ratio, "seeded", seeded.Round(time.Minute))
+	s.db.Model(download).Update("removed", true)
+}
+
+// downloadOutputPath returns the file or folder a completed download was saved to.
+// Most clients report the parent folder, SABnzbd the job's own folder.
+func downloadOutputPath(info *downloader.DownloadInfo) string {
+	if info.Name == "" || filepath.Base(info.SavePath) == info.Name {
+		return info.SavePath
+	}
+	return filepath.Join(info.SavePath, info.Name)
+}
+
+// findEbookFile returns the largest ebook file in a folder, or the path itself if it
+// isn't a folder or has none
+func findEbookFile(path string) string {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return path
+	}
+
+	scanner := media.NewScanner()
+	best, bestSize := path, int64(-1)
+	filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if scanner.DetectMediaType(filepath.Ext(file)) == "ebook" && info.Size() > bestSize {
+			best, bestSize = file, info.Size()
+		}
+		return nil
+	})
+	return best
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,13 +17,14 @@ import (
 
 // DownloadRequest represents a request to download a book
 type DownloadRequest struct {
-	BookID      uint   `json:"bookId"`
+	BookID      uint   `json:"bookId" validate:"required"`
 	IndexerName string `json:"indexer"`
-	DownloadURL string `json:"downloadUrl"`
+	DownloadURL string `json:"downloadUrl" validate:"required"`
 	Title       string `json:"title"`
-	Size        int64  `json:"size"`
+	Size        int64  `json:"size" validate:"min=0"`
 	Format      string `json:"format"`
-	MediaType   string `json:"mediaType"` // ebook or audiobook
+	Protocol    string `json:"protocol" validate:"oneof=torrent usenet direct"` // From the search result
+	MediaType   string `json:"mediaType" validate:"oneof=ebook audiobook"`      // ebook or audiobook
 }
 
 // DownloadResponse represents a download status response
@@ -41,35 +44,21 @@ type DownloadResponse struct {
 // triggerDownload initiates a download for a book
 func (s *Server) triggerDownload(c echo.Context) error {
 	var req DownloadRequest
-	if err := c.Bind(&req); err != nil {
-		log.Printf("[DEBUG] triggerDownload: invalid request body, error=%v", err)
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		downloadsLog.Debug("triggerDownload: invalid request", "error", err)
+		return err
 	}
 
-	log.Printf("[DEBUG] triggerDownload called: bookId=%d, indexer=%s, title=%s, url=%s", req.BookID, req.IndexerName, req.Title, req.DownloadURL)
-
-	if req.BookID == 0 || req.DownloadURL == "" {
-		log.Printf("[DEBUG] triggerDownload: missing required fields")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "bookId and downloadUrl are required"})
-	}
+	downloadsLog.Debug("triggerDownload called", "bookId", req.BookID, "indexer", req.IndexerName, "title", req.Title, "url", req.DownloadURL)
 
 	// Verify book exists
 	var book db.Book
 	if err := s.db.First(&book, req.BookID).Error; err != nil {
-		log.Printf("[DEBUG] triggerDownload: book not found with id=%d, error=%v", req.BookID, err)
+		downloadsLog.Debug("triggerDownload: book not found", "bookId", req.BookID, "error", err)
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
 	}
 
-	log.Printf("[DEBUG] triggerDownload: found book '%s'", book.Title)
-
-	// Get the first enabled download client
-	var downloadClient db.DownloadClient
-	if err := s.db.Where("enabled = ?", true).Order("priority ASC").First(&downloadClient).Error; err != nil {
-		log.Printf("[DEBUG] triggerDownload: no enabled download client found, error=%v", err)
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No download client configured"})
-	}
-
-	log.Printf("[DEBUG] triggerDownload: using download client '%s' (type=%s, url=%s)", downloadClient.Name, downloadClient.Type, downloadClient.URL)
+	downloadsLog.Debug("triggerDownload: found book", "book", book.Title)
 
 	// Default media type to ebook if not specified
 	mediaType := req.MediaType
@@ -77,54 +66,111 @@ func (s *Server) triggerDownload(c echo.Context) error {
 		mediaType = "ebook"
 	}
 
-	// Create download record
-	download := db.Download{
-		BookID:      req.BookID,
-		ClientID:    downloadClient.ID,
-		ClientType:  downloadClient.Type,
-		MediaType:   mediaType,
-		Title:       req.Title,
-		DownloadURL: req.DownloadURL,
-		Size:        req.Size,
-		Status:      "queued",
-		Category:    downloadClient.Category,
-		AddedAt:     time.Now().Unix(),
+	if err := s.checkDownloadSpace(req.Size); err != nil {
+		downloadsLog.Warn("triggerDownload: not enough free space", "book", book.Title, "error", err)
+		return c.JSON(http.StatusInsufficientStorage, map[string]string{"error": err.Error()})
 	}
 
-	// Create the download client
-	client, err := downloader.CreateClientFromDB(downloadClient.Type, downloadClient.URL, downloadClient.Username, downloadClient.Password)
+	// Direct downloads are fetched by Shelfarr, not sent to a download client
+	if req.Protocol == indexer.ProtocolDirect {
+		download, err := s.startDirectDownload(&book, indexer.SearchResult{
+			Title:       req.Title,
+			Size:        req.Size,
+			Format:      req.Format,
+			DownloadURL: req.DownloadURL,
+			Indexer:     req.IndexerName,
+		}, mediaType)
+		if err != nil {
+			downloadsLog.Debug("triggerDownload: failed to start direct download", "error", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+		s.notifier.SendNotification("grab", map[string]interface{}{
+			"title":     book.Title,
+			"release":   download.Title,
+			"indexer":   req.IndexerName,
+			"mediaType": download.MediaType,
+			"bookId":    book.ID,
+		})
+		setAudit(c, "download.grab", "Grabbed "+download.Title+" for "+book.Title)
+
+		return c.JSON(http.StatusCreated, DownloadResponse{
+			ID:        download.ID,
+			BookID:    download.BookID,
+			Title:     download.Title,
+			MediaType: download.MediaType,
+			Status:    download.Status,
+			Size:      download.Size,
+			AddedAt:   download.AddedAt,
+		})
+	}
+
+	// Get the enabled download clients for the result's protocol, in the order to try them
+	clients, err := s.downloadClientsFor(req.Protocol)
 	if err != nil {
-		log.Printf("[DEBUG] triggerDownload: failed to create download client, error=%v", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create download client"})
+		downloadsLog.Debug("triggerDownload: no enabled download client found", "error", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
 	// Add to download client
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
 	defer cancel()
 
-	log.Printf("[DEBUG] triggerDownload: adding download to client with category=%s", downloadClient.Category)
-	externalID, err := client.AddDownload(ctx, req.DownloadURL, &downloader.DownloadOptions{
-		Category: downloadClient.Category,
-	})
+	downloadURL, authenticate, err := s.resolveDownloadURL(ctx, req.IndexerName, indexer.SearchResult{Title: req.Title, DownloadURL: req.DownloadURL})
 	if err != nil {
-		log.Printf("[DEBUG] triggerDownload: failed to add download to client, error=%v", err)
+		downloadsLog.Debug("triggerDownload: failed to get download link from indexer", "error", err)
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to get download link: " + err.Error()})
+	}
+
+	grabbed, err := s.grabRelease(ctx, clients, mediaType, downloadURL, authenticate)
+	if err != nil {
+		downloadsLog.Debug("triggerDownload: failed to add download to client", "error", err)
+		s.notifier.SendNotification("failure", map[string]interface{}{
+			"title":   book.Title,
+			"message": "Failed to add download: " + err.Error(),
+			"bookId":  book.ID,
+		})
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add download: " + err.Error()})
 	}
 
-	log.Printf("[DEBUG] triggerDownload: download added successfully, externalID=%s", externalID)
+	downloadsLog.Debug("triggerDownload: download added", "client", grabbed.client.Name, "externalId", grabbed.externalID)
 
-	download.ExternalID = externalID
-	download.Status = "downloading"
+	// Create download record
+	download := db.Download{
+		BookID:      req.BookID,
+		ClientID:    grabbed.client.ID,
+		ClientType:  grabbed.client.Type,
+		ExternalID:  grabbed.externalID,
+		InfoHash:    grabbed.infoHash,
+		MediaType:   mediaType,
+		Indexer:     req.IndexerName,
+		Title:       req.Title,
+		DownloadURL: req.DownloadURL,
+		Size:        req.Size,
+		Status:      "downloading",
+		Category:    grabbed.category,
+		AddedAt:     time.Now().Unix(),
+	}
 
 	if err := s.db.Create(&download).Error; err != nil {
-		log.Printf("[DEBUG] triggerDownload: failed to save download record, error=%v", err)
+		downloadsLog.Debug("triggerDownload: failed to save download record", "error", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save download"})
 	}
 
 	// Update book status
 	s.db.Model(&book).Update("status", "downloading")
+	s.events.DownloadStarted(download.ID, book.ID, download.Title)
+
+	s.notifier.SendNotification("grab", map[string]interface{}{
+		"title":     book.Title,
+		"release":   download.Title,
+		"indexer":   req.IndexerName,
+		"mediaType": download.MediaType,
+		"bookId":    book.ID,
+	})
 
-	log.Printf("[DEBUG] triggerDownload: download started successfully, downloadId=%d", download.ID)
+	downloadsLog.Debug("triggerDownload: download started", "downloadId", download.ID)
+	setAudit(c, "download.grab", "Grabbed "+download.Title+" for "+book.Title)
 
 	return c.JSON(http.StatusCreated, DownloadResponse{
 		ID:        download.ID,
@@ -207,7 +253,8 @@ func (s *Server) getDownload(c echo.Context) error {
 	})
 }
 
-// deleteDownload removes a download
+// deleteDownload removes a download. It's also removed from its download client unless
+// removeFromClient is false, and blocklisted for its book when blocklist is true.
 func (s *Server) deleteDownload(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -219,11 +266,22 @@ func (s *Server) deleteDownload(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Download not found"})
 	}
 
-	// Try to remove from download client
-	if download.ExternalID != "" && download.ClientID > 0 {
+	removeFromClient := c.QueryParam("removeFromClient") != "false"
+	if c.QueryParam("blocklist") == "true" {
+		reason := c.QueryParam("reason")
+		if reason == "" {
+			reason = "Removed from queue"
+		}
+		s.blocklistDownload(&download, reason)
+	}
+
+	// Try to remove from download client, or stop fetching a direct download
+	if download.ClientType == clientTypeDirect {
+		s.cancelDirectDownload(&download)
+	} else if removeFromClient && download.ExternalID != "" && download.ClientID > 0 {
 		var downloadClient db.DownloadClient
 		if s.db.First(&downloadClient, download.ClientID).Error == nil {
-			client, _ := downloader.CreateClientFromDB(downloadClient.Type, downloadClient.URL, downloadClient.Username, downloadClient.Password)
+			client, _ := s.newDownloadClient(downloadClient)
 			if client != nil {
 				ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
 				client.RemoveDownload(ctx, download.ExternalID, false)
@@ -236,6 +294,7 @@ func (s *Server) deleteDownload(c echo.Context) error {
 	if err := s.db.Delete(&download).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete download"})
 	}
+	s.releaseBook(download.BookID, download.ID)
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -253,9 +312,10 @@ func (s *Server) automaticSearch(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
 	}
 
-	// Get enabled indexers
+	// Get enabled indexers, leaving out tagged ones that share no tag with the book
+	tagIDs := db.BookTagIDs(s.db, &book)
 	var dbIndexers []db.Indexer
-	if err := s.db.Where("enabled = ?", true).Order("priority ASC").Find(&dbIndexers).Error; err != nil {
+	if err := s.db.Scopes(db.IndexerTags.AvailableFor(tagIDs)).Where("enabled = ?", true).Order("priority ASC").Find(&dbIndexers).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load indexers"})
 	}
 
@@ -265,6 +325,7 @@ func (s *Server) automaticSearch(c echo.Context) error {
 
 	// Create indexer manager and search
 	manager := indexer.NewManager()
+	manager.SetHealth(s.health)
 	for _, dbIdx := range dbIndexers {
 		idx := createIndexerFromDB(dbIdx)
 		if idx != nil {
@@ -299,11 +360,14 @@ func (s *Server) automaticSearch(c echo.Context) error {
 	}
 	isAudiobook := mediaType == "audiobook"
 
-	// Get quality profile for this media type
+	// Get quality profile for this media type, preferring one sharing a tag with the book
 	var profile db.QualityProfile
-	if err := s.db.Where("media_type = ? AND is_default = ?", mediaType, true).First(&profile).Error; err != nil {
+	available := db.QualityProfileTags.AvailableFor(tagIDs)
+	if err := s.db.Scopes(db.QualityProfileTags.Tagged(tagIDs)).Where("media_type = ?", mediaType).First(&profile).Error; err == nil {
+		downloadsLog.Debug("Using quality profile tagged for book", "profile", profile.Name, "bookId", book.ID)
+	} else if err := s.db.Scopes(available).Where("media_type = ? AND is_default = ?", mediaType, true).First(&profile).Error; err != nil {
 		// If no default profile, try to get any profile for this media type
-		if err := s.db.Where("media_type = ?", mediaType).First(&profile).Error; err != nil {
+		if err := s.db.Scopes(available).Where("media_type = ?", mediaType).First(&profile).Error; err != nil {
 			// Fall back to simple scoring if no profiles configured
 			profile = db.QualityProfile{
 				FormatRanking: "epub,azw3,mobi,pdf",
@@ -315,51 +379,110 @@ func (s *Server) automaticSearch(c echo.Context) error {
 		}
 	}
 
-	// Select best result using quality profile scoring
+	// Select best result using quality profile scoring, leaving out blocklisted releases
+	results = s.filterBlocklisted(book.ID, results)
 	bestResult := indexer.GetBestResult(results, profile.FormatRanking, profile.MinBitrate, isAudiobook)
 	if bestResult == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "No suitable results found matching quality profile"})
 	}
 
-	// Get the first enabled download client
-	var downloadClient db.DownloadClient
-	if err := s.db.Where("enabled = ?", true).Order("priority ASC").First(&downloadClient).Error; err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No download client configured"})
+	// Get the enabled download clients for the result's protocol, in the order to try
+	// them. Direct downloads are fetched by Shelfarr instead.
+	var clients []db.DownloadClient
+	clientName := "Shelfarr (direct download)"
+	if bestResult.Protocol != indexer.ProtocolDirect {
+		clients, err = s.downloadClientsFor(bestResult.Protocol)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		clientName = clients[0].Name
 	}
 
-	// Create and initiate download
-	client, err := downloader.CreateClientFromDB(downloadClient.Type, downloadClient.URL, downloadClient.Username, downloadClient.Password)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create download client"})
-	}
+	// In dry-run mode, report the grab that would have been made without touching the client
+	if s.isAutomationDryRun() {
+		score := indexer.ScoreResult(*bestResult, profile.FormatRanking, profile.MinBitrate, isAudiobook)
+		downloadsLog.Info("Dry run: automaticSearch would grab release", "release", bestResult.Title, "indexer", bestResult.Indexer,
+			"book", book.Title, "score", score.Score, "format", bestResult.Format, "reason", score.Reason, "client", clientName)
 
-	externalID, err := client.AddDownload(ctx, bestResult.DownloadURL, &downloader.DownloadOptions{
-		Category: downloadClient.Category,
-	})
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add download: " + err.Error()})
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message":  "Dry run: download not started",
+			"dryRun":   true,
+			"title":    bestResult.Title,
+			"indexer":  bestResult.Indexer,
+			"size":     bestResult.Size,
+			"format":   bestResult.Format,
+			"score":    score.Score,
+			"reason":   score.Reason,
+			"client":   clientName,
+			"profile":  profile.Name,
+			"searched": len(results),
+		})
 	}
 
-	// Save download record
-	download := db.Download{
-		BookID:      book.ID,
-		ClientID:    downloadClient.ID,
-		ClientType:  downloadClient.Type,
-		ExternalID:  externalID,
-		Title:       bestResult.Title,
-		DownloadURL: bestResult.DownloadURL,
-		Size:        bestResult.Size,
-		Status:      "downloading",
-		Category:    downloadClient.Category,
-		AddedAt:     time.Now().Unix(),
+	if err := s.checkDownloadSpace(bestResult.Size); err != nil {
+		downloadsLog.Warn("automaticSearch: not grabbing release", "release", bestResult.Title, "book", book.Title, "error", err)
+		return c.JSON(http.StatusInsufficientStorage, map[string]string{"error": err.Error()})
 	}
 
-	if err := s.db.Create(&download).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save download"})
+	var download db.Download
+	if bestResult.Protocol == indexer.ProtocolDirect {
+		started, err := s.startDirectDownload(&book, *bestResult, mediaType)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		download = *started
+	} else {
+		// Create and initiate download
+		downloadURL, authenticate, err := s.resolveDownloadURL(ctx, bestResult.Indexer, *bestResult)
+		if err != nil {
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to get download link: " + err.Error()})
+		}
+
+		grabbed, err := s.grabRelease(ctx, clients, mediaType, downloadURL, authenticate)
+		if err != nil {
+			s.notifier.SendNotification("failure", map[string]interface{}{
+				"title":   book.Title,
+				"author":  book.Author.Name,
+				"message": "Failed to add download: " + err.Error(),
+				"bookId":  book.ID,
+			})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add download: " + err.Error()})
+		}
+
+		// Save download record
+		download = db.Download{
+			BookID:      book.ID,
+			ClientID:    grabbed.client.ID,
+			ClientType:  grabbed.client.Type,
+			ExternalID:  grabbed.externalID,
+			InfoHash:    grabbed.infoHash,
+			MediaType:   mediaType,
+			Indexer:     bestResult.Indexer,
+			Title:       bestResult.Title,
+			DownloadURL: bestResult.DownloadURL,
+			Size:        bestResult.Size,
+			Status:      "downloading",
+			Category:    grabbed.category,
+			AddedAt:     time.Now().Unix(),
+		}
+
+		if err := s.db.Create(&download).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save download"})
+		}
+
+		// Update book status
+		s.db.Model(&book).Update("status", "downloading")
+		s.events.DownloadStarted(download.ID, book.ID, download.Title)
 	}
 
-	// Update book status
-	s.db.Model(&book).Update("status", "downloading")
+	s.notifier.SendNotification("grab", map[string]interface{}{
+		"title":     book.Title,
+		"author":    book.Author.Name,
+		"release":   bestResult.Title,
+		"indexer":   bestResult.Indexer,
+		"mediaType": mediaType,
+		"bookId":    book.ID,
+	})
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message":    "Download started",
@@ -392,6 +515,188 @@ func selectBestResult(results []indexer.SearchResult) *indexer.SearchResult {
 	return best
 }
 
+// downloadClientsFor returns the enabled download clients for a protocol, highest
+// priority first: SABnzbd or NZBGet for usenet results, torrent clients for torrents.
+// Results without a protocol can go to any client. With load balancing on, clients of
+// equal priority take turns at the front.
+func (s *Server) downloadClientsFor(protocol string) ([]db.DownloadClient, error) {
+	var clients []db.DownloadClient
+	s.db.Where("enabled = ?", true).Order("priority ASC, id ASC").Find(&clients)
+	if len(clients) == 0 {
+		return nil, errors.New("No download client configured")
+	}
+
+	var matching []db.DownloadClient
+	for _, client := range clients {
+		if protocol == "" || downloader.ClientProtocol(client.Type) == protocol {
+			matching = append(matching, client)
+		}
+	}
+	if len(matching) == 0 {
+		if protocol == indexer.ProtocolUsenet {
+			return nil, errors.New("No usenet download client (SABnzbd or NZBGet) configured")
+		}
+		return nil, errors.New("No torrent download client configured")
+	}
+
+	if s.isLoadBalancingClients() {
+		s.rotateClients(matching)
+	}
+	return matching, nil
+}
+
+// rotateClients moves each run of equal-priority clients round by one more place on
+// every call, spreading grabs across them
+func (s *Server) rotateClients(clients []db.DownloadClient) {
+	turn := int(s.clientTurn.Add(1) - 1)
+	for start := 0; start < len(clients); {
+		end := start + 1
+		for end < len(clients) && clients[end].Priority == clients[start].Priority {
+			end++
+		}
+		group := clients[start:end]
+		n := turn % len(group)
+		rotated := append(append([]db.DownloadClient{}, group[n:]...), group[:n]...)
+		copy(group, rotated)
+		start = end
+	}
+}
+
+// resolveDownloadURL asks a result's indexer for the URL to give the download client.
+// Most indexers return the result's URL; scraped ones such as AudioBookBay link to a
+// page the magnet is built from. Indexers whose links need their credentials, such as
+// MAM, also return a function that adds them to the request for the file.
+func (s *Server) resolveDownloadURL(ctx context.Context, indexerName string, result indexer.SearchResult) (string, downloader.Authenticate, error) {
+	var dbIndexer db.Indexer
+	if err := s.db.Where("name = ?", indexerName).First(&dbIndexer).Error; err != nil {
+		return result.DownloadURL, nil, nil // Removed since the search; use the URL as is
+	}
+	idx := createIndexerFromDB(dbIndexer)
+	if idx == nil {
+		return result.DownloadURL, nil, nil
+	}
+
+	downloadURL, err := idx.Download(ctx, result)
+	if err != nil {
+		return "", nil, err
+	}
+	if auth, ok := idx.(indexer.Authenticator); ok {
+		return downloadURL, auth.Authenticate, nil
+	}
+	return downloadURL, nil, nil
+}
+
+// grabbedRelease is a release a download client accepted
+type grabbedRelease struct {
+	client     db.DownloadClient
+	externalID string
+	infoHash   string
+	category   string
+}
+
+// grabRelease hands a release to the first of clients that accepts it. Clients whose
+// circuit is open after repeated failures are skipped, and a client that fails is
+// recorded against its circuit before the next one is tried, so one unreachable client
+// doesn't fail the grab.
+//
+// The torrent or NZB is fetched here, once, with the indexer's credentials when it needs
+// them, and handed to the client as a file: clients can't fetch links that need an
+// indexer's session, and a torrent's info hash is then known even for clients that track
+// downloads by their own IDs.
+func (s *Server) grabRelease(ctx context.Context, clients []db.DownloadClient, mediaType, downloadURL string, authenticate downloader.Authenticate) (*grabbedRelease, error) {
+	var (
+		torrentFile *downloader.TorrentFile
+		nzbFile     *downloader.NZBFile
+		lastErr     error
+	)
+	for _, dc := range clients {
+		client, err := s.newDownloadClient(dc)
+		if err != nil {
+			downloadsLog.Warn("grabRelease: could not create download client", "client", dc.Name, "error", err)
+			lastErr = fmt.Errorf("%s: %w", dc.Name, err)
+			continue
+		}
+
+		opts := &downloader.DownloadOptions{}
+		opts.Category, opts.SavePath = downloadClientTarget(dc, mediaType)
+		switch downloader.ClientProtocol(dc.Type) {
+		case "torrent":
+			if torrentFile == nil {
+				if torrentFile, err = downloader.ResolveTorrent(ctx, downloadURL, authenticate); err != nil {
+					return nil, err
+				}
+			}
+			opts.Torrent = torrentFile
+		case "usenet":
+			if nzbFile == nil {
+				if nzbFile, err = downloader.FetchNZB(ctx, downloadURL, authenticate); err != nil {
+					return nil, err
+				}
+			}
+			opts.NZB = nzbFile
+		}
+
+		circuit := s.health.Circuit(downloader.CircuitName(dc.Name))
+		if err := circuit.Allow(); err != nil {
+			downloadsLog.Warn("grabRelease: skipping download client", "client", dc.Name, "error", err)
+			lastErr = fmt.Errorf("%s: %w", dc.Name, err)
+			continue
+		}
+
+		externalID, err := client.AddDownload(ctx, downloadURL, opts)
+		circuit.Record(err)
+		if err != nil {
+			downloadsLog.Warn("grabRelease: download client failed", "client", dc.Name, "error", err)
+			lastErr = fmt.Errorf("%s: %w", dc.Name, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		grabbed := &grabbedRelease{client: dc, externalID: externalID, category: opts.Category}
+		if opts.Torrent != nil {
+			grabbed.infoHash = opts.Torrent.InfoHash
+		}
+		return grabbed, nil
+	}
+	return nil, lastErr
+}
+
+// downloadClientTarget returns the category and save path a client should use for a
+// media type. Unset values fall back to the client's category and default folder.
+func downloadClientTarget(dc db.DownloadClient, mediaType string) (category, savePath string) {
+	category, savePath = dc.EbookCategory, dc.EbookSavePath
+	if mediaType == "audiobook" {
+		category, savePath = dc.AudiobookCategory, dc.AudiobookSavePath
+	}
+	if category == "" {
+		category = dc.Category
+	}
+	return category, savePath
+}
+
+// newDownloadClient creates the client for a configured download client. Direct clients
+// download into their folder, or the downloads folder when none is set, and take their
+// concurrency limit from the settings.
+func (s *Server) newDownloadClient(dc db.DownloadClient) (downloader.Client, error) {
+	if dc.Type != clientTypeDirect {
+		return downloader.CreateClientFromDB(dc.Type, dc.URL, dc.Username, dc.Password)
+	}
+
+	var settings struct {
+		MaxConcurrent int `json:"maxConcurrent"`
+	}
+	if dc.Settings != "" {
+		json.Unmarshal([]byte(dc.Settings), &settings)
+	}
+	folder := dc.URL
+	if folder == "" {
+		folder = s.config.DownloadsPath
+	}
+	return downloader.NewDirectClient(folder, settings.MaxConcurrent), nil
+}
+
 // calculateScore calculates a quality score for a search result
 func calculateScore(r *indexer.SearchResult) int {
 	score := 0
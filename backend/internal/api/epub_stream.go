@@ -0,0 +1,154 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// EPUB streaming. The web reader renders an EPUB a resource at a time rather than
+// downloading the whole book first: it reads the manifest and reading order, then fetches
+// the package document, chapters, stylesheets, images and fonts from inside the EPUB as
+// it needs them. Relative links between them resolve, as the URLs mirror the paths inside
+// the EPUB.
+
+// epubResourceCSP stops a book's scripts and plugins running with the user's session
+const epubResourceCSP = "script-src 'none'; object-src 'none'"
+
+// EPUBContentsResponse is what's inside an EPUB
+type EPUBContentsResponse struct {
+	MediaFileID uint                    `json:"mediaFileId"`
+	BaseURL     string                  `json:"baseUrl"` // Resources are at baseUrl + their path
+	PackagePath string                  `json:"packagePath"`
+	Version     string                  `json:"version,omitempty"`
+	Title       string                  `json:"title,omitempty"`
+	NavPath     string                  `json:"navPath,omitempty"` // EPUB 3 table of contents
+	NCXPath     string                  `json:"ncxPath,omitempty"` // EPUB 2 table of contents
+	CoverPath   string                  `json:"coverPath,omitempty"`
+	Resources   []EPUBResourceResponse  `json:"resources"`
+	Spine       []EPUBSpineItemResponse `json:"spine"` // Reading order
+}
+
+// EPUBResourceResponse is a file inside an EPUB
+type EPUBResourceResponse struct {
+	ID         string `json:"id"`
+	Path       string `json:"path"`
+	URL        string `json:"url"`
+	MediaType  string `json:"mediaType"`
+	Properties string `json:"properties,omitempty"`
+}
+
+// EPUBSpineItemResponse is a document in an EPUB's reading order
+type EPUBSpineItemResponse struct {
+	ID     string `json:"id"`
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Linear bool   `json:"linear"` // False for auxiliary documents, like notes
+}
+
+// epubMediaFile loads the EPUB media file named by the :id parameter, if the user can see it
+func (s *Server) epubMediaFile(c echo.Context) (*db.MediaFile, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var file db.MediaFile
+	if err := s.db.First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+	if !strings.EqualFold(filepath.Ext(file.FilePath), ".epub") {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "Only EPUB files can be streamed a resource at a time"})
+	}
+	return &file, nil
+}
+
+// epubResourceURL returns the URL a file inside an EPUB media file is served at
+func epubResourceURL(base, p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return base + strings.Join(segments, "/")
+}
+
+// getEPUBContents returns an EPUB's manifest and reading order, with the URLs of its resources
+func (s *Server) getEPUBContents(c echo.Context) error {
+	file, err := s.epubMediaFile(c)
+	if file == nil {
+		return err
+	}
+
+	contents, err := media.ReadEPUBContents(file.FilePath)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Failed to read EPUB: " + err.Error()})
+	}
+
+	base := fmt.Sprintf("%s/api/v1/mediafiles/%d/epub/", currentURLBase(), file.ID)
+	response := EPUBContentsResponse{
+		MediaFileID: file.ID,
+		BaseURL:     base,
+		PackagePath: contents.PackagePath,
+		Version:     contents.Version,
+		Title:       contents.Title,
+		NavPath:     contents.NavPath,
+		NCXPath:     contents.NCXPath,
+		CoverPath:   contents.CoverPath,
+		Resources:   make([]EPUBResourceResponse, len(contents.Resources)),
+		Spine:       make([]EPUBSpineItemResponse, len(contents.Spine)),
+	}
+	for i, resource := range contents.Resources {
+		response.Resources[i] = EPUBResourceResponse{
+			ID:         resource.ID,
+			Path:       resource.Path,
+			URL:        epubResourceURL(base, resource.Path),
+			MediaType:  resource.MediaType,
+			Properties: resource.Properties,
+		}
+	}
+	for i, item := range contents.Spine {
+		response.Spine[i] = EPUBSpineItemResponse{
+			ID:     item.ID,
+			Path:   item.Path,
+			URL:    epubResourceURL(base, item.Path),
+			Linear: item.Linear,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// streamEPUBResource serves one file from inside an EPUB, such as its package document, a
+// chapter, a stylesheet or an image, with its media type
+func (s *Server) streamEPUBResource(c echo.Context) error {
+	file, err := s.epubMediaFile(c)
+	if file == nil {
+		return err
+	}
+
+	name := c.Param("*")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	entry, err := media.OpenEPUBEntry(file.FilePath, name)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Resource not found in EPUB"})
+	}
+	defer entry.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentLength, strconv.FormatInt(entry.Size, 10))
+	header.Set("Cache-Control", "private, max-age=86400")
+	header.Set("Content-Security-Policy", epubResourceCSP)
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	if !entry.Modified.IsZero() {
+		header.Set(echo.HeaderLastModified, entry.Modified.UTC().Format(http.TimeFormat))
+	}
+	return c.Stream(http.StatusOK, entry.MediaType, entry)
+}
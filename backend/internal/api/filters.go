@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// SavedFilterRequest creates or updates a saved filter
+type SavedFilterRequest struct {
+	Name  string         `json:"name" validate:"max=100"`
+	Rules db.FilterRules `json:"rules"`
+}
+
+// SavedFilterResponse represents a saved filter in API responses
+type SavedFilterResponse struct {
+	ID    uint           `json:"id"`
+	Name  string         `json:"name"`
+	Rules db.FilterRules `json:"rules"`
+}
+
+func toSavedFilterResponse(filter db.SavedFilter) SavedFilterResponse {
+	rules, _ := db.ParseFilterRules(filter.Rules)
+	if rules.Rules == nil {
+		rules.Rules = []db.FilterRule{}
+	}
+	return SavedFilterResponse{ID: filter.ID, Name: filter.Name, Rules: rules}
+}
+
+// getSavedFilters returns the current user's saved filters
+func (s *Server) getSavedFilters(c echo.Context) error {
+	var filters []db.SavedFilter
+	if err := s.db.Where("user_id = ?", currentUserID(c)).Order("name ASC").Find(&filters).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]SavedFilterResponse, len(filters))
+	for i, filter := range filters {
+		responses[i] = toSavedFilterResponse(filter)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// createSavedFilter saves a filter for the current user
+func (s *Server) createSavedFilter(c echo.Context) error {
+	var req SavedFilterRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	rules, err := validateSavedFilterRequest(&req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	filter := db.SavedFilter{UserID: currentUserID(c), Name: req.Name, Rules: rules}
+	if err := s.db.Create(&filter).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create filter"})
+	}
+	return c.JSON(http.StatusCreated, toSavedFilterResponse(filter))
+}
+
+// updateSavedFilter replaces one of the current user's saved filters
+func (s *Server) updateSavedFilter(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var filter db.SavedFilter
+	if err := s.db.Where("user_id = ?", currentUserID(c)).First(&filter, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Filter not found"})
+	}
+
+	var req SavedFilterRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	rules, err := validateSavedFilterRequest(&req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	filter.Name = req.Name
+	filter.Rules = rules
+	if err := s.db.Save(&filter).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update filter"})
+	}
+	return c.JSON(http.StatusOK, toSavedFilterResponse(filter))
+}
+
+// deleteSavedFilter removes one of the current user's saved filters
+func (s *Server) deleteSavedFilter(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	if err := s.db.Where("user_id = ?", currentUserID(c)).Delete(&db.SavedFilter{}, id).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete filter"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// validateSavedFilterRequest checks a filter request and returns its rules as JSON
+func validateSavedFilterRequest(req *SavedFilterRequest) (string, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if err := req.Rules.Validate(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(req.Rules)
+	return string(data), err
+}
+
+// savedFilterScope returns the scope for the current user's saved filter named by the
+// filterId query parameter, or a scope that changes nothing when there isn't one. It
+// reports false if the filter doesn't exist.
+func (s *Server) savedFilterScope(c echo.Context) (func(*gorm.DB) *gorm.DB, bool) {
+	param := c.QueryParam("filterId")
+	if param == "" {
+		return db.FilterRules{}.Scope(), true
+	}
+	id, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+
+	var filter db.SavedFilter
+	if err := s.db.Where("user_id = ?", currentUserID(c)).First(&filter, id).Error; err != nil {
+		return nil, false
+	}
+	rules, err := db.ParseFilterRules(filter.Rules)
+	if err != nil {
+		return nil, false
+	}
+	return rules.Scope(), true
+}
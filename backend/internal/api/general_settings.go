@@ -1,11 +1,17 @@
 package api
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/logging"
 )
 
 // GeneralSettingsResponse represents the general application settings
@@ -15,6 +21,13 @@ type GeneralSettingsResponse struct {
 	PreferredLanguages []string `json:"preferredLanguages"`
 	StartPage          string   `json:"startPage"`
 	DateFormat         string   `json:"dateFormat"`
+	CheckForUpdates    bool     `json:"checkForUpdates"` // Check GitHub for new releases twice a day
+	URLBase            string   `json:"urlBase"`         // Path Shelfarr is served under, such as /shelfarr
+	URLBaseFromEnv     bool     `json:"urlBaseFromEnv"`  // Set by SHELFARR_URL_BASE, so it can't be changed here
+	LogSettings
+	TLSSettings
+	SecuritySettings
+	CORSSettings
 }
 
 // GeneralSettingsRequest represents the request body for updating general settings
@@ -24,6 +37,30 @@ type GeneralSettingsRequest struct {
 	PreferredLanguages []string `json:"preferredLanguages,omitempty"`
 	StartPage          *string  `json:"startPage,omitempty"`
 	DateFormat         *string  `json:"dateFormat,omitempty"`
+	CheckForUpdates    *bool    `json:"checkForUpdates,omitempty"`
+	URLBase            *string  `json:"urlBase,omitempty"`
+
+	LogLevel           *string           `json:"logLevel,omitempty"`
+	LogComponentLevels map[string]string `json:"logComponentLevels,omitempty"`
+	LogToFile          *bool             `json:"logToFile,omitempty"`
+	LogMaxSizeMB       *int              `json:"logMaxSizeMb,omitempty" validate:"min=0"`
+	LogMaxFiles        *int              `json:"logMaxFiles,omitempty" validate:"min=0"`
+	LogMaxAgeDays      *int              `json:"logMaxAgeDays,omitempty" validate:"min=0"`
+
+	HTTPSEnabled    *bool   `json:"httpsEnabled,omitempty"`
+	HTTPSListenAddr *string `json:"httpsListenAddr,omitempty"`
+	HTTPSCertPath   *string `json:"httpsCertPath,omitempty"`
+	HTTPSKeyPath    *string `json:"httpsKeyPath,omitempty"`
+	HTTPSRedirect   *bool   `json:"httpsRedirect,omitempty"`
+
+	RateLimitEnabled    *bool `json:"rateLimitEnabled,omitempty"`
+	RateLimitPerMinute  *int  `json:"rateLimitPerMinute,omitempty" validate:"min=0"`
+	RateLimitBurst      *int  `json:"rateLimitBurst,omitempty" validate:"min=0"`
+	LoginMaxFailures    *int  `json:"loginMaxFailures,omitempty" validate:"min=0"`
+	LoginLockoutMinutes *int  `json:"loginLockoutMinutes,omitempty" validate:"min=0"`
+
+	CORSAllowedOrigins   []string `json:"corsAllowedOrigins,omitempty"`
+	CORSAllowCredentials *bool    `json:"corsAllowCredentials,omitempty"`
 }
 
 // LanguageOption represents a selectable language
@@ -41,6 +78,7 @@ func (s *Server) getGeneralSettings(c echo.Context) error {
 		PreferredLanguages: []string{"en"},
 		StartPage:          "library",
 		DateFormat:         "MMMM d, yyyy",
+		CheckForUpdates:    true,
 	}
 
 	// Load settings from database
@@ -61,17 +99,58 @@ func (s *Server) getGeneralSettings(c echo.Context) error {
 			settings.StartPage = setting.Value
 		case "general_date_format":
 			settings.DateFormat = setting.Value
+		case "general_check_for_updates":
+			settings.CheckForUpdates = setting.Value != "false"
 		}
 	}
 
+	_, settings.URLBaseFromEnv = s.urlBaseSetting()
+	settings.URLBase = currentURLBase()
+	settings.LogSettings = s.logSettings()
+	settings.TLSSettings = s.tlsSettings()
+	settings.SecuritySettings = s.securitySettings()
+	settings.CORSSettings = s.corsSettings()
+
 	return c.JSON(http.StatusOK, settings)
 }
 
 // updateGeneralSettings updates general settings
 func (s *Server) updateGeneralSettings(c echo.Context) error {
 	var req GeneralSettingsRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if req.LogLevel != nil {
+		if _, err := logging.ParseLevel(*req.LogLevel); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.HTTPSEnabled != nil || req.HTTPSListenAddr != nil || req.HTTPSCertPath != nil || req.HTTPSKeyPath != nil || req.HTTPSRedirect != nil {
+		if err := s.updateTLSSettings(req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.CORSAllowedOrigins != nil || req.CORSAllowCredentials != nil {
+		if err := s.updateCORSSettings(req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.URLBase != nil {
+		base, err := normalizeURLBase(*req.URLBase)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		setting := db.Setting{Key: "general_url_base", Value: base}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+		s.applyURLBase()
+	}
+	if req.LogComponentLevels != nil {
+		if err := logging.SetComponentLevels(req.LogComponentLevels); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		value, _ := json.Marshal(req.LogComponentLevels)
+		setting := db.Setting{Key: "general_log_component_levels", Value: string(value)}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
 	}
 
 	// Update string settings that are provided
@@ -96,40 +175,156 @@ func (s *Server) updateGeneralSettings(c echo.Context) error {
 		s.db.Where("key = ?", "general_preferred_languages").Assign(setting).FirstOrCreate(&setting)
 	}
 
+	if req.CheckForUpdates != nil {
+		setting := db.Setting{Key: "general_check_for_updates", Value: strconv.FormatBool(*req.CheckForUpdates)}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+	}
+
+	if req.LogLevel != nil {
+		logging.SetLevel(*req.LogLevel)
+		setting := db.Setting{Key: "general_log_level", Value: logging.Level()}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+	}
+
+	if req.LogToFile != nil || req.LogMaxSizeMB != nil || req.LogMaxFiles != nil || req.LogMaxAgeDays != nil {
+		values := map[string]string{}
+		if req.LogToFile != nil {
+			values["general_log_to_file"] = strconv.FormatBool(*req.LogToFile)
+		}
+		if req.LogMaxSizeMB != nil {
+			values["general_log_max_size_mb"] = strconv.Itoa(*req.LogMaxSizeMB)
+		}
+		if req.LogMaxFiles != nil {
+			values["general_log_max_files"] = strconv.Itoa(*req.LogMaxFiles)
+		}
+		if req.LogMaxAgeDays != nil {
+			values["general_log_max_age_days"] = strconv.Itoa(*req.LogMaxAgeDays)
+		}
+		for key, value := range values {
+			setting := db.Setting{Key: key, Value: value}
+			s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+		}
+		s.applyLogFile(s.logSettings())
+	}
+
+	security := map[string]*int{
+		"general_security_rate_limit_per_minute": req.RateLimitPerMinute,
+		"general_security_rate_limit_burst":      req.RateLimitBurst,
+		"general_security_login_max_failures":    req.LoginMaxFailures,
+		"general_security_login_lockout_minutes": req.LoginLockoutMinutes,
+	}
+	changed := req.RateLimitEnabled != nil
+	if req.RateLimitEnabled != nil {
+		setting := db.Setting{Key: "general_security_rate_limit_enabled", Value: strconv.FormatBool(*req.RateLimitEnabled)}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+	}
+	for key, n := range security {
+		if n != nil {
+			setting := db.Setting{Key: key, Value: strconv.Itoa(*n)}
+			s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+			changed = true
+		}
+	}
+	if changed {
+		s.applySecuritySettings()
+	}
+
 	return c.JSON(http.StatusOK, map[string]string{"message": "Settings updated"})
 }
 
+// updateTLSSettings checks and saves the HTTPS options, which take effect on restart
+func (s *Server) updateTLSSettings(req GeneralSettingsRequest) error {
+	settings := s.tlsSettings()
+	if req.HTTPSEnabled != nil {
+		settings.Enabled = *req.HTTPSEnabled
+	}
+	if req.HTTPSListenAddr != nil {
+		settings.ListenAddr = strings.TrimSpace(*req.HTTPSListenAddr)
+	}
+	if req.HTTPSCertPath != nil {
+		settings.CertPath = strings.TrimSpace(*req.HTTPSCertPath)
+	}
+	if req.HTTPSKeyPath != nil {
+		settings.KeyPath = strings.TrimSpace(*req.HTTPSKeyPath)
+	}
+	if req.HTTPSRedirect != nil {
+		settings.RedirectHTTP = *req.HTTPSRedirect
+	}
+
+	if settings.Enabled {
+		if _, _, err := net.SplitHostPort(settings.ListenAddr); err != nil {
+			return fmt.Errorf("HTTPS address must be a host and port, such as :8443")
+		}
+		if settings.ListenAddr == s.config.ListenAddr {
+			return fmt.Errorf("HTTPS needs a different port from HTTP (%s)", s.config.ListenAddr)
+		}
+		if (settings.CertPath == "") != (settings.KeyPath == "") {
+			return fmt.Errorf("set both the certificate and key, or neither for a self-signed certificate")
+		}
+		if settings.CertPath != "" {
+			if _, err := tls.LoadX509KeyPair(settings.CertPath, settings.KeyPath); err != nil {
+				return fmt.Errorf("failed to load certificate: %w", err)
+			}
+		}
+	}
+
+	values := map[string]string{
+		"general_https_enabled":     strconv.FormatBool(settings.Enabled),
+		"general_https_listen_addr": settings.ListenAddr,
+		"general_https_cert_path":   settings.CertPath,
+		"general_https_key_path":    settings.KeyPath,
+		"general_https_redirect":    strconv.FormatBool(settings.RedirectHTTP),
+	}
+	for key, value := range values {
+		setting := db.Setting{Key: key, Value: value}
+		s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+	}
+	return nil
+}
+
+// availableLanguages are the languages offered as preferred languages
+var availableLanguages = []LanguageOption{
+	{Code: "en", Name: "English"},
+	{Code: "es", Name: "Spanish"},
+	{Code: "fr", Name: "French"},
+	{Code: "de", Name: "German"},
+	{Code: "it", Name: "Italian"},
+	{Code: "pt", Name: "Portuguese"},
+	{Code: "nl", Name: "Dutch"},
+	{Code: "ru", Name: "Russian"},
+	{Code: "ja", Name: "Japanese"},
+	{Code: "zh", Name: "Chinese"},
+	{Code: "ko", Name: "Korean"},
+	{Code: "ar", Name: "Arabic"},
+	{Code: "hi", Name: "Hindi"},
+	{Code: "pl", Name: "Polish"},
+	{Code: "sv", Name: "Swedish"},
+	{Code: "da", Name: "Danish"},
+	{Code: "no", Name: "Norwegian"},
+	{Code: "fi", Name: "Finnish"},
+	{Code: "tr", Name: "Turkish"},
+	{Code: "cs", Name: "Czech"},
+	{Code: "hu", Name: "Hungarian"},
+	{Code: "el", Name: "Greek"},
+	{Code: "he", Name: "Hebrew"},
+	{Code: "th", Name: "Thai"},
+	{Code: "vi", Name: "Vietnamese"},
+}
+
 // getAvailableLanguages returns all available language options
 func (s *Server) getAvailableLanguages(c echo.Context) error {
-	languages := []LanguageOption{
-		{Code: "en", Name: "English"},
-		{Code: "es", Name: "Spanish"},
-		{Code: "fr", Name: "French"},
-		{Code: "de", Name: "German"},
-		{Code: "it", Name: "Italian"},
-		{Code: "pt", Name: "Portuguese"},
-		{Code: "nl", Name: "Dutch"},
-		{Code: "ru", Name: "Russian"},
-		{Code: "ja", Name: "Japanese"},
-		{Code: "zh", Name: "Chinese"},
-		{Code: "ko", Name: "Korean"},
-		{Code: "ar", Name: "Arabic"},
-		{Code: "hi", Name: "Hindi"},
-		{Code: "pl", Name: "Polish"},
-		{Code: "sv", Name: "Swedish"},
-		{Code: "da", Name: "Danish"},
-		{Code: "no", Name: "Norwegian"},
-		{Code: "fi", Name: "Finnish"},
-		{Code: "tr", Name: "Turkish"},
-		{Code: "cs", Name: "Czech"},
-		{Code: "hu", Name: "Hungarian"},
-		{Code: "el", Name: "Greek"},
-		{Code: "he", Name: "Hebrew"},
-		{Code: "th", Name: "Thai"},
-		{Code: "vi", Name: "Vietnamese"},
-	}
-
-	return c.JSON(http.StatusOK, languages)
+	return c.JSON(http.StatusOK, availableLanguages)
+}
+
+// languageName returns the English name of an ISO 639-1 language code, or "" if it
+// isn't one of the available languages
+func languageName(code string) string {
+	for _, language := range availableLanguages {
+		if language.Code == code {
+			return language.Name
+		}
+	}
+	return ""
 }
 
 // GetPreferredLanguages is a helper function to get the user's preferred languages
@@ -1,11 +1,13 @@
 package api
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/downloader"
 	"github.com/shelfarr/shelfarr/internal/media"
 )
 
@@ -24,15 +27,24 @@ import (
 // DownloadClientResponse is the API response format for download clients
 type DownloadClientResponse struct {
 	ID       uint   `json:"id"`
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	URL      string `json:"url"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Type     string `json:"type" validate:"required,oneof=qbittorrent transmission deluge rtorrent sabnzbd nzbget direct aria2 synology"`
+	URL      string `json:"url" validate:"required_unless=type direct"`
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Category string `json:"category"`
-	Priority int    `json:"priority"`
+	Priority int    `json:"priority" validate:"min=0"`
 	Enabled  bool   `json:"enabled"`
 	Settings string `json:"settings"`
+
+	EbookCategory     string `json:"ebookCategory"`
+	AudiobookCategory string `json:"audiobookCategory"`
+	EbookSavePath     string `json:"ebookSavePath"`
+	AudiobookSavePath string `json:"audiobookSavePath"`
+
+	SeedRatio         float64 `json:"seedRatio" validate:"min=0"`
+	SeedTime          int     `json:"seedTime" validate:"min=0"` // Minutes
+	DeleteSeededFiles bool    `json:"deleteSeededFiles"`
 }
 
 func toDownloadClientResponse(c db.DownloadClient) DownloadClientResponse {
@@ -47,6 +59,15 @@ func toDownloadClientResponse(c db.DownloadClient) DownloadClientResponse {
 		Priority: c.Priority,
 		Enabled:  c.Enabled,
 		Settings: c.Settings,
+
+		EbookCategory:     c.EbookCategory,
+		AudiobookCategory: c.AudiobookCategory,
+		EbookSavePath:     c.EbookSavePath,
+		AudiobookSavePath: c.AudiobookSavePath,
+
+		SeedRatio:         c.SeedRatio,
+		SeedTime:          c.SeedTime,
+		DeleteSeededFiles: c.DeleteSeededFiles,
 	}
 }
 
@@ -68,8 +89,8 @@ func (s *Server) getDownloadClients(c echo.Context) error {
 // addDownloadClient creates a new download client
 func (s *Server) addDownloadClient(c echo.Context) error {
 	var req DownloadClientResponse
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	client := db.DownloadClient{
@@ -82,6 +103,15 @@ func (s *Server) addDownloadClient(c echo.Context) error {
 		Priority: req.Priority,
 		Enabled:  req.Enabled,
 		Settings: req.Settings,
+
+		EbookCategory:     req.EbookCategory,
+		AudiobookCategory: req.AudiobookCategory,
+		EbookSavePath:     req.EbookSavePath,
+		AudiobookSavePath: req.AudiobookSavePath,
+
+		SeedRatio:         req.SeedRatio,
+		SeedTime:          req.SeedTime,
+		DeleteSeededFiles: req.DeleteSeededFiles,
 	}
 
 	if err := s.db.Create(&client).Error; err != nil {
@@ -104,8 +134,8 @@ func (s *Server) updateDownloadClient(c echo.Context) error {
 	}
 
 	var req DownloadClientResponse
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	// Update fields
@@ -118,6 +148,13 @@ func (s *Server) updateDownloadClient(c echo.Context) error {
 	client.Priority = req.Priority
 	client.Enabled = req.Enabled
 	client.Settings = req.Settings
+	client.EbookCategory = req.EbookCategory
+	client.AudiobookCategory = req.AudiobookCategory
+	client.EbookSavePath = req.EbookSavePath
+	client.AudiobookSavePath = req.AudiobookSavePath
+	client.SeedRatio = req.SeedRatio
+	client.SeedTime = req.SeedTime
+	client.DeleteSeededFiles = req.DeleteSeededFiles
 
 	if err := s.db.Save(&client).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update download client"})
@@ -152,7 +189,14 @@ func (s *Server) testDownloadClient(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Download client not found"})
 	}
 
-	testErr := testDownloadClientConnection(client.Type, client.URL, client.Username, client.Password)
+	var testErr error
+	if client.Type == clientTypeDirect {
+		// Direct clients without a folder use the downloads folder
+		direct, _ := s.newDownloadClient(client)
+		testErr = direct.Test(c.Request().Context())
+	} else {
+		testErr = testDownloadClientConnection(client.Type, client.URL, client.Username, client.Password)
+	}
 	if testErr != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": testErr.Error()})
 	}
@@ -163,18 +207,14 @@ func (s *Server) testDownloadClient(c echo.Context) error {
 // testDownloadClientConfig tests connectivity without saving the client first
 func (s *Server) testDownloadClientConfig(c echo.Context) error {
 	var req struct {
-		Type     string `json:"type"`
-		URL      string `json:"url"`
+		Type     string `json:"type" validate:"required"`
+		URL      string `json:"url" validate:"required"`
 		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.URL == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URL is required"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	testErr := testDownloadClientConnection(req.Type, req.URL, req.Username, req.Password)
@@ -200,6 +240,12 @@ func testDownloadClientConnection(clientType, url, username, password string) er
 		return testSABnzbd(url, password)
 	case "nzbget":
 		return testNZBGet(url, username, password)
+	case "direct":
+		return downloader.NewDirectClient(url, 0).Test(context.Background())
+	case "aria2":
+		return downloader.NewAria2Client(url, password).Test(context.Background())
+	case "synology":
+		return downloader.NewSynologyClient(url, username, password).Test(context.Background())
 	default:
 		return fmt.Errorf("unknown client type: %s", clientType)
 	}
@@ -402,9 +448,14 @@ func (s *Server) deleteMediaFile(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
 	}
 
-	// Soft delete - moves to recycle bin
-	if err := s.db.Delete(&db.MediaFile{}, id).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete media file"})
+	var file db.MediaFile
+	if err := s.db.First(&file, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+
+	// Moves the file to the recycle bin, if enabled
+	if err := s.removeMediaFile(&file); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete media file: " + err.Error()})
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -418,7 +469,8 @@ func (s *Server) deleteMediaFile(c echo.Context) error {
 func (s *Server) getMediaFiles(c echo.Context) error {
 	var files []db.MediaFile
 
-	query := s.db.Preload("Book")
+	query := s.db.Preload("Book").
+		Where("book_id IN (?)", s.db.Model(&db.Book{}).Select("books.id").Scopes(s.visibleBooks(c)))
 
 	if bookID := c.QueryParam("bookId"); bookID != "" {
 		query = query.Where("book_id = ?", bookID)
@@ -442,34 +494,84 @@ func (s *Server) streamMediaFile(c echo.Context) error {
 	}
 
 	var file db.MediaFile
-	if err := s.db.First(&file, id).Error; err != nil {
+	if err := s.db.First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
 	}
 
-	// Serve the file
+	// Kobo readers want KEPUB; convert EPUBs on the fly (cached until the source changes)
+	if strings.EqualFold(c.QueryParam("format"), "kepub") {
+		if !strings.EqualFold(filepath.Ext(file.FilePath), ".epub") {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only EPUB files can be converted to KEPUB"})
+		}
+		kepubPath, err := s.cachedKepub(file)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.Attachment(kepubPath, media.KepubFileName(file.FilePath))
+	}
+
+	// Players that can't play the file ask for it transcoded, such as browsers without M4B
+	if transcode := strings.ToLower(c.QueryParam("transcode")); transcode != "" {
+		return s.streamTranscoded(c, file, transcode)
+	}
+
+	// Serve the file, with a type readers recognize for comics and ebooks. Range requests
+	// get the part asked for, so audio players can seek.
+	c.Response().Header().Set(echo.HeaderContentType, mediaMimeType(file.Format))
 	return c.File(file.FilePath)
 }
 
-// sendToKindle sends a book to a Kindle device via email
-func (s *Server) sendToKindle(c echo.Context) error {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+// kepubCacheMaxAge is how long a KEPUB conversion is kept after it was last used
+const kepubCacheMaxAge = 30 * 24 * time.Hour
+
+// cachedKepub returns the path of a KEPUB conversion of an EPUB media file, converting it if needed
+func (s *Server) cachedKepub(file db.MediaFile) (string, error) {
+	info, err := os.Stat(file.FilePath)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+		return "", fmt.Errorf("media file not found on disk")
 	}
 
-	var file db.MediaFile
-	if err := s.db.First(&file, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	cacheDir := filepath.Join(s.config.ConfigPath, "cache", "kepub")
+	kepubPath := filepath.Join(cacheDir, fmt.Sprintf("%d-%d.kepub.epub", file.ID, info.ModTime().Unix()))
+	if _, err := os.Stat(kepubPath); err == nil {
+		now := time.Now()
+		os.Chtimes(kepubPath, now, now) // Marks it used, for pruning
+		return kepubPath, nil
 	}
 
-	// TODO: Implement SMTP sending logic
-	// 1. Check format - convert if needed (EPUB -> MOBI)
-	// 2. Get user's Kindle email from settings
-	// 3. Send via SMTP
+	if err := media.NewKepubConverter().Convert(file.FilePath, kepubPath); err != nil {
+		return "", err
+	}
+	pruneKepubCache(cacheDir, file.ID, kepubPath)
+	return kepubPath, nil
+}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Send to Kindle queued (not yet implemented)",
-	})
+// pruneKepubCache removes the conversions of a media file's older versions, any
+// conversion not used in kepubCacheMaxAge, and temp files left by interrupted ones
+func pruneKepubCache(cacheDir string, fileID uint, keep string) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+	prefix := fmt.Sprintf("%d-", fileID)
+	for _, entry := range entries {
+		path := filepath.Join(cacheDir, entry.Name())
+		info, err := entry.Info()
+		if path == keep || entry.IsDir() || err != nil {
+			continue
+		}
+		age := time.Since(info.ModTime())
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			// Conversions in progress are left alone
+			if age > time.Hour {
+				os.Remove(path)
+			}
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), prefix) || age > kepubCacheMaxAge {
+			os.Remove(path)
+		}
+	}
 }
 
 // ========================
@@ -485,20 +587,36 @@ func (s *Server) getPendingImports(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, pending)
+	// Suggest the library books each file most likely is
+	books := s.loadLibraryBooks()
+	responses := make([]PendingImportResponse, len(pending))
+	for i, p := range pending {
+		responses[i] = PendingImportResponse{
+			PendingImport: p,
+			Candidates: s.libraryCandidates(importMatch{
+				title:  p.ExtractedTitle,
+				author: p.ExtractedAuthor,
+				series: p.ExtractedSeries,
+				isbn:   p.ExtractedISBN,
+				asin:   p.ExtractedASIN,
+			}, books),
+		}
+	}
+
+	return c.JSON(http.StatusOK, responses)
 }
 
 // manualImport manually maps a file to a book
 func (s *Server) manualImport(c echo.Context) error {
 	var req struct {
-		FilePath    string `json:"filePath"`
-		BookID      uint   `json:"bookId"`
-		MediaType   string `json:"mediaType"`
+		FilePath    string `json:"filePath" validate:"required"`
+		BookID      uint   `json:"bookId" validate:"required"`
+		MediaType   string `json:"mediaType" validate:"required,oneof=ebook audiobook"`
 		EditionName string `json:"editionName,omitempty"`
 	}
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	// Get book details for path building
@@ -507,43 +625,97 @@ func (s *Server) manualImport(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
 	}
 
+	result, err := s.importToLibrary(&book, req.FilePath, req.MediaType, req.EditionName)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":     result.Success,
+		"newPath":     result.NewPath,
+		"mediaFileId": result.MediaFileID,
+	})
+}
+
+// importToLibrary imports a downloaded file or folder for a book into the library and
+// sends the import (or failure) notification. The book must have its Author and Series loaded.
+func (s *Server) importToLibrary(book *db.Book, sourcePath, mediaType, editionName string) (*media.ImportResult, error) {
 	// Determine format from file extension
-	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(req.FilePath)), ".")
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(sourcePath)), ".")
 	if format == "" {
 		format = "unknown"
 	}
 
 	// Build import request
 	importReq := media.ImportRequest{
-		SourcePath:  req.FilePath,
-		BookID:      req.BookID,
+		SourcePath:  sourcePath,
+		BookID:      book.ID,
 		AuthorName:  book.Author.Name,
 		BookTitle:   book.Title,
-		MediaType:   req.MediaType,
+		Year:        book.ReleaseYear,
+		MediaType:   mediaType,
 		Format:      format,
-		EditionName: req.EditionName,
+		EditionName: editionName,
 	}
 
 	// Add series info if available
 	if book.Series != nil {
 		importReq.SeriesName = book.Series.Name
 		if book.SeriesIndex != nil {
-			importReq.SeriesIndex = int(*book.SeriesIndex)
+			importReq.SeriesIndex = float64(*book.SeriesIndex)
 		}
 	}
 
+	// A book that already has a file of this media type is being upgraded
+	var existing int64
+	s.db.Model(&db.MediaFile{}).Where("book_id = ? AND media_type = ?", book.ID, mediaType).Count(&existing)
+
 	// Perform import
-	importer := media.NewImporter(s.db, s.config.BooksPath, s.config.AudiobooksPath, media.OpHardlink)
+	booksPath, audiobooksPath := s.importRoots(book)
+	root := booksPath
+	if mediaType == "audiobook" {
+		root = audiobooksPath
+	}
+	importer := media.NewImporter(s.db, booksPath, audiobooksPath, s.importOperation(root))
+	importer.SetNaming(s.namingOptions())
 	result, err := importer.Import(importReq)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		s.notifier.SendNotification("failure", map[string]interface{}{
+			"title":   book.Title,
+			"author":  book.Author.Name,
+			"message": "Import failed: " + err.Error(),
+			"bookId":  book.ID,
+		})
+		return nil, err
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success":     result.Success,
-		"newPath":     result.NewPath,
-		"mediaFileId": result.MediaFileID,
+	event := "import"
+	if existing > 0 {
+		event = "upgrade"
+	}
+	if mediaType == "ebook" && format == "epub" {
+		s.embedEPUBMetadata(book.ID, result)
+	}
+	if mediaType == "audiobook" {
+		s.tagAudiobookFiles(book.ID, result)
+	}
+
+	s.notifier.SendNotification(event, map[string]interface{}{
+		"title":      book.Title,
+		"author":     book.Author.Name,
+		"format":     format,
+		"mediaType":  mediaType,
+		"bookId":     book.ID,
+		"path":       result.NewPath,
+		"sourcePath": sourcePath,
 	})
+	s.pushOwnedToHardcover(book)
+	go s.writeBookMetadataFiles(book.ID)
+	if mediaType == "audiobook" {
+		s.syncToAudiobookshelf(book, result.NewPath)
+	}
+
+	return result, nil
 }
 
 // ========================
@@ -568,8 +740,8 @@ func (s *Server) getUsers(c echo.Context) error {
 // createUser creates a new user
 func (s *Server) createUser(c echo.Context) error {
 	var user db.User
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &user); err != nil {
+		return err
 	}
 
 	// TODO: Hash password before storing
@@ -579,6 +751,7 @@ func (s *Server) createUser(c echo.Context) error {
 	}
 
 	user.PasswordHash = ""
+	setAudit(c, "user.create", "Created user "+user.Username)
 	return c.JSON(http.StatusCreated, user)
 }
 
@@ -613,13 +786,25 @@ func (s *Server) updateUser(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
 	}
 
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	// Users who aren't admins can change their own profile, but not what they're allowed
+	isAdmin := currentUserIsAdmin(c)
+	if !isAdmin && user.ID != currentUserID(c) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Only admins can change other users"})
+	}
+	stored := user
+
+	if err := bindRequest(c, &user); err != nil {
+		return err
+	}
+	if !isAdmin {
+		user.Model, user.IsAdmin, user.CanRead, user.CanDelete = stored.Model, stored.IsAdmin, stored.CanRead, stored.CanDelete
+		user.AutoApproveRequests, user.RequestQuota = stored.AutoApproveRequests, stored.RequestQuota
 	}
 
 	if err := s.db.Save(&user).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update user"})
 	}
+	setAudit(c, "user.update", "Updated user "+user.Username)
 
 	user.PasswordHash = ""
 	return c.JSON(http.StatusOK, user)
@@ -636,8 +821,7 @@ func (s *Server) getProgress(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid media file ID"})
 	}
 
-	// TODO: Get current user from auth context
-	userID := uint(1) // Placeholder
+	userID := currentUserID(c)
 
 	var progress db.ReadProgress
 	if err := s.db.Where("user_id = ? AND media_file_id = ?", userID, mediaFileID).First(&progress).Error; err != nil {
@@ -654,7 +838,8 @@ func (s *Server) getProgress(c echo.Context) error {
 	})
 }
 
-// updateProgress saves reading/listening progress
+// updateProgress saves reading/listening progress on a device, and returns where to
+// resume from across the user's devices
 func (s *Server) updateProgress(c echo.Context) error {
 	mediaFileID, err := strconv.ParseUint(c.Param("mediaFileId"), 10, 32)
 	if err != nil {
@@ -662,15 +847,36 @@ func (s *Server) updateProgress(c echo.Context) error {
 	}
 
 	var req struct {
-		Progress float32 `json:"progress"`
-		Position int     `json:"position"`
+		Progress   float32    `json:"progress" validate:"min=0,max=1"`
+		Position   int        `json:"position" validate:"min=0"`
+		Device     string     `json:"device" validate:"max=100"` // The default device if left out
+		RecordedAt *time.Time `json:"recordedAt,omitempty"`      // When the device was there, if it's syncing late
 	}
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
-	// TODO: Get current user from auth context
-	userID := uint(1) // Placeholder
+	userID := currentUserID(c)
+
+	var mediaFile db.MediaFile
+	if err := s.db.Preload("Book").First(&mediaFile, mediaFileID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+
+	update := db.DeviceProgress{
+		UserID:      userID,
+		MediaFileID: mediaFile.ID,
+		Device:      req.Device,
+		Progress:    req.Progress,
+		Position:    req.Position,
+		RecordedAt:  time.Now(),
+	}
+	if update.Device == "" {
+		update.Device = db.DefaultProgressDevice
+	}
+	if req.RecordedAt != nil && req.RecordedAt.Before(update.RecordedAt) {
+		update.RecordedAt = *req.RecordedAt
+	}
 
 	var progress db.ReadProgress
 	result := s.db.Where("user_id = ? AND media_file_id = ?", userID, mediaFileID).First(&progress)
@@ -683,14 +889,35 @@ func (s *Server) updateProgress(c echo.Context) error {
 		}
 	}
 
-	progress.Progress = req.Progress
-	progress.Position = req.Position
+	previous, saved, err := s.saveDeviceProgress(update)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save progress"})
+	}
+	if saved && mediaFile.MediaType == db.MediaTypeAudiobook {
+		// Audiobook positions are in seconds, so how far the device moved is time listened.
+		// A device new to the book picks up from where the user was.
+		if previous != nil {
+			s.recordListening(userID, mediaFile, update.Position-previous.Position, update.RecordedAt.Sub(previous.RecordedAt))
+		} else if result.Error == nil {
+			s.recordListening(userID, mediaFile, update.Position-progress.Position, update.RecordedAt.Sub(progress.LastReadAt))
+		}
+	}
+
+	strategy := s.progressStrategy(userID)
+	devices := s.deviceProgress(userID, mediaFile.ID)
+	wasFinished := progress.Progress >= 1
+	resolveProgress(&progress, devices, strategy)
+	markFinished(&progress, wasFinished)
 
 	if err := s.db.Save(&progress).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save progress"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Progress saved"})
+	if progress.Progress >= 1 && !wasFinished {
+		s.pushReadToHardcover(&mediaFile.Book)
+	}
+
+	return c.JSON(http.StatusOK, resumeResponse(devices, strategy, update.Device))
 }
 
 // ========================
@@ -705,6 +932,15 @@ func (s *Server) getSettings(c echo.Context) error {
 	if err := s.db.Where("key = ?", "hardcover_api_key").First(&hardcoverSetting).Error; err == nil {
 		hardcoverAPIKey = hardcoverSetting.Value
 	}
+	googleBooksAPIKey := s.getGoogleBooksAPIKey()
+	hardcoverLimits := s.loadHardcoverLimits()
+
+	// Kindle address is stored per user
+	kindleEmail := ""
+	var user db.User
+	if err := s.db.First(&user, currentUserID(c)).Error; err == nil {
+		kindleEmail = user.KindleEmail
+	}
 
 	settings := map[string]interface{}{
 		"general": map[string]interface{}{
@@ -716,17 +952,23 @@ func (s *Server) getSettings(c echo.Context) error {
 			"downloads":  s.config.DownloadsPath,
 		},
 		"kindle": map[string]interface{}{
-			"enabled": false,
-			"email":   "",
+			"enabled": kindleEmail != "" && loadSMTPConfig(s.db).Valid(),
+			"email":   kindleEmail,
 		},
 		"librarySearchProviders": map[string]interface{}{
 			"hardcover": map[string]interface{}{
 				"enabled":    hardcoverAPIKey != "",
 				"apiKey":     hardcoverAPIKey,
 				"apiUrl":     s.config.HardcoverAPIURL,
-				"rateLimit":  60, // requests per minute
-				"maxDepth":   3,  // max query depth
-				"maxTimeout": 30, // seconds
+				"rateLimit":  hardcoverLimits.RateLimit,  // requests per minute
+				"burst":      hardcoverLimits.Burst,      // requests allowed at once
+				"maxDepth":   3,                          // max query depth
+				"maxTimeout": hardcoverLimits.MaxTimeout, // seconds
+				"pushOwned":  s.hardcoverPushEnabled(settingHardcoverPushOwned),
+				"pushRead":   s.hardcoverPushEnabled(settingHardcoverPushRead),
+			},
+			"googlebooks": map[string]interface{}{
+				"apiKey": googleBooksAPIKey, // Optional; raises the anonymous quota
 			},
 		},
 	}
@@ -737,8 +979,8 @@ func (s *Server) getSettings(c echo.Context) error {
 // updateSettings updates application settings
 func (s *Server) updateSettings(c echo.Context) error {
 	var req map[string]interface{}
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	// Handle librarySearchProviders.hardcover.apiKey
@@ -750,6 +992,53 @@ func (s *Server) updateSettings(c echo.Context) error {
 					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save API key"})
 				}
 			}
+
+			limitKeys := map[string]string{
+				"rateLimit":  "hardcover_rate_limit",
+				"burst":      "hardcover_rate_burst",
+				"maxTimeout": "hardcover_timeout",
+			}
+			limitsChanged := false
+			for field, key := range limitKeys {
+				value, ok := hardcover[field].(float64)
+				if !ok {
+					continue
+				}
+				if value < 1 || value != float64(int(value)) {
+					return c.JSON(http.StatusBadRequest, map[string]string{"error": field + " must be a positive whole number"})
+				}
+				setting := db.Setting{Key: key, Value: strconv.Itoa(int(value))}
+				if err := s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting).Error; err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save Hardcover limits"})
+				}
+				limitsChanged = true
+			}
+			if limitsChanged {
+				s.applyHardcoverLimits()
+			}
+
+			pushKeys := map[string]string{
+				"pushOwned": settingHardcoverPushOwned,
+				"pushRead":  settingHardcoverPushRead,
+			}
+			for field, key := range pushKeys {
+				value, ok := hardcover[field].(bool)
+				if !ok {
+					continue
+				}
+				setting := db.Setting{Key: key, Value: strconv.FormatBool(value)}
+				if err := s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting).Error; err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save Hardcover sync settings"})
+				}
+			}
+		}
+		if googleBooks, ok := providers["googlebooks"].(map[string]interface{}); ok {
+			if apiKey, ok := googleBooks["apiKey"].(string); ok {
+				setting := db.Setting{Key: "google_books_api_key", Value: apiKey}
+				if err := s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting).Error; err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save API key"})
+				}
+			}
 		}
 	}
 
@@ -777,6 +1066,11 @@ func (s *Server) getProfiles(c echo.Context) error {
 				FormatRanking: "m4b,mp3",
 				MinBitrate:    64,
 			},
+			{
+				Name:          "Comics",
+				MediaType:     db.MediaTypeEbook,
+				FormatRanking: "cbz,cbr,pdf",
+			},
 		}
 		for _, p := range defaults {
 			s.db.Create(&p)
@@ -790,8 +1084,8 @@ func (s *Server) getProfiles(c echo.Context) error {
 // createProfile creates a new quality profile
 func (s *Server) createProfile(c echo.Context) error {
 	var profile db.QualityProfile
-	if err := c.Bind(&profile); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &profile); err != nil {
+		return err
 	}
 
 	if err := s.db.Create(&profile).Error; err != nil {
@@ -823,14 +1117,15 @@ func (s *Server) updateProfile(c echo.Context) error {
 	}
 
 	var updates struct {
-		Name          string `json:"name"`
-		MediaType     string `json:"mediaType"`
+		Name          string `json:"name" validate:"max=100"`
+		MediaType     string `json:"mediaType" validate:"oneof=ebook audiobook"`
 		FormatRanking string `json:"formatRanking"`
 		MinBitrate    int    `json:"minBitrate"`
+		EmbedMetadata *bool  `json:"embedMetadata"`
 	}
 
-	if err := c.Bind(&updates); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &updates); err != nil {
+		return err
 	}
 
 	if updates.Name != "" {
@@ -845,6 +1140,9 @@ func (s *Server) updateProfile(c echo.Context) error {
 	if updates.MinBitrate >= 0 {
 		profile.MinBitrate = updates.MinBitrate
 	}
+	if updates.EmbedMetadata != nil {
+		profile.EmbedMetadata = *updates.EmbedMetadata
+	}
 
 	if err := s.db.Save(&profile).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update profile"})
@@ -1,15 +1,19 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
 	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/metadata"
 )
 
 type EditionResponse struct {
@@ -73,6 +77,7 @@ type HardcoverBookResponse struct {
 	Compilation           bool                  `json:"compilation"`
 	Editions              []EditionResponse     `json:"editions,omitempty"`
 	Contributors          []ContributorResponse `json:"contributors,omitempty"`
+	Provider              string                `json:"provider,omitempty"`
 	InLibrary             bool                  `json:"inLibrary"`
 	LibraryBook           *BookResponse         `json:"libraryBook,omitempty"`
 }
@@ -101,7 +106,7 @@ type HardcoverSeriesResponse struct {
 	Books             []HardcoverBookResponse `json:"books,omitempty"`
 }
 
-// getHardcoverClient creates a Hardcover client with the configured API key
+// getHardcoverClient returns the shared Hardcover client with the configured API key
 func (s *Server) getHardcoverClient() (*hardcover.Client, error) {
 	// Get API key from database first, fallback to config
 	apiKey := s.config.HardcoverAPIKey
@@ -114,7 +119,64 @@ func (s *Server) getHardcoverClient() (*hardcover.Client, error) {
 		return nil, echo.NewHTTPError(http.StatusBadRequest, "Hardcover.app API key not configured")
 	}
 
-	return hardcover.NewClientWithAPIKey(s.config.HardcoverAPIURL, apiKey), nil
+	s.hardcover.SetAPIKey(apiKey)
+	return s.hardcover, nil
+}
+
+// hardcoverErrorStatus maps a Hardcover client error to the status returned to the caller
+func hardcoverErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, hardcover.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, hardcover.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, hardcover.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, health.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// HardcoverLimits are the configurable limits of the shared Hardcover client
+type HardcoverLimits struct {
+	RateLimit  int // Requests per minute
+	Burst      int
+	MaxTimeout int // Seconds
+}
+
+// loadHardcoverLimits reads the client limits from settings, falling back to the defaults
+func (s *Server) loadHardcoverLimits() HardcoverLimits {
+	limits := HardcoverLimits{
+		RateLimit:  hardcover.DefaultRequestsPerMinute,
+		Burst:      hardcover.DefaultBurst,
+		MaxTimeout: int(hardcover.DefaultTimeout / time.Second),
+	}
+
+	var settings []db.Setting
+	s.db.Where("key IN ?", []string{"hardcover_rate_limit", "hardcover_rate_burst", "hardcover_timeout"}).Find(&settings)
+	for _, setting := range settings {
+		value, err := strconv.Atoi(setting.Value)
+		if err != nil || value <= 0 {
+			continue
+		}
+		switch setting.Key {
+		case "hardcover_rate_limit":
+			limits.RateLimit = value
+		case "hardcover_rate_burst":
+			limits.Burst = value
+		case "hardcover_timeout":
+			limits.MaxTimeout = value
+		}
+	}
+	return limits
+}
+
+// applyHardcoverLimits configures the shared client from settings
+func (s *Server) applyHardcoverLimits() {
+	limits := s.loadHardcoverLimits()
+	s.hardcover.Configure(limits.RateLimit, limits.Burst, time.Duration(limits.MaxTimeout)*time.Second)
 }
 
 // getHardcoverBook returns detailed book info from Hardcover
@@ -124,35 +186,50 @@ func (s *Server) getHardcoverBook(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Book ID is required"})
 	}
 
-	client, err := s.getHardcoverClient()
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to initialize Hardcover client: " + err.Error(),
-		})
-	}
-
-	book, err := client.GetBook(id)
-	if err != nil {
-		// Provide more detailed error information
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "authentication failed") {
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "Hardcover API authentication failed. Please check your API key in Settings.",
+	// Books found through a fallback provider carry its name; their IDs are in its namespace
+	provider := c.QueryParam("provider")
+	var book *hardcover.BookData
+	var extraIDs map[string]string
+	if provider != "" && provider != "hardcover" {
+		fetched, err := s.getProviderBook(c.Request().Context(), provider, id)
+		if err != nil {
+			if errors.Is(err, metadata.ErrNotFound) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+			}
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch book from " + provider + ": " + err.Error()})
+		}
+		book = &fetched.BookData
+		extraIDs = fetched.Identifiers
+	} else {
+		provider = "hardcover"
+		client, err := s.getHardcoverClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to initialize Hardcover client: " + err.Error(),
 			})
 		}
-		if strings.Contains(errMsg, "book not found") {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Book not found in Hardcover database",
+
+		fetched, err := client.GetBook(c.Request().Context(), id)
+		if err != nil {
+			// Provide more detailed error information
+			switch {
+			case errors.Is(err, hardcover.ErrUnauthorized):
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Hardcover API authentication failed. Please check your API key in Settings.",
+				})
+			case errors.Is(err, hardcover.ErrNotFound):
+				return c.JSON(http.StatusNotFound, map[string]string{
+					"error": "Book not found in Hardcover database",
+				})
+			}
+			return c.JSON(hardcoverErrorStatus(err), map[string]string{
+				"error": "Failed to fetch book from Hardcover: " + err.Error(),
 			})
 		}
-		return c.JSON(http.StatusBadGateway, map[string]string{
-			"error": "Failed to fetch book from Hardcover: " + errMsg,
-		})
+		book = fetched
 	}
 
-	var libBook db.Book
-	err = s.db.Where("hardcover_id = ?", book.ID).First(&libBook).Error
-	inLibrary := err == nil
+	libBook, inLibrary := s.findLibraryBook(provider, book, extraIDs)
 
 	releaseDate := ""
 	releaseYear := book.ReleaseYear
@@ -231,11 +308,12 @@ func (s *Server) getHardcoverBook(c echo.Context) error {
 		Compilation:           book.Compilation,
 		Editions:              editions,
 		Contributors:          contributors,
+		Provider:              provider,
 		InLibrary:             inLibrary,
 	}
 
 	if inLibrary {
-		libResp := bookToResponse(libBook)
+		libResp := bookToResponse(*libBook)
 		resp.LibraryBook = &libResp
 	}
 
@@ -253,13 +331,13 @@ func (s *Server) getHardcoverAuthor(c echo.Context) error {
 		return err
 	}
 
-	author, err := client.GetAuthor(id)
+	author, err := client.GetAuthor(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch author: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch author: " + err.Error()})
 	}
 
 	languages := s.GetPreferredLanguages()
-	result, err := client.GetBooksByAuthor(id, languages)
+	result, err := client.GetBooksByAuthor(c.Request().Context(), id, languages)
 	if err != nil {
 		result = &hardcover.FilteredBooksResult{}
 	}
@@ -274,7 +352,7 @@ func (s *Server) getHardcoverAuthor(c echo.Context) error {
 			booksWithSeries++
 		}
 	}
-	log.Printf("[DEBUG] getHardcoverAuthor: Author '%s' has %d books, %d with series info", author.Name, len(result.Books), booksWithSeries)
+	metadataLog.Debug("getHardcoverAuthor: fetched books", "author", author.Name, "books", len(result.Books), "withSeries", booksWithSeries)
 
 	bookResponses := make([]HardcoverBookResponse, len(result.Books))
 	for i, book := range result.Books {
@@ -295,7 +373,7 @@ func (s *Server) getHardcoverAuthor(c echo.Context) error {
 			if book.SeriesIndex != nil {
 				seriesIdx = fmt.Sprintf("%.1f", *book.SeriesIndex)
 			}
-			log.Printf("[DEBUG] getHardcoverAuthor: Book '%s' - Series: %s (#%s)", book.Title, book.SeriesName, seriesIdx)
+			metadataLog.Debug("getHardcoverAuthor: book in series", "book", book.Title, "series", book.SeriesName, "position", seriesIdx)
 		}
 
 		resp := HardcoverBookResponse{
@@ -353,9 +431,9 @@ func (s *Server) getHardcoverSeries(c echo.Context) error {
 	}
 
 	languages := s.GetPreferredLanguages()
-	result, err := client.GetSeries(id, languages)
+	result, err := client.GetSeries(c.Request().Context(), id, languages)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch series: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch series: " + err.Error()})
 	}
 
 	var seriesCount int64
@@ -432,18 +510,20 @@ func (s *Server) addHardcoverBook(c echo.Context) error {
 		req.MediaType = "both"
 	}
 
+	provider := c.QueryParam("provider")
+	if provider == "" {
+		provider = "hardcover"
+	}
+
 	var existing db.Book
-	if err := s.db.Unscoped().Where("hardcover_id = ?", id).First(&existing).Error; err == nil {
+	if err := s.db.Unscoped().Where(providerIDColumn(provider)+" = ?", id).First(&existing).Error; err == nil {
 		if existing.DeletedAt.Valid {
-			if err := s.db.Unscoped().Model(&existing).Updates(map[string]any{
-				"deleted_at": nil,
-				"status":     db.StatusMissing,
-				"monitored":  req.Monitored,
-			}).Error; err != nil {
-				log.Printf("[ERROR] addHardcoverBook: failed to restore book: %v", err)
+			if err := s.restoreBook(&existing, req.Monitored); err != nil {
+				metadataLog.Error("addHardcoverBook: failed to restore book", "error", err)
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore book"})
 			}
-			log.Printf("[DEBUG] addHardcoverBook: restored soft-deleted book '%s' (ID: %d)", existing.Title, existing.ID)
+			metadataLog.Debug("addHardcoverBook: restored soft-deleted book", "book", existing.Title, "bookId", existing.ID)
+			setAudit(c, "book.add", "Restored "+existing.Title)
 			return c.JSON(http.StatusCreated, map[string]any{
 				"message": "Book restored to library",
 				"bookId":  existing.ID,
@@ -459,34 +539,88 @@ func (s *Server) addHardcoverBook(c echo.Context) error {
 		})
 	}
 
-	client, err := s.getHardcoverClient()
-	if err != nil {
-		return err
+	var book *hardcover.BookData
+	var extraIDs map[string]string
+	if provider != "hardcover" {
+		fetched, err := s.getProviderBook(c.Request().Context(), provider, id)
+		if err != nil {
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch book: " + err.Error()})
+		}
+		book = &fetched.BookData
+		extraIDs = fetched.Identifiers
+	} else {
+		client, err := s.getHardcoverClient()
+		if err != nil {
+			return err
+		}
+
+		fetched, err := client.GetBook(c.Request().Context(), id)
+		if err != nil {
+			return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch book: " + err.Error()})
+		}
+		book = fetched
+	}
+
+	// The same book may already be in the library under another provider's ID
+	ids := providerIdentifiers(provider, book, extraIDs)
+	if match, err := db.FindBookByIdentifiers(s.db, ids); err == nil {
+		if err := db.SaveBookIdentifiers(s.db, match.ID, ids); err != nil {
+			metadataLog.Warn("addHardcoverBook: failed to record identifiers", "bookId", match.ID, "error", err)
+		}
+		return c.JSON(http.StatusConflict, map[string]any{
+			"error":       "Book already in library",
+			"bookId":      match.ID,
+			"hardcoverId": match.HardcoverID,
+			"status":      match.Status,
+			"authorId":    match.AuthorID,
+			"seriesId":    match.SeriesID,
+		})
 	}
 
-	book, err := client.GetBook(id)
+	newBook, err := s.createBook(provider, book, ids, req.Monitored, req.ForceAuthorID, req.ForceSeriesID)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch book: " + err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add book"})
 	}
+	setAudit(c, "book.add", "Added "+newBook.Title)
+
+	return c.JSON(http.StatusCreated, map[string]any{
+		"message": "Book added to library",
+		"bookId":  newBook.ID,
+	})
+}
 
+// restoreBook brings a soft-deleted book back into the library as missing
+func (s *Server) restoreBook(book *db.Book, monitored bool) error {
+	return s.db.Unscoped().Model(book).Updates(map[string]any{
+		"deleted_at": nil,
+		"status":     db.StatusMissing,
+		"monitored":  monitored,
+	}).Error
+}
+
+// createBook adds a provider's book record to the library, creating its author and
+// series as needed. Forced author and series IDs override the provider's when they exist.
+func (s *Server) createBook(provider string, book *hardcover.BookData, ids db.BookIdentifiers, monitored bool, forceAuthorID, forceSeriesID uint) (*db.Book, error) {
 	var authorID uint
-	if req.ForceAuthorID > 0 {
+	if forceAuthorID > 0 {
 		var forcedAuthor db.Author
-		if err := s.db.First(&forcedAuthor, req.ForceAuthorID).Error; err == nil {
+		if err := s.db.First(&forcedAuthor, forceAuthorID).Error; err == nil {
 			authorID = forcedAuthor.ID
-			log.Printf("[DEBUG] addHardcoverBook: using forced author ID %d for book '%s'", authorID, book.Title)
+			metadataLog.Debug("createBook: using forced author", "authorId", authorID, "book", book.Title)
 		}
 	}
-	if authorID == 0 && book.AuthorID != "" {
+	if authorID == 0 && provider != "hardcover" && book.AuthorName != "" {
+		authorID = s.getOrCreateAuthorByName(book)
+	} else if authorID == 0 && book.AuthorID != "" {
 		authorID = s.getOrCreateAuthor(book)
 	}
 
 	var seriesID *uint
-	if req.ForceSeriesID > 0 {
+	if forceSeriesID > 0 {
 		var forcedSeries db.Series
-		if err := s.db.First(&forcedSeries, req.ForceSeriesID).Error; err == nil {
+		if err := s.db.First(&forcedSeries, forceSeriesID).Error; err == nil {
 			seriesID = &forcedSeries.ID
-			log.Printf("[DEBUG] addHardcoverBook: using forced series ID %d for book '%s'", *seriesID, book.Title)
+			metadataLog.Debug("createBook: using forced series", "seriesId", *seriesID, "book", book.Title)
 		}
 	}
 	if seriesID == nil && book.SeriesID != "" {
@@ -495,7 +629,6 @@ func (s *Server) addHardcoverBook(c echo.Context) error {
 
 	now := timeNow()
 	newBook := db.Book{
-		HardcoverID:           book.ID,
 		Title:                 book.Title,
 		SortTitle:             book.SortTitle,
 		Subtitle:              book.Subtitle,
@@ -528,55 +661,109 @@ func (s *Server) addHardcoverBook(c echo.Context) error {
 		SeriesID:              seriesID,
 		SeriesIndex:           book.SeriesIndex,
 		Status:                db.StatusMissing,
-		Monitored:             req.Monitored,
+		Monitored:             monitored,
 		LastSyncedAt:          &now,
 	}
+	setProviderID(&newBook, provider, book.ID)
 
 	if err := s.db.Create(&newBook).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add book"})
+		return nil, err
 	}
+	s.covers.Queue(newBook.ID, newBook.CoverURL)
 
 	s.syncGenres(&newBook, book.Genres)
 	s.syncEditions(&newBook, book)
 	s.syncContributors(&newBook, book)
+	s.syncIdentifiers(&newBook, ids)
 
-	return c.JSON(http.StatusCreated, map[string]any{
-		"message": "Book added to library",
-		"bookId":  newBook.ID,
-	})
+	return &newBook, nil
 }
 
 func timeNow() time.Time {
 	return time.Now()
 }
 
+// getProviderBook fetches a book from a non-Hardcover metadata provider and fills its
+// gaps from the other enabled providers
+func (s *Server) getProviderBook(ctx context.Context, provider, id string) (*metadata.Book, error) {
+	book, err := s.metadata.GetBook(ctx, provider, id)
+	if err != nil {
+		return nil, err
+	}
+	s.metadata.Enrich(ctx, book)
+	return book, nil
+}
+
+// providerIDColumn returns the books column holding a metadata provider's ID
+func providerIDColumn(provider string) string {
+	switch provider {
+	case "openlibrary":
+		return "open_library_work_id"
+	case "googlebooks":
+		return "google_volume_id"
+	default:
+		return "hardcover_id"
+	}
+}
+
+// setProviderID stores a metadata provider's ID on a book
+func setProviderID(book *db.Book, provider, id string) {
+	switch provider {
+	case "openlibrary":
+		book.OpenLibraryWorkID = id
+	case "googlebooks":
+		book.GoogleVolumeID = id
+	default:
+		book.HardcoverID = id
+	}
+}
+
+// getOrCreateAuthorByName matches authors from fallback providers by name, since their
+// IDs aren't Hardcover IDs
+func (s *Server) getOrCreateAuthorByName(book *hardcover.BookData) uint {
+	if author, _, err := db.FindAuthorByName(s.db, book.AuthorName); err == nil {
+		return author.ID
+	}
+
+	author := db.Author{
+		Name:     book.AuthorName,
+		SortName: book.AuthorName,
+		ImageURL: book.AuthorImage,
+	}
+	if err := s.db.Create(&author).Error; err != nil {
+		metadataLog.Error("getOrCreateAuthorByName: failed to create author", "author", book.AuthorName, "error", err)
+		return 0
+	}
+	return author.ID
+}
+
 func (s *Server) getOrCreateAuthor(book *hardcover.BookData) uint {
-	var author db.Author
-	if err := s.db.Where("hardcover_id = ?", book.AuthorID).First(&author).Error; err != nil {
-		author = db.Author{
-			HardcoverID: book.AuthorID,
-			Name:        book.AuthorName,
-			SortName:    book.AuthorName,
-		}
-		if len(book.Contributors) > 0 {
-			for _, c := range book.Contributors {
-				if c.AuthorID == book.AuthorID {
-					author.Slug = c.AuthorSlug
-					author.Biography = c.AuthorBio
-					author.ImageURL = c.AuthorImage
-					author.BornDate = c.BornDate
-					author.BornYear = c.BornYear
-					author.DeathDate = c.DeathDate
-					author.DeathYear = c.DeathYear
-					author.Location = c.Location
-					author.IsBIPOC = c.IsBIPOC
-					author.IsLGBTQ = c.IsLGBTQ
-					break
-				}
-			}
+	if author, ok := s.findHardcoverAuthor(book.AuthorID, book.AuthorName); ok {
+		return author.ID
+	}
+
+	author := db.Author{
+		HardcoverID: book.AuthorID,
+		Name:        book.AuthorName,
+		SortName:    book.AuthorName,
+	}
+	for _, c := range book.Contributors {
+		if c.AuthorID == book.AuthorID {
+			author.Slug = c.AuthorSlug
+			author.Biography = c.AuthorBio
+			author.ImageURL = c.AuthorImage
+			author.BornDate = c.BornDate
+			author.BornYear = c.BornYear
+			author.DeathDate = c.DeathDate
+			author.DeathYear = c.DeathYear
+			author.Location = c.Location
+			author.IsBIPOC = c.IsBIPOC
+			author.IsLGBTQ = c.IsLGBTQ
+			author.AlternateNames = alternateNamesJSON(c.AlternateNames)
+			break
 		}
-		s.db.Create(&author)
 	}
+	s.db.Create(&author)
 	return author.ID
 }
 
@@ -602,7 +789,16 @@ func (s *Server) getOrCreateSeries(book *hardcover.BookData) *uint {
 }
 
 func (s *Server) syncGenres(dbBook *db.Book, genres []string) {
+	if len(genres) == 0 || lockedFields(dbBook)["genres"] {
+		return
+	}
+	s.setGenres(dbBook, genres)
+}
+
+// setGenres replaces a book's genres, creating any that don't exist yet
+func (s *Server) setGenres(dbBook *db.Book, genres []string) {
 	if len(genres) == 0 {
+		s.db.Model(dbBook).Association("Genres").Clear()
 		return
 	}
 
@@ -669,24 +865,25 @@ func (s *Server) syncContributors(dbBook *db.Book, book *hardcover.BookData) {
 	s.db.Where("book_id = ?", dbBook.ID).Delete(&db.Contributor{})
 
 	for _, c := range book.Contributors {
-		var author db.Author
-		if err := s.db.Where("hardcover_id = ?", c.AuthorID).First(&author).Error; err != nil {
-			author = db.Author{
-				HardcoverID: c.AuthorID,
-				Name:        c.AuthorName,
-				SortName:    c.AuthorName,
-				Slug:        c.AuthorSlug,
-				Biography:   c.AuthorBio,
-				ImageURL:    c.AuthorImage,
-				BornDate:    c.BornDate,
-				BornYear:    c.BornYear,
-				DeathDate:   c.DeathDate,
-				DeathYear:   c.DeathYear,
-				Location:    c.Location,
-				IsBIPOC:     c.IsBIPOC,
-				IsLGBTQ:     c.IsLGBTQ,
+		author, ok := s.findHardcoverAuthor(c.AuthorID, c.AuthorName)
+		if !ok {
+			author = &db.Author{
+				HardcoverID:    c.AuthorID,
+				Name:           c.AuthorName,
+				SortName:       c.AuthorName,
+				Slug:           c.AuthorSlug,
+				Biography:      c.AuthorBio,
+				ImageURL:       c.AuthorImage,
+				BornDate:       c.BornDate,
+				BornYear:       c.BornYear,
+				DeathDate:      c.DeathDate,
+				DeathYear:      c.DeathYear,
+				Location:       c.Location,
+				IsBIPOC:        c.IsBIPOC,
+				IsLGBTQ:        c.IsLGBTQ,
+				AlternateNames: alternateNamesJSON(c.AlternateNames),
 			}
-			s.db.Create(&author)
+			s.db.Create(author)
 		}
 
 		role := db.ContributorRole(c.Role)
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+)
+
+// Settings that push library changes back to the Hardcover account of the API key
+const (
+	settingHardcoverPushOwned = "hardcover_push_owned" // Mark imported books as owned
+	settingHardcoverPushRead  = "hardcover_push_read"  // Mark finished books as read
+)
+
+// hardcoverPushEnabled reports whether a push setting is turned on; both default to off
+func (s *Server) hardcoverPushEnabled(key string) bool {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", key).First(&setting).Error; err != nil {
+		return false
+	}
+	return setting.Value == "true"
+}
+
+// pushOwnedToHardcover marks an imported book as owned on Hardcover, if enabled
+func (s *Server) pushOwnedToHardcover(book *db.Book) {
+	if !s.hardcoverPushEnabled(settingHardcoverPushOwned) {
+		return
+	}
+	s.pushHardcoverUserBook(book, hardcover.UserBookUpdate{Owned: true})
+}
+
+// pushReadToHardcover marks a finished book as read on Hardcover, if enabled
+func (s *Server) pushReadToHardcover(book *db.Book) {
+	if !s.hardcoverPushEnabled(settingHardcoverPushRead) {
+		return
+	}
+	s.pushHardcoverUserBook(book, hardcover.UserBookUpdate{StatusID: hardcover.StatusRead})
+}
+
+// pushHardcoverUserBook sends the update in the background so the triggering request
+// doesn't wait on Hardcover's rate limit. Failures are logged.
+func (s *Server) pushHardcoverUserBook(book *db.Book, update hardcover.UserBookUpdate) {
+	if book.HardcoverID == "" {
+		return // Added from another provider; nothing to update on Hardcover
+	}
+	client, err := s.getHardcoverClient()
+	if err != nil {
+		return
+	}
+
+	bookID, title := book.HardcoverID, book.Title
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if err := client.UpdateUserBook(ctx, bookID, update); err != nil {
+			metadataLog.Warn("Failed to update book on Hardcover", "book", title, "error", err)
+			return
+		}
+		metadataLog.Debug("Updated book on Hardcover", "book", title, "status", update.StatusID, "owned", update.Owned)
+	}()
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/downloader"
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/indexer"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// Health check severities
+const (
+	SeverityNotice  = "notice"  // A feature is unavailable but nothing is failing
+	SeverityWarning = "warning" // Something is failing and may need attention
+	SeverityError   = "error"   // Downloads or imports won't work until it's fixed
+)
+
+// HealthCheck is a problem found by a health check. Message says what's wrong and Help
+// how to fix it.
+type HealthCheck struct {
+	Source   string `json:"source"` // What the problem is with, such as "Indexer MAM"; one check per source
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Help     string `json:"help"`
+}
+
+// healthIssues remembers the problems found by the last health check, so notifications
+// are only sent when one appears or clears
+type healthIssues struct {
+	mutex    sync.Mutex
+	bySource map[string]HealthCheck
+}
+
+// runHealthChecks checks the download clients, indexers, root folders, helper programs,
+// metadata providers and database, and sends a health notification for each warning or error
+// that has appeared or cleared since the last run
+func (s *Server) runHealthChecks() []HealthCheck {
+	var checks []HealthCheck
+	checks = append(checks, s.checkDownloadClients()...)
+	checks = append(checks, s.checkIndexers()...)
+	checks = append(checks, s.checkRootFolders()...)
+	checks = append(checks, checkHelperPrograms()...)
+	checks = append(checks, s.checkMetadataProviders()...)
+	checks = append(checks, s.checkDatabase()...)
+
+	severityOrder := map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityNotice: 2}
+	sort.SliceStable(checks, func(i, j int) bool {
+		return severityOrder[checks[i].Severity] < severityOrder[checks[j].Severity]
+	})
+
+	s.healthIssues.mutex.Lock()
+	previous := s.healthIssues.bySource
+	current := make(map[string]HealthCheck, len(checks))
+	for _, check := range checks {
+		current[check.Source] = check
+	}
+	s.healthIssues.bySource = current
+	s.healthIssues.mutex.Unlock()
+
+	for source, check := range current {
+		if _, ok := previous[source]; !ok && check.Severity != SeverityNotice {
+			slog.Warn("Health check failed", "source", source, "severity", check.Severity, "message", check.Message)
+			s.notifier.SendNotification("health", map[string]interface{}{
+				"title":    source,
+				"message":  check.Message,
+				"severity": check.Severity,
+			})
+		}
+	}
+	for source, check := range previous {
+		if _, ok := current[source]; !ok && check.Severity != SeverityNotice {
+			slog.Info("Health check resolved", "source", source)
+			s.notifier.SendNotification("health", map[string]interface{}{
+				"title":    source,
+				"message":  "Resolved: " + check.Message,
+				"severity": check.Severity,
+				"resolved": true,
+			})
+		}
+	}
+
+	return checks
+}
+
+// runHealthCheckTask runs the health checks on the scheduler
+func (s *Server) runHealthCheckTask(ctx context.Context) error {
+	s.runHealthChecks()
+	return nil
+}
+
+// checkDownloadClients reports enabled download clients that keep failing
+func (s *Server) checkDownloadClients() []HealthCheck {
+	var clients []db.DownloadClient
+	s.db.Where("enabled = ?", true).Order("priority ASC").Find(&clients)
+	if len(clients) == 0 {
+		return []HealthCheck{{
+			Source:   "Download clients",
+			Severity: SeverityWarning,
+			Message:  "No download client is enabled",
+			Help:     "Releases from torrent and usenet indexers can't be grabbed. Add or enable a client in Settings > Download Clients.",
+		}}
+	}
+
+	var checks []HealthCheck
+	for _, dc := range clients {
+		status := s.health.Circuit(downloader.CircuitName(dc.Name)).Status()
+		if status.State == health.StateClosed {
+			continue
+		}
+		checks = append(checks, HealthCheck{
+			Source:   "Download client " + dc.Name,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s is unreachable: %s", dc.Name, status.LastError),
+			Help:     "Check the client is running and its URL and credentials are right, then test it in Settings > Download Clients. Grabs go to the next client until it's back.",
+		})
+	}
+	return checks
+}
+
+// checkIndexers reports enabled indexers that keep failing and MyAnonamouse indexers
+// whose session cookie has been rejected
+func (s *Server) checkIndexers() []HealthCheck {
+	var indexers []db.Indexer
+	s.db.Where("enabled = ?", true).Order("priority ASC").Find(&indexers)
+	if len(indexers) == 0 {
+		return []HealthCheck{{
+			Source:   "Indexers",
+			Severity: SeverityWarning,
+			Message:  "No indexer is enabled",
+			Help:     "Books can't be searched for. Add or enable an indexer in Settings > Indexers.",
+		}}
+	}
+
+	var checks []HealthCheck
+	failing := 0
+	for _, idx := range indexers {
+		status := s.health.Circuit(indexer.CircuitName(idx.Name)).Status()
+		switch {
+		case idx.Type == "mam" && status.ConsecutiveFailures > 0 && strings.Contains(status.LastError, "authentication failed"):
+			failing++
+			checks = append(checks, HealthCheck{
+				Source:   "Indexer " + idx.Name,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s's MyAnonamouse cookie has expired", idx.Name),
+				Help:     "Create a new session on MyAnonamouse's Security page, paste its mam_id into the indexer's settings and test it.",
+			})
+		case status.State != health.StateClosed:
+			failing++
+			checks = append(checks, HealthCheck{
+				Source:   "Indexer " + idx.Name,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s keeps failing and is skipped for now: %s", idx.Name, status.LastError),
+				Help:     "Searches leave the indexer out until it recovers. Check its URL and API key, then test it in Settings > Indexers.",
+			})
+		}
+	}
+
+	if failing == len(indexers) {
+		checks = append(checks, HealthCheck{
+			Source:   "Indexers",
+			Severity: SeverityError,
+			Message:  "All indexers are unavailable",
+			Help:     "No searches can be made until one of them is back.",
+		})
+	}
+	return checks
+}
+
+// checkRootFolders reports root folders that are missing or can't be written to
+func (s *Server) checkRootFolders() []HealthCheck {
+	var rootFolders []db.RootFolder
+	s.db.Order("path").Find(&rootFolders)
+
+	var checks []HealthCheck
+	for _, rf := range rootFolders {
+		check := HealthCheck{
+			Source:   "Root folder " + rf.Path,
+			Severity: SeverityError,
+			Help:     "Books can't be imported into it. If it's on a network share or another disk, check it's mounted and Shelfarr's user can write to it.",
+		}
+
+		info, err := os.Stat(rf.Path)
+		switch {
+		case os.IsNotExist(err):
+			check.Message = rf.Path + " doesn't exist"
+		case err != nil:
+			check.Message = fmt.Sprintf("%s can't be read: %v", rf.Path, err)
+		case !info.IsDir():
+			check.Message = rf.Path + " isn't a folder"
+		case !folderWritable(rf.Path):
+			check.Message = rf.Path + " isn't writable"
+		default:
+			continue
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// folderWritable reports whether a file can be created in dir
+func folderWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".shelfarr_write_test")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// checkHelperPrograms reports missing programs that features rely on
+func checkHelperPrograms() []HealthCheck {
+	var checks []HealthCheck
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		checks = append(checks, HealthCheck{
+			Source:   "ffmpeg",
+			Severity: SeverityWarning,
+			Message:  "ffmpeg isn't installed",
+			Help:     "Audiobook durations and chapters can't be read, and audiobooks can't be tagged or merged. Install ffmpeg and ffprobe on Shelfarr's PATH.",
+		})
+	}
+	if !media.NewEbookConverter().IsAvailable() {
+		checks = append(checks, HealthCheck{
+			Source:   "Calibre",
+			Severity: SeverityNotice,
+			Message:  "Calibre's ebook-convert isn't installed",
+			Help:     "Ebooks can't be converted between formats, and MOBI and AZW3 files can't be sent to Kindles. Install Calibre to enable them.",
+		})
+	}
+	return checks
+}
+
+// checkMetadataProviders reports a Google Books quota that has run out
+func (s *Server) checkMetadataProviders() []HealthCheck {
+	status := s.health.Circuit("googlebooks").Status()
+	if status.ConsecutiveFailures == 0 || !strings.Contains(status.LastError, "429") {
+		return nil
+	}
+	return []HealthCheck{{
+		Source:   "Google Books",
+		Severity: SeverityWarning,
+		Message:  "The Google Books quota is exhausted",
+		Help:     "Google Books lookups fail until the quota resets. Add your own API key in Settings > Metadata for a larger quota.",
+	}}
+}
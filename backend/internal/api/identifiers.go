@@ -0,0 +1,46 @@
+package api
+
+import (
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/metadata"
+)
+
+// providerIdentifiers collects the identifiers a metadata provider returned for a book
+func providerIdentifiers(provider string, book *hardcover.BookData, extra map[string]string) db.BookIdentifiers {
+	var ids db.BookIdentifiers
+	for column, value := range extra {
+		ids.Set(column, value)
+	}
+	ids.Set(metadata.IdentifierColumn(provider), book.ID)
+	ids.ISBN10 = book.ISBN
+	ids.ISBN13 = book.ISBN13
+	for _, ed := range book.Editions {
+		if ids.ASIN == "" {
+			ids.ASIN = ed.ASIN
+		}
+	}
+	return ids
+}
+
+// findLibraryBook returns the library book matching a provider's record, by any shared identifier
+func (s *Server) findLibraryBook(provider string, book *hardcover.BookData, extra map[string]string) (*db.Book, bool) {
+	found, err := db.FindBookByIdentifiers(s.db, providerIdentifiers(provider, book, extra))
+	if err != nil {
+		return nil, false
+	}
+	return found, true
+}
+
+// syncIdentifiers records a library book's identifiers from its fields and editions,
+// merging in extra ones found through metadata providers
+func (s *Server) syncIdentifiers(book *db.Book, extra db.BookIdentifiers) {
+	withEditions := *book
+	s.db.Where("book_id = ?", book.ID).Find(&withEditions.Editions)
+
+	ids := db.IdentifiersFromBook(&withEditions)
+	ids.Merge(extra)
+	if err := db.SaveBookIdentifiers(s.db, book.ID, ids); err != nil {
+		metadataLog.Warn("Failed to save identifiers", "bookId", book.ID, "error", err)
+	}
+}
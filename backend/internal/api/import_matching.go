@@ -0,0 +1,421 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// Limits on how many candidates are suggested for a pending import
+const (
+	maxLibraryCandidates = 5
+	maxImportCandidates  = 10
+	minCandidateScore    = 50
+)
+
+// ImportCandidate is a book a pending import may be, with how well it matches
+type ImportCandidate struct {
+	Score       int      `json:"score"` // 0-100
+	BookID      uint     `json:"bookId,omitempty"`
+	InLibrary   bool     `json:"inLibrary"`
+	Provider    string   `json:"provider,omitempty"` // Metadata provider, for books not yet in the library
+	ProviderID  string   `json:"providerId,omitempty"`
+	Title       string   `json:"title"`
+	Author      string   `json:"author"`
+	SeriesName  string   `json:"seriesName,omitempty"`
+	SeriesIndex *float32 `json:"seriesIndex,omitempty"`
+	CoverURL    string   `json:"coverUrl,omitempty"`
+	ReleaseYear int      `json:"releaseYear,omitempty"`
+}
+
+// PendingImportResponse is a pending import with the library books it most likely is
+type PendingImportResponse struct {
+	media.PendingImport
+	Candidates []ImportCandidate `json:"candidates"`
+}
+
+// importMatch is what is known about a pending import, from its name or metadata
+type importMatch struct {
+	title  string
+	author string
+	series string
+	isbn   string
+	asin   string
+}
+
+// pathImportMatch returns what is known about a file or folder awaiting import: the
+// metadata an EPUB holds or an audiobook's tags, and otherwise what its name says
+func pathImportMatch(path string) importMatch {
+	var match importMatch
+	match.author, match.title, match.series, _ = media.NewScanner().ExtractMetadataFromFilename(filepath.Base(path))
+	match.applyEPUB(path)
+	match.applyAudioTags(path)
+	return match
+}
+
+// applyAudioTags replaces what is known with the tags of an audiobook's first file,
+// where it has any. The album names the book, as a book's files are titled by part.
+func (m *importMatch) applyAudioTags(path string) {
+	if media.NewScanner().DetectMediaType(filepath.Ext(path)) == "ebook" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	tags := media.ReadAudiobookTags(ctx, path)
+	if tags == nil {
+		return
+	}
+	if tags.Album != "" {
+		m.title = tags.Album
+	}
+	if tags.Author != "" {
+		m.author = tags.Author
+	}
+	if tags.Series != "" {
+		m.series = tags.Series
+	}
+	if tags.ASIN != "" {
+		m.asin = tags.ASIN
+	}
+}
+
+// applyEPUB replaces what is known with the metadata an EPUB holds, where it has any.
+// Anything other than a readable EPUB is ignored.
+func (m *importMatch) applyEPUB(path string) {
+	if !strings.EqualFold(filepath.Ext(path), ".epub") {
+		return
+	}
+	meta, err := media.ReadEPUBMetadata(path)
+	if err != nil {
+		return
+	}
+	if meta.Title != "" {
+		m.title = meta.Title
+	}
+	if len(meta.Authors) > 0 {
+		m.author = meta.Authors[0]
+	}
+	if meta.Series != "" {
+		m.series = meta.Series
+	}
+	if meta.ISBN != "" {
+		m.isbn = meta.ISBN
+	}
+}
+
+// getImportSuggestions ranks the books a file in the downloads folder may be: library
+// books, and books found with the metadata providers that may not be in the library
+// yet. The title and author are taken from the file, or its name, unless given.
+func (s *Server) getImportSuggestions(c echo.Context) error {
+	var match importMatch
+	if path := c.QueryParam("path"); path != "" {
+		match = pathImportMatch(path)
+	}
+	if title := c.QueryParam("title"); title != "" {
+		match.title = title
+	}
+	if author := c.QueryParam("author"); author != "" {
+		match.author = author
+	}
+	if strings.TrimSpace(match.title) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "path or title is required"})
+	}
+
+	candidates := s.libraryCandidates(match, s.loadLibraryBooks())
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 20*time.Second)
+	defer cancel()
+
+	results, err := s.metadata.SearchBooks(ctx, strings.TrimSpace(cleanImportTitle(match.title)+" "+match.author), nil)
+	if err != nil {
+		// Library candidates are still worth returning
+		return c.JSON(http.StatusOK, candidates)
+	}
+
+	byBook := make(map[uint]int, len(candidates))
+	for i, candidate := range candidates {
+		byBook[candidate.BookID] = i
+	}
+	for _, result := range results {
+		score := scoreImportMatch(match, result.Title, result.AuthorName, result.SeriesName)
+		if score < minCandidateScore {
+			continue
+		}
+
+		if book, ok := s.findLibraryBook(result.Provider, &result.BookData, result.Identifiers); ok {
+			if i, seen := byBook[book.ID]; seen {
+				candidates[i].Score = max(candidates[i].Score, score)
+				continue
+			}
+			s.db.Preload("Author").Preload("Series").First(book, book.ID)
+			byBook[book.ID] = len(candidates)
+			candidate := libraryCandidate(book)
+			candidate.Score = score
+			candidates = append(candidates, candidate)
+			continue
+		}
+
+		candidates = append(candidates, ImportCandidate{
+			Score:       score,
+			Provider:    result.Provider,
+			ProviderID:  result.ID,
+			Title:       result.Title,
+			Author:      result.AuthorName,
+			SeriesName:  result.SeriesName,
+			SeriesIndex: result.SeriesIndex,
+			CoverURL:    result.CoverURL,
+			ReleaseYear: result.ReleaseYear,
+		})
+	}
+
+	sortCandidates(candidates)
+	if len(candidates) > maxImportCandidates {
+		candidates = candidates[:maxImportCandidates]
+	}
+	return c.JSON(http.StatusOK, candidates)
+}
+
+// getImportCover returns the cover embedded in an EPUB awaiting import, so it can be
+// compared with the suggested books' covers
+func (s *Server) getImportCover(c echo.Context) error {
+	path := filepath.Clean(c.QueryParam("path"))
+	if s.config.DownloadsPath == "" || !underAny(path, []string{s.config.DownloadsPath}) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "path must be in the downloads folder"})
+	}
+
+	cover, mediaType, err := media.ReadEPUBCover(path)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.Blob(http.StatusOK, mediaType, cover)
+}
+
+// loadLibraryBooks loads every library book with its author and series, for matching
+func (s *Server) loadLibraryBooks() []db.Book {
+	var books []db.Book
+	s.db.Preload("Author").Preload("Series").Find(&books)
+	return books
+}
+
+// libraryCandidates returns the library books that best match a pending import. A book
+// with the import's ISBN or ASIN, on itself or one of its editions, is a certain match.
+func (s *Server) libraryCandidates(match importMatch, books []db.Book) []ImportCandidate {
+	identified := s.identifiedBooks(match)
+	candidates := []ImportCandidate{}
+	for i := range books {
+		book := &books[i]
+		var series string
+		if book.Series != nil {
+			series = book.Series.Name
+		}
+		score := scoreImportMatch(match, book.Title, book.Author.Name, series)
+		if book.Subtitle != "" {
+			score = max(score, scoreImportMatch(match, book.Title+" "+book.Subtitle, book.Author.Name, series))
+		}
+		if identified[book.ID] {
+			score = 100
+		}
+		if score < minCandidateScore {
+			continue
+		}
+		candidate := libraryCandidate(book)
+		candidate.Score = score
+		candidates = append(candidates, candidate)
+	}
+
+	sortCandidates(candidates)
+	if len(candidates) > maxLibraryCandidates {
+		candidates = candidates[:maxLibraryCandidates]
+	}
+	return candidates
+}
+
+// identifiedBooks returns the IDs of the library books with a pending import's ISBN, in
+// either its 10 or 13 digit form, or its ASIN
+func (s *Server) identifiedBooks(match importMatch) map[uint]bool {
+	found := make(map[uint]bool)
+	pluck := func(model interface{}, column, query string, args ...interface{}) {
+		var ids []uint
+		s.db.Model(model).Where(query, args...).Pluck(column, &ids)
+		for _, id := range ids {
+			found[id] = true
+		}
+	}
+
+	if isbns := isbnForms(match.isbn); len(isbns) > 0 {
+		pluck(&db.Book{}, "id", "isbn IN ? OR isbn13 IN ?", isbns, isbns)
+		pluck(&db.Edition{}, "book_id", "isbn10 IN ? OR isbn13 IN ?", isbns, isbns)
+		pluck(&db.BookIdentifiers{}, "book_id", "isbn10 IN ? OR isbn13 IN ?", isbns, isbns)
+	}
+	if match.asin != "" {
+		pluck(&db.Edition{}, "book_id", "asin = ?", match.asin)
+		pluck(&db.BookIdentifiers{}, "book_id", "asin = ?", match.asin)
+	}
+	return found
+}
+
+// isbnForms returns an ISBN with its ISBN-10 or ISBN-13 equivalent, where it has one
+func isbnForms(isbn string) []string {
+	switch {
+	case len(isbn) == 10:
+		body := "978" + isbn[:9]
+		return []string{isbn, body + isbnCheckDigit13(body)}
+	case len(isbn) == 13 && strings.HasPrefix(isbn, "978"):
+		body := isbn[3:12]
+		return []string{isbn, body + isbnCheckDigit10(body)}
+	case len(isbn) == 13:
+		return []string{isbn}
+	}
+	return nil
+}
+
+// isbnCheckDigit13 returns the check digit of the first 12 digits of an ISBN-13
+func isbnCheckDigit13(body string) string {
+	sum := 0
+	for i, r := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+	return strconv.Itoa((10 - sum%10) % 10)
+}
+
+// isbnCheckDigit10 returns the check digit of the first 9 digits of an ISBN-10
+func isbnCheckDigit10(body string) string {
+	sum := 0
+	for i, r := range body {
+		sum += int(r-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return "X"
+	}
+	return strconv.Itoa(check)
+}
+
+func libraryCandidate(book *db.Book) ImportCandidate {
+	candidate := ImportCandidate{
+		BookID:      book.ID,
+		InLibrary:   true,
+		Title:       book.Title,
+		Author:      book.Author.Name,
+		SeriesIndex: book.SeriesIndex,
+		CoverURL:    coverImageURL(book),
+		ReleaseYear: book.ReleaseYear,
+	}
+	if book.Series != nil {
+		candidate.SeriesName = book.Series.Name
+	}
+	return candidate
+}
+
+func sortCandidates(candidates []ImportCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].InLibrary && !candidates[j].InLibrary
+	})
+}
+
+// scoreImportMatch scores how well a book matches what is known about a pending import,
+// from 0 to 100. File names are often "Title - Author" rather than "Author - Title",
+// so the author and title are also tried the other way round.
+func scoreImportMatch(match importMatch, title, author, series string) int {
+	score := func(matchTitle, matchAuthor string) float64 {
+		titleScore := similarity(normalizeImportTitle(matchTitle), normalizeImportTitle(title))
+		// Titles with a subtitle match on the main title too
+		if main, _, ok := strings.Cut(matchTitle, ":"); ok {
+			titleScore = max(titleScore, similarity(normalizeImportTitle(main), normalizeImportTitle(title)))
+		}
+		if matchAuthor == "" || author == "" {
+			return titleScore
+		}
+		return 0.75*titleScore + 0.25*similarity(normalizeImportName(matchAuthor), normalizeImportName(author))
+	}
+
+	best := score(match.title, match.author)
+	if match.author != "" {
+		best = max(best, score(match.author, match.title))
+	}
+	if match.series != "" && series != "" && similarity(normalizeImportTitle(match.series), normalizeImportTitle(series)) > 0.8 {
+		best = min(1, best+0.05)
+	}
+	return int(best*100 + 0.5)
+}
+
+var (
+	bracketedPattern    = regexp.MustCompile(`[(\[{][^)\]}]*[)\]}]`)
+	releaseNoisePattern = regexp.MustCompile(`(?i)\b(unabridged|abridged|retail|epub|mobi|azw3|pdf|m4b|mp3|audiobook|ebook)\b`)
+)
+
+// cleanImportTitle removes release noise from a title taken from a file name, such as
+// bracketed tags and format names
+func cleanImportTitle(title string) string {
+	title = bracketedPattern.ReplaceAllString(title, " ")
+	title = releaseNoisePattern.ReplaceAllString(title, " ")
+	title = strings.NewReplacer("_", " ", ".", " ").Replace(title)
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// normalizeImportTitle lowercases a title and drops punctuation and leading articles
+func normalizeImportTitle(title string) string {
+	words := importWords(cleanImportTitle(title))
+	if len(words) > 1 && (words[0] == "the" || words[0] == "a" || words[0] == "an") {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeImportName normalizes an author's name so "Last, First" matches "First Last"
+func normalizeImportName(name string) string {
+	words := importWords(name)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+func importWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r <= 127
+	})
+}
+
+// similarity compares two strings by the Sørensen–Dice coefficient of their character
+// bigrams, from 0 (nothing in common) to 1 (the same)
+func similarity(a, b string) float64 {
+	if a == b {
+		if a == "" {
+			return 0
+		}
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		return 0
+	}
+
+	bigrams := make(map[[2]rune]int)
+	for i := 0; i < len(ra)-1; i++ {
+		bigrams[[2]rune{ra[i], ra[i+1]}]++
+	}
+	shared := 0
+	for i := 0; i < len(rb)-1; i++ {
+		bigram := [2]rune{rb[i], rb[i+1]}
+		if bigrams[bigram] > 0 {
+			bigrams[bigram]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ra)+len(rb)-2)
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -13,15 +15,27 @@ import (
 
 // IndexerRequest represents the request body for creating/updating an indexer
 type IndexerRequest struct {
-	Name          string `json:"name" validate:"required"`
-	Type          string `json:"type" validate:"required"` // torznab, mam, anna
-	URL           string `json:"url" validate:"required"`
+	Name          string `json:"name" validate:"required,max=100"`
+	Type          string `json:"type" validate:"required,oneof=torznab newznab mam anna libgen audiobookbay"`
+	URL           string `json:"url" validate:"required,url"`
 	APIKey        string `json:"apiKey,omitempty"`
 	Cookie        string `json:"cookie,omitempty"`
-	Priority      int    `json:"priority"`
+	Categories    []int  `json:"categories,omitempty"`
+	Priority      int    `json:"priority" validate:"min=0"`
 	Enabled       bool   `json:"enabled"`
 	VIPOnly       bool   `json:"vipOnly,omitempty"`
 	FreeleechOnly bool   `json:"freeleechOnly,omitempty"`
+
+	// Torznab category mapping; detected from the indexer's capabilities when omitted
+	EbookCategories     []int `json:"ebookCategories,omitempty"`
+	AudiobookCategories []int `json:"audiobookCategories,omitempty"`
+
+	// Seeding goals; zero uses the download client's
+	SeedRatio float64 `json:"seedRatio,omitempty" validate:"min=0"`
+	SeedTime  int     `json:"seedTime,omitempty" validate:"min=0"` // Minutes
+
+	// Tagged indexers are only searched for books sharing a tag; omit to leave unchanged
+	Tags []uint `json:"tags"`
 }
 
 // IndexerResponse represents an indexer in API responses
@@ -30,32 +44,69 @@ type IndexerResponse struct {
 	Name          string `json:"name"`
 	Type          string `json:"type"`
 	URL           string `json:"url"`
+	Categories    []int  `json:"categories"`
 	Priority      int    `json:"priority"`
 	Enabled       bool   `json:"enabled"`
 	VIPOnly       bool   `json:"vipOnly,omitempty"`
 	FreeleechOnly bool   `json:"freeleechOnly,omitempty"`
+
+	// Torznab capabilities and category mapping
+	SupportedCategories []indexer.Category `json:"supportedCategories,omitempty"`
+	SearchModes         []string           `json:"searchModes,omitempty"`
+	EbookCategories     []int              `json:"ebookCategories,omitempty"`
+	AudiobookCategories []int              `json:"audiobookCategories,omitempty"`
+
+	SeedRatio float64 `json:"seedRatio,omitempty"`
+	SeedTime  int     `json:"seedTime,omitempty"`
+
+	Tags []uint `json:"tags"`
+}
+
+func toIndexerResponse(idx db.Indexer) IndexerResponse {
+	response := IndexerResponse{
+		ID:                  idx.ID,
+		Name:                idx.Name,
+		Type:                idx.Type,
+		URL:                 idx.URL,
+		Categories:          parseCategories(idx.Categories),
+		Priority:            idx.Priority,
+		Enabled:             idx.Enabled,
+		VIPOnly:             idx.VIPOnly,
+		FreeleechOnly:       idx.FreeleechOnly,
+		EbookCategories:     parseCategories(idx.EbookCategories),
+		AudiobookCategories: parseCategories(idx.AudiobookCategories),
+		SeedRatio:           idx.SeedRatio,
+		SeedTime:            idx.SeedTime,
+	}
+	if idx.SearchModes != "" {
+		response.SearchModes = strings.Split(idx.SearchModes, ",")
+	}
+	json.Unmarshal([]byte(idx.SupportedCategories), &response.SupportedCategories)
+	return response
 }
 
 // getIndexers returns all configured indexers
 func (s *Server) getIndexers(c echo.Context) error {
 	var indexers []db.Indexer
 	
-	if err := s.db.Order("priority ASC").Find(&indexers).Error; err != nil {
+	query := s.db.Order("priority ASC")
+	if tags := parseTagIDs(c.QueryParam("tags")); len(tags) > 0 {
+		query = query.Scopes(db.IndexerTags.Tagged(tags))
+	}
+	if err := query.Find(&indexers).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	ids := make([]uint, len(indexers))
+	for i, idx := range indexers {
+		ids[i] = idx.ID
+	}
+	tags := db.IndexerTags.IDs(s.db, ids...)
+
 	responses := make([]IndexerResponse, len(indexers))
 	for i, idx := range indexers {
-		responses[i] = IndexerResponse{
-			ID:            idx.ID,
-			Name:          idx.Name,
-			Type:          idx.Type,
-			URL:           idx.URL,
-			Priority:      idx.Priority,
-			Enabled:       idx.Enabled,
-			VIPOnly:       idx.VIPOnly,
-			FreeleechOnly: idx.FreeleechOnly,
-		}
+		responses[i] = toIndexerResponse(idx)
+		responses[i].Tags = tagIDsOrEmpty(tags[idx.ID])
 	}
 
 	return c.JSON(http.StatusOK, responses)
@@ -64,18 +115,8 @@ func (s *Server) getIndexers(c echo.Context) error {
 // addIndexer creates a new indexer
 func (s *Server) addIndexer(c echo.Context) error {
 	var req IndexerRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.Name == "" || req.Type == "" || req.URL == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name, type, and url are required"})
-	}
-
-	// Validate type
-	validTypes := map[string]bool{"torznab": true, "mam": true, "anna": true}
-	if !validTypes[req.Type] {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid indexer type. Must be: torznab, mam, or anna"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	indexer := db.Indexer{
@@ -84,26 +125,31 @@ func (s *Server) addIndexer(c echo.Context) error {
 		URL:           req.URL,
 		APIKey:        req.APIKey,
 		Cookie:        req.Cookie,
+		Categories:    formatCategories(req.Categories),
 		Priority:      req.Priority,
 		Enabled:       req.Enabled,
 		VIPOnly:       req.VIPOnly,
 		FreeleechOnly: req.FreeleechOnly,
+		SeedRatio:     req.SeedRatio,
+		SeedTime:      req.SeedTime,
+	}
+
+	if !s.validTagIDs(req.Tags) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown tag"})
 	}
 
+	s.detectTorznabCaps(c.Request().Context(), &indexer, req.EbookCategories, req.AudiobookCategories)
+
 	if err := s.db.Create(&indexer).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create indexer"})
 	}
+	if err := db.IndexerTags.Set(s.db, indexer.ID, uniqueIDs(req.Tags)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to tag indexer"})
+	}
 
-	return c.JSON(http.StatusCreated, IndexerResponse{
-		ID:            indexer.ID,
-		Name:          indexer.Name,
-		Type:          indexer.Type,
-		URL:           indexer.URL,
-		Priority:      indexer.Priority,
-		Enabled:       indexer.Enabled,
-		VIPOnly:       indexer.VIPOnly,
-		FreeleechOnly: indexer.FreeleechOnly,
-	})
+	response := toIndexerResponse(indexer)
+	response.Tags = tagIDsOrEmpty(uniqueIDs(req.Tags))
+	return c.JSON(http.StatusCreated, response)
 }
 
 // updateIndexer updates an existing indexer
@@ -119,8 +165,8 @@ func (s *Server) updateIndexer(c echo.Context) error {
 	}
 
 	var req IndexerRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	indexer.Name = req.Name
@@ -128,25 +174,32 @@ func (s *Server) updateIndexer(c echo.Context) error {
 	indexer.URL = req.URL
 	indexer.APIKey = req.APIKey
 	indexer.Cookie = req.Cookie
+	indexer.Categories = formatCategories(req.Categories)
 	indexer.Priority = req.Priority
 	indexer.Enabled = req.Enabled
 	indexer.VIPOnly = req.VIPOnly
 	indexer.FreeleechOnly = req.FreeleechOnly
+	indexer.SeedRatio = req.SeedRatio
+	indexer.SeedTime = req.SeedTime
+
+	if !s.validTagIDs(req.Tags) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown tag"})
+	}
+
+	s.detectTorznabCaps(c.Request().Context(), &indexer, req.EbookCategories, req.AudiobookCategories)
 
 	if err := s.db.Save(&indexer).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update indexer"})
 	}
+	if req.Tags != nil {
+		if err := db.IndexerTags.Set(s.db, indexer.ID, uniqueIDs(req.Tags)); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to tag indexer"})
+		}
+	}
 
-	return c.JSON(http.StatusOK, IndexerResponse{
-		ID:            indexer.ID,
-		Name:          indexer.Name,
-		Type:          indexer.Type,
-		URL:           indexer.URL,
-		Priority:      indexer.Priority,
-		Enabled:       indexer.Enabled,
-		VIPOnly:       indexer.VIPOnly,
-		FreeleechOnly: indexer.FreeleechOnly,
-	})
+	response := toIndexerResponse(indexer)
+	response.Tags = tagIDsOrEmpty(db.IndexerTags.IDs(s.db, indexer.ID)[indexer.ID])
+	return c.JSON(http.StatusOK, response)
 }
 
 // deleteIndexer removes an indexer
@@ -185,10 +238,8 @@ func (s *Server) testIndexer(c echo.Context) error {
 	switch dbIndexer.Type {
 	case "mam":
 		idx = indexer.NewMAMIndexer(dbIndexer.Name, dbIndexer.Cookie, dbIndexer.VIPOnly, dbIndexer.FreeleechOnly)
-	case "torznab":
-		idx = indexer.NewTorznabIndexer(dbIndexer.Name, dbIndexer.URL, dbIndexer.APIKey)
-	case "anna":
-		idx = indexer.NewAnnaIndexer(dbIndexer.Name)
+	case "torznab", "newznab", "anna", "libgen", "audiobookbay":
+		idx = createIndexerFromDB(dbIndexer)
 	default:
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown indexer type"})
 	}
@@ -197,7 +248,10 @@ func (s *Server) testIndexer(c echo.Context) error {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
 	defer cancel()
 
-	if err := idx.Test(ctx); err != nil {
+	// A manual test also resets an open circuit once the indexer is back
+	err = idx.Test(ctx)
+	s.health.Circuit(indexer.CircuitName(dbIndexer.Name)).Record(err)
+	if err != nil {
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"success": false,
 			"message": "Connection test failed: " + err.Error(),
@@ -210,3 +264,56 @@ func (s *Server) testIndexer(c echo.Context) error {
 	})
 }
 
+
+// detectTorznabCaps queries a Torznab or Newznab indexer's capabilities and stores its categories
+// and search modes. The ebook and audiobook categories are mapped from them unless given;
+// categories offered through Prowlarr limit the mapping. Indexers are saved even when
+// detection fails, and then search the standard categories.
+func (s *Server) detectTorznabCaps(ctx context.Context, idx *db.Indexer, ebook, audiobook []int) {
+	if idx.Type != "torznab" && idx.Type != "newznab" {
+		return
+	}
+	if len(ebook) > 0 || len(audiobook) > 0 {
+		idx.EbookCategories = formatCategories(ebook)
+		idx.AudiobookCategories = formatCategories(audiobook)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	caps, err := indexer.NewTorznabIndexer(idx.Name, idx.URL, idx.APIKey).Capabilities(ctx)
+	if err != nil {
+		indexersLog.Warn("Could not detect indexer capabilities", "indexer", idx.Name, "error", err)
+		return
+	}
+
+	supported, _ := json.Marshal(caps.Categories)
+	idx.SupportedCategories = string(supported)
+	var modes []string
+	for _, mode := range caps.SearchModes {
+		// Book search is only used for title and author, so it's useless without them
+		if mode != "book-search" || caps.SupportsBookSearch() {
+			modes = append(modes, mode)
+		}
+	}
+	idx.SearchModes = strings.Join(modes, ",")
+	if len(ebook) > 0 || len(audiobook) > 0 {
+		return
+	}
+
+	categories := caps.Categories
+	if offered := parseCategories(idx.Categories); len(offered) > 0 {
+		categories = nil
+		for _, category := range caps.Categories {
+			for _, id := range offered {
+				if category.ID == id {
+					categories = append(categories, category)
+					break
+				}
+			}
+		}
+	}
+	ebook, audiobook = indexer.MapCategories(categories)
+	idx.EbookCategories = formatCategories(ebook)
+	idx.AudiobookCategories = formatCategories(audiobook)
+}
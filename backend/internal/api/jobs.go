@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// JobResponse represents a background job in API responses
+type JobResponse struct {
+	ID          uint            `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Progress    float64         `json:"progress"`
+	Message     string          `json:"message,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int             `json:"attempts"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	StartedAt   *time.Time      `json:"startedAt,omitempty"`
+	CompletedAt *time.Time      `json:"completedAt,omitempty"`
+}
+
+func toJobResponse(j db.Job) JobResponse {
+	resp := JobResponse{
+		ID:          j.ID,
+		Type:        j.Type,
+		Status:      j.Status,
+		Progress:    j.Progress,
+		Message:     j.Message,
+		Error:       j.Error,
+		Attempts:    j.Attempts,
+		CreatedAt:   j.CreatedAt,
+		StartedAt:   j.StartedAt,
+		CompletedAt: j.CompletedAt,
+	}
+	if j.Payload != "" {
+		resp.Payload = json.RawMessage(j.Payload)
+	}
+	if j.Result != "" {
+		resp.Result = json.RawMessage(j.Result)
+	}
+	return resp
+}
+
+// setupJobQueue registers job handlers and starts the workers
+func (s *Server) setupJobQueue() {
+	s.jobs.Register(jobEbookConvert, s.runEbookConvertJob)
+	s.jobs.Register(jobAudiobookMerge, s.runAudiobookMergeJob)
+	s.jobs.Register(jobTrackerImport, s.runTrackerImportJob)
+	s.jobs.Register(jobCalibreImport, s.runCalibreImportJob)
+	s.jobs.Register(jobDirectDownload, s.runDirectDownloadJob)
+
+	s.jobs.OnUpdate(func(job db.Job) {
+		s.events.JobUpdated(job.ID, job.Type, job.Status, job.Progress, job.Message)
+	})
+
+	s.jobs.Start()
+}
+
+// ffmpegJobProgress adapts ffmpeg encoding progress to job progress, mapping it onto
+// the [from, to] range of the job and appending the ETA to the step message
+func ffmpegJobProgress(progress jobs.ProgressFunc, step string, from, to float64) media.ProgressFunc {
+	return func(percent float64, eta time.Duration) {
+		message := step
+		if eta > 0 {
+			message = fmt.Sprintf("%s (ETA %s)", step, eta.Round(time.Second))
+		}
+		progress(from+(to-from)*percent/100, message)
+	}
+}
+
+// getJobs returns background jobs, newest first
+func (s *Server) getJobs(c echo.Context) error {
+	limit := 100
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	query := s.db.Model(&db.Job{})
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType := c.QueryParam("type"); jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var list []db.Job
+	if err := query.Order("id DESC").Limit(limit).Find(&list).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]JobResponse, len(list))
+	for i, j := range list {
+		responses[i] = toJobResponse(j)
+	}
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// getJob returns a single job
+func (s *Server) getJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var job db.Job
+	if err := s.db.First(&job, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job not found"})
+	}
+
+	return c.JSON(http.StatusOK, toJobResponse(job))
+}
+
+// cancelJob cancels a queued or running job
+func (s *Server) cancelJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	if err := s.jobs.Cancel(uint(id)); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Job cancelled"})
+}
+
+// retryJob requeues a failed or cancelled job
+func (s *Server) retryJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	job, err := s.jobs.Retry(uint(id))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, toJobResponse(*job))
+}
+
+// deleteJob removes a finished job from the history
+func (s *Server) deleteJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var job db.Job
+	if err := s.db.First(&job, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job not found"})
+	}
+	if job.Status == jobs.StatusQueued || job.Status == jobs.StatusRunning {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Cancel the job before deleting it"})
+	}
+
+	if err := s.db.Unscoped().Delete(&job).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete job"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
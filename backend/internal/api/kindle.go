@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/kindle"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// KindleSettingsResponse represents the current user's Send to Kindle settings
+type KindleSettingsResponse struct {
+	Email          string `json:"email"`
+	Enabled        bool   `json:"enabled"`        // Kindle email and SMTP are both configured
+	SMTPConfigured bool   `json:"smtpConfigured"` // Outgoing mail server is set up
+	CanConvert     bool   `json:"canConvert"`     // ebook-convert is available for MOBI/AZW3 sources
+}
+
+// KindleSettingsRequest represents the request body for updating Send to Kindle settings
+type KindleSettingsRequest struct {
+	Email *string `json:"email,omitempty" validate:"max=254"`
+}
+
+// SendHistoryResponse represents a send history entry in API responses
+type SendHistoryResponse struct {
+	ID          uint      `json:"id"`
+	MediaFileID uint      `json:"mediaFileId"`
+	ToEmail     string    `json:"toEmail"`
+	FileName    string    `json:"fileName"`
+	Format      string    `json:"format"`
+	FileSize    int64     `json:"fileSize"`
+	Converted   bool      `json:"converted"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// getKindleSettings returns the current user's Send to Kindle settings
+func (s *Server) getKindleSettings(c echo.Context) error {
+	var user db.User
+	if err := s.db.First(&user, currentUserID(c)).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	smtpConfigured := loadSMTPConfig(s.db).Valid()
+	return c.JSON(http.StatusOK, KindleSettingsResponse{
+		Email:          user.KindleEmail,
+		Enabled:        user.KindleEmail != "" && smtpConfigured,
+		SMTPConfigured: smtpConfigured,
+		CanConvert:     media.NewEbookConverter().IsAvailable(),
+	})
+}
+
+// updateKindleSettings updates the current user's Send to Kindle settings
+func (s *Server) updateKindleSettings(c echo.Context) error {
+	var req KindleSettingsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var user db.User
+	if err := s.db.First(&user, currentUserID(c)).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if email != "" && !kindle.ValidateKindleEmail(email) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Kindle email must end with @kindle.com"})
+		}
+		if err := s.db.Model(&user).Update("kindle_email", email).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save Kindle email"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Settings updated"})
+}
+
+// sendToKindle sends a book to the current user's Kindle via email
+func (s *Server) sendToKindle(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var file db.MediaFile
+	if err := s.db.First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+
+	if file.MediaType != db.MediaTypeEbook {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only ebooks can be sent to Kindle"})
+	}
+
+	var user db.User
+	if err := s.db.First(&user, currentUserID(c)).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	if user.KindleEmail == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No Kindle email configured - set one in Settings"})
+	}
+
+	smtp := loadSMTPConfig(s.db)
+	if !smtp.Valid() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "SMTP server is not configured"})
+	}
+
+	sender := kindle.NewKindleSender(kindle.SMTPConfig{
+		Host:     smtp.Host,
+		Port:     smtp.Port,
+		Username: smtp.Username,
+		Password: smtp.Password,
+		From:     smtp.From,
+		UseTLS:   smtp.UseTLS,
+	})
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	result, sendErr := sender.SendWithConversion(ctx, file.FilePath, user.KindleEmail, media.NewEbookConverter())
+
+	history := db.SendHistory{
+		MediaFileID: file.ID,
+		UserID:      user.ID,
+		ToEmail:     user.KindleEmail,
+		FileName:    filepath.Base(file.FilePath),
+		Format:      result.Format,
+		FileSize:    result.Size,
+		Converted:   result.Converted,
+		Status:      "sent",
+	}
+	if sendErr != nil {
+		history.Status = "failed"
+		history.Error = sendErr.Error()
+	}
+	s.db.Create(&history)
+
+	if sendErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": sendErr.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "Sent to Kindle",
+		"toEmail":   user.KindleEmail,
+		"format":    result.Format,
+		"converted": result.Converted,
+	})
+}
+
+// getSendHistory returns the current user's send history for a media file, or
+// everyone's for admins
+func (s *Server) getSendHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var file db.MediaFile
+	if err := s.db.Select("id", "book_id").First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+
+	query := s.db.Where("media_file_id = ?", id)
+	if !currentUserIsAdmin(c) {
+		query = query.Where("user_id = ?", currentUserID(c))
+	}
+	var entries []db.SendHistory
+	if err := query.Order("created_at DESC").Find(&entries).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]SendHistoryResponse, len(entries))
+	for i, e := range entries {
+		responses[i] = SendHistoryResponse{
+			ID:          e.ID,
+			MediaFileID: e.MediaFileID,
+			ToEmail:     e.ToEmail,
+			FileName:    e.FileName,
+			Format:      e.Format,
+			FileSize:    e.FileSize,
+			Converted:   e.Converted,
+			Status:      e.Status,
+			Error:       e.Error,
+			SentAt:      e.CreatedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, responses)
+}
@@ -0,0 +1,532 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/kobo"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"gorm.io/gorm"
+)
+
+// koboSyncPageSize is how many books one library sync response carries; the device
+// keeps syncing until it has them all
+const koboSyncPageSize = 100
+
+// KoboSyncResponse is returned when Kobo sync is enabled for a device
+type KoboSyncResponse struct {
+	// APIEndpoint goes in the api_endpoint setting of the device's
+	// .kobo/Kobo/Kobo eReader.conf, replacing https://storeapi.kobo.com
+	APIEndpoint string `json:"apiEndpoint"`
+}
+
+// enableKoboSync gives a Kobo device a sync URL, replacing any it had before
+func (s *Server) enableKoboSync(c echo.Context) error {
+	device, err := s.currentUserKobo(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate sync token"})
+	}
+	device.KoboSyncToken = hex.EncodeToString(secret)
+	if err := s.db.Save(device).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to enable Kobo sync"})
+	}
+
+	return c.JSON(http.StatusOK, KoboSyncResponse{APIEndpoint: koboBaseURL(c, device.KoboSyncToken)})
+}
+
+// disableKoboSync revokes a Kobo device's sync URL
+func (s *Server) disableKoboSync(c echo.Context) error {
+	device, err := s.currentUserKobo(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	if err := s.db.Model(device).Update("kobo_sync_token", "").Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to disable Kobo sync"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// currentUserKobo loads the current user's Kobo device named by the :id parameter
+func (s *Server) currentUserKobo(c echo.Context) (*db.Device, error) {
+	var device db.Device
+	err := s.db.Where("user_id = ? AND type = ?", currentUserID(c), "kobo").First(&device, c.Param("id")).Error
+	if err != nil {
+		return nil, fmt.Errorf("Kobo device not found")
+	}
+	return &device, nil
+}
+
+// koboAuth authenticates Kobo requests by the sync token in the URL, acting as the
+// device's owner
+func (s *Server) koboAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.Param("token")
+		var device db.Device
+		if token == "" || s.db.Where("kobo_sync_token = ? AND type = ?", token, "kobo").First(&device).Error != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unknown Kobo sync token")
+		}
+		c.Set("koboDevice", &device)
+		c.Set("userId", device.UserID)
+		return next(c)
+	}
+}
+
+// koboBaseURL is the root of a device's Kobo API, as seen by the device
+func koboBaseURL(c echo.Context, token string) string {
+	return c.Scheme() + "://" + c.Request().Host + currentURLBase() + "/kobo/" + token
+}
+
+// koboInitialization returns the store's resource URLs with the library ones pointed
+// at Shelfarr. The rest come from Kobo's store when it is reachable.
+func (s *Server) koboInitialization(c echo.Context) error {
+	resources := map[string]any{}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, kobo.StoreURL+"/v1/initialization", nil); err == nil {
+		req.Header.Set("Authorization", c.Request().Header.Get("Authorization"))
+		req.Header.Set("User-Agent", c.Request().Header.Get("User-Agent"))
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			var store struct {
+				Resources map[string]any `json:"Resources"`
+			}
+			if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&store) == nil && store.Resources != nil {
+				resources = store.Resources
+			}
+			resp.Body.Close()
+		} else {
+			slog.Debug("Kobo store initialization unavailable", "error", err)
+		}
+	}
+
+	base := koboBaseURL(c, c.Param("token"))
+	resources["image_host"] = c.Scheme() + "://" + c.Request().Host
+	resources["image_url_template"] = base + "/{ImageId}/{Width}/{Height}/false/image.jpg"
+	resources["image_url_quality_template"] = base + "/{ImageId}/{Width}/{Height}/{Quality}/{IsGreyscale}/image.jpg"
+	resources["library_sync"] = base + "/v1/library/sync"
+
+	c.Response().Header().Set("x-kobo-apitoken", "e30=")
+	return c.JSON(http.StatusOK, map[string]any{"Resources": resources})
+}
+
+// koboAuthDevice answers the device's token request. Access is controlled by the sync
+// token in the URL, so the tokens handed out here are placeholders.
+func (s *Server) koboAuthDevice(c echo.Context) error {
+	var req struct {
+		UserKey string `json:"UserKey"`
+	}
+	c.Bind(&req)
+
+	token := make([]byte, 24)
+	rand.Read(token)
+	return c.JSON(http.StatusOK, map[string]string{
+		"AccessToken":  hex.EncodeToString(token),
+		"RefreshToken": hex.EncodeToString(token),
+		"TokenType":    "Bearer",
+		"TrackingId":   kobo.NewUUID(),
+		"UserKey":      req.UserKey,
+	})
+}
+
+// koboBook is a library book as synced to a Kobo: its EPUB and when either last changed
+type koboBook struct {
+	book     db.Book
+	file     db.MediaFile
+	modified time.Time
+}
+
+// koboLibrarySync sends the books and reading states that changed since the device's
+// last sync. Only books with an EPUB are synced; the device downloads them as KEPUB.
+func (s *Server) koboLibrarySync(c echo.Context) error {
+	device := c.Get("koboDevice").(*db.Device)
+	token := kobo.ParseSyncToken(c.Request().Header.Get(kobo.HeaderSyncToken))
+
+	var books []db.Book
+	err := s.db.Unscoped().Scopes(s.koboVisibleBooks(c)).
+		Preload("Author").Preload("Series").
+		Preload("MediaFiles", "LOWER(format) = ?", "epub").
+		Where("EXISTS (SELECT 1 FROM media_files WHERE media_files.book_id = books.id AND LOWER(media_files.format) = 'epub' AND media_files.deleted_at IS NULL)").
+		Find(&books).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	var changed []koboBook
+	for _, book := range books {
+		if len(book.MediaFiles) == 0 {
+			continue
+		}
+		kb := koboBook{book: book, file: book.MediaFiles[0], modified: book.UpdatedAt}
+		for _, f := range book.MediaFiles {
+			if f.ImportedAt.After(kb.modified) {
+				kb.modified = f.ImportedAt
+			}
+		}
+		if book.DeletedAt.Valid {
+			if token.BooksLastModified.IsZero() {
+				continue // Never synced, so nothing to remove
+			}
+			kb.modified = book.DeletedAt.Time
+		}
+		if kb.modified.After(token.BooksLastModified) {
+			changed = append(changed, kb)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].modified.Before(changed[j].modified) })
+
+	more := len(changed) > koboSyncPageSize
+	if more {
+		changed = changed[:koboSyncPageSize]
+	}
+
+	states := s.koboReadingStates(device.UserID, token.ReadingStateLastModified)
+	base := koboBaseURL(c, device.KoboSyncToken)
+	items := []kobo.SyncItem{}
+	for _, kb := range changed {
+		entitlement := &kobo.Entitlement{
+			BookEntitlement: koboBookEntitlement(kb),
+			BookMetadata:    koboBookMetadata(base, kb),
+		}
+		if state, ok := states[kb.book.ID]; ok {
+			entitlement.ReadingState = state
+			delete(states, kb.book.ID)
+		}
+
+		// Books whose EPUB arrived since the last sync are new to the device
+		if kb.file.ImportedAt.After(token.BooksLastModified) && !kb.book.DeletedAt.Valid {
+			items = append(items, kobo.SyncItem{NewEntitlement: entitlement})
+		} else {
+			items = append(items, kobo.SyncItem{ChangedEntitlement: entitlement})
+		}
+		token.BooksLastModified = kb.modified
+	}
+
+	if !more {
+		// Reading states for books not in this response go out once every book has
+		for _, state := range states {
+			items = append(items, kobo.SyncItem{ChangedReadingState: &kobo.ChangedReadingState{ReadingState: *state}})
+		}
+		token.ReadingStateLastModified = s.koboLatestReadingState(device.UserID, token.ReadingStateLastModified)
+	}
+
+	c.Response().Header().Set(kobo.HeaderSyncToken, token.Encode())
+	if more {
+		c.Response().Header().Set(kobo.HeaderSync, "continue")
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// koboReadingStates returns the user's EPUB reading states changed since a time, by book
+func (s *Server) koboReadingStates(userID uint, since time.Time) map[uint]*kobo.ReadingState {
+	var progress []db.ReadProgress
+	s.db.Preload("MediaFile").
+		Joins("JOIN media_files ON media_files.id = read_progresses.media_file_id AND LOWER(media_files.format) = 'epub'").
+		Where("read_progresses.user_id = ? AND read_progresses.updated_at > ?", userID, since).
+		Find(&progress)
+
+	states := make(map[uint]*kobo.ReadingState, len(progress))
+	for i := range progress {
+		states[progress[i].MediaFile.BookID] = koboReadingState(progress[i].MediaFile.BookID, &progress[i])
+	}
+	return states
+}
+
+// koboLatestReadingState returns when the user's reading progress last changed, or since
+// if it hasn't changed after it
+func (s *Server) koboLatestReadingState(userID uint, since time.Time) time.Time {
+	var latest db.ReadProgress
+	if err := s.db.Where("user_id = ?", userID).Order("updated_at DESC").First(&latest).Error; err == nil && latest.UpdatedAt.After(since) {
+		return latest.UpdatedAt
+	}
+	return since
+}
+
+func koboBookEntitlement(kb koboBook) kobo.BookEntitlement {
+	id := kobo.EntitlementID(kb.book.ID)
+	return kobo.BookEntitlement{
+		Accessibility:   "Full",
+		ActivePeriod:    kobo.ActivePeriod{From: kobo.Timestamp(time.Now())},
+		Created:         kobo.Timestamp(kb.book.CreatedAt),
+		CrossRevisionID: id,
+		ID:              id,
+		IsRemoved:       kb.book.DeletedAt.Valid,
+		LastModified:    kobo.Timestamp(kb.modified),
+		OriginCategory:  "Imported",
+		RevisionID:      id,
+		Status:          "Active",
+	}
+}
+
+func koboBookMetadata(base string, kb koboBook) *kobo.BookMetadata {
+	id := kobo.EntitlementID(kb.book.ID)
+	metadata := &kobo.BookMetadata{
+		Categories:             []string{"00000000-0000-0000-0000-000000000001"},
+		CoverImageID:           id,
+		CrossRevisionID:        id,
+		CurrentDisplayPrice:    kobo.Price{CurrencyCode: "USD"},
+		Description:            kb.book.Description,
+		EntitlementID:          id,
+		ExternalIDs:            []string{},
+		Genre:                  "00000000-0000-0000-0000-000000000001",
+		IsSocialEnabled:        true,
+		Language:               kb.book.LanguageCode,
+		PhoneticPronunciations: map[string]any{},
+		RevisionID:             id,
+		Title:                  kb.book.Title,
+		WorkID:                 id,
+		DownloadUrls: []kobo.DownloadURL{{
+			Format:   "KEPUB",
+			Size:     kb.file.FileSize,
+			URL:      fmt.Sprintf("%s/download/%d/kepub", base, kb.book.ID),
+			Platform: "Generic",
+		}},
+	}
+	if kb.book.Author.Name != "" {
+		metadata.Contributors = []string{kb.book.Author.Name}
+		metadata.ContributorRoles = []kobo.Contributor{{Name: kb.book.Author.Name}}
+	}
+	if kb.book.ReleaseDate != nil {
+		metadata.PublicationDate = kobo.Timestamp(*kb.book.ReleaseDate)
+	}
+	if kb.book.Series != nil {
+		series := &kobo.Series{Name: kb.book.Series.Name, ID: kobo.EntitlementID(kb.book.Series.ID)}
+		if kb.book.SeriesIndex != nil {
+			series.NumberFloat = *kb.book.SeriesIndex
+			series.Number = int(*kb.book.SeriesIndex)
+		}
+		metadata.Series = series
+	}
+	return metadata
+}
+
+// koboReadingState converts saved reading progress to a Kobo reading state
+func koboReadingState(bookID uint, progress *db.ReadProgress) *kobo.ReadingState {
+	modified := kobo.Timestamp(progress.UpdatedAt)
+	status := kobo.StatusReading
+	switch {
+	case progress.Progress >= 1:
+		status = kobo.StatusFinished
+	case progress.Progress <= 0:
+		status = kobo.StatusReadyToRead
+	}
+
+	percent := progress.Progress * 100
+	bookmark := &kobo.Bookmark{LastModified: modified, ProgressPercent: &percent}
+	if progress.Location != "" {
+		var location kobo.Location
+		if json.Unmarshal([]byte(progress.Location), &location) == nil {
+			bookmark.Location = &location
+		}
+	}
+
+	return &kobo.ReadingState{
+		EntitlementID:     kobo.EntitlementID(bookID),
+		Created:           kobo.Timestamp(progress.CreatedAt),
+		LastModified:      modified,
+		PriorityTimestamp: modified,
+		StatusInfo:        &kobo.StatusInfo{LastModified: modified, Status: status, TimesStartedReading: 1},
+		Statistics:        &kobo.Statistics{LastModified: modified},
+		CurrentBookmark:   bookmark,
+	}
+}
+
+// koboVisibleBooks limits a query on books to those the device's user can see
+func (s *Server) koboVisibleBooks(c echo.Context) func(*gorm.DB) *gorm.DB {
+	return s.libraryAccessFor(c.Get("koboDevice").(*db.Device).UserID).books
+}
+
+// koboLoadBook loads a library book the device's user can see and the EPUB synced to
+// Kobo devices for it
+func (s *Server) koboLoadBook(c echo.Context, bookID uint) (*db.Book, *db.MediaFile, error) {
+	var book db.Book
+	if err := s.db.Scopes(s.koboVisibleBooks(c)).Preload("Author").Preload("Series").First(&book, bookID).Error; err != nil {
+		return nil, nil, err
+	}
+	var file db.MediaFile
+	if err := s.db.Where("book_id = ? AND LOWER(format) = ?", book.ID, "epub").First(&file).Error; err != nil {
+		return nil, nil, err
+	}
+	return &book, &file, nil
+}
+
+// koboEntitledBook loads the book named by the :uuid entitlement ID
+func (s *Server) koboEntitledBook(c echo.Context) (*db.Book, *db.MediaFile, error) {
+	bookID, err := kobo.ParseEntitlementID(c.Param("uuid"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.koboLoadBook(c, bookID)
+}
+
+// koboGetMetadata returns one book's metadata
+func (s *Server) koboGetMetadata(c echo.Context) error {
+	book, file, err := s.koboEntitledBook(c)
+	if err != nil {
+		return s.koboStoreRedirect(c)
+	}
+	kb := koboBook{book: *book, file: *file, modified: book.UpdatedAt}
+	return c.JSON(http.StatusOK, []*kobo.BookMetadata{koboBookMetadata(koboBaseURL(c, c.Param("token")), kb)})
+}
+
+// koboDownload serves a book's EPUB converted to KEPUB
+func (s *Server) koboDownload(c echo.Context) error {
+	bookID, err := strconv.ParseUint(c.Param("bookId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+	_, file, err := s.koboLoadBook(c, uint(bookID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+	kepubPath, err := s.cachedKepub(*file)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.Attachment(kepubPath, media.KepubFileName(file.FilePath))
+}
+
+// koboCover serves a book's cover image, scaled to the width the device asks for
+func (s *Server) koboCover(c echo.Context) error {
+	id, err := kobo.ParseEntitlementID(c.Param("uuid"))
+	if err != nil {
+		return s.koboStoreRedirect(c)
+	}
+	var book db.Book
+	if err := s.db.Scopes(s.koboVisibleBooks(c)).First(&book, id).Error; err != nil || bookCover(&book) == "" {
+		return c.NoContent(http.StatusNotFound)
+	}
+	width, _ := strconv.Atoi(c.Param("width"))
+	return s.serveCover(c, &book, width)
+}
+
+// koboGetReadingState returns the user's reading state for a book
+func (s *Server) koboGetReadingState(c echo.Context) error {
+	book, file, err := s.koboEntitledBook(c)
+	if err != nil {
+		return s.koboStoreRedirect(c)
+	}
+
+	var progress db.ReadProgress
+	if err := s.db.Where("user_id = ? AND media_file_id = ?", currentUserID(c), file.ID).First(&progress).Error; err != nil {
+		progress = db.ReadProgress{Model: gorm.Model{CreatedAt: book.CreatedAt, UpdatedAt: book.CreatedAt}}
+	}
+	return c.JSON(http.StatusOK, []*kobo.ReadingState{koboReadingState(book.ID, &progress)})
+}
+
+// koboUpdateReadingState saves the reading state the device reports for a book
+func (s *Server) koboUpdateReadingState(c echo.Context) error {
+	book, file, err := s.koboEntitledBook(c)
+	if err != nil {
+		return s.koboStoreRedirect(c)
+	}
+
+	var req kobo.ReadingStatesRequest
+	if err := c.Bind(&req); err != nil || len(req.ReadingStates) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid reading state"})
+	}
+	state := req.ReadingStates[0]
+
+	userID := currentUserID(c)
+	var progress db.ReadProgress
+	if err := s.db.Where("user_id = ? AND media_file_id = ?", userID, file.ID).First(&progress).Error; err != nil {
+		progress = db.ReadProgress{UserID: userID, MediaFileID: file.ID}
+	}
+	wasFinished := progress.Progress >= 1
+
+	if bookmark := state.CurrentBookmark; bookmark != nil {
+		if bookmark.ProgressPercent != nil {
+			progress.Progress = *bookmark.ProgressPercent / 100
+		}
+		if bookmark.Location != nil {
+			if location, err := json.Marshal(bookmark.Location); err == nil {
+				progress.Location = string(location)
+			}
+		}
+	}
+	if info := state.StatusInfo; info != nil {
+		switch info.Status {
+		case kobo.StatusFinished:
+			progress.Progress = 1
+		case kobo.StatusReadyToRead:
+			progress.Progress = 0
+		}
+	}
+	progress.LastReadAt = time.Now()
+
+	// The Kobo is one of the user's devices, whose position may not be the one they resume from
+	deviceName := c.Get("koboDevice").(*db.Device).Name
+	if deviceName == "" {
+		deviceName = "Kobo"
+	}
+	_, _, err = s.saveDeviceProgress(db.DeviceProgress{
+		UserID:      userID,
+		MediaFileID: file.ID,
+		Device:      deviceName,
+		Progress:    progress.Progress,
+		RecordedAt:  progress.LastReadAt,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save reading state"})
+	}
+	resolveProgress(&progress, s.deviceProgress(userID, file.ID), s.progressStrategy(userID))
+	markFinished(&progress, wasFinished)
+
+	if err := s.db.Save(&progress).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save reading state"})
+	}
+	if progress.Progress >= 1 && !wasFinished {
+		s.pushReadToHardcover(book)
+	}
+
+	success := kobo.Result{Result: "Success"}
+	return c.JSON(http.StatusOK, kobo.ReadingStatesResponse{
+		RequestResult: "Success",
+		UpdateResults: []kobo.UpdateResult{{
+			EntitlementID:         state.EntitlementID,
+			CurrentBookmarkResult: success,
+			StatisticsResult:      success,
+			StatusInfoResult:      success,
+		}},
+	})
+}
+
+// koboArchive acknowledges a book removed on the device; the library keeps it
+func (s *Server) koboArchive(c echo.Context) error {
+	if _, err := kobo.ParseEntitlementID(c.Param("uuid")); err != nil {
+		return s.koboStoreRedirect(c)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// koboStoreRedirect sends requests Shelfarr doesn't handle to Kobo's store, so store
+// features keep working. Requests that can't be redirected get an empty response.
+func (s *Server) koboStoreRedirect(c echo.Context) error {
+	path := strings.TrimPrefix(c.Request().URL.Path, "/kobo/"+c.Param("token"))
+	if c.Request().Method == http.MethodGet {
+		target := kobo.StoreURL + path
+		if c.Request().URL.RawQuery != "" {
+			target += "?" + c.Request().URL.RawQuery
+		}
+		return c.Redirect(http.StatusTemporaryRedirect, target)
+	}
+	io.Copy(io.Discard, c.Request().Body)
+	return c.JSON(http.StatusOK, map[string]any{})
+}
@@ -3,9 +3,11 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
 )
 
 // LibraryResponse represents the library grid data
@@ -22,9 +24,11 @@ type BookResponse struct {
 	HardcoverID  string              `json:"hardcoverId"`
 	Title        string              `json:"title"`
 	SortTitle    string              `json:"sortTitle"`
+	Subtitle     string              `json:"subtitle,omitempty"`
 	ISBN         string              `json:"isbn"`
 	Description  string              `json:"description"`
 	CoverURL     string              `json:"coverUrl"`
+	CustomCover  bool                `json:"customCover,omitempty"` // Cover picked or uploaded by the user
 	Rating       float32             `json:"rating"`
 	ReleaseDate  string              `json:"releaseDate,omitempty"`
 	PageCount    int                 `json:"pageCount"`
@@ -37,6 +41,14 @@ type BookResponse struct {
 	HasEbook     bool                `json:"hasEbook"`
 	HasAudiobook bool                `json:"hasAudiobook"`
 	Format       string              `json:"format,omitempty"` // Primary format badge
+	Narrators    []string            `json:"narrators,omitempty"`
+	Genres       []string            `json:"genres,omitempty"`
+	LockedFields []string            `json:"lockedFields,omitempty"` // Edited by hand, left alone by refreshes
+	Tags         []uint              `json:"tags,omitempty"`         // The book's own tags, not its author's
+
+	// Root folders the book's files are imported into; unset uses the default
+	EbookRootFolderID     *uint `json:"ebookRootFolderId,omitempty"`
+	AudiobookRootFolderID *uint `json:"audiobookRootFolderId,omitempty"`
 }
 
 // AuthorResponse represents an author in API responses
@@ -46,10 +58,12 @@ type AuthorResponse struct {
 	Name            string `json:"name"`
 	SortName        string `json:"sortName"`
 	ImageURL        string `json:"imageUrl"`
+	CustomImage     bool   `json:"customImage,omitempty"` // Photo uploaded by the user
 	Monitored       bool   `json:"monitored"`
 	BookCount       int    `json:"bookCount,omitempty"`       // Books in library
 	TotalBooksCount int    `json:"totalBooksCount,omitempty"` // Total books from Hardcover (cached)
 	DownloadedCount int    `json:"downloadedCount,omitempty"` // Books with files
+	Tags            []uint `json:"tags,omitempty"`
 }
 
 // SeriesResponse represents a series in API responses
@@ -72,6 +86,7 @@ type MediaFileResponse struct {
 	MediaType   string `json:"mediaType"`
 	Bitrate     int    `json:"bitrate,omitempty"`
 	Duration    int    `json:"duration,omitempty"`
+	PageCount   int    `json:"pageCount,omitempty"`
 	EditionName string `json:"editionName,omitempty"`
 }
 
@@ -128,6 +143,14 @@ func (s *Server) getLibrary(c echo.Context) error {
 	if seriesID != "" {
 		query = query.Where("series_id = ?", seriesID)
 	}
+	if tags := parseTagIDs(c.QueryParam("tags")); len(tags) > 0 {
+		query = query.Scopes(db.BooksTagged(tags))
+	}
+	filter, ok := s.savedFilterScope(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Filter not found"})
+	}
+	query = query.Scopes(filter, s.visibleBooks(c))
 	if mediaType != "" {
 		query = query.Joins("JOIN media_files ON media_files.book_id = books.id").
 			Where("media_files.media_type = ?", mediaType).
@@ -150,6 +173,72 @@ func (s *Server) getLibrary(c echo.Context) error {
 	for i, book := range books {
 		bookResponses[i] = bookToResponse(book)
 	}
+	s.addBookTags(bookResponses)
+
+	return c.JSON(http.StatusOK, LibraryResponse{
+		Books:    bookResponses,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// searchLibrary searches the library's titles, subtitles, descriptions, authors and
+// series, most relevant first. Words match as prefixes and "quoted words" as phrases.
+// Without the full-text index (SQLite built without FTS5), words are matched with LIKE.
+func (s *Server) searchLibrary(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	q := strings.TrimSpace(c.QueryParam("q"))
+	if q == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "q is required"})
+	}
+
+	query := s.db.Model(&db.Book{}).Scopes(s.visibleBooks(c))
+	if db.HasSearchIndex(s.db) {
+		match := db.SearchQuery(q)
+		if match == "" {
+			return c.JSON(http.StatusOK, LibraryResponse{Books: []BookResponse{}, Page: page, PageSize: pageSize})
+		}
+		query = query.Scopes(db.SearchBooks(match))
+	} else {
+		op := db.Like(s.db)
+		for _, term := range strings.Fields(strings.ReplaceAll(q, `"`, " ")) {
+			like := "%" + term + "%"
+			query = query.Where(
+				"books.title "+op+" ? OR books.subtitle "+op+" ? OR books.description "+op+" ?"+
+					" OR books.author_id IN (?) OR books.series_id IN (?)",
+				like, like, like,
+				s.db.Model(&db.Author{}).Select("id").Where("name "+op+" ?", like),
+				s.db.Model(&db.Series{}).Select("id").Where("name "+op+" ?", like),
+			)
+		}
+		query = query.Order("books.title")
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var books []db.Book
+	err := query.Preload("Author").Preload("Series").Preload("MediaFiles").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&books).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	bookResponses := make([]BookResponse, len(books))
+	for i, book := range books {
+		bookResponses[i] = bookToResponse(book)
+	}
+	s.addBookTags(bookResponses)
 
 	return c.JSON(http.StatusOK, LibraryResponse{
 		Books:    bookResponses,
@@ -159,21 +248,23 @@ func (s *Server) getLibrary(c echo.Context) error {
 	})
 }
 
-// getLibraryStats returns library statistics
+// getLibraryStats returns statistics of the part of the library the user can see
 func (s *Server) getLibraryStats(c echo.Context) error {
 	var stats LibraryStatsResponse
 
-	s.db.Model(&db.Book{}).Count(&stats.TotalBooks)
-	s.db.Model(&db.Book{}).Where("monitored = ?", true).Count(&stats.MonitoredBooks)
-	s.db.Model(&db.Book{}).Where("status = ?", db.StatusDownloaded).Count(&stats.DownloadedBooks)
-	s.db.Model(&db.Book{}).Where("status = ?", db.StatusMissing).Count(&stats.MissingBooks)
-	s.db.Model(&db.Author{}).Count(&stats.TotalAuthors)
-	s.db.Model(&db.Series{}).Count(&stats.TotalSeries)
-	s.db.Model(&db.MediaFile{}).Where("media_type = ?", db.MediaTypeEbook).Count(&stats.TotalEbooks)
-	s.db.Model(&db.MediaFile{}).Where("media_type = ?", db.MediaTypeAudiobook).Count(&stats.TotalAudiobooks)
+	books := s.visibleBooks(c)
+	files := s.ofVisibleBooks(c, "media_files.book_id", "books.id")
+	s.db.Model(&db.Book{}).Scopes(books).Count(&stats.TotalBooks)
+	s.db.Model(&db.Book{}).Scopes(books).Where("monitored = ?", true).Count(&stats.MonitoredBooks)
+	s.db.Model(&db.Book{}).Scopes(books).Where("status = ?", db.StatusDownloaded).Count(&stats.DownloadedBooks)
+	s.db.Model(&db.Book{}).Scopes(books).Where("status = ?", db.StatusMissing).Count(&stats.MissingBooks)
+	s.db.Model(&db.Author{}).Scopes(s.ofVisibleBooks(c, "authors.id", "books.author_id")).Count(&stats.TotalAuthors)
+	s.db.Model(&db.Series{}).Scopes(s.ofVisibleBooks(c, "series.id", "books.series_id")).Count(&stats.TotalSeries)
+	s.db.Model(&db.MediaFile{}).Scopes(files).Where("media_type = ?", db.MediaTypeEbook).Count(&stats.TotalEbooks)
+	s.db.Model(&db.MediaFile{}).Scopes(files).Where("media_type = ?", db.MediaTypeAudiobook).Count(&stats.TotalAudiobooks)
 
 	var totalSize struct{ Total int64 }
-	s.db.Model(&db.MediaFile{}).Select("COALESCE(SUM(file_size), 0) as total").Scan(&totalSize)
+	s.db.Model(&db.MediaFile{}).Scopes(files).Select("COALESCE(SUM(file_size), 0) as total").Scan(&totalSize)
 	stats.TotalFileSize = totalSize.Total
 
 	return c.JSON(http.StatusOK, stats)
@@ -186,14 +277,19 @@ func bookToResponse(book db.Book) BookResponse {
 		HardcoverID: book.HardcoverID,
 		Title:       book.Title,
 		SortTitle:   book.SortTitle,
+		Subtitle:    book.Subtitle,
 		ISBN:        book.ISBN,
 		Description: book.Description,
-		CoverURL:    book.CoverURL,
+		CoverURL:    coverImageURL(&book),
+		CustomCover: book.CustomCover != "",
 		Rating:      book.Rating,
 		PageCount:   book.PageCount,
 		Status:      string(book.Status),
 		Monitored:   book.Monitored,
 		SeriesIndex: book.SeriesIndex,
+
+		EbookRootFolderID:     book.EbookRootFolderID,
+		AudiobookRootFolderID: book.AudiobookRootFolderID,
 	}
 
 	if book.ReleaseDate != nil {
@@ -206,7 +302,7 @@ func bookToResponse(book db.Book) BookResponse {
 			HardcoverID: book.Author.HardcoverID,
 			Name:        book.Author.Name,
 			SortName:    book.Author.SortName,
-			ImageURL:    book.Author.ImageURL,
+			ImageURL:    authorImageURL(&book.Author),
 			Monitored:   book.Author.Monitored,
 		}
 	}
@@ -219,6 +315,14 @@ func bookToResponse(book db.Book) BookResponse {
 		}
 	}
 
+	resp.Narrators = bookNarrators(book)
+	for _, genre := range book.Genres {
+		resp.Genres = append(resp.Genres, genre.Name)
+	}
+	if locked := lockedFields(&book); len(locked) > 0 {
+		resp.LockedFields = lockedFieldList(locked)
+	}
+
 	// Process media files
 	for _, mf := range book.MediaFiles {
 		resp.MediaFiles = append(resp.MediaFiles, MediaFileResponse{
@@ -230,6 +334,7 @@ func bookToResponse(book db.Book) BookResponse {
 			MediaType:   string(mf.MediaType),
 			Bitrate:     mf.Bitrate,
 			Duration:    mf.Duration,
+			PageCount:   mf.PageCount,
 			EditionName: mf.EditionName,
 		})
 
@@ -245,3 +350,45 @@ func bookToResponse(book db.Book) BookResponse {
 
 	return resp
 }
+
+// bookNarrators returns narrator names from preloaded contributors, falling back to
+// Audible narrators on preloaded audiobook editions
+func bookNarrators(book db.Book) []string {
+	var narrators []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			narrators = append(narrators, name)
+		}
+	}
+
+	for _, c := range book.Contributors {
+		if c.Role == db.RoleNarrator {
+			add(c.Author.Name)
+		}
+	}
+	if len(narrators) > 0 {
+		return narrators
+	}
+
+	for _, ed := range book.Editions {
+		for _, name := range strings.Split(ed.Narrators, ",") {
+			add(name)
+		}
+	}
+	return narrators
+}
+
+// addBookTags fills in the tags of book responses
+func (s *Server) addBookTags(responses []BookResponse) {
+	ids := make([]uint, len(responses))
+	for i, resp := range responses {
+		ids[i] = resp.ID
+	}
+	tags := db.BookTags.IDs(s.db, ids...)
+	for i := range responses {
+		responses[i].Tags = tags[responses[i].ID]
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// Library access. An admin can restrict a user's library, as for a child's account, to
+// the books sharing one of the user's tags, directly or through their author, and the
+// books in collections shared with them. Restricted users can't see or stream the rest,
+// in the web UI or the OPDS catalog. Admins always see everything.
+
+// UserLibraryRequest sets what a user can see of the library
+type UserLibraryRequest struct {
+	Restricted    bool   `json:"restricted"`
+	Tags          []uint `json:"tags"`
+	CollectionIDs []uint `json:"collectionIds"` // Collections shared with the user, whoever owns them
+}
+
+// UserLibraryResponse is what a user can see of the library
+type UserLibraryResponse struct {
+	Restricted    bool   `json:"restricted"`
+	Tags          []uint `json:"tags"`
+	CollectionIDs []uint `json:"collectionIds"`
+	BookCount     int64  `json:"bookCount"` // Books the user can see
+}
+
+// libraryAccess is what a user can see of the library
+type libraryAccess struct {
+	restricted    bool
+	tagIDs        []uint
+	collectionIDs []uint
+}
+
+// libraryAccessFor loads what a user can see of the library
+func (s *Server) libraryAccessFor(userID uint) libraryAccess {
+	var user db.User
+	if err := s.db.Select("id", "is_admin", "library_restricted").First(&user, userID).Error; err != nil || user.IsAdmin || !user.LibraryRestricted {
+		return libraryAccess{}
+	}
+	return libraryAccess{
+		restricted:    true,
+		tagIDs:        db.UserTags.IDs(s.db, userID)[userID],
+		collectionIDs: s.sharedCollectionIDs(userID),
+	}
+}
+
+// currentLibraryAccess returns what the current user can see of the library: all of it
+// for admins, including the API key and AUTH_DISABLED
+func (s *Server) currentLibraryAccess(c echo.Context) libraryAccess {
+	if currentUserIsAdmin(c) {
+		return libraryAccess{}
+	}
+	return s.libraryAccessFor(currentUserID(c))
+}
+
+// visibleBooks limits a query on books to those the current user can see
+func (s *Server) visibleBooks(c echo.Context) func(*gorm.DB) *gorm.DB {
+	return s.currentLibraryAccess(c).books
+}
+
+// books limits a query on books to those the user can see
+func (access libraryAccess) books(query *gorm.DB) *gorm.DB {
+	if !access.restricted {
+		return query
+	}
+	newDB := query.Session(&gorm.Session{NewDB: true})
+	return query.Where("(books.id IN (?) OR books.author_id IN (?) OR books.id IN (?))",
+		newDB.Table("book_tags").Select("book_id").Where("tag_id IN ?", access.tagIDs),
+		newDB.Table("author_tags").Select("author_id").Where("tag_id IN ?", access.tagIDs),
+		newDB.Table("collection_books").Select("book_id").Where("collection_id IN ?", access.collectionIDs))
+}
+
+// ofVisibleBooks limits a query to the rows whose column matches bookColumn of a book
+// the current user can see, such as downloads with "downloads.book_id" and "books.id",
+// or authors with "authors.id" and "books.author_id"
+func (s *Server) ofVisibleBooks(c echo.Context, column, bookColumn string) func(*gorm.DB) *gorm.DB {
+	access := s.currentLibraryAccess(c)
+	return func(query *gorm.DB) *gorm.DB {
+		if !access.restricted {
+			return query
+		}
+		return query.Where(column+" IN (?)",
+			query.Session(&gorm.Session{NewDB: true}).Model(&db.Book{}).Select(bookColumn).Scopes(access.books))
+	}
+}
+
+// canSeeBook reports whether the current user can see a book
+func (s *Server) canSeeBook(c echo.Context, bookID uint) bool {
+	var count int64
+	s.db.Model(&db.Book{}).Where("books.id = ?", bookID).Scopes(s.visibleBooks(c)).Count(&count)
+	return count > 0
+}
+
+// sharedCollectionIDs returns the collections shared with a user
+func (s *Server) sharedCollectionIDs(userID uint) []uint {
+	var ids []uint
+	s.db.Table("collection_shares").Where("user_id = ?", userID).Order("collection_id").Pluck("collection_id", &ids)
+	return ids
+}
+
+// visibleCollections limits a query on collections to the current user's own and
+// those shared with them
+func visibleCollections(c echo.Context) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		userID := currentUserID(c)
+		return query.Where("(collections.user_id = ? OR collections.id IN (?))", userID,
+			query.Session(&gorm.Session{NewDB: true}).Table("collection_shares").Select("collection_id").Where("user_id = ?", userID))
+	}
+}
+
+// getUserLibrary returns what a user can see of the library
+func (s *Server) getUserLibrary(c echo.Context) error {
+	var user db.User
+	if err := s.db.First(&user, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	return c.JSON(http.StatusOK, s.userLibraryResponse(user))
+}
+
+// setUserLibrary sets what a user can see of the library
+func (s *Server) setUserLibrary(c echo.Context) error {
+	var user db.User
+	if err := s.db.First(&user, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	var req UserLibraryRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if !s.validTagIDs(req.Tags) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown tag"})
+	}
+	collectionIDs := uniqueIDs(req.CollectionIDs)
+	var count int64
+	s.db.Model(&db.Collection{}).Where("id IN ?", collectionIDs).Count(&count)
+	if int(count) != len(collectionIDs) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown collection"})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("library_restricted", req.Restricted).Error; err != nil {
+			return err
+		}
+		if err := db.UserTags.Set(tx, user.ID, uniqueIDs(req.Tags)); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM collection_shares WHERE user_id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		for _, id := range collectionIDs {
+			if err := tx.Table("collection_shares").Create(map[string]any{"collection_id": id, "user_id": user.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update the user's library"})
+	}
+	setAudit(c, "user.library", "Changed what "+user.Username+" can see of the library")
+
+	return c.JSON(http.StatusOK, s.userLibraryResponse(user))
+}
+
+// userLibraryResponse describes what a user can see of the library
+func (s *Server) userLibraryResponse(user db.User) UserLibraryResponse {
+	response := UserLibraryResponse{
+		Restricted:    user.LibraryRestricted,
+		Tags:          tagIDsOrEmpty(db.UserTags.IDs(s.db, user.ID)[user.ID]),
+		CollectionIDs: s.sharedCollectionIDs(user.ID),
+	}
+	if response.CollectionIDs == nil {
+		response.CollectionIDs = []uint{}
+	}
+	s.db.Model(&db.Book{}).Scopes(s.libraryAccessFor(user.ID).books).Count(&response.BookCount)
+	return response
+}
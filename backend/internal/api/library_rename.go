@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"gorm.io/gorm"
+)
+
+// LibraryRename is a library file whose path doesn't follow the naming templates
+type LibraryRename struct {
+	MediaFileID uint   `json:"mediaFileId"`
+	BookID      uint   `json:"bookId"`
+	BookTitle   string `json:"bookTitle"`
+	CurrentPath string `json:"currentPath"`
+	NewPath     string `json:"newPath"`
+	Conflict    string `json:"conflict,omitempty"` // Why the file can't be renamed
+}
+
+// LibraryRenameResult summarizes renaming library files
+type LibraryRenameResult struct {
+	Renamed int             `json:"renamed"`
+	Skipped []LibraryRename `json:"skipped"` // Renames with a conflict
+}
+
+// getLibraryRenames lists the library files whose paths differ from what the naming
+// templates give, as a dry run. bookId limits it to one book.
+func (s *Server) getLibraryRenames(c echo.Context) error {
+	var bookID uint
+	if id := c.QueryParam("bookId"); id != "" {
+		parsed, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+		}
+		bookID = uint(parsed)
+	}
+
+	renames, err := s.planLibraryRenames(bookID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, renames)
+}
+
+// renameLibraryFiles moves library files to the paths the naming templates give.
+// mediaFileIds limits it to some files, as picked from the dry run. The files are
+// moved and their paths updated together: if any move fails, the files already moved
+// are put back and nothing changes.
+func (s *Server) renameLibraryFiles(c echo.Context) error {
+	var req struct {
+		BookID       uint   `json:"bookId"` // 0 for the whole library
+		MediaFileIDs []uint `json:"mediaFileIds"`
+	}
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	renames, err := s.planLibraryRenames(req.BookID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if len(req.MediaFileIDs) > 0 {
+		wanted := make(map[uint]bool, len(req.MediaFileIDs))
+		for _, id := range req.MediaFileIDs {
+			wanted[id] = true
+		}
+		selected := renames[:0]
+		for _, rename := range renames {
+			if wanted[rename.MediaFileID] {
+				selected = append(selected, rename)
+			}
+		}
+		renames = selected
+	}
+
+	result, err := s.applyLibraryRenames(renames)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// planLibraryRenames works out where each library file belongs under the naming
+// templates. Files stay in the root folder they're in.
+func (s *Server) planLibraryRenames(bookID uint) ([]LibraryRename, error) {
+	query := s.db.Preload("Book").Preload("Book.Author").Preload("Book.Series").Order("id")
+	if bookID != 0 {
+		query = query.Where("book_id = ?", bookID)
+	}
+	var files []db.MediaFile
+	if err := query.Find(&files).Error; err != nil {
+		return nil, err
+	}
+
+	opts := s.namingOptions()
+	roots := s.libraryRoots()
+	renames := []LibraryRename{}
+	targets := make(map[string]int) // New path to its index in renames
+	for _, file := range files {
+		newPath := s.expectedPath(&file, roots, opts)
+		if newPath == "" || newPath == file.FilePath {
+			continue
+		}
+
+		rename := LibraryRename{
+			MediaFileID: file.ID,
+			BookID:      file.BookID,
+			BookTitle:   file.Book.Title,
+			CurrentPath: file.FilePath,
+			NewPath:     newPath,
+		}
+		if i, taken := targets[newPath]; taken {
+			rename.Conflict = "Another file would be renamed to the same path"
+			renames[i].Conflict = rename.Conflict
+		} else if _, err := os.Stat(newPath); err == nil {
+			rename.Conflict = "A file already exists at the new path"
+		} else if _, err := os.Stat(file.FilePath); err != nil {
+			rename.Conflict = "File is missing"
+		}
+		targets[newPath] = len(renames)
+		renames = append(renames, rename)
+	}
+	return renames, nil
+}
+
+// expectedPath returns the path a library file should have under the naming templates,
+// in the root folder it's in, or "" if it isn't in a known root
+func (s *Server) expectedPath(file *db.MediaFile, roots []string, opts media.NamingOptions) string {
+	root := ""
+	for _, candidate := range roots {
+		if underAny(file.FilePath, []string{candidate}) && len(candidate) > len(root) {
+			root = candidate
+		}
+	}
+	if root == "" {
+		return ""
+	}
+
+	// Keep the file's own extension, which the format doesn't always match (.kepub.epub)
+	format := ""
+	if info, err := os.Stat(file.FilePath); err != nil || !info.IsDir() {
+		format = strings.TrimPrefix(filepath.Ext(file.FilePath), ".")
+	}
+
+	paths := media.NewPathBuilder(root, root)
+	paths.SetNaming(opts)
+	return paths.BuildPath(string(file.MediaType), namingValues(&file.Book, format))
+}
+
+// applyLibraryRenames moves files and updates their paths in one transaction, putting
+// moved files back if anything fails. Renames with a conflict are skipped.
+func (s *Server) applyLibraryRenames(renames []LibraryRename) (*LibraryRenameResult, error) {
+	result := &LibraryRenameResult{Skipped: []LibraryRename{}}
+	roots := s.libraryRoots()
+
+	type move struct{ from, to string }
+	var moved []move
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, rename := range renames {
+			if rename.Conflict != "" {
+				result.Skipped = append(result.Skipped, rename)
+				continue
+			}
+
+			if err := movePath(rename.CurrentPath, rename.NewPath); err != nil {
+				return fmt.Errorf("could not move %s: %w", rename.CurrentPath, err)
+			}
+			moved = append(moved, move{rename.CurrentPath, rename.NewPath})
+
+			if err := tx.Model(&db.MediaFile{}).Where("id = ?", rename.MediaFileID).Updates(map[string]interface{}{
+				"file_path": rename.NewPath,
+				"file_name": filepath.Base(rename.NewPath),
+			}).Error; err != nil {
+				return err
+			}
+			result.Renamed++
+		}
+		return nil
+	})
+	if err != nil {
+		for i := len(moved) - 1; i >= 0; i-- {
+			if undoErr := movePath(moved[i].to, moved[i].from); undoErr != nil {
+				libraryLog.Warn("Rename: could not move file back", "from", moved[i].to, "to", moved[i].from, "error", undoErr)
+			}
+			removeEmptyParents(moved[i].to, roots)
+		}
+		return nil, err
+	}
+
+	for _, m := range moved {
+		removeEmptyParents(m.from, roots)
+	}
+	libraryLog.Info("Renamed library files", "renamed", result.Renamed, "skipped", len(result.Skipped))
+	return result, nil
+}
+
+// movePath moves a file or folder, creating the folders it goes into
+func movePath(from, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return errors.New("destination already exists")
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return media.NewFileOperator(media.OpMove).ImportFile(from, to, media.OpMove)
+	}
+	return os.Rename(from, to) // Folders only move within a filesystem
+}
+
+// removeEmptyParents removes the folders a moved file leaves empty, up to the first one
+// that still holds something. Root folders are kept.
+func removeEmptyParents(path string, roots []string) {
+	for dir := filepath.Dir(path); underAny(dir, roots); dir = filepath.Dir(dir) {
+		for _, root := range roots {
+			if dir == root {
+				return
+			}
+		}
+		if os.Remove(dir) != nil { // Fails on folders that aren't empty
+			return
+		}
+	}
+}
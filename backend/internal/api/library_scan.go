@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// minScanMatchScore is how closely a file found by a library scan must match a book to
+// be added to it without asking
+const minScanMatchScore = 85
+
+// LibraryScanResult summarizes a library scan
+type LibraryScanResult struct {
+	Added             int      `json:"added"`             // Files found on disk and matched to a book
+	Moved             int      `json:"moved"`             // Known files found at a new path
+	Removed           int      `json:"removed"`           // Known files no longer on disk
+	BooksMissingFiles int      `json:"booksMissingFiles"` // Books reverted to missing because their files are gone
+	Unmatched         []string `json:"unmatched"`         // Files that couldn't be matched to a book
+}
+
+// libraryItem is a book file found on disk. Audiobooks split into several files are
+// one item, their folder.
+type libraryItem struct {
+	path      string
+	mediaType string
+	format    string
+	size      int64
+	root      string
+	isDir     bool
+}
+
+var seriesPrefixPattern = regexp.MustCompile(`^\d+(\.\d+)?\s*-\s*`)
+
+// scanLibrary runs a library scan on demand
+func (s *Server) scanLibrary(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Minute)
+	defer cancel()
+
+	result, err := s.rescanLibrary(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// runLibraryScan is the scheduled library scan
+func (s *Server) runLibraryScan(ctx context.Context) error {
+	_, err := s.rescanLibrary(ctx)
+	return err
+}
+
+// rescanLibrary reconciles the media files table with the root folders. New files are
+// matched to library books by their path, files that moved are followed, and files
+// that are gone are removed, reverting their books to missing when they have no other
+// files. Roots that are missing or empty are skipped, so an unmounted share doesn't
+// look like a deleted library.
+func (s *Server) rescanLibrary(ctx context.Context) (*LibraryScanResult, error) {
+	result := &LibraryScanResult{Unmatched: []string{}}
+
+	var known []db.MediaFile
+	if err := s.db.Find(&known).Error; err != nil {
+		return nil, err
+	}
+	knownByPath := make(map[string]*db.MediaFile, len(known))
+	for i := range known {
+		knownByPath[known[i].FilePath] = &known[i]
+	}
+
+	// Find every book file in the roots that can be scanned
+	var scanned []string
+	var items []libraryItem
+	for _, root := range s.libraryRoots() {
+		rootItems, err := scanLibraryRoot(ctx, root, knownByPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(rootItems) == 0 {
+			libraryLog.Warn("Library scan: skipping root folder, which is missing or empty", "path", root)
+			continue
+		}
+		scanned = append(scanned, root)
+		items = append(items, rootItems...)
+	}
+
+	seen := make(map[string]bool, len(items))
+	var newItems []libraryItem
+	for _, item := range items {
+		if knownByPath[item.path] != nil {
+			seen[item.path] = true
+		} else {
+			newItems = append(newItems, item)
+		}
+	}
+
+	// Known files that are gone from a scanned root, by name and size to spot moves
+	gone := make(map[string]*db.MediaFile)
+	for i := range known {
+		file := &known[i]
+		if !seen[file.FilePath] && underAny(file.FilePath, scanned) {
+			if _, err := os.Stat(file.FilePath); os.IsNotExist(err) {
+				gone[movedKey(filepath.Base(file.FilePath), file.FileSize)] = file
+			}
+		}
+	}
+
+	var books []db.Book
+	for _, item := range newItems {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if file := gone[movedKey(filepath.Base(item.path), item.size)]; file != nil {
+			delete(gone, movedKey(filepath.Base(item.path), item.size))
+			s.db.Model(file).Updates(map[string]interface{}{
+				"file_path": item.path,
+				"file_name": filepath.Base(item.path),
+			})
+			result.Moved++
+			continue
+		}
+
+		if books == nil {
+			books = s.loadLibraryBooks()
+		}
+		candidates := s.libraryCandidates(libraryItemMatch(item), books)
+		if len(candidates) == 0 || candidates[0].Score < minScanMatchScore ||
+			(len(candidates) > 1 && candidates[1].Score == candidates[0].Score) {
+			result.Unmatched = append(result.Unmatched, item.path)
+			continue
+		}
+
+		file := db.MediaFile{
+			BookID:     candidates[0].BookID,
+			FilePath:   item.path,
+			FileName:   filepath.Base(item.path),
+			FileSize:   item.size,
+			Format:     item.format,
+			MediaType:  db.MediaType(item.mediaType),
+			PageCount:  pageCount(item.path),
+			ImportedAt: time.Now(),
+		}
+		if err := s.db.Create(&file).Error; err != nil {
+			libraryLog.Warn("Library scan: could not add file", "path", item.path, "error", err)
+			continue
+		}
+		s.db.Model(&db.Book{}).Where("id = ?", file.BookID).Update("status", db.StatusDownloaded)
+		result.Added++
+	}
+
+	// Whatever is still gone was deleted
+	affected := make(map[uint]bool)
+	for _, file := range gone {
+		if err := db.DeleteMediaFile(s.db, file); err != nil {
+			continue
+		}
+		affected[file.BookID] = true
+		result.Removed++
+	}
+	for bookID := range affected {
+		if s.markMissingIfNoFiles(bookID) {
+			result.BooksMissingFiles++
+		}
+	}
+
+	libraryLog.Info("Library scan finished", "added", result.Added, "moved", result.Moved, "removed", result.Removed, "unmatched", len(result.Unmatched))
+	return result, nil
+}
+
+// libraryRoots returns the configured books and audiobooks folders and any root folders
+func (s *Server) libraryRoots() []string {
+	roots := []string{s.config.BooksPath, s.config.AudiobooksPath}
+	var folders []db.RootFolder
+	s.db.Find(&folders)
+	for _, folder := range folders {
+		roots = append(roots, folder.Path)
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		root = filepath.Clean(root)
+		if !seen[root] {
+			seen[root] = true
+			unique = append(unique, root)
+		}
+	}
+	return unique
+}
+
+// scanLibraryRoot finds the book files in a root folder. Audio files that share a folder
+// with others are grouped into the folder, as the importer stores them, unless a single
+// file in it is already known.
+func scanLibraryRoot(ctx context.Context, root string, known map[string]*db.MediaFile) ([]libraryItem, error) {
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	scanner := media.NewScanner()
+	var files []libraryItem
+	audioPerFolder := make(map[string]int)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil // Skip what can't be read
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		mediaType := scanner.DetectMediaType(ext)
+		if mediaType == "" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if mediaType == "audiobook" {
+			audioPerFolder[filepath.Dir(path)]++
+		}
+		files = append(files, libraryItem{
+			path:      path,
+			mediaType: mediaType,
+			format:    strings.TrimPrefix(ext, "."),
+			size:      info.Size(),
+			root:      root,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []libraryItem
+	folders := make(map[string]*libraryItem)
+	for _, file := range files {
+		folder := filepath.Dir(file.path)
+		grouped := file.mediaType == "audiobook" && folder != root && known[file.path] == nil &&
+			(audioPerFolder[folder] > 1 || known[folder] != nil)
+		if !grouped {
+			items = append(items, file)
+			continue
+		}
+		if item, ok := folders[folder]; ok {
+			item.size += file.size
+			continue
+		}
+		folders[folder] = &libraryItem{path: folder, mediaType: "audiobook", format: file.format, size: file.size, root: root, isDir: true}
+	}
+	for _, item := range folders {
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// libraryItemMatch works out a file's book from its path. Libraries are laid out as
+// Author/Title/Title.ext or Author/Series/NN - Title/NN - Title.ext.
+func libraryItemMatch(item libraryItem) importMatch {
+	name := filepath.Base(item.path)
+	if !item.isDir {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	var match importMatch
+	if rel, err := filepath.Rel(item.root, item.path); err == nil {
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) > 1 {
+			match.author = parts[0]
+		}
+		if len(parts) > 3 {
+			match.series = parts[1]
+		}
+	}
+	if match.author == "" {
+		match.author, match.title, match.series, _ = media.NewScanner().ExtractMetadataFromFilename(filepath.Base(item.path))
+		return match
+	}
+	match.title = seriesPrefixPattern.ReplaceAllString(name, "")
+	return match
+}
+
+// movedKey identifies a file or folder by its name and size, to recognize it elsewhere
+func movedKey(name string, size int64) string {
+	return name + "\x00" + strconv.FormatInt(size, 10)
+}
+
+// underAny reports whether path is inside one of the roots
+func underAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,14 +15,15 @@ import (
 
 // ListRequest represents a request to create/update a Hardcover list
 type ListRequest struct {
-	Name           string `json:"name"`
-	HardcoverURL   string `json:"hardcoverUrl"`
+	Name           string `json:"name" validate:"max=200"`
+	HardcoverURL   string `json:"hardcoverUrl" validate:"url"`
 	HardcoverID    string `json:"hardcoverId"`
 	Enabled        bool   `json:"enabled"`
 	AutoAdd        bool   `json:"autoAdd"`
 	Monitor        bool   `json:"monitor"`
-	SyncInterval   int    `json:"syncInterval"` // hours
+	SyncInterval   int    `json:"syncInterval" validate:"min=0"` // hours
 	QualityProfile uint   `json:"qualityProfile,omitempty"`
+	Source         string `json:"source,omitempty" validate:"oneof=list want_to_read"` // list (default) or want_to_read
 }
 
 // getLists returns all configured Hardcover lists
@@ -33,12 +36,13 @@ func (s *Server) getLists(c echo.Context) error {
 // addList creates a new Hardcover list configuration
 func (s *Server) addList(c echo.Context) error {
 	var req ListRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	list := db.HardcoverList{
 		Name:            req.Name,
+		Source:          db.ListSourceList,
 		HardcoverURL:    req.HardcoverURL,
 		HardcoverID:     req.HardcoverID,
 		Enabled:         req.Enabled,
@@ -48,6 +52,12 @@ func (s *Server) addList(c echo.Context) error {
 		QualityProfile:  req.QualityProfile,
 	}
 
+	if req.Source == db.ListSourceWantToRead {
+		if err := s.setupWantToReadList(c.Request().Context(), &list); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
 	if err := s.db.Create(&list).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create list"})
 	}
@@ -68,13 +78,16 @@ func (s *Server) updateList(c echo.Context) error {
 	}
 
 	var req ListRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	list.Name = req.Name
-	list.HardcoverURL = req.HardcoverURL
-	list.HardcoverID = req.HardcoverID
+	if list.Source != db.ListSourceWantToRead {
+		// The shelf's URL and ID come from the API key's user
+		list.HardcoverURL = req.HardcoverURL
+		list.HardcoverID = req.HardcoverID
+	}
 	list.Enabled = req.Enabled
 	list.AutoAdd = req.AutoAdd
 	list.Monitor = req.Monitor
@@ -114,11 +127,13 @@ func (s *Server) syncList(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "List not found"})
 	}
 
-	// Create Hardcover client
-	client := hardcover.NewClientWithAPIKey(s.config.HardcoverAPIURL, s.config.HardcoverAPIKey)
+	client, err := s.getHardcoverClient()
+	if err != nil {
+		return err
+	}
 
 	// Sync the list
-	addedCount, err := syncHardcoverList(s.db, client, &list)
+	addedCount, err := syncHardcoverList(c.Request().Context(), s.db, client, &list)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to sync list: " + err.Error()})
 	}
@@ -134,13 +149,43 @@ func (s *Server) syncList(c echo.Context) error {
 	})
 }
 
-// syncHardcoverList syncs books from a Hardcover list
-func syncHardcoverList(gdb *gorm.DB, client *hardcover.Client, list *db.HardcoverList) (int, error) {
-	// Get books from the Hardcover list
-	// This would call the Hardcover API to fetch list contents
-	// For now, we'll use a placeholder implementation
+// setupWantToReadList points a new list at the Want to Read shelf of the API key's user.
+// Shelf books are always added; Monitor decides whether they are searched for.
+func (s *Server) setupWantToReadList(ctx context.Context, list *db.HardcoverList) error {
+	var count int64
+	s.db.Model(&db.HardcoverList{}).Where("source = ?", db.ListSourceWantToRead).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("the Want to Read shelf is already synced")
+	}
+
+	client, err := s.getHardcoverClient()
+	if err != nil {
+		return fmt.Errorf("Hardcover API key not configured")
+	}
+	user, err := client.GetMe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to look up the Hardcover user: %w", err)
+	}
+
+	list.Source = db.ListSourceWantToRead
+	list.HardcoverID = user.ID
+	list.HardcoverURL = "https://hardcover.app/@" + user.Username + "/books/want-to-read"
+	list.AutoAdd = true
+	if list.Name == "" {
+		list.Name = "Want to Read"
+	}
+	return nil
+}
 
-	result, err := client.GetListBooks(list.HardcoverID)
+// syncHardcoverList syncs books from a Hardcover list or the Want to Read shelf
+func syncHardcoverList(ctx context.Context, gdb *gorm.DB, client *hardcover.Client, list *db.HardcoverList) (int, error) {
+	var result *hardcover.FilteredBooksResult
+	var err error
+	if list.Source == db.ListSourceWantToRead {
+		result, err = client.GetWantToReadBooks(ctx)
+	} else {
+		result, err = client.GetListBooks(ctx, list.HardcoverID)
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -164,10 +209,14 @@ func syncHardcoverList(gdb *gorm.DB, client *hardcover.Client, list *db.Hardcove
 
 		// Get or create author
 		var author db.Author
+		authorName := hcBook.AuthorName
 		if len(hcBook.Authors) > 0 {
-			authorName := hcBook.Authors[0]
-			result := gdb.Where("name = ?", authorName).First(&author)
-			if result.Error != nil {
+			authorName = hcBook.Authors[0]
+		}
+		if authorName != "" {
+			if found, _, err := db.FindAuthorByName(gdb, authorName); err == nil {
+				author = *found
+			} else {
 				author = db.Author{
 					Name: authorName,
 				}
@@ -216,7 +265,7 @@ func NewListSyncService(database *gorm.DB, hcClient *hardcover.Client) *ListSync
 }
 
 // SyncAllLists syncs all enabled lists
-func (ls *ListSyncService) SyncAllLists() error {
+func (ls *ListSyncService) SyncAllLists(ctx context.Context) error {
 	var lists []db.HardcoverList
 	ls.db.Where("enabled = ?", true).Find(&lists)
 
@@ -229,7 +278,7 @@ func (ls *ListSyncService) SyncAllLists() error {
 			}
 		}
 
-		_, err := syncHardcoverList(ls.db, ls.hardcoverClient, &list)
+		_, err := syncHardcoverList(ctx, ls.db, ls.hardcoverClient, &list)
 		if err != nil {
 			// Log error but continue with other lists
 			continue
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/logging"
+)
+
+// Log file defaults, used until they're set in the general settings
+const (
+	defaultLogLevel      = "info"
+	defaultLogMaxSizeMB  = 10
+	defaultLogMaxFiles   = 5
+	defaultLogMaxAgeDays = 14
+)
+
+// Loggers for the parts of the server whose level can be set apart from the rest
+var (
+	indexersLog      = logging.Component("indexers")
+	downloadsLog     = logging.Component("downloads")
+	libraryLog       = logging.Component("library")
+	metadataLog      = logging.Component("metadata")
+	notificationsLog = logging.Component("notifications")
+)
+
+// LogSettings are the logging options in the general settings
+type LogSettings struct {
+	Level           string            `json:"logLevel"`           // debug, info, warn or error
+	ComponentLevels map[string]string `json:"logComponentLevels"` // Levels of components logged apart from Level
+	ToFile          bool              `json:"logToFile"`
+	FilePath        string            `json:"logFilePath"` // Read-only; in the config folder
+	MaxSizeMB       int               `json:"logMaxSizeMb"`
+	MaxFiles        int               `json:"logMaxFiles"`
+	MaxAgeDays      int               `json:"logMaxAgeDays"`
+}
+
+// LogResponse is a page of recent log entries
+type LogResponse struct {
+	Entries []logging.Entry `json:"entries"`
+	Level   string          `json:"level"` // Minimum level currently logged
+}
+
+// logFilePath is where the log file is written
+func (s *Server) logFilePath() string {
+	return filepath.Join(s.config.ConfigPath, "logs", "shelfarr.log")
+}
+
+// logSettings returns the logging options stored in the general settings
+func (s *Server) logSettings() LogSettings {
+	settings := LogSettings{
+		Level:           defaultLogLevel,
+		ComponentLevels: map[string]string{},
+		FilePath:        s.logFilePath(),
+		MaxSizeMB:       defaultLogMaxSizeMB,
+		MaxFiles:        defaultLogMaxFiles,
+		MaxAgeDays:      defaultLogMaxAgeDays,
+	}
+
+	var dbSettings []db.Setting
+	s.db.Where("key LIKE ?", "general_log_%").Find(&dbSettings)
+	for _, setting := range dbSettings {
+		switch setting.Key {
+		case "general_log_level":
+			settings.Level = setting.Value
+		case "general_log_component_levels":
+			json.Unmarshal([]byte(setting.Value), &settings.ComponentLevels)
+		case "general_log_to_file":
+			settings.ToFile = setting.Value == "true"
+		case "general_log_max_size_mb":
+			if n, err := strconv.Atoi(setting.Value); err == nil {
+				settings.MaxSizeMB = n
+			}
+		case "general_log_max_files":
+			if n, err := strconv.Atoi(setting.Value); err == nil {
+				settings.MaxFiles = n
+			}
+		case "general_log_max_age_days":
+			if n, err := strconv.Atoi(setting.Value); err == nil {
+				settings.MaxAgeDays = n
+			}
+		}
+	}
+	// A level from the environment or config file wins over the stored one
+	if level := os.Getenv("SHELFARR_LOG_LEVEL"); level != "" {
+		settings.Level = level
+	}
+	return settings
+}
+
+// applyLogSettings sets the log levels and log file stored in the general settings
+func (s *Server) applyLogSettings() {
+	settings := s.logSettings()
+	if err := logging.SetLevel(settings.Level); err != nil {
+		slog.Warn("Ignoring stored log level", "error", err)
+	}
+	if err := logging.SetComponentLevels(settings.ComponentLevels); err != nil {
+		slog.Warn("Ignoring stored component log levels", "error", err)
+	}
+	s.applyLogFile(settings)
+}
+
+// applyLogFile starts or stops writing the log file
+func (s *Server) applyLogFile(settings LogSettings) {
+	var opts *logging.FileOptions
+	if settings.ToFile {
+		opts = &logging.FileOptions{
+			Path:       settings.FilePath,
+			MaxSizeMB:  settings.MaxSizeMB,
+			MaxFiles:   settings.MaxFiles,
+			MaxAgeDays: settings.MaxAgeDays,
+		}
+	}
+	if err := logging.SetFile(opts); err != nil {
+		slog.Error("Failed to open log file", "path", settings.FilePath, "error", err)
+	}
+}
+
+// getLogs returns recent log entries, oldest first. Query parameters: limit (default
+// 500), level to leave out less severe entries, search to match text, and after to
+// only return entries newer than the given ID, for following the log.
+func (s *Server) getLogs(c echo.Context) error {
+	query := logging.Query{
+		Level:  c.QueryParam("level"),
+		Search: c.QueryParam("search"),
+		Limit:  500,
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		query.Limit = n
+	}
+	if after := c.QueryParam("after"); after != "" {
+		id, err := strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid after"})
+		}
+		query.After = id
+	}
+	if query.Level != "" {
+		if _, err := logging.ParseLevel(query.Level); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, LogResponse{
+		Entries: logging.Recent(query),
+		Level:   logging.Level(),
+	})
+}
+
+// clearLogs empties the recent log entries
+func (s *Server) clearLogs(c echo.Context) error {
+	logging.Clear()
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/mediaserver"
+)
+
+// MediaServerRequest represents a media server connection request
+type MediaServerRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	Type      string `json:"type" validate:"required,oneof=plex jellyfin kavita komga"`
+	Enabled   bool   `json:"enabled"`
+	URL       string `json:"url" validate:"required"`
+	Token     string `json:"token"`
+	OnImport  bool   `json:"onImport"`
+	OnUpgrade bool   `json:"onUpgrade"`
+}
+
+// getMediaServers returns all media server connections
+func (s *Server) getMediaServers(c echo.Context) error {
+	var servers []db.MediaServer
+	s.db.Find(&servers)
+	return c.JSON(http.StatusOK, servers)
+}
+
+// addMediaServer creates a new media server connection
+func (s *Server) addMediaServer(c echo.Context) error {
+	var req MediaServerRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if mediaserver.New(req.Type, req.URL, req.Token) == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown media server type"})
+	}
+
+	server := db.MediaServer{
+		Name:      req.Name,
+		Type:      req.Type,
+		Enabled:   req.Enabled,
+		URL:       strings.TrimSpace(req.URL),
+		Token:     strings.TrimSpace(req.Token),
+		OnImport:  req.OnImport,
+		OnUpgrade: req.OnUpgrade,
+	}
+
+	if err := s.db.Create(&server).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create media server"})
+	}
+
+	return c.JSON(http.StatusCreated, server)
+}
+
+// updateMediaServer updates an existing media server connection
+func (s *Server) updateMediaServer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var server db.MediaServer
+	if err := s.db.First(&server, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media server not found"})
+	}
+
+	var req MediaServerRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if mediaserver.New(req.Type, req.URL, req.Token) == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown media server type"})
+	}
+
+	server.Name = req.Name
+	server.Type = req.Type
+	server.Enabled = req.Enabled
+	server.URL = strings.TrimSpace(req.URL)
+	server.Token = strings.TrimSpace(req.Token)
+	server.OnImport = req.OnImport
+	server.OnUpgrade = req.OnUpgrade
+
+	if err := s.db.Save(&server).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update media server"})
+	}
+
+	return c.JSON(http.StatusOK, server)
+}
+
+// deleteMediaServer removes a media server connection
+func (s *Server) deleteMediaServer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	if err := s.db.Delete(&db.MediaServer{}, id).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete media server"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// testMediaServer checks a media server connection's URL and token
+func (s *Server) testMediaServer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var server db.MediaServer
+	if err := s.db.First(&server, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media server not found"})
+	}
+
+	ms := mediaserver.New(server.Type, server.URL, server.Token)
+	if ms == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown media server type"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if err := ms.Test(ctx); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Connection successful"})
+}
+
+// refreshMediaServers scans the folder of an imported path on each enabled media server
+// subscribed to the event. Events without a "path" are ignored.
+func (ns *NotificationService) refreshMediaServers(eventType string, data map[string]interface{}) {
+	path, _ := data["path"].(string)
+	if path == "" {
+		return
+	}
+
+	var servers []db.MediaServer
+	ns.db.Where("enabled = ?", true).Find(&servers)
+
+	// Audiobooks import as folders; single files are scanned by their folder
+	folder := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		folder = filepath.Dir(path)
+	}
+
+	for _, server := range servers {
+		shouldRefresh := false
+		switch eventType {
+		case "import":
+			shouldRefresh = server.OnImport
+		case "upgrade":
+			shouldRefresh = server.OnUpgrade
+		}
+
+		if !shouldRefresh {
+			continue
+		}
+
+		ms := mediaserver.New(server.Type, server.URL, server.Token)
+		if ms == nil {
+			continue
+		}
+
+		go func(name string, ms mediaserver.MediaServer) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := ms.Refresh(ctx, folder); err != nil {
+				notificationsLog.Warn("Media server refresh failed", "server", name, "type", ms.Type(), "error", err)
+			}
+		}(server.Name, ms)
+	}
+}
@@ -1,13 +1,17 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
 	"syscall"
 
 	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/audiobookshelf"
 	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"gorm.io/gorm"
 )
 
 // MediaSettingsResponse represents the media management settings
@@ -17,10 +21,17 @@ type MediaSettingsResponse struct {
 	FileNamingEbook     string `json:"fileNamingEbook"`
 	FileNamingAudiobook string `json:"fileNamingAudiobook"`
 	FolderNaming        string `json:"folderNaming"`
-	UseHardlinks        bool   `json:"useHardlinks"`
+	ASCIIFileNames      bool   `json:"asciiFileNames"`
+	ReplaceIllegalChars bool   `json:"replaceIllegalCharacters"`
+	ImportOperation     string `json:"importOperation"` // move, copy, hardlink or reflink
 	RecycleBinEnabled   bool   `json:"recycleBinEnabled"`
 	RecycleBinPath      string `json:"recycleBinPath"`
+	RecycleBinRetention int    `json:"recycleBinRetentionDays"` // 0 keeps files until the bin is emptied
 	RescanAfterImport   bool   `json:"rescanAfterImport"`
+	WatchDownloads      bool   `json:"watchDownloads"`
+	WriteMetadataFiles  bool   `json:"writeMetadataFiles"` // metadata.opf, desc.txt and cover.jpg in book folders
+	TagAudioFiles       bool   `json:"tagAudioFiles"`      // Rewrite imported audiobooks' tags and cover with ffmpeg
+	MinimumFreeSpace    int    `json:"minimumFreeSpaceMb"` // Space a grab must leave free in the downloads folder; 0 turns the check off
 }
 
 // MediaSettingsRequest represents the request body for updating media settings
@@ -30,10 +41,17 @@ type MediaSettingsRequest struct {
 	FileNamingEbook     *string `json:"fileNamingEbook,omitempty"`
 	FileNamingAudiobook *string `json:"fileNamingAudiobook,omitempty"`
 	FolderNaming        *string `json:"folderNaming,omitempty"`
-	UseHardlinks        *bool   `json:"useHardlinks,omitempty"`
+	ASCIIFileNames      *bool   `json:"asciiFileNames,omitempty"`
+	ReplaceIllegalChars *bool   `json:"replaceIllegalCharacters,omitempty"`
+	ImportOperation     *string `json:"importOperation,omitempty"`
 	RecycleBinEnabled   *bool   `json:"recycleBinEnabled,omitempty"`
 	RecycleBinPath      *string `json:"recycleBinPath,omitempty"`
+	RecycleBinRetention *int    `json:"recycleBinRetentionDays,omitempty" validate:"min=0"`
 	RescanAfterImport   *bool   `json:"rescanAfterImport,omitempty"`
+	WatchDownloads      *bool   `json:"watchDownloads,omitempty"`
+	WriteMetadataFiles  *bool   `json:"writeMetadataFiles,omitempty"`
+	TagAudioFiles       *bool   `json:"tagAudioFiles,omitempty"`
+	MinimumFreeSpace    *int    `json:"minimumFreeSpaceMb,omitempty" validate:"min=0"`
 }
 
 // RootFolderResponse represents a root folder in API responses
@@ -42,29 +60,52 @@ type RootFolderResponse struct {
 	Path       string `json:"path"`
 	MediaType  string `json:"mediaType"`
 	Name       string `json:"name"`
+	IsDefault  bool   `json:"isDefault"`
 	FreeSpace  int64  `json:"freeSpace"`
 	TotalSpace int64  `json:"totalSpace"`
 	Accessible bool   `json:"accessible"`
+
+	ImportOperation string `json:"importOperation"` // Empty uses the media management setting
+
+	AudiobookshelfMode      string `json:"audiobookshelfMode"`
+	AudiobookshelfLibraryID string `json:"audiobookshelfLibraryId"`
+	AudiobookshelfFolderID  string `json:"audiobookshelfFolderId"`
 }
 
 // RootFolderRequest represents the request body for creating a root folder
 type RootFolderRequest struct {
 	Path      string `json:"path" validate:"required"`
-	MediaType string `json:"mediaType" validate:"required"` // ebook or audiobook
-	Name      string `json:"name,omitempty"`
+	MediaType string `json:"mediaType" validate:"required,oneof=ebook audiobook"`
+	Name      string `json:"name,omitempty" validate:"max=100"`
+	IsDefault *bool  `json:"isDefault,omitempty"` // Import books without a root folder of their own here
+
+	// move, copy, hardlink or reflink; empty uses the media management setting
+	ImportOperation string `json:"importOperation,omitempty"`
+
+	// Audiobookshelf integration: "" (off), "scan" or "upload"
+	AudiobookshelfMode      string `json:"audiobookshelfMode,omitempty"`
+	AudiobookshelfLibraryID string `json:"audiobookshelfLibraryId,omitempty"`
+	AudiobookshelfFolderID  string `json:"audiobookshelfFolderId,omitempty"`
 }
 
 // getMediaSettings returns the current media management settings
 func (s *Server) getMediaSettings(c echo.Context) error {
 	settings := MediaSettingsResponse{
 		// Defaults
-		FileNamingEbook:     "{Author}/{Title}",
-		FileNamingAudiobook: "{Author}/{Title}",
+		FileNamingEbook:     media.DefaultEbookTemplate,
+		FileNamingAudiobook: media.DefaultAudiobookTemplate,
 		FolderNaming:        "{Author}/{Series}",
-		UseHardlinks:        false,
+		ASCIIFileNames:      false,
+		ReplaceIllegalChars: true,
+		ImportOperation:     string(media.OpHardlink),
 		RecycleBinEnabled:   false,
 		RecycleBinPath:      "",
+		RecycleBinRetention: defaultRecycleBinRetentionDays,
 		RescanAfterImport:   true,
+		WatchDownloads:      false,
+		WriteMetadataFiles:  false,
+		TagAudioFiles:       false,
+		MinimumFreeSpace:    defaultMinimumFreeSpaceMB,
 	}
 
 	// Load settings from database
@@ -83,14 +124,32 @@ func (s *Server) getMediaSettings(c echo.Context) error {
 			settings.FileNamingAudiobook = setting.Value
 		case "media_folder_naming":
 			settings.FolderNaming = setting.Value
-		case "media_use_hardlinks":
-			settings.UseHardlinks = setting.Value == "true"
+		case "media_naming_ascii":
+			settings.ASCIIFileNames = setting.Value == "true"
+		case "media_naming_replace_illegal":
+			settings.ReplaceIllegalChars = setting.Value != "false" // Default true
+		case "media_import_operation":
+			settings.ImportOperation = setting.Value
 		case "media_recycle_bin_enabled":
 			settings.RecycleBinEnabled = setting.Value == "true"
 		case "media_recycle_bin_path":
 			settings.RecycleBinPath = setting.Value
+		case "media_recycle_bin_retention_days":
+			if days, err := strconv.Atoi(setting.Value); err == nil {
+				settings.RecycleBinRetention = days
+			}
 		case "media_rescan_after_import":
 			settings.RescanAfterImport = setting.Value != "false" // Default true
+		case "media_watch_downloads":
+			settings.WatchDownloads = setting.Value == "true"
+		case "media_write_metadata_files":
+			settings.WriteMetadataFiles = setting.Value == "true"
+		case "media_tag_audio_files":
+			settings.TagAudioFiles = setting.Value == "true"
+		case "media_minimum_free_space_mb":
+			if mb, err := strconv.Atoi(setting.Value); err == nil {
+				settings.MinimumFreeSpace = mb
+			}
 		}
 	}
 
@@ -100,8 +159,23 @@ func (s *Server) getMediaSettings(c echo.Context) error {
 // updateMediaSettings updates media management settings
 func (s *Server) updateMediaSettings(c echo.Context) error {
 	var req MediaSettingsRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	for _, template := range []*string{req.FileNamingEbook, req.FileNamingAudiobook} {
+		if template == nil {
+			continue
+		}
+		if err := media.ValidateNamingTemplate(*template); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid naming template: " + err.Error()})
+		}
+	}
+
+	if req.ImportOperation != nil {
+		if _, err := media.ParseFileOperation(*req.ImportOperation); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 	}
 
 	// Update settings that are provided
@@ -112,6 +186,7 @@ func (s *Server) updateMediaSettings(c echo.Context) error {
 		"media_file_naming_audiobook": req.FileNamingAudiobook,
 		"media_folder_naming":         req.FolderNaming,
 		"media_recycle_bin_path":      req.RecycleBinPath,
+		"media_import_operation":      req.ImportOperation,
 	}
 
 	for key, valuePtr := range updates {
@@ -121,11 +196,25 @@ func (s *Server) updateMediaSettings(c echo.Context) error {
 		}
 	}
 
+	if req.RecycleBinRetention != nil {
+		setting := db.Setting{Key: "media_recycle_bin_retention_days", Value: strconv.Itoa(*req.RecycleBinRetention)}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+	}
+
+	if req.MinimumFreeSpace != nil {
+		setting := db.Setting{Key: "media_minimum_free_space_mb", Value: strconv.Itoa(*req.MinimumFreeSpace)}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+	}
+
 	// Handle boolean settings
 	boolUpdates := map[string]*bool{
-		"media_use_hardlinks":       req.UseHardlinks,
-		"media_recycle_bin_enabled": req.RecycleBinEnabled,
-		"media_rescan_after_import": req.RescanAfterImport,
+		"media_naming_ascii":           req.ASCIIFileNames,
+		"media_naming_replace_illegal": req.ReplaceIllegalChars,
+		"media_recycle_bin_enabled":    req.RecycleBinEnabled,
+		"media_rescan_after_import":    req.RescanAfterImport,
+		"media_watch_downloads":        req.WatchDownloads,
+		"media_write_metadata_files":   req.WriteMetadataFiles,
+		"media_tag_audio_files":        req.TagAudioFiles,
 	}
 
 	for key, valuePtr := range boolUpdates {
@@ -151,16 +240,7 @@ func (s *Server) getRootFolders(c echo.Context) error {
 
 	responses := make([]RootFolderResponse, len(rootFolders))
 	for i, rf := range rootFolders {
-		freeSpace, totalSpace, accessible := getDiskSpace(rf.Path)
-		responses[i] = RootFolderResponse{
-			ID:         rf.ID,
-			Path:       rf.Path,
-			MediaType:  string(rf.MediaType),
-			Name:       rf.Name,
-			FreeSpace:  freeSpace,
-			TotalSpace: totalSpace,
-			Accessible: accessible,
-		}
+		responses[i] = toRootFolderResponse(rf)
 	}
 
 	return c.JSON(http.StatusOK, responses)
@@ -169,16 +249,8 @@ func (s *Server) getRootFolders(c echo.Context) error {
 // addRootFolder creates a new root folder
 func (s *Server) addRootFolder(c echo.Context) error {
 	var req RootFolderRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if req.Path == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Path is required"})
-	}
-
-	if req.MediaType != "ebook" && req.MediaType != "audiobook" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Media type must be 'ebook' or 'audiobook'"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	// Check if path exists and is a directory
@@ -199,26 +271,132 @@ func (s *Server) addRootFolder(c echo.Context) error {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "Root folder already exists"})
 	}
 
+	if err := validateAudiobookshelfMode(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := validateImportOperation(req.ImportOperation); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
 	rootFolder := db.RootFolder{
-		Path:      req.Path,
-		MediaType: db.MediaType(req.MediaType),
-		Name:      req.Name,
+		Path:                    req.Path,
+		MediaType:               db.MediaType(req.MediaType),
+		Name:                    req.Name,
+		ImportOperation:         req.ImportOperation,
+		AudiobookshelfMode:      req.AudiobookshelfMode,
+		AudiobookshelfLibraryID: req.AudiobookshelfLibraryID,
+		AudiobookshelfFolderID:  req.AudiobookshelfFolderID,
 	}
 
+	// The first root folder for a media type is its default
+	var others int64
+	s.db.Model(&db.RootFolder{}).Where("media_type = ?", req.MediaType).Count(&others)
+	makeDefault := others == 0 || (req.IsDefault != nil && *req.IsDefault)
+
 	if err := s.db.Create(&rootFolder).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create root folder"})
 	}
+	if makeDefault {
+		if err := s.setDefaultRootFolder(&rootFolder); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to make root folder the default"})
+		}
+	}
 
-	freeSpace, totalSpace, accessible := getDiskSpace(rootFolder.Path)
-	return c.JSON(http.StatusCreated, RootFolderResponse{
-		ID:         rootFolder.ID,
-		Path:       rootFolder.Path,
-		MediaType:  string(rootFolder.MediaType),
-		Name:       rootFolder.Name,
-		FreeSpace:  freeSpace,
-		TotalSpace: totalSpace,
-		Accessible: accessible,
-	})
+	return c.JSON(http.StatusCreated, toRootFolderResponse(rootFolder))
+}
+
+// updateRootFolder updates a root folder's name, whether it's the default, how files are
+// imported there and its Audiobookshelf integration. The path and media type can't change once files are
+// imported there.
+func (s *Server) updateRootFolder(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid root folder ID"})
+	}
+
+	var rootFolder db.RootFolder
+	if err := s.db.First(&rootFolder, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Root folder not found"})
+	}
+
+	var req RootFolderRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	req.MediaType = string(rootFolder.MediaType)
+	if err := validateAudiobookshelfMode(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := validateImportOperation(req.ImportOperation); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	rootFolder.Name = req.Name
+	rootFolder.ImportOperation = req.ImportOperation
+	rootFolder.AudiobookshelfMode = req.AudiobookshelfMode
+	rootFolder.AudiobookshelfLibraryID = req.AudiobookshelfLibraryID
+	rootFolder.AudiobookshelfFolderID = req.AudiobookshelfFolderID
+	if req.IsDefault != nil && !*req.IsDefault {
+		rootFolder.IsDefault = false
+	}
+	if err := s.db.Save(&rootFolder).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update root folder"})
+	}
+	if req.IsDefault != nil && *req.IsDefault {
+		if err := s.setDefaultRootFolder(&rootFolder); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to make root folder the default"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, toRootFolderResponse(rootFolder))
+}
+
+// validateAudiobookshelfMode checks a root folder's Audiobookshelf settings
+func validateAudiobookshelfMode(req RootFolderRequest) error {
+	switch req.AudiobookshelfMode {
+	case "":
+		return nil
+	case audiobookshelf.ModeScan, audiobookshelf.ModeUpload:
+	default:
+		return fmt.Errorf("Audiobookshelf mode must be 'scan' or 'upload'")
+	}
+	if req.MediaType != "audiobook" {
+		return fmt.Errorf("Audiobookshelf integration is only available for audiobook root folders")
+	}
+	if req.AudiobookshelfLibraryID == "" {
+		return fmt.Errorf("an Audiobookshelf library is required")
+	}
+	if req.AudiobookshelfMode == audiobookshelf.ModeUpload && req.AudiobookshelfFolderID == "" {
+		return fmt.Errorf("an Audiobookshelf library folder is required for uploads")
+	}
+	return nil
+}
+
+// validateImportOperation checks a root folder's import operation, which may be empty
+func validateImportOperation(operation string) error {
+	if operation == "" {
+		return nil
+	}
+	_, err := media.ParseFileOperation(operation)
+	return err
+}
+
+func toRootFolderResponse(rf db.RootFolder) RootFolderResponse {
+	freeSpace, totalSpace, accessible := getDiskSpace(rf.Path)
+	return RootFolderResponse{
+		ID:                      rf.ID,
+		Path:                    rf.Path,
+		MediaType:               string(rf.MediaType),
+		Name:                    rf.Name,
+		IsDefault:               rf.IsDefault,
+		FreeSpace:               freeSpace,
+		TotalSpace:              totalSpace,
+		Accessible:              accessible,
+		ImportOperation:         rf.ImportOperation,
+		AudiobookshelfMode:      rf.AudiobookshelfMode,
+		AudiobookshelfLibraryID: rf.AudiobookshelfLibraryID,
+		AudiobookshelfFolderID:  rf.AudiobookshelfFolderID,
+	}
 }
 
 // deleteRootFolder removes a root folder
@@ -237,9 +415,84 @@ func (s *Server) deleteRootFolder(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete root folder"})
 	}
 
+	// Books assigned the folder go back to the default, and the oldest folder left
+	// takes over as the default
+	s.db.Model(&db.Book{}).Where("ebook_root_folder_id = ?", rootFolder.ID).Update("ebook_root_folder_id", nil)
+	s.db.Model(&db.Book{}).Where("audiobook_root_folder_id = ?", rootFolder.ID).Update("audiobook_root_folder_id", nil)
+	if rootFolder.IsDefault {
+		var next db.RootFolder
+		if err := s.db.Where("media_type = ?", rootFolder.MediaType).Order("id").First(&next).Error; err == nil {
+			s.setDefaultRootFolder(&next)
+		}
+	}
+
 	return c.NoContent(http.StatusNoContent)
 }
 
+// setDefaultRootFolder makes a root folder the default for its media type
+func (s *Server) setDefaultRootFolder(rootFolder *db.RootFolder) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.RootFolder{}).Where("media_type = ? AND id <> ?", rootFolder.MediaType, rootFolder.ID).
+			Update("is_default", false).Error; err != nil {
+			return err
+		}
+		rootFolder.IsDefault = true
+		return tx.Model(rootFolder).Update("is_default", true).Error
+	})
+}
+
+// assignableRootFolder checks that a book can be assigned a root folder, returning the
+// ID to store. 0 clears the assignment.
+func (s *Server) assignableRootFolder(id uint, mediaType db.MediaType) (*uint, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var rootFolder db.RootFolder
+	if err := s.db.First(&rootFolder, id).Error; err != nil {
+		return nil, fmt.Errorf("root folder %d not found", id)
+	}
+	if rootFolder.MediaType != mediaType {
+		return nil, fmt.Errorf("root folder %s is not for %ss", rootFolder.Path, mediaType)
+	}
+	return &id, nil
+}
+
+// importRoots returns the folders a book's ebooks and audiobooks are imported into: the
+// root folders assigned to the book, else the defaults, else the configured paths
+func (s *Server) importRoots(book *db.Book) (booksPath, audiobooksPath string) {
+	root := func(assigned *uint, mediaType db.MediaType, fallback string) string {
+		var rootFolder db.RootFolder
+		if assigned != nil && s.db.First(&rootFolder, *assigned).Error == nil {
+			return rootFolder.Path
+		}
+		if s.db.Where("media_type = ? AND is_default = ?", mediaType, true).First(&rootFolder).Error == nil {
+			return rootFolder.Path
+		}
+		return fallback
+	}
+	return root(book.EbookRootFolderID, db.MediaTypeEbook, s.config.BooksPath),
+		root(book.AudiobookRootFolderID, db.MediaTypeAudiobook, s.config.AudiobooksPath)
+}
+
+// importOperation returns how files are imported into a root folder: its own operation,
+// else the media management setting, else hardlinks. Links and clones fall back to
+// copying when the download and the library are on different filesystems.
+func (s *Server) importOperation(root string) media.FileOperation {
+	var rootFolder db.RootFolder
+	if s.db.Where("path = ?", root).First(&rootFolder).Error == nil && rootFolder.ImportOperation != "" {
+		if op, err := media.ParseFileOperation(rootFolder.ImportOperation); err == nil {
+			return op
+		}
+	}
+	var setting db.Setting
+	if s.db.Where("key = ?", "media_import_operation").First(&setting).Error == nil {
+		if op, err := media.ParseFileOperation(setting.Value); err == nil {
+			return op
+		}
+	}
+	return media.OpHardlink
+}
+
 // getDiskSpace returns free and total space for a path
 func getDiskSpace(path string) (freeSpace, totalSpace int64, accessible bool) {
 	var stat syscall.Statfs_t
@@ -255,43 +508,97 @@ func getDiskSpace(path string) (freeSpace, totalSpace int64, accessible bool) {
 	return
 }
 
-// getNamingPreview returns a preview of how a file would be named
+// getNamingPreview returns how a sample book would be named by a template, in and out
+// of a series. mediaType picks the kind of file (default ebook); ascii and
+// replaceIllegal override the saved options.
 func (s *Server) getNamingPreview(c echo.Context) error {
 	template := c.QueryParam("template")
 	if template == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Template is required"})
 	}
+	if err := media.ValidateNamingTemplate(template); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	mediaType := c.QueryParam("mediaType")
+	if mediaType != "audiobook" {
+		mediaType = "ebook"
+	}
+
+	opts := s.namingOptions()
+	opts.EbookTemplate, opts.AudiobookTemplate = template, template
+	if ascii := c.QueryParam("ascii"); ascii != "" {
+		opts.ASCIIOnly = ascii == "true"
+	}
+	if replace := c.QueryParam("replaceIllegal"); replace != "" {
+		opts.ReplaceIllegal = replace == "true"
+	}
 
 	// Sample data for preview
-	preview := map[string]string{
-		"template": template,
-		"preview": applyNamingTemplate(template, map[string]string{
-			"Author":      "Brandon Sanderson",
-			"Title":       "The Way of Kings",
-			"Series":      "The Stormlight Archive",
-			"SeriesIndex": "1",
-			"Year":        "2010",
-			"Quality":     "EPUB",
-			"Format":      "epub",
-		}),
-	}
-
-	return c.JSON(http.StatusOK, preview)
+	sample := media.NamingValues{
+		Author:      "Brandon Sanderson",
+		Title:       "The Way of Kings",
+		Series:      "The Stormlight Archive",
+		SeriesIndex: 1,
+		Year:        2010,
+		Format:      "epub",
+	}
+	standalone := media.NamingValues{
+		Author: "Ted Chiang",
+		Title:  "Exhalation: Stories",
+		Year:   2019,
+		Format: "epub",
+	}
+	if mediaType == "audiobook" {
+		sample.Format, standalone.Format = "m4b", "m4b"
+	}
+
+	builder := media.NewPathBuilder("", "")
+	builder.SetNaming(opts)
+	return c.JSON(http.StatusOK, map[string]string{
+		"template":   template,
+		"preview":    builder.BuildPath(mediaType, sample),
+		"standalone": builder.BuildPath(mediaType, standalone),
+	})
 }
 
-// applyNamingTemplate applies template variables to a naming template
-func applyNamingTemplate(template string, vars map[string]string) string {
-	result := template
-	for key, value := range vars {
-		placeholder := "{" + key + "}"
-		// Simple string replacement
-		for i := 0; i < len(result)-len(placeholder)+1; i++ {
-			if result[i:i+len(placeholder)] == placeholder {
-				result = result[:i] + value + result[i+len(placeholder):]
-			}
+// namingOptions returns the configured naming templates and options
+func (s *Server) namingOptions() media.NamingOptions {
+	opts := media.DefaultNamingOptions()
+
+	var settings []db.Setting
+	s.db.Where("key IN ?", []string{"media_file_naming_ebook", "media_file_naming_audiobook", "media_naming_ascii", "media_naming_replace_illegal"}).Find(&settings)
+	for _, setting := range settings {
+		switch setting.Key {
+		case "media_file_naming_ebook":
+			opts.EbookTemplate = setting.Value
+		case "media_file_naming_audiobook":
+			opts.AudiobookTemplate = setting.Value
+		case "media_naming_ascii":
+			opts.ASCIIOnly = setting.Value == "true"
+		case "media_naming_replace_illegal":
+			opts.ReplaceIllegal = setting.Value != "false"
+		}
+	}
+	return opts
+}
+
+// namingValues returns a book's naming token values for a file of the given format. The
+// book must have its Author and Series loaded.
+func namingValues(book *db.Book, format string) media.NamingValues {
+	values := media.NamingValues{
+		Author: book.Author.Name,
+		Title:  book.Title,
+		Year:   book.ReleaseYear,
+		Format: format,
+	}
+	if book.Series != nil {
+		values.Series = book.Series.Name
+		if book.SeriesIndex != nil {
+			values.SeriesIndex = float64(*book.SeriesIndex)
 		}
 	}
-	return result
+	return values
 }
 
 // DirectoryInfo represents information about a directory
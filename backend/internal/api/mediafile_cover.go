@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// pageCount returns the number of pages of a PDF or comic, or 0 for other formats
+func pageCount(path string) int {
+	if !media.IsPagedFormat(filepath.Ext(path)) {
+		return 0
+	}
+	pages, err := media.PageCount(path)
+	if err != nil {
+		metadataLog.Warn("Could not count pages", "file", filepath.Base(path), "error", err)
+	}
+	return pages
+}
+
+// getMediaFileCover returns a thumbnail of a PDF's or comic's first page, for books
+// (such as manga volumes) whose metadata has no cover. Thumbnails are cached until
+// the file changes. A missing page count is filled in on the way.
+func (s *Server) getMediaFileCover(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID"})
+	}
+
+	var file db.MediaFile
+	if err := s.db.First(&file, id).Error; err != nil || !s.canSeeBook(c, file.BookID) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found"})
+	}
+	if !media.IsPagedFormat(file.Format) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Only PDFs and comics have page thumbnails"})
+	}
+
+	info, err := os.Stat(file.FilePath)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Media file not found on disk"})
+	}
+
+	cacheDir := filepath.Join(os.TempDir(), "shelfarr-covers")
+	cachePath := filepath.Join(cacheDir, fmt.Sprintf("%d-%d", file.ID, info.ModTime().Unix()))
+	if cover, err := os.ReadFile(cachePath); err == nil {
+		return c.Blob(http.StatusOK, http.DetectContentType(cover), cover)
+	}
+
+	cover, err := media.ExtractCover(c.Request().Context(), file.FilePath)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	// Pages the thumbnailer can't decode, such as WebP, are served as they are
+	if thumbnail, err := media.Thumbnail(cover, media.ThumbnailHeight); err == nil {
+		cover = thumbnail
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err == nil {
+		os.WriteFile(cachePath, cover, 0644)
+	}
+
+	if file.PageCount == 0 {
+		if pages := pageCount(file.FilePath); pages > 0 {
+			s.db.Model(&file).Update("page_count", pages)
+		}
+	}
+
+	return c.Blob(http.StatusOK, http.DetectContentType(cover), cover)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// lockableFields are the book fields that can be edited by hand and locked against
+// metadata refreshes, by their JSON names
+var lockableFields = map[string]bool{
+	"title":       true,
+	"sortTitle":   true,
+	"subtitle":    true,
+	"description": true,
+	"releaseDate": true,
+	"pageCount":   true,
+	"isbn":        true,
+	"isbn13":      true,
+	"language":    true,
+	"series":      true, // The series and the book's place in it
+	"genres":      true,
+}
+
+// lockedFields returns the fields of a book that refreshes must leave alone
+func lockedFields(book *db.Book) map[string]bool {
+	locked := make(map[string]bool)
+	if book.LockedFields == "" {
+		return locked
+	}
+	var fields []string
+	json.Unmarshal([]byte(book.LockedFields), &fields)
+	for _, field := range fields {
+		locked[field] = true
+	}
+	return locked
+}
+
+// lockedFieldList returns a book's locked fields in a stable order
+func lockedFieldList(locked map[string]bool) []string {
+	fields := make([]string, 0, len(locked))
+	for field, isLocked := range locked {
+		if isLocked {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// setLockedFields stores which of a book's fields refreshes must leave alone
+func setLockedFields(book *db.Book, locked map[string]bool) {
+	book.LockedFields = ""
+	if fields := lockedFieldList(locked); len(fields) > 0 {
+		data, _ := json.Marshal(fields)
+		book.LockedFields = string(data)
+	}
+}
+
+// UpdateMetadataRequest edits a book's metadata. Only fields that are present are
+// changed, and each edited field is locked unless Locked says otherwise. Locked can
+// also lock or unlock fields without editing them.
+type UpdateMetadataRequest struct {
+	Title       *string         `json:"title"`
+	SortTitle   *string         `json:"sortTitle"`
+	Subtitle    *string         `json:"subtitle"`
+	Description *string         `json:"description"`
+	ReleaseDate *string         `json:"releaseDate"` // YYYY-MM-DD, or "" to clear
+	PageCount   *int            `json:"pageCount" validate:"min=0"`
+	ISBN        *string         `json:"isbn"`
+	ISBN13      *string         `json:"isbn13"`
+	Language    *string         `json:"language"` // ISO 639-1 code
+	Series      *string         `json:"series"`   // Series name, or "" to remove the book from its series
+	SeriesIndex *float32        `json:"seriesIndex" validate:"min=0"`
+	Genres      *[]string       `json:"genres"`
+	Locked      map[string]bool `json:"locked"`
+}
+
+// updateBookMetadata edits a book's metadata by hand, locking the edited fields so
+// refreshes from Hardcover don't undo the corrections
+func (s *Server) updateBookMetadata(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	var req UpdateMetadataRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	for field := range req.Locked {
+		if !lockableFields[field] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown metadata field: " + field})
+		}
+	}
+
+	locked := lockedFields(&book)
+	edited := func(field string) {
+		locked[field] = true
+	}
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Title cannot be empty"})
+		}
+		book.Title = title
+		edited("title")
+	}
+	if req.SortTitle != nil {
+		book.SortTitle = strings.TrimSpace(*req.SortTitle)
+		edited("sortTitle")
+	}
+	if req.Subtitle != nil {
+		book.Subtitle = strings.TrimSpace(*req.Subtitle)
+		edited("subtitle")
+	}
+	if req.Description != nil {
+		book.Description = *req.Description
+		edited("description")
+	}
+	if req.ReleaseDate != nil {
+		if *req.ReleaseDate == "" {
+			book.ReleaseDate, book.ReleaseYear = nil, 0
+		} else {
+			date, err := time.Parse("2006-01-02", *req.ReleaseDate)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "releaseDate must be YYYY-MM-DD"})
+			}
+			book.ReleaseDate, book.ReleaseYear = &date, date.Year()
+		}
+		edited("releaseDate")
+	}
+	if req.PageCount != nil {
+		if *req.PageCount < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "pageCount cannot be negative"})
+		}
+		book.PageCount = *req.PageCount
+		edited("pageCount")
+	}
+	if req.ISBN != nil {
+		book.ISBN = strings.TrimSpace(*req.ISBN)
+		edited("isbn")
+	}
+	if req.ISBN13 != nil {
+		book.ISBN13 = strings.TrimSpace(*req.ISBN13)
+		edited("isbn13")
+	}
+	if req.Language != nil {
+		book.LanguageCode = strings.ToLower(strings.TrimSpace(*req.Language))
+		book.Language = languageName(book.LanguageCode)
+		edited("language")
+	}
+	if req.Series != nil {
+		if name := strings.TrimSpace(*req.Series); name == "" {
+			book.SeriesID, book.SeriesIndex = nil, nil
+		} else if book.SeriesID = s.getOrCreateSeriesByName(name, book.AuthorID); book.SeriesID == nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create series"})
+		}
+		edited("series")
+	}
+	if req.SeriesIndex != nil {
+		book.SeriesIndex = req.SeriesIndex
+		edited("series")
+	}
+	var genres []string
+	if req.Genres != nil {
+		for _, genre := range *req.Genres {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				genres = append(genres, genre)
+			}
+		}
+		edited("genres")
+	}
+
+	for field, isLocked := range req.Locked {
+		locked[field] = isLocked
+	}
+	setLockedFields(&book, locked)
+
+	if err := s.db.Save(&book).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update book"})
+	}
+
+	if req.Genres != nil {
+		s.setGenres(&book, genres)
+	}
+
+	go s.writeBookMetadataFiles(book.ID)
+
+	s.db.Preload("Author").Preload("Series").Preload("MediaFiles").Preload("Genres").First(&book, book.ID)
+	return c.JSON(http.StatusOK, bookToResponse(book))
+}
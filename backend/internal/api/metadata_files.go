@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+// isWritingMetadataFiles reports whether metadata.opf and friends are written next to
+// imported books
+func (s *Server) isWritingMetadataFiles() bool {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "media_write_metadata_files").First(&setting).Error; err != nil {
+		return false
+	}
+	return setting.Value == "true"
+}
+
+// writeBookMetadataFiles writes metadata.opf, desc.txt and the cover into each folder
+// holding a book's files, when enabled. Folders shared with other books (an author's
+// folder of ebooks) and root folders are skipped, as the files would clash.
+func (s *Server) writeBookMetadataFiles(bookID uint) {
+	if !s.isWritingMetadataFiles() {
+		return
+	}
+
+	book, err := s.loadBookMetadata(bookID)
+	if err != nil {
+		return
+	}
+
+	folders := s.bookFolders(book)
+	if len(folders) == 0 {
+		return
+	}
+
+	cover := s.fetchCover(book)
+	meta := s.bookMetadata(book)
+	for _, folder := range folders {
+		if err := media.WriteMetadataFiles(folder, meta, cover); err != nil {
+			libraryLog.Warn("Could not write metadata files", "book", book.Title, "folder", folder, "error", err)
+		}
+	}
+}
+
+// embedEPUBMetadata rewrites the metadata and cover inside an imported EPUB to match the
+// library, when the ebook quality profile asks for it. Failures are logged, as the book
+// is still imported.
+func (s *Server) embedEPUBMetadata(bookID uint, result *media.ImportResult) {
+	var profile db.QualityProfile
+	if err := s.db.Where("media_type = ?", db.MediaTypeEbook).Order("id").First(&profile).Error; err != nil || !profile.EmbedMetadata {
+		return
+	}
+
+	book, err := s.loadBookMetadata(bookID)
+	if err != nil {
+		return
+	}
+	if err := media.EmbedEPUBMetadata(result.NewPath, s.bookMetadata(book), s.fetchCover(book)); err != nil {
+		libraryLog.Warn("Could not embed metadata", "path", result.NewPath, "error", err)
+		return
+	}
+	if info, err := os.Stat(result.NewPath); err == nil {
+		s.db.Model(&db.MediaFile{}).Where("id = ?", result.MediaFileID).Update("file_size", info.Size())
+	}
+}
+
+// tagAudiobookFiles writes the library's metadata and cover into the tags of an imported
+// audiobook's files, when enabled. Failures are logged, as the book is still imported.
+func (s *Server) tagAudiobookFiles(bookID uint, result *media.ImportResult) {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "media_tag_audio_files").First(&setting).Error; err != nil || setting.Value != "true" {
+		return
+	}
+	processor := media.NewAudiobookProcessor()
+	if !processor.IsAvailable() {
+		libraryLog.Warn("Can't tag audio file: ffmpeg not found", "path", result.NewPath)
+		return
+	}
+	files := media.AudioFilesIn(result.NewPath)
+	if len(files) == 0 {
+		return
+	}
+
+	book, err := s.loadBookMetadata(bookID)
+	if err != nil {
+		return
+	}
+	meta := s.bookMetadata(book)
+	tags := media.AudioTags{
+		Album:       meta.FullTitle(),
+		Author:      strings.Join(meta.Authors, ", "),
+		Narrator:    strings.Join(meta.Narrators, ", "),
+		Series:      meta.Series,
+		SeriesIndex: meta.SeriesIndex,
+		Year:        book.ReleaseYear,
+		Genre:       "Audiobook",
+		Description: meta.Description,
+		ASIN:        meta.ASIN,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	coverPath, err := s.downloadCover(ctx, book)
+	if err == nil {
+		defer os.Remove(coverPath)
+	} else {
+		coverPath = ""
+	}
+
+	for i, file := range files {
+		fileTags := tags
+		if len(files) == 1 {
+			fileTags.Title = meta.FullTitle()
+		} else {
+			fileTags.Track, fileTags.TrackTotal = i+1, len(files)
+		}
+		if err := processor.WriteTags(ctx, file, fileTags, coverPath); err != nil {
+			libraryLog.Warn("Could not tag audio file", "path", file, "error", err)
+		}
+	}
+	if info, err := os.Stat(result.NewPath); err == nil && !info.IsDir() {
+		s.db.Model(&db.MediaFile{}).Where("id = ?", result.MediaFileID).Update("file_size", info.Size())
+	}
+}
+
+// loadBookMetadata loads a book with what bookMetadata needs, and its media files
+func (s *Server) loadBookMetadata(bookID uint) (*db.Book, error) {
+	var book db.Book
+	err := s.db.Preload("Author").Preload("Series").Preload("Genres").Preload("Identifiers").
+		Preload("Contributors", "role = ?", db.RoleNarrator).Preload("Contributors.Author").
+		Preload("MediaFiles").First(&book, bookID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// fetchCover returns a book's cover from the cover cache, returning nil if it can't
+func (s *Server) fetchCover(book *db.Book) []byte {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	coverPath, err := s.covers.Fetch(ctx, book.ID, bookCover(book))
+	if err != nil {
+		return nil
+	}
+	cover, _ := os.ReadFile(coverPath)
+	return cover
+}
+
+// bookFolders returns the folders that hold only a book's files
+func (s *Server) bookFolders(book *db.Book) []string {
+	roots := s.libraryRoots()
+	seen := make(map[string]bool)
+	var folders []string
+	for _, file := range book.MediaFiles {
+		folder := file.FilePath
+		if info, err := os.Stat(folder); err != nil {
+			continue
+		} else if !info.IsDir() {
+			folder = filepath.Dir(folder)
+		}
+		if seen[folder] {
+			continue
+		}
+		seen[folder] = true
+
+		isRoot := false
+		for _, root := range roots {
+			isRoot = isRoot || folder == root
+		}
+		if isRoot || !underAny(folder, roots) {
+			continue
+		}
+
+		var others int64
+		s.db.Model(&db.MediaFile{}).
+			Where("book_id <> ? AND (file_path = ? OR file_path LIKE ?)", book.ID, folder, folder+string(filepath.Separator)+"%").
+			Count(&others)
+		if others == 0 {
+			folders = append(folders, folder)
+		}
+	}
+	return folders
+}
+
+// bookMetadata gathers the metadata written to a book's sidecar files. The book must
+// have its Author, Series, Genres, Identifiers and narrators (as Contributors) loaded.
+func (s *Server) bookMetadata(book *db.Book) media.BookMetadata {
+	meta := media.BookMetadata{
+		BookID:      book.ID,
+		Title:       book.Title,
+		Subtitle:    book.Subtitle,
+		Description: strings.TrimSpace(book.Description),
+		Language:    book.LanguageCode,
+		ISBN:        book.ISBN13,
+		HardcoverID: book.HardcoverID,
+	}
+	if book.Author.Name != "" {
+		meta.Authors = []string{book.Author.Name}
+	}
+	if meta.ISBN == "" {
+		meta.ISBN = book.ISBN
+	}
+	if book.ReleaseDate != nil {
+		meta.Published = book.ReleaseDate.Format("2006-01-02")
+	} else if book.ReleaseYear > 0 {
+		meta.Published = strconv.Itoa(book.ReleaseYear)
+	}
+	if book.Series != nil {
+		meta.Series = book.Series.Name
+		if book.SeriesIndex != nil {
+			meta.SeriesIndex = float64(*book.SeriesIndex)
+		}
+	}
+	if book.Identifiers != nil {
+		meta.ASIN = book.Identifiers.ASIN
+	}
+	for _, contributor := range book.Contributors {
+		if contributor.Author.Name != "" {
+			meta.Narrators = append(meta.Narrators, contributor.Author.Name)
+		}
+	}
+	for _, genre := range book.Genres {
+		meta.Genres = append(meta.Genres, genre.Name)
+	}
+
+	var edition db.Edition
+	if s.db.Where("book_id = ? AND publisher_name <> ''", book.ID).Order("id").First(&edition).Error == nil {
+		meta.Publisher = edition.PublisherName
+	}
+	return meta
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/cache"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/metadata"
+	"gorm.io/gorm"
+)
+
+// MetadataProviderRequest represents an update to one metadata provider's settings
+type MetadataProviderRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Priority *int   `json:"priority,omitempty" validate:"min=0"`
+}
+
+// setupMetadataProviders registers the metadata providers and applies saved settings
+func (s *Server) setupMetadataProviders() {
+	openLibrary := metadata.NewOpenLibraryProvider()
+	openLibrary.SetCircuit(s.health.Circuit("openlibrary"))
+	googleBooks := metadata.NewGoogleBooksProvider(s.getGoogleBooksAPIKey)
+	googleBooks.SetCircuit(s.health.Circuit("googlebooks"))
+
+	s.metadata.Register(metadata.NewHardcoverProvider(s.getHardcoverClient), metadata.ProviderSettings{Enabled: true, Priority: 0})
+	s.metadata.Register(openLibrary, metadata.ProviderSettings{Enabled: true, Priority: 1})
+	s.metadata.Register(googleBooks, metadata.ProviderSettings{Enabled: true, Priority: 2})
+
+	s.loadMetadataSettings()
+}
+
+// getGoogleBooksAPIKey returns the optional Google Books API key
+func (s *Server) getGoogleBooksAPIKey() string {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "google_books_api_key").First(&setting).Error; err != nil {
+		return ""
+	}
+	return setting.Value
+}
+
+// loadMetadataSettings applies the saved enable/priority settings to the registry
+func (s *Server) loadMetadataSettings() {
+	var settings []db.Setting
+	s.db.Where("key LIKE ?", "metadata_%").Find(&settings)
+
+	values := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+	}
+
+	for _, info := range s.metadata.Info() {
+		cfg := metadata.ProviderSettings{Enabled: info.Enabled, Priority: info.Priority}
+		if v, ok := values["metadata_"+info.Name+"_enabled"]; ok {
+			cfg.Enabled = v == "true"
+		}
+		if v, ok := values["metadata_"+info.Name+"_priority"]; ok {
+			if p, err := strconv.Atoi(v); err == nil {
+				cfg.Priority = p
+			}
+		}
+		s.metadata.Configure(info.Name, cfg)
+	}
+}
+
+// getMetadataSettings returns the metadata providers in priority order
+func (s *Server) getMetadataSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.metadata.Info())
+}
+
+// updateMetadataSettings updates provider enable/priority settings
+func (s *Server) updateMetadataSettings(c echo.Context) error {
+	var req []MetadataProviderRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	for _, p := range req {
+		if _, ok := s.metadata.Get(p.Name); !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown metadata provider: " + p.Name})
+		}
+	}
+
+	for _, p := range req {
+		if p.Enabled != nil {
+			value := "false"
+			if *p.Enabled {
+				value = "true"
+			}
+			key := "metadata_" + p.Name + "_enabled"
+			setting := db.Setting{Key: key, Value: value}
+			s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+		}
+		if p.Priority != nil {
+			key := "metadata_" + p.Name + "_priority"
+			setting := db.Setting{Key: key, Value: strconv.Itoa(*p.Priority)}
+			s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+		}
+	}
+
+	s.loadMetadataSettings()
+
+	return c.JSON(http.StatusOK, s.metadata.Info())
+}
+
+// MetadataCacheSettingsRequest represents an update to the metadata cache settings
+type MetadataCacheSettingsRequest struct {
+	TTLMinutes *int `json:"ttlMinutes,omitempty" validate:"min=1"`
+}
+
+// loadCacheTTL returns the saved metadata cache TTL, or the default
+func loadCacheTTL(gdb *gorm.DB) time.Duration {
+	var setting db.Setting
+	if err := gdb.Where("key = ?", "metadata_cache_ttl_minutes").First(&setting).Error; err == nil {
+		if minutes, err := strconv.Atoi(setting.Value); err == nil && minutes > 0 {
+			return time.Duration(minutes) * time.Minute
+		}
+	}
+	return cache.DefaultTTL
+}
+
+// getMetadataCacheSettings returns the cache TTL and usage
+func (s *Server) getMetadataCacheSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.cache.Stats())
+}
+
+// updateMetadataCacheSettings changes the cache TTL. Entries already cached keep their expiry.
+func (s *Server) updateMetadataCacheSettings(c echo.Context) error {
+	var req MetadataCacheSettingsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	if req.TTLMinutes != nil {
+		setting := db.Setting{Key: "metadata_cache_ttl_minutes", Value: strconv.Itoa(*req.TTLMinutes)}
+		s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+		s.cache.SetTTL(time.Duration(*req.TTLMinutes) * time.Minute)
+	}
+
+	return c.JSON(http.StatusOK, s.cache.Stats())
+}
+
+// bustMetadataCache clears cached metadata responses so the next lookups hit the providers
+func (s *Server) bustMetadataCache(c echo.Context) error {
+	prefix := ""
+	switch provider := c.QueryParam("provider"); provider {
+	case "":
+	case "hardcover":
+		prefix = hardcover.CacheKeyPrefix
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown provider: " + provider})
+	}
+
+	removed := s.cache.Bust(prefix)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Metadata cache cleared",
+		"removed": removed,
+	})
+}
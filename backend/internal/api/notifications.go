@@ -1,33 +1,72 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/notifier"
 	"gorm.io/gorm"
 )
 
 // NotificationRequest represents a notification configuration request
 type NotificationRequest struct {
-	Name            string `json:"name"`
-	Type            string `json:"type"` // webhook, discord, telegram, email
-	Enabled         bool   `json:"enabled"`
-	WebhookURL      string `json:"webhookUrl,omitempty"`
-	DiscordWebhook  string `json:"discordWebhook,omitempty"`
-	TelegramBotToken string `json:"telegramBotToken,omitempty"`
-	TelegramChatID  string `json:"telegramChatId,omitempty"`
-	EmailTo         string `json:"emailTo,omitempty"`
-	OnGrab          bool   `json:"onGrab"`
-	OnDownload      bool   `json:"onDownload"`
-	OnUpgrade       bool   `json:"onUpgrade"`
-	OnImport        bool   `json:"onImport"`
-	OnDelete        bool   `json:"onDelete"`
-	OnHealthIssue   bool   `json:"onHealthIssue"`
+	Name             string `json:"name" validate:"required,max=100"`
+	Type             string `json:"type" validate:"required,oneof=webhook discord telegram email ntfy gotify pushover script"`
+	Enabled          bool   `json:"enabled"`
+	WebhookURL       string `json:"webhookUrl,omitempty" validate:"required_if=type webhook,url"`
+	DiscordWebhook   string `json:"discordWebhook,omitempty" validate:"required_if=type discord,url"`
+	TelegramBotToken string `json:"telegramBotToken,omitempty" validate:"required_if=type telegram"`
+	TelegramChatID   string `json:"telegramChatId,omitempty" validate:"required_if=type telegram"`
+	EmailTo          string `json:"emailTo,omitempty" validate:"required_if=type email"`
+	NtfyServerURL    string `json:"ntfyServerUrl,omitempty" validate:"url"`
+	NtfyTopic        string `json:"ntfyTopic,omitempty" validate:"required_if=type ntfy"`
+	NtfyToken        string `json:"ntfyToken,omitempty"`
+	GotifyServerURL  string `json:"gotifyServerUrl,omitempty" validate:"required_if=type gotify,url"`
+	GotifyAppToken   string `json:"gotifyAppToken,omitempty" validate:"required_if=type gotify"`
+	PushoverUserKey  string `json:"pushoverUserKey,omitempty" validate:"required_if=type pushover"`
+	PushoverAppToken string `json:"pushoverAppToken,omitempty" validate:"required_if=type pushover"`
+	ScriptPath       string `json:"scriptPath,omitempty" validate:"required_if=type script"`
+	OnGrab           bool   `json:"onGrab"`
+	OnDownload       bool   `json:"onDownload"`
+	OnUpgrade        bool   `json:"onUpgrade"`
+	OnImport         bool   `json:"onImport"`
+	OnDelete         bool   `json:"onDelete"`
+	OnHealthIssue    bool   `json:"onHealthIssue"`
+	OnRelease        bool   `json:"onRelease"`
+	OnFailure        bool   `json:"onFailure"`
+	OnUpdate         bool   `json:"onUpdate"`
+	OnRequest        bool   `json:"onRequest"`
+}
+
+// scriptsPath is the folder custom scripts are run from: SHELFARR_SCRIPTS_PATH, or
+// scripts in the config folder
+var scriptsPath string
+
+// setScriptsPath sets the scripts folder when starting
+func setScriptsPath(configPath string) {
+	scriptsPath = os.Getenv("SHELFARR_SCRIPTS_PATH")
+	if scriptsPath == "" {
+		scriptsPath = filepath.Join(configPath, "scripts")
+	}
+}
+
+// validScript checks a custom script connection's script is in the scripts folder
+func validScript(req NotificationRequest) error {
+	if req.Type != "script" {
+		return nil
+	}
+	if _, err := notifier.ResolveScript(req.ScriptPath, scriptsPath); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return nil
 }
 
 // getNotifications returns all notification configurations
@@ -40,8 +79,11 @@ func (s *Server) getNotifications(c echo.Context) error {
 // addNotification creates a new notification configuration
 func (s *Server) addNotification(c echo.Context) error {
 	var req NotificationRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if err := validScript(req); err != nil {
+		return err
 	}
 
 	notification := db.Notification{
@@ -53,12 +95,24 @@ func (s *Server) addNotification(c echo.Context) error {
 		TelegramBotToken: req.TelegramBotToken,
 		TelegramChatID:   req.TelegramChatID,
 		EmailTo:          req.EmailTo,
+		NtfyServerURL:    req.NtfyServerURL,
+		NtfyTopic:        req.NtfyTopic,
+		NtfyToken:        req.NtfyToken,
+		GotifyServerURL:  req.GotifyServerURL,
+		GotifyAppToken:   req.GotifyAppToken,
+		PushoverUserKey:  req.PushoverUserKey,
+		PushoverAppToken: req.PushoverAppToken,
+		ScriptPath:       req.ScriptPath,
 		OnGrab:           req.OnGrab,
 		OnDownload:       req.OnDownload,
 		OnUpgrade:        req.OnUpgrade,
 		OnImport:         req.OnImport,
 		OnDelete:         req.OnDelete,
 		OnHealthIssue:    req.OnHealthIssue,
+		OnRelease:        req.OnRelease,
+		OnFailure:        req.OnFailure,
+		OnUpdate:         req.OnUpdate,
+		OnRequest:        req.OnRequest,
 	}
 
 	if err := s.db.Create(&notification).Error; err != nil {
@@ -81,8 +135,11 @@ func (s *Server) updateNotification(c echo.Context) error {
 	}
 
 	var req NotificationRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if err := validScript(req); err != nil {
+		return err
 	}
 
 	notification.Name = req.Name
@@ -93,12 +150,24 @@ func (s *Server) updateNotification(c echo.Context) error {
 	notification.TelegramBotToken = req.TelegramBotToken
 	notification.TelegramChatID = req.TelegramChatID
 	notification.EmailTo = req.EmailTo
+	notification.NtfyServerURL = req.NtfyServerURL
+	notification.NtfyTopic = req.NtfyTopic
+	notification.NtfyToken = req.NtfyToken
+	notification.GotifyServerURL = req.GotifyServerURL
+	notification.GotifyAppToken = req.GotifyAppToken
+	notification.PushoverUserKey = req.PushoverUserKey
+	notification.PushoverAppToken = req.PushoverAppToken
+	notification.ScriptPath = req.ScriptPath
 	notification.OnGrab = req.OnGrab
 	notification.OnDownload = req.OnDownload
 	notification.OnUpgrade = req.OnUpgrade
 	notification.OnImport = req.OnImport
 	notification.OnDelete = req.OnDelete
 	notification.OnHealthIssue = req.OnHealthIssue
+	notification.OnRelease = req.OnRelease
+	notification.OnFailure = req.OnFailure
+	notification.OnUpdate = req.OnUpdate
+	notification.OnRequest = req.OnRequest
 
 	if err := s.db.Save(&notification).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update notification"})
@@ -133,97 +202,53 @@ func (s *Server) testNotification(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Notification not found"})
 	}
 
-	// Send test notification based on type
-	var testErr error
-	switch notification.Type {
-	case "webhook":
-		testErr = sendWebhookTest(notification.WebhookURL)
-	case "discord":
-		testErr = sendDiscordTest(notification.DiscordWebhook)
-	case "telegram":
-		testErr = sendTelegramTest(notification.TelegramBotToken, notification.TelegramChatID)
-	default:
+	n := createNotifierFromDB(notification, loadSMTPConfig(s.db))
+	if n == nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown notification type"})
 	}
 
-	if testErr != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": testErr.Error()})
-	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), notificationTimeout(n))
+	defer cancel()
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Test notification sent successfully"})
-}
-
-// sendWebhookTest sends a test webhook
-func sendWebhookTest(url string) error {
-	payload := map[string]interface{}{
-		"event": "test",
-		"message": "Test notification from Shelfarr",
+	if err := n.Test(ctx); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to send webhook: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
-	}
-
-	return nil
+	return c.JSON(http.StatusOK, map[string]string{"message": "Test notification sent successfully"})
 }
 
-// sendDiscordTest sends a test Discord notification
-func sendDiscordTest(webhookURL string) error {
-	payload := map[string]interface{}{
-		"embeds": []map[string]interface{}{
-			{
-				"title":       "Shelfarr Test",
-				"description": "This is a test notification from Shelfarr",
-				"color":       3447003, // Blue
-				"footer": map[string]string{
-					"text": "Shelfarr",
-				},
-			},
-		},
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to send Discord notification: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("Discord returned status %d", resp.StatusCode)
+// createNotifierFromDB creates a notifier instance from a database record.
+// smtp is only used by email notifications.
+func createNotifierFromDB(n db.Notification, smtp notifier.SMTPConfig) notifier.Notifier {
+	switch n.Type {
+	case "webhook":
+		return notifier.NewWebhookNotifier(n.WebhookURL)
+	case "discord":
+		return notifier.NewDiscordNotifier(n.DiscordWebhook)
+	case "telegram":
+		return notifier.NewTelegramNotifier(n.TelegramBotToken, n.TelegramChatID)
+	case "ntfy":
+		return notifier.NewNtfyNotifier(n.NtfyServerURL, n.NtfyTopic, n.NtfyToken)
+	case "gotify":
+		return notifier.NewGotifyNotifier(n.GotifyServerURL, n.GotifyAppToken)
+	case "pushover":
+		return notifier.NewPushoverNotifier(n.PushoverUserKey, n.PushoverAppToken)
+	case "email":
+		return notifier.NewEmailNotifier(smtp, n.EmailTo)
+	case "script":
+		return notifier.NewScriptNotifier(n.ScriptPath, scriptsPath)
+	default:
+		return nil
 	}
-
-	return nil
 }
 
-// sendTelegramTest sends a test Telegram notification
-func sendTelegramTest(botToken, chatID string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
-	payload := map[string]interface{}{
-		"chat_id": chatID,
-		"text":    "🧪 *Test notification from Shelfarr*\n\nIf you see this message, notifications are working!",
-		"parse_mode": "Markdown",
+// notificationTimeout is how long a notification may take to send. Custom scripts get
+// longer, as they may post-process files.
+func notificationTimeout(n notifier.Notifier) time.Duration {
+	if n.Type() == "script" {
+		return notifier.ScriptTimeout
 	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to send Telegram notification: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("Telegram returned status %d", resp.StatusCode)
-	}
-
-	return nil
+	return 30 * time.Second
 }
 
 // NotificationService provides methods for sending notifications
@@ -236,11 +261,17 @@ func NewNotificationService(database *gorm.DB) *NotificationService {
 	return &NotificationService{db: database}
 }
 
-// SendNotification sends a notification to all enabled notification configs
+// SendNotification sends a notification to all enabled notification configs, and
+// refreshes media servers for events that changed library files
 func (ns *NotificationService) SendNotification(eventType string, data map[string]interface{}) {
 	var notifications []db.Notification
 	ns.db.Where("enabled = ?", true).Find(&notifications)
 
+	ns.refreshMediaServers(eventType, data)
+
+	msg := notifier.MessageFromData(notifier.EventType(eventType), data)
+	smtp := loadSMTPConfig(ns.db)
+
 	for _, n := range notifications {
 		// Check if this notification should receive this event type
 		shouldSend := false
@@ -257,67 +288,136 @@ func (ns *NotificationService) SendNotification(eventType string, data map[strin
 			shouldSend = n.OnDelete
 		case "health":
 			shouldSend = n.OnHealthIssue
+		case "release":
+			shouldSend = n.OnRelease
+		case "failure":
+			shouldSend = n.OnFailure
+		case "update":
+			shouldSend = n.OnUpdate
+		case "request":
+			shouldSend = n.OnRequest
 		}
 
 		if !shouldSend {
 			continue
 		}
 
-		// Send notification based on type
-		switch n.Type {
-		case "webhook":
-			go sendWebhook(n.WebhookURL, eventType, data)
-		case "discord":
-			go sendDiscord(n.DiscordWebhook, eventType, data)
-		case "telegram":
-			go sendTelegram(n.TelegramBotToken, n.TelegramChatID, eventType, data)
+		sender := createNotifierFromDB(n, smtp)
+		if sender == nil {
+			continue
 		}
+
+		go func(name string, sender notifier.Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout(sender))
+			defer cancel()
+			if err := sender.Send(ctx, msg); err != nil {
+				notificationsLog.Warn("Notification failed", "notification", name, "type", sender.Type(), "error", err)
+			}
+		}(n.Name, sender)
 	}
 }
 
-func sendWebhook(url, eventType string, data map[string]interface{}) {
-	payload := map[string]interface{}{
-		"event": eventType,
-		"data":  data,
+// NotifyNewReleases marks monitored books whose release date has passed as missing
+// and sends a release notification for each. Returns the number of books released.
+func (ns *NotificationService) NotifyNewReleases() (int, error) {
+	var books []db.Book
+	err := ns.db.Preload("Author").
+		Where("monitored = ? AND status = ? AND release_date IS NOT NULL AND release_date <= ?", true, db.StatusUnreleased, time.Now()).
+		Find(&books).Error
+	if err != nil {
+		return 0, err
 	}
-	body, _ := json.Marshal(payload)
-	http.Post(url, "application/json", bytes.NewBuffer(body))
+
+	for _, book := range books {
+		if err := ns.db.Model(&book).Update("status", db.StatusMissing).Error; err != nil {
+			notificationsLog.Warn("Failed to mark book as released", "book", book.Title, "error", err)
+			continue
+		}
+
+		ns.SendNotification("release", map[string]interface{}{
+			"title":       book.Title,
+			"author":      book.Author.Name,
+			"bookId":      book.ID,
+			"releaseDate": book.ReleaseDate.Format("2006-01-02"),
+		})
+	}
+
+	return len(books), nil
 }
 
-func sendDiscord(webhookURL, eventType string, data map[string]interface{}) {
-	title := data["title"].(string)
-	message := fmt.Sprintf("Event: %s", eventType)
-	if msg, ok := data["message"].(string); ok {
-		message = msg
+// SendLibraryDigest emails every opted-in user a summary of books imported since the given time.
+// Returns the number of digests sent.
+func (ns *NotificationService) SendLibraryDigest(ctx context.Context, since time.Time) (int, error) {
+	smtp := loadSMTPConfig(ns.db)
+	if !smtp.Valid() {
+		return 0, fmt.Errorf("SMTP server is not configured")
 	}
 
-	payload := map[string]interface{}{
-		"embeds": []map[string]interface{}{
-			{
-				"title":       title,
-				"description": message,
-				"color":       3447003,
-			},
-		},
+	var users []db.User
+	if err := ns.db.Where("weekly_digest = ? AND email != ''", true).Find(&users).Error; err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, nil
 	}
-	body, _ := json.Marshal(payload)
-	http.Post(webhookURL, "application/json", bytes.NewBuffer(body))
-}
 
-func sendTelegram(botToken, chatID, eventType string, data map[string]interface{}) {
-	title := data["title"].(string)
-	message := fmt.Sprintf("📚 *%s*\n\nEvent: %s", title, eventType)
-	if msg, ok := data["message"].(string); ok {
-		message = fmt.Sprintf("📚 *%s*\n\n%s", title, msg)
+	var files []db.MediaFile
+	if err := ns.db.Preload("Book.Author").Where("imported_at >= ?", since).Order("imported_at ASC").Find(&files).Error; err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, nil
 	}
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
-	payload := map[string]interface{}{
-		"chat_id":    chatID,
-		"text":       message,
-		"parse_mode": "Markdown",
+	// Group files by book so each title is listed once with all its formats
+	var order []uint
+	formats := make(map[uint][]string)
+	books := make(map[uint]db.Book)
+	for _, f := range files {
+		if _, seen := books[f.BookID]; !seen {
+			order = append(order, f.BookID)
+			books[f.BookID] = f.Book
+		}
+		formats[f.BookID] = append(formats[f.BookID], strings.ToUpper(f.Format))
 	}
-	body, _ := json.Marshal(payload)
-	http.Post(url, "application/json", bytes.NewBuffer(body))
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%d new book(s) were added to your library since %s:\n\n", len(order), since.Format("January 2, 2006"))
+	for _, id := range order {
+		book := books[id]
+		line := "- " + book.Title
+		if book.Author.Name != "" {
+			line += " by " + book.Author.Name
+		}
+		fmt.Fprintf(&body, "%s (%s)\n", line, strings.Join(formats[id], ", "))
+	}
+	body.WriteString("\nHappy reading!\n")
+
+	subject := "Shelfarr: New in your library"
+	sent := 0
+	for _, user := range users {
+		if err := notifier.SendMail(ctx, smtp, []string{user.Email}, subject, body.String()); err != nil {
+			notificationsLog.Warn("Failed to send library digest", "user", user.Username, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
 }
 
+// sendLibraryDigest sends the "new in your library" digest for the past week immediately
+func (s *Server) sendLibraryDigest(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+
+	sent, err := s.notifier.SendLibraryDigest(ctx, time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Library digest sent",
+		"sent":    sent,
+	})
+}
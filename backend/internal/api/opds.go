@@ -0,0 +1,344 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// OPDS 1.2 catalog (https://specs.opds.io/opds-1.2) for e-reader apps. Only books with
+// files are listed, since a catalog entry is only useful if it can be downloaded.
+
+const (
+	opdsPageSize        = 50
+	opdsNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	opdsAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	openSearchType      = "application/opensearchdescription+xml"
+)
+
+// opdsFeed is an Atom feed with OPDS extensions
+type opdsFeed struct {
+	XMLName      xml.Name    `xml:"feed"`
+	Xmlns        string      `xml:"xmlns,attr"`
+	XmlnsOPDS    string      `xml:"xmlns:opds,attr"`
+	XmlnsSearch  string      `xml:"xmlns:opensearch,attr"`
+	XmlnsDC      string      `xml:"xmlns:dc,attr"`
+	ID           string      `xml:"id"`
+	Title        string      `xml:"title"`
+	Updated      string      `xml:"updated"`
+	TotalResults int64       `xml:"opensearch:totalResults,omitempty"`
+	ItemsPerPage int         `xml:"opensearch:itemsPerPage,omitempty"`
+	Links        []opdsLink  `xml:"link"`
+	Entries      []opdsEntry `xml:"entry"`
+}
+
+type opdsLink struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type opdsEntry struct {
+	ID       string         `xml:"id"`
+	Title    string         `xml:"title"`
+	Updated  string         `xml:"updated"`
+	Authors  []opdsAuthor   `xml:"author,omitempty"`
+	Language string         `xml:"dc:language,omitempty"`
+	Issued   string         `xml:"dc:issued,omitempty"`
+	Content  *opdsContent   `xml:"content,omitempty"`
+	Category []opdsCategory `xml:"category,omitempty"`
+	Links    []opdsLink     `xml:"link"`
+}
+
+type opdsAuthor struct {
+	Name string `xml:"name"`
+}
+
+type opdsContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type opdsCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// openSearchDescription tells OPDS clients how to build search URLs
+type openSearchDescription struct {
+	XMLName        xml.Name        `xml:"OpenSearchDescription"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	ShortName      string          `xml:"ShortName"`
+	Description    string          `xml:"Description"`
+	InputEncoding  string          `xml:"InputEncoding"`
+	OutputEncoding string          `xml:"OutputEncoding"`
+	URL            []openSearchURL `xml:"Url"`
+}
+
+type openSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// getOPDSRoot returns the catalog's navigation feed
+func (s *Server) getOPDSRoot(c echo.Context) error {
+	feed := newOPDSFeed("urn:shelfarr:root", "Shelfarr Library")
+	feed.Links = append(feed.Links,
+		opdsLink{Rel: "self", Href: opdsURL(""), Type: opdsNavigationType},
+		opdsLink{Rel: "start", Href: opdsURL(""), Type: opdsNavigationType},
+		opdsLink{Rel: "search", Href: opdsURL("/opensearch.xml"), Type: openSearchType},
+	)
+	feed.Entries = []opdsEntry{{
+		ID:      "urn:shelfarr:new",
+		Title:   "Recently Added",
+		Updated: feed.Updated,
+		Content: &opdsContent{Type: "text", Text: "Books most recently added to the library"},
+		Links:   []opdsLink{{Rel: "subsection", Href: opdsURL("/new"), Type: opdsAcquisitionType}},
+	}}
+
+	var collections int64
+	s.db.Model(&db.Collection{}).Where("opds = ?", true).Scopes(visibleCollections(c)).Count(&collections)
+	if collections > 0 {
+		feed.Entries = append(feed.Entries, opdsEntry{
+			ID:      "urn:shelfarr:collections",
+			Title:   "Collections",
+			Updated: feed.Updated,
+			Content: &opdsContent{Type: "text", Text: "Your collections"},
+			Links:   []opdsLink{{Rel: "subsection", Href: opdsURL("/collections"), Type: opdsNavigationType}},
+		})
+	}
+	return opdsXML(c, opdsNavigationType, feed)
+}
+
+// getOPDSCollections lists the user's collections, and those shared with them, that
+// are shared to the catalog
+func (s *Server) getOPDSCollections(c echo.Context) error {
+	var collections []db.Collection
+	s.db.Where("opds = ?", true).Scopes(visibleCollections(c)).Order("name ASC").Find(&collections)
+
+	feed := newOPDSFeed("urn:shelfarr:collections", "Collections")
+	feed.Links = append(feed.Links,
+		opdsLink{Rel: "self", Href: opdsURL("/collections"), Type: opdsNavigationType},
+		opdsLink{Rel: "start", Href: opdsURL(""), Type: opdsNavigationType},
+		opdsLink{Rel: "up", Href: opdsURL(""), Type: opdsNavigationType},
+	)
+	for _, collection := range collections {
+		entry := opdsEntry{
+			ID:      fmt.Sprintf("urn:shelfarr:collection:%d", collection.ID),
+			Title:   collection.Name,
+			Updated: collection.UpdatedAt.UTC().Format(time.RFC3339),
+			Links:   []opdsLink{{Rel: "subsection", Href: opdsURL(fmt.Sprintf("/collections/%d", collection.ID)), Type: opdsAcquisitionType}},
+		}
+		if collection.Description != "" {
+			entry.Content = &opdsContent{Type: "text", Text: collection.Description}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return opdsXML(c, opdsNavigationType, feed)
+}
+
+// getOPDSCollection returns a shared collection's books in the collection's order
+func (s *Server) getOPDSCollection(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid collection ID"})
+	}
+
+	var collection db.Collection
+	if err := s.db.Where("opds = ?", true).Scopes(visibleCollections(c)).First(&collection, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Collection not found"})
+	}
+
+	feed := newOPDSFeed(fmt.Sprintf("urn:shelfarr:collection:%d", collection.ID), collection.Name)
+	query := s.opdsBookQuery(c).
+		Joins("JOIN collection_books ON collection_books.book_id = books.id").
+		Where("collection_books.collection_id = ?", collection.ID).
+		Order("collection_books.position")
+	return s.opdsBooks(c, feed, opdsURL(fmt.Sprintf("/collections/%d?", collection.ID)), query)
+}
+
+// getOPDSOpenSearch returns the OpenSearch description for the catalog's search
+func (s *Server) getOPDSOpenSearch(c echo.Context) error {
+	return opdsXML(c, openSearchType, openSearchDescription{
+		Xmlns:          "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:      "Shelfarr",
+		Description:    "Search the Shelfarr library by title, author or series",
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+		URL: []openSearchURL{{
+			Type:     opdsAcquisitionType,
+			Template: opdsURL("/search?q={searchTerms}&page={startPage?}"),
+		}},
+	})
+}
+
+// getOPDSNew returns the books most recently added to the library
+func (s *Server) getOPDSNew(c echo.Context) error {
+	feed := newOPDSFeed("urn:shelfarr:new", "Recently Added")
+	return s.opdsBooks(c, feed, opdsURL("/new?"), s.opdsBookQuery(c).Order("books.created_at DESC"))
+}
+
+// searchOPDS returns the books whose title, author or series matches q
+func (s *Server) searchOPDS(c echo.Context) error {
+	q := strings.TrimSpace(c.QueryParam("q"))
+	feed := newOPDSFeed("urn:shelfarr:search:"+url.QueryEscape(q), "Search: "+q)
+	if q == "" {
+		return opdsXML(c, opdsAcquisitionType, feed)
+	}
+
+	query := s.opdsBookQuery(c)
+	op := db.Like(s.db)
+	for _, term := range strings.Fields(q) {
+		like := "%" + term + "%"
+		query = query.Where(
+			"books.title "+op+" ? OR books.subtitle "+op+" ? OR books.isbn13 = ? OR books.isbn = ?"+
+				" OR books.author_id IN (?) OR books.series_id IN (?)",
+			like, like, term, term,
+			s.db.Model(&db.Author{}).Select("id").Where("name "+op+" ?", like),
+			s.db.Model(&db.Series{}).Select("id").Where("name "+op+" ?", like),
+		)
+	}
+	return s.opdsBooks(c, feed, opdsURL("/search?q=")+url.QueryEscape(q)+"&", query.Order("books.title"))
+}
+
+// opdsBookQuery selects the library books the user can see that have at least one file
+func (s *Server) opdsBookQuery(c echo.Context) *gorm.DB {
+	return s.db.Model(&db.Book{}).Scopes(s.visibleBooks(c)).
+		Where("EXISTS (SELECT 1 FROM media_files WHERE media_files.book_id = books.id AND media_files.deleted_at IS NULL)")
+}
+
+// opdsBooks writes one page of query's books as an acquisition feed. pageURL is the
+// feed's URL, ending in "?" or "&", for the navigation links.
+func (s *Server) opdsBooks(c echo.Context, feed opdsFeed, pageURL string, query *gorm.DB) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var books []db.Book
+	err := query.Preload("Author").Preload("Series").Preload("Genres").Preload("MediaFiles").
+		Offset((page - 1) * opdsPageSize).Limit(opdsPageSize).
+		Find(&books).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	feed.TotalResults = total
+	feed.ItemsPerPage = opdsPageSize
+	feed.Links = append(feed.Links,
+		opdsLink{Rel: "self", Href: fmt.Sprintf("%spage=%d", pageURL, page), Type: opdsAcquisitionType},
+		opdsLink{Rel: "start", Href: opdsURL(""), Type: opdsNavigationType},
+		opdsLink{Rel: "search", Href: opdsURL("/opensearch.xml"), Type: openSearchType},
+	)
+	if page > 1 {
+		feed.Links = append(feed.Links, opdsLink{Rel: "previous", Href: fmt.Sprintf("%spage=%d", pageURL, page-1), Type: opdsAcquisitionType})
+	}
+	if int64(page*opdsPageSize) < total {
+		feed.Links = append(feed.Links, opdsLink{Rel: "next", Href: fmt.Sprintf("%spage=%d", pageURL, page+1), Type: opdsAcquisitionType})
+	}
+
+	for _, book := range books {
+		feed.Entries = append(feed.Entries, opdsBookEntry(book))
+	}
+	return opdsXML(c, opdsAcquisitionType, feed)
+}
+
+// opdsBookEntry builds a catalog entry with a download link for each of a book's files
+func opdsBookEntry(book db.Book) opdsEntry {
+	entry := opdsEntry{
+		ID:       fmt.Sprintf("urn:shelfarr:book:%d", book.ID),
+		Title:    book.Title,
+		Updated:  book.UpdatedAt.UTC().Format(time.RFC3339),
+		Language: book.LanguageCode,
+	}
+	if book.Author.Name != "" {
+		entry.Authors = []opdsAuthor{{Name: book.Author.Name}}
+	}
+	if book.ReleaseYear > 0 {
+		entry.Issued = strconv.Itoa(book.ReleaseYear)
+	}
+	if book.Description != "" {
+		entry.Content = &opdsContent{Type: "text", Text: book.Description}
+	}
+	for _, genre := range book.Genres {
+		entry.Category = append(entry.Category, opdsCategory{Term: genre.Slug, Label: genre.Name})
+	}
+	if cover := coverImageURL(&book); cover != "" {
+		entry.Links = append(entry.Links,
+			opdsLink{Rel: "http://opds-spec.org/image", Href: cover, Type: "image/jpeg"},
+			opdsLink{Rel: "http://opds-spec.org/image/thumbnail", Href: cover + "&width=300", Type: "image/jpeg"},
+		)
+	}
+	for _, file := range book.MediaFiles {
+		entry.Links = append(entry.Links, opdsLink{
+			Rel:   "http://opds-spec.org/acquisition",
+			Href:  opdsURL(fmt.Sprintf("/files/%d", file.ID)),
+			Type:  mediaMimeType(file.Format),
+			Title: strings.ToUpper(file.Format),
+		})
+	}
+	return entry
+}
+
+// mediaMimeType returns the MIME type for a media file format, including the ebook and
+// comic formats Go doesn't know by extension
+func mediaMimeType(format string) string {
+	switch strings.ToLower(format) {
+	case "epub":
+		return "application/epub+zip"
+	case "mobi":
+		return "application/x-mobipocket-ebook"
+	case "azw", "azw3":
+		return "application/vnd.amazon.ebook"
+	case "cbz":
+		return "application/vnd.comicbook+zip"
+	case "cbr":
+		return "application/vnd.comicbook-rar"
+	case "fb2":
+		return "application/x-fictionbook+xml"
+	case "m4b":
+		return "audio/mp4"
+	}
+	if t := mime.TypeByExtension("." + format); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// opdsURL returns the URL of a page of the catalog, such as "/new"
+func opdsURL(page string) string {
+	return currentURLBase() + "/opds" + page
+}
+
+func newOPDSFeed(id, title string) opdsFeed {
+	return opdsFeed{
+		Xmlns:       "http://www.w3.org/2005/Atom",
+		XmlnsOPDS:   "http://opds-spec.org/2010/catalog",
+		XmlnsSearch: "http://a9.com/-/spec/opensearch/1.1/",
+		XmlnsDC:     "http://purl.org/dc/terms/",
+		ID:          id,
+		Title:       title,
+		Updated:     time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func opdsXML(c echo.Context, contentType string, v interface{}) error {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, contentType+";charset=utf-8", append([]byte(xml.Header), body...))
+}
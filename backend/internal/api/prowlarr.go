@@ -0,0 +1,315 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/indexer"
+)
+
+// Prowlarr application sync. Prowlarr pushes its indexers to applications through the
+// Readarr v1 indexer API, so Shelfarr implements that subset under /api/v1. Add Shelfarr
+// in Prowlarr as a Readarr application with Shelfarr's API key; Prowlarr also checks
+// the version from /api/v1/system/status.
+
+// arrIndexer is an indexer in the Readarr v1 API
+type arrIndexer struct {
+	ID                      uint       `json:"id"`
+	Name                    string     `json:"name" validate:"required"`
+	EnableRss               bool       `json:"enableRss"`
+	EnableAutomaticSearch   bool       `json:"enableAutomaticSearch"`
+	EnableInteractiveSearch bool       `json:"enableInteractiveSearch"`
+	Priority                int        `json:"priority" validate:"min=0"`
+	Implementation          string     `json:"implementation" validate:"required"`
+	ImplementationName      string     `json:"implementationName"`
+	ConfigContract          string     `json:"configContract"`
+	Protocol                string     `json:"protocol"`
+	Tags                    []int      `json:"tags"`
+	Fields                  []arrField `json:"fields"`
+}
+
+// arrField is one setting of an arrIndexer
+type arrField struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// arrFieldNames are the fields Prowlarr sets on a Newznab indexer schema; it fails to
+// sync if any are missing
+var arrFieldNames = []string{
+	"baseUrl",
+	"apiPath",
+	"apiKey",
+	"categories",
+	"earlyReleaseLimit",
+}
+
+// arrTorrentFieldNames are the fields a Torznab indexer schema has in addition
+var arrTorrentFieldNames = []string{
+	"minimumSeeders",
+	"seedCriteria.seedRatio",
+	"seedCriteria.seedTime",
+	"seedCriteria.discographySeedTime",
+	"rejectBlocklistedTorrentHashesWhileGrabbing",
+}
+
+// arrIndexerTypes are the Shelfarr indexer types Prowlarr syncs
+var arrIndexerTypes = []string{"torznab", "newznab"}
+
+// getArrIndexerSchema returns the indexer types Prowlarr can create
+func (s *Server) getArrIndexerSchema(c echo.Context) error {
+	var schemas []arrIndexer
+	for _, indexerType := range arrIndexerTypes {
+		schema := newArrIndexer(indexerType)
+		schema.EnableRss = true
+		schema.EnableAutomaticSearch = true
+		schema.EnableInteractiveSearch = true
+		schema.Priority = 25
+		for _, name := range arrIndexerFieldNames(indexerType) {
+			schema.Fields = append(schema.Fields, arrField{Name: name})
+		}
+		schemas = append(schemas, schema)
+	}
+	return c.JSON(http.StatusOK, schemas)
+}
+
+// getArrIndexers returns the Torznab and Newznab indexers
+func (s *Server) getArrIndexers(c echo.Context) error {
+	var indexers []db.Indexer
+	if err := s.db.Where("type IN ?", arrIndexerTypes).Order("priority ASC").Find(&indexers).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]arrIndexer, len(indexers))
+	for i, idx := range indexers {
+		responses[i] = toArrIndexer(idx)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// getArrIndexer returns one Torznab or Newznab indexer
+func (s *Server) getArrIndexer(c echo.Context) error {
+	var indexer db.Indexer
+	if err := s.db.Where("type IN ?", arrIndexerTypes).First(&indexer, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Indexer not found"})
+	}
+	return c.JSON(http.StatusOK, toArrIndexer(indexer))
+}
+
+// addArrIndexer creates an indexer pushed by Prowlarr
+func (s *Server) addArrIndexer(c echo.Context) error {
+	var req arrIndexer
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var indexer db.Indexer
+	if err := applyArrIndexer(&indexer, req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	s.detectTorznabCaps(c.Request().Context(), &indexer, nil, nil)
+	if err := s.db.Create(&indexer).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create indexer"})
+	}
+
+	return c.JSON(http.StatusCreated, toArrIndexer(indexer))
+}
+
+// updateArrIndexer updates an indexer pushed by Prowlarr
+func (s *Server) updateArrIndexer(c echo.Context) error {
+	var indexer db.Indexer
+	if err := s.db.Where("type IN ?", arrIndexerTypes).First(&indexer, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Indexer not found"})
+	}
+
+	var req arrIndexer
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if err := applyArrIndexer(&indexer, req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	s.detectTorznabCaps(c.Request().Context(), &indexer, nil, nil)
+	if err := s.db.Save(&indexer).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update indexer"})
+	}
+
+	return c.JSON(http.StatusAccepted, toArrIndexer(indexer))
+}
+
+// deleteArrIndexer removes an indexer Prowlarr no longer syncs
+func (s *Server) deleteArrIndexer(c echo.Context) error {
+	var indexer db.Indexer
+	if err := s.db.Where("type IN ?", arrIndexerTypes).First(&indexer, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Indexer not found"})
+	}
+	if err := s.db.Delete(&indexer).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete indexer"})
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// testArrIndexer validates an indexer Prowlarr is about to push. The indexer isn't
+// queried, since Prowlarr sends a placeholder when testing the application.
+func (s *Server) testArrIndexer(c echo.Context) error {
+	var req arrIndexer
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if err := applyArrIndexer(&db.Indexer{}, req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{})
+}
+
+// applyArrIndexer copies the settings of a Readarr API indexer onto a Torznab or Newznab
+// indexer
+func applyArrIndexer(indexer *db.Indexer, req arrIndexer) error {
+	switch req.Implementation {
+	case "Torznab":
+		indexer.Type = "torznab"
+	case "Newznab":
+		indexer.Type = "newznab"
+	default:
+		return fmt.Errorf("only Torznab and Newznab indexers are supported")
+	}
+
+	var baseURL, apiPath, apiKey string
+	var categories []int
+	var seedRatio *float64
+	var seedTime *int
+	for _, field := range req.Fields {
+		switch field.Name {
+		case "baseUrl":
+			json.Unmarshal(field.Value, &baseURL)
+		case "apiPath":
+			json.Unmarshal(field.Value, &apiPath)
+		case "apiKey":
+			json.Unmarshal(field.Value, &apiKey)
+		case "categories":
+			json.Unmarshal(field.Value, &categories)
+		case "seedCriteria.seedRatio":
+			json.Unmarshal(field.Value, &seedRatio)
+		case "seedCriteria.seedTime":
+			json.Unmarshal(field.Value, &seedTime)
+		}
+	}
+	if req.Name == "" || baseURL == "" {
+		return fmt.Errorf("name and baseUrl are required")
+	}
+	if apiPath == "" {
+		apiPath = "/api"
+	}
+
+	indexer.Name = req.Name
+	indexer.URL = strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(apiPath, "/")
+	indexer.APIKey = apiKey
+	indexer.Categories = formatCategories(categories)
+	indexer.Priority = req.Priority
+	indexer.Enabled = req.EnableAutomaticSearch || req.EnableInteractiveSearch
+	indexer.SeedRatio, indexer.SeedTime = 0, 0
+	if seedRatio != nil {
+		indexer.SeedRatio = *seedRatio
+	}
+	if seedTime != nil {
+		indexer.SeedTime = *seedTime
+	}
+	return nil
+}
+
+// toArrIndexer converts a Torznab or Newznab indexer for the Readarr API
+func toArrIndexer(indexer db.Indexer) arrIndexer {
+	// Prowlarr sends base URLs with a trailing slash, and updates indexers whose
+	// settings read back differently
+	baseURL, apiPath := indexer.URL, "/api"
+	if strings.HasSuffix(baseURL, apiPath) {
+		baseURL = strings.TrimSuffix(baseURL, apiPath) + "/"
+	} else {
+		apiPath = ""
+	}
+
+	values := map[string]interface{}{
+		"baseUrl":    baseURL,
+		"apiPath":    apiPath,
+		"apiKey":     indexer.APIKey,
+		"categories": parseCategories(indexer.Categories),
+	}
+	// Unset seeding goals are sent as null, so Prowlarr doesn't see them as changed
+	if indexer.SeedRatio > 0 {
+		values["seedCriteria.seedRatio"] = indexer.SeedRatio
+	}
+	if indexer.SeedTime > 0 {
+		values["seedCriteria.seedTime"] = indexer.SeedTime
+	}
+	var fields []arrField
+	for _, name := range arrIndexerFieldNames(indexer.Type) {
+		field := arrField{Name: name}
+		if value, ok := values[name]; ok {
+			field.Value, _ = json.Marshal(value)
+		}
+		fields = append(fields, field)
+	}
+
+	arr := newArrIndexer(indexer.Type)
+	arr.ID = indexer.ID
+	arr.Name = indexer.Name
+	arr.EnableRss = indexer.Enabled
+	arr.EnableAutomaticSearch = indexer.Enabled
+	arr.EnableInteractiveSearch = indexer.Enabled
+	arr.Priority = indexer.Priority
+	arr.Fields = fields
+	return arr
+}
+
+// newArrIndexer returns a Readarr API indexer of the implementation for an indexer type
+func newArrIndexer(indexerType string) arrIndexer {
+	if indexerType == "newznab" {
+		return arrIndexer{
+			Implementation:     "Newznab",
+			ImplementationName: "Newznab",
+			ConfigContract:     "NewznabSettings",
+			Protocol:           indexer.ProtocolUsenet,
+			Tags:               []int{},
+		}
+	}
+	return arrIndexer{
+		Implementation:     "Torznab",
+		ImplementationName: "Torznab",
+		ConfigContract:     "TorznabSettings",
+		Protocol:           indexer.ProtocolTorrent,
+		Tags:               []int{},
+	}
+}
+
+// arrIndexerFieldNames returns the schema fields of an indexer type
+func arrIndexerFieldNames(indexerType string) []string {
+	if indexerType == "newznab" {
+		return arrFieldNames
+	}
+	return append(append([]string{}, arrFieldNames...), arrTorrentFieldNames...)
+}
+
+// parseCategories parses a comma-separated list of Newznab category IDs
+func parseCategories(s string) []int {
+	categories := []int{}
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			categories = append(categories, id)
+		}
+	}
+	return categories
+}
+
+// formatCategories formats Newznab category IDs for storage
+func formatCategories(categories []int) string {
+	parts := make([]string, len(categories))
+	for i, id := range categories {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/indexer"
+	"github.com/shelfarr/shelfarr/internal/torrent"
+)
+
+// activeDownloadStatuses are the statuses of downloads still on their way to the library
+var activeDownloadStatuses = []string{"queued", "downloading", "paused", "importing"}
+
+// BlocklistResponse is the API response format for blocklisted releases
+type BlocklistResponse struct {
+	ID          uint   `json:"id"`
+	BookID      uint   `json:"bookId"`
+	BookTitle   string `json:"bookTitle,omitempty"`
+	Title       string `json:"title"`
+	Indexer     string `json:"indexer"`
+	DownloadURL string `json:"downloadUrl"`
+	InfoHash    string `json:"infoHash,omitempty"`
+	Reason      string `json:"reason"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+// importQueueItem imports a download by hand, for downloads that are stuck or were
+// matched to the wrong book. The book and media type can be changed, and the path
+// defaults to where the download client saved the download.
+func (s *Server) importQueueItem(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid download ID"})
+	}
+
+	var req struct {
+		BookID    uint   `json:"bookId"`
+		MediaType string `json:"mediaType" validate:"oneof=ebook audiobook"`
+		Path      string `json:"path"`
+	}
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var download db.Download
+	if err := s.db.First(&download, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Download not found"})
+	}
+
+	// Map the download to a different book
+	if req.BookID != 0 && req.BookID != download.BookID {
+		var book db.Book
+		if err := s.db.First(&book, req.BookID).Error; err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+		}
+		oldBookID := download.BookID
+		download.BookID = req.BookID
+		s.db.Model(&download).Update("book_id", req.BookID)
+		s.releaseBook(oldBookID, download.ID)
+	}
+	if req.MediaType != "" && req.MediaType != download.MediaType {
+		download.MediaType = req.MediaType
+		s.db.Model(&download).Update("media_type", req.MediaType)
+	}
+
+	path := req.Path
+	if path == "" {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
+		path, err = s.downloadPath(ctx, &download)
+		cancel()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	s.importDownload(&download, path)
+
+	s.db.First(&download, download.ID)
+	if download.Status == "failed" {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": download.ErrorMessage})
+	}
+
+	return c.JSON(http.StatusOK, DownloadResponse{
+		ID:          download.ID,
+		BookID:      download.BookID,
+		Title:       download.Title,
+		MediaType:   download.MediaType,
+		Status:      download.Status,
+		Progress:    download.Progress,
+		Size:        download.Size,
+		Downloaded:  download.Downloaded,
+		AddedAt:     download.AddedAt,
+		CompletedAt: download.CompletedAt,
+	})
+}
+
+// ignoreQueueItem stops tracking a download without touching it in its download client.
+// Its book goes back to missing so it can be searched for again.
+func (s *Server) ignoreQueueItem(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid download ID"})
+	}
+
+	var download db.Download
+	if err := s.db.First(&download, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Download not found"})
+	}
+
+	if download.ClientType == clientTypeDirect {
+		s.cancelDirectDownload(&download)
+	}
+	s.db.Model(&download).Update("status", "ignored")
+	s.releaseBook(download.BookID, download.ID)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Download ignored"})
+}
+
+// downloadPath returns where a download was saved, asking its download client if it
+// hasn't been recorded yet
+func (s *Server) downloadPath(ctx context.Context, download *db.Download) (string, error) {
+	if download.OutputPath != "" {
+		return download.OutputPath, nil
+	}
+	if download.ClientType == clientTypeDirect {
+		return "", errors.New("Direct download has not finished; give the path to import")
+	}
+
+	mc := s.monitoredClient(download.ClientID)
+	if mc == nil {
+		return "", errors.New("Download client not found; give the path to import")
+	}
+	info, err := s.lookupDownload(ctx, download, mc.client)
+	if err != nil {
+		return "", errors.New("Could not get download from " + mc.config.Name + ": " + err.Error())
+	}
+	return downloadOutputPath(info), nil
+}
+
+// releaseBook puts a book back to missing when a download for it is removed, ignored or
+// mapped to another book, unless another download for it is still active
+func (s *Server) releaseBook(bookID, downloadID uint) {
+	var active int64
+	s.db.Model(&db.Download{}).Where("book_id = ? AND id <> ? AND status IN ?", bookID, downloadID, activeDownloadStatuses).Count(&active)
+	if active > 0 {
+		return
+	}
+	s.db.Model(&db.Book{}).Where("id = ? AND status = ?", bookID, db.StatusDownloading).Update("status", db.StatusMissing)
+}
+
+// blocklistDownload stops a download's release from being grabbed for its book again
+func (s *Server) blocklistDownload(download *db.Download, reason string) {
+	s.db.Create(&db.BlocklistItem{
+		BookID:      download.BookID,
+		Title:       download.Title,
+		Indexer:     download.Indexer,
+		DownloadURL: download.DownloadURL,
+		InfoHash:    download.InfoHash,
+		Reason:      reason,
+	})
+}
+
+// filterBlocklisted drops the results blocklisted for a book. Releases match by download
+// URL, by indexer and title, or for magnets by info hash.
+func (s *Server) filterBlocklisted(bookID uint, results []indexer.SearchResult) []indexer.SearchResult {
+	var items []db.BlocklistItem
+	s.db.Where("book_id = ?", bookID).Find(&items)
+	if len(items) == 0 {
+		return results
+	}
+
+	urls := make(map[string]bool)
+	releases := make(map[string]bool)
+	hashes := make(map[string]bool)
+	for _, item := range items {
+		if item.DownloadURL != "" {
+			urls[item.DownloadURL] = true
+		}
+		releases[item.Indexer+"\x00"+item.Title] = true
+		if item.InfoHash != "" {
+			hashes[item.InfoHash] = true
+		}
+	}
+
+	filtered := make([]indexer.SearchResult, 0, len(results))
+	for _, result := range results {
+		if urls[result.DownloadURL] || releases[result.Indexer+"\x00"+result.Title] {
+			continue
+		}
+		if torrent.IsMagnet(result.DownloadURL) {
+			if magnet, err := torrent.ParseMagnet(result.DownloadURL); err == nil && hashes[magnet.InfoHash] {
+				continue
+			}
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// getBlocklist returns blocklisted releases, newest first. bookId limits them to one book.
+func (s *Server) getBlocklist(c echo.Context) error {
+	query := s.db.Order("created_at DESC")
+	if bookID := c.QueryParam("bookId"); bookID != "" {
+		query = query.Where("book_id = ?", bookID)
+	}
+
+	var items []db.BlocklistItem
+	if err := query.Find(&items).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	bookIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		bookIDs = append(bookIDs, item.BookID)
+	}
+	var books []db.Book
+	s.db.Select("id", "title").Where("id IN ?", bookIDs).Find(&books)
+	titles := make(map[uint]string, len(books))
+	for _, book := range books {
+		titles[book.ID] = book.Title
+	}
+
+	responses := make([]BlocklistResponse, len(items))
+	for i, item := range items {
+		responses[i] = BlocklistResponse{
+			ID:          item.ID,
+			BookID:      item.BookID,
+			BookTitle:   titles[item.BookID],
+			Title:       item.Title,
+			Indexer:     item.Indexer,
+			DownloadURL: item.DownloadURL,
+			InfoHash:    item.InfoHash,
+			Reason:      item.Reason,
+			CreatedAt:   item.CreatedAt.Unix(),
+		}
+	}
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// deleteBlocklistItem removes a release from the blocklist so it can be grabbed again
+func (s *Server) deleteBlocklistItem(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid blocklist ID"})
+	}
+
+	result := s.db.Delete(&db.BlocklistItem{}, id)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete blocklist item"})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Blocklist item not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"golang.org/x/time/rate"
+)
+
+// Defaults for the security settings
+const (
+	defaultRateLimitPerMinute = 600
+	defaultRateLimitBurst     = 120
+	defaultLoginMaxFailures   = 5
+	defaultLoginLockoutMins   = 1
+)
+
+// maxLoginLockout caps how long repeated failed logins lock a client out
+const maxLoginLockout = 24 * time.Hour
+
+// SecuritySettings are the request rate limit and login lockout options in the
+// general settings
+type SecuritySettings struct {
+	RateLimitEnabled    bool `json:"rateLimitEnabled"`
+	RateLimitPerMinute  int  `json:"rateLimitPerMinute"`  // Sustained API requests a client can make
+	RateLimitBurst      int  `json:"rateLimitBurst"`      // Requests allowed at once above the sustained rate
+	LoginMaxFailures    int  `json:"loginMaxFailures"`    // Failed logins from an address before it's locked out; 0 never locks out
+	LoginLockoutMinutes int  `json:"loginLockoutMinutes"` // First lockout, doubled for each further failure
+}
+
+// securitySettings returns the rate limit and lockout options stored in the general
+// settings
+func (s *Server) securitySettings() SecuritySettings {
+	settings := SecuritySettings{
+		RateLimitEnabled:    true,
+		RateLimitPerMinute:  defaultRateLimitPerMinute,
+		RateLimitBurst:      defaultRateLimitBurst,
+		LoginMaxFailures:    defaultLoginMaxFailures,
+		LoginLockoutMinutes: defaultLoginLockoutMins,
+	}
+
+	var dbSettings []db.Setting
+	s.db.Where("key LIKE ?", "general_security_%").Find(&dbSettings)
+	for _, setting := range dbSettings {
+		n, _ := strconv.Atoi(setting.Value)
+		switch setting.Key {
+		case "general_security_rate_limit_enabled":
+			settings.RateLimitEnabled = setting.Value != "false"
+		case "general_security_rate_limit_per_minute":
+			settings.RateLimitPerMinute = n
+		case "general_security_rate_limit_burst":
+			settings.RateLimitBurst = n
+		case "general_security_login_max_failures":
+			settings.LoginMaxFailures = n
+		case "general_security_login_lockout_minutes":
+			settings.LoginLockoutMinutes = n
+		}
+	}
+	return settings
+}
+
+// applySecuritySettings sets the rate limits and login lockouts from the general
+// settings
+func (s *Server) applySecuritySettings() {
+	settings := s.securitySettings()
+	s.limiter.configure(settings)
+	s.logins.configure(settings)
+}
+
+// rateLimiter limits how fast each client, by API key or else IP address, can make
+// requests, with a token bucket per client
+type rateLimiter struct {
+	mutex     sync.Mutex
+	enabled   bool
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*rateLimitedClient
+	lastPrune time.Time
+}
+
+type rateLimitedClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{clients: map[string]*rateLimitedClient{}}
+}
+
+func (l *rateLimiter) configure(settings SecuritySettings) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.enabled = settings.RateLimitEnabled && settings.RateLimitPerMinute > 0
+	l.limit = rate.Limit(float64(settings.RateLimitPerMinute) / 60)
+	l.burst = max(settings.RateLimitBurst, 1)
+	// Start everyone afresh with the new limits
+	clear(l.clients)
+}
+
+// allow reports whether a client can make a request now, and if not, how long until
+// it can
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.enabled {
+		return true, 0
+	}
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > 10*time.Minute {
+		for k, client := range l.clients {
+			if now.Sub(client.lastSeen) > 10*time.Minute {
+				delete(l.clients, k)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	client, ok := l.clients[key]
+	if !ok {
+		client = &rateLimitedClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+
+	reservation := client.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// limitRequests turns away clients making requests faster than the rate limit
+func (s *Server) limitRequests(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Only the real API key gets a bucket of its own, so made-up keys can't dodge the limit
+		key := "ip:" + c.RealIP()
+		if given := givenAPIKey(c); given != "" && s.validAPIKey(given) {
+			key = "key:api"
+		}
+
+		if ok, wait := s.limiter.allow(key); !ok {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many requests; slow down and try again shortly"})
+		}
+		return next(c)
+	}
+}
+
+// clientIPExtractor returns how the client's address is found: from the connection, or
+// from X-Forwarded-For when the request comes through a reverse proxy listed in
+// SHELFARR_TRUSTED_PROXIES, so clients can't pick their own address to dodge the rate
+// limit and login lockouts
+func clientIPExtractor() echo.IPExtractor {
+	value := os.Getenv("SHELFARR_TRUSTED_PROXIES")
+	if value == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range strings.Split(value, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			slog.Warn("Ignoring invalid SHELFARR_TRUSTED_PROXIES entry; use an address or range such as 172.18.0.0/16", "value", proxy)
+			continue
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// loginGuard locks out addresses that keep failing to log in. Each failure past the
+// limit doubles the lockout.
+type loginGuard struct {
+	mutex       sync.Mutex
+	maxFailures int
+	lockout     time.Duration
+	addresses   map[string]*loginFailures
+}
+
+type loginFailures struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func newLoginGuard() *loginGuard {
+	return &loginGuard{addresses: map[string]*loginFailures{}}
+}
+
+func (g *loginGuard) configure(settings SecuritySettings) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.maxFailures = settings.LoginMaxFailures
+	g.lockout = time.Duration(max(settings.LoginLockoutMinutes, 1)) * time.Minute
+}
+
+// lockedFor returns how much longer an address is locked out, or 0
+func (g *loginGuard) lockedFor(ip string) time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if failures, ok := g.addresses[ip]; ok {
+		return max(time.Until(failures.lockedUntil), 0)
+	}
+	return 0
+}
+
+// failed records a failed login, returning how long the address is now locked out
+func (g *loginGuard) failed(ip string) time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := time.Now()
+	for addr, failures := range g.addresses {
+		// Failures are forgotten after a day without any
+		if now.Sub(failures.lastFailure) > 24*time.Hour && now.After(failures.lockedUntil) {
+			delete(g.addresses, addr)
+		}
+	}
+
+	failures, ok := g.addresses[ip]
+	if !ok {
+		failures = &loginFailures{}
+		g.addresses[ip] = failures
+	}
+	failures.count++
+	failures.lastFailure = now
+	if g.maxFailures <= 0 || failures.count < g.maxFailures {
+		return 0
+	}
+
+	lockout := maxLoginLockout
+	if doublings := failures.count - g.maxFailures; doublings < 16 {
+		lockout = min(g.lockout<<doublings, maxLoginLockout)
+	}
+	failures.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+// succeeded forgets an address's failed logins
+func (g *loginGuard) succeeded(ip string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.addresses, ip)
+}
+
+// guardLogins turns away logins from addresses that are locked out and records how
+// the others went. Requests that didn't try to log in, such as an e-reader's first
+// request before it's asked for credentials, aren't counted.
+func (s *Server) guardLogins(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ip := c.RealIP()
+		if wait := s.logins.lockedFor(ip); wait > 0 {
+			return loginLockedOut(c, wait)
+		}
+
+		err := next(c)
+
+		status := c.Response().Status
+		if he, ok := err.(*echo.HTTPError); ok {
+			status = he.Code
+		}
+		_, _, basicAuth := c.Request().BasicAuth()
+		attempted := basicAuth || strings.HasSuffix(c.Path(), "/auth/login")
+		switch {
+		case status == http.StatusUnauthorized && attempted:
+			if lockout := s.logins.failed(ip); lockout > 0 {
+				slog.Warn("Locking out logins after repeated failures", "ip", ip, "duration", lockout)
+			}
+		case status < 400 && attempted:
+			s.logins.succeeded(ip)
+		}
+		return err
+	}
+}
+
+func loginLockedOut(c echo.Context, wait time.Duration) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return c.JSON(http.StatusTooManyRequests, map[string]string{
+		"error": fmt.Sprintf("Too many failed logins; try again in %s", wait.Round(time.Second)),
+	})
+}
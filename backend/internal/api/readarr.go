@@ -0,0 +1,521 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/downloader"
+	"gorm.io/gorm"
+)
+
+// Readarr compatibility. Dashboards (Homarr, Organizr) and scripts written for Readarr
+// read its author, book, calendar and queue resources and post commands to it, so
+// Shelfarr serves that subset of the Readarr v1 API under /api/v1, next to its own
+// API. Point them at Shelfarr as if it were Readarr, with Shelfarr's API key.
+
+// arrAuthor is an author in the Readarr v1 API
+type arrAuthor struct {
+	ID              uint          `json:"id"`
+	AuthorName      string        `json:"authorName"`
+	SortName        string        `json:"sortName"`
+	ForeignAuthorID string        `json:"foreignAuthorId"`
+	TitleSlug       string        `json:"titleSlug"`
+	Overview        string        `json:"overview"`
+	Status          string        `json:"status"` // continuing, or ended once the author has died
+	Ended           bool          `json:"ended"`
+	Monitored       bool          `json:"monitored"`
+	Images          []arrImage    `json:"images"`
+	Added           time.Time     `json:"added"`
+	Statistics      arrStatistics `json:"statistics"`
+}
+
+// arrBook is a book in the Readarr v1 API
+type arrBook struct {
+	ID            uint          `json:"id"`
+	Title         string        `json:"title"`
+	SeriesTitle   string        `json:"seriesTitle"`
+	Overview      string        `json:"overview"`
+	AuthorID      uint          `json:"authorId"`
+	ForeignBookID string        `json:"foreignBookId"`
+	TitleSlug     string        `json:"titleSlug"`
+	Monitored     bool          `json:"monitored"`
+	Grabbed       bool          `json:"grabbed"`
+	ReleaseDate   *time.Time    `json:"releaseDate,omitempty"`
+	PageCount     int           `json:"pageCount"`
+	Genres        []string      `json:"genres"`
+	Ratings       arrRatings    `json:"ratings"`
+	Images        []arrImage    `json:"images"`
+	Added         time.Time     `json:"added"`
+	Statistics    arrStatistics `json:"statistics"`
+	Author        *arrAuthor    `json:"author,omitempty"`
+}
+
+// arrImage is a cover or author photo. URL is Shelfarr's cached copy; RemoteURL is
+// where it came from.
+type arrImage struct {
+	CoverType string `json:"coverType"` // cover for books, poster for authors
+	URL       string `json:"url"`
+	RemoteURL string `json:"remoteUrl"`
+}
+
+type arrRatings struct {
+	Votes int     `json:"votes"`
+	Value float32 `json:"value"`
+}
+
+// arrStatistics count a book's or author's files. For a book, the book counts are 1.
+type arrStatistics struct {
+	BookFileCount      int     `json:"bookFileCount"`
+	BookCount          int     `json:"bookCount"`          // Monitored books
+	AvailableBookCount int     `json:"availableBookCount"` // Books with files
+	TotalBookCount     int     `json:"totalBookCount"`
+	SizeOnDisk         int64   `json:"sizeOnDisk"`
+	PercentOfBooks     float64 `json:"percentOfBooks"`
+}
+
+// arrQueueItem is a download in the Readarr v1 queue
+type arrQueueItem struct {
+	ID                    uint       `json:"id"`
+	AuthorID              uint       `json:"authorId"`
+	BookID                uint       `json:"bookId"`
+	Title                 string     `json:"title"`
+	Size                  int64      `json:"size"`
+	Sizeleft              int64      `json:"sizeleft"`
+	Status                string     `json:"status"`                // queued, downloading, paused, completed, failed
+	TrackedDownloadStatus string     `json:"trackedDownloadStatus"` // ok, error
+	TrackedDownloadState  string     `json:"trackedDownloadState"`  // downloading, importing, failed
+	ErrorMessage          string     `json:"errorMessage,omitempty"`
+	DownloadID            string     `json:"downloadId"`
+	Protocol              string     `json:"protocol"` // torrent, usenet, unknown
+	DownloadClient        string     `json:"downloadClient"`
+	Indexer               string     `json:"indexer"`
+	OutputPath            string     `json:"outputPath,omitempty"`
+	Added                 time.Time  `json:"added"`
+	Author                *arrAuthor `json:"author,omitempty"`
+	Book                  *arrBook   `json:"book,omitempty"`
+}
+
+// arrPage is a page of a Readarr v1 list
+type arrPage[T any] struct {
+	Page          int    `json:"page"`
+	PageSize      int    `json:"pageSize"`
+	SortKey       string `json:"sortKey"`
+	SortDirection string `json:"sortDirection"`
+	TotalRecords  int64  `json:"totalRecords"`
+	Records       []T    `json:"records"`
+}
+
+// arrQueueStatuses are the downloads in the Readarr queue: those on their way to the
+// library, and failed ones until they're removed
+var arrQueueStatuses = append([]string{"failed"}, activeDownloadStatuses...)
+
+// getArrAuthors returns every author with a book the user can see
+func (s *Server) getArrAuthors(c echo.Context) error {
+	var authors []db.Author
+	if err := s.db.Scopes(s.ofVisibleBooks(c, "authors.id", "books.author_id")).Order("sort_name ASC, name ASC").Find(&authors).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, s.toArrAuthors(authors, s.visibleBooks(c)))
+}
+
+// getArrAuthor returns one author
+func (s *Server) getArrAuthor(c echo.Context) error {
+	var author db.Author
+	if err := s.db.Scopes(s.ofVisibleBooks(c, "authors.id", "books.author_id")).First(&author, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Author not found"})
+	}
+	return c.JSON(http.StatusOK, s.toArrAuthors([]db.Author{author}, s.visibleBooks(c))[0])
+}
+
+// getArrBooks returns the books, or with ?authorId= an author's, or with ?bookIds=
+// (repeated or comma-separated) the ones given
+func (s *Server) getArrBooks(c echo.Context) error {
+	visible := s.visibleBooks(c)
+	query := s.db.Scopes(visible).Preload("Author").Preload("Series").Preload("Genres").Order("sort_title ASC, title ASC")
+	if authorID := c.QueryParam("authorId"); authorID != "" {
+		query = query.Where("author_id = ?", authorID)
+	}
+	if ids := arrIDs(c.QueryParams()["bookIds"]); len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var books []db.Book
+	if err := query.Find(&books).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, s.toArrBooks(books, c.QueryParam("includeAuthor") != "false", visible))
+}
+
+// getArrBook returns one book
+func (s *Server) getArrBook(c echo.Context) error {
+	visible := s.visibleBooks(c)
+	var book db.Book
+	if err := s.db.Scopes(visible).Preload("Author").Preload("Series").Preload("Genres").First(&book, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+	return c.JSON(http.StatusOK, s.toArrBooks([]db.Book{book}, true, visible)[0])
+}
+
+// monitorArrBooks monitors or unmonitors books
+func (s *Server) monitorArrBooks(c echo.Context) error {
+	var req struct {
+		BookIDs   []uint `json:"bookIds" validate:"required"`
+		Monitored bool   `json:"monitored"`
+	}
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	visible := s.visibleBooks(c)
+	if err := s.db.Model(&db.Book{}).Scopes(visible).Where("id IN ?", req.BookIDs).Update("monitored", req.Monitored).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update books"})
+	}
+
+	var books []db.Book
+	if err := s.db.Scopes(visible).Preload("Author").Preload("Series").Preload("Genres").Where("id IN ?", req.BookIDs).Find(&books).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, s.toArrBooks(books, false, visible))
+}
+
+// getArrCalendar returns the books released between ?start= and ?end= (dates or
+// RFC 3339 times; today and two days on by default), only monitored ones unless
+// ?unmonitored=true
+func (s *Server) getArrCalendar(c echo.Context) error {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	start, err := parseArrTime(c.QueryParam("start"), today)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid start"})
+	}
+	end, err := parseArrTime(c.QueryParam("end"), today.AddDate(0, 0, 2))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid end"})
+	}
+
+	visible := s.visibleBooks(c)
+	query := s.db.Scopes(visible).Preload("Author").Preload("Series").Preload("Genres").
+		Where("release_date >= ? AND release_date <= ?", start, end).
+		Order("release_date ASC")
+	if c.QueryParam("unmonitored") != "true" {
+		query = query.Where("monitored = ?", true)
+	}
+
+	var books []db.Book
+	if err := query.Find(&books).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, s.toArrBooks(books, c.QueryParam("includeAuthor") == "true", visible))
+}
+
+// getArrQueue returns a page of the queue: ?page= (from 1) and ?pageSize= (10 by
+// default), newest first. ?includeAuthor=true and ?includeBook=true add the author
+// and book to each item.
+func (s *Server) getArrQueue(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	query := s.db.Model(&db.Download{}).Scopes(s.ofVisibleBooks(c, "downloads.book_id", "books.id")).Where("status IN ?", arrQueueStatuses)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	var downloads []db.Download
+	if err := query.Order("added_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&downloads).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	records, err := s.toArrQueueItems(downloads, c.QueryParam("includeAuthor") == "true", c.QueryParam("includeBook") == "true", s.visibleBooks(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, arrPage[arrQueueItem]{
+		Page:          page,
+		PageSize:      pageSize,
+		SortKey:       "added",
+		SortDirection: "descending",
+		TotalRecords:  total,
+		Records:       records,
+	})
+}
+
+// getArrQueueStatus counts the queue, as dashboards show in badges
+func (s *Server) getArrQueueStatus(c echo.Context) error {
+	var downloads []db.Download
+	if err := s.db.Select("status").Scopes(s.ofVisibleBooks(c, "downloads.book_id", "books.id")).Where("status IN ?", arrQueueStatuses).Find(&downloads).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	failed := 0
+	for _, download := range downloads {
+		if download.Status == "failed" {
+			failed++
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"totalCount":      len(downloads),
+		"count":           len(downloads),
+		"unknownCount":    0,
+		"errors":          failed > 0,
+		"warnings":        false,
+		"unknownErrors":   false,
+		"unknownWarnings": false,
+	})
+}
+
+// toArrAuthors converts authors, counting their books the user can see and their files
+func (s *Server) toArrAuthors(authors []db.Author, visible func(*gorm.DB) *gorm.DB) []arrAuthor {
+	ids := make([]uint, len(authors))
+	for i, author := range authors {
+		ids[i] = author.ID
+	}
+	var books []db.Book
+	s.db.Scopes(visible).Select("id", "author_id", "monitored").Where("author_id IN ?", ids).Find(&books)
+	bookIDs := make([]uint, len(books))
+	for i, book := range books {
+		bookIDs[i] = book.ID
+	}
+	files := s.arrFileStats(bookIDs)
+
+	stats := make(map[uint]*arrStatistics, len(authors))
+	for _, book := range books {
+		st := stats[book.AuthorID]
+		if st == nil {
+			st = &arrStatistics{}
+			stats[book.AuthorID] = st
+		}
+		st.TotalBookCount++
+		if book.Monitored {
+			st.BookCount++
+		}
+		if f := files[book.ID]; f.BookFileCount > 0 {
+			st.AvailableBookCount++
+			st.BookFileCount += f.BookFileCount
+			st.SizeOnDisk += f.SizeOnDisk
+		}
+	}
+
+	responses := make([]arrAuthor, len(authors))
+	for i, author := range authors {
+		ended := author.DeathDate != nil || author.DeathYear != nil
+		status := "continuing"
+		if ended {
+			status = "ended"
+		}
+		responses[i] = arrAuthor{
+			ID:              author.ID,
+			AuthorName:      author.Name,
+			SortName:        author.SortName,
+			ForeignAuthorID: author.HardcoverID,
+			TitleSlug:       author.Slug,
+			Overview:        author.Biography,
+			Status:          status,
+			Ended:           ended,
+			Monitored:       author.Monitored,
+			Images:          []arrImage{},
+			Added:           author.CreatedAt,
+		}
+		if image := authorImage(&author); image != "" {
+			responses[i].Images = append(responses[i].Images, arrImage{CoverType: "poster", URL: authorImageURL(&author), RemoteURL: image})
+		}
+		if st := stats[author.ID]; st != nil {
+			responses[i].Statistics = *st
+			if st.BookCount > 0 {
+				responses[i].Statistics.PercentOfBooks = float64(st.AvailableBookCount) * 100 / float64(st.BookCount)
+			}
+		}
+	}
+	return responses
+}
+
+// toArrBooks converts books, which must have their author, series and genres loaded;
+// authors' counts include only the visible books
+func (s *Server) toArrBooks(books []db.Book, includeAuthor bool, visible func(*gorm.DB) *gorm.DB) []arrBook {
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	files := s.arrFileStats(ids)
+
+	authors := make(map[uint]arrAuthor)
+	if includeAuthor {
+		var list []db.Author
+		seen := make(map[uint]bool)
+		for _, book := range books {
+			if book.Author.ID != 0 && !seen[book.Author.ID] {
+				seen[book.Author.ID] = true
+				list = append(list, book.Author)
+			}
+		}
+		for _, author := range s.toArrAuthors(list, visible) {
+			authors[author.ID] = author
+		}
+	}
+
+	responses := make([]arrBook, len(books))
+	for i, book := range books {
+		responses[i] = arrBook{
+			ID:            book.ID,
+			Title:         book.Title,
+			Overview:      book.Description,
+			AuthorID:      book.AuthorID,
+			ForeignBookID: book.HardcoverID,
+			TitleSlug:     book.Slug,
+			Monitored:     book.Monitored,
+			Grabbed:       book.Status == db.StatusDownloading,
+			ReleaseDate:   book.ReleaseDate,
+			PageCount:     book.PageCount,
+			Genres:        []string{},
+			Ratings:       arrRatings{Votes: book.RatingsCount, Value: book.Rating},
+			Images:        []arrImage{},
+			Added:         book.CreatedAt,
+			Statistics:    files[book.ID],
+		}
+		if book.Series != nil {
+			responses[i].SeriesTitle = book.Series.Name
+		}
+		for _, genre := range book.Genres {
+			responses[i].Genres = append(responses[i].Genres, genre.Name)
+		}
+		if cover := bookCover(&book); cover != "" {
+			responses[i].Images = append(responses[i].Images, arrImage{CoverType: "cover", URL: coverImageURL(&book), RemoteURL: cover})
+		}
+		st := &responses[i].Statistics
+		st.TotalBookCount = 1
+		if book.Monitored {
+			st.BookCount = 1
+		}
+		if st.BookFileCount > 0 {
+			st.AvailableBookCount = 1
+			st.PercentOfBooks = 100
+		}
+		if author, ok := authors[book.AuthorID]; ok {
+			responses[i].Author = &author
+		}
+	}
+	return responses
+}
+
+// toArrQueueItems converts downloads to queue items
+func (s *Server) toArrQueueItems(downloads []db.Download, includeAuthor, includeBook bool, visible func(*gorm.DB) *gorm.DB) ([]arrQueueItem, error) {
+	bookIDs := make([]uint, len(downloads))
+	clientIDs := make([]uint, len(downloads))
+	for i, download := range downloads {
+		bookIDs[i] = download.BookID
+		clientIDs[i] = download.ClientID
+	}
+
+	var books []db.Book
+	if err := s.db.Preload("Author").Preload("Series").Preload("Genres").Where("id IN ?", bookIDs).Find(&books).Error; err != nil {
+		return nil, err
+	}
+	booksByID := make(map[uint]arrBook, len(books))
+	for _, book := range s.toArrBooks(books, includeAuthor, visible) {
+		booksByID[book.ID] = book
+	}
+
+	var clients []db.DownloadClient
+	if err := s.db.Select("id", "name").Where("id IN ?", clientIDs).Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	clientNames := make(map[uint]string, len(clients))
+	for _, client := range clients {
+		clientNames[client.ID] = client.Name
+	}
+
+	items := make([]arrQueueItem, len(downloads))
+	for i, download := range downloads {
+		item := arrQueueItem{
+			ID:                    download.ID,
+			BookID:                download.BookID,
+			Title:                 download.Title,
+			Size:                  download.Size,
+			Status:                download.Status,
+			TrackedDownloadStatus: "ok",
+			TrackedDownloadState:  "downloading",
+			DownloadID:            download.ExternalID,
+			Protocol:              downloader.ClientProtocol(download.ClientType),
+			DownloadClient:        clientNames[download.ClientID],
+			Indexer:               download.Indexer,
+			OutputPath:            download.OutputPath,
+			Added:                 time.Unix(download.AddedAt, 0),
+		}
+		if download.Size > download.Downloaded {
+			item.Sizeleft = download.Size - download.Downloaded
+		}
+		switch download.Status {
+		case "importing":
+			item.Status, item.TrackedDownloadState, item.Sizeleft = "completed", "importing", 0
+		case "failed":
+			item.TrackedDownloadStatus, item.TrackedDownloadState = "error", "failed"
+			item.ErrorMessage = download.ErrorMessage
+		}
+		if download.ClientType == clientTypeDirect {
+			item.Protocol, item.DownloadClient = "unknown", "Shelfarr (direct download)"
+		}
+		if book, ok := booksByID[download.BookID]; ok {
+			item.AuthorID = book.AuthorID
+			if includeAuthor {
+				item.Author = book.Author
+			}
+			if includeBook {
+				book.Author = nil
+				item.Book = &book
+			}
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// arrFileStats counts the files of books and their size, by book ID
+func (s *Server) arrFileStats(bookIDs []uint) map[uint]arrStatistics {
+	var rows []struct {
+		BookID uint
+		Files  int
+		Size   int64
+	}
+	s.db.Model(&db.MediaFile{}).Select("book_id, COUNT(*) AS files, COALESCE(SUM(file_size), 0) AS size").
+		Where("book_id IN ?", bookIDs).Group("book_id").Scan(&rows)
+
+	stats := make(map[uint]arrStatistics, len(rows))
+	for _, row := range rows {
+		stats[row.BookID] = arrStatistics{BookFileCount: row.Files, SizeOnDisk: row.Size}
+	}
+	return stats
+}
+
+// arrIDs parses IDs given as repeated or comma-separated query parameters, skipping
+// any that aren't numbers
+func arrIDs(values []string) []uint {
+	var ids []uint
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil {
+				ids = append(ids, uint(id))
+			}
+		}
+	}
+	return ids
+}
+
+// parseArrTime parses a date or RFC 3339 time, or returns fallback for ""
+func parseArrTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
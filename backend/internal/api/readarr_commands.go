@@ -0,0 +1,324 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// Readarr commands. Scripts start Readarr's work by posting a command, such as
+// {"name": "BookSearch", "bookIds": [1]}, then poll it by ID until it ends. Shelfarr
+// runs the commands it has an equivalent for in the background and remembers the
+// most recent ones.
+
+// arrCommandNames are the commands Shelfarr runs, with the names Readarr shows for them
+var arrCommandNames = map[string]string{
+	"BookSearch":                "Book Search",
+	"AuthorSearch":              "Author Search",
+	"MissingBookSearch":         "Missing Book Search",
+	"RefreshBook":               "Refresh Book",
+	"RefreshAuthor":             "Refresh Author",
+	"RescanFolders":             "Rescan Folders",
+	"RefreshMonitoredDownloads": "Refresh Monitored Downloads",
+	"DownloadedBooksScan":       "Downloaded Books Scan",
+}
+
+// maxArrCommands is how many commands are remembered
+const maxArrCommands = 100
+
+// arrCommandBody is what a command works on
+type arrCommandBody struct {
+	BookIDs  []uint `json:"bookIds,omitempty"`
+	BookID   uint   `json:"bookId,omitempty"`
+	AuthorID uint   `json:"authorId,omitempty"`
+}
+
+// arrCommand is a command in the Readarr v1 API
+type arrCommand struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	CommandName string         `json:"commandName"`
+	Message     string         `json:"message,omitempty"`
+	Body        arrCommandBody `json:"body"`
+	Priority    string         `json:"priority"`
+	Status      string         `json:"status"` // queued, started, completed, failed
+	Result      string         `json:"result"` // unknown, successful, unsuccessful
+	Queued      time.Time      `json:"queued"`
+	Started     *time.Time     `json:"started,omitempty"`
+	Ended       *time.Time     `json:"ended,omitempty"`
+	Trigger     string         `json:"trigger"`
+}
+
+// arrCommands are the most recent commands, oldest first
+type arrCommands struct {
+	mu     sync.Mutex
+	nextID uint
+	list   []*arrCommand
+}
+
+// add remembers a new command, forgetting the oldest beyond maxArrCommands
+func (cs *arrCommands) add(command *arrCommand) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.nextID++
+	command.ID = cs.nextID
+	cs.list = append(cs.list, command)
+	if len(cs.list) > maxArrCommands {
+		cs.list = cs.list[len(cs.list)-maxArrCommands:]
+	}
+}
+
+// update changes a command while holding the lock, so readers see it whole
+func (cs *arrCommands) update(command *arrCommand, change func(*arrCommand)) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	change(command)
+}
+
+// get returns a copy of a command, or false if it's unknown or forgotten
+func (cs *arrCommands) get(id uint) (arrCommand, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for _, command := range cs.list {
+		if command.ID == id {
+			return *command, true
+		}
+	}
+	return arrCommand{}, false
+}
+
+// all returns copies of the commands, newest first
+func (cs *arrCommands) all() []arrCommand {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	commands := make([]arrCommand, len(cs.list))
+	for i, command := range cs.list {
+		commands[len(cs.list)-1-i] = *command
+	}
+	return commands
+}
+
+// getArrCommands returns the recent commands
+func (s *Server) getArrCommands(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.commands.all())
+}
+
+// getArrCommand returns one command, so scripts can poll it until it ends
+func (s *Server) getArrCommand(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid command ID"})
+	}
+	command, ok := s.commands.get(uint(id))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Command not found"})
+	}
+	return c.JSON(http.StatusOK, command)
+}
+
+// startArrCommand queues a command and runs it in the background
+func (s *Server) startArrCommand(c echo.Context) error {
+	var req struct {
+		Name string `json:"name" validate:"required"`
+		arrCommandBody
+	}
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	commandName, ok := arrCommandNames[req.Name]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown command: " + req.Name})
+	}
+
+	command := &arrCommand{
+		Name:        req.Name,
+		CommandName: commandName,
+		Body:        req.arrCommandBody,
+		Priority:    "normal",
+		Status:      "queued",
+		Result:      "unknown",
+		Queued:      time.Now(),
+		Trigger:     "manual",
+	}
+	s.commands.add(command)
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.runArrCommand(command)
+	}()
+
+	queued, _ := s.commands.get(command.ID)
+	return c.JSON(http.StatusCreated, queued)
+}
+
+// runArrCommand runs a command, recording when it starts and how it ends
+func (s *Server) runArrCommand(command *arrCommand) {
+	s.commands.update(command, func(command *arrCommand) {
+		started := time.Now()
+		command.Status, command.Started = "started", &started
+	})
+
+	message, err := s.execArrCommand(s.ctx, command.Name, command.Body)
+
+	s.commands.update(command, func(command *arrCommand) {
+		ended := time.Now()
+		command.Ended, command.Message = &ended, message
+		if err != nil {
+			command.Status, command.Result, command.Message = "failed", "unsuccessful", err.Error()
+			return
+		}
+		command.Status, command.Result = "completed", "successful"
+	})
+	if err != nil {
+		libraryLog.Warn("Readarr command failed", "command", command.Name, "error", err)
+	}
+}
+
+// execArrCommand does a command's work, returning a message about how it went
+func (s *Server) execArrCommand(ctx context.Context, name string, body arrCommandBody) (string, error) {
+	switch name {
+	case "BookSearch":
+		return s.searchArrBooks(body.BookIDs)
+	case "AuthorSearch":
+		if body.AuthorID == 0 {
+			return "", errors.New("authorId is required")
+		}
+		return s.searchArrBooks(s.missingArrBooks(s.db.Where("author_id = ?", body.AuthorID)))
+	case "MissingBookSearch":
+		return s.searchArrBooks(s.missingArrBooks(s.db))
+	case "RefreshBook":
+		if body.BookID == 0 {
+			return "", errors.New("bookId is required")
+		}
+		return s.callHandler(s.refreshBookMetadata, "/", nil, "id", strconv.FormatUint(uint64(body.BookID), 10))
+	case "RefreshAuthor":
+		// Without an author, every book is refreshed
+		var req struct {
+			BookIDs []uint `json:"bookIds"`
+		}
+		if body.AuthorID != 0 {
+			if err := s.db.Model(&db.Book{}).Where("author_id = ? AND hardcover_id != ''", body.AuthorID).Pluck("id", &req.BookIDs).Error; err != nil {
+				return "", err
+			}
+			if len(req.BookIDs) == 0 {
+				return "The author has no books to refresh", nil
+			}
+		}
+		return s.callHandler(s.refreshAllMetadata, "/", req)
+	case "RescanFolders":
+		result, err := s.rescanLibrary(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Added %d, moved %d and removed %d files", result.Added, result.Moved, result.Removed), nil
+	case "RefreshMonitoredDownloads", "DownloadedBooksScan":
+		return "", s.scheduler.RunNow("download_sync")
+	}
+	return "", errors.New("unknown command: " + name)
+}
+
+// missingArrBooks returns the IDs of the monitored books in query that have no files
+func (s *Server) missingArrBooks(query *gorm.DB) []uint {
+	var ids []uint
+	query.Model(&db.Book{}).Where("monitored = ? AND status = ?", true, db.StatusMissing).Pluck("id", &ids)
+	return ids
+}
+
+// searchArrBooks searches the indexers for books and grabs the best release of each,
+// as their search button does, failing only if no book was grabbed. mediaTypes are
+// searched for in turn; ebook if none are given.
+func (s *Server) searchArrBooks(bookIDs []uint, mediaTypes ...string) (string, error) {
+	if len(bookIDs) == 0 {
+		return "No books to search for", nil
+	}
+
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"ebook"}
+	}
+
+	var grabbed int
+	var failures []error
+	for _, id := range bookIDs {
+		found := false
+		for _, mediaType := range mediaTypes {
+			if s.ctx.Err() != nil {
+				return "", s.ctx.Err()
+			}
+			target := "/?mediaType=" + url.QueryEscape(mediaType)
+			if _, err := s.callHandler(s.automaticSearch, target, nil, "bookId", strconv.FormatUint(uint64(id), 10)); err != nil {
+				failures = append(failures, fmt.Errorf("book %d (%s): %w", id, mediaType, err))
+				continue
+			}
+			found = true
+		}
+		if found {
+			grabbed++
+		}
+	}
+
+	message := fmt.Sprintf("Grabbed releases for %d of %d books", grabbed, len(bookIDs))
+	if grabbed == 0 {
+		return "", fmt.Errorf("%s: %w", message, errors.Join(failures...))
+	}
+	return message, nil
+}
+
+// callHandler runs an API handler outside a request, as the API key's user. target is
+// the path and query, such as "/?mediaType=audiobook", body is sent as JSON unless
+// it's nil, and params are path parameter names and values in turn. It returns the
+// "message" of a successful response, or the "error" of a failed one.
+func (s *Server) callHandler(handler echo.HandlerFunc, target string, body any, params ...string) (string, error) {
+	var reader bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		reader.Reset(data)
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, target, &reader)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := s.echo.NewContext(req, rec)
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names, values = append(names, params[i]), append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	c.Set("userId", uint(1))
+	c.Set("isAdmin", true)
+	if err := handler(c); err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if rec.Code >= http.StatusBadRequest {
+		if response.Error == "" {
+			response.Error = http.StatusText(rec.Code)
+		}
+		return "", errors.New(response.Error)
+	}
+	return response.Message, nil
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reading goals. Users set a number of books to finish, or hours of audiobooks to
+// listen to, in a year or a month. Books count when their progress first reaches the
+// end in the period, from the reader or a Kobo; hours are what the audio player
+// reports as it moves through an audiobook.
+
+// maxListeningSpeed is the fastest playback counted as listening. Moving further
+// through an audiobook than this in the time since the last update is a seek, which
+// doesn't count.
+const maxListeningSpeed = 3
+
+// listeningSlack allows for players that report progress late
+const listeningSlack = time.Minute
+
+// ReadingGoalRequest creates a reading goal
+type ReadingGoalRequest struct {
+	Year   int    `json:"year" validate:"required,min=2000,max=2100"`
+	Month  int    `json:"month" validate:"min=0,max=12"` // 0, or left out, for the whole year
+	Unit   string `json:"unit" validate:"required,oneof=books hours"`
+	Target int    `json:"target" validate:"required,min=1"`
+}
+
+// UpdateReadingGoalRequest changes a reading goal's target
+type UpdateReadingGoalRequest struct {
+	Target int `json:"target" validate:"required,min=1"`
+}
+
+// ReadingGoalResponse is a reading goal and the progress toward it
+type ReadingGoalResponse struct {
+	ID        uint               `json:"id"`
+	Year      int                `json:"year"`
+	Month     int                `json:"month"` // 0 for the whole year
+	Unit      string             `json:"unit"`
+	Target    int                `json:"target"`
+	Start     time.Time          `json:"start"`
+	End       time.Time          `json:"end"`      // Exclusive
+	Current   float64            `json:"current"`  // Books finished, or hours listened, so far
+	Percent   float64            `json:"percent"`  // Of the target; over 100 once it's beaten
+	Expected  float64            `json:"expected"` // Where the user would be by now at a steady pace
+	OnTrack   bool               `json:"onTrack"`
+	Completed bool               `json:"completed"`
+	Books     []GoalBookResponse `json:"books,omitempty"` // Counted toward the goal, for a single goal
+}
+
+// GoalBookResponse is a book finished, or listened to, toward a goal
+type GoalBookResponse struct {
+	BookID     uint       `json:"bookId"`
+	Title      string     `json:"title"`
+	AuthorName string     `json:"authorName,omitempty"`
+	CoverURL   string     `json:"coverUrl,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Hours      float64    `json:"hours,omitempty"` // For goals in hours
+}
+
+// getReadingGoals returns the current user's goals with their progress, for ?year= or
+// every year
+func (s *Server) getReadingGoals(c echo.Context) error {
+	query := s.db.Where("user_id = ?", currentUserID(c))
+	if year := c.QueryParam("year"); year != "" {
+		query = query.Where("year = ?", year)
+	}
+
+	var goals []db.ReadingGoal
+	if err := query.Order("year DESC, month ASC, unit ASC").Find(&goals).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]ReadingGoalResponse, len(goals))
+	for i, goal := range goals {
+		responses[i] = s.readingGoalProgress(goal, time.Now())
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// getCurrentReadingGoals returns the current user's goals for this year and this month
+func (s *Server) getCurrentReadingGoals(c echo.Context) error {
+	now := time.Now()
+	var goals []db.ReadingGoal
+	err := s.db.Where("user_id = ? AND year = ? AND month IN ?", currentUserID(c), now.Year(), []int{0, int(now.Month())}).
+		Order("month ASC, unit ASC").Find(&goals).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]ReadingGoalResponse, len(goals))
+	for i, goal := range goals {
+		responses[i] = s.readingGoalProgress(goal, now)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// getReadingGoal returns one of the current user's goals, with its progress and the
+// books counted toward it
+func (s *Server) getReadingGoal(c echo.Context) error {
+	goal, ok := s.findReadingGoal(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Goal not found"})
+	}
+
+	response := s.readingGoalProgress(*goal, time.Now())
+	response.Books = s.goalBooks(*goal)
+	return c.JSON(http.StatusOK, response)
+}
+
+// createReadingGoal sets a goal for the current user
+func (s *Server) createReadingGoal(c echo.Context) error {
+	var req ReadingGoalRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	goal := db.ReadingGoal{
+		UserID: currentUserID(c),
+		Year:   req.Year,
+		Month:  req.Month,
+		Unit:   req.Unit,
+		Target: req.Target,
+	}
+	var existing db.ReadingGoal
+	if err := s.db.Where("user_id = ? AND year = ? AND month = ? AND unit = ?", goal.UserID, goal.Year, goal.Month, goal.Unit).
+		First(&existing).Error; err == nil {
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": "There's already a goal for that period", "goalId": existing.ID})
+	}
+	if err := s.db.Create(&goal).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create goal"})
+	}
+
+	return c.JSON(http.StatusCreated, s.readingGoalProgress(goal, time.Now()))
+}
+
+// updateReadingGoal changes the target of one of the current user's goals
+func (s *Server) updateReadingGoal(c echo.Context) error {
+	goal, ok := s.findReadingGoal(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Goal not found"})
+	}
+
+	var req UpdateReadingGoalRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	if err := s.db.Model(goal).Update("target", req.Target).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update goal"})
+	}
+	return c.JSON(http.StatusOK, s.readingGoalProgress(*goal, time.Now()))
+}
+
+// deleteReadingGoal removes one of the current user's goals
+func (s *Server) deleteReadingGoal(c echo.Context) error {
+	goal, ok := s.findReadingGoal(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Goal not found"})
+	}
+
+	if err := s.db.Unscoped().Delete(goal).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete goal"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// findReadingGoal loads the current user's goal named by the id path parameter
+func (s *Server) findReadingGoal(c echo.Context) (*db.ReadingGoal, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, false
+	}
+
+	var goal db.ReadingGoal
+	if err := s.db.Where("user_id = ?", currentUserID(c)).First(&goal, id).Error; err != nil {
+		return nil, false
+	}
+	return &goal, true
+}
+
+// goalPeriod returns when a goal starts and ends (exclusive), in server time
+func goalPeriod(goal db.ReadingGoal) (time.Time, time.Time) {
+	if goal.Month == 0 {
+		start := time.Date(goal.Year, time.January, 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(1, 0, 0)
+	}
+	start := time.Date(goal.Year, time.Month(goal.Month), 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// readingGoalProgress works out how far the user has got toward a goal as of now
+func (s *Server) readingGoalProgress(goal db.ReadingGoal, now time.Time) ReadingGoalResponse {
+	start, end := goalPeriod(goal)
+	response := ReadingGoalResponse{
+		ID:     goal.ID,
+		Year:   goal.Year,
+		Month:  goal.Month,
+		Unit:   goal.Unit,
+		Target: goal.Target,
+		Start:  start,
+		End:    end,
+	}
+
+	if goal.Unit == "hours" {
+		var seconds int64
+		s.db.Model(&db.ListeningTime{}).Where("user_id = ? AND date >= ? AND date < ?", goal.UserID, start, end).
+			Select("COALESCE(SUM(seconds), 0)").Scan(&seconds)
+		response.Current = math.Round(float64(seconds)/3600*10) / 10
+	} else {
+		var books int64
+		s.finishedProgress(goal.UserID, start, end).Distinct("media_files.book_id").Count(&books)
+		response.Current = float64(books)
+	}
+
+	// A steady pace through the period, all of it once it's over
+	elapsed := 1.0
+	if now.Before(end) {
+		elapsed = max(now.Sub(start).Seconds()/end.Sub(start).Seconds(), 0)
+	}
+	response.Expected = math.Round(float64(goal.Target)*elapsed*10) / 10
+	response.Percent = math.Round(response.Current/float64(goal.Target)*1000) / 10
+	response.Completed = response.Current >= float64(goal.Target)
+	response.OnTrack = response.Completed || response.Current >= response.Expected
+	return response
+}
+
+// finishedProgress selects a user's progress on media files they finished in a period
+func (s *Server) finishedProgress(userID uint, start, end time.Time) *gorm.DB {
+	return s.db.Table("read_progresses").
+		Joins("JOIN media_files ON media_files.id = read_progresses.media_file_id").
+		Where("read_progresses.user_id = ? AND read_progresses.deleted_at IS NULL", userID).
+		Where("read_progresses.finished_at >= ? AND read_progresses.finished_at < ?", start, end)
+}
+
+// goalBooks returns the books counted toward a goal: those finished in its period, or
+// for goals in hours, those listened to, most recent first
+func (s *Server) goalBooks(goal db.ReadingGoal) []GoalBookResponse {
+	start, end := goalPeriod(goal)
+
+	// Most recent first, one row per book; times are compared here as SQLite returns
+	// aggregates of them as text
+	var rows []struct {
+		BookID  uint
+		At      time.Time
+		Seconds int64
+	}
+	if goal.Unit == "hours" {
+		s.db.Model(&db.ListeningTime{}).Select("book_id, date AS at, seconds").
+			Where("user_id = ? AND date >= ? AND date < ?", goal.UserID, start, end).
+			Order("date DESC").Scan(&rows)
+	} else {
+		s.finishedProgress(goal.UserID, start, end).
+			Select("media_files.book_id AS book_id, read_progresses.finished_at AS at").
+			Order("read_progresses.finished_at DESC").Scan(&rows)
+	}
+
+	var ids []uint
+	latest := make(map[uint]time.Time)
+	seconds := make(map[uint]int64)
+	for _, row := range rows {
+		if _, ok := latest[row.BookID]; !ok {
+			ids = append(ids, row.BookID)
+			latest[row.BookID] = row.At
+		}
+		seconds[row.BookID] += row.Seconds
+	}
+	if len(ids) == 0 {
+		return []GoalBookResponse{}
+	}
+
+	var books []db.Book
+	s.db.Unscoped().Preload("Author").Where("id IN ?", ids).Find(&books)
+	byID := make(map[uint]db.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	responses := make([]GoalBookResponse, len(ids))
+	for i, id := range ids {
+		book := byID[id]
+		responses[i] = GoalBookResponse{
+			BookID:     id,
+			Title:      book.Title,
+			AuthorName: book.Author.Name,
+			CoverURL:   book.CoverURL,
+		}
+		if goal.Unit == "hours" {
+			responses[i].Hours = math.Round(float64(seconds[id])/3600*10) / 10
+		} else {
+			finishedAt := latest[id]
+			responses[i].FinishedAt = &finishedAt
+		}
+	}
+	return responses
+}
+
+// markFinished records when progress first reaches the end, for reading goals
+func markFinished(progress *db.ReadProgress, wasFinished bool) {
+	if progress.Progress >= 1 && !wasFinished {
+		now := time.Now()
+		progress.FinishedAt = &now
+	}
+}
+
+// recordListening adds time a user listened to an audiobook file today, from how far
+// its position moved since the last update. Moves too far for the time that passed
+// are seeks, and don't count.
+func (s *Server) recordListening(userID uint, file db.MediaFile, seconds int, since time.Duration) {
+	if seconds <= 0 || time.Duration(seconds)*time.Second > since*maxListeningSpeed+listeningSlack {
+		return
+	}
+
+	now := time.Now()
+	listening := db.ListeningTime{
+		UserID:      userID,
+		MediaFileID: file.ID,
+		BookID:      file.BookID,
+		Date:        time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local),
+		Seconds:     seconds,
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "media_file_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"seconds": gorm.Expr("listening_times.seconds + ?", seconds)}),
+	}).Create(&listening).Error
+	if err != nil {
+		libraryLog.Warn("Failed to record listening time", "mediaFile", file.ID, "error", err)
+	}
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// defaultRecycleBinRetentionDays is how long deleted files are kept when no retention is set
+const defaultRecycleBinRetentionDays = 7
+
+// RecycledFileResponse is a deleted media file in the recycle bin
+type RecycledFileResponse struct {
+	ID           uint   `json:"id"`
+	BookID       uint   `json:"bookId"`
+	BookTitle    string `json:"bookTitle"`
+	FileName     string `json:"fileName"`
+	OriginalPath string `json:"originalPath"`
+	FileSize     int64  `json:"fileSize"`
+	Format       string `json:"format"`
+	MediaType    string `json:"mediaType"`
+	DeletedAt    int64  `json:"deletedAt"`
+	ExpiresAt    int64  `json:"expiresAt,omitempty"` // Unset when files are kept until the bin is emptied
+}
+
+// recycleBinPath returns the recycle bin folder, or "" if deleted files are removed
+// for good. With the bin enabled but no folder set, files go to recyclebin in the
+// config folder rather than being deleted.
+func (s *Server) recycleBinPath() string {
+	var settings []db.Setting
+	s.db.Where("key IN ?", []string{"media_recycle_bin_enabled", "media_recycle_bin_path"}).Find(&settings)
+
+	enabled, path := false, ""
+	for _, setting := range settings {
+		switch setting.Key {
+		case "media_recycle_bin_enabled":
+			enabled = setting.Value == "true"
+		case "media_recycle_bin_path":
+			path = setting.Value
+		}
+	}
+	if !enabled {
+		return ""
+	}
+	if path == "" {
+		return filepath.Join(s.config.ConfigPath, "recyclebin")
+	}
+	return path
+}
+
+// recycleBinRetention returns how long deleted files are kept, or 0 to keep them until
+// the bin is emptied
+func (s *Server) recycleBinRetention() time.Duration {
+	days := defaultRecycleBinRetentionDays
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "media_recycle_bin_retention_days").First(&setting).Error; err == nil {
+		if parsed, err := strconv.Atoi(setting.Value); err == nil && parsed >= 0 {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// removeMediaFile deletes a media file from the library. With the recycle bin enabled
+// the file is moved there and can be restored; otherwise it's deleted from disk. The
+// book goes back to missing when it has no files left, and the delete event is sent.
+func (s *Server) removeMediaFile(file *db.MediaFile) error {
+	originalPath := file.FilePath
+	updates := map[string]interface{}{}
+	if bin := s.recycleBinPath(); bin != "" {
+		// Each file gets a folder of its own, so files with the same name don't collide
+		recycled := filepath.Join(bin, strconv.FormatUint(uint64(file.ID), 10), filepath.Base(file.FilePath))
+		switch err := movePath(file.FilePath, recycled); {
+		case err == nil:
+			updates["file_path"] = recycled
+			updates["recycled_from"] = file.FilePath
+		case !os.IsNotExist(err):
+			return err
+		}
+	} else if err := os.RemoveAll(file.FilePath); err != nil {
+		return err
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(file).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if err := s.db.Delete(file).Error; err != nil {
+		return err
+	}
+	s.markMissingIfNoFiles(file.BookID)
+
+	var book db.Book
+	s.db.Unscoped().Preload("Author").First(&book, file.BookID)
+	data := map[string]interface{}{
+		"title":     book.Title,
+		"author":    book.Author.Name,
+		"format":    file.Format,
+		"mediaType": string(file.MediaType),
+		"bookId":    file.BookID,
+		"path":      originalPath,
+	}
+	if recycled, ok := updates["file_path"]; ok {
+		data["recycledPath"] = recycled
+	}
+	s.notifier.SendNotification("delete", data)
+	return nil
+}
+
+// removeBookFiles deletes the media files of books, logging the ones that can't be
+func (s *Server) removeBookFiles(bookIDs []uint) {
+	var files []db.MediaFile
+	s.db.Where("book_id IN ?", bookIDs).Find(&files)
+	for i := range files {
+		if err := s.removeMediaFile(&files[i]); err != nil {
+			libraryLog.Warn("Could not delete file", "path", files[i].FilePath, "error", err)
+		}
+	}
+}
+
+// markMissingIfNoFiles reverts a downloaded book to missing once it has no media files,
+// reporting whether it did
+func (s *Server) markMissingIfNoFiles(bookID uint) bool {
+	var remaining int64
+	s.db.Model(&db.MediaFile{}).Where("book_id = ?", bookID).Count(&remaining)
+	if remaining > 0 {
+		return false
+	}
+	update := s.db.Model(&db.Book{}).Where("id = ? AND status = ?", bookID, db.StatusDownloaded).Update("status", db.StatusMissing)
+	return update.RowsAffected > 0
+}
+
+// getRecycleBin returns the files in the recycle bin, most recently deleted first
+func (s *Server) getRecycleBin(c echo.Context) error {
+	var files []db.MediaFile
+	if err := s.db.Unscoped().Where("deleted_at IS NOT NULL AND recycled_from <> ''").Order("deleted_at DESC").Find(&files).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	bookIDs := make([]uint, 0, len(files))
+	for _, file := range files {
+		bookIDs = append(bookIDs, file.BookID)
+	}
+	var books []db.Book
+	s.db.Unscoped().Select("id", "title").Where("id IN ?", bookIDs).Find(&books)
+	titles := make(map[uint]string, len(books))
+	for _, book := range books {
+		titles[book.ID] = book.Title
+	}
+
+	retention := s.recycleBinRetention()
+	responses := make([]RecycledFileResponse, len(files))
+	for i, file := range files {
+		responses[i] = RecycledFileResponse{
+			ID:           file.ID,
+			BookID:       file.BookID,
+			BookTitle:    titles[file.BookID],
+			FileName:     file.FileName,
+			OriginalPath: file.RecycledFrom,
+			FileSize:     file.FileSize,
+			Format:       file.Format,
+			MediaType:    string(file.MediaType),
+			DeletedAt:    file.DeletedAt.Time.Unix(),
+		}
+		if retention > 0 {
+			responses[i].ExpiresAt = file.DeletedAt.Time.Add(retention).Unix()
+		}
+	}
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// restoreRecycledFile moves a file from the recycle bin back to where it was and puts
+// it back in the library, restoring its book if that was deleted too
+func (s *Server) restoreRecycledFile(c echo.Context) error {
+	file, err := s.recycledFile(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "File not found in recycle bin"})
+	}
+
+	if _, err := os.Stat(file.RecycledFrom); err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "A file already exists at " + file.RecycledFrom})
+	}
+	if err := movePath(file.FilePath, file.RecycledFrom); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore file: " + err.Error()})
+	}
+	os.Remove(filepath.Dir(file.FilePath)) // The file's own folder in the bin
+
+	if err := s.db.Unscoped().Model(file).Updates(map[string]interface{}{
+		"file_path":     file.RecycledFrom,
+		"recycled_from": "",
+		"deleted_at":    nil,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore file"})
+	}
+	s.db.Unscoped().Model(&db.Book{}).Where("id = ?", file.BookID).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"status":     db.StatusDownloaded,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "File restored", "path": file.RecycledFrom})
+}
+
+// deleteRecycledFile deletes a file in the recycle bin for good
+func (s *Server) deleteRecycledFile(c echo.Context) error {
+	file, err := s.recycledFile(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "File not found in recycle bin"})
+	}
+	if err := s.purgeRecycledFile(file); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete file: " + err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// emptyRecycleBin deletes every file in the recycle bin for good
+func (s *Server) emptyRecycleBin(c echo.Context) error {
+	purged, err := s.purgeRecycleBin(time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]int{"deleted": purged})
+}
+
+// cleanupRecycleBin is the scheduled task that deletes files past the retention period
+func (s *Server) cleanupRecycleBin(ctx context.Context) error {
+	retention := s.recycleBinRetention()
+	if retention == 0 {
+		return nil
+	}
+	purged, err := s.purgeRecycleBin(time.Now().Add(-retention))
+	if purged > 0 {
+		libraryLog.Info("Recycle bin cleanup finished", "deleted", purged)
+	}
+	return err
+}
+
+// purgeRecycleBin deletes the files that went in the recycle bin before a time
+func (s *Server) purgeRecycleBin(before time.Time) (int, error) {
+	var files []db.MediaFile
+	if err := s.db.Unscoped().Where("deleted_at IS NOT NULL AND recycled_from <> '' AND deleted_at < ?", before).Find(&files).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range files {
+		if err := s.purgeRecycledFile(&files[i]); err != nil {
+			libraryLog.Warn("Recycle bin: could not delete file", "path", files[i].FilePath, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeRecycledFile deletes a recycled file from disk and its record
+func (s *Server) purgeRecycledFile(file *db.MediaFile) error {
+	if err := os.RemoveAll(file.FilePath); err != nil {
+		return err
+	}
+	os.Remove(filepath.Dir(file.FilePath))
+	return db.DeleteMediaFile(s.db, file)
+}
+
+// recycledFile loads the recycled file named by the :id parameter
+func (s *Server) recycledFile(c echo.Context) (*db.MediaFile, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	var file db.MediaFile
+	if err := s.db.Unscoped().Where("deleted_at IS NOT NULL AND recycled_from <> ''").First(&file, id).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
@@ -0,0 +1,608 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// Requests. Users who aren't admins ask for books, or an author's books, to be added to
+// the library rather than adding them, as in Overseerr; adding through the usual routes
+// makes a request for them. Admins approve or decline requests. A user's requests are
+// approved automatically while they're within their quota, if auto-approval is on for
+// everyone or for them, and an admin's always are. Approving a request adds it to the
+// library and searches for it, as adding it by hand with automatic search does.
+
+// Request statuses
+const (
+	requestPending  = "pending"
+	requestApproved = "approved"
+	requestDeclined = "declined"
+	requestFailed   = "failed"
+)
+
+const defaultRequestQuotaDays = 7
+
+// RequestSettings control when requests are approved automatically
+type RequestSettings struct {
+	AutoApprove bool `json:"autoApprove"` // Approve everyone's requests while they're within their quota
+	Quota       int  `json:"quota"`       // Requests approved automatically per user every QuotaDays; 0 for no limit
+	QuotaDays   int  `json:"quotaDays"`
+}
+
+// RequestSettingsRequest updates the request settings
+type RequestSettingsRequest struct {
+	AutoApprove *bool `json:"autoApprove,omitempty"`
+	Quota       *int  `json:"quota,omitempty" validate:"min=0"`
+	QuotaDays   *int  `json:"quotaDays,omitempty" validate:"min=1"`
+}
+
+// CreateRequestRequest asks for a book or an author's books to be added to the library
+type CreateRequestRequest struct {
+	Type      string `json:"type" validate:"required,oneof=book author"`
+	ForeignID string `json:"foreignId" validate:"required"`
+	Provider  string `json:"provider" validate:"oneof=hardcover openlibrary googlebooks"` // hardcover by default
+	MediaType string `json:"mediaType" validate:"oneof=ebook audiobook both"`             // ebook by default
+	Note      string `json:"note" validate:"max=1000"`
+}
+
+// DeclineRequestRequest declines a request, saying why
+type DeclineRequestRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+// RequestResponse is a request in the API
+type RequestResponse struct {
+	ID           uint       `json:"id"`
+	Type         string     `json:"type"`
+	Provider     string     `json:"provider"`
+	ForeignID    string     `json:"foreignId"`
+	Title        string     `json:"title"`
+	AuthorName   string     `json:"authorName,omitempty"`
+	CoverURL     string     `json:"coverUrl,omitempty"`
+	MediaType    string     `json:"mediaType"`
+	Note         string     `json:"note,omitempty"`
+	Status       string     `json:"status"`
+	Reason       string     `json:"reason,omitempty"`
+	AutoApproved bool       `json:"autoApproved"`
+	UserID       uint       `json:"userId"`
+	Username     string     `json:"username"`
+	DecidedBy    string     `json:"decidedBy,omitempty"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty"`
+	BookID       *uint      `json:"bookId,omitempty"`
+	AuthorID     *uint      `json:"authorId,omitempty"`
+	BookStatus   string     `json:"bookStatus,omitempty"` // Once added; downloaded when it's available
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// RequestQuotaResponse is how many of a user's requests will still be approved
+// automatically
+type RequestQuotaResponse struct {
+	AutoApprove bool `json:"autoApprove"` // Whether the user's requests are approved automatically
+	Limit       int  `json:"limit"`       // 0 for no limit
+	Days        int  `json:"days"`
+	Used        int  `json:"used"`
+	Remaining   int  `json:"remaining"` // -1 for no limit
+}
+
+// requestSettings returns the request settings
+func (s *Server) requestSettings() RequestSettings {
+	settings := RequestSettings{QuotaDays: defaultRequestQuotaDays}
+
+	var dbSettings []db.Setting
+	s.db.Where("key LIKE ?", "requests_%").Find(&dbSettings)
+	for _, setting := range dbSettings {
+		n, _ := strconv.Atoi(setting.Value)
+		switch setting.Key {
+		case "requests_auto_approve":
+			settings.AutoApprove = setting.Value == "true"
+		case "requests_quota":
+			settings.Quota = n
+		case "requests_quota_days":
+			if n > 0 {
+				settings.QuotaDays = n
+			}
+		}
+	}
+	return settings
+}
+
+// getRequestSettings returns the request settings
+func (s *Server) getRequestSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.requestSettings())
+}
+
+// updateRequestSettings updates the request settings
+func (s *Server) updateRequestSettings(c echo.Context) error {
+	var req RequestSettingsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	values := map[string]string{}
+	if req.AutoApprove != nil {
+		values["requests_auto_approve"] = strconv.FormatBool(*req.AutoApprove)
+	}
+	if req.Quota != nil {
+		values["requests_quota"] = strconv.Itoa(*req.Quota)
+	}
+	if req.QuotaDays != nil {
+		values["requests_quota_days"] = strconv.Itoa(*req.QuotaDays)
+	}
+	for key, value := range values {
+		setting := db.Setting{Key: key, Value: value}
+		s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+	}
+
+	return c.JSON(http.StatusOK, s.requestSettings())
+}
+
+// getRequests returns a page of requests, newest first: everyone's for admins, and
+// their own for other users. ?status= and ?userId= filter them. canApprove says
+// whether the user can approve and decline them.
+func (s *Server) getRequests(c echo.Context) error {
+	query := s.db.Model(&db.MediaRequest{})
+	if !currentUserIsAdmin(c) {
+		query = query.Where("user_id = ?", currentUserID(c))
+	} else if userID := c.QueryParam("userId"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.QueryParam("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	var requests []db.MediaRequest
+	if err := query.Preload("User").Order("created_at DESC").Limit(limit).Offset(offset).Find(&requests).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"requests":   s.toRequestResponses(requests),
+		"total":      total,
+		"canApprove": currentUserIsAdmin(c),
+	})
+}
+
+// getRequestQuota returns how many more of the current user's requests will be
+// approved automatically
+func (s *Server) getRequestQuota(c echo.Context) error {
+	var user db.User
+	if err := s.db.First(&user, currentUserID(c)).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	settings := s.requestSettings()
+	limit := requestQuota(&user, settings)
+	used := s.requestsUsed(user.ID, settings)
+	quota := RequestQuotaResponse{
+		AutoApprove: currentUserIsAdmin(c) || settings.AutoApprove || user.AutoApproveRequests,
+		Limit:       limit,
+		Days:        settings.QuotaDays,
+		Used:        used,
+		Remaining:   -1,
+	}
+	if limit > 0 {
+		quota.Remaining = max(limit-used, 0)
+	}
+	return c.JSON(http.StatusOK, quota)
+}
+
+// createRequest requests a book or an author's books
+func (s *Server) createRequest(c echo.Context) error {
+	var req CreateRequestRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	request, err := s.submitRequest(c, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, s.toRequestResponses([]db.MediaRequest{*request})[0])
+}
+
+// requestUnlessAdmin turns adding a book or author to the library into requesting it,
+// for users who aren't admins. The route's :id parameter, or hardcoverId in the body,
+// is what's requested.
+func (s *Server) requestUnlessAdmin(requestType string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if currentUserIsAdmin(c) {
+				return next(c)
+			}
+
+			var body struct {
+				HardcoverID string `json:"hardcoverId"`
+				MediaType   string `json:"mediaType"`
+			}
+			if err := c.Bind(&body); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+			}
+			req := CreateRequestRequest{
+				Type:      requestType,
+				ForeignID: body.HardcoverID,
+				Provider:  c.QueryParam("provider"),
+				MediaType: body.MediaType,
+			}
+			if id := c.Param("id"); id != "" {
+				req.ForeignID = id
+			}
+			if err := c.Validate(&req); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request: " + err.Error()})
+			}
+
+			request, err := s.submitRequest(c, req)
+			if err != nil {
+				return err
+			}
+			message := "Requested; an admin will review the request"
+			if request.Status == requestApproved {
+				message = "Request approved; adding it to the library"
+			}
+			return c.JSON(http.StatusAccepted, map[string]any{
+				"message":   message,
+				"requestId": request.ID,
+				"status":    request.Status,
+			})
+		}
+	}
+}
+
+// submitRequest records a request from the current user, approving it if their
+// requests are approved automatically. Errors are responses for the handler to return.
+func (s *Server) submitRequest(c echo.Context, req CreateRequestRequest) (*db.MediaRequest, error) {
+	if req.Provider == "" {
+		req.Provider = "hardcover"
+	}
+	if req.MediaType == "" {
+		req.MediaType = "ebook"
+	}
+	if req.Type == "author" && req.Provider != "hardcover" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, map[string]string{"error": "Authors can only be requested from Hardcover"})
+	}
+
+	var user db.User
+	if err := s.db.First(&user, currentUserID(c)).Error; err != nil {
+		return nil, echo.NewHTTPError(http.StatusForbidden, map[string]string{"error": "Only users can make requests"})
+	}
+
+	// Already in the library, or already asked for
+	if req.Type == "book" {
+		var book db.Book
+		if err := s.db.Where(providerIDColumn(req.Provider)+" = ?", req.ForeignID).First(&book).Error; err == nil {
+			return nil, echo.NewHTTPError(http.StatusConflict, map[string]any{"error": "Book already in library", "bookId": book.ID})
+		}
+	} else {
+		var author db.Author
+		if err := s.db.Where("hardcover_id = ?", req.ForeignID).First(&author).Error; err == nil {
+			return nil, echo.NewHTTPError(http.StatusConflict, map[string]any{"error": "Author already in library", "authorId": author.ID})
+		}
+	}
+	var existing db.MediaRequest
+	if err := s.db.Where("type = ? AND provider = ? AND foreign_id = ? AND status IN ?", req.Type, req.Provider, req.ForeignID,
+		[]string{requestPending, requestApproved}).First(&existing).Error; err == nil {
+		return nil, echo.NewHTTPError(http.StatusConflict, map[string]any{"error": "Already requested", "requestId": existing.ID})
+	}
+
+	request := db.MediaRequest{
+		UserID:    user.ID,
+		Type:      req.Type,
+		Provider:  req.Provider,
+		ForeignID: req.ForeignID,
+		MediaType: req.MediaType,
+		Note:      req.Note,
+		Status:    requestPending,
+	}
+	if err := s.describeRequest(c, &request); err != nil {
+		return nil, err
+	}
+
+	// Count the user's requests before this one counts against their quota
+	autoApprove := currentUserIsAdmin(c) || s.withinRequestQuota(&user)
+	if err := s.db.Create(&request).Error; err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, map[string]string{"error": "Failed to save request"})
+	}
+	setAudit(c, "request.create", "Requested "+request.Title)
+
+	if autoApprove {
+		s.grantRequest(&request, nil)
+		return &request, nil
+	}
+	s.notifier.SendNotification("request", map[string]interface{}{
+		"title":     request.Title,
+		"author":    request.AuthorName,
+		"message":   user.Username + " requested this " + request.Type + " and it's waiting for approval",
+		"requestId": request.ID,
+		"user":      user.Username,
+	})
+	return &request, nil
+}
+
+// describeRequest fills in a request's title, author and cover from its metadata provider
+func (s *Server) describeRequest(c echo.Context, request *db.MediaRequest) error {
+	ctx := c.Request().Context()
+	if request.Type == "author" {
+		client, err := s.getHardcoverClient()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]string{"error": "Hardcover API key not configured"})
+		}
+		author, err := client.GetAuthor(ctx, request.ForeignID)
+		if err != nil {
+			return echo.NewHTTPError(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch author: " + err.Error()})
+		}
+		request.Title, request.CoverURL = author.Name, author.ImageURL
+		return nil
+	}
+
+	if request.Provider != "hardcover" {
+		book, err := s.getProviderBook(ctx, request.Provider, request.ForeignID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadGateway, map[string]string{"error": "Failed to fetch book: " + err.Error()})
+		}
+		request.Title, request.AuthorName, request.CoverURL = book.Title, book.AuthorName, book.CoverURL
+		return nil
+	}
+	client, err := s.getHardcoverClient()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{"error": "Hardcover API key not configured"})
+	}
+	book, err := client.GetBook(ctx, request.ForeignID)
+	if err != nil {
+		return echo.NewHTTPError(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch book: " + err.Error()})
+	}
+	request.Title, request.AuthorName, request.CoverURL = book.Title, book.AuthorName, book.CoverURL
+	return nil
+}
+
+// withinRequestQuota reports whether a user's next request is approved automatically
+func (s *Server) withinRequestQuota(user *db.User) bool {
+	settings := s.requestSettings()
+	if !settings.AutoApprove && !user.AutoApproveRequests {
+		return false
+	}
+	limit := requestQuota(user, settings)
+	return limit == 0 || s.requestsUsed(user.ID, settings) < limit
+}
+
+// requestQuota returns how many of a user's requests are approved automatically per
+// quota period, 0 for no limit
+func requestQuota(user *db.User, settings RequestSettings) int {
+	if user.RequestQuota != nil {
+		return *user.RequestQuota
+	}
+	return settings.Quota
+}
+
+// requestsUsed counts a user's requests in the current quota period that weren't declined
+func (s *Server) requestsUsed(userID uint, settings RequestSettings) int {
+	var count int64
+	since := time.Now().AddDate(0, 0, -settings.QuotaDays)
+	s.db.Model(&db.MediaRequest{}).Where("user_id = ? AND created_at >= ? AND status != ?", userID, since, requestDeclined).Count(&count)
+	return int(count)
+}
+
+// approveRequest approves a pending request, or retries a failed one
+func (s *Server) approveRequest(c echo.Context) error {
+	var request db.MediaRequest
+	if err := s.db.First(&request, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Request not found"})
+	}
+	if request.Status != requestPending && request.Status != requestFailed {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Request is already " + request.Status})
+	}
+
+	decidedBy := currentUserID(c)
+	s.grantRequest(&request, &decidedBy)
+	setAudit(c, "request.approve", "Approved request for "+request.Title)
+
+	s.db.Preload("User").First(&request, request.ID)
+	return c.JSON(http.StatusOK, s.toRequestResponses([]db.MediaRequest{request})[0])
+}
+
+// declineRequest declines a pending request
+func (s *Server) declineRequest(c echo.Context) error {
+	var request db.MediaRequest
+	if err := s.db.First(&request, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Request not found"})
+	}
+	if request.Status != requestPending {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Request is already " + request.Status})
+	}
+
+	var req DeclineRequestRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	decidedBy := currentUserID(c)
+	if err := s.db.Model(&request).Updates(map[string]any{
+		"status":        requestDeclined,
+		"reason":        req.Reason,
+		"decided_by_id": decidedBy,
+		"decided_at":    now,
+		"auto_approved": false,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to decline request"})
+	}
+	setAudit(c, "request.decline", "Declined request for "+request.Title)
+
+	s.db.Preload("User").First(&request, request.ID)
+	return c.JSON(http.StatusOK, s.toRequestResponses([]db.MediaRequest{request})[0])
+}
+
+// deleteRequest removes a request: any for admins, and their own pending ones for
+// other users
+func (s *Server) deleteRequest(c echo.Context) error {
+	var request db.MediaRequest
+	if err := s.db.First(&request, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Request not found"})
+	}
+	if !currentUserIsAdmin(c) && (request.UserID != currentUserID(c) || request.Status != requestPending) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Only pending requests of your own can be cancelled"})
+	}
+
+	if err := s.db.Delete(&request).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete request"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// grantRequest marks a request approved, by decidedBy or automatically if that's
+// nil, and adds it to the library and searches for it in the background
+func (s *Server) grantRequest(request *db.MediaRequest, decidedBy *uint) {
+	now := time.Now()
+	request.Status = requestApproved
+	request.Reason = ""
+	request.AutoApproved = decidedBy == nil
+	request.DecidedByID = decidedBy
+	request.DecidedAt = &now
+	s.db.Model(request).Select("status", "reason", "auto_approved", "decided_by_id", "decided_at").Updates(request)
+
+	approved := *request
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.fulfilRequest(&approved)
+	}()
+}
+
+// fulfilRequest adds an approved request to the library and searches for it, recording
+// how that went. It fails only if it couldn't be added.
+func (s *Server) fulfilRequest(request *db.MediaRequest) {
+	var bookIDs []uint
+	var err error
+	if request.Type == "author" {
+		bookIDs, err = s.addRequestedAuthor(request)
+	} else {
+		bookIDs, err = s.addRequestedBook(request)
+	}
+	if err != nil {
+		libraryLog.Warn("Failed to add requested "+request.Type, "request", request.ID, "title", request.Title, "error", err)
+		s.db.Model(request).Updates(map[string]any{"status": requestFailed, "reason": "Failed to add to the library: " + err.Error()})
+		return
+	}
+
+	mediaTypes := []string{request.MediaType}
+	if request.MediaType == "both" {
+		mediaTypes = []string{"ebook", "audiobook"}
+	}
+	reason := "Added to the library"
+	if message, err := s.searchArrBooks(bookIDs, mediaTypes...); err != nil {
+		reason += "; no release grabbed yet: " + err.Error()
+	} else {
+		reason += "; " + message
+	}
+	s.db.Model(request).Update("reason", reason)
+}
+
+// addRequestedBook adds a requested book, monitored, and returns its ID to search for
+func (s *Server) addRequestedBook(request *db.MediaRequest) ([]uint, error) {
+	target := "/?provider=" + url.QueryEscape(request.Provider)
+	body := map[string]any{"monitored": true, "mediaType": request.MediaType}
+	_, addErr := s.callHandler(s.addHardcoverBook, target, body, "id", request.ForeignID)
+
+	// A book added since the request was made is used as it is
+	var book db.Book
+	if err := s.db.Where(providerIDColumn(request.Provider)+" = ?", request.ForeignID).First(&book).Error; err != nil {
+		return nil, errors.Join(addErr, err)
+	}
+	s.db.Model(request).Update("book_id", book.ID)
+	return []uint{book.ID}, nil
+}
+
+// addRequestedAuthor adds a requested author and their books, monitored, and returns
+// the IDs of their missing books to search for
+func (s *Server) addRequestedAuthor(request *db.MediaRequest) ([]uint, error) {
+	body := AddAuthorRequest{HardcoverID: request.ForeignID, Monitored: true, AddAllBooks: true}
+	_, addErr := s.callHandler(s.addAuthor, "/", body)
+
+	var author db.Author
+	if err := s.db.Where("hardcover_id = ?", request.ForeignID).First(&author).Error; err != nil {
+		return nil, errors.Join(addErr, err)
+	}
+	if !author.Monitored {
+		s.db.Model(&author).Update("monitored", true)
+	}
+	s.db.Model(request).Update("author_id", author.ID)
+	return s.missingArrBooks(s.db.Where("author_id = ?", author.ID)), nil
+}
+
+// toRequestResponses converts requests, which must have their user loaded
+func (s *Server) toRequestResponses(requests []db.MediaRequest) []RequestResponse {
+	var bookIDs, deciderIDs []uint
+	for _, request := range requests {
+		if request.BookID != nil {
+			bookIDs = append(bookIDs, *request.BookID)
+		}
+		if request.DecidedByID != nil {
+			deciderIDs = append(deciderIDs, *request.DecidedByID)
+		}
+	}
+
+	bookStatuses := make(map[uint]string)
+	if len(bookIDs) > 0 {
+		var books []db.Book
+		s.db.Select("id", "status").Where("id IN ?", bookIDs).Find(&books)
+		for _, book := range books {
+			bookStatuses[book.ID] = string(book.Status)
+		}
+	}
+	deciders := make(map[uint]string)
+	if len(deciderIDs) > 0 {
+		var users []db.User
+		s.db.Select("id", "username").Where("id IN ?", deciderIDs).Find(&users)
+		for _, user := range users {
+			deciders[user.ID] = user.Username
+		}
+	}
+
+	responses := make([]RequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = RequestResponse{
+			ID:           request.ID,
+			Type:         request.Type,
+			Provider:     request.Provider,
+			ForeignID:    request.ForeignID,
+			Title:        request.Title,
+			AuthorName:   request.AuthorName,
+			CoverURL:     request.CoverURL,
+			MediaType:    request.MediaType,
+			Note:         request.Note,
+			Status:       request.Status,
+			Reason:       request.Reason,
+			AutoApproved: request.AutoApproved,
+			UserID:       request.UserID,
+			Username:     request.User.Username,
+			DecidedAt:    request.DecidedAt,
+			BookID:       request.BookID,
+			AuthorID:     request.AuthorID,
+			CreatedAt:    request.CreatedAt,
+		}
+		if request.DecidedByID != nil {
+			responses[i].DecidedBy = deciders[*request.DecidedByID]
+		}
+		if request.BookID != nil {
+			responses[i].BookStatus = bookStatuses[*request.BookID]
+		}
+	}
+	return responses
+}
@@ -2,14 +2,15 @@ package api
 
 import (
 	"context"
-	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
 	"github.com/shelfarr/shelfarr/internal/hardcover"
 	"github.com/shelfarr/shelfarr/internal/indexer"
+	"github.com/shelfarr/shelfarr/internal/metadata"
 )
 
 // SearchResult represents a search result from Hardcover.app
@@ -23,6 +24,7 @@ type SearchResult struct {
 	ReleaseYear int     `json:"releaseYear,omitempty"`
 	ISBN        string  `json:"isbn,omitempty"`
 	Description string  `json:"description,omitempty"`
+	Provider    string  `json:"provider,omitempty"`
 	InLibrary   bool    `json:"inLibrary"`
 }
 
@@ -61,6 +63,7 @@ type UnifiedSearchResponse struct {
 	Authors []AuthorSearchResult `json:"authors,omitempty"`
 	Series  []SeriesSearchResult `json:"series,omitempty"`
 	Lists   []ListSearchResult   `json:"lists,omitempty"`
+	Errors  map[string]string    `json:"errors,omitempty"` // Searches that failed, by type
 }
 
 // IndexerSearchResult represents a search result from an indexer
@@ -69,6 +72,7 @@ type IndexerSearchResult struct {
 	Title       string `json:"title"`
 	Size        int64  `json:"size"`
 	Format      string `json:"format"`
+	Protocol    string `json:"protocol,omitempty"` // torrent or usenet
 	Seeders     int    `json:"seeders,omitempty"`
 	Leechers    int    `json:"leechers,omitempty"`
 	DownloadURL string `json:"downloadUrl"`
@@ -95,6 +99,12 @@ func (s *Server) searchHardcover(c echo.Context) error {
 		searchType = "book"
 	}
 
+	// Book searches go through the metadata provider chain, which falls back to Open
+	// Library and Google Books when Hardcover has no API key or no results
+	if searchType == "book" {
+		return s.searchBooks(c, query)
+	}
+
 	// Get API key from database first, fallback to config
 	apiKey := s.config.HardcoverAPIKey
 	var setting db.Setting
@@ -104,11 +114,14 @@ func (s *Server) searchHardcover(c echo.Context) error {
 
 	// Check if API key is configured
 	if apiKey == "" {
+		if searchType == "all" {
+			return s.searchFallbackAll(c, query, "hardcover")
+		}
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Hardcover.app API key not configured. Please add your API key in Settings > Library Search Providers."})
 	}
 
-	// Create client with API key
-	client := hardcover.NewClientWithAPIKey(s.config.HardcoverAPIURL, apiKey)
+	s.hardcover.SetAPIKey(apiKey)
+	client := s.hardcover
 
 	// Handle unified search (all types)
 	if searchType == "all" {
@@ -117,8 +130,6 @@ func (s *Server) searchHardcover(c echo.Context) error {
 
 	// Handle specific type searches
 	switch searchType {
-	case "book":
-		return s.searchHardcoverBooks(c, client, query)
 	case "author":
 		return s.searchHardcoverAuthors(c, client, query)
 	case "series":
@@ -126,23 +137,39 @@ func (s *Server) searchHardcover(c echo.Context) error {
 	case "list":
 		return s.searchHardcoverLists(c, client, query)
 	default:
-		return s.searchHardcoverBooks(c, client, query)
+		return s.searchBooks(c, query)
 	}
 }
 
-// searchHardcoverBooks searches for books
-func (s *Server) searchHardcoverBooks(c echo.Context, client *hardcover.Client, query string) error {
+// searchBooks searches for books across the enabled metadata providers
+func (s *Server) searchBooks(c echo.Context, query string) error {
 	languages := s.GetPreferredLanguages()
-	books, err := client.SearchBooks(query, languages)
+	books, err := s.metadata.SearchBooks(c.Request().Context(), query, languages)
 	if err != nil {
 		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Search failed: " + err.Error()})
 	}
 
+	return c.JSON(http.StatusOK, s.toSearchResults(books))
+}
+
+// searchFallbackAll answers a unified search with books only, from the metadata
+// provider chain, when Hardcover can't be used. exclude skips providers already tried.
+func (s *Server) searchFallbackAll(c echo.Context, query string, exclude ...string) error {
+	languages := s.GetPreferredLanguages()
+	books, err := s.metadata.SearchBooks(c.Request().Context(), query, languages, exclude...)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Search failed: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, UnifiedSearchResponse{Books: s.toSearchResults(books)})
+}
+
+// toSearchResults converts provider books to search results with library status
+func (s *Server) toSearchResults(books []metadata.Book) []SearchResult {
 	var results []SearchResult
 	for _, book := range books {
-		// Check if already in library
-		var count int64
-		s.db.Model(&db.Book{}).Where("hardcover_id = ?", book.ID).Count(&count)
+		// Check if already in library, under this or any other provider's ID
+		_, inLibrary := s.findLibraryBook(book.Provider, &book.BookData, book.Identifiers)
 
 		results = append(results, SearchResult{
 			ID:          book.ID,
@@ -154,18 +181,19 @@ func (s *Server) searchHardcoverBooks(c echo.Context, client *hardcover.Client,
 			ReleaseYear: book.ReleaseYear,
 			ISBN:        book.ISBN,
 			Description: book.Description,
-			InLibrary:   count > 0,
+			Provider:    book.Provider,
+			InLibrary:   inLibrary,
 		})
 	}
 
-	return c.JSON(http.StatusOK, results)
+	return results
 }
 
 // searchHardcoverAuthors searches for authors
 func (s *Server) searchHardcoverAuthors(c echo.Context, client *hardcover.Client, query string) error {
-	authors, err := client.SearchAuthors(query)
+	authors, err := client.SearchAuthors(c.Request().Context(), query)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Search failed: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Search failed: " + err.Error()})
 	}
 
 	var results []AuthorSearchResult
@@ -189,9 +217,9 @@ func (s *Server) searchHardcoverAuthors(c echo.Context, client *hardcover.Client
 
 // searchHardcoverSeries searches for series
 func (s *Server) searchHardcoverSeries(c echo.Context, client *hardcover.Client, query string) error {
-	seriesList, err := client.SearchSeries(query)
+	seriesList, err := client.SearchSeries(c.Request().Context(), query)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Search failed: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Search failed: " + err.Error()})
 	}
 
 	var results []SeriesSearchResult
@@ -215,9 +243,9 @@ func (s *Server) searchHardcoverSeries(c echo.Context, client *hardcover.Client,
 
 // searchHardcoverLists searches for lists
 func (s *Server) searchHardcoverLists(c echo.Context, client *hardcover.Client, query string) error {
-	lists, err := client.SearchLists(query)
+	lists, err := client.SearchLists(c.Request().Context(), query)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Search failed: " + err.Error()})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Search failed: " + err.Error()})
 	}
 
 	var results []ListSearchResult
@@ -238,12 +266,20 @@ func (s *Server) searchHardcoverLists(c echo.Context, client *hardcover.Client,
 func (s *Server) searchHardcoverAll(c echo.Context, client *hardcover.Client, query string) error {
 	// Use the client's SearchAll which handles errors properly
 	languages := s.GetPreferredLanguages()
-	results, err := client.SearchAll(query, languages)
+	results, err := client.SearchAll(c.Request().Context(), query, languages)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Search failed: " + err.Error()})
+		indexersLog.Debug("searchHardcoverAll: Hardcover search failed, falling back", "error", err)
+		return s.searchFallbackAll(c, query, "hardcover")
 	}
 
 	response := UnifiedSearchResponse{}
+	for searchType, searchErr := range results.Errors {
+		indexersLog.Warn("searchHardcoverAll: search failed", "type", searchType, "error", searchErr)
+		if response.Errors == nil {
+			response.Errors = make(map[string]string)
+		}
+		response.Errors[searchType] = searchErr.Error()
+	}
 
 	// Process books and check library status
 	for _, book := range results.Books {
@@ -264,6 +300,14 @@ func (s *Server) searchHardcoverAll(c echo.Context, client *hardcover.Client, qu
 		})
 	}
 
+	// Hardcover found no books; fill them in from the fallback providers
+	if len(response.Books) == 0 {
+		if books, err := s.metadata.SearchBooks(c.Request().Context(), query, languages, "hardcover"); err == nil {
+			response.Books = s.toSearchResults(books)
+			delete(response.Errors, "books")
+		}
+	}
+
 	// Process authors and check library status
 	for _, author := range results.Authors {
 		var count int64
@@ -322,9 +366,9 @@ func (s *Server) testHardcover(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Hardcover.app API key not configured"})
 	}
 
-	// Create client and test connection
-	client := hardcover.NewClientWithAPIKey(s.config.HardcoverAPIURL, apiKey)
-	if err := client.Test(); err != nil {
+	// Test with the shared client so the check counts against the rate limit
+	s.hardcover.SetAPIKey(apiKey)
+	if err := s.hardcover.Test(c.Request().Context()); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Connection failed: " + err.Error()})
 	}
 
@@ -337,53 +381,57 @@ func (s *Server) searchIndexers(c echo.Context) error {
 	query := c.QueryParam("q")
 	mediaType := c.QueryParam("mediaType") // "ebook" or "audiobook"
 
-	log.Printf("[DEBUG] searchIndexers called: bookId=%s, q=%s, mediaType=%s", bookID, query, mediaType)
+	indexersLog.Debug("searchIndexers called", "bookId", bookID, "query", query, "mediaType", mediaType)
 
 	if bookID == "" && query == "" {
-		log.Printf("[DEBUG] searchIndexers: missing required parameters")
+		indexersLog.Debug("searchIndexers: missing required parameters")
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Either 'bookId' or 'q' parameter is required"})
 	}
 
 	// Build search query
 	var searchQuery indexer.SearchQuery
 	searchQuery.MediaType = mediaType
+	indexers := s.db.Where("enabled = ?", true)
 
 	// If bookId provided, get book details for search
 	if bookID != "" {
 		var book db.Book
 		if err := s.db.Preload("Author").First(&book, bookID).Error; err != nil {
-			log.Printf("[DEBUG] searchIndexers: book not found with id=%s, error=%v", bookID, err)
+			indexersLog.Debug("searchIndexers: book not found", "bookId", bookID, "error", err)
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
 		}
 		searchQuery.Title = book.Title
 		searchQuery.Author = book.Author.Name
 		searchQuery.ISBN = book.ISBN
 		searchQuery.BookID = book.HardcoverID
-		log.Printf("[DEBUG] searchIndexers: searching for book '%s' by '%s' (ISBN: %s)", searchQuery.Title, searchQuery.Author, searchQuery.ISBN)
+		// Tagged indexers are only searched for books sharing one of their tags
+		indexers = indexers.Scopes(db.IndexerTags.AvailableFor(db.BookTagIDs(s.db, &book)))
+		indexersLog.Debug("searchIndexers: searching for book", "title", searchQuery.Title, "author", searchQuery.Author, "isbn", searchQuery.ISBN)
 	} else {
 		searchQuery.Title = query
-		log.Printf("[DEBUG] searchIndexers: free-text search for '%s'", query)
+		indexersLog.Debug("searchIndexers: free-text search", "query", query)
 	}
 
 	// Load enabled indexers from database
 	var dbIndexers []db.Indexer
-	if err := s.db.Where("enabled = ?", true).Order("priority ASC").Find(&dbIndexers).Error; err != nil {
-		log.Printf("[DEBUG] searchIndexers: failed to load indexers, error=%v", err)
+	if err := indexers.Order("priority ASC").Find(&dbIndexers).Error; err != nil {
+		indexersLog.Debug("searchIndexers: failed to load indexers", "error", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load indexers"})
 	}
 
-	log.Printf("[DEBUG] searchIndexers: found %d enabled indexers", len(dbIndexers))
+	indexersLog.Debug("searchIndexers: found enabled indexers", "count", len(dbIndexers))
 	for _, idx := range dbIndexers {
-		log.Printf("[DEBUG]   - Indexer: %s (type=%s, enabled=%v)", idx.Name, idx.Type, idx.Enabled)
+		indexersLog.Debug("searchIndexers: indexer", "indexer", idx.Name, "type", idx.Type, "enabled", idx.Enabled)
 	}
 
 	if len(dbIndexers) == 0 {
-		log.Printf("[DEBUG] searchIndexers: no enabled indexers configured, returning empty results")
+		indexersLog.Debug("searchIndexers: no enabled indexers configured, returning empty results")
 		return c.JSON(http.StatusOK, []IndexerSearchResult{})
 	}
 
 	// Create indexer manager and add indexers
 	manager := indexer.NewManager()
+	manager.SetHealth(s.health)
 	for _, dbIdx := range dbIndexers {
 		idx := createIndexerFromDB(dbIdx)
 		if idx != nil {
@@ -395,14 +443,14 @@ func (s *Server) searchIndexers(c echo.Context) error {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
 	defer cancel()
 
-	log.Printf("[DEBUG] searchIndexers: starting search across %d indexers", len(dbIndexers))
+	indexersLog.Debug("searchIndexers: starting search", "indexers", len(dbIndexers))
 	results, err := manager.SearchAll(ctx, searchQuery)
 	if err != nil {
-		log.Printf("[DEBUG] searchIndexers: search failed, error=%v", err)
+		indexersLog.Debug("searchIndexers: search failed", "error", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Search failed: " + err.Error()})
 	}
 
-	log.Printf("[DEBUG] searchIndexers: received %d total results from indexers", len(results))
+	indexersLog.Debug("searchIndexers: received results", "results", len(results))
 
 	// Convert to API response format
 	apiResults := make([]IndexerSearchResult, 0, len(results))
@@ -412,6 +460,7 @@ func (s *Server) searchIndexers(c echo.Context) error {
 			Title:       r.Title,
 			Size:        r.Size,
 			Format:      r.Format,
+			Protocol:    r.Protocol,
 			Seeders:     r.Seeders,
 			Leechers:    r.Leechers,
 			DownloadURL: r.DownloadURL,
@@ -436,9 +485,21 @@ func createIndexerFromDB(dbIdx db.Indexer) indexer.Indexer {
 	case "mam":
 		return indexer.NewMAMIndexer(dbIdx.Name, dbIdx.Cookie, dbIdx.VIPOnly, dbIdx.FreeleechOnly)
 	case "torznab":
-		return indexer.NewTorznabIndexer(dbIdx.Name, dbIdx.URL, dbIdx.APIKey)
+		torznab := indexer.NewTorznabIndexer(dbIdx.Name, dbIdx.URL, dbIdx.APIKey)
+		torznab.SetCategories(parseCategories(dbIdx.EbookCategories), parseCategories(dbIdx.AudiobookCategories))
+		torznab.SetBookSearch(strings.Contains(dbIdx.SearchModes, "book-search"))
+		return torznab
+	case "newznab":
+		newznab := indexer.NewNewznabIndexer(dbIdx.Name, dbIdx.URL, dbIdx.APIKey)
+		newznab.SetCategories(parseCategories(dbIdx.EbookCategories), parseCategories(dbIdx.AudiobookCategories))
+		newznab.SetBookSearch(strings.Contains(dbIdx.SearchModes, "book-search"))
+		return newznab
 	case "anna":
-		return indexer.NewAnnaIndexer(dbIdx.Name)
+		return indexer.NewAnnaIndexer(dbIdx.Name, dbIdx.URL, dbIdx.APIKey)
+	case "libgen":
+		return indexer.NewLibGenIndexer(dbIdx.Name, dbIdx.URL)
+	case "audiobookbay":
+		return indexer.NewAudioBookBayIndexer(dbIdx.Name, dbIdx.URL)
 	default:
 		return nil
 	}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"log"
 	"net/http"
 	"sort"
 	"strconv"
@@ -90,9 +89,9 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 		client, err := s.getHardcoverClient()
 		if err == nil {
 			languages := s.GetPreferredLanguages()
-			result, err := client.GetSeries(series.HardcoverID, languages)
+			result, err := client.GetSeries(c.Request().Context(), series.HardcoverID, languages)
 			if err == nil && result.Series != nil {
-				log.Printf("[DEBUG] getSeriesDetail: fetched %d books from Hardcover for series '%s' (languages: %v)", len(result.Books), series.Name, languages)
+				metadataLog.Debug("getSeriesDetail: fetched books from Hardcover", "books", len(result.Books), "series", series.Name, "languages", languages)
 
 				now := time.Now()
 				if result.Series.PrimaryBooksCount > 0 {
@@ -117,7 +116,7 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 				libraryBooksByHardcoverID := make(map[string]db.Book)
 				if len(hardcoverIDs) > 0 {
 					var libraryBooks []db.Book
-					s.db.Preload("Author").Preload("MediaFiles").
+					s.db.Scopes(s.visibleBooks(c)).Preload("Author").Preload("MediaFiles").
 						Where("hardcover_id IN ?", hardcoverIDs).
 						Find(&libraryBooks)
 
@@ -126,7 +125,7 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 							libraryBooksByHardcoverID[book.HardcoverID] = book
 						}
 					}
-					log.Printf("[DEBUG] getSeriesDetail: found %d library books matching Hardcover IDs for series '%s'", len(libraryBooks), series.Name)
+					metadataLog.Debug("getSeriesDetail: found library books matching Hardcover IDs", "books", len(libraryBooks), "series", series.Name)
 				}
 
 				for _, hcBook := range result.Books {
@@ -162,7 +161,7 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 					totalBooks++
 				}
 			} else {
-				log.Printf("[DEBUG] getSeriesDetail: failed to fetch from Hardcover: %v", err)
+				metadataLog.Debug("getSeriesDetail: failed to fetch from Hardcover", "series", series.Name, "error", err)
 			}
 		}
 	}
@@ -170,12 +169,12 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 	// Fallback to library-only view if no Hardcover data (query by series_id)
 	if len(entries) == 0 {
 		var libraryBooks []db.Book
-		s.db.Preload("Author").Preload("MediaFiles").
+		s.db.Scopes(s.visibleBooks(c)).Preload("Author").Preload("MediaFiles").
 			Where("series_id = ?", series.ID).
 			Find(&libraryBooks)
 
 		if len(libraryBooks) > 0 {
-			log.Printf("[DEBUG] getSeriesDetail: using library-only view for series '%s' (%d books)", series.Name, len(libraryBooks))
+			metadataLog.Debug("getSeriesDetail: using library-only view", "series", series.Name, "books", len(libraryBooks))
 			for _, book := range libraryBooks {
 				resp := bookToResponse(book)
 				var index float32 = 0
@@ -187,7 +186,7 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 					Book:        &resp,
 					HardcoverID: book.HardcoverID,
 					Title:       book.Title,
-					CoverURL:    book.CoverURL,
+					CoverURL:    coverImageURL(&book),
 					Rating:      book.Rating,
 					InLibrary:   true,
 				}
@@ -233,7 +232,7 @@ func (s *Server) getSeriesDetail(c echo.Context) error {
 }
 
 type AddSeriesBooksRequest struct {
-	BookIDs   []string `json:"bookIds"`
+	BookIDs   []string `json:"bookIds" validate:"required"`
 	Monitored bool     `json:"monitored"`
 }
 
@@ -251,12 +250,8 @@ func (s *Server) addSeriesBooks(c echo.Context) error {
 	}
 
 	var req AddSeriesBooksRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	}
-
-	if len(req.BookIDs) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one book ID is required"})
+	if err := bindRequest(c, &req); err != nil {
+		return err
 	}
 
 	client, err := s.getHardcoverClient()
@@ -276,9 +271,9 @@ func (s *Server) addSeriesBooks(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Series not found"})
 	}
 
-	result, err := client.GetSeries(series.HardcoverID, languages)
+	result, err := client.GetSeries(c.Request().Context(), series.HardcoverID, languages)
 	if err != nil {
-		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to fetch series from Hardcover"})
+		return c.JSON(hardcoverErrorStatus(err), map[string]string{"error": "Failed to fetch series from Hardcover: " + err.Error()})
 	}
 
 	bookDataMap := make(map[string]*hardcover.BookData)
@@ -306,7 +301,7 @@ func (s *Server) addSeriesBooks(c echo.Context) error {
 
 		bookData, exists := bookDataMap[bookID]
 		if !exists {
-			fetchedBook, fetchErr := client.GetBook(bookID)
+			fetchedBook, fetchErr := client.GetBook(c.Request().Context(), bookID)
 			if fetchErr != nil {
 				errors = append(errors, "Failed to fetch book "+bookID+": "+fetchErr.Error())
 				continue
@@ -351,6 +346,7 @@ func (s *Server) addSeriesBooks(c echo.Context) error {
 			errors = append(errors, "Failed to add book "+bookData.Title)
 			continue
 		}
+		s.covers.Queue(newBook.ID, newBook.CoverURL)
 
 		addedCount++
 	}
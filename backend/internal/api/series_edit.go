@@ -0,0 +1,191 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// SetBookSeriesRequest places a book in a series, given either an existing series's
+// ID or a name to find or create one by
+type SetBookSeriesRequest struct {
+	SeriesID    *uint    `json:"seriesId"`
+	SeriesName  string   `json:"seriesName" validate:"max=200"`
+	SeriesIndex *float32 `json:"seriesIndex" validate:"min=0"`
+}
+
+// setBookSeries assigns a book's series and its place in it by hand. The series is
+// locked so refreshes from Hardcover don't move the book back.
+func (s *Server) setBookSeries(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	var req SetBookSeriesRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	switch name := strings.TrimSpace(req.SeriesName); {
+	case req.SeriesID != nil:
+		var series db.Series
+		if err := s.db.First(&series, *req.SeriesID).Error; err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Series not found"})
+		}
+		book.SeriesID = &series.ID
+	case name != "":
+		if book.SeriesID = s.getOrCreateSeriesByName(name, book.AuthorID); book.SeriesID == nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create series"})
+		}
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "seriesId or seriesName is required"})
+	}
+	book.SeriesIndex = req.SeriesIndex
+
+	return s.saveBookSeries(c, &book)
+}
+
+// removeBookSeries takes a book out of its series, locking it out so refreshes don't
+// put it back
+func (s *Server) removeBookSeries(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid book ID"})
+	}
+
+	var book db.Book
+	if err := s.db.First(&book, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Book not found"})
+	}
+
+	book.SeriesID, book.SeriesIndex = nil, nil
+	return s.saveBookSeries(c, &book)
+}
+
+func (s *Server) saveBookSeries(c echo.Context, book *db.Book) error {
+	locked := lockedFields(book)
+	locked["series"] = true
+	setLockedFields(book, locked)
+
+	if err := s.db.Save(book).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update book"})
+	}
+
+	go s.writeBookMetadataFiles(book.ID)
+
+	s.db.Preload("Author").Preload("Series").Preload("MediaFiles").Preload("Genres").First(book, book.ID)
+	return c.JSON(http.StatusOK, bookToResponse(*book))
+}
+
+// MergeSeriesRequest names the series to merge into another
+type MergeSeriesRequest struct {
+	SeriesIDs []uint `json:"seriesIds" validate:"required"`
+}
+
+// mergeSeries merges duplicate series, such as one imported from Calibre and the same
+// series from Hardcover, into the series in the path and deletes them
+func (s *Server) mergeSeries(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid series ID"})
+	}
+
+	var target db.Series
+	if err := s.db.First(&target, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Series not found"})
+	}
+
+	var req MergeSeriesRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	var sources []db.Series
+	if err := s.db.Where("id IN ? AND id <> ?", req.SeriesIDs, target.ID).Find(&sources).Error; err != nil || len(sources) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "No series to merge"})
+	}
+
+	var moved []uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, source := range sources {
+			ids, err := mergeSeriesInto(tx, &target, &source)
+			if err != nil {
+				return err
+			}
+			moved = append(moved, ids...)
+		}
+		return tx.Save(&target).Error
+	})
+	if err != nil {
+		slog.Error("Failed to merge series", "into", target.Name, "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to merge series"})
+	}
+
+	for _, source := range sources {
+		slog.Info("Merged series", "series", source.Name, "into", target.Name)
+	}
+	for _, bookID := range moved {
+		go s.writeBookMetadataFiles(bookID)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":  "Series merged",
+		"seriesId": target.ID,
+		"merged":   len(sources),
+		"books":    len(moved),
+	})
+}
+
+// mergeSeriesInto moves source's books onto target and deletes source, returning the
+// IDs of the books moved. If both series are on Hardcover, refreshes would move the
+// books back to source's Hardcover series, so their series is locked.
+func mergeSeriesInto(tx *gorm.DB, target, source *db.Series) ([]uint, error) {
+	var books []db.Book
+	if err := tx.Where("series_id = ?", source.ID).Find(&books).Error; err != nil {
+		return nil, err
+	}
+
+	lock := source.HardcoverID != "" && target.HardcoverID != ""
+	ids := make([]uint, 0, len(books))
+	for _, book := range books {
+		book.SeriesID = &target.ID
+		if lock {
+			locked := lockedFields(&book)
+			locked["series"] = true
+			setLockedFields(&book, locked)
+		}
+		if err := tx.Save(&book).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, book.ID)
+	}
+
+	if target.HardcoverID == "" {
+		target.HardcoverID = source.HardcoverID
+		target.Slug = source.Slug
+		target.IsCompleted = source.IsCompleted
+		target.PrimaryBooksCount = source.PrimaryBooksCount
+		target.TotalBooksCount = source.TotalBooksCount
+		target.CachedAt = source.CachedAt
+	}
+	if target.Description == "" {
+		target.Description = source.Description
+	}
+	if target.AuthorID == nil {
+		target.AuthorID = source.AuthorID
+	}
+
+	// Deleted for good, so its Hardcover ID can move to target
+	return ids, tx.Unscoped().Delete(source).Error
+}
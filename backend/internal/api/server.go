@@ -1,14 +1,34 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/shelfarr/shelfarr/internal/audnexus"
 	"github.com/shelfarr/shelfarr/internal/auth"
+	"github.com/shelfarr/shelfarr/internal/cache"
 	"github.com/shelfarr/shelfarr/internal/config"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/images"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+	"github.com/shelfarr/shelfarr/internal/logging"
+	"github.com/shelfarr/shelfarr/internal/metadata"
 	"github.com/shelfarr/shelfarr/internal/realtime"
+	"github.com/shelfarr/shelfarr/internal/scheduler"
+	"github.com/shelfarr/shelfarr/internal/version"
 	"gorm.io/gorm"
 )
 
@@ -19,15 +39,46 @@ type Server struct {
 	echo        *echo.Echo
 	authService *auth.AuthService
 	wsHub       *realtime.Hub
+	events      *realtime.EventEmitter // Broadcasts to WebSocket and event stream clients
+	notifier    *NotificationService
+	jobs        *jobs.Queue
+	audnexus    *audnexus.Client
+	metadata    *metadata.Registry
+	cache       *cache.Cache
+	hardcover   *hardcover.Client // Shared so its rate limiter covers every request
+	health      *health.Tracker   // Circuit breakers for metadata providers, indexers and download clients
+	scheduler   *scheduler.Scheduler
+	covers      *images.CoverCache // Local copies of book covers
+	photos      *images.CoverCache // Local copies of author photos
+	clientTurn  atomic.Uint64      // Round-robin position for load balancing download clients
+
+	healthIssues healthIssues                        // Problems found by the last health check
+	limiter      *rateLimiter                        // API request rate limits per client
+	logins       *loginGuard                         // Lockouts after repeated failed logins
+	corsPolicy   atomic.Pointer[echo.MiddlewareFunc] // Current CORS middleware, from the settings
+	commands     arrCommands                         // Recent commands posted to the Readarr API
+
+	ctx        context.Context    // Cancelled when shutting down, for work outside requests
+	stop       context.CancelFunc // Cancels ctx
+	background sync.WaitGroup     // Work using ctx, waited for when shutting down
+	servers    []*http.Server     // Listening for requests
+	serversMu  sync.Mutex
 }
 
 // NewServer creates a new API server instance
 func NewServer(cfg *config.Config, db *gorm.DB) *Server {
+	logging.Setup(os.Stderr)
+	logConfigFile()
+
 	e := echo.New()
 	e.HideBanner = true
+	e.Validator = requestValidator{}
+	e.IPExtractor = clientIPExtractor()
 
 	// Create auth service
 	authService := auth.NewAuthService(db, cfg.JWTSecret, 7*24*time.Hour)
+	setCoverKey(cfg.JWTSecret)
+	setScriptsPath(cfg.ConfigPath)
 
 	// Ensure admin user exists
 	authService.EnsureAdminExists()
@@ -37,13 +88,9 @@ func NewServer(cfg *config.Config, db *gorm.DB) *Server {
 	go wsHub.Run()
 
 	// Global Middleware
+	e.Pre(stripURLBase)
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-	}))
 
 	s := &Server{
 		config:      cfg,
@@ -51,13 +98,69 @@ func NewServer(cfg *config.Config, db *gorm.DB) *Server {
 		echo:        e,
 		authService: authService,
 		wsHub:       wsHub,
+		events:      realtime.NewEventEmitter(wsHub),
+		notifier:    NewNotificationService(db),
+		jobs:        jobs.NewQueue(db, 2),
+		audnexus:    audnexus.NewClient(),
+		metadata:    metadata.NewRegistry(),
+		cache:       cache.New(db, loadCacheTTL(db)),
+		hardcover:   hardcover.NewClient(cfg.HardcoverAPIURL),
+		health:      health.NewTracker(health.DefaultThreshold, health.DefaultCooldown),
+		scheduler:   scheduler.NewScheduler(),
+		covers:      images.NewCoverCache(filepath.Join(cfg.ConfigPath, "covers")),
+		photos:      images.NewCoverCache(filepath.Join(cfg.ConfigPath, "covers", "authors")),
+
+		limiter: newRateLimiter(),
+		logins:  newLoginGuard(),
 	}
-
+	s.ctx, s.stop = context.WithCancel(context.Background())
+	s.health.OnChange(func(status health.Status) {
+		s.events.HealthChanged(status)
+	})
+	s.applyLogSettings()
+	s.applyURLBase()
+	s.applySecuritySettings()
+	s.applyCORSSettings()
+	e.Use(s.cors)
+	s.hardcover.SetCache(s.cache)
+	s.hardcover.SetCircuit(s.health.Circuit("hardcover"))
+	s.applyHardcoverLimits()
+
+	s.setupMetadataProviders()
+	s.setupJobQueue()
+	s.setupScheduler()
+	s.startDownloadsWatcher()
 	s.setupRoutes()
 
 	return s
 }
 
+// setupScheduler registers the scheduled tasks and starts the scheduler
+func (s *Server) setupScheduler() {
+	s.scheduler.SetupDefaultTasks(nil, nil, s.monitorDownloads, s.runLibraryScan, s.cleanupRecycleBin, func(ctx context.Context) error {
+		_, err := s.notifier.SendLibraryDigest(ctx, time.Now().AddDate(0, 0, -7))
+		return err
+	}, func(ctx context.Context) error {
+		_, err := s.notifier.NotifyNewReleases()
+		return err
+	})
+	s.scheduler.AddTask("health_check", 5*time.Minute, s.runHealthCheckTask)
+	s.scheduler.AddTask("update_check", updateCheckInterval, s.checkForUpdates)
+	s.scheduler.AddTask("db_maintenance", databaseMaintenanceInterval, s.runDatabaseMaintenance)
+	s.scheduler.SetListener(func(name string, running bool, err error) {
+		switch {
+		case running:
+			s.events.TaskUpdated(name, "running", "")
+		case err != nil:
+			s.events.TaskUpdated(name, "failed", err.Error())
+		default:
+			s.events.TaskUpdated(name, "success", "")
+		}
+	})
+	s.scheduler.Start()
+	go s.checkForUpdatesIfStale()
+}
+
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
 	// Health check (public)
@@ -66,14 +169,47 @@ func (s *Server) setupRoutes() {
 	// WebSocket endpoint (authenticated)
 	s.echo.GET("/ws", s.wsHub.WebSocketHandler)
 
+	// Cached book covers and author photos (public, so <img> tags and e-readers can load them)
+	s.echo.GET("/api/images/covers/:id", s.getCoverImage)
+	s.echo.GET("/api/images/authors/:id", s.getAuthorImage)
+
 	// Auth handlers
 	authHandlers := NewAuthHandlers(s.authService)
 
+	// OPDS catalog for e-reader apps, which use HTTP Basic auth
+	opds := s.echo.Group("/opds", s.limitRequests, s.guardLogins, auth.BasicAuthMiddleware(s.authService, "Shelfarr"))
+	opds.GET("", s.getOPDSRoot)
+	opds.GET("/opensearch.xml", s.getOPDSOpenSearch)
+	opds.GET("/new", s.getOPDSNew)
+	opds.GET("/search", s.searchOPDS)
+	opds.GET("/collections", s.getOPDSCollections)
+	opds.GET("/collections/:id", s.getOPDSCollection)
+	opds.GET("/files/:id", s.streamMediaFile)
+
+	// Kobo store sync, authenticated by the device's sync token
+	koboGroup := s.echo.Group("/kobo/:token", s.koboAuth)
+	koboGroup.GET("/v1/initialization", s.koboInitialization)
+	koboGroup.POST("/v1/auth/device", s.koboAuthDevice)
+	koboGroup.GET("/v1/library/sync", s.koboLibrarySync)
+	koboGroup.GET("/v1/library/:uuid/metadata", s.koboGetMetadata)
+	koboGroup.GET("/v1/library/:uuid/state", s.koboGetReadingState)
+	koboGroup.PUT("/v1/library/:uuid/state", s.koboUpdateReadingState)
+	koboGroup.DELETE("/v1/library/:uuid", s.koboArchive)
+	koboGroup.GET("/download/:bookId/kepub", s.koboDownload)
+	koboGroup.GET("/:uuid/:width/:height/:greyscale/image.jpg", s.koboCover)
+	koboGroup.GET("/:uuid/:width/:height/:quality/:greyscale/image.jpg", s.koboCover)
+	koboGroup.Any("/*", s.koboStoreRedirect)
+
 	// API v1 group
 	api := s.echo.Group("/api/v1")
 
-	// Public routes (no auth required)
-	api.POST("/auth/login", authHandlers.Login)
+	// Clients are rate limited, and changes made through the API are recorded in the
+	// audit log
+	api.Use(s.limitRequests)
+	api.Use(s.auditRequests)
+
+	// Public routes (no auth required); addresses that keep failing to log in are locked out
+	api.POST("/auth/login", authHandlers.Login, s.guardLogins)
 
 	// Apply authentication middleware to protected routes
 	// In development, auth can be optional. In production, enable it.
@@ -87,6 +223,13 @@ func (s *Server) setupRoutes() {
 	// JWT middleware for remaining requests
 	protected.Use(auth.JWTMiddleware(s.authService))
 
+	// Routes *arr applications call, which also take the API key (see apiKeyOr)
+	arr := api.Group("")
+	if s.config.EnableSSO {
+		arr.Use(auth.SSOMiddleware(s.authService, s.config.SSOHeaderName))
+	}
+	arr.Use(s.apiKeyOr(auth.JWTMiddleware(s.authService)))
+
 	// Auth routes (protected)
 	protected.POST("/auth/refresh", authHandlers.Refresh)
 	protected.GET("/auth/me", authHandlers.GetCurrentUser)
@@ -95,19 +238,37 @@ func (s *Server) setupRoutes() {
 	// Library endpoints
 	protected.GET("/library", s.getLibrary)
 	protected.GET("/library/stats", s.getLibraryStats)
+	protected.GET("/library/search", s.searchLibrary)
+	protected.POST("/library/scan", s.scanLibrary)
+	protected.GET("/library/rename", s.getLibraryRenames)
+	protected.POST("/library/rename", s.renameLibraryFiles)
+
+	// Recycle bin for deleted media files
+	protected.GET("/recyclebin", s.getRecycleBin)
+	protected.DELETE("/recyclebin", s.emptyRecycleBin)
+	protected.POST("/recyclebin/:id/restore", s.restoreRecycledFile)
+	protected.DELETE("/recyclebin/:id", s.deleteRecycledFile)
 
 	// Book endpoints
 	protected.GET("/books", s.getBooks)
 	protected.GET("/books/:id", s.getBook)
-	protected.POST("/books", s.addBook)
+	protected.POST("/books", s.addBook, s.requestUnlessAdmin("book"))
 	protected.PUT("/books/bulk", s.bulkUpdateBooks)    // Must be before :id routes
 	protected.DELETE("/books/bulk", s.bulkDeleteBooks) // Must be before :id routes
 	protected.PUT("/books/:id", s.updateBook)
+	protected.PUT("/books/:id/metadata", s.updateBookMetadata)
+	protected.PUT("/books/:id/series", s.setBookSeries)
+	protected.DELETE("/books/:id/series", s.removeBookSeries)
+	protected.PUT("/books/:id/tags", s.setBookTags)
 	protected.DELETE("/books/:id", s.deleteBook)
 	protected.POST("/books/:bookId/search", s.automaticSearch)
 	protected.GET("/books/:id/editions", s.getBookEditions)
 	protected.GET("/books/:id/contributors", s.getBookContributors)
 	protected.POST("/books/:id/refresh", s.refreshBookMetadata)
+	protected.POST("/books/:id/audiobook/merge", s.mergeAudiobook)
+	protected.POST("/books/:id/cover", s.uploadBookCover)
+	protected.PUT("/books/:id/cover", s.setBookCover)
+	protected.DELETE("/books/:id/cover", s.resetBookCover)
 
 	// Genre endpoints
 	protected.GET("/genres", s.getGenres)
@@ -115,14 +276,22 @@ func (s *Server) setupRoutes() {
 	// Author endpoints
 	protected.GET("/authors", s.getAuthors)
 	protected.GET("/authors/:id", s.getAuthor)
-	protected.POST("/authors", s.addAuthor)
+	protected.POST("/authors", s.addAuthor, s.requestUnlessAdmin("author"))
 	protected.PUT("/authors/:id", s.updateAuthor)
 	protected.DELETE("/authors/:id", s.deleteAuthor)
+	protected.POST("/authors/:id/image", s.uploadAuthorImage)
+	protected.DELETE("/authors/:id/image", s.resetAuthorImage)
+	protected.GET("/authors/:id/aliases", s.getAuthorAliases)
+	protected.POST("/authors/:id/aliases", s.addAuthorAlias)
+	protected.DELETE("/authors/:id/aliases/:aliasId", s.deleteAuthorAlias)
+	protected.POST("/authors/:id/merge", s.mergeAuthors)
+	protected.PUT("/authors/:id/tags", s.setAuthorTags)
 
 	// Series endpoints
 	protected.GET("/series", s.getSeries)
 	protected.GET("/series/:id", s.getSeriesDetail)
-	protected.POST("/series/:id/books", s.addSeriesBooks)
+	protected.POST("/series/:id/books", s.addSeriesBooks, auth.RequireAdmin()) // Adds many books, so can't be a request
+	protected.POST("/series/:id/merge", s.mergeSeries)
 
 	// Search endpoints
 	// Register POST route before GET to avoid path conflicts
@@ -130,11 +299,16 @@ func (s *Server) setupRoutes() {
 	protected.GET("/search/hardcover", s.searchHardcover)
 	protected.GET("/search/indexers", s.searchIndexers)
 
+	// Discover endpoints (Hardcover lists, minus books already in the library)
+	protected.GET("/discover/trending", s.getDiscoverTrending)
+	protected.GET("/discover/popular", s.getDiscoverPopular)
+	protected.GET("/discover/recent", s.getDiscoverRecent)
+
 	// Hardcover detail endpoints (for viewing before adding)
 	protected.GET("/hardcover/book/:id", s.getHardcoverBook)
 	protected.GET("/hardcover/author/:id", s.getHardcoverAuthor)
 	protected.GET("/hardcover/series/:id", s.getHardcoverSeries)
-	protected.POST("/hardcover/book/:id", s.addHardcoverBook)
+	protected.POST("/hardcover/book/:id", s.addHardcoverBook, s.requestUnlessAdmin("book"))
 
 	// Indexer endpoints
 	protected.GET("/indexers", s.getIndexers)
@@ -143,6 +317,29 @@ func (s *Server) setupRoutes() {
 	protected.DELETE("/indexers/:id", s.deleteIndexer)
 	protected.POST("/indexers/:id/test", s.testIndexer)
 
+	// Prowlarr application sync (Readarr v1 indexer API)
+	arr.GET("/indexer", s.getArrIndexers)
+	arr.GET("/indexer/schema", s.getArrIndexerSchema)
+	arr.POST("/indexer/test", s.testArrIndexer)
+	arr.POST("/indexer", s.addArrIndexer)
+	arr.GET("/indexer/:id", s.getArrIndexer)
+	arr.PUT("/indexer/:id", s.updateArrIndexer)
+	arr.DELETE("/indexer/:id", s.deleteArrIndexer)
+
+	// Readarr v1 API for dashboards and scripts written for Readarr (see readarr.go)
+	arr.GET("/author", s.getArrAuthors)
+	arr.GET("/author/:id", s.getArrAuthor)
+	arr.GET("/book", s.getArrBooks)
+	arr.PUT("/book/monitor", s.monitorArrBooks)
+	arr.GET("/book/:id", s.getArrBook)
+	arr.GET("/calendar", s.getArrCalendar)
+	arr.GET("/queue", s.getArrQueue)
+	arr.GET("/queue/status", s.getArrQueueStatus)
+	arr.DELETE("/queue/:id", s.deleteDownload)
+	arr.GET("/command", s.getArrCommands)
+	arr.POST("/command", s.startArrCommand)
+	arr.GET("/command/:id", s.getArrCommand)
+
 	// Download client endpoints
 	protected.GET("/downloadclients", s.getDownloadClients)
 	protected.POST("/downloadclients", s.addDownloadClient)
@@ -154,28 +351,106 @@ func (s *Server) setupRoutes() {
 	// Media file endpoints
 	protected.GET("/mediafiles", s.getMediaFiles)
 	protected.GET("/mediafiles/:id/stream", s.streamMediaFile)
+	protected.GET("/mediafiles/:id/epub", s.getEPUBContents)
+	protected.GET("/mediafiles/:id/epub/*", s.streamEPUBResource) // Files inside the EPUB, by path
 	protected.POST("/mediafiles/:id/kindle", s.sendToKindle)
+	protected.POST("/mediafiles/:id/send", s.sendToDevice)
+	protected.POST("/mediafiles/:id/convert", s.convertMediaFile)
+	protected.GET("/mediafiles/:id/sends", s.getSendHistory)
+	protected.GET("/mediafiles/:id/cover", s.getMediaFileCover)
 	protected.DELETE("/mediafiles/:id", s.deleteMediaFile)
 
 	// Import endpoints
 	protected.GET("/import/pending", s.getPendingImports)
+	protected.GET("/import/suggestions", s.getImportSuggestions)
+	protected.GET("/import/cover", s.getImportCover)
 	protected.POST("/import/manual", s.manualImport)
+	protected.POST("/import/trackers/:tracker", s.importTracker, auth.RequireAdmin())
+	protected.POST("/import/calibre", s.importCalibre, auth.RequireAdmin())
 
 	// Download endpoints
 	protected.GET("/downloads", s.getDownloads)
 	protected.GET("/downloads/:id", s.getDownload)
-	protected.POST("/downloads", s.triggerDownload)
+	protected.POST("/downloads", s.triggerDownload, auth.RequireAdmin()) // Others request books instead
 	protected.DELETE("/downloads/:id", s.deleteDownload)
+	protected.POST("/downloads/:id/import", s.importQueueItem)
+	protected.POST("/downloads/:id/ignore", s.ignoreQueueItem)
+
+	// Blocklist endpoints
+	protected.GET("/blocklist", s.getBlocklist)
+	protected.DELETE("/blocklist/:id", s.deleteBlocklistItem)
+
+	// Server-sent events: download progress, imports, tasks and health changes
+	protected.GET("/events", s.wsHub.EventStreamHandler)
 
 	// User endpoints (admin only for some)
 	protected.GET("/users", s.getUsers)
-	protected.POST("/users", s.createUser)
+	protected.POST("/users", s.createUser, auth.RequireAdmin())
 	protected.GET("/users/me", s.getCurrentUser)
 	protected.PUT("/users/:id", s.updateUser)
+	protected.GET("/users/:id/library", s.getUserLibrary, auth.RequireAdmin())
+	protected.PUT("/users/:id/library", s.setUserLibrary, auth.RequireAdmin())
+
+	// Requests: users ask for books and authors, admins approve them
+	protected.GET("/requests", s.getRequests)
+	protected.POST("/requests", s.createRequest)
+	protected.GET("/requests/quota", s.getRequestQuota) // Must be before :id routes
+	protected.POST("/requests/:id/approve", s.approveRequest, auth.RequireAdmin())
+	protected.POST("/requests/:id/decline", s.declineRequest, auth.RequireAdmin())
+	protected.DELETE("/requests/:id", s.deleteRequest)
+	protected.GET("/settings/requests", s.getRequestSettings)
+	protected.PUT("/settings/requests", s.updateRequestSettings, auth.RequireAdmin())
+
+	// Background jobs (conversions, merges)
+	protected.GET("/jobs", s.getJobs)
+	protected.GET("/jobs/:id", s.getJob)
+	protected.POST("/jobs/:id/cancel", s.cancelJob)
+	protected.POST("/jobs/:id/retry", s.retryJob)
+	protected.DELETE("/jobs/:id", s.deleteJob)
+
+	// E-reader devices (per user)
+	protected.GET("/devices", s.getDevices)
+	protected.POST("/devices", s.addDevice)
+	protected.PUT("/devices/:id", s.updateDevice)
+	protected.DELETE("/devices/:id", s.deleteDevice)
+	protected.POST("/devices/:id/kobo-sync", s.enableKoboSync)
+	protected.DELETE("/devices/:id/kobo-sync", s.disableKoboSync)
+
+	// Saved library filters (per user); apply with ?filterId= on /books and /library
+	protected.GET("/filters", s.getSavedFilters)
+	protected.POST("/filters", s.createSavedFilter)
+	protected.PUT("/filters/:id", s.updateSavedFilter)
+	protected.DELETE("/filters/:id", s.deleteSavedFilter)
+
+	// Collections (per user)
+	protected.GET("/collections", s.getCollections)
+	protected.POST("/collections", s.createCollection)
+	protected.GET("/collections/:id", s.getCollection)
+	protected.PUT("/collections/:id", s.updateCollection)
+	protected.DELETE("/collections/:id", s.deleteCollection)
+	protected.POST("/collections/:id/books", s.addCollectionBooks)
+	protected.PUT("/collections/:id/books", s.reorderCollectionBooks)
+	protected.DELETE("/collections/:id/books/:bookId", s.removeCollectionBook)
 
 	// Progress tracking
 	protected.GET("/progress/:mediaFileId", s.getProgress)
 	protected.PUT("/progress/:mediaFileId", s.updateProgress)
+	protected.GET("/progress/:mediaFileId/resume", s.getResume)
+
+	// Bookmarks and highlights
+	protected.GET("/annotations", s.getAnnotations)
+	protected.GET("/annotations/export", s.exportAnnotations)
+	protected.POST("/annotations", s.createAnnotation)
+	protected.PUT("/annotations/:id", s.updateAnnotation)
+	protected.DELETE("/annotations/:id", s.deleteAnnotation)
+
+	// Reading goals: books finished or hours listened in a year or month
+	protected.GET("/goals", s.getReadingGoals)
+	protected.POST("/goals", s.createReadingGoal)
+	protected.GET("/goals/current", s.getCurrentReadingGoals) // Must be before :id routes
+	protected.GET("/goals/:id", s.getReadingGoal)
+	protected.PUT("/goals/:id", s.updateReadingGoal)
+	protected.DELETE("/goals/:id", s.deleteReadingGoal)
 
 	// Settings endpoints
 	protected.GET("/settings", s.getSettings)
@@ -185,6 +460,31 @@ func (s *Server) setupRoutes() {
 	protected.GET("/settings/general", s.getGeneralSettings)
 	protected.PUT("/settings/general", s.updateGeneralSettings)
 	protected.GET("/settings/languages", s.getAvailableLanguages)
+	protected.GET("/settings/apikey", s.getAPIKey, auth.RequireAdmin())
+	protected.POST("/settings/apikey", s.regenerateAPIKey, auth.RequireAdmin())
+
+	// SMTP settings (shared by email notifications)
+	protected.GET("/settings/smtp", s.getSMTPSettings)
+	protected.PUT("/settings/smtp", s.updateSMTPSettings)
+	protected.POST("/settings/smtp/test", s.testSMTPSettings)
+
+	// Audiobookshelf connection (used by audiobook root folders)
+	protected.GET("/settings/audiobookshelf", s.getAudiobookshelfSettings)
+	protected.PUT("/settings/audiobookshelf", s.updateAudiobookshelfSettings)
+	protected.GET("/settings/audiobookshelf/libraries", s.getAudiobookshelfLibraries)
+
+	// Send to Kindle settings (per user)
+	protected.GET("/settings/kindle", s.getKindleSettings)
+	protected.PUT("/settings/kindle", s.updateKindleSettings)
+
+	// Automation settings
+	protected.GET("/settings/automation", s.getAutomationSettings)
+	protected.PUT("/settings/automation", s.updateAutomationSettings)
+	protected.GET("/settings/metadata", s.getMetadataSettings)
+	protected.PUT("/settings/metadata", s.updateMetadataSettings)
+	protected.GET("/settings/metadata/cache", s.getMetadataCacheSettings)
+	protected.PUT("/settings/metadata/cache", s.updateMetadataCacheSettings)
+	protected.DELETE("/metadata/cache", s.bustMetadataCache)
 
 	// Media management settings
 	protected.GET("/settings/media", s.getMediaSettings)
@@ -197,7 +497,9 @@ func (s *Server) setupRoutes() {
 	// Root folder endpoints
 	protected.GET("/rootfolders", s.getRootFolders)
 	protected.POST("/rootfolders", s.addRootFolder)
+	protected.PUT("/rootfolders/:id", s.updateRootFolder)
 	protected.DELETE("/rootfolders/:id", s.deleteRootFolder)
+	protected.GET("/diskspace", s.getDiskSpaces)
 
 	// Quality profile endpoints
 	protected.GET("/profiles", s.getProfiles)
@@ -205,6 +507,13 @@ func (s *Server) setupRoutes() {
 	protected.POST("/profiles", s.createProfile)
 	protected.PUT("/profiles/:id", s.updateProfile)
 	protected.DELETE("/profiles/:id", s.deleteProfile)
+	protected.PUT("/profiles/:id/tags", s.setProfileTags)
+
+	// Tag endpoints
+	protected.GET("/tags", s.getTags)
+	protected.POST("/tags", s.createTag)
+	protected.PUT("/tags/:id", s.updateTag)
+	protected.DELETE("/tags/:id", s.deleteTag)
 
 	// Activity/History endpoint
 	protected.GET("/activity", s.getActivity)
@@ -216,34 +525,113 @@ func (s *Server) setupRoutes() {
 	protected.GET("/wanted/cutoff", s.getWantedCutoff)
 
 	// System endpoints
-	protected.GET("/system/status", s.getSystemStatus)
+	protected.GET("/health", s.getProviderHealth)
+	arr.GET("/system/status", s.getSystemStatus) // Prowlarr checks the version here
 	protected.GET("/system/tasks", s.getSystemTasks)
+	protected.GET("/config", s.getConfig, auth.RequireAdmin())
 	protected.POST("/system/tasks/:name/run", s.runSystemTask)
-	protected.GET("/system/logs", s.getSystemLogs)
 	protected.POST("/system/backup", s.createBackup)
 	protected.POST("/system/refresh-metadata", s.refreshAllMetadata)
 
+	// Logs
+	protected.GET("/log", s.getLogs)
+	protected.DELETE("/log", s.clearLogs)
+	protected.GET("/audit", s.getAuditLog, auth.RequireAdmin())
+
 	// Notification endpoints
-	protected.GET("/notifications", s.getNotifications)
-	protected.POST("/notifications", s.addNotification)
-	protected.PUT("/notifications/:id", s.updateNotification)
-	protected.DELETE("/notifications/:id", s.deleteNotification)
-	protected.POST("/notifications/:id/test", s.testNotification)
+	// Admins only: custom scripts run as the server, and connections hold tokens
+	protected.GET("/notifications", s.getNotifications, auth.RequireAdmin())
+	protected.POST("/notifications", s.addNotification, auth.RequireAdmin())
+	protected.PUT("/notifications/:id", s.updateNotification, auth.RequireAdmin())
+	protected.DELETE("/notifications/:id", s.deleteNotification, auth.RequireAdmin())
+	protected.POST("/notifications/:id/test", s.testNotification, auth.RequireAdmin())
+	protected.POST("/notifications/digest", s.sendLibraryDigest, auth.RequireAdmin())
+
+	// Media servers (library refresh on import)
+	protected.GET("/mediaservers", s.getMediaServers)
+	protected.POST("/mediaservers", s.addMediaServer)
+	protected.PUT("/mediaservers/:id", s.updateMediaServer)
+	protected.DELETE("/mediaservers/:id", s.deleteMediaServer)
+	protected.POST("/mediaservers/:id/test", s.testMediaServer)
 
 	// Hardcover List endpoints
 	protected.GET("/lists", s.getLists)
-	protected.POST("/lists", s.addList)
-	protected.PUT("/lists/:id", s.updateList)
-	protected.DELETE("/lists/:id", s.deleteList)
-	protected.POST("/lists/:id/sync", s.syncList)
-
-	// Serve static frontend files in production
-	s.echo.Static("/", "public")
+	// Admins only, as syncing adds the lists' books to the library
+	protected.POST("/lists", s.addList, auth.RequireAdmin())
+	protected.PUT("/lists/:id", s.updateList, auth.RequireAdmin())
+	protected.DELETE("/lists/:id", s.deleteList, auth.RequireAdmin())
+	protected.POST("/lists/:id/sync", s.syncList, auth.RequireAdmin())
+
+	// Serve the frontend in production
+	s.echo.GET("/*", s.serveFrontend)
 }
 
-// Start begins listening for requests
+// Start begins listening for requests, and serves them until the server fails or
+// SIGTERM or SIGINT arrives, when it shuts down gracefully (see Shutdown) and returns
+// nil. A second signal stops it straight away.
 func (s *Server) Start() error {
-	return s.echo.Start(s.config.ListenAddr)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- s.serve()
+	}()
+
+	select {
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("Shutting down", "timeout", shutdownTimeout())
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// serve listens for requests until the servers are shut down. With HTTPS turned on,
+// the HTTPS address is served too, and plain HTTP either redirects to it or serves as
+// before.
+func (s *Server) serve() error {
+	settings := s.tlsSettings()
+	if !settings.Enabled {
+		return s.echo.StartServer(s.addServer(&http.Server{Addr: s.config.ListenAddr}))
+	}
+
+	cert, err := s.loadCertificate(settings)
+	if err != nil {
+		// Rather than locking everyone out, carry on so the settings can be fixed
+		slog.Error("HTTPS is turned on but there's no usable certificate; serving HTTP only", "error", err)
+		return s.echo.StartServer(s.addServer(&http.Server{Addr: s.config.ListenAddr}))
+	}
+
+	var handler http.Handler = s.echo
+	if settings.RedirectHTTP {
+		handler = s.redirectToHTTPS(settings.ListenAddr)
+	}
+	plain := s.addServer(&http.Server{Addr: s.config.ListenAddr, Handler: handler})
+	go func() {
+		if err := plain.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server stopped", "addr", s.config.ListenAddr, "error", err)
+		}
+	}()
+
+	slog.Info("Serving HTTPS", "addr", settings.ListenAddr)
+	return s.echo.StartServer(s.addServer(&http.Server{
+		Addr:      settings.ListenAddr,
+		Handler:   s.echo,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}))
+}
+
+// addServer records a server so Shutdown stops it
+func (s *Server) addServer(server *http.Server) *http.Server {
+	s.serversMu.Lock()
+	defer s.serversMu.Unlock()
+	s.servers = append(s.servers, server)
+	return server
 }
 
 // GetWSHub returns the WebSocket hub for external use
@@ -255,6 +643,6 @@ func (s *Server) GetWSHub() *realtime.Hub {
 func (s *Server) healthCheck(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{
 		"status":  "healthy",
-		"version": "1.0.0",
+		"version": version.Version,
 	})
 }
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// defaultShutdownTimeout is how long shutting down waits for work in progress. Docker
+// waits 10 seconds before killing a container unless it's given a longer
+// stop_grace_period, as in the example compose file.
+const defaultShutdownTimeout = 25 * time.Second
+
+// shutdownTimeout returns how long shutting down waits for work in progress:
+// SHELFARR_SHUTDOWN_TIMEOUT, such as "60s", or 25 seconds
+func shutdownTimeout() time.Duration {
+	if value := os.Getenv("SHELFARR_SHUTDOWN_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("Ignoring invalid SHELFARR_SHUTDOWN_TIMEOUT; use a duration such as 60s", "value", value)
+	}
+	return defaultShutdownTimeout
+}
+
+// Shutdown stops the server gracefully. It stops accepting requests, starting
+// scheduled tasks and starting jobs, then waits until ctx ends for requests, tasks
+// (such as importing finished downloads), jobs (such as conversions) and the downloads
+// folder watcher to finish. Whatever's still running then is cancelled; interrupted
+// jobs are requeued for the next start. Finally the database is closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	// Event streams and WebSockets stay open until they're told to go
+	s.wsHub.CloseAll()
+
+	s.serversMu.Lock()
+	servers := s.servers
+	s.serversMu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(servers)+2)
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = server.Shutdown(ctx)
+		}()
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		errs[len(servers)] = s.scheduler.Shutdown(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		errs[len(servers)+1] = s.jobs.Shutdown(ctx)
+	}()
+	wg.Wait()
+
+	s.stop()
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		slog.Warn("Work was still in progress at the shutdown timeout and was cancelled", "error", err)
+	}
+	if closeErr := s.closeDatabase(); closeErr != nil {
+		slog.Error("Failed to close the database", "error", closeErr)
+		err = errors.Join(err, closeErr)
+	}
+	slog.Info("Shut down")
+	return err
+}
+
+// closeDatabase closes the database connections, first folding SQLite's write-ahead
+// log back into the database file so it's complete on its own
+func (s *Server) closeDatabase() error {
+	if db.IsSQLite(s.db) {
+		if err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+			slog.Warn("Failed to checkpoint the database", "error", err)
+		}
+	}
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
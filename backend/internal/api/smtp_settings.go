@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/notifier"
+	"gorm.io/gorm"
+)
+
+// SMTPSettingsResponse represents the outgoing mail server settings
+type SMTPSettingsResponse struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"` // Masked when set
+	From     string `json:"from"`
+	UseTLS   bool   `json:"useTls"` // Implicit TLS; STARTTLS is negotiated automatically otherwise
+}
+
+// SMTPSettingsRequest represents the request body for updating SMTP settings
+type SMTPSettingsRequest struct {
+	Host     *string `json:"host,omitempty"`
+	Port     *int    `json:"port,omitempty" validate:"min=1,max=65535"`
+	Username *string `json:"username,omitempty"`
+	Password *string `json:"password,omitempty"` // The stored password is kept if this is the mask; "" clears it
+	From     *string `json:"from,omitempty" validate:"email"`
+	UseTLS   *bool   `json:"useTls,omitempty"`
+}
+
+// getSMTPSettings returns the current SMTP settings
+func (s *Server) getSMTPSettings(c echo.Context) error {
+	cfg := loadSMTPConfig(s.db)
+	return c.JSON(http.StatusOK, SMTPSettingsResponse{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: masked(cfg.Password),
+		From:     cfg.From,
+		UseTLS:   cfg.UseTLS,
+	})
+}
+
+// updateSMTPSettings updates SMTP settings
+func (s *Server) updateSMTPSettings(c echo.Context) error {
+	var req SMTPSettingsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	updates := map[string]*string{
+		"smtp_host":     req.Host,
+		"smtp_username": req.Username,
+		"smtp_password": req.Password,
+		"smtp_from":     req.From,
+	}
+	if req.Password != nil && *req.Password == secretMask {
+		// What getSMTPSettings sent, sent back unchanged
+		updates["smtp_password"] = nil
+	}
+	if req.Port != nil {
+		port := strconv.Itoa(*req.Port)
+		updates["smtp_port"] = &port
+	}
+	if req.UseTLS != nil {
+		useTLS := "false"
+		if *req.UseTLS {
+			useTLS = "true"
+		}
+		updates["smtp_use_tls"] = &useTLS
+	}
+
+	for key, valuePtr := range updates {
+		if valuePtr != nil {
+			setting := db.Setting{Key: key, Value: *valuePtr}
+			s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Settings updated"})
+}
+
+// testSMTPSettings sends a test email to the given address using the saved settings
+func (s *Server) testSMTPSettings(c echo.Context) error {
+	var req struct {
+		To string `json:"to" validate:"required,email"`
+	}
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if err := notifier.NewEmailNotifier(loadSMTPConfig(s.db), req.To).Test(ctx); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Test email sent successfully"})
+}
+
+// loadSMTPConfig reads the SMTP settings from the database
+func loadSMTPConfig(gdb *gorm.DB) notifier.SMTPConfig {
+	cfg := notifier.SMTPConfig{Port: 587}
+
+	var settings []db.Setting
+	gdb.Where("key LIKE ?", "smtp_%").Find(&settings)
+
+	for _, setting := range settings {
+		switch setting.Key {
+		case "smtp_host":
+			cfg.Host = strings.TrimSpace(setting.Value)
+		case "smtp_port":
+			if port, err := strconv.Atoi(setting.Value); err == nil {
+				cfg.Port = port
+			}
+		case "smtp_username":
+			cfg.Username = setting.Value
+		case "smtp_password":
+			cfg.Password = setting.Value
+		case "smtp_from":
+			cfg.From = setting.Value
+		case "smtp_use_tls":
+			cfg.UseTLS = setting.Value == "true"
+		}
+	}
+
+	return cfg
+}
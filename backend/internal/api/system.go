@@ -8,28 +8,56 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/version"
 )
 
 // SystemStatus represents the overall system status
 type SystemStatus struct {
-	Version   string       `json:"version"`
-	StartTime time.Time    `json:"startTime"`
-	Uptime    string       `json:"uptime"`
-	OS        string       `json:"os"`
-	Arch      string       `json:"arch"`
-	GoVersion string       `json:"goVersion"`
-	Database  DBStatus     `json:"database"`
-	Disk      DiskStatus   `json:"disk"`
-	Clients   ClientStatus `json:"clients"`
-	Library   LibStatus    `json:"library"`
+	Version   string        `json:"version"`
+	Build     version.Info  `json:"build"`
+	StartTime time.Time     `json:"startTime"`
+	Uptime    string        `json:"uptime"`
+	OS        string        `json:"os"`
+	Arch      string        `json:"arch"`
+	GoVersion string        `json:"goVersion"`
+	Timezone  string        `json:"timezone"`
+	Update    UpdateStatus  `json:"update"`
+	Runtime   RuntimeStatus `json:"runtime"`
+	Database  DBStatus      `json:"database"`
+	Paths     PathsStatus   `json:"paths"`
+	Disk      DiskStatus    `json:"disk"`
+	Clients   ClientStatus  `json:"clients"`
+	Library   LibStatus     `json:"library"`
+}
+
+// RuntimeStatus represents the Go runtime's state
+type RuntimeStatus struct {
+	NumCPU      int    `json:"numCpu"`
+	GOMAXPROCS  int    `json:"gomaxprocs"`
+	Goroutines  int    `json:"goroutines"`
+	MemoryAlloc uint64 `json:"memoryAlloc"` // Bytes of live heap objects
+	MemorySys   uint64 `json:"memorySys"`   // Bytes obtained from the OS
+	NumGC       uint32 `json:"numGc"`
 }
 
 // DBStatus represents database status
 type DBStatus struct {
-	Type   string `json:"type"`
-	Path   string `json:"path"`
-	Size   int64  `json:"size"`
-	Status string `json:"status"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Schema  uint   `json:"schemaVersion"` // Last migration applied
+	Status  string `json:"status"`
+}
+
+// PathsStatus lists the folders Shelfarr was configured with
+type PathsStatus struct {
+	Config     string `json:"config"`
+	Database   string `json:"database"`
+	Books      string `json:"books"`
+	Audiobooks string `json:"audiobooks"`
+	Downloads  string `json:"downloads"`
 }
 
 // DiskStatus represents disk usage
@@ -75,27 +103,66 @@ type TaskInfo struct {
 	LastStatus string    `json:"lastStatus"`
 }
 
+// ProviderHealth reports the circuit breaker state of every external provider and the
+// problems found by the health checks
+type ProviderHealth struct {
+	Status    string          `json:"status"` // healthy; degraded when a circuit is open or a check warns; unhealthy when a check errors
+	Providers []health.Status `json:"providers"`
+	Checks    []HealthCheck   `json:"checks"`
+}
+
 var serverStartTime = time.Now()
 
 // getSystemStatus returns system status information
 func (s *Server) getSystemStatus(c echo.Context) error {
 	status := SystemStatus{
-		Version:   "1.0.0",
+		Version:   version.Version,
+		Build:     version.Get(),
 		StartTime: serverStartTime,
 		Uptime:    time.Since(serverStartTime).Round(time.Second).String(),
 		OS:        runtime.GOOS,
 		Arch:      runtime.GOARCH,
 		GoVersion: runtime.Version(),
+		Timezone:  time.Local.String(),
+		Update:    s.updateStatus(),
+	}
+
+	// Runtime status
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	status.Runtime = RuntimeStatus{
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		Goroutines:  runtime.NumGoroutine(),
+		MemoryAlloc: mem.Alloc,
+		MemorySys:   mem.Sys,
+		NumGC:       mem.NumGC,
 	}
 
 	// Database status
 	status.Database = DBStatus{
-		Type:   "sqlite",
-		Path:   s.config.DatabasePath,
+		Type:   s.db.Dialector.Name(),
 		Status: "connected",
 	}
-	if info, err := os.Stat(s.config.DatabasePath); err == nil {
-		status.Database.Size = info.Size()
+	versionQuery := "SHOW server_version"
+	if db.IsSQLite(s.db) {
+		versionQuery = "SELECT sqlite_version()"
+		status.Database.Path = s.config.DatabasePath
+		if info, err := os.Stat(s.config.DatabasePath); err == nil {
+			status.Database.Size = info.Size()
+		}
+	}
+	if err := s.db.Raw(versionQuery).Scan(&status.Database.Version).Error; err != nil {
+		status.Database.Status = "error: " + err.Error()
+	}
+	status.Database.Schema, _ = db.SchemaVersion(s.db)
+
+	status.Paths = PathsStatus{
+		Config:     s.config.ConfigPath,
+		Database:   s.config.DatabasePath,
+		Books:      s.config.BooksPath,
+		Audiobooks: s.config.AudiobooksPath,
+		Downloads:  s.config.DownloadsPath,
 	}
 
 	// Disk status
@@ -170,10 +237,28 @@ func (s *Server) getSystemTasks(c echo.Context) error {
 		},
 		{
 			Name:       "RecycleBinCleanup",
-			Interval:   "168h",
+			Interval:   "24h",
+			Enabled:    true,
+			LastStatus: "success",
+		},
+		{
+			Name:       "HealthCheck",
+			Interval:   "5m",
 			Enabled:    true,
 			LastStatus: "success",
 		},
+		{
+			Name:       "UpdateCheck",
+			Interval:   "12h",
+			Enabled:    s.updateChecksEnabled(),
+			LastStatus: "success",
+		},
+		{
+			Name:       "DatabaseMaintenance",
+			Interval:   "7d",
+			Enabled:    db.IsSQLite(s.db),
+			LastStatus: "success",
+		},
 	}
 
 	return c.JSON(http.StatusOK, tasks)
@@ -185,46 +270,38 @@ func (s *Server) runSystemTask(c echo.Context) error {
 
 	// In a full implementation, this would call the scheduler
 	validTasks := map[string]bool{
-		"MetadataSync":      true,
-		"ListSync":          true,
-		"DownloadSync":      true,
-		"LibraryScan":       true,
-		"RecycleBinCleanup": true,
+		"MetadataSync":        true,
+		"ListSync":            true,
+		"DownloadSync":        true,
+		"LibraryScan":         true,
+		"RecycleBinCleanup":   true,
+		"HealthCheck":         true,
+		"UpdateCheck":         true,
+		"DatabaseMaintenance": true,
 	}
 
 	if !validTasks[taskName] {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Task not found"})
 	}
 
+	// Tasks the scheduler runs
+	scheduled := map[string]string{
+		"DownloadSync":        "download_sync",
+		"LibraryScan":         "library_scan",
+		"RecycleBinCleanup":   "recycle_cleanup",
+		"HealthCheck":         "health_check",
+		"UpdateCheck":         "update_check",
+		"DatabaseMaintenance": "db_maintenance",
+	}
+	if name, ok := scheduled[taskName]; ok {
+		go s.scheduler.RunNow(name)
+	}
+
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "Task " + taskName + " started",
 	})
 }
 
-// getSystemLogs returns recent system logs
-func (s *Server) getSystemLogs(c echo.Context) error {
-	// In production, this would read from a log file or logging system
-	logs := []map[string]interface{}{
-		{
-			"timestamp": time.Now().Add(-5 * time.Minute),
-			"level":     "info",
-			"message":   "Download completed: Example Book",
-		},
-		{
-			"timestamp": time.Now().Add(-10 * time.Minute),
-			"level":     "info",
-			"message":   "Library scan completed: 0 new files",
-		},
-		{
-			"timestamp": time.Now().Add(-1 * time.Hour),
-			"level":     "info",
-			"message":   "Server started",
-		},
-	}
-
-	return c.JSON(http.StatusOK, logs)
-}
-
 // createBackup creates a database backup
 func (s *Server) createBackup(c echo.Context) error {
 	// In production, this would create an actual backup
@@ -313,12 +390,13 @@ func (s *Server) refreshAllMetadata(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create Hardcover client"})
 	}
+	client = client.BypassCache() // A refresh must see current data
 
 	var refreshed, failed int
 	var errors []string
 
 	for _, book := range books {
-		bookData, err := client.GetBook(book.HardcoverID)
+		bookData, err := client.GetBook(c.Request().Context(), book.HardcoverID)
 		if err != nil {
 			failed++
 			errors = append(errors, book.Title+": "+err.Error())
@@ -335,6 +413,8 @@ func (s *Server) refreshAllMetadata(c echo.Context) error {
 		s.syncGenres(&book, bookData.Genres)
 		s.syncEditions(&book, bookData)
 		s.syncContributors(&book, bookData)
+		s.syncIdentifiers(&book, db.BookIdentifiers{})
+		go s.writeBookMetadataFiles(book.ID)
 		refreshed++
 
 		time.Sleep(100 * time.Millisecond)
@@ -347,3 +427,32 @@ func (s *Server) refreshAllMetadata(c echo.Context) error {
 		"errors":    errors,
 	})
 }
+
+// getProviderHealth returns the health of metadata providers and indexers, running the
+// health checks
+func (s *Server) getProviderHealth(c echo.Context) error {
+	response := ProviderHealth{
+		Status:    "healthy",
+		Providers: s.health.Status(),
+		Checks:    s.runHealthChecks(),
+	}
+	for _, provider := range response.Providers {
+		if provider.State != health.StateClosed {
+			response.Status = "degraded"
+		}
+	}
+	for _, check := range response.Checks {
+		switch check.Severity {
+		case SeverityError:
+			response.Status = "unhealthy"
+		case SeverityWarning:
+			if response.Status == "healthy" {
+				response.Status = "degraded"
+			}
+		}
+	}
+	if response.Checks == nil {
+		response.Checks = []HealthCheck{}
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// TagResponse represents a tag and what uses it
+type TagResponse struct {
+	ID                uint   `json:"id"`
+	Name              string `json:"name"`
+	BookCount         int    `json:"bookCount"`
+	AuthorCount       int    `json:"authorCount"`
+	IndexerIDs        []uint `json:"indexerIds"`
+	QualityProfileIDs []uint `json:"qualityProfileIds"`
+}
+
+// TagRequest creates or renames a tag
+type TagRequest struct {
+	Name string `json:"name" validate:"max=50"`
+}
+
+// SetTagsRequest replaces an item's tags
+type SetTagsRequest struct {
+	Tags []uint `json:"tags"`
+}
+
+// parseTagIDs reads a comma-separated list of tag IDs from a query parameter
+func parseTagIDs(param string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(param, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// validTagIDs checks every tag ID names an existing tag
+func (s *Server) validTagIDs(ids []uint) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	var count int64
+	s.db.Model(&db.Tag{}).Where("id IN ?", ids).Count(&count)
+	return int(count) == len(uniqueIDs(ids))
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool)
+	var unique []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// getTags returns all tags with what they're on
+func (s *Server) getTags(c echo.Context) error {
+	var tags []db.Tag
+	if err := s.db.Order("name").Find(&tags).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	responses := make([]TagResponse, len(tags))
+	for i, tag := range tags {
+		responses[i] = s.tagToResponse(tag)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+func (s *Server) tagToResponse(tag db.Tag) TagResponse {
+	response := TagResponse{ID: tag.ID, Name: tag.Name, IndexerIDs: []uint{}, QualityProfileIDs: []uint{}}
+
+	var count int64
+	s.db.Model(&db.Book{}).Scopes(db.BookTags.Tagged([]uint{tag.ID})).Count(&count)
+	response.BookCount = int(count)
+	s.db.Model(&db.Author{}).Scopes(db.AuthorTags.Tagged([]uint{tag.ID})).Count(&count)
+	response.AuthorCount = int(count)
+
+	s.db.Model(&db.Indexer{}).Scopes(db.IndexerTags.Tagged([]uint{tag.ID})).Pluck("id", &response.IndexerIDs)
+	s.db.Model(&db.QualityProfile{}).Scopes(db.QualityProfileTags.Tagged([]uint{tag.ID})).Pluck("id", &response.QualityProfileIDs)
+	return response
+}
+
+// createTag adds a tag
+func (s *Server) createTag(c echo.Context) error {
+	var req TagRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	var existing db.Tag
+	if err := s.db.Where("LOWER(name) = LOWER(?)", name).First(&existing).Error; err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Tag already exists"})
+	}
+
+	tag := db.Tag{Name: name}
+	if err := s.db.Create(&tag).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create tag"})
+	}
+	return c.JSON(http.StatusCreated, s.tagToResponse(tag))
+}
+
+// updateTag renames a tag
+func (s *Server) updateTag(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tag ID"})
+	}
+
+	var tag db.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Tag not found"})
+	}
+
+	var req TagRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	var existing db.Tag
+	if err := s.db.Where("LOWER(name) = LOWER(?) AND id <> ?", name, tag.ID).First(&existing).Error; err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Tag already exists"})
+	}
+
+	tag.Name = name
+	if err := s.db.Save(&tag).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update tag"})
+	}
+	return c.JSON(http.StatusOK, s.tagToResponse(tag))
+}
+
+// deleteTag removes a tag from everything it's on
+func (s *Server) deleteTag(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tag ID"})
+	}
+
+	var tag db.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Tag not found"})
+	}
+
+	if err := db.DeleteTag(s.db, tag.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete tag"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// setBookTags replaces a book's tags
+func (s *Server) setBookTags(c echo.Context) error {
+	return s.setTags(c, &db.Book{}, db.BookTags, "Book")
+}
+
+// setAuthorTags replaces an author's tags, which their books share
+func (s *Server) setAuthorTags(c echo.Context) error {
+	return s.setTags(c, &db.Author{}, db.AuthorTags, "Author")
+}
+
+// setProfileTags replaces a quality profile's tags. A tagged profile is used for books
+// sharing one of its tags, ahead of the default profile.
+func (s *Server) setProfileTags(c echo.Context) error {
+	return s.setTags(c, &db.QualityProfile{}, db.QualityProfileTags, "Profile")
+}
+
+func (s *Server) setTags(c echo.Context, model any, links db.TagLinks, kind string) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid " + strings.ToLower(kind) + " ID"})
+	}
+	if err := s.db.First(model, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": kind + " not found"})
+	}
+
+	var req SetTagsRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+	if !s.validTagIDs(req.Tags) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown tag"})
+	}
+
+	if err := links.Set(s.db, uint(id), uniqueIDs(req.Tags)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update tags"})
+	}
+	return c.JSON(http.StatusOK, SetTagsRequest{Tags: tagIDsOrEmpty(links.IDs(s.db, uint(id))[uint(id)])})
+}
+
+// tagIDsOrEmpty keeps untagged items' tags as [] rather than null in responses
+func tagIDsOrEmpty(ids []uint) []uint {
+	if ids == nil {
+		return []uint{}
+	}
+	return ids
+}
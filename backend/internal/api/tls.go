@@ -0,0 +1,150 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// defaultHTTPSListenAddr is where HTTPS is served unless set in the general settings
+const defaultHTTPSListenAddr = ":8443"
+
+// TLSSettings are the HTTPS options in the general settings. They take effect when
+// Shelfarr is restarted.
+type TLSSettings struct {
+	Enabled      bool   `json:"httpsEnabled"`
+	ListenAddr   string `json:"httpsListenAddr"` // Such as ":8443"
+	CertPath     string `json:"httpsCertPath"`   // PEM certificate; empty for a self-signed one
+	KeyPath      string `json:"httpsKeyPath"`    // PEM private key for CertPath
+	RedirectHTTP bool   `json:"httpsRedirect"`   // Redirect plain HTTP requests to HTTPS
+}
+
+// tlsSettings returns the HTTPS options stored in the general settings
+func (s *Server) tlsSettings() TLSSettings {
+	settings := TLSSettings{ListenAddr: defaultHTTPSListenAddr}
+
+	var dbSettings []db.Setting
+	s.db.Where("key LIKE ?", "general_https_%").Find(&dbSettings)
+	for _, setting := range dbSettings {
+		switch setting.Key {
+		case "general_https_enabled":
+			settings.Enabled = setting.Value == "true"
+		case "general_https_listen_addr":
+			if setting.Value != "" {
+				settings.ListenAddr = setting.Value
+			}
+		case "general_https_cert_path":
+			settings.CertPath = setting.Value
+		case "general_https_key_path":
+			settings.KeyPath = setting.Value
+		case "general_https_redirect":
+			settings.RedirectHTTP = setting.Value == "true"
+		}
+	}
+	return settings
+}
+
+// loadCertificate loads the configured certificate, or a self-signed one kept in the
+// config folder if none is configured
+func (s *Server) loadCertificate(settings TLSSettings) (tls.Certificate, error) {
+	if settings.CertPath != "" || settings.KeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertPath, settings.KeyPath)
+		if err != nil {
+			return cert, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		return cert, nil
+	}
+
+	dir := filepath.Join(s.config.ConfigPath, "tls")
+	certPath, keyPath := filepath.Join(dir, "shelfarr.crt"), filepath.Join(dir, "shelfarr.key")
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && time.Until(leaf.NotAfter) > 30*24*time.Hour {
+			return cert, nil
+		}
+	}
+
+	slog.Info("Creating self-signed certificate", "path", certPath)
+	if err := writeSelfSignedCertificate(certPath, keyPath); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.LoadX509KeyPair(certPath, keyPath)
+}
+
+// writeSelfSignedCertificate creates a certificate for localhost and this machine's
+// name, valid for a year. Browsers warn about it until it's trusted.
+func writeSelfSignedCertificate(certPath, keyPath string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Shelfarr", Organization: []string{"Shelfarr"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "localhost" {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certPath), 0700); err != nil {
+		return fmt.Errorf("failed to create certificate folder: %w", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+// redirectToHTTPS sends plain HTTP requests to the same address on the HTTPS port.
+// /health is still answered over HTTP, for container health checks.
+func (s *Server) redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			s.echo.ServeHTTP(w, r)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
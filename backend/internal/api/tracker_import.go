@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/jobs"
+	"github.com/shelfarr/shelfarr/internal/metadata"
+	"github.com/shelfarr/shelfarr/internal/trackers"
+)
+
+const jobTrackerImport = "tracker_import"
+
+// trackerImportPayload is the job payload for adding a tracker export's books to the library
+type trackerImportPayload struct {
+	Tracker   string           `json:"tracker"`
+	Monitored bool             `json:"monitored"`
+	Entries   []trackers.Entry `json:"entries"`
+}
+
+// TrackerImportResult summarizes a finished tracker import
+type TrackerImportResult struct {
+	Added     int      `json:"added"`
+	Existing  int      `json:"existing"`
+	Unmatched []string `json:"unmatched"` // "Title - Author" of books no provider could match
+	Failed    []string `json:"failed"`    // Books that matched but couldn't be added
+}
+
+// Outcomes of importing a single tracker entry
+const (
+	trackerEntryAdded = iota
+	trackerEntryExisting
+	trackerEntryUnmatched
+)
+
+// importTracker queues the books in an uploaded tracker export to be matched and added.
+// The "shelves" form field limits the import to some shelves (comma-separated); by
+// default every shelf is imported. With "monitored" on (the default), books on the
+// to-read and currently-reading shelves are monitored.
+func (s *Server) importTracker(c echo.Context) error {
+	tracker := c.Param("tracker")
+	parse, ok := trackers.Parsers[tracker]
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Unknown tracker: " + tracker})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Export file is required"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read export file"})
+	}
+	defer file.Close()
+
+	entries, err := parse(file)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse export: " + err.Error()})
+	}
+
+	if shelves := c.FormValue("shelves"); shelves != "" {
+		wanted := make(map[string]bool)
+		for _, shelf := range strings.Split(shelves, ",") {
+			wanted[strings.TrimSpace(shelf)] = true
+		}
+		filtered := entries[:0]
+		for _, entry := range entries {
+			if wanted[entry.Shelf] {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+	if len(entries) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Export contains no books to import"})
+	}
+
+	job, err := s.jobs.Enqueue(jobTrackerImport, trackerImportPayload{
+		Tracker:   tracker,
+		Monitored: c.FormValue("monitored") != "false",
+		Entries:   entries,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// runTrackerImportJob matches each exported book against the metadata providers and
+// adds the matches that aren't already in the library
+func (s *Server) runTrackerImportJob(ctx context.Context, job *db.Job, progress jobs.ProgressFunc) (interface{}, error) {
+	var payload trackerImportPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return nil, err
+	}
+
+	result := TrackerImportResult{Unmatched: []string{}, Failed: []string{}}
+	for i, entry := range payload.Entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		progress(float64(i)*100/float64(len(payload.Entries)), fmt.Sprintf("Matching %s (%d/%d)", entry.Title, i+1, len(payload.Entries)))
+
+		monitored := payload.Monitored && (entry.Shelf == trackers.ShelfToRead || entry.Shelf == trackers.ShelfReading)
+		outcome, err := s.importTrackerEntry(ctx, entry, monitored)
+		label := entry.Title
+		if entry.Author != "" {
+			label += " - " + entry.Author
+		}
+		switch {
+		case err != nil:
+			libraryLog.Warn("Tracker import: failed to add book", "tracker", payload.Tracker, "book", entry.Title, "error", err)
+			result.Failed = append(result.Failed, label)
+		case outcome == trackerEntryAdded:
+			result.Added++
+		case outcome == trackerEntryExisting:
+			result.Existing++
+		default:
+			result.Unmatched = append(result.Unmatched, label)
+		}
+	}
+
+	progress(100, fmt.Sprintf("Added %d books, %d already in library, %d unmatched", result.Added, result.Existing, len(result.Unmatched)))
+	return result, nil
+}
+
+// importTrackerEntry adds one exported book to the library unless it's already there.
+// Books are matched by ISBN first, then by title and author.
+func (s *Server) importTrackerEntry(ctx context.Context, entry trackers.Entry, monitored bool) (int, error) {
+	if entry.ISBN != "" {
+		var ids db.BookIdentifiers
+		if len(entry.ISBN) == 13 {
+			ids.ISBN13 = entry.ISBN
+		} else {
+			ids.ISBN10 = entry.ISBN
+		}
+		if _, err := db.FindBookByIdentifiers(s.db, ids); err == nil {
+			return trackerEntryExisting, nil
+		}
+	}
+
+	match, err := s.matchTrackerEntry(ctx, entry)
+	if err != nil {
+		return 0, err
+	}
+	if match == nil {
+		return trackerEntryUnmatched, nil
+	}
+	if _, inLibrary := s.findLibraryBook(match.Provider, &match.BookData, match.Identifiers); inLibrary {
+		return trackerEntryExisting, nil
+	}
+
+	var existing db.Book
+	if err := s.db.Unscoped().Where(providerIDColumn(match.Provider)+" = ?", match.ID).First(&existing).Error; err == nil {
+		if !existing.DeletedAt.Valid {
+			return trackerEntryExisting, nil
+		}
+		if err := s.restoreBook(&existing, monitored); err != nil {
+			return 0, err
+		}
+		return trackerEntryAdded, nil
+	}
+
+	// Search results are partial records; fetch the full book before adding it
+	var book *hardcover.BookData
+	var extraIDs map[string]string
+	if match.Provider == "hardcover" {
+		client, err := s.getHardcoverClient()
+		if err != nil {
+			return 0, err
+		}
+		if book, err = client.GetBook(ctx, match.ID); err != nil {
+			return 0, err
+		}
+	} else {
+		fetched, err := s.getProviderBook(ctx, match.Provider, match.ID)
+		if err != nil {
+			return 0, err
+		}
+		book = &fetched.BookData
+		extraIDs = fetched.Identifiers
+	}
+
+	if _, err := s.createBook(match.Provider, book, providerIdentifiers(match.Provider, book, extraIDs), monitored, 0, 0); err != nil {
+		return 0, err
+	}
+	libraryLog.Debug("Tracker import: added book", "book", book.Title, "provider", match.Provider)
+	return trackerEntryAdded, nil
+}
+
+// matchTrackerEntry finds an exported book with the metadata providers, returning nil
+// when none of the results is the same book
+func (s *Server) matchTrackerEntry(ctx context.Context, entry trackers.Entry) (*metadata.Book, error) {
+	if entry.ISBN != "" {
+		results, err := s.metadata.SearchBooks(ctx, "isbn:"+entry.ISBN, nil)
+		if err == nil {
+			if match := metadata.FindMatch(results, entry.Title, entry.Author, entry.ISBN); match != nil {
+				return match, nil
+			}
+		}
+	}
+
+	// Trackers often keep the subtitle in the title; providers mostly don't
+	title, _, _ := strings.Cut(entry.Title, ":")
+	results, err := s.metadata.SearchBooks(ctx, strings.TrimSpace(title+" "+entry.Author), nil)
+	if err != nil {
+		return nil, err
+	}
+	if match := metadata.FindMatch(results, entry.Title, entry.Author, entry.ISBN); match != nil {
+		return match, nil
+	}
+	return metadata.FindMatch(results, strings.TrimSpace(title), entry.Author, ""), nil
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/version"
+)
+
+// updateCheckInterval is how often GitHub is asked for a newer release
+const updateCheckInterval = 12 * time.Hour
+
+// UpdateStatus reports the result of the last check for a newer release
+type UpdateStatus struct {
+	Enabled        bool       `json:"enabled"`   // Checking is turned on in the general settings
+	Available      bool       `json:"available"` // LatestVersion is newer than the running version
+	CurrentVersion string     `json:"currentVersion"`
+	LatestVersion  string     `json:"latestVersion,omitempty"`
+	ReleaseName    string     `json:"releaseName,omitempty"`
+	ReleaseURL     string     `json:"releaseUrl,omitempty"`
+	PublishedAt    *time.Time `json:"publishedAt,omitempty"`
+	CheckedAt      *time.Time `json:"checkedAt,omitempty"`
+	Error          string     `json:"error,omitempty"` // Why the last check failed
+}
+
+// updateChecksEnabled reports whether checking for new releases is turned on
+func (s *Server) updateChecksEnabled() bool {
+	var setting db.Setting
+	if s.db.Where("key = ?", "general_check_for_updates").First(&setting).Error == nil {
+		return setting.Value != "false"
+	}
+	return true
+}
+
+// updateStatus returns the stored result of the last update check
+func (s *Server) updateStatus() UpdateStatus {
+	status := UpdateStatus{
+		Enabled:        s.updateChecksEnabled(),
+		CurrentVersion: version.Version,
+	}
+
+	var settings []db.Setting
+	s.db.Where("key LIKE ?", "update_%").Find(&settings)
+	for _, setting := range settings {
+		switch setting.Key {
+		case "update_latest_version":
+			status.LatestVersion = setting.Value
+		case "update_release_name":
+			status.ReleaseName = setting.Value
+		case "update_release_url":
+			status.ReleaseURL = setting.Value
+		case "update_published_at":
+			if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+				status.PublishedAt = &t
+			}
+		case "update_checked_at":
+			if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+				status.CheckedAt = &t
+			}
+		case "update_error":
+			status.Error = setting.Value
+		}
+	}
+
+	status.Available = status.LatestVersion != "" && version.IsNewer(status.LatestVersion, version.Version)
+	return status
+}
+
+// checkForUpdates asks GitHub for the newest release and stores the result. The first
+// time a newer release is seen, an update notification is sent.
+func (s *Server) checkForUpdates(ctx context.Context) error {
+	if !s.updateChecksEnabled() {
+		return nil
+	}
+
+	release, err := version.LatestRelease(ctx, &http.Client{Timeout: 30 * time.Second})
+	values := map[string]string{
+		"update_checked_at": time.Now().UTC().Format(time.RFC3339),
+		"update_error":      "",
+	}
+	if err != nil {
+		values["update_error"] = err.Error()
+	} else {
+		values["update_latest_version"] = release.Version
+		values["update_release_name"] = release.Name
+		values["update_release_url"] = release.URL
+		values["update_published_at"] = release.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	for key, value := range values {
+		setting := db.Setting{Key: key, Value: value}
+		s.db.Where("key = ?", key).Assign(setting).FirstOrCreate(&setting)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !version.IsNewer(release.Version, version.Version) {
+		return nil
+	}
+
+	var notified db.Setting
+	s.db.Where("key = ?", "update_notified_version").First(&notified)
+	if notified.Value == release.Version {
+		return nil
+	}
+
+	slog.Info("A new Shelfarr release is available", "version", release.Version, "running", version.Version, "url", release.URL)
+	s.notifier.SendNotification("update", map[string]interface{}{
+		"title":          "Shelfarr " + release.Version,
+		"message":        "Shelfarr " + release.Version + " is available; you're running " + version.Version + ". " + release.URL,
+		"version":        release.Version,
+		"currentVersion": version.Version,
+		"url":            release.URL,
+	})
+	setting := db.Setting{Key: "update_notified_version", Value: release.Version}
+	s.db.Where("key = ?", setting.Key).Assign(setting).FirstOrCreate(&setting)
+	return nil
+}
+
+// checkForUpdatesIfStale runs the update check when the last one is older than the check
+// interval, so a restart doesn't wait a full interval to learn about a release
+func (s *Server) checkForUpdatesIfStale() {
+	status := s.updateStatus()
+	if !status.Enabled || (status.CheckedAt != nil && time.Since(*status.CheckedAt) < updateCheckInterval) {
+		return
+	}
+	if err := s.scheduler.RunNow("update_check"); err != nil {
+		slog.Warn("Update check failed", "error", err)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/db"
+)
+
+// frontendDir holds the built frontend
+const frontendDir = "public"
+
+// urlBase is the path Shelfarr is served under behind a reverse proxy, such as
+// "/shelfarr", or "" when it's served from the root. Every request reads it, so it's
+// kept in memory rather than read from the settings.
+var urlBase atomic.Pointer[string]
+
+// currentURLBase returns the URL base, which links Shelfarr generates start with
+func currentURLBase() string {
+	if base := urlBase.Load(); base != nil {
+		return *base
+	}
+	return ""
+}
+
+// normalizeURLBase tidies a URL base as entered, such as "shelfarr/" to "/shelfarr"
+func normalizeURLBase(base string) (string, error) {
+	base = strings.Trim(strings.TrimSpace(base), "/")
+	if base == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(base, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid URL base %q", base)
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.~", r)) {
+				return "", fmt.Errorf("URL base can only contain letters, numbers, slashes and - _ . ~")
+			}
+		}
+	}
+	return "/" + base, nil
+}
+
+// urlBaseSetting returns the configured URL base: SHELFARR_URL_BASE (or the config
+// file) if set, otherwise the general settings. It reports whether it came from the
+// environment, in which case the settings can't change it.
+func (s *Server) urlBaseSetting() (string, bool) {
+	if base, ok := os.LookupEnv("SHELFARR_URL_BASE"); ok {
+		return base, true
+	}
+	var setting db.Setting
+	s.db.Where("key = ?", "general_url_base").First(&setting)
+	return setting.Value, false
+}
+
+// applyURLBase starts serving under the configured URL base
+func (s *Server) applyURLBase() {
+	setting, _ := s.urlBaseSetting()
+	base, err := normalizeURLBase(setting)
+	if err != nil {
+		slog.Warn("Ignoring URL base", "error", err)
+	}
+	urlBase.Store(&base)
+}
+
+// stripURLBase takes the URL base off request paths before they're routed, so routes
+// are registered without it. Paths outside the URL base aren't found, except /, which
+// redirects into it, and /health, for container health checks.
+func stripURLBase(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		base := currentURLBase()
+		if base == "" {
+			return next(c)
+		}
+
+		req := c.Request()
+		switch p := req.URL.Path; {
+		case strings.HasPrefix(p, base+"/"):
+			req.URL.Path = strings.TrimPrefix(p, base)
+			req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, base)
+			return next(c)
+		case p == base || p == "/":
+			return c.Redirect(http.StatusFound, base+"/")
+		case p == "/health":
+			return next(c)
+		}
+		return echo.ErrNotFound
+	}
+}
+
+// serveFrontend serves the built frontend's files, and index.html for the app's own
+// routes, so pages can be reloaded and linked to. index.html is given the URL base,
+// which the app's links and requests are relative to.
+func (s *Server) serveFrontend(c echo.Context) error {
+	name := path.Clean("/" + c.Param("*"))
+	for _, prefix := range []string{"/api/", "/opds/", "/kobo/"} {
+		if strings.HasPrefix(name, prefix) {
+			return echo.ErrNotFound
+		}
+	}
+
+	if name != "/" {
+		file := filepath.Join(frontendDir, filepath.FromSlash(name))
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return c.File(file)
+		}
+		if path.Ext(name) != "" {
+			// A missing asset rather than a page
+			return echo.ErrNotFound
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(frontendDir, "index.html"))
+	if err != nil {
+		return echo.ErrNotFound
+	}
+	base := currentURLBase()
+	head := fmt.Sprintf(`<head><base href="%s/"><script>window.__SHELFARR_URL_BASE__ = %q</script>`, base, base)
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	return c.HTML(http.StatusOK, strings.Replace(string(index), "<head>", head, 1))
+}
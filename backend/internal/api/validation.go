@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/validate"
+)
+
+// requestValidator checks request bodies against their validate tags for c.Validate
+type requestValidator struct{}
+
+func (requestValidator) Validate(i interface{}) error {
+	return validate.Struct(i)
+}
+
+// bindRequest reads the request body into req and checks it against its validate tags.
+// If either fails, the error is a 400 response for the handler to return: an "error"
+// message, and for invalid fields, "fields" with a message for each by its JSON path.
+func bindRequest(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if err := c.Validate(req); err != nil {
+		var invalid validate.Errors
+		if errors.As(err, &invalid) {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]interface{}{
+				"error":  "Invalid request: " + invalid.Error(),
+				"fields": invalid.Fields(),
+			})
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return nil
+}
@@ -45,7 +45,7 @@ func (s *Server) getWanted(c echo.Context) error {
 	var books []db.Book
 	var total int64
 
-	query := s.db.Model(&db.Book{}).
+	query := s.db.Model(&db.Book{}).Scopes(s.visibleBooks(c)).
 		Where("monitored = ? AND status = ?", true, db.StatusMissing)
 	
 	query.Count(&total)
@@ -60,7 +60,7 @@ func (s *Server) getWanted(c echo.Context) error {
 			ID:         book.ID,
 			Title:      book.Title,
 			AuthorID:   book.AuthorID,
-			CoverURL:   book.CoverURL,
+			CoverURL:   coverImageURL(&book),
 			Status:     string(book.Status),
 			Monitored:  book.Monitored,
 		}
@@ -116,7 +116,7 @@ func (s *Server) getWantedMissing(c echo.Context) error {
 	var books []db.Book
 	var total int64
 
-	query := s.db.Model(&db.Book{}).Where("monitored = ?", true)
+	query := s.db.Model(&db.Book{}).Scopes(s.visibleBooks(c)).Where("monitored = ?", true)
 
 	if mediaType != "" {
 		// Books missing this specific media type
@@ -138,7 +138,7 @@ func (s *Server) getWantedMissing(c echo.Context) error {
 			ID:        book.ID,
 			Title:     book.Title,
 			AuthorID:  book.AuthorID,
-			CoverURL:  book.CoverURL,
+			CoverURL:  coverImageURL(&book),
 			Status:    string(book.Status),
 			Monitored: book.Monitored,
 		}
@@ -189,7 +189,7 @@ func (s *Server) getWantedCutoff(c echo.Context) error {
 	var total int64
 
 	// Books that have media but might need better quality
-	query := s.db.Model(&db.Book{}).
+	query := s.db.Model(&db.Book{}).Scopes(s.visibleBooks(c)).
 		Where("monitored = ?", true).
 		Where("id IN (SELECT DISTINCT book_id FROM media_files WHERE deleted_at IS NULL)")
 
@@ -204,9 +204,16 @@ func (s *Server) getWantedCutoff(c echo.Context) error {
 	for _, book := range books {
 		// Check if any media file is below cutoff
 		needsUpgrade := false
+		hasReflowable := false
 		for _, mf := range book.MediaFiles {
-			// Simplified: if format is PDF for ebook, needs upgrade
-			if mf.MediaType == db.MediaTypeEbook && mf.Format == "pdf" {
+			if mf.MediaType == db.MediaTypeEbook && mf.Format != "pdf" {
+				hasReflowable = true
+			}
+		}
+		for _, mf := range book.MediaFiles {
+			// Simplified: if the only ebook is a PDF, needs upgrade
+			// (converting it to EPUB via /mediafiles/:id/convert satisfies the cutoff)
+			if mf.MediaType == db.MediaTypeEbook && mf.Format == "pdf" && !hasReflowable {
 				needsUpgrade = true
 				break
 			}
@@ -222,7 +229,7 @@ func (s *Server) getWantedCutoff(c echo.Context) error {
 				ID:        book.ID,
 				Title:     book.Title,
 				AuthorID:  book.AuthorID,
-				CoverURL:  book.CoverURL,
+				CoverURL:  coverImageURL(&book),
 				Status:    "cutoff",
 				Monitored: book.Monitored,
 			}
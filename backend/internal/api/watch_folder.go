@@ -0,0 +1,119 @@
+package api
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/media"
+	"github.com/shelfarr/shelfarr/internal/watcher"
+)
+
+// startDownloadsWatcher watches the downloads folder, when enabled, and imports books
+// as soon as they finish appearing there
+func (s *Server) startDownloadsWatcher() {
+	if s.config.DownloadsPath == "" {
+		return
+	}
+	w := watcher.New(s.config.DownloadsPath, watcher.DefaultInterval, s.isWatchingDownloads, s.importWatchedPath)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		w.Run(s.ctx)
+	}()
+}
+
+// isWatchingDownloads reports whether new files in the downloads folder are imported
+// without waiting for the download monitor or a manual import
+func (s *Server) isWatchingDownloads() bool {
+	var setting db.Setting
+	if err := s.db.Where("key = ?", "media_watch_downloads").First(&setting).Error; err != nil {
+		return false
+	}
+	return setting.Value == "true"
+}
+
+// importWatchedPath imports a file or folder that appeared in the downloads folder if it
+// confidently matches a library book. Anything else is left for manual import.
+func (s *Server) importWatchedPath(path string) {
+	if s.isTrackedDownload(path) {
+		return // The download monitor imports it
+	}
+
+	mediaType := watchedMediaType(path)
+	if mediaType == "" {
+		return
+	}
+
+	match := pathImportMatch(path)
+	if mediaType == "ebook" {
+		// A book downloaded in a folder is matched by the EPUB inside, if it is one
+		if file := findEbookFile(path); file != path {
+			match.applyEPUB(file)
+		}
+	}
+	candidates := s.libraryCandidates(match, s.loadLibraryBooks())
+	if len(candidates) == 0 || candidates[0].Score < minScanMatchScore ||
+		(len(candidates) > 1 && candidates[1].Score == candidates[0].Score) {
+		libraryLog.Info("Watch folder: no confident match; leaving it for manual import", "file", filepath.Base(path))
+		return
+	}
+
+	var book db.Book
+	if err := s.db.Preload("Author").Preload("Series").First(&book, candidates[0].BookID).Error; err != nil {
+		return
+	}
+
+	if mediaType == "ebook" {
+		// The importer treats folders as audiobooks; use the book file inside
+		path = findEbookFile(path)
+	}
+	result, err := s.importToLibrary(&book, path, mediaType, "")
+	if err != nil {
+		libraryLog.Warn("Watch folder: import failed", "file", filepath.Base(path), "error", err)
+		s.events.ImportFailed(book.ID, filepath.Base(path), err.Error())
+		return
+	}
+	libraryLog.Info("Watch folder: imported file", "file", filepath.Base(path), "book", book.Title)
+	s.events.ImportCompleted(book.ID, result.MediaFileID, result.NewPath)
+}
+
+// isTrackedDownload reports whether a path belongs to a download Shelfarr grabbed, which
+// the download monitor imports itself
+func (s *Server) isTrackedDownload(path string) bool {
+	var count int64
+	s.db.Model(&db.Download{}).
+		Where("output_path = ? OR (title = ? AND status IN ?)", path, filepath.Base(path), activeDownloadStatuses).
+		Count(&count)
+	return count > 0
+}
+
+// watchedMediaType works out whether a watched file or folder is an ebook or an
+// audiobook. Folders holding audio files are audiobooks.
+func watchedMediaType(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	scanner := media.NewScanner()
+	if !info.IsDir() {
+		return scanner.DetectMediaType(filepath.Ext(path))
+	}
+
+	mediaType := ""
+	filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		switch scanner.DetectMediaType(filepath.Ext(file)) {
+		case "audiobook":
+			mediaType = "audiobook"
+			return filepath.SkipAll
+		case "ebook":
+			mediaType = "ebook"
+		}
+		return nil
+	})
+	return mediaType
+}
@@ -0,0 +1,172 @@
+// Package audiobookshelf talks to an Audiobookshelf server so audiobooks Shelfarr imports
+// show up there without waiting for its folder watcher.
+package audiobookshelf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Root folder modes
+const (
+	ModeScan   = "scan"   // Ask Audiobookshelf to rescan the library that shares the folder
+	ModeUpload = "upload" // Upload the files through the API, for servers without access to the folder
+)
+
+// Client calls the Audiobookshelf REST API with an API token
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Audiobookshelf client
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			// Uploads send whole audiobooks
+			Timeout: 30 * time.Minute,
+		},
+	}
+}
+
+// Library is an Audiobookshelf library
+type Library struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	MediaType string   `json:"mediaType"` // book or podcast
+	Folders   []Folder `json:"folders"`
+}
+
+// Folder is one of the folders a library is stored in
+type Folder struct {
+	ID       string `json:"id"`
+	FullPath string `json:"fullPath"`
+}
+
+// Libraries returns the server's libraries
+func (c *Client) Libraries(ctx context.Context) ([]Library, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/libraries", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Libraries []Library `json:"libraries"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	return result.Libraries, nil
+}
+
+// ScanLibrary starts a scan of a library for new and changed items
+func (c *Client) ScanLibrary(ctx context.Context, libraryID string) error {
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/libraries/"+libraryID+"/scan", nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
+
+// Upload describes an audiobook to upload into a library folder
+type Upload struct {
+	LibraryID string
+	FolderID  string
+	Title     string
+	Author    string
+	Series    string
+	Files     []string // Paths of the audio files, cover and other files of the item
+}
+
+// Upload sends an item's files to Audiobookshelf, which stores them under
+// Author/Series/Title in the folder and adds the item to the library
+func (c *Client) Upload(ctx context.Context, upload Upload) error {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	// Stream the form so large audiobooks aren't held in memory
+	go func() {
+		writer.CloseWithError(writeUploadForm(form, upload))
+	}()
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/upload", body)
+	if err != nil {
+		body.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return c.do(req, nil)
+}
+
+// writeUploadForm writes the fields and files of an upload
+func writeUploadForm(form *multipart.Writer, upload Upload) error {
+	fields := [][2]string{
+		{"title", upload.Title},
+		{"author", upload.Author},
+		{"series", upload.Series},
+		{"library", upload.LibraryID},
+		{"folder", upload.FolderID},
+	}
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+
+	for i, path := range upload.Files {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		part, err := form.CreateFormFile(fmt.Sprint(i), filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return form.Close()
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	if c.baseURL == "" {
+		return nil, fmt.Errorf("Audiobookshelf URL is not configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return req, nil
+}
+
+// do sends a request and decodes the JSON response into v, if given
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Audiobookshelf request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Audiobookshelf returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,165 @@
+package audnexus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultBaseURL is the public Audnexus API
+const DefaultBaseURL = "https://api.audnex.us"
+
+// ErrNotFound is returned when Audnexus has no data for an ASIN
+var ErrNotFound = errors.New("not found on Audnexus")
+
+// Client fetches Audible metadata and chapter lists from the Audnexus API
+type Client struct {
+	baseURL     string
+	region      string
+	httpClient  *http.Client
+	rateLimiter *rate.Limiter
+}
+
+// NewClient creates a new Audnexus client for the US Audible region
+func NewClient() *Client {
+	return &Client{
+		baseURL: DefaultBaseURL,
+		region:  "us",
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		// Audnexus is a free community service; stay well under its limits
+		rateLimiter: rate.NewLimiter(rate.Every(500*time.Millisecond), 1),
+	}
+}
+
+// SetRegion sets the Audible region (us, uk, de, fr, ca, au, it, in, jp, es)
+func (c *Client) SetRegion(region string) {
+	if region != "" {
+		c.region = strings.ToLower(region)
+	}
+}
+
+// Person is an author or narrator
+type Person struct {
+	ASIN string `json:"asin,omitempty"`
+	Name string `json:"name"`
+}
+
+// Genre is an Audible genre or tag
+type Genre struct {
+	ASIN string `json:"asin"`
+	Name string `json:"name"`
+	Type string `json:"type"` // genre or tag
+}
+
+// Series is an Audible series reference
+type Series struct {
+	ASIN     string `json:"asin"`
+	Name     string `json:"name"`
+	Position string `json:"position"`
+}
+
+// Book is audiobook metadata for an ASIN
+type Book struct {
+	ASIN             string   `json:"asin"`
+	Title            string   `json:"title"`
+	Subtitle         string   `json:"subtitle"`
+	Authors          []Person `json:"authors"`
+	Narrators        []Person `json:"narrators"`
+	Description      string   `json:"description"`
+	Summary          string   `json:"summary"`
+	Image            string   `json:"image"`
+	Genres           []Genre  `json:"genres"`
+	SeriesPrimary    *Series  `json:"seriesPrimary"`
+	SeriesSecondary  *Series  `json:"seriesSecondary"`
+	PublisherName    string   `json:"publisherName"`
+	ReleaseDate      string   `json:"releaseDate"` // ISO 8601
+	RuntimeLengthMin int      `json:"runtimeLengthMin"`
+	Language         string   `json:"language"`
+	FormatType       string   `json:"formatType"` // unabridged, abridged
+	Rating           string   `json:"rating"`
+	Region           string   `json:"region"`
+}
+
+// RuntimeSeconds returns the runtime in seconds
+func (b *Book) RuntimeSeconds() int {
+	return b.RuntimeLengthMin * 60
+}
+
+// Chapter is a single chapter marker
+type Chapter struct {
+	Title          string `json:"title"`
+	LengthMs       int64  `json:"lengthMs"`
+	StartOffsetMs  int64  `json:"startOffsetMs"`
+	StartOffsetSec int64  `json:"startOffsetSec"`
+}
+
+// Chapters is the chapter list for an ASIN
+type Chapters struct {
+	ASIN                 string    `json:"asin"`
+	BrandIntroDurationMs int64     `json:"brandIntroDurationMs"`
+	BrandOutroDurationMs int64     `json:"brandOutroDurationMs"`
+	Chapters             []Chapter `json:"chapters"`
+	IsAccurate           bool      `json:"isAccurate"`
+	RuntimeLengthMs      int64     `json:"runtimeLengthMs"`
+	RuntimeLengthSec     int64     `json:"runtimeLengthSec"`
+	Region               string    `json:"region"`
+}
+
+// GetBook fetches audiobook metadata by ASIN
+func (c *Client) GetBook(ctx context.Context, asin string) (*Book, error) {
+	var book Book
+	if err := c.get(ctx, "/books/"+url.PathEscape(asin), &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// GetChapters fetches the chapter list for an ASIN
+func (c *Client) GetChapters(ctx context.Context, asin string) (*Chapters, error) {
+	var chapters Chapters
+	if err := c.get(ctx, "/books/"+url.PathEscape(asin)+"/chapters", &chapters); err != nil {
+		return nil, err
+	}
+	return &chapters, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	reqURL := c.baseURL + path + "?region=" + url.QueryEscape(c.region)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Shelfarr/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("audnexus request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("audnexus returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse audnexus response: %w", err)
+	}
+	return nil
+}
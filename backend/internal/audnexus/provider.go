@@ -0,0 +1,81 @@
+package audnexus
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AudiobookMetadata is the audiobook-specific metadata Shelfarr takes from Audible
+type AudiobookMetadata struct {
+	ASIN           string
+	Title          string
+	Narrators      []string
+	Abridged       bool
+	RuntimeSeconds int
+	SeriesName     string
+	SeriesPosition *float32 // nil when Audible has no numeric position
+	PublisherName  string
+	ReleaseDate    *time.Time
+	CoverURL       string
+}
+
+// Provider looks up audiobook metadata by ASIN through Audnexus
+type Provider struct {
+	client *Client
+}
+
+// NewProvider creates a new Audible metadata provider
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// GetAudiobook returns audiobook metadata for an ASIN
+func (p *Provider) GetAudiobook(ctx context.Context, asin string) (*AudiobookMetadata, error) {
+	book, err := p.client.GetBook(ctx, asin)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &AudiobookMetadata{
+		ASIN:           book.ASIN,
+		Title:          book.Title,
+		Abridged:       strings.EqualFold(book.FormatType, "abridged"),
+		RuntimeSeconds: book.RuntimeSeconds(),
+		PublisherName:  book.PublisherName,
+		CoverURL:       book.Image,
+	}
+
+	for _, n := range book.Narrators {
+		if name := strings.TrimSpace(n.Name); name != "" {
+			meta.Narrators = append(meta.Narrators, name)
+		}
+	}
+
+	if book.SeriesPrimary != nil {
+		meta.SeriesName = book.SeriesPrimary.Name
+		meta.SeriesPosition = parseSeriesPosition(book.SeriesPrimary.Position)
+	}
+
+	if book.ReleaseDate != "" {
+		if t, err := time.Parse(time.RFC3339, book.ReleaseDate); err == nil {
+			meta.ReleaseDate = &t
+		} else if t, err := time.Parse("2006-01-02", book.ReleaseDate); err == nil {
+			meta.ReleaseDate = &t
+		}
+	}
+
+	return meta, nil
+}
+
+// parseSeriesPosition extracts a number from Audible positions like "3", "2.5" or "Book 3"
+func parseSeriesPosition(position string) *float32 {
+	for _, field := range strings.Fields(position) {
+		if v, err := strconv.ParseFloat(strings.Trim(field, ",;"), 32); err == nil {
+			f := float32(v)
+			return &f
+		}
+	}
+	return nil
+}
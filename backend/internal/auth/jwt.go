@@ -75,8 +75,8 @@ func (User) TableName() string {
 
 // LoginRequest represents a login request
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 // LoginResponse represents a login response
@@ -98,18 +98,13 @@ type UserInfo struct {
 
 // Login authenticates a user and returns a JWT token
 func (s *AuthService) Login(username, password string) (*LoginResponse, error) {
-	var user User
-	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
-		return nil, ErrInvalidCredentials
-	}
-
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, ErrInvalidCredentials
+	user, err := s.Authenticate(username, password)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate token
-	token, expiresAt, err := s.generateToken(&user)
+	token, expiresAt, err := s.generateToken(user)
 	if err != nil {
 		return nil, err
 	}
@@ -286,8 +281,12 @@ func JWTMiddleware(authService *AuthService) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			// Get token from Authorization header
+			// Get token from Authorization header. Browsers can't set headers on an
+			// EventSource, so event streams may pass it as ?token= instead.
 			auth := c.Request().Header.Get("Authorization")
+			if auth == "" && strings.HasSuffix(path, "/events") && c.QueryParam("token") != "" {
+				auth = "Bearer " + c.QueryParam("token")
+			}
 			if auth == "" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
 			}
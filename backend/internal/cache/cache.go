@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"gorm.io/gorm"
+)
+
+// DefaultTTL is how long responses are cached unless configured otherwise
+const DefaultTTL = 24 * time.Hour
+
+// maxMemoryEntries bounds the in-memory layer; older entries stay in SQLite
+const maxMemoryEntries = 2000
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Cache stores API responses in memory, backed by SQLite so they survive restarts
+type Cache struct {
+	db      *gorm.DB
+	ttl     time.Duration
+	entries map[string]entry
+	mutex   sync.RWMutex
+
+	hits   int64
+	misses int64
+}
+
+// Stats describes the cache contents and hit rate
+type Stats struct {
+	TTLMinutes    int   `json:"ttlMinutes"`
+	MemoryEntries int   `json:"memoryEntries"`
+	StoredEntries int64 `json:"storedEntries"`
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+}
+
+// New creates a cache and drops expired entries left from previous runs
+func New(gdb *gorm.DB, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	c := &Cache{
+		db:      gdb,
+		ttl:     ttl,
+		entries: make(map[string]entry),
+	}
+	if err := gdb.Where("expires_at < ?", time.Now()).Delete(&db.CacheEntry{}).Error; err != nil {
+		slog.Warn("Failed to purge expired cache entries", "error", err)
+	}
+	return c
+}
+
+// SetTTL changes the lifetime of newly cached entries
+func (c *Cache) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	c.mutex.Lock()
+	c.ttl = ttl
+	c.mutex.Unlock()
+}
+
+// TTL returns the lifetime of newly cached entries
+func (c *Cache) TTL() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.ttl
+}
+
+// Get returns a cached value, checking memory first and then SQLite
+func (c *Cache) Get(key string) ([]byte, bool) {
+	now := time.Now()
+
+	c.mutex.RLock()
+	e, ok := c.entries[key]
+	c.mutex.RUnlock()
+	if ok && now.Before(e.expiresAt) {
+		c.record(true)
+		return e.value, true
+	}
+
+	var stored db.CacheEntry
+	if err := c.db.Where("key = ? AND expires_at > ?", key, now).First(&stored).Error; err != nil {
+		c.record(false)
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	c.storeLocked(key, entry{value: stored.Value, expiresAt: stored.ExpiresAt})
+	c.hits++
+	c.mutex.Unlock()
+	return stored.Value, true
+}
+
+// Set caches a value for the configured TTL
+func (c *Cache) Set(key string, value []byte) {
+	c.mutex.Lock()
+	e := entry{value: value, expiresAt: time.Now().Add(c.ttl)}
+	c.storeLocked(key, e)
+	c.mutex.Unlock()
+
+	stored := db.CacheEntry{Key: key, Value: value, ExpiresAt: e.expiresAt}
+	if err := c.db.Save(&stored).Error; err != nil {
+		slog.Warn("Failed to persist cache entry", "error", err)
+	}
+}
+
+// Bust removes every entry whose key starts with prefix; an empty prefix clears the cache.
+// Returns the number of stored entries removed.
+func (c *Cache) Bust(prefix string) int64 {
+	c.mutex.Lock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	c.mutex.Unlock()
+
+	query := c.db.Where("1 = 1")
+	if prefix != "" {
+		query = c.db.Where(`key LIKE ? ESCAPE '\'`, escapeLike(prefix)+"%")
+	}
+	result := query.Delete(&db.CacheEntry{})
+	if result.Error != nil {
+		slog.Warn("Failed to bust cache", "error", result.Error)
+	}
+	return result.RowsAffected
+}
+
+// Stats returns the cache size and hit counts since startup
+func (c *Cache) Stats() Stats {
+	var stored int64
+	c.db.Model(&db.CacheEntry{}).Where("expires_at > ?", time.Now()).Count(&stored)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return Stats{
+		TTLMinutes:    int(c.ttl / time.Minute),
+		MemoryEntries: len(c.entries),
+		StoredEntries: stored,
+		Hits:          c.hits,
+		Misses:        c.misses,
+	}
+}
+
+func (c *Cache) record(hit bool) {
+	c.mutex.Lock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.mutex.Unlock()
+}
+
+// storeLocked adds an entry to memory, evicting the soonest-expiring one when full.
+// Callers hold the write lock.
+func (c *Cache) storeLocked(key string, e entry) {
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxMemoryEntries {
+		var oldest string
+		var oldestAt time.Time
+		for k, v := range c.entries {
+			if oldest == "" || v.expiresAt.Before(oldestAt) {
+				oldest, oldestAt = k, v.expiresAt
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = e
+}
+
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "%", `\%`)
+	return strings.ReplaceAll(s, "_", `\_`)
+}
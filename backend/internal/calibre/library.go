@@ -0,0 +1,189 @@
+// Package calibre reads a Calibre library folder: the metadata.db catalogue and the
+// book files stored alongside it
+package calibre
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Book is a book in a Calibre library
+type Book struct {
+	ID          int
+	UUID        string
+	Title       string
+	SortTitle   string
+	Authors     []string // In Calibre's order; the first is the primary author
+	Series      string
+	SeriesIndex float32
+	PubDate     *time.Time
+	Description string
+	Languages   []string
+	Tags        []string
+
+	// Identifiers are keyed by Calibre's identifier type ("isbn", "amazon", "goodreads")
+	Identifiers map[string]string
+	Files       []File
+}
+
+// File is one format of a Calibre book
+type File struct {
+	Path   string
+	Format string // Lowercase extension without the dot ("epub")
+	Size   int64
+}
+
+// Library is an open Calibre library
+type Library struct {
+	path string
+	db   *gorm.DB
+}
+
+// Open opens the Calibre library at path read-only
+func Open(path string) (*Library, error) {
+	dbPath := filepath.Join(path, "metadata.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("no Calibre library at %s: %w", path, err)
+	}
+
+	// Read-only and immutable, so a running Calibre's lock doesn't get in the way
+	dsn := "file:" + (&url.URL{Path: dbPath}).EscapedPath() + "?mode=ro&immutable=1"
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dbPath, err)
+	}
+	return &Library{path: path, db: gdb}, nil
+}
+
+// Close closes the library's database
+func (l *Library) Close() error {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Books returns every book in the library
+func (l *Library) Books() ([]Book, error) {
+	var rows []struct {
+		ID          int
+		UUID        string
+		Title       string
+		Sort        string
+		Path        string
+		PubDate     string
+		SeriesIndex float32
+	}
+	err := l.db.Raw(`SELECT id, uuid, title, sort, path, CAST(pubdate AS TEXT) AS pub_date, series_index FROM books ORDER BY id`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read books: %w", err)
+	}
+
+	books := make([]Book, len(rows))
+	index := make(map[int]*Book, len(rows))
+	paths := make(map[int]string, len(rows))
+	for i, r := range rows {
+		books[i] = Book{
+			ID:          r.ID,
+			UUID:        r.UUID,
+			Title:       r.Title,
+			SortTitle:   r.Sort,
+			SeriesIndex: r.SeriesIndex,
+			PubDate:     parseDate(r.PubDate),
+			Identifiers: make(map[string]string),
+		}
+		index[r.ID] = &books[i]
+		paths[r.ID] = r.Path
+	}
+
+	links := []struct {
+		query string
+		apply func(b *Book, value, extra string)
+	}{
+		{`SELECT l.book, a.name AS value, '' AS extra FROM books_authors_link l JOIN authors a ON a.id = l.author ORDER BY l.id`,
+			func(b *Book, value, _ string) { b.Authors = append(b.Authors, value) }},
+		{`SELECT l.book, s.name AS value, '' AS extra FROM books_series_link l JOIN series s ON s.id = l.series`,
+			func(b *Book, value, _ string) { b.Series = value }},
+		{`SELECT book, text AS value, '' AS extra FROM comments`,
+			func(b *Book, value, _ string) { b.Description = plainText(value) }},
+		{`SELECT l.book, g.lang_code AS value, '' AS extra FROM books_languages_link l JOIN languages g ON g.id = l.lang_code ORDER BY l.item_order`,
+			func(b *Book, value, _ string) { b.Languages = append(b.Languages, value) }},
+		{`SELECT l.book, t.name AS value, '' AS extra FROM books_tags_link l JOIN tags t ON t.id = l.tag`,
+			func(b *Book, value, _ string) { b.Tags = append(b.Tags, value) }},
+		{`SELECT book, type AS value, val AS extra FROM identifiers`,
+			func(b *Book, value, extra string) { b.Identifiers[strings.ToLower(value)] = extra }},
+		{`SELECT book, name AS value, format AS extra FROM data`,
+			func(b *Book, value, extra string) { b.Files = append(b.Files, l.file(paths[b.ID], value, extra)) }},
+	}
+	for _, link := range links {
+		var linked []struct {
+			Book  int
+			Value string
+			Extra string
+		}
+		if err := l.db.Raw(link.query).Scan(&linked).Error; err != nil {
+			return nil, fmt.Errorf("failed to read book details: %w", err)
+		}
+		for _, row := range linked {
+			if b, ok := index[row.Book]; ok {
+				link.apply(b, row.Value, row.Extra)
+			}
+		}
+	}
+
+	return books, nil
+}
+
+// file builds a File for one of a book's formats. Calibre names files
+// "<book folder>/<name>.<format>".
+func (l *Library) file(bookPath, name, format string) File {
+	format = strings.ToLower(format)
+	f := File{
+		Path:   filepath.Join(l.path, filepath.FromSlash(bookPath), name+"."+format),
+		Format: format,
+	}
+	if info, err := os.Stat(f.Path); err == nil {
+		f.Size = info.Size()
+	}
+	return f
+}
+
+// parseDate parses a Calibre timestamp. Calibre stores 0101-01-01 for unknown dates.
+func parseDate(s string) *time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999-07:00", "2006-01-02 15:04:05-07:00", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			if t.Year() <= 101 {
+				return nil
+			}
+			return &t
+		}
+	}
+	return nil
+}
+
+var (
+	htmlBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	htmlTags   = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// plainText converts the HTML Calibre stores book descriptions as to plain text
+func plainText(s string) string {
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTags.ReplaceAllString(s, ""))
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
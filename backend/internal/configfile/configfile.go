@@ -0,0 +1,205 @@
+// Package configfile reads Shelfarr's optional config.yml. Its values are copied into
+// the environment variables they correspond to, such as SHELFARR_BOOKS_PATH, unless
+// those are already set, so the environment overrides the file and everything that
+// reads the environment picks the file up without knowing about it.
+//
+// The file is read when the package is initialized, which is before main reads its
+// configuration. It's SHELFARR_CONFIG_FILE if set, otherwise config.yml in the config
+// folder (SHELFARR_CONFIG_PATH, or /config). A missing file is fine.
+package configfile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the layout of config.yml
+type File struct {
+	Server struct {
+		ListenAddr string `yaml:"listen_addr"` // Such as ":8080"
+		URLBase    string `yaml:"url_base"`    // Such as "/shelfarr" behind a reverse proxy
+
+		TrustedProxies string `yaml:"trusted_proxies"` // Such as "172.18.0.0/16"
+
+		ShutdownTimeout string `yaml:"shutdown_timeout"` // Such as "60s"
+	} `yaml:"server"`
+
+	Paths struct {
+		Config     string `yaml:"config"`
+		Books      string `yaml:"books"`
+		Audiobooks string `yaml:"audiobooks"`
+		Downloads  string `yaml:"downloads"`
+		Scripts    string `yaml:"scripts"` // Custom notification scripts
+	} `yaml:"paths"`
+
+	Database struct {
+		Driver          string `yaml:"driver"` // sqlite or postgres
+		DSN             string `yaml:"dsn"`
+		MaxOpenConns    int    `yaml:"max_open_conns"`
+		MaxIdleConns    int    `yaml:"max_idle_conns"`
+		ConnMaxLifetime string `yaml:"conn_max_lifetime"` // Such as "30m"
+	} `yaml:"database"`
+
+	Log struct {
+		Level string `yaml:"level"` // debug, info, warn or error
+	} `yaml:"log"`
+
+	// Proxy for outgoing requests to indexers, download clients and metadata providers
+	Proxy struct {
+		HTTP    string `yaml:"http"`
+		HTTPS   string `yaml:"https"`
+		NoProxy string `yaml:"no_proxy"` // Comma-separated hosts to reach directly
+	} `yaml:"proxy"`
+
+	Hardcover struct {
+		APIURL string `yaml:"api_url"`
+		APIKey string `yaml:"api_key"`
+	} `yaml:"hardcover"`
+}
+
+// Env returns the environment variables the file's values stand for, leaving out
+// those it doesn't set
+func (f *File) Env() map[string]string {
+	env := map[string]string{}
+	set := func(name, value string) {
+		if value != "" {
+			env[name] = value
+		}
+	}
+	setInt := func(name string, value int) {
+		if value != 0 {
+			env[name] = strconv.Itoa(value)
+		}
+	}
+
+	set("SHELFARR_LISTEN_ADDR", f.Server.ListenAddr)
+	set("SHELFARR_URL_BASE", f.Server.URLBase)
+	set("SHELFARR_TRUSTED_PROXIES", f.Server.TrustedProxies)
+	set("SHELFARR_SHUTDOWN_TIMEOUT", f.Server.ShutdownTimeout)
+	set("SHELFARR_CONFIG_PATH", f.Paths.Config)
+	set("SHELFARR_BOOKS_PATH", f.Paths.Books)
+	set("SHELFARR_AUDIOBOOKS_PATH", f.Paths.Audiobooks)
+	set("SHELFARR_DOWNLOADS_PATH", f.Paths.Downloads)
+	set("SHELFARR_SCRIPTS_PATH", f.Paths.Scripts)
+	set("SHELFARR_DB_DRIVER", f.Database.Driver)
+	set("SHELFARR_DB_DSN", f.Database.DSN)
+	setInt("SHELFARR_DB_MAX_OPEN_CONNS", f.Database.MaxOpenConns)
+	setInt("SHELFARR_DB_MAX_IDLE_CONNS", f.Database.MaxIdleConns)
+	set("SHELFARR_DB_CONN_MAX_LIFETIME", f.Database.ConnMaxLifetime)
+	set("SHELFARR_LOG_LEVEL", f.Log.Level)
+	set("HTTP_PROXY", f.Proxy.HTTP)
+	set("HTTPS_PROXY", f.Proxy.HTTPS)
+	set("NO_PROXY", f.Proxy.NoProxy)
+	set("HARDCOVER_API_URL", f.Hardcover.APIURL)
+	set("HARDCOVER_API_KEY", f.Hardcover.APIKey)
+	return env
+}
+
+// Sources of a setting's value
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+var state struct {
+	mutex   sync.RWMutex
+	path    string          // The file read, or "" if there wasn't one
+	err     error           // Why it couldn't be read
+	fromEnv map[string]bool // Variables set in the environment before the file was read
+	applied map[string]bool // Variables set from the file
+}
+
+func init() {
+	Load()
+}
+
+// Load reads the config file and sets the environment variables it stands for that
+// aren't set already. It's called when the package is initialized; calling it again
+// rereads the file, though what's already read the environment won't notice.
+func Load() error {
+	path := Path()
+	fromEnv := map[string]bool{}
+	applied := map[string]bool{}
+
+	err := func() error {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) && os.Getenv("SHELFARR_CONFIG_FILE") == "" {
+			path = ""
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		var file File
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		for name, value := range file.Env() {
+			if _, ok := os.LookupEnv(name); ok && !wasApplied(name) {
+				fromEnv[name] = true
+				continue
+			}
+			os.Setenv(name, value)
+			applied[name] = true
+		}
+		return nil
+	}()
+
+	state.mutex.Lock()
+	state.path, state.err = path, err
+	state.fromEnv, state.applied = fromEnv, applied
+	state.mutex.Unlock()
+	return err
+}
+
+// Path returns where the config file is read from
+func Path() string {
+	if path := os.Getenv("SHELFARR_CONFIG_FILE"); path != "" {
+		return path
+	}
+	dir := os.Getenv("SHELFARR_CONFIG_PATH")
+	if dir == "" {
+		dir = "/config"
+	}
+	return filepath.Join(dir, "config.yml")
+}
+
+// Loaded returns the config file that was read, or "" if there wasn't one, and the
+// error if it couldn't be read
+func Loaded() (string, error) {
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	return state.path, state.err
+}
+
+// Source reports where an environment variable's value came from: the environment,
+// the config file, or neither, in which case the default applies
+func Source(name string) string {
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	switch {
+	case state.applied[name]:
+		return SourceFile
+	case state.fromEnv[name]:
+		return SourceEnv
+	}
+	if _, ok := os.LookupEnv(name); ok {
+		return SourceEnv
+	}
+	return SourceDefault
+}
+
+func wasApplied(name string) bool {
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	return state.applied[name]
+}
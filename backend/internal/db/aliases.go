@@ -0,0 +1,114 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuthorAlias is a name an author is known by: their own name, a pen name, or another
+// spelling of it. Names are matched normalized, so "J.R.R. Tolkien" and "J. R. R.
+// Tolkien" are one alias, and each normalized name belongs to one author.
+type AuthorAlias struct {
+	gorm.Model
+	AuthorID       uint   `gorm:"index;not null"`
+	Name           string // As first seen
+	NormalizedName string `gorm:"uniqueIndex"`
+}
+
+// NormalizeAuthorName reduces a name to the form aliases are matched in: lower case,
+// without punctuation, and with initials run together
+func NormalizeAuthorName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var parts []string
+	initials := ""
+	for _, word := range words {
+		if len([]rune(word)) == 1 {
+			initials += word
+			continue
+		}
+		if initials != "" {
+			parts = append(parts, initials)
+			initials = ""
+		}
+		parts = append(parts, word)
+	}
+	if initials != "" {
+		parts = append(parts, initials)
+	}
+	return strings.Join(parts, " ")
+}
+
+// AddAuthorAliases records names for an author. Names already belonging to another
+// author are left with them.
+func AddAuthorAliases(db *gorm.DB, authorID uint, names ...string) error {
+	for _, name := range names {
+		normalized := NormalizeAuthorName(name)
+		if normalized == "" {
+			continue
+		}
+		alias := AuthorAlias{AuthorID: authorID, Name: strings.TrimSpace(name), NormalizedName: normalized}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindAuthorByName returns the library author known by a name, along with whether the
+// name is one of their aliases rather than their own name. Returns
+// gorm.ErrRecordNotFound when no author has the name.
+func FindAuthorByName(db *gorm.DB, name string) (*Author, bool, error) {
+	normalized := NormalizeAuthorName(name)
+	if normalized == "" {
+		return nil, false, gorm.ErrRecordNotFound
+	}
+
+	var author Author
+	err := db.Joins("JOIN author_aliases ON author_aliases.author_id = authors.id AND author_aliases.deleted_at IS NULL").
+		Where("author_aliases.normalized_name = ?", normalized).
+		First(&author).Error
+	if err != nil {
+		return nil, false, err
+	}
+	return &author, NormalizeAuthorName(author.Name) != normalized, nil
+}
+
+// BackfillAuthorAliases records the names of authors that predate the aliases table
+func BackfillAuthorAliases(db *gorm.DB) error {
+	var authors []Author
+	err := db.Where("id NOT IN (?)", db.Model(&AuthorAlias{}).Select("author_id")).
+		Find(&authors).Error
+	if err != nil {
+		return err
+	}
+
+	for _, author := range authors {
+		if err := AddAuthorAliases(db, author.ID, author.names()...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// names returns an author's own name followed by their alternate names from Hardcover
+func (a *Author) names() []string {
+	names := []string{a.Name}
+	if a.AlternateNames != "" {
+		var alternates []string
+		json.Unmarshal([]byte(a.AlternateNames), &alternates)
+		names = append(names, alternates...)
+	}
+	return names
+}
+
+// AfterCreate records a new author's names as aliases, whichever path added them
+func (a *Author) AfterCreate(tx *gorm.DB) error {
+	return AddAuthorAliases(tx.Session(&gorm.Session{NewDB: true}), a.ID, a.names()...)
+}
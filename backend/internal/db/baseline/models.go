@@ -0,0 +1,700 @@
+// Package baseline freezes the models as they were when versioned migrations began.
+// Migration 1 brings databases from before then up to this schema, and the migrations
+// after it change the schema from here, so these must never change: change the models
+// in package db, with a new migration, instead.
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookStatus represents the current state of a book in the library
+type BookStatus string
+
+const (
+	StatusMissing     BookStatus = "missing"
+	StatusDownloading BookStatus = "downloading"
+	StatusDownloaded  BookStatus = "downloaded"
+	StatusUnmonitored BookStatus = "unmonitored"
+	StatusUnreleased  BookStatus = "unreleased"
+)
+
+// MediaType distinguishes between ebooks and audiobooks
+type MediaType string
+
+const (
+	MediaTypeEbook     MediaType = "ebook"
+	MediaTypeAudiobook MediaType = "audiobook"
+)
+
+// ContributorRole defines the type of contribution to a book
+type ContributorRole string
+
+const (
+	RoleAuthor      ContributorRole = "Author"
+	RoleNarrator    ContributorRole = "Narrator"
+	RoleEditor      ContributorRole = "Editor"
+	RoleIllustrator ContributorRole = "Illustrator"
+	RoleTranslator  ContributorRole = "Translator"
+	RoleContributor ContributorRole = "Contributor" // Generic fallback
+)
+
+// Author represents a book author
+type Author struct {
+	gorm.Model
+	HardcoverID string `gorm:"uniqueIndex:idx_authors_hardcover_id,where:hardcover_id <> ''"` // Empty for authors from fallback providers
+	Name        string `gorm:"index"`
+	SortName    string
+	Biography   string `gorm:"type:text"`
+	ImageURL    string
+	CustomImage string // Photo picked or uploaded by the user, shown instead of ImageURL
+	Slug        string `gorm:"index"` // URL-friendly identifier
+
+	// Biographical info from Hardcover
+	BornDate  *time.Time
+	BornYear  *int
+	DeathDate *time.Time
+	DeathYear *int
+	Location  string
+
+	// Diversity metadata (pointers to distinguish null from false)
+	GenderID *int
+	IsBIPOC  *bool
+	IsLGBTQ  *bool
+
+	// Alternate names (JSON array stored as string)
+	AlternateNames string `gorm:"type:text"` // JSON: ["Pen Name", "Pseudonym"]
+
+	// Monitoring
+	Monitored bool `gorm:"default:false"`
+
+	// Relationships
+	Books         []Book
+	Contributions []Contributor // All contributions by this author
+
+	// Cached metadata from Hardcover
+	TotalBooksCount int        `gorm:"default:0"` // Total books by author from Hardcover
+	CachedAt        *time.Time // When Hardcover data was last cached
+}
+
+// Series represents a book series
+type Series struct {
+	gorm.Model
+	HardcoverID string `gorm:"uniqueIndex:idx_series_hardcover_id,where:hardcover_id <> ''"` // Empty for series imported from Calibre
+	Name        string `gorm:"index"`
+	Slug        string `gorm:"index"` // URL-friendly identifier
+	Description string `gorm:"type:text"`
+
+	// Series metadata from Hardcover
+	IsCompleted       *bool // Pointer to distinguish null from false
+	PrimaryBooksCount int   `gorm:"default:0"` // Main entries only (excludes novellas, etc.)
+
+	// Primary author (optional - some series have multiple authors)
+	AuthorID *uint
+	Author   *Author
+
+	// Relationships
+	Books []Book
+
+	// Cached metadata from Hardcover
+	TotalBooksCount int        `gorm:"default:0"` // Total books in series from Hardcover
+	CachedAt        *time.Time // When Hardcover data was last cached
+}
+
+// Publisher represents a book publisher
+type Publisher struct {
+	gorm.Model
+	HardcoverID string `gorm:"uniqueIndex"`
+	Name        string `gorm:"index"`
+}
+
+// Genre represents a book genre/tag extracted from Hardcover's cached_tags
+type Genre struct {
+	gorm.Model
+	Name  string  `gorm:"uniqueIndex"` // "Fantasy", "Science Fiction", "Romance"
+	Slug  string  `gorm:"uniqueIndex"` // URL-friendly: "science-fiction"
+	Books []*Book `gorm:"many2many:book_genres;"`
+}
+
+// Book represents a book entry in the library
+type Book struct {
+	gorm.Model
+	HardcoverID string `gorm:"uniqueIndex:idx_books_hardcover_id,where:hardcover_id <> ''"` // Empty for books from fallback providers
+	Title       string `gorm:"index"`
+	SortTitle   string
+	Subtitle    string // Book subtitle
+	Headline    string // Short marketing tagline
+	Slug        string `gorm:"index"` // URL-friendly identifier
+
+	// Identifiers (from primary/default edition for quick access)
+	ISBN   string `gorm:"index"`
+	ISBN13 string `gorm:"index"`
+
+	// IDs from fallback metadata providers, for books not found on Hardcover
+	OpenLibraryWorkID string `gorm:"index"`
+	GoogleVolumeID    string `gorm:"index"`
+
+	// Core metadata
+	Description  string `gorm:"type:text"`
+	CoverURL     string
+	CustomCover  string // Cover picked or uploaded by the user, shown instead of CoverURL and kept on refresh
+	Rating       float32
+	RatingsCount int // Number of ratings on Hardcover
+	ReviewsCount int // Number of reviews on Hardcover
+	ReleaseDate  *time.Time
+	ReleaseYear  int // For quick filtering
+	PageCount    int
+
+	// Primary language (from preferred/default edition)
+	LanguageCode string `gorm:"index;size:5"` // ISO 639-1: "en", "es", "fr"
+	Language     string // Full name: "English", "Spanish"
+
+	// Audio info (from audiobook editions)
+	AudioDuration int // Seconds - from longest audiobook edition
+
+	// Format availability flags (computed from editions)
+	HasEbook     bool `gorm:"index;default:false"`
+	HasAudiobook bool `gorm:"index;default:false"`
+	HasPhysical  bool `gorm:"index;default:false"`
+
+	// Edition counts
+	EditionCount          int `gorm:"default:0"`
+	EbookEditionCount     int `gorm:"default:0"`
+	AudiobookEditionCount int `gorm:"default:0"`
+	PhysicalEditionCount  int `gorm:"default:0"`
+
+	// Classification
+	LiteraryType string // "Novel", "Novella", "Short Story", "Poetry"
+	Category     string // "Fiction", "Non-fiction"
+	Compilation  bool   `gorm:"default:false"` // Is anthology/collection
+
+	// Primary Author (first contributor with Role=Author)
+	AuthorID uint
+	Author   Author
+
+	// Series relationship
+	SeriesID    *uint
+	Series      *Series
+	SeriesIndex *float32
+
+	// Root folders the book's files are imported into; nil uses the default for the media type
+	EbookRootFolderID     *uint
+	AudiobookRootFolderID *uint
+
+	// Many-to-many relationships
+	Genres       []*Genre         `gorm:"many2many:book_genres;"`
+	Contributors []Contributor    // All contributors (authors, narrators, etc.)
+	Editions     []Edition        // All editions
+	Identifiers  *BookIdentifiers // IDs at every metadata provider
+
+	// Status tracking
+	Status    BookStatus `gorm:"default:'missing'"`
+	Monitored bool       `gorm:"default:true"`
+
+	// Media files (downloaded content)
+	MediaFiles []MediaFile
+
+	// Sync tracking
+	LastSyncedAt *time.Time // When metadata was last refreshed from Hardcover
+	LockedFields string     `gorm:"type:text"` // JSON: ["title", "genres"] - edited by hand, left alone by refreshes
+}
+
+// BookIdentifiers holds a book's IDs across metadata providers, so a book found
+// through any provider can be matched to the library and deduplicated
+type BookIdentifiers struct {
+	gorm.Model
+	BookID         uint   `gorm:"uniqueIndex;not null"`
+	HardcoverID    string `gorm:"index"`
+	OLWorkID       string `gorm:"index"` // Open Library work key ("OL45804W")
+	OLEditionID    string `gorm:"index"` // Open Library edition key ("OL7353617M")
+	ISBN10         string `gorm:"index"`
+	ISBN13         string `gorm:"index"`
+	ASIN           string `gorm:"index"`
+	GoodreadsID    string `gorm:"index"`
+	GoogleVolumeID string `gorm:"index"`
+	CalibreUUID    string `gorm:"index"` // Book UUID in the Calibre library it was imported from
+}
+
+// Edition represents a specific edition of a book from Hardcover
+// Each book can have multiple editions (Kindle, Hardcover, Audiobook, translations, etc.)
+type Edition struct {
+	gorm.Model
+
+	// Hardcover reference
+	HardcoverID string `gorm:"uniqueIndex"` // Edition ID from Hardcover
+	BookID      uint   `gorm:"index;not null"`
+	Book        Book
+
+	// Identifiers
+	ISBN10 string `gorm:"index"`
+	ISBN13 string `gorm:"index"`
+	ASIN   string `gorm:"index"` // Amazon identifier (Kindle/Audible)
+
+	// Edition-specific metadata
+	Title         string // May differ from parent book (e.g., translated title)
+	Subtitle      string
+	EditionFormat string // Free-text: "Kindle Edition", "Hardcover", "Mass Market Paperback"
+
+	// Format classification (enumerated, reliable)
+	Format string `gorm:"index;size:20"` // "Physical", "Ebook", "Audiobook"
+
+	// Language
+	LanguageCode string `gorm:"index;size:5"` // ISO 639-1: "en", "es", "fr"
+	Language     string // Full name: "English", "Spanish"
+
+	// Publisher
+	PublisherID   *uint
+	Publisher     *Publisher
+	PublisherName string // Denormalized for quick display
+
+	// Physical/Audio attributes
+	PageCount    int
+	AudioSeconds int    // For audiobooks - duration in seconds
+	Narrators    string // For audiobooks - comma-separated, from Audible
+	Abridged     bool   // For audiobooks - from Audible
+
+	// Release info (edition-specific)
+	ReleaseDate *time.Time
+
+	// Cover image (edition-specific - may differ from book cover)
+	CoverURL string
+}
+
+// Contributor represents a person's contribution to a book
+// Maps to Hardcover's "contributions" relationship
+type Contributor struct {
+	gorm.Model
+	BookID   uint `gorm:"index;not null"`
+	Book     Book
+	AuthorID uint `gorm:"index;not null"`
+	Author   Author
+	Role     ContributorRole `gorm:"index;size:20"`
+	Position int             `gorm:"default:0"` // Hardcover's position order (0 = primary)
+}
+
+// MediaFile represents a physical file (ebook or audiobook)
+type MediaFile struct {
+	gorm.Model
+	BookID uint
+	Book   Book
+
+	// File info
+	FilePath  string `gorm:"uniqueIndex"`
+	FileName  string
+	FileSize  int64
+	Format    string // epub, pdf, m4b, mp3, etc.
+	MediaType MediaType
+
+	// Quality info (for audiobooks)
+	Bitrate  int
+	Duration int // seconds
+
+	// Pages, for PDFs and comics
+	PageCount int
+
+	// Edition info
+	EditionName string // "US Edition", "Narrator A", etc.
+
+	// Tracking
+	ImportedAt   time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"` // Soft delete for recycle bin
+	RecycledFrom string         // Where the file was before it moved to the recycle bin (FilePath is then in the bin)
+}
+
+// User represents an application user
+type User struct {
+	gorm.Model
+	Username     string `gorm:"uniqueIndex"`
+	PasswordHash string
+	Email        string
+	IsAdmin      bool `gorm:"default:false"`
+	CanRead      bool `gorm:"default:true"`
+	CanDelete    bool `gorm:"default:false"`
+
+	// Email preferences
+	WeeklyDigest bool   `gorm:"default:false"` // Receive the weekly "new in your library" email
+	KindleEmail  string // Send to Kindle address (@kindle.com)
+
+	// SSO support
+	RemoteUser string `gorm:"index"` // For header-based auth
+
+	// Reading progress
+	ReadProgress []ReadProgress
+}
+
+// ReadProgress tracks user progress through media
+type ReadProgress struct {
+	gorm.Model
+	UserID      uint
+	User        User
+	MediaFileID uint
+	MediaFile   MediaFile
+
+	// Progress tracking
+	Progress   float32 // 0.0 - 1.0 (percentage for ebooks, timestamp ratio for audio)
+	Position   int     // Page number or seconds
+	Location   string  // Reader-specific position, such as a Kobo bookmark (JSON)
+	LastReadAt time.Time
+}
+
+// Device represents a user's e-reader, which accepts books by email or (Kobo) syncs them
+type Device struct {
+	gorm.Model
+	UserID          uint   `gorm:"index;not null"`
+	Name            string // "Paperwhite", "Kobo Libra"
+	Type            string // kindle, kobo, pocketbook, other
+	Email           string
+	PreferredFormat string // epub, kepub, azw3, mobi, pdf
+	KoboSyncToken   string `gorm:"index"` // Secret in a Kobo's sync URL; empty until Kobo sync is enabled
+}
+
+// SendHistory records each attempt to email a media file to an e-reader
+type SendHistory struct {
+	gorm.Model
+	MediaFileID uint `gorm:"index"`
+	MediaFile   MediaFile
+	UserID      uint  `gorm:"index"`
+	DeviceID    *uint `gorm:"index"` // Nil when sent to the user's Kindle email setting
+
+	ToEmail   string
+	FileName  string
+	Format    string // Format actually sent (after conversion)
+	FileSize  int64
+	Converted bool
+	Status    string // sent, failed
+	Error     string
+}
+
+// Collection is a user's own shelf of books, such as "Summer reading" or "DNF",
+// kept apart from monitored Hardcover lists
+type Collection struct {
+	gorm.Model
+	UserID      uint `gorm:"index;not null"`
+	Name        string
+	Description string
+	OPDS        bool `gorm:"column:opds;default:false"` // Listed in the user's OPDS catalog
+	Books       []CollectionBook
+}
+
+// CollectionBook places a book in a collection
+type CollectionBook struct {
+	ID           uint `gorm:"primarykey"`
+	CollectionID uint `gorm:"uniqueIndex:idx_collection_book"`
+	BookID       uint `gorm:"uniqueIndex:idx_collection_book;index"`
+	Book         Book
+	Position     int // Order within the collection, from 0
+	CreatedAt    time.Time
+}
+
+// Indexer represents a configured search indexer
+type Indexer struct {
+	gorm.Model
+	Name       string
+	Type       string // "torznab", "newznab", "mam", "anna", "libgen", "audiobookbay"
+	URL        string // Comma-separated search mirrors for LibGen
+	APIKey     string // Torznab/Newznab API key, or an Anna's Archive member key
+	Cookie     string // For MAM
+	Categories string // Comma-separated Newznab category IDs offered (set by Prowlarr); limits the mapping below
+	Priority   int    `gorm:"default:0"`
+	Enabled    bool   `gorm:"default:true"`
+
+	// Torznab capabilities, detected from t=caps when the indexer is saved
+	SupportedCategories string // JSON list of the indexer's categories
+	SearchModes         string // Comma-separated search functions (search, book-search)
+	EbookCategories     string // Comma-separated categories searched for ebooks
+	AudiobookCategories string // Comma-separated categories searched for audiobooks
+
+	// MAM-specific
+	VIPOnly       bool `gorm:"default:false"`
+	FreeleechOnly bool `gorm:"default:false"`
+
+	// Seeding goals for torrents from this indexer; zero uses the download client's
+	SeedRatio float64
+	SeedTime  int // Minutes
+}
+
+// DownloadClient represents a configured download client
+type DownloadClient struct {
+	gorm.Model
+	Name     string `json:"name"`
+	Type     string `json:"type"` // "qbittorrent", "transmission", "deluge", "sabnzbd", "nzbget", "rtorrent", "direct", "aria2", "synology"
+	URL      string `json:"url"`  // Download folder for direct clients
+	Username string `json:"username"`
+	Password string `json:"password"` // API key for SABnzbd, RPC secret for aria2
+	Category string `json:"category"`
+	Priority int    `json:"priority" gorm:"default:0"`
+	Enabled  bool   `json:"enabled" gorm:"default:true"`
+	Settings string `json:"settings" gorm:"type:text"` // JSON for extra settings (SSL, port, seedbox type, path mappings, maxConcurrent for direct)
+
+	// Ebooks and audiobooks can each have their own category and save path. Empty values
+	// fall back to Category and the client's default folder.
+	EbookCategory     string `json:"ebookCategory"`
+	AudiobookCategory string `json:"audiobookCategory"`
+	EbookSavePath     string `json:"ebookSavePath"`
+	AudiobookSavePath string `json:"audiobookSavePath"`
+
+	// Torrents are removed from the client once either goal is met; zero means no goal.
+	// Indexers can override them.
+	SeedRatio         float64 `json:"seedRatio"`
+	SeedTime          int     `json:"seedTime"`          // Minutes
+	DeleteSeededFiles bool    `json:"deleteSeededFiles"` // Delete the downloaded data when removing
+}
+
+// QualityProfile defines format/quality preferences
+type QualityProfile struct {
+	gorm.Model
+	Name      string
+	MediaType MediaType
+
+	// For ebooks: comma-separated format ranking (e.g., "epub,azw3,mobi,pdf")
+	// For audiobooks: comma-separated format ranking (e.g., "m4b,mp3")
+	FormatRanking string
+
+	// Audiobook specific
+	MinBitrate int `gorm:"default:0"` // Minimum acceptable bitrate
+
+	// Ebook specific: rewrite imported EPUBs' metadata and cover to match the library
+	EmbedMetadata bool `gorm:"default:false"`
+}
+
+// Notification represents a notification configuration
+type Notification struct {
+	gorm.Model
+	Name    string
+	Type    string // "webhook", "discord", "telegram", "email", "ntfy", "gotify", "pushover", "script"
+	Enabled bool   `gorm:"default:true"`
+
+	// Connection settings
+	WebhookURL       string
+	DiscordWebhook   string
+	TelegramBotToken string
+	TelegramChatID   string
+	EmailTo          string
+
+	// Push provider settings
+	NtfyServerURL    string // Defaults to https://ntfy.sh
+	NtfyTopic        string
+	NtfyToken        string // Optional access token for protected topics
+	GotifyServerURL  string
+	GotifyAppToken   string
+	PushoverUserKey  string
+	PushoverAppToken string
+
+	// Custom script run with the event in its environment
+	ScriptPath string
+
+	// Triggers
+	OnGrab        bool `gorm:"default:false"`
+	OnDownload    bool `gorm:"default:true"`
+	OnUpgrade     bool `gorm:"default:false"`
+	OnImport      bool `gorm:"default:true"`
+	OnDelete      bool `gorm:"default:false"`
+	OnHealthIssue bool `gorm:"default:true"`
+	OnRelease     bool `gorm:"default:false"` // Monitored book reached its release date
+	OnFailure     bool `gorm:"default:false"` // Download or import failed
+	OnUpdate      bool `gorm:"default:false"` // A new Shelfarr release is available
+}
+
+// MediaServer is a media server connection whose library is scanned when files change
+type MediaServer struct {
+	gorm.Model
+	Name    string
+	Type    string // "plex", "jellyfin", "kavita", "komga"
+	Enabled bool   `gorm:"default:true"`
+	URL     string
+	Token   string // Plex token, Jellyfin/Kavita/Komga API key
+
+	// Triggers
+	OnImport  bool `gorm:"default:true"`
+	OnUpgrade bool `gorm:"default:true"`
+}
+
+// Hardcover list sources
+const (
+	ListSourceList       = "list"         // A public Hardcover list, by ID
+	ListSourceWantToRead = "want_to_read" // The API key owner's Want to Read shelf
+)
+
+// HardcoverList represents a monitored Hardcover.app list
+type HardcoverList struct {
+	gorm.Model
+	Name            string
+	Source          string `gorm:"default:list"`
+	HardcoverURL    string `gorm:"uniqueIndex"`
+	HardcoverID     string `gorm:"index"`
+	Enabled         bool   `gorm:"default:true"`
+	Monitor         bool   `gorm:"default:true"`
+	AutoAdd         bool   `gorm:"default:true"`
+	SyncIntervalHrs int    `gorm:"default:6"`
+	QualityProfile  uint
+	LastSyncedAt    *time.Time
+}
+
+// Download represents an active or completed download
+type Download struct {
+	gorm.Model
+	BookID       uint   `gorm:"index"`
+	ClientID     uint   `gorm:"index"`
+	ClientType   string // qbittorrent, transmission, sabnzbd, etc.
+	ExternalID   string `gorm:"index"` // Hash for torrents, NZB ID for usenet
+	InfoHash     string `gorm:"index"` // Torrent info hash (lowercase hex), worked out when grabbed
+	MediaType    string // ebook or audiobook - allows both types per book
+	Indexer      string // Name of the indexer the release was grabbed from
+	Title        string
+	DownloadURL  string
+	OutputPath   string
+	Size         int64
+	Downloaded   int64
+	Progress     float64
+	Status       string `gorm:"default:'queued'"` // queued, downloading, paused, completed, failed, importing, ignored
+	Category     string
+	ErrorMessage string
+	AddedAt      int64
+	CompletedAt  int64
+	Removed      bool // Removed from the download client once seeding goals were met
+}
+
+// BlocklistItem is a release that won't be grabbed for a book again, because it failed
+// or turned out to be the wrong book
+type BlocklistItem struct {
+	gorm.Model
+	BookID      uint `gorm:"index"`
+	Title       string
+	Indexer     string
+	DownloadURL string
+	InfoHash    string // Lowercase hex, for torrents
+	Reason      string
+}
+
+// Job represents a long-running background task such as a format conversion.
+// Jobs are persisted so queued work survives a restart.
+type Job struct {
+	gorm.Model
+	Type        string  `gorm:"index"`                  // ebook_convert, audiobook_merge, etc.
+	Status      string  `gorm:"index;default:'queued'"` // queued, running, completed, failed, cancelled
+	Progress    float64 // 0-100
+	Message     string  // Current step, for display
+	Payload     string  `gorm:"type:text"` // JSON input for the job handler
+	Result      string  `gorm:"type:text"` // JSON output from the job handler
+	Error       string
+	Attempts    int
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// AuditEntry records a change a user made, such as adding a book, changing settings
+// or grabbing a release, and failed logins
+type AuditEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	UserID    uint      `gorm:"index"` // 0 when unknown, as for failed logins
+	Username  string    // As it was at the time, so entries outlive renamed and deleted users
+	Action    string    `gorm:"index"` // Such as book.add, settings.update or download.grab
+	Summary   string    // What was done, for display
+	Method    string
+	Path      string // Request path, with IDs
+	Status    int
+	IP        string
+}
+
+// Setting represents a key-value configuration setting stored in the database
+type Setting struct {
+	Key   string `gorm:"primaryKey"`
+	Value string
+}
+
+// CacheEntry is a cached metadata API response
+type CacheEntry struct {
+	Key       string `gorm:"primaryKey"`
+	Value     []byte
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// RootFolder represents a configured media library root folder
+type RootFolder struct {
+	gorm.Model
+	Path       string    `gorm:"uniqueIndex"`
+	MediaType  MediaType // ebook or audiobook
+	Name       string    // Optional display name
+	IsDefault  bool      // Books not assigned a root folder are imported into the default for their media type
+	FreeSpace  int64     `gorm:"-"` // Calculated at runtime, not stored
+	TotalSpace int64     `gorm:"-"` // Calculated at runtime, not stored
+
+	// How files are imported here: move, copy, hardlink or reflink. Empty uses the
+	// media management setting.
+	ImportOperation string
+
+	// Audiobookshelf integration for audiobooks imported here
+	AudiobookshelfMode      string // "" (off), "scan" or "upload"
+	AudiobookshelfLibraryID string
+	AudiobookshelfFolderID  string // Library folder uploads go to
+}
+
+// AuthorAlias is a name an author is known by: their own name, a pen name, or another
+// spelling of it. Names are matched normalized, so "J.R.R. Tolkien" and "J. R. R.
+// Tolkien" are one alias, and each normalized name belongs to one author.
+type AuthorAlias struct {
+	gorm.Model
+	AuthorID       uint   `gorm:"index;not null"`
+	Name           string // As first seen
+	NormalizedName string `gorm:"uniqueIndex"`
+}
+
+// SavedFilter is a named library view, such as "Unread fantasy audiobooks", whose rules
+// are applied by the server
+type SavedFilter struct {
+	gorm.Model
+	UserID uint `gorm:"index;not null"`
+	Name   string
+	Rules  string // FilterRules as JSON
+}
+
+// Tag is a user-defined label on books, authors, indexers and quality profiles. Tags
+// scope indexers and profiles: a tagged indexer is only searched for books sharing one
+// of its tags, and a tagged profile is preferred for them. A book has its own tags
+// and its author's.
+type Tag struct {
+	gorm.Model
+	Name            string            `gorm:"uniqueIndex"`
+	Books           []*Book           `gorm:"many2many:book_tags;"`
+	Authors         []*Author         `gorm:"many2many:author_tags;"`
+	Indexers        []*Indexer        `gorm:"many2many:indexer_tags;"`
+	QualityProfiles []*QualityProfile `gorm:"many2many:quality_profile_tags;"`
+}
+
+// Models are the tables of the baseline schema
+var Models = []interface{}{
+	&Author{},
+	&AuthorAlias{},
+	&Series{},
+	&Publisher{},
+	&Genre{},
+	&Book{},
+	&BookIdentifiers{},
+	&Edition{},
+	&Contributor{},
+	&MediaFile{},
+	&User{},
+	&ReadProgress{},
+	&Device{},
+	&SendHistory{},
+	&Collection{},
+	&CollectionBook{},
+	&Indexer{},
+	&DownloadClient{},
+	&QualityProfile{},
+	&Notification{},
+	&MediaServer{},
+	&HardcoverList{},
+	&Download{},
+	&BlocklistItem{},
+	&Job{},
+	&Setting{},
+	&CacheEntry{},
+	&RootFolder{},
+	&Tag{},
+	&SavedFilter{},
+	&AuditEntry{},
+}
@@ -1,43 +1,184 @@
 package db
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// Initialize creates and configures the database connection
+// Database drivers
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Options configures the database connection
+type Options struct {
+	Driver string // sqlite (the default) or postgres
+	// For sqlite, the database file. For postgres, a connection string such as
+	// "host=db user=shelfarr password=secret dbname=shelfarr" or a postgres:// URL.
+	DSN string
+
+	// Connection pool; zero values keep database/sql's defaults
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// dialectors open a connection for each compiled-in driver. PostgreSQL is added by
+// postgres.go, which is only built with the postgres build tag.
+var dialectors = map[string]func(dsn string) gorm.Dialector{
+	DriverSQLite: func(dsn string) gorm.Dialector {
+		// WAL mode for concurrent reads/writes, waiting up to 5s for a lock rather than
+		// failing, and foreign keys enforced on every connection
+		return sqlite.Open(dsn + "?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=1")
+	},
+}
+
+// Initialize creates and configures the database connection. The SQLite file at dbPath
+// is used unless SHELFARR_DB_DRIVER picks another driver; see OptionsFromEnv.
 func Initialize(dbPath string) (*gorm.DB, error) {
-	// SQLite with WAL mode for concurrent reads/writes
-	db, err := gorm.Open(sqlite.Open(dbPath+"?_journal_mode=WAL&_busy_timeout=5000"), &gorm.Config{
+	opts, err := OptionsFromEnv(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	return Open(opts)
+}
+
+// OptionsFromEnv reads the database options from the environment:
+// SHELFARR_DB_DRIVER (sqlite or postgres), SHELFARR_DB_DSN (required for postgres),
+// SHELFARR_DB_MAX_OPEN_CONNS, SHELFARR_DB_MAX_IDLE_CONNS and
+// SHELFARR_DB_CONN_MAX_LIFETIME (a duration such as "30m"). SQLite uses dbPath
+// unless SHELFARR_DB_DSN is set.
+func OptionsFromEnv(dbPath string) (Options, error) {
+	opts := Options{
+		Driver: strings.ToLower(os.Getenv("SHELFARR_DB_DRIVER")),
+		DSN:    os.Getenv("SHELFARR_DB_DSN"),
+	}
+	if opts.Driver == "" {
+		opts.Driver = DriverSQLite
+	}
+	if opts.Driver == DriverSQLite && opts.DSN == "" {
+		opts.DSN = dbPath
+	}
+
+	for name, target := range map[string]*int{
+		"SHELFARR_DB_MAX_OPEN_CONNS": &opts.MaxOpenConns,
+		"SHELFARR_DB_MAX_IDLE_CONNS": &opts.MaxIdleConns,
+	} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return opts, fmt.Errorf("%s must be a whole number", name)
+			}
+			*target = n
+		}
+	}
+	if value := os.Getenv("SHELFARR_DB_CONN_MAX_LIFETIME"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return opts, fmt.Errorf("SHELFARR_DB_CONN_MAX_LIFETIME must be a duration such as 30m: %w", err)
+		}
+		opts.ConnMaxLifetime = d
+	}
+	return opts, nil
+}
+
+// Open connects to the database described by opts
+func Open(opts Options) (*gorm.DB, error) {
+	dialector, ok := dialectors[opts.Driver]
+	if !ok {
+		return nil, fmt.Errorf("database driver %q isn't available; use sqlite, or build with -tags postgres for postgres", opts.Driver)
+	}
+	if opts.DSN == "" {
+		return nil, fmt.Errorf("no database connection string set for %s", opts.Driver)
+	}
+
+	db, err := gorm.Open(dialector(opts.DSN), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+
 	return db, nil
 }
 
+// IsSQLite reports whether db is a SQLite database
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverSQLite
+}
+
+// Like returns the operator for a case-insensitive LIKE. SQLite's LIKE ignores case
+// for ASCII already; PostgreSQL's doesn't.
+func Like(db *gorm.DB) string {
+	if db.Dialector.Name() == DriverPostgres {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// Migrate brings the schema up to date by applying any migrations it hasn't had yet
+// (see migrations.go) and creating the search index. On SQLite, foreign keys are turned off while it
+// runs, since changing a column rebuilds its table.
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&Author{},
-		&Series{},
-		&Publisher{},
-		&Genre{},
-		&Book{},
-		&Edition{},
-		&Contributor{},
-		&MediaFile{},
-		&User{},
-		&ReadProgress{},
-		&Indexer{},
-		&DownloadClient{},
-		&QualityProfile{},
-		&Notification{},
-		&HardcoverList{},
-		&Download{},
-		&Setting{},
-		&RootFolder{},
-	)
+	if !IsSQLite(db) {
+		return migrate(db)
+	}
+	return db.Connection(func(conn *gorm.DB) error {
+		conn = conn.Session(&gorm.Session{NewDB: true})
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+		return migrate(conn)
+	})
+}
+
+func migrate(db *gorm.DB) error {
+	if err := runMigrations(db); err != nil {
+		return err
+	}
+	return ensureSearchIndex(db)
+}
+
+// dropFullHardcoverIndexes drops the old hardcover_id unique indexes that covered empty
+// IDs, so AutoMigrate recreates them as partial indexes and books, authors and series
+// from fallback metadata providers or Calibre (which have no Hardcover ID) can coexist.
+// Only SQLite databases predate the partial indexes.
+func dropFullHardcoverIndexes(db *gorm.DB) error {
+	if !IsSQLite(db) {
+		return nil
+	}
+	for _, name := range []string{"idx_authors_hardcover_id", "idx_books_hardcover_id", "idx_series_hardcover_id"} {
+		var sql string
+		db.Raw("SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?", name).Scan(&sql)
+		if sql == "" || strings.Contains(strings.ToUpper(sql), "WHERE") {
+			continue
+		}
+		if err := db.Exec("DROP INDEX " + name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SavedFilter is a named library view, such as "Unread fantasy audiobooks", whose rules
+// are applied by the server
+type SavedFilter struct {
+	gorm.Model
+	UserID uint `gorm:"index;not null"`
+	Name   string
+	Rules  string // FilterRules as JSON
+}
+
+// FilterRules are a saved filter's rules and how they combine
+type FilterRules struct {
+	Match string       `json:"match"` // "all" (default) or "any"
+	Rules []FilterRule `json:"rules"`
+}
+
+// FilterRule is one condition on a book.
+//
+//	status     is, isNot       a BookStatus
+//	monitored  is              "true" or "false"
+//	mediaType  has, lacks      "ebook" or "audiobook"
+//	format     has, lacks      a file format, such as "epub" or "m4b"
+//	genre      is, isNot       a genre's name or slug
+//	tag        is, isNot       a tag ID; books share their author's tags
+//	language   is, isNot       an ISO 639-1 code, such as "en"
+//	rating     gte, lte        a Hardcover rating from 0 to 5
+type FilterRule struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+var filterOperators = map[string][]string{
+	"status":    {"is", "isNot"},
+	"monitored": {"is"},
+	"mediaType": {"has", "lacks"},
+	"format":    {"has", "lacks"},
+	"genre":     {"is", "isNot"},
+	"tag":       {"is", "isNot"},
+	"language":  {"is", "isNot"},
+	"rating":    {"gte", "lte"},
+}
+
+// ParseFilterRules decodes and checks a saved filter's rules
+func ParseFilterRules(data string) (FilterRules, error) {
+	var rules FilterRules
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return rules, fmt.Errorf("invalid rules: %w", err)
+	}
+	return rules, rules.Validate()
+}
+
+// Validate checks every rule names a known field, an operator it supports and a
+// usable value
+func (r FilterRules) Validate() error {
+	if r.Match != "" && r.Match != "all" && r.Match != "any" {
+		return fmt.Errorf("match must be all or any")
+	}
+	for _, rule := range r.Rules {
+		operators, ok := filterOperators[rule.Field]
+		if !ok {
+			return fmt.Errorf("unknown field %q", rule.Field)
+		}
+		if !slices.Contains(operators, rule.Operator) {
+			return fmt.Errorf("%s doesn't support %q; use %s", rule.Field, rule.Operator, strings.Join(operators, " or "))
+		}
+		if strings.TrimSpace(rule.Value) == "" {
+			return fmt.Errorf("%s needs a value", rule.Field)
+		}
+		switch rule.Field {
+		case "monitored":
+			if _, err := strconv.ParseBool(rule.Value); err != nil {
+				return fmt.Errorf("monitored must be true or false")
+			}
+		case "mediaType":
+			if rule.Value != string(MediaTypeEbook) && rule.Value != string(MediaTypeAudiobook) {
+				return fmt.Errorf("mediaType must be ebook or audiobook")
+			}
+		case "tag":
+			if _, err := strconv.ParseUint(rule.Value, 10, 32); err != nil {
+				return fmt.Errorf("tag must be a tag ID")
+			}
+		case "rating":
+			if _, err := strconv.ParseFloat(rule.Value, 32); err != nil {
+				return fmt.Errorf("rating must be a number")
+			}
+		}
+	}
+	return nil
+}
+
+// Scope limits a query on books to those matching the rules. No rules match every book.
+func (r FilterRules) Scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		var conditions []string
+		var args []any
+		for _, rule := range r.Rules {
+			condition, ruleArgs := rule.condition()
+			conditions = append(conditions, "("+condition+")")
+			args = append(args, ruleArgs...)
+		}
+		if len(conditions) == 0 {
+			return db
+		}
+
+		join := " AND "
+		if r.Match == "any" {
+			join = " OR "
+		}
+		return db.Where("("+strings.Join(conditions, join)+")", args...)
+	}
+}
+
+// condition returns the rule as SQL on the books table
+func (r FilterRule) condition() (string, []any) {
+	var condition string
+	var args []any
+	value := strings.TrimSpace(r.Value)
+
+	switch r.Field {
+	case "status":
+		condition, args = "books.status = ?", []any{value}
+	case "monitored":
+		monitored, _ := strconv.ParseBool(value)
+		condition, args = "books.monitored = ?", []any{monitored}
+	case "mediaType":
+		condition = "EXISTS (SELECT 1 FROM media_files WHERE media_files.book_id = books.id AND media_files.deleted_at IS NULL AND media_files.media_type = ?)"
+		args = []any{value}
+	case "format":
+		condition = "EXISTS (SELECT 1 FROM media_files WHERE media_files.book_id = books.id AND media_files.deleted_at IS NULL AND LOWER(media_files.format) = ?)"
+		args = []any{strings.ToLower(strings.TrimPrefix(value, "."))}
+	case "genre":
+		condition = "books.id IN (SELECT book_genres.book_id FROM book_genres JOIN genres ON genres.id = book_genres.genre_id WHERE genres.slug = ? OR LOWER(genres.name) = LOWER(?))"
+		args = []any{value, value}
+	case "tag":
+		tagID, _ := strconv.ParseUint(value, 10, 32)
+		condition = "books.id IN (SELECT book_id FROM book_tags WHERE tag_id = ?) OR books.author_id IN (SELECT author_id FROM author_tags WHERE tag_id = ?)"
+		args = []any{tagID, tagID}
+	case "language":
+		condition, args = "LOWER(COALESCE(books.language_code, '')) = LOWER(?)", []any{value}
+	case "rating":
+		rating, _ := strconv.ParseFloat(value, 32)
+		if r.Operator == "lte" {
+			condition = "books.rating <= ?"
+		} else {
+			condition = "books.rating >= ?"
+		}
+		args = []any{rating}
+	}
+
+	if r.Operator == "isNot" || r.Operator == "lacks" {
+		condition = "NOT (" + condition + ")"
+	}
+	return condition, args
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// IdentifierColumns lists the BookIdentifiers columns used for matching
+var IdentifierColumns = []string{
+	"hardcover_id", "ol_work_id", "ol_edition_id", "isbn10", "isbn13",
+	"asin", "goodreads_id", "google_volume_id", "calibre_uuid",
+}
+
+// Values returns the non-empty identifiers keyed by column name
+func (ids *BookIdentifiers) Values() map[string]string {
+	values := map[string]string{
+		"hardcover_id":     ids.HardcoverID,
+		"ol_work_id":       ids.OLWorkID,
+		"ol_edition_id":    ids.OLEditionID,
+		"isbn10":           ids.ISBN10,
+		"isbn13":           ids.ISBN13,
+		"asin":             ids.ASIN,
+		"goodreads_id":     ids.GoodreadsID,
+		"google_volume_id": ids.GoogleVolumeID,
+		"calibre_uuid":     ids.CalibreUUID,
+	}
+	for column, value := range values {
+		if value == "" {
+			delete(values, column)
+		}
+	}
+	return values
+}
+
+// Set stores an identifier by column name. Unknown columns are ignored.
+func (ids *BookIdentifiers) Set(column, value string) {
+	value = strings.TrimSpace(value)
+	switch column {
+	case "hardcover_id":
+		ids.HardcoverID = value
+	case "ol_work_id":
+		ids.OLWorkID = value
+	case "ol_edition_id":
+		ids.OLEditionID = value
+	case "isbn10":
+		ids.ISBN10 = value
+	case "isbn13":
+		ids.ISBN13 = value
+	case "asin":
+		ids.ASIN = value
+	case "goodreads_id":
+		ids.GoodreadsID = value
+	case "google_volume_id":
+		ids.GoogleVolumeID = value
+	case "calibre_uuid":
+		ids.CalibreUUID = value
+	}
+}
+
+// Merge fills identifiers that are empty in ids from other
+func (ids *BookIdentifiers) Merge(other BookIdentifiers) {
+	existing := ids.Values()
+	for column, value := range other.Values() {
+		if _, ok := existing[column]; !ok {
+			ids.Set(column, value)
+		}
+	}
+}
+
+// IdentifiersFromBook collects the identifiers stored on a book and its loaded editions
+func IdentifiersFromBook(book *Book) BookIdentifiers {
+	ids := BookIdentifiers{
+		BookID:         book.ID,
+		HardcoverID:    book.HardcoverID,
+		OLWorkID:       book.OpenLibraryWorkID,
+		GoogleVolumeID: book.GoogleVolumeID,
+		ISBN10:         book.ISBN,
+		ISBN13:         book.ISBN13,
+	}
+	for _, ed := range book.Editions {
+		if ids.ISBN10 == "" {
+			ids.ISBN10 = ed.ISBN10
+		}
+		if ids.ISBN13 == "" {
+			ids.ISBN13 = ed.ISBN13
+		}
+		if ids.ASIN == "" {
+			ids.ASIN = ed.ASIN
+		}
+	}
+	return ids
+}
+
+// SaveBookIdentifiers merges ids into the book's identifiers row, creating it if needed.
+// Identifiers already recorded are kept.
+func SaveBookIdentifiers(db *gorm.DB, bookID uint, ids BookIdentifiers) error {
+	var row BookIdentifiers
+	err := db.Where("book_id = ?", bookID).First(&row).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	row.BookID = bookID
+	row.Merge(ids)
+	return db.Save(&row).Error
+}
+
+// FindBookByIdentifiers returns the library book matching any of the given identifiers.
+// Pass db.Unscoped() to include soft-deleted books. Returns gorm.ErrRecordNotFound when
+// nothing matches.
+func FindBookByIdentifiers(db *gorm.DB, ids BookIdentifiers) (*Book, error) {
+	values := ids.Values()
+	if len(values) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	match := db.Session(&gorm.Session{NewDB: true})
+	first := true
+	for _, column := range IdentifierColumns {
+		value, ok := values[column]
+		if !ok {
+			continue
+		}
+		if first {
+			match = match.Where("book_identifiers."+column+" = ?", value)
+			first = false
+		} else {
+			match = match.Or("book_identifiers."+column+" = ?", value)
+		}
+	}
+
+	var book Book
+	err := db.Joins("JOIN book_identifiers ON book_identifiers.book_id = books.id AND book_identifiers.deleted_at IS NULL").
+		Where(match).
+		First(&book).Error
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// BackfillBookIdentifiers creates identifier rows for books that predate the table
+func BackfillBookIdentifiers(db *gorm.DB) error {
+	var books []Book
+	err := db.Preload("Editions").
+		Where("id NOT IN (?)", db.Model(&BookIdentifiers{}).Select("book_id")).
+		Find(&books).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		if err := SaveBookIdentifiers(db, books[i].ID, IdentifiersFromBook(&books[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AfterCreate records the identifiers of every new book, whichever path added it
+func (b *Book) AfterCreate(tx *gorm.DB) error {
+	return SaveBookIdentifiers(tx.Session(&gorm.Session{NewDB: true}), b.ID, IdentifiersFromBook(b))
+}
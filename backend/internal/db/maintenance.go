@@ -0,0 +1,87 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaintenanceResult is the outcome of a database maintenance run
+type MaintenanceResult struct {
+	Integrity          []string      `json:"integrity"`          // Problems integrity_check found; empty when the database is sound
+	ForeignKeyProblems int           `json:"foreignKeyProblems"` // Rows pointing at rows that don't exist
+	SizeBefore         int64         `json:"sizeBefore"`         // Bytes
+	SizeAfter          int64         `json:"sizeAfter"`
+	Duration           time.Duration `json:"duration"`
+}
+
+// Maintain checks a SQLite database's integrity and foreign keys, refreshes the query
+// planner's statistics with ANALYZE and compacts the file with VACUUM. VACUUM is skipped
+// when the database is damaged, so nothing more is rewritten. Other databases maintain
+// themselves and are left alone.
+func Maintain(db *gorm.DB) (*MaintenanceResult, error) {
+	if !IsSQLite(db) {
+		return nil, nil
+	}
+	start := time.Now()
+	result := &MaintenanceResult{SizeBefore: sqliteSize(db)}
+
+	var integrity []string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&integrity).Error; err != nil {
+		return nil, fmt.Errorf("integrity check failed: %w", err)
+	}
+	for _, line := range integrity {
+		if line != "ok" {
+			result.Integrity = append(result.Integrity, line)
+		}
+	}
+
+	var violations []map[string]interface{}
+	if err := db.Raw("PRAGMA foreign_key_check").Scan(&violations).Error; err != nil {
+		return nil, fmt.Errorf("foreign key check failed: %w", err)
+	}
+	result.ForeignKeyProblems = len(violations)
+
+	if err := db.Exec("ANALYZE").Error; err != nil {
+		return nil, fmt.Errorf("ANALYZE failed: %w", err)
+	}
+	if len(result.Integrity) == 0 {
+		if err := db.Exec("VACUUM").Error; err != nil {
+			return nil, fmt.Errorf("VACUUM failed: %w", err)
+		}
+		db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	}
+
+	result.SizeAfter = sqliteSize(db)
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// sqliteSize returns the size of a SQLite database in bytes
+func sqliteSize(db *gorm.DB) int64 {
+	var pages, pageSize int64
+	db.Raw("PRAGMA page_count").Scan(&pages)
+	db.Raw("PRAGMA page_size").Scan(&pageSize)
+	return pages * pageSize
+}
+
+// DeleteMediaFile removes a media file's record for good, along with the reading progress,
+// annotations and send history that point at it
+func DeleteMediaFile(db *gorm.DB, file *MediaFile) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("media_file_id = ?", file.ID).Delete(&ReadProgress{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("media_file_id = ?", file.ID).Delete(&DeviceProgress{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("media_file_id = ?", file.ID).Delete(&Annotation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("media_file_id = ?", file.ID).Delete(&SendHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(file).Error
+	})
+}
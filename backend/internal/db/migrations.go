@@ -0,0 +1,268 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db/baseline"
+	"gorm.io/gorm"
+)
+
+// SchemaMigration records a migration that has been applied to the database
+type SchemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName keeps the table name the same as other migration tools use
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migration is one step in bringing the schema up to date. Each runs once, in a
+// transaction, and is recorded in schema_migrations.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// migrations are applied in order. Once released, a migration must not change: add a
+// new one instead, and update the models to match what it leaves behind, since new
+// databases are created straight from the models (see createSchema).
+//
+// A migration shouldn't use the models to change existing tables, as they describe the
+// latest schema rather than the one the migration starts from. Use Migrator() with a
+// struct local to the migration, or SQL, for renames and backfills, such as:
+//
+//	{Version: 4, Name: "split book status per media type", Up: func(tx *gorm.DB) error {
+//		type Book struct{ EbookStatus, AudiobookStatus string }
+//		if err := tx.Migrator().AddColumn(&Book{}, "EbookStatus"); err != nil {
+//			return err
+//		}
+//		...
+//		return tx.Exec("UPDATE books SET ebook_status = status").Error
+//	}},
+var migrations = []Migration{
+	{Version: 1, Name: "baseline schema", Up: migrateBaseline},
+	{Version: 2, Name: "backfill book identifiers", Up: BackfillBookIdentifiers},
+	{Version: 3, Name: "backfill author aliases", Up: BackfillAuthorAliases},
+	{Version: 4, Name: "add media requests", Up: func(tx *gorm.DB) error {
+		type User struct {
+			AutoApproveRequests bool
+			RequestQuota        *int
+		}
+		type Notification struct {
+			OnRequest bool `gorm:"default:false"`
+		}
+		for _, column := range []struct {
+			model any
+			name  string
+		}{
+			{&User{}, "AutoApproveRequests"},
+			{&User{}, "RequestQuota"},
+			{&Notification{}, "OnRequest"},
+		} {
+			if err := addMissingColumn(tx, column.model, column.name); err != nil {
+				return err
+			}
+		}
+		return createMissingTable(tx, &MediaRequest{})
+	}},
+	{Version: 5, Name: "restrict user libraries", Up: func(tx *gorm.DB) error {
+		type User struct {
+			LibraryRestricted bool `gorm:"default:false"`
+		}
+		type UserTag struct {
+			TagID  uint `gorm:"primaryKey"`
+			UserID uint `gorm:"primaryKey"`
+		}
+		type CollectionShare struct {
+			CollectionID uint `gorm:"primaryKey"`
+			UserID       uint `gorm:"primaryKey"`
+		}
+		if err := addMissingColumn(tx, &User{}, "LibraryRestricted"); err != nil {
+			return err
+		}
+		if err := createMissingTable(tx.Table("user_tags"), &UserTag{}); err != nil {
+			return err
+		}
+		return createMissingTable(tx.Table("collection_shares"), &CollectionShare{})
+	}},
+	{Version: 6, Name: "add reading goals", Up: func(tx *gorm.DB) error {
+		type ReadProgress struct {
+			FinishedAt *time.Time
+		}
+		if err := addMissingColumn(tx, &ReadProgress{}, "FinishedAt"); err != nil {
+			return err
+		}
+		// Books finished before now count from when their progress last changed
+		if err := tx.Exec("UPDATE read_progresses SET finished_at = updated_at WHERE progress >= 1 AND finished_at IS NULL").Error; err != nil {
+			return err
+		}
+		if err := createMissingTable(tx, &ListeningTime{}); err != nil {
+			return err
+		}
+		return createMissingTable(tx, &ReadingGoal{})
+	}},
+	{Version: 7, Name: "track progress per device", Up: func(tx *gorm.DB) error {
+		type User struct {
+			ProgressStrategy string `gorm:"default:latest"`
+		}
+		if err := addMissingColumn(tx, &User{}, "ProgressStrategy"); err != nil {
+			return err
+		}
+		if err := createMissingTable(tx, &DeviceProgress{}); err != nil {
+			return err
+		}
+		// Progress saved before now becomes the default device's
+		return tx.Exec(`INSERT INTO device_progresses (user_id, media_file_id, device, progress, position, recorded_at, updated_at)
+			SELECT rp.user_id, rp.media_file_id, ?, rp.progress, rp.position, rp.last_read_at, rp.updated_at FROM read_progresses rp
+			WHERE rp.deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM device_progresses dp
+				WHERE dp.user_id = rp.user_id AND dp.media_file_id = rp.media_file_id AND dp.device = ?)`,
+			DefaultProgressDevice, DefaultProgressDevice).Error
+	}},
+	{Version: 8, Name: "add annotations", Up: func(tx *gorm.DB) error {
+		return createMissingTable(tx, &Annotation{})
+	}},
+}
+
+// models are the tables the schema is created from
+var models = []interface{}{
+	&Author{},
+	&AuthorAlias{},
+	&Series{},
+	&Publisher{},
+	&Genre{},
+	&Book{},
+	&BookIdentifiers{},
+	&Edition{},
+	&Contributor{},
+	&MediaFile{},
+	&User{},
+	&ReadProgress{},
+	&DeviceProgress{},
+	&Annotation{},
+	&ListeningTime{},
+	&ReadingGoal{},
+	&MediaRequest{},
+	&Device{},
+	&SendHistory{},
+	&Collection{},
+	&CollectionBook{},
+	&Indexer{},
+	&DownloadClient{},
+	&QualityProfile{},
+	&Notification{},
+	&MediaServer{},
+	&HardcoverList{},
+	&Download{},
+	&BlocklistItem{},
+	&Job{},
+	&Setting{},
+	&CacheEntry{},
+	&RootFolder{},
+	&Tag{},
+	&SavedFilter{},
+	&AuditEntry{},
+}
+
+// migrateBaseline brings a database from before versioned migrations up to the schema
+// they start from, which AutoMigrate used to maintain on every start. It migrates the
+// frozen baseline models rather than the live ones, which already include what later
+// migrations add.
+func migrateBaseline(tx *gorm.DB) error {
+	if err := dropFullHardcoverIndexes(tx); err != nil {
+		return err
+	}
+	return tx.AutoMigrate(baseline.Models...)
+}
+
+// runMigrations creates the schema for a new database, or applies the migrations an
+// existing one hasn't had yet
+func runMigrations(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Book{}) {
+		return createSchema(db)
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+
+	var applied []uint
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return err
+	}
+	done := make(map[uint]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+		if version > latestMigration() {
+			return fmt.Errorf("database schema version %d is newer than this version of Shelfarr supports (%d); upgrade Shelfarr or restore a backup", version, latestMigration())
+		}
+	}
+
+	for _, m := range migrations {
+		if done[m.Version] {
+			continue
+		}
+		slog.Info("Migrating database", "version", m.Version, "migration", m.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// createSchema creates the tables of a new database from the models, which already
+// match the latest migration, and records every migration as applied
+func createSchema(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(append(models, &SchemaMigration{})...); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, m := range migrations {
+			if err := tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addMissingColumn adds a column unless the table already has it, as a database may have
+// been migrated past its recorded version, such as by an older baseline that migrated the
+// latest models
+func addMissingColumn(tx *gorm.DB, model any, name string) error {
+	if tx.Migrator().HasColumn(model, name) {
+		return nil
+	}
+	return tx.Migrator().AddColumn(model, name)
+}
+
+// createMissingTable creates a table unless it already exists, for the same reason
+func createMissingTable(tx *gorm.DB, model any) error {
+	if tx.Migrator().HasTable(model) {
+		return nil
+	}
+	return tx.Migrator().CreateTable(model)
+}
+
+// latestMigration returns the version of the last migration
+func latestMigration() uint {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the version of the last migration applied to the database
+func SchemaVersion(db *gorm.DB) (uint, error) {
+	var version uint
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shelfarr/shelfarr/internal/db/baseline"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens an empty SQLite database in a temporary directory
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := Open(Options{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "shelfarr.db")})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Discard
+	return db
+}
+
+// assertLatestSchema checks a database is at the latest migration and has every column
+// of the models
+func assertLatestSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	version, err := SchemaVersion(db)
+	if err != nil {
+		t.Fatalf("schema version: %v", err)
+	}
+	if version != latestMigration() {
+		t.Fatalf("schema version = %d, want %d", version, latestMigration())
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("table %s is missing", stmt.Schema.Table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("column %s.%s is missing", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	// A database from before versioned migrations: the baseline schema, unversioned
+	if err := db.AutoMigrate(baseline.Models...); err != nil {
+		t.Fatalf("create baseline schema: %v", err)
+	}
+	if err := db.Create(&baseline.User{Username: "reader"}).Error; err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate baseline database: %v", err)
+	}
+	assertLatestSchema(t, db)
+
+	var user User
+	if err := db.Where("username = ?", "reader").First(&user).Error; err != nil {
+		t.Fatalf("user lost in migration: %v", err)
+	}
+
+	// Migrating again is a no-op
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+}
+
+func TestMigrateDatabaseAheadOfItsVersion(t *testing.T) {
+	db := openTestDB(t)
+
+	// What the baseline migration used to leave: the latest models, recorded as version 3
+	if err := db.AutoMigrate(append(models, &SchemaMigration{})...); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	for _, m := range migrations[:3] {
+		if err := db.Create(&SchemaMigration{Version: m.Version, Name: m.Name}).Error; err != nil {
+			t.Fatalf("record migration %d: %v", m.Version, err)
+		}
+	}
+	if err := db.Create(&User{Username: "reader"}).Error; err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	assertLatestSchema(t, db)
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate new database: %v", err)
+	}
+	assertLatestSchema(t, db)
+}
@@ -40,11 +40,12 @@ const (
 // Author represents a book author
 type Author struct {
 	gorm.Model
-	HardcoverID string `gorm:"uniqueIndex"`
+	HardcoverID string `gorm:"uniqueIndex:idx_authors_hardcover_id,where:hardcover_id <> ''"` // Empty for authors from fallback providers
 	Name        string `gorm:"index"`
 	SortName    string
 	Biography   string `gorm:"type:text"`
 	ImageURL    string
+	CustomImage string // Photo picked or uploaded by the user, shown instead of ImageURL
 	Slug        string `gorm:"index"` // URL-friendly identifier
 
 	// Biographical info from Hardcover
@@ -77,7 +78,7 @@ type Author struct {
 // Series represents a book series
 type Series struct {
 	gorm.Model
-	HardcoverID string `gorm:"uniqueIndex"`
+	HardcoverID string `gorm:"uniqueIndex:idx_series_hardcover_id,where:hardcover_id <> ''"` // Empty for series imported from Calibre
 	Name        string `gorm:"index"`
 	Slug        string `gorm:"index"` // URL-friendly identifier
 	Description string `gorm:"type:text"`
@@ -116,7 +117,7 @@ type Genre struct {
 // Book represents a book entry in the library
 type Book struct {
 	gorm.Model
-	HardcoverID string `gorm:"uniqueIndex"`
+	HardcoverID string `gorm:"uniqueIndex:idx_books_hardcover_id,where:hardcover_id <> ''"` // Empty for books from fallback providers
 	Title       string `gorm:"index"`
 	SortTitle   string
 	Subtitle    string // Book subtitle
@@ -127,9 +128,14 @@ type Book struct {
 	ISBN   string `gorm:"index"`
 	ISBN13 string `gorm:"index"`
 
+	// IDs from fallback metadata providers, for books not found on Hardcover
+	OpenLibraryWorkID string `gorm:"index"`
+	GoogleVolumeID    string `gorm:"index"`
+
 	// Core metadata
 	Description  string `gorm:"type:text"`
 	CoverURL     string
+	CustomCover  string // Cover picked or uploaded by the user, shown instead of CoverURL and kept on refresh
 	Rating       float32
 	RatingsCount int // Number of ratings on Hardcover
 	ReviewsCount int // Number of reviews on Hardcover
@@ -169,10 +175,15 @@ type Book struct {
 	Series      *Series
 	SeriesIndex *float32
 
+	// Root folders the book's files are imported into; nil uses the default for the media type
+	EbookRootFolderID     *uint
+	AudiobookRootFolderID *uint
+
 	// Many-to-many relationships
-	Genres       []*Genre      `gorm:"many2many:book_genres;"`
-	Contributors []Contributor // All contributors (authors, narrators, etc.)
-	Editions     []Edition     // All editions
+	Genres       []*Genre         `gorm:"many2many:book_genres;"`
+	Contributors []Contributor    // All contributors (authors, narrators, etc.)
+	Editions     []Edition        // All editions
+	Identifiers  *BookIdentifiers // IDs at every metadata provider
 
 	// Status tracking
 	Status    BookStatus `gorm:"default:'missing'"`
@@ -183,6 +194,23 @@ type Book struct {
 
 	// Sync tracking
 	LastSyncedAt *time.Time // When metadata was last refreshed from Hardcover
+	LockedFields string     `gorm:"type:text"` // JSON: ["title", "genres"] - edited by hand, left alone by refreshes
+}
+
+// BookIdentifiers holds a book's IDs across metadata providers, so a book found
+// through any provider can be matched to the library and deduplicated
+type BookIdentifiers struct {
+	gorm.Model
+	BookID         uint   `gorm:"uniqueIndex;not null"`
+	HardcoverID    string `gorm:"index"`
+	OLWorkID       string `gorm:"index"` // Open Library work key ("OL45804W")
+	OLEditionID    string `gorm:"index"` // Open Library edition key ("OL7353617M")
+	ISBN10         string `gorm:"index"`
+	ISBN13         string `gorm:"index"`
+	ASIN           string `gorm:"index"`
+	GoodreadsID    string `gorm:"index"`
+	GoogleVolumeID string `gorm:"index"`
+	CalibreUUID    string `gorm:"index"` // Book UUID in the Calibre library it was imported from
 }
 
 // Edition represents a specific edition of a book from Hardcover
@@ -219,7 +247,9 @@ type Edition struct {
 
 	// Physical/Audio attributes
 	PageCount    int
-	AudioSeconds int // For audiobooks - duration in seconds
+	AudioSeconds int    // For audiobooks - duration in seconds
+	Narrators    string // For audiobooks - comma-separated, from Audible
+	Abridged     bool   // For audiobooks - from Audible
 
 	// Release info (edition-specific)
 	ReleaseDate *time.Time
@@ -257,29 +287,71 @@ type MediaFile struct {
 	Bitrate  int
 	Duration int // seconds
 
+	// Pages, for PDFs and comics
+	PageCount int
+
 	// Edition info
 	EditionName string // "US Edition", "Narrator A", etc.
 
 	// Tracking
-	ImportedAt time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"` // Soft delete for recycle bin
+	ImportedAt   time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"` // Soft delete for recycle bin
+	RecycledFrom string         // Where the file was before it moved to the recycle bin (FilePath is then in the bin)
 }
 
 // User represents an application user
 type User struct {
 	gorm.Model
-	Username     string `gorm:"uniqueIndex"`
+	Username     string `gorm:"uniqueIndex" validate:"required,max=100"`
 	PasswordHash string
 	Email        string
 	IsAdmin      bool `gorm:"default:false"`
 	CanRead      bool `gorm:"default:true"`
 	CanDelete    bool `gorm:"default:false"`
 
+	// Email preferences
+	WeeklyDigest bool   `gorm:"default:false"` // Receive the weekly "new in your library" email
+	KindleEmail  string // Send to Kindle address (@kindle.com)
+
 	// SSO support
 	RemoteUser string `gorm:"index"` // For header-based auth
 
+	// Requests for books and authors
+	AutoApproveRequests bool // Approved without an admin while within the quota, even if auto-approval is off
+	RequestQuota        *int // Requests approved automatically per quota period; nil uses the default
+
+	// LibraryRestricted limits what the user can see and stream, as for a child's
+	// account, to books sharing one of the user's tags and books in collections shared
+	// with them. Admins see everything. Only admins change it (see setUserLibrary).
+	LibraryRestricted bool `gorm:"default:false" json:"-"`
+
 	// Reading progress
-	ReadProgress []ReadProgress
+	ReadProgress     []ReadProgress
+	ProgressStrategy string `gorm:"default:latest" validate:"oneof=latest furthest"` // Which device's position wins: latest or furthest
+}
+
+// MediaRequest is a user's request for a book, or an author's books, to be added to the
+// library. An admin approves or declines it, unless it's approved automatically; once
+// approved, it's added and searched for.
+type MediaRequest struct {
+	gorm.Model
+	UserID       uint `gorm:"index"`
+	User         User
+	Type         string `gorm:"index"` // book or author
+	Provider     string // Metadata provider ForeignID is from: hardcover, openlibrary or googlebooks
+	ForeignID    string `gorm:"index"` // The book's or author's ID at the provider
+	Title        string // Book title, or author name
+	AuthorName   string // For book requests
+	CoverURL     string
+	MediaType    string // ebook, audiobook or both
+	Note         string `gorm:"type:text"`               // From the user
+	Status       string `gorm:"index;default:'pending'"` // pending, approved, declined, failed
+	Reason       string `gorm:"type:text"`               // Why it was declined or failed, or how the search went
+	AutoApproved bool
+	DecidedByID  *uint
+	DecidedAt    *time.Time
+	BookID       *uint // What was added, once approved
+	AuthorID     *uint
 }
 
 // ReadProgress tracks user progress through media
@@ -293,44 +365,176 @@ type ReadProgress struct {
 	// Progress tracking
 	Progress   float32 // 0.0 - 1.0 (percentage for ebooks, timestamp ratio for audio)
 	Position   int     // Page number or seconds
+	Location   string  // Reader-specific position, such as a Kobo bookmark (JSON)
 	LastReadAt time.Time
+	FinishedAt *time.Time // When the user last reached the end; counts toward reading goals
+}
+
+// DeviceProgress is where a user last was in a media file on one device, so reading or
+// listening on two devices doesn't silently overwrite either's position. ReadProgress holds
+// the position resolved across them by the user's ProgressStrategy.
+type DeviceProgress struct {
+	ID          uint      `gorm:"primarykey"`
+	UserID      uint      `gorm:"uniqueIndex:idx_device_progress"`
+	MediaFileID uint      `gorm:"uniqueIndex:idx_device_progress"`
+	Device      string    `gorm:"uniqueIndex:idx_device_progress"` // Name the client gives itself, such as "Pixel 8"
+	Progress    float32   // 0.0 - 1.0
+	Position    int       // Page number or seconds
+	RecordedAt  time.Time // When the device was there, which is earlier than now if it synced late
+	UpdatedAt   time.Time
+}
+
+// Annotation is a user's bookmark or highlight in a media file, with an optional note.
+// In an ebook it's at a locator, such as an EPUB CFI; in an audiobook, at a time.
+type Annotation struct {
+	gorm.Model
+	UserID      uint `gorm:"index"`
+	MediaFileID uint `gorm:"index"`
+	MediaFile   MediaFile
+	BookID      uint   `gorm:"index"`
+	Type        string // bookmark or highlight
+	Locator     string // Where it is in an ebook: a CFI, or the reader's locator (JSON)
+	Position    int    // Seconds into an audiobook, or the page in an ebook
+	EndPosition int    // Where an audiobook highlight ends, in seconds
+	Chapter     string
+	Text        string // The highlighted text
+	Note        string
+	Color       string
+}
+
+// DefaultProgressDevice is the device progress is saved for when the client doesn't name one
+const DefaultProgressDevice = "default"
+
+// ListeningTime is how long a user listened to an audiobook file on one day, for
+// reading goals counted in hours
+type ListeningTime struct {
+	ID          uint      `gorm:"primarykey"`
+	UserID      uint      `gorm:"uniqueIndex:idx_listening_time"`
+	MediaFileID uint      `gorm:"uniqueIndex:idx_listening_time"`
+	BookID      uint      `gorm:"index"`
+	Date        time.Time `gorm:"uniqueIndex:idx_listening_time;index"` // Midnight, server time
+	Seconds     int
+}
+
+// ReadingGoal is a number of books to finish, or hours to listen, in a year or a month
+type ReadingGoal struct {
+	gorm.Model
+	UserID uint   `gorm:"uniqueIndex:idx_reading_goal"`
+	Year   int    `gorm:"uniqueIndex:idx_reading_goal"`
+	Month  int    `gorm:"uniqueIndex:idx_reading_goal"` // 1-12, or 0 for the whole year
+	Unit   string `gorm:"uniqueIndex:idx_reading_goal"` // books or hours
+	Target int
+}
+
+// Device represents a user's e-reader, which accepts books by email or (Kobo) syncs them
+type Device struct {
+	gorm.Model
+	UserID          uint   `gorm:"index;not null"`
+	Name            string // "Paperwhite", "Kobo Libra"
+	Type            string // kindle, kobo, pocketbook, other
+	Email           string
+	PreferredFormat string // epub, kepub, azw3, mobi, pdf
+	KoboSyncToken   string `gorm:"index"` // Secret in a Kobo's sync URL; empty until Kobo sync is enabled
+}
+
+// SendHistory records each attempt to email a media file to an e-reader
+type SendHistory struct {
+	gorm.Model
+	MediaFileID uint `gorm:"index"`
+	MediaFile   MediaFile
+	UserID      uint  `gorm:"index"`
+	DeviceID    *uint `gorm:"index"` // Nil when sent to the user's Kindle email setting
+
+	ToEmail   string
+	FileName  string
+	Format    string // Format actually sent (after conversion)
+	FileSize  int64
+	Converted bool
+	Status    string // sent, failed
+	Error     string
+}
+
+// Collection is a user's own shelf of books, such as "Summer reading" or "DNF",
+// kept apart from monitored Hardcover lists
+type Collection struct {
+	gorm.Model
+	UserID      uint `gorm:"index;not null"`
+	Name        string
+	Description string
+	OPDS        bool `gorm:"column:opds;default:false"` // Listed in the user's OPDS catalog
+	Books       []CollectionBook
+	SharedWith  []*User `gorm:"many2many:collection_shares;"` // Users it's listed for, read-only, besides its owner
+}
+
+// CollectionBook places a book in a collection
+type CollectionBook struct {
+	ID           uint `gorm:"primarykey"`
+	CollectionID uint `gorm:"uniqueIndex:idx_collection_book"`
+	BookID       uint `gorm:"uniqueIndex:idx_collection_book;index"`
+	Book         Book
+	Position     int // Order within the collection, from 0
+	CreatedAt    time.Time
 }
 
 // Indexer represents a configured search indexer
 type Indexer struct {
 	gorm.Model
-	Name     string
-	Type     string // "torznab", "mam", "anna"
-	URL      string
-	APIKey   string
-	Cookie   string // For MAM
-	Priority int    `gorm:"default:0"`
-	Enabled  bool   `gorm:"default:true"`
+	Name       string
+	Type       string // "torznab", "newznab", "mam", "anna", "libgen", "audiobookbay"
+	URL        string // Comma-separated search mirrors for LibGen
+	APIKey     string // Torznab/Newznab API key, or an Anna's Archive member key
+	Cookie     string // For MAM
+	Categories string // Comma-separated Newznab category IDs offered (set by Prowlarr); limits the mapping below
+	Priority   int    `gorm:"default:0"`
+	Enabled    bool   `gorm:"default:true"`
+
+	// Torznab capabilities, detected from t=caps when the indexer is saved
+	SupportedCategories string // JSON list of the indexer's categories
+	SearchModes         string // Comma-separated search functions (search, book-search)
+	EbookCategories     string // Comma-separated categories searched for ebooks
+	AudiobookCategories string // Comma-separated categories searched for audiobooks
 
 	// MAM-specific
 	VIPOnly       bool `gorm:"default:false"`
 	FreeleechOnly bool `gorm:"default:false"`
+
+	// Seeding goals for torrents from this indexer; zero uses the download client's
+	SeedRatio float64
+	SeedTime  int // Minutes
 }
 
 // DownloadClient represents a configured download client
 type DownloadClient struct {
 	gorm.Model
 	Name     string `json:"name"`
-	Type     string `json:"type"` // "qbittorrent", "transmission", "deluge", "sabnzbd", "nzbget", "rtorrent"
-	URL      string `json:"url"`
+	Type     string `json:"type"` // "qbittorrent", "transmission", "deluge", "sabnzbd", "nzbget", "rtorrent", "direct", "aria2", "synology"
+	URL      string `json:"url"`  // Download folder for direct clients
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password string `json:"password"` // API key for SABnzbd, RPC secret for aria2
 	Category string `json:"category"`
 	Priority int    `json:"priority" gorm:"default:0"`
 	Enabled  bool   `json:"enabled" gorm:"default:true"`
-	Settings string `json:"settings" gorm:"type:text"` // JSON for extra settings (SSL, port, seedbox type, path mappings)
+	Settings string `json:"settings" gorm:"type:text"` // JSON for extra settings (SSL, port, seedbox type, path mappings, maxConcurrent for direct)
+
+	// Ebooks and audiobooks can each have their own category and save path. Empty values
+	// fall back to Category and the client's default folder.
+	EbookCategory     string `json:"ebookCategory"`
+	AudiobookCategory string `json:"audiobookCategory"`
+	EbookSavePath     string `json:"ebookSavePath"`
+	AudiobookSavePath string `json:"audiobookSavePath"`
+
+	// Torrents are removed from the client once either goal is met; zero means no goal.
+	// Indexers can override them.
+	SeedRatio         float64 `json:"seedRatio"`
+	SeedTime          int     `json:"seedTime"`          // Minutes
+	DeleteSeededFiles bool    `json:"deleteSeededFiles"` // Delete the downloaded data when removing
 }
 
 // QualityProfile defines format/quality preferences
 type QualityProfile struct {
 	gorm.Model
-	Name      string
-	MediaType MediaType
+	Name      string    `validate:"required,max=100"`
+	MediaType MediaType `validate:"oneof=ebook audiobook"`
 
 	// For ebooks: comma-separated format ranking (e.g., "epub,azw3,mobi,pdf")
 	// For audiobooks: comma-separated format ranking (e.g., "m4b,mp3")
@@ -338,13 +542,16 @@ type QualityProfile struct {
 
 	// Audiobook specific
 	MinBitrate int `gorm:"default:0"` // Minimum acceptable bitrate
+
+	// Ebook specific: rewrite imported EPUBs' metadata and cover to match the library
+	EmbedMetadata bool `gorm:"default:false"`
 }
 
 // Notification represents a notification configuration
 type Notification struct {
 	gorm.Model
 	Name    string
-	Type    string // "webhook", "discord", "telegram", "email"
+	Type    string // "webhook", "discord", "telegram", "email", "ntfy", "gotify", "pushover", "script"
 	Enabled bool   `gorm:"default:true"`
 
 	// Connection settings
@@ -354,6 +561,18 @@ type Notification struct {
 	TelegramChatID   string
 	EmailTo          string
 
+	// Push provider settings
+	NtfyServerURL    string // Defaults to https://ntfy.sh
+	NtfyTopic        string
+	NtfyToken        string // Optional access token for protected topics
+	GotifyServerURL  string
+	GotifyAppToken   string
+	PushoverUserKey  string
+	PushoverAppToken string
+
+	// Custom script run with the event in its environment
+	ScriptPath string
+
 	// Triggers
 	OnGrab        bool `gorm:"default:false"`
 	OnDownload    bool `gorm:"default:true"`
@@ -361,12 +580,37 @@ type Notification struct {
 	OnImport      bool `gorm:"default:true"`
 	OnDelete      bool `gorm:"default:false"`
 	OnHealthIssue bool `gorm:"default:true"`
+	OnRelease     bool `gorm:"default:false"` // Monitored book reached its release date
+	OnFailure     bool `gorm:"default:false"` // Download or import failed
+	OnUpdate      bool `gorm:"default:false"` // A new Shelfarr release is available
+	OnRequest     bool `gorm:"default:false"` // A user requested a book or author for an admin to approve
+}
+
+// MediaServer is a media server connection whose library is scanned when files change
+type MediaServer struct {
+	gorm.Model
+	Name    string
+	Type    string // "plex", "jellyfin", "kavita", "komga"
+	Enabled bool   `gorm:"default:true"`
+	URL     string
+	Token   string // Plex token, Jellyfin/Kavita/Komga API key
+
+	// Triggers
+	OnImport  bool `gorm:"default:true"`
+	OnUpgrade bool `gorm:"default:true"`
 }
 
+// Hardcover list sources
+const (
+	ListSourceList       = "list"         // A public Hardcover list, by ID
+	ListSourceWantToRead = "want_to_read" // The API key owner's Want to Read shelf
+)
+
 // HardcoverList represents a monitored Hardcover.app list
 type HardcoverList struct {
 	gorm.Model
 	Name            string
+	Source          string `gorm:"default:list"`
 	HardcoverURL    string `gorm:"uniqueIndex"`
 	HardcoverID     string `gorm:"index"`
 	Enabled         bool   `gorm:"default:true"`
@@ -384,18 +628,64 @@ type Download struct {
 	ClientID     uint   `gorm:"index"`
 	ClientType   string // qbittorrent, transmission, sabnzbd, etc.
 	ExternalID   string `gorm:"index"` // Hash for torrents, NZB ID for usenet
+	InfoHash     string `gorm:"index"` // Torrent info hash (lowercase hex), worked out when grabbed
 	MediaType    string // ebook or audiobook - allows both types per book
+	Indexer      string // Name of the indexer the release was grabbed from
 	Title        string
 	DownloadURL  string
 	OutputPath   string
 	Size         int64
 	Downloaded   int64
 	Progress     float64
-	Status       string `gorm:"default:'queued'"` // queued, downloading, paused, completed, failed, importing
+	Status       string `gorm:"default:'queued'"` // queued, downloading, paused, completed, failed, importing, ignored
 	Category     string
 	ErrorMessage string
 	AddedAt      int64
 	CompletedAt  int64
+	Removed      bool // Removed from the download client once seeding goals were met
+}
+
+// BlocklistItem is a release that won't be grabbed for a book again, because it failed
+// or turned out to be the wrong book
+type BlocklistItem struct {
+	gorm.Model
+	BookID      uint `gorm:"index"`
+	Title       string
+	Indexer     string
+	DownloadURL string
+	InfoHash    string // Lowercase hex, for torrents
+	Reason      string
+}
+
+// Job represents a long-running background task such as a format conversion.
+// Jobs are persisted so queued work survives a restart.
+type Job struct {
+	gorm.Model
+	Type        string  `gorm:"index"`                  // ebook_convert, audiobook_merge, etc.
+	Status      string  `gorm:"index;default:'queued'"` // queued, running, completed, failed, cancelled
+	Progress    float64 // 0-100
+	Message     string  // Current step, for display
+	Payload     string  `gorm:"type:text"` // JSON input for the job handler
+	Result      string  `gorm:"type:text"` // JSON output from the job handler
+	Error       string
+	Attempts    int
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// AuditEntry records a change a user made, such as adding a book, changing settings
+// or grabbing a release, and failed logins
+type AuditEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	UserID    uint      `gorm:"index"` // 0 when unknown, as for failed logins
+	Username  string    // As it was at the time, so entries outlive renamed and deleted users
+	Action    string    `gorm:"index"` // Such as book.add, settings.update or download.grab
+	Summary   string    // What was done, for display
+	Method    string
+	Path      string // Request path, with IDs
+	Status    int
+	IP        string
 }
 
 // Setting represents a key-value configuration setting stored in the database
@@ -404,12 +694,29 @@ type Setting struct {
 	Value string
 }
 
+// CacheEntry is a cached metadata API response
+type CacheEntry struct {
+	Key       string `gorm:"primaryKey"`
+	Value     []byte
+	ExpiresAt time.Time `gorm:"index"`
+}
+
 // RootFolder represents a configured media library root folder
 type RootFolder struct {
 	gorm.Model
 	Path       string    `gorm:"uniqueIndex"`
 	MediaType  MediaType // ebook or audiobook
 	Name       string    // Optional display name
+	IsDefault  bool      // Books not assigned a root folder are imported into the default for their media type
 	FreeSpace  int64     `gorm:"-"` // Calculated at runtime, not stored
 	TotalSpace int64     `gorm:"-"` // Calculated at runtime, not stored
+
+	// How files are imported here: move, copy, hardlink or reflink. Empty uses the
+	// media management setting.
+	ImportOperation string
+
+	// Audiobookshelf integration for audiobooks imported here
+	AudiobookshelfMode      string // "" (off), "scan" or "upload"
+	AudiobookshelfLibraryID string
+	AudiobookshelfFolderID  string // Library folder uploads go to
 }
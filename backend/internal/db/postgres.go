@@ -0,0 +1,19 @@
+//go:build postgres
+
+package db
+
+import (
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// PostgreSQL support is opt-in so the default build leaves its driver out. Add the
+// postgres tag to the build tags to include it, or build the Docker image with:
+//
+//	docker build --build-arg BUILD_TAGS="sqlite_fts5 postgres" -f docker/Dockerfile .
+
+func init() {
+	dialectors[DriverPostgres] = func(dsn string) gorm.Dialector {
+		return postgres.Open(dsn)
+	}
+}
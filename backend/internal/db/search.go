@@ -0,0 +1,124 @@
+package db
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// The library's full-text index is an FTS5 table, book_search, with a row per book
+// (rowid is the book's ID) holding its title, subtitle, description, author and
+// series. Triggers keep it in step with the books, authors and series tables, so
+// nothing else has to update it.
+//
+// FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build tag. Without it, or
+// on PostgreSQL, the index isn't created, HasSearchIndex reports false and search
+// falls back to LIKE.
+
+const searchIndexColumns = "title, subtitle, description, author, series"
+
+// searchIndexRows selects the index rows for the books matching where
+const searchIndexRows = `SELECT books.id, books.title, COALESCE(books.subtitle, ''), COALESCE(books.description, ''),
+	COALESCE(authors.name, ''), COALESCE(series.name, '')
+FROM books
+LEFT JOIN authors ON authors.id = books.author_id
+LEFT JOIN series ON series.id = books.series_id
+WHERE books.deleted_at IS NULL AND `
+
+var searchIndexTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS book_search_insert AFTER INSERT ON books BEGIN
+		INSERT INTO book_search (rowid, ` + searchIndexColumns + `) ` + searchIndexRows + `books.id = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_update AFTER UPDATE ON books BEGIN
+		DELETE FROM book_search WHERE rowid = OLD.id;
+		INSERT INTO book_search (rowid, ` + searchIndexColumns + `) ` + searchIndexRows + `books.id = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_delete AFTER DELETE ON books BEGIN
+		DELETE FROM book_search WHERE rowid = OLD.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_author AFTER UPDATE OF name ON authors BEGIN
+		DELETE FROM book_search WHERE rowid IN (SELECT id FROM books WHERE author_id = NEW.id);
+		INSERT INTO book_search (rowid, ` + searchIndexColumns + `) ` + searchIndexRows + `books.author_id = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_series AFTER UPDATE OF name ON series BEGIN
+		DELETE FROM book_search WHERE rowid IN (SELECT id FROM books WHERE series_id = NEW.id);
+		INSERT INTO book_search (rowid, ` + searchIndexColumns + `) ` + searchIndexRows + `books.series_id = NEW.id;
+	END`,
+}
+
+// ensureSearchIndex creates the full-text index and its triggers, filling it from the
+// library when it's new. It does nothing if SQLite was built without FTS5, or for
+// other databases.
+func ensureSearchIndex(db *gorm.DB) error {
+	if !IsSQLite(db) || HasSearchIndex(db) {
+		return nil
+	}
+
+	err := db.Exec("CREATE VIRTUAL TABLE book_search USING fts5(" + searchIndexColumns + ", tokenize = 'unicode61 remove_diacritics 2')").Error
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			return nil
+		}
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, trigger := range searchIndexTriggers {
+			if err := tx.Exec(trigger).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("INSERT INTO book_search (rowid, " + searchIndexColumns + ") " + searchIndexRows + "1 = 1").Error
+	})
+}
+
+// HasSearchIndex reports whether the full-text index exists
+func HasSearchIndex(db *gorm.DB) bool {
+	if !IsSQLite(db) {
+		return false
+	}
+	var count int64
+	db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'book_search'").Scan(&count)
+	return count > 0
+}
+
+// SearchQuery turns a user's search into an FTS5 query. Words match as prefixes, so
+// "sand dun" finds "Sanderson" and "Dune"; "quoted words" match as a phrase. Every
+// word or phrase must match. It returns "" if there's nothing to search for.
+func SearchQuery(q string) string {
+	var terms []string
+	for i, part := range strings.Split(q, `"`) {
+		// Odd parts were inside quotes
+		if i%2 == 1 {
+			if words := searchWords(part); len(words) > 0 {
+				terms = append(terms, `"`+strings.Join(words, " ")+`"`)
+			}
+			continue
+		}
+		for _, word := range searchWords(part) {
+			terms = append(terms, `"`+word+`"*`)
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// searchWords splits text into words, dropping FTS5 syntax characters
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		switch r {
+		case ' ', '\t', '\n', '*', '^', ':', '(', ')', '{', '}', '+', '-', ',', '.', ';', '!', '?', '\'':
+			return true
+		}
+		return false
+	})
+}
+
+// SearchBooks limits a query on books to those matching an FTS5 query from
+// SearchQuery, most relevant first. Title and author matches weigh the most.
+func SearchBooks(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN (SELECT rowid AS book_id, bm25(book_search, 10.0, 4.0, 1.0, 8.0, 6.0) AS search_rank"+
+			" FROM book_search WHERE book_search MATCH ?) AS search_matches ON search_matches.book_id = books.id", query).
+			Order("search_matches.search_rank")
+	}
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tag is a user-defined label on books, authors, indexers and quality profiles. Tags
+// scope indexers and profiles: a tagged indexer is only searched for books sharing one
+// of its tags, and a tagged profile is preferred for them. A book has its own tags
+// and its author's. A restricted user sees only the books sharing one of their tags.
+type Tag struct {
+	gorm.Model
+	Name            string            `gorm:"uniqueIndex"`
+	Books           []*Book           `gorm:"many2many:book_tags;"`
+	Authors         []*Author         `gorm:"many2many:author_tags;"`
+	Indexers        []*Indexer        `gorm:"many2many:indexer_tags;"`
+	QualityProfiles []*QualityProfile `gorm:"many2many:quality_profile_tags;"`
+	Users           []*User           `gorm:"many2many:user_tags;"`
+}
+
+// TagLinks describes the join table linking tags to one kind of item
+type TagLinks struct {
+	Table      string // The items' table
+	JoinTable  string
+	ForeignKey string // The join table's column for the item
+}
+
+var (
+	BookTags           = TagLinks{"books", "book_tags", "book_id"}
+	AuthorTags         = TagLinks{"authors", "author_tags", "author_id"}
+	IndexerTags        = TagLinks{"indexers", "indexer_tags", "indexer_id"}
+	QualityProfileTags = TagLinks{"quality_profiles", "quality_profile_tags", "quality_profile_id"}
+	UserTags           = TagLinks{"users", "user_tags", "user_id"}
+)
+
+// Set replaces an item's tags
+func (l TagLinks) Set(db *gorm.DB, itemID uint, tagIDs []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+l.JoinTable+" WHERE "+l.ForeignKey+" = ?", itemID).Error; err != nil {
+			return err
+		}
+		return l.Add(tx, []uint{itemID}, tagIDs)
+	})
+}
+
+// Add tags items, leaving their other tags
+func (l TagLinks) Add(db *gorm.DB, itemIDs, tagIDs []uint) error {
+	var rows []map[string]any
+	for _, itemID := range itemIDs {
+		for _, tagID := range tagIDs {
+			rows = append(rows, map[string]any{"tag_id": tagID, l.ForeignKey: itemID})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Table(l.JoinTable).Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error
+}
+
+// Remove takes tags off items
+func (l TagLinks) Remove(db *gorm.DB, itemIDs, tagIDs []uint) error {
+	if len(itemIDs) == 0 || len(tagIDs) == 0 {
+		return nil
+	}
+	return db.Exec("DELETE FROM "+l.JoinTable+" WHERE "+l.ForeignKey+" IN ? AND tag_id IN ?", itemIDs, tagIDs).Error
+}
+
+// IDs returns the tag IDs of each of the given items
+func (l TagLinks) IDs(db *gorm.DB, itemIDs ...uint) map[uint][]uint {
+	var links []struct {
+		ItemID uint
+		TagID  uint
+	}
+	db.Table(l.JoinTable).Select(l.ForeignKey+" AS item_id, tag_id").
+		Where(l.ForeignKey+" IN ?", itemIDs).Order("tag_id").Scan(&links)
+
+	tags := make(map[uint][]uint)
+	for _, link := range links {
+		tags[link.ItemID] = append(tags[link.ItemID], link.TagID)
+	}
+	return tags
+}
+
+// Tagged limits a query to items with any of the tags
+func (l TagLinks) Tagged(tagIDs []uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(l.Table+".id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Table(l.JoinTable).Select(l.ForeignKey).Where("tag_id IN ?", tagIDs))
+	}
+}
+
+// AvailableFor limits a query to the items that apply to something with the given
+// tags: untagged items, which apply to everything, and those sharing one of its tags
+func (l TagLinks) AvailableFor(tagIDs []uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		tagged := db.Session(&gorm.Session{NewDB: true}).Table(l.JoinTable).Select(l.ForeignKey)
+		if len(tagIDs) == 0 {
+			return db.Where(l.Table+".id NOT IN (?)", tagged)
+		}
+		return db.Where("("+l.Table+".id NOT IN (?) OR "+l.Table+".id IN (?))", tagged,
+			db.Session(&gorm.Session{NewDB: true}).Table(l.JoinTable).Select(l.ForeignKey).Where("tag_id IN ?", tagIDs))
+	}
+}
+
+// BookTagIDs returns a book's tags, including those of its author
+func BookTagIDs(db *gorm.DB, book *Book) []uint {
+	var tagIDs []uint
+	db.Raw("SELECT tag_id FROM book_tags WHERE book_id = ? UNION SELECT tag_id FROM author_tags WHERE author_id = ?",
+		book.ID, book.AuthorID).Scan(&tagIDs)
+	return tagIDs
+}
+
+// BooksTagged limits a query on books to those with any of the tags, directly or
+// through their author
+func BooksTagged(tagIDs []uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		newDB := db.Session(&gorm.Session{NewDB: true})
+		return db.Where("(books.id IN (?) OR books.author_id IN (?))",
+			newDB.Table("book_tags").Select("book_id").Where("tag_id IN ?", tagIDs),
+			newDB.Table("author_tags").Select("author_id").Where("tag_id IN ?", tagIDs))
+	}
+}
+
+// DeleteTag removes a tag and its links
+func DeleteTag(db *gorm.DB, tagID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, links := range []TagLinks{BookTags, AuthorTags, IndexerTags, QualityProfileTags, UserTags} {
+			if err := tx.Exec("DELETE FROM "+links.JoinTable+" WHERE tag_id = ?", tagID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&Tag{}, tagID).Error
+	})
+}
@@ -0,0 +1,387 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aria2Client handles communication with aria2's JSON-RPC interface. aria2 fetches
+// both torrents (magnets and .torrent URLs) and plain HTTP downloads.
+type Aria2Client struct {
+	rpcURL     string
+	secret     string
+	httpClient *http.Client
+	requestID  int
+}
+
+// NewAria2Client creates a new aria2 client. baseURL may be the RPC endpoint or the
+// host it's served from; secret is the --rpc-secret token, if one is set.
+func NewAria2Client(baseURL, secret string) *Aria2Client {
+	rpcURL := strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(rpcURL, "/jsonrpc") {
+		rpcURL += "/jsonrpc"
+	}
+
+	return &Aria2Client{
+		rpcURL: rpcURL,
+		secret: secret,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// aria2Request represents a JSON-RPC request to aria2
+type aria2Request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// aria2Response represents a JSON-RPC response from aria2
+type aria2Response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// aria2Status is the subset of aria2.tellStatus fields Shelfarr uses
+type aria2Status struct {
+	GID             string   `json:"gid"`
+	Status          string   `json:"status"`
+	TotalLength     string   `json:"totalLength"`
+	CompletedLength string   `json:"completedLength"`
+	UploadLength    string   `json:"uploadLength"`
+	DownloadSpeed   string   `json:"downloadSpeed"`
+	Dir             string   `json:"dir"`
+	ErrorMessage    string   `json:"errorMessage"`
+	FollowedBy      []string `json:"followedBy"`
+	Seeder          string   `json:"seeder"`
+	Files           []struct {
+		Path string `json:"path"`
+	} `json:"files"`
+	Bittorrent *struct {
+		Info struct {
+			Name string `json:"name"`
+		} `json:"info"`
+	} `json:"bittorrent"`
+}
+
+var aria2StatusKeys = []string{
+	"gid", "status", "totalLength", "completedLength", "uploadLength", "downloadSpeed", "dir",
+	"errorMessage", "followedBy", "seeder", "files", "bittorrent",
+}
+
+// call makes a JSON-RPC call to aria2, passing the secret token first
+func (a *Aria2Client) call(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	a.requestID++
+
+	if a.secret != "" {
+		params = append([]interface{}{"token:" + a.secret}, params...)
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	jsonBody, err := json.Marshal(aria2Request{
+		JSONRPC: "2.0",
+		ID:      "shelfarr-" + strconv.Itoa(a.requestID),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.rpcURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var aria2Resp aria2Response
+	if err := json.Unmarshal(body, &aria2Resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w (status: %d)", err, resp.StatusCode)
+	}
+
+	if aria2Resp.Error != nil {
+		return nil, fmt.Errorf("aria2 error: %s (code: %d)", aria2Resp.Error.Message, aria2Resp.Error.Code)
+	}
+
+	return aria2Resp.Result, nil
+}
+
+// Test checks if the connection is working
+func (a *Aria2Client) Test(ctx context.Context) error {
+	_, err := a.call(ctx, "aria2.getVersion")
+	return err
+}
+
+// Type returns the client type
+func (a *Aria2Client) Type() string {
+	return "aria2"
+}
+
+// AddDownload implements the Client interface
+func (a *Aria2Client) AddDownload(ctx context.Context, url string, opts *DownloadOptions) (string, error) {
+	options := make(map[string]interface{})
+	if opts != nil {
+		if opts.SavePath != "" {
+			options["dir"] = opts.SavePath
+		}
+		if opts.FileName != "" && !strings.HasPrefix(url, "magnet:") {
+			options["out"] = opts.FileName
+		}
+		if opts.Paused {
+			options["pause"] = "true"
+		}
+	}
+
+	// Torrent files the caller already fetched are uploaded instead of fetched again
+	method := "aria2.addUri"
+	params := []interface{}{[]string{url}, options}
+	if opts != nil && opts.Torrent != nil {
+		if opts.Torrent.IsMagnet() {
+			params[0] = []string{opts.Torrent.URL}
+		} else {
+			method = "aria2.addTorrent"
+			params = []interface{}{base64.StdEncoding.EncodeToString(opts.Torrent.Data), []string{}, options}
+		}
+	}
+	if opts != nil && opts.Priority > 0 {
+		params = append(params, 0) // Front of the queue
+	}
+
+	result, err := a.call(ctx, method, params...)
+	if err != nil {
+		return "", fmt.Errorf("failed to add download: %w", err)
+	}
+
+	var gid string
+	if err := json.Unmarshal(result, &gid); err != nil {
+		return "", fmt.Errorf("failed to parse download ID: %w", err)
+	}
+	return gid, nil
+}
+
+// GetDownload implements the Client interface. Magnets and .torrent URLs are added as
+// a metadata download that is followed by the torrent itself, so the download reported
+// is the last one in that chain.
+func (a *Aria2Client) GetDownload(ctx context.Context, id string) (*DownloadInfo, error) {
+	status, err := a.tellStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(status.FollowedBy) > 0 {
+		followed, err := a.tellStatus(ctx, status.FollowedBy[0])
+		if err != nil {
+			break
+		}
+		status = followed
+	}
+
+	info := a.toDownloadInfo(status)
+	info.ID = id
+	return &info, nil
+}
+
+func (a *Aria2Client) tellStatus(ctx context.Context, gid string) (*aria2Status, error) {
+	result, err := a.call(ctx, "aria2.tellStatus", gid, aria2StatusKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var status aria2Status
+	if err := json.Unmarshal(result, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (a *Aria2Client) toDownloadInfo(status *aria2Status) DownloadInfo {
+	size, _ := strconv.ParseInt(status.TotalLength, 10, 64)
+	done, _ := strconv.ParseInt(status.CompletedLength, 10, 64)
+	speed, _ := strconv.ParseInt(status.DownloadSpeed, 10, 64)
+
+	info := DownloadInfo{
+		ID:            status.GID,
+		Name:          a.downloadName(status),
+		Size:          size,
+		Downloaded:    done,
+		Status:        a.mapStatus(status),
+		DownloadSpeed: speed,
+		SavePath:      status.Dir,
+		Error:         status.ErrorMessage,
+	}
+	if size > 0 {
+		info.Progress = float64(done) / float64(size)
+	}
+	if uploaded, _ := strconv.ParseInt(status.UploadLength, 10, 64); uploaded > 0 && done > 0 {
+		info.Ratio = float64(uploaded) / float64(done)
+	}
+	if info.Status == StatusCompleted {
+		info.Progress = 1.0
+	}
+	if speed > 0 && size > done {
+		info.ETA = (size - done) / speed
+	}
+	return info
+}
+
+// downloadName returns the torrent's name, or the file's path relative to its folder
+func (a *Aria2Client) downloadName(status *aria2Status) string {
+	if status.Bittorrent != nil && status.Bittorrent.Info.Name != "" {
+		return status.Bittorrent.Info.Name
+	}
+	if len(status.Files) > 0 && status.Files[0].Path != "" {
+		if rel, err := filepath.Rel(status.Dir, status.Files[0].Path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+		return filepath.Base(status.Files[0].Path)
+	}
+	return ""
+}
+
+func (a *Aria2Client) mapStatus(status *aria2Status) DownloadStatus {
+	switch status.Status {
+	case "active":
+		// Torrents keep seeding once they finish
+		if status.Seeder == "true" {
+			return StatusCompleted
+		}
+		return StatusDownloading
+	case "waiting":
+		return StatusQueued
+	case "paused":
+		return StatusPaused
+	case "complete":
+		return StatusCompleted
+	case "error", "removed":
+		return StatusFailed
+	default:
+		return StatusDownloading
+	}
+}
+
+// GetAllDownloads implements the Client interface. aria2 has no categories, so every
+// download is returned.
+func (a *Aria2Client) GetAllDownloads(ctx context.Context, category string) ([]DownloadInfo, error) {
+	var statuses []aria2Status
+	for _, call := range []struct {
+		method string
+		params []interface{}
+	}{
+		{"aria2.tellActive", []interface{}{aria2StatusKeys}},
+		{"aria2.tellWaiting", []interface{}{0, 1000, aria2StatusKeys}},
+		{"aria2.tellStopped", []interface{}{0, 1000, aria2StatusKeys}},
+	} {
+		result, err := a.call(ctx, call.method, call.params...)
+		if err != nil {
+			return nil, err
+		}
+		var batch []aria2Status
+		if err := json.Unmarshal(result, &batch); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, batch...)
+	}
+
+	downloads := make([]DownloadInfo, 0, len(statuses))
+	for i := range statuses {
+		// Metadata downloads are reported through the torrent that follows them
+		if len(statuses[i].FollowedBy) > 0 {
+			continue
+		}
+		downloads = append(downloads, a.toDownloadInfo(&statuses[i]))
+	}
+
+	return downloads, nil
+}
+
+// RemoveDownload implements the Client interface. aria2 never deletes files itself, so
+// deleteFiles removes them once the download is gone.
+func (a *Aria2Client) RemoveDownload(ctx context.Context, id string, deleteFiles bool) error {
+	gids := []string{id}
+	var files []string
+	if status, err := a.tellStatus(ctx, id); err == nil {
+		gids = append(gids, status.FollowedBy...)
+	}
+	if deleteFiles {
+		for _, gid := range gids {
+			if status, err := a.tellStatus(ctx, gid); err == nil {
+				if name := a.downloadName(status); name != "" {
+					files = append(files, filepath.Join(status.Dir, name))
+				}
+			}
+		}
+	}
+
+	var removeErr error
+	for _, gid := range gids {
+		// Active downloads are stopped first; stopped ones only have their result to clear
+		_, stopErr := a.call(ctx, "aria2.forceRemove", gid)
+		if _, err := a.call(ctx, "aria2.removeDownloadResult", gid); err != nil && stopErr != nil && gid == id {
+			removeErr = err
+		}
+	}
+
+	for _, path := range files {
+		if err := os.RemoveAll(path); err != nil && removeErr == nil {
+			removeErr = err
+		}
+	}
+	return removeErr
+}
+
+// PauseDownload implements the Client interface
+func (a *Aria2Client) PauseDownload(ctx context.Context, id string) error {
+	_, err := a.call(ctx, "aria2.pause", a.currentGID(ctx, id))
+	return err
+}
+
+// ResumeDownload implements the Client interface
+func (a *Aria2Client) ResumeDownload(ctx context.Context, id string) error {
+	_, err := a.call(ctx, "aria2.unpause", a.currentGID(ctx, id))
+	return err
+}
+
+// currentGID returns the download a magnet or .torrent URL's GID has been followed by
+func (a *Aria2Client) currentGID(ctx context.Context, id string) string {
+	gid := id
+	for {
+		status, err := a.tellStatus(ctx, gid)
+		if err != nil || len(status.FollowedBy) == 0 {
+			return gid
+		}
+		gid = status.FollowedBy[0]
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -180,19 +181,30 @@ func (d *DelugeClient) AddDownload(ctx context.Context, url string, opts *Downlo
 		options["download_location"] = opts.SavePath
 	}
 
-	// Add torrent by URL
-	result, err := d.call(ctx, "web.add_torrents", []map[string]interface{}{
-		{
-			"path":    url,
-			"options": options,
-		},
-	})
+	// Resolve the torrent so its hash is known even if Deluge doesn't return it
+	torrent, err := resolveTorrent(ctx, url, opts)
 	if err != nil {
-		// Try alternative method for magnet/URL
-		result, err = d.call(ctx, "core.add_torrent_url", url, options)
+		return "", err
+	}
+
+	var result json.RawMessage
+	if torrent.IsMagnet() {
+		result, err = d.call(ctx, "web.add_torrents", []map[string]interface{}{
+			{
+				"path":    torrent.URL,
+				"options": options,
+			},
+		})
 		if err != nil {
-			return "", fmt.Errorf("failed to add torrent: %w", err)
+			// Try alternative method for magnets
+			result, err = d.call(ctx, "core.add_torrent_magnet", torrent.URL, options)
 		}
+	} else {
+		result, err = d.call(ctx, "core.add_torrent_file", torrent.InfoHash+".torrent",
+			base64.StdEncoding.EncodeToString(torrent.Data), options)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to add torrent: %w", err)
 	}
 
 	// Parse the result to get torrent ID
@@ -210,7 +222,7 @@ func (d *DelugeClient) AddDownload(ctx context.Context, url string, opts *Downlo
 	}
 
 	if torrentID == "" {
-		torrentID = url // Use URL as fallback ID
+		torrentID = torrent.InfoHash
 	}
 
 	return torrentID, nil
@@ -224,7 +236,7 @@ func (d *DelugeClient) GetDownload(ctx context.Context, id string) (*DownloadInf
 
 	keys := []string{
 		"name", "total_size", "progress", "state", "download_payload_rate",
-		"eta", "save_path", "total_done", "label",
+		"eta", "save_path", "total_done", "label", "ratio",
 	}
 
 	result, err := d.call(ctx, "core.get_torrent_status", id, keys)
@@ -249,6 +261,7 @@ func (d *DelugeClient) GetDownload(ctx context.Context, id string) (*DownloadInf
 		Status:   d.mapState(getString(status, "state")),
 		SavePath: getString(status, "save_path"),
 		Category: getString(status, "label"),
+		Ratio:    getFloat64(status, "ratio"),
 	}
 
 	if eta, ok := status["eta"].(float64); ok && eta > 0 {
@@ -293,7 +306,7 @@ func (d *DelugeClient) GetAllDownloads(ctx context.Context, category string) ([]
 
 	keys := []string{
 		"name", "total_size", "progress", "state", "download_payload_rate",
-		"eta", "save_path", "total_done", "label", "hash",
+		"eta", "save_path", "total_done", "label", "hash", "ratio",
 	}
 
 	filterDict := make(map[string]interface{})
@@ -324,6 +337,7 @@ func (d *DelugeClient) GetAllDownloads(ctx context.Context, category string) ([]
 			SavePath:      getString(status, "save_path"),
 			Downloaded:    getInt64(status, "total_done"),
 			Category:      getString(status, "label"),
+			Ratio:         getFloat64(status, "ratio"),
 		}
 		downloads = append(downloads, info)
 	}
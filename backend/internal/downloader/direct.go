@@ -0,0 +1,485 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDirectConcurrency is how many files a direct client downloads at once by default
+const DefaultDirectConcurrency = 3
+
+// directRetries is how many times a failed transfer is resumed before the download fails
+const directRetries = 3
+
+// DirectClient downloads files over plain HTTP into a folder, for sources no torrent or
+// usenet client handles (Anna's Archive, Libgen, publisher freebies). Partial files are
+// kept as <id>.part, so paused, interrupted and failed downloads resume where they
+// stopped, and the ID is derived from the URL so that holds across restarts too.
+type DirectClient struct {
+	folder        string
+	httpClient    *http.Client
+	maxConcurrent int
+	active        int
+	order         []string
+	downloads     map[string]*directDownload
+	mutex         sync.Mutex
+}
+
+// directDownload is the state of one download
+type directDownload struct {
+	id         string
+	url        string
+	dir        string
+	category   string
+	name       string
+	fallback   string // File name used when the server doesn't give one
+	size       int64
+	downloaded int64
+	status     DownloadStatus
+	err        string
+	started    time.Time
+	startBytes int64
+	cancel     context.CancelFunc
+}
+
+// directClients holds a client per folder; download state lives in memory, while
+// clients are created from the database for each request
+var directClients = struct {
+	sync.Mutex
+	clients map[string]*DirectClient
+}{clients: make(map[string]*DirectClient)}
+
+// NewDirectClient returns the direct client for a folder. maxConcurrent limits how many
+// files download at once; 0 keeps the current limit.
+func NewDirectClient(folder string, maxConcurrent int) *DirectClient {
+	folder = filepath.Clean(folder)
+
+	directClients.Lock()
+	client, ok := directClients.clients[folder]
+	if !ok {
+		client = &DirectClient{
+			folder: folder,
+			// No overall timeout: audiobooks can take a long time
+			httpClient: &http.Client{Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: 60 * time.Second,
+			}},
+			maxConcurrent: DefaultDirectConcurrency,
+			downloads:     make(map[string]*directDownload),
+		}
+		directClients.clients[folder] = client
+	}
+	directClients.Unlock()
+
+	if maxConcurrent > 0 {
+		client.mutex.Lock()
+		client.maxConcurrent = maxConcurrent
+		client.mutex.Unlock()
+		client.schedule()
+	}
+	return client
+}
+
+func (c *DirectClient) Type() string {
+	return "direct"
+}
+
+// Test checks the download folder can be written to
+func (c *DirectClient) Test(ctx context.Context) error {
+	if err := os.MkdirAll(c.folder, 0755); err != nil {
+		return fmt.Errorf("cannot create download folder: %w", err)
+	}
+	file, err := os.CreateTemp(c.folder, ".shelfarr-test-*")
+	if err != nil {
+		return fmt.Errorf("download folder is not writable: %w", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// AddDownload queues a URL. The category, if any, is a subfolder of the client's folder,
+// unless a save path is given. Adding a URL that's already tracked returns its ID.
+func (c *DirectClient) AddDownload(ctx context.Context, fileURL string, opts *DownloadOptions) (string, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	u, err := url.Parse(fileURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid download URL: %s", fileURL)
+	}
+
+	dir := c.folder
+	if opts.SavePath != "" {
+		dir = opts.SavePath
+	} else if opts.Category != "" {
+		dir = filepath.Join(dir, sanitizeFileName(opts.Category))
+	}
+
+	sum := sha1.Sum([]byte(fileURL))
+	id := hex.EncodeToString(sum[:8])
+
+	c.mutex.Lock()
+	if _, ok := c.downloads[id]; ok {
+		c.mutex.Unlock()
+		return id, nil
+	}
+	d := &directDownload{
+		id:       id,
+		url:      fileURL,
+		dir:      dir,
+		category: opts.Category,
+		name:     id,
+		fallback: opts.FileName,
+		status:   StatusQueued,
+	}
+	if opts.Paused {
+		d.status = StatusPaused
+	}
+	if info, err := os.Stat(d.partPath()); err == nil {
+		d.downloaded = info.Size()
+	}
+	c.downloads[id] = d
+	c.order = append(c.order, id)
+	c.mutex.Unlock()
+
+	c.schedule()
+	return id, nil
+}
+
+func (c *DirectClient) GetDownload(ctx context.Context, id string) (*DownloadInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	d, ok := c.downloads[id]
+	if !ok {
+		return nil, fmt.Errorf("download not found: %s", id)
+	}
+	info := d.info()
+	return &info, nil
+}
+
+// GetAllDownloads returns the downloads, limited to a category's subfolder if given
+func (c *DirectClient) GetAllDownloads(ctx context.Context, category string) ([]DownloadInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var infos []DownloadInfo
+	for _, id := range c.order {
+		info := c.downloads[id].info()
+		if category == "" || info.Category == category {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (c *DirectClient) RemoveDownload(ctx context.Context, id string, deleteFiles bool) error {
+	c.mutex.Lock()
+	d, ok := c.downloads[id]
+	if !ok {
+		c.mutex.Unlock()
+		return fmt.Errorf("download not found: %s", id)
+	}
+	delete(c.downloads, id)
+	for i, orderID := range c.order {
+		if orderID == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	if d.cancel != nil {
+		d.cancel()
+	}
+	status := d.status
+	c.mutex.Unlock()
+
+	// Partial files are useless once the download is gone
+	os.Remove(d.partPath())
+	if deleteFiles && status == StatusCompleted {
+		os.Remove(filepath.Join(d.dir, d.name))
+	}
+	return nil
+}
+
+func (c *DirectClient) PauseDownload(ctx context.Context, id string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	d, ok := c.downloads[id]
+	if !ok {
+		return fmt.Errorf("download not found: %s", id)
+	}
+	if d.status != StatusQueued && d.status != StatusDownloading {
+		return nil
+	}
+	d.status = StatusPaused
+	if d.cancel != nil {
+		d.cancel()
+	}
+	return nil
+}
+
+func (c *DirectClient) ResumeDownload(ctx context.Context, id string) error {
+	c.mutex.Lock()
+	d, ok := c.downloads[id]
+	if !ok {
+		c.mutex.Unlock()
+		return fmt.Errorf("download not found: %s", id)
+	}
+	// Failed downloads resume too, from what was already fetched
+	if d.status == StatusPaused || d.status == StatusFailed {
+		d.status = StatusQueued
+		d.err = ""
+	}
+	c.mutex.Unlock()
+
+	c.schedule()
+	return nil
+}
+
+// schedule starts queued downloads, oldest first, while under the concurrency limit
+func (c *DirectClient) schedule() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, id := range c.order {
+		if c.active >= c.maxConcurrent {
+			return
+		}
+		d := c.downloads[id]
+		if d.status != StatusQueued {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		d.status = StatusDownloading
+		d.cancel = cancel
+		d.started = time.Now()
+		d.startBytes = d.downloaded
+		c.active++
+		go c.run(ctx, d)
+	}
+}
+
+// run downloads a file, resuming after transfer errors
+func (c *DirectClient) run(ctx context.Context, d *directDownload) {
+	var err error
+	for attempt := 0; attempt < directRetries; attempt++ {
+		if err = c.fetch(ctx, d); err == nil || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(attempt+1) * 5 * time.Second):
+		}
+	}
+
+	c.mutex.Lock()
+	c.active--
+	d.cancel = nil
+	// Pausing and removing set the status themselves
+	if ctx.Err() == nil {
+		if err != nil {
+			d.status = StatusFailed
+			d.err = err.Error()
+		} else {
+			d.status = StatusCompleted
+		}
+	}
+	c.mutex.Unlock()
+
+	c.schedule()
+}
+
+// fetch transfers the rest of a file into its .part file, then renames it to the name
+// the server gives it
+func (c *DirectClient) fetch(ctx context.Context, d *directDownload) error {
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create download folder: %w", err)
+	}
+
+	file, err := os.OpenFile(d.partPath(), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range, so start over
+		if offset > 0 {
+			if err := file.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset = 0
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Everything was already fetched before an interruption
+		if offset == 0 {
+			return fmt.Errorf("server returned status %d", resp.StatusCode)
+		}
+		file.Close()
+		return c.finish(d, resp)
+	default:
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return fmt.Errorf("server returned a web page instead of a file")
+	}
+
+	c.mutex.Lock()
+	d.downloaded = offset
+	if resp.ContentLength > 0 {
+		d.size = offset + resp.ContentLength
+	}
+	c.mutex.Unlock()
+
+	if _, err := io.Copy(file, &directProgress{reader: resp.Body, client: c, download: d}); err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return c.finish(d, resp)
+}
+
+// finish renames a complete .part file after the server's file name, the URL's, or the
+// fallback name the download was added with
+func (c *DirectClient) finish(d *directDownload, resp *http.Response) error {
+	name := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = filepath.Base(params["filename"])
+	}
+	if name == "" || name == "." || name == "/" {
+		if base, err := url.PathUnescape(path.Base(resp.Request.URL.Path)); err == nil && path.Ext(base) != "" {
+			name = base
+		}
+	}
+	if name == "" || name == "." || name == "/" {
+		name = d.fallback
+	}
+	if name == "" {
+		name = d.id
+	}
+	name = sanitizeFileName(name)
+
+	// Don't overwrite an earlier download with the same name
+	final := filepath.Join(d.dir, name)
+	ext := filepath.Ext(name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(final); os.IsNotExist(err) {
+			break
+		}
+		final = filepath.Join(d.dir, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), i, ext))
+	}
+	if err := os.Rename(d.partPath(), final); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	d.name = filepath.Base(final)
+	if d.size == 0 {
+		d.size = d.downloaded
+	}
+	c.mutex.Unlock()
+	return nil
+}
+
+func (d *directDownload) partPath() string {
+	return filepath.Join(d.dir, d.id+".part")
+}
+
+// info reports a download's state; the caller must hold the client's mutex
+func (d *directDownload) info() DownloadInfo {
+	info := DownloadInfo{
+		ID:         d.id,
+		Name:       d.name,
+		Size:       d.size,
+		Downloaded: d.downloaded,
+		Status:     d.status,
+		SavePath:   d.dir,
+		Category:   d.category,
+		Error:      d.err,
+	}
+	if d.size > 0 {
+		info.Progress = float64(d.downloaded) / float64(d.size)
+	}
+	if d.status == StatusCompleted {
+		info.Progress = 1.0
+	}
+	if d.status == StatusDownloading {
+		if elapsed := time.Since(d.started).Seconds(); elapsed >= 1 {
+			info.DownloadSpeed = int64(float64(d.downloaded-d.startBytes) / elapsed)
+		}
+		if info.DownloadSpeed > 0 && d.size > 0 {
+			info.ETA = (d.size - d.downloaded) / info.DownloadSpeed
+		}
+	}
+	return info
+}
+
+// directProgress counts the bytes of a download as they're read
+type directProgress struct {
+	reader   io.Reader
+	client   *DirectClient
+	download *directDownload
+}
+
+func (p *directProgress) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	p.client.mutex.Lock()
+	p.download.downloaded += int64(n)
+	p.client.mutex.Unlock()
+	return n, err
+}
+
+// sanitizeFileName replaces characters that aren't allowed in file names
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "download"
+	}
+	return name
+}
@@ -61,7 +61,14 @@ type DownloadOptions struct {
 	Category string
 	SavePath string
 	Paused   bool
-	Priority int // 0 = normal, 1 = high, -1 = low
+	Priority int    // 0 = normal, 1 = high, -1 = low
+	FileName string // Direct downloads: name to save as when the server doesn't give one
+
+	// Torrent is the torrent the caller already resolved, so the client doesn't
+	// download it again. Clients resolve it themselves when it's nil.
+	Torrent *TorrentFile
+	// NZB is an NZB file the caller already fetched, for usenet clients
+	NZB *NZBFile
 }
 
 // DownloadInfo holds information about a download
@@ -76,6 +83,8 @@ type DownloadInfo struct {
 	ETA           int64 // seconds
 	SavePath      string
 	Category      string
+	Error         string  // Why a failed download stopped, when the client reports it
+	Ratio         float64 // Upload ratio, for torrents
 }
 
 // Manager manages multiple download clients and downloads
@@ -215,7 +224,39 @@ func CreateClientFromDB(clientType, url, username, password string) (Client, err
 		return NewSABnzbdClient(url, password), nil // password is API key for SABnzbd
 	case "deluge":
 		return NewDelugeClient(url, password), nil
+	case "rtorrent":
+		return NewRTorrentClient(url, username, password), nil // url is the XML-RPC endpoint
+	case "direct":
+		return NewDirectClient(url, 0), nil // url is the download folder
+	case "aria2":
+		return NewAria2Client(url, password), nil // password is the RPC secret
+	case "synology":
+		return NewSynologyClient(url, username, password), nil
 	default:
 		return nil, fmt.Errorf("unsupported client type: %s", clientType)
 	}
 }
+
+// ClientProtocol returns the protocol a client type downloads: "usenet" for SABnzbd and
+// NZBGet, "direct" for HTTP downloads, "torrent" for the rest. aria2 also takes direct
+// downloads; see HandlesDirect.
+func ClientProtocol(clientType string) string {
+	switch clientType {
+	case "sabnzbd", "nzbget":
+		return "usenet"
+	case "direct":
+		return "direct"
+	default:
+		return "torrent"
+	}
+}
+
+// CircuitName is the name of a download client's circuit in the health tracker
+func CircuitName(name string) string {
+	return "downloadclient:" + name
+}
+
+// HandlesDirect reports whether a client type can fetch direct (HTTP) downloads
+func HandlesDirect(clientType string) bool {
+	return clientType == "direct" || clientType == "aria2"
+}
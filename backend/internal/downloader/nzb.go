@@ -0,0 +1,78 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// NZBFile is an NZB fetched by Shelfarr, for indexers whose links the download client
+// can't fetch itself
+type NZBFile struct {
+	Name string // File name, ending in .nzb
+	Data []byte
+}
+
+// Authenticate adds an indexer's credentials, such as a session cookie, to a request
+// for one of its files
+type Authenticate func(req *http.Request)
+
+var nzbHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// FetchNZB downloads an NZB file, authenticating the request if authenticate is set
+func FetchNZB(ctx context.Context, nzbURL string, authenticate Authenticate) (*NZBFile, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", nzbURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authenticate != nil {
+		authenticate(req)
+	}
+
+	resp, err := nzbHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download NZB: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download NZB: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 50<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download NZB: %w", err)
+	}
+	// Indexers answer bad links with an HTML or JSON error rather than a status
+	if head := strings.ToLower(string(data[:min(len(data), 4096)])); !strings.Contains(head, "<nzb") {
+		return nil, fmt.Errorf("indexer did not return an NZB file")
+	}
+
+	return &NZBFile{Name: nzbFileName(resp, nzbURL), Data: data}, nil
+}
+
+// nzbFileName takes the file name from the response's Content-Disposition, or the URL
+func nzbFileName(resp *http.Response, nzbURL string) string {
+	name := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		if u, err := url.Parse(nzbURL); err == nil {
+			name = path.Base(u.Path)
+		}
+	}
+	if name == "" || name == "/" || name == "." {
+		name = "download"
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".nzb") {
+		name += ".nzb"
+	}
+	return name
+}
@@ -206,13 +206,23 @@ func (q *QBittorrentClient) AddDownload(ctx context.Context, url string, opts *D
 		addOpts.Paused = opts.Paused
 	}
 	
-	if err := q.AddTorrentByURL(ctx, url, addOpts); err != nil {
+	// qBittorrent doesn't return the hash on add, so the torrent is resolved first to
+	// learn it. Torrent files are uploaded rather than fetched a second time.
+	torrent, err := resolveTorrent(ctx, url, opts)
+	if err != nil {
 		return "", err
 	}
-	
-	// qBittorrent doesn't return the hash on add, so we return the URL as identifier
-	// The actual hash would need to be fetched separately
-	return url, nil
+
+	if torrent.IsMagnet() {
+		err = q.AddTorrentByURL(ctx, torrent.URL, addOpts)
+	} else {
+		err = q.AddTorrentFile(ctx, torrent.InfoHash+".torrent", torrent.Data, addOpts)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return torrent.InfoHash, nil
 }
 
 // GetDownload implements the Client interface
@@ -224,13 +234,14 @@ func (q *QBittorrentClient) GetDownload(ctx context.Context, id string) (*Downlo
 	
 	status := StatusDownloading
 	switch torrent.State {
-	case "pausedDL", "pausedUP":
+	case "pausedDL", "stoppedDL":
 		status = StatusPaused
-	case "stalledDL", "stalledUP", "uploading", "seeding":
+	case "stalledUP", "uploading", "forcedUP", "queuedUP", "pausedUP", "stoppedUP":
+		// Finished torrents stay complete while seeding, even when paused or queued
 		status = StatusCompleted
 	case "error":
 		status = StatusFailed
-	case "queuedDL", "queuedUP", "checkingDL", "checkingUP":
+	case "queuedDL", "checkingDL", "checkingUP":
 		status = StatusQueued
 	}
 	
@@ -245,6 +256,7 @@ func (q *QBittorrentClient) GetDownload(ctx context.Context, id string) (*Downlo
 		ETA:           torrent.ETA,
 		SavePath:      torrent.SavePath,
 		Category:      torrent.Category,
+		Ratio:         torrent.Ratio,
 	}, nil
 }
 
@@ -377,6 +389,13 @@ func (q *QBittorrentClient) AddTorrentFile(ctx context.Context, filename string,
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusForbidden {
+		if err := q.Login(ctx); err != nil {
+			return err
+		}
+		return q.AddTorrentFile(ctx, filename, torrentData, opts)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("add torrent failed: %s", string(respBody))
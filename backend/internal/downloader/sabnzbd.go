@@ -1,10 +1,12 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
@@ -111,7 +113,16 @@ func (s *SABnzbdClient) AddDownload(ctx context.Context, downloadURL string, opt
 		}
 	}
 
-	resp, err := s.request(ctx, "addurl", params)
+	// NZBs the caller already fetched are uploaded; SABnzbd can't fetch links that need
+	// an indexer's session
+	var resp []byte
+	var err error
+	if opts != nil && opts.NZB != nil {
+		delete(params, "name")
+		resp, err = s.upload(ctx, "addfile", params, opts.NZB)
+	} else {
+		resp, err = s.request(ctx, "addurl", params)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -279,6 +290,39 @@ func (s *SABnzbdClient) request(ctx context.Context, mode string, params map[str
 		return nil, err
 	}
 
+	return s.do(req)
+}
+
+// upload makes an API request that posts an NZB file
+func (s *SABnzbdClient) upload(ctx context.Context, mode string, params map[string]string, nzb *NZBFile) ([]byte, error) {
+	q := url.Values{}
+	q.Set("output", "json")
+	q.Set("apikey", s.apiKey)
+	q.Set("mode", mode)
+	for k, v := range params {
+		q.Set(k, v)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("name", nzb.Name)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(nzb.Data)
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/api?"+q.Encode(), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return s.do(req)
+}
+
+// do sends an API request and checks the response for an error
+func (s *SABnzbdClient) do(req *http.Request) ([]byte, error) {
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, err
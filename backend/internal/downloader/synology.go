@@ -0,0 +1,434 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SynologyClient handles communication with Synology Download Station's Web API
+type SynologyClient struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+	sid        string // Session ID
+}
+
+// NewSynologyClient creates a new Download Station client. baseURL is the DSM address,
+// e.g. http://nas:5000.
+func NewSynologyClient(baseURL, username, password string) *SynologyClient {
+	return &SynologyClient{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// synologyResponse is the envelope of every Download Station API response
+type synologyResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+// SynologyTask represents a task in Download Station
+type SynologyTask struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Size        int64  `json:"size"`
+	Status      string `json:"status"`
+	StatusExtra *struct {
+		ErrorDetail string `json:"error_detail"`
+	} `json:"status_extra"`
+	Additional struct {
+		Detail struct {
+			Destination string `json:"destination"`
+			URI         string `json:"uri"`
+			CreateTime  int64  `json:"create_time"`
+		} `json:"detail"`
+		Transfer struct {
+			SizeDownloaded int64 `json:"size_downloaded"`
+			SizeUploaded   int64 `json:"size_uploaded"`
+			SpeedDownload  int64 `json:"speed_download"`
+		} `json:"transfer"`
+	} `json:"additional"`
+}
+
+// synologyErrors describes the API's error codes. Codes below 400 are shared by all
+// APIs, 400-403 are login errors and the rest come from the task API.
+var synologyErrors = map[int]string{
+	100: "unknown error",
+	101: "invalid parameter",
+	102: "the requested API does not exist",
+	103: "the requested method does not exist",
+	104: "the requested version does not support this method",
+	105: "the user does not have permission",
+	106: "session timeout",
+	107: "session interrupted by duplicate login",
+	119: "session ID not found",
+	400: "invalid username or password",
+	401: "account disabled",
+	402: "permission denied",
+	403: "2-step verification is required",
+	404: "failed to authenticate 2-step verification code",
+	405: "file upload failed",
+	406: "max number of tasks reached",
+	407: "destination denied",
+	408: "destination does not exist",
+	409: "invalid task ID",
+	410: "invalid task action",
+	411: "no default destination",
+}
+
+// Login authenticates with DSM and starts a Download Station session
+func (s *SynologyClient) Login(ctx context.Context) error {
+	params := url.Values{
+		"api":     {"SYNO.API.Auth"},
+		"version": {"3"},
+		"method":  {"login"},
+		"account": {s.username},
+		"passwd":  {s.password},
+		"session": {"DownloadStation"},
+		"format":  {"sid"},
+	}
+
+	data, err := s.request(ctx, "auth.cgi", params)
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	var login struct {
+		SID string `json:"sid"`
+	}
+	if err := json.Unmarshal(data, &login); err != nil || login.SID == "" {
+		return fmt.Errorf("login failed: no session ID returned")
+	}
+	s.sid = login.SID
+	return nil
+}
+
+// request calls a Web API endpoint and returns the response's data
+func (s *SynologyClient) request(ctx context.Context, path string, params url.Values) (json.RawMessage, error) {
+	if s.sid != "" {
+		params.Set("_sid", s.sid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/webapi/"+path, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var synoResp synologyResponse
+	if err := json.Unmarshal(body, &synoResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w (status: %d)", err, resp.StatusCode)
+	}
+
+	if !synoResp.Success {
+		return nil, synologyError(synoResp)
+	}
+
+	return synoResp.Data, nil
+}
+
+// synologyError describes a failed response's error code
+func synologyError(synoResp synologyResponse) error {
+	code := 100
+	if synoResp.Error != nil {
+		code = synoResp.Error.Code
+	}
+	message, ok := synologyErrors[code]
+	if !ok {
+		message = "unknown error"
+	}
+	return fmt.Errorf("download station error: %s (code: %d)", message, code)
+}
+
+// task calls a method of the Download Station task API, logging in if needed
+func (s *SynologyClient) task(ctx context.Context, method string, params url.Values) (json.RawMessage, error) {
+	if s.sid == "" {
+		if err := s.Login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	params.Set("api", "SYNO.DownloadStation.Task")
+	params.Set("version", "1")
+	params.Set("method", method)
+	return s.request(ctx, "DownloadStation/task.cgi", params)
+}
+
+// Test checks if the connection is working
+func (s *SynologyClient) Test(ctx context.Context) error {
+	_, err := s.task(ctx, "list", url.Values{"limit": {"1"}})
+	return err
+}
+
+// Type returns the client type
+func (s *SynologyClient) Type() string {
+	return "synology"
+}
+
+// AddDownload implements the Client interface. Download Station doesn't return the
+// new task's ID, so the task is found by its URL afterwards. Torrent files the caller
+// already fetched are uploaded, and found as the task that wasn't there before.
+func (s *SynologyClient) AddDownload(ctx context.Context, url string, opts *DownloadOptions) (string, error) {
+	params := map[string][]string{"uri": {url}}
+	if opts != nil && opts.SavePath != "" {
+		// Destinations are shared folder paths without the leading slash, e.g. downloads/books
+		params["destination"] = []string{strings.TrimPrefix(opts.SavePath, "/")}
+	}
+
+	var found *SynologyTask
+	if opts != nil && opts.Torrent != nil && !opts.Torrent.IsMagnet() {
+		existing := make(map[string]bool)
+		tasks, err := s.listTasks(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, task := range tasks {
+			existing[task.ID] = true
+		}
+
+		delete(params, "uri")
+		if err := s.uploadTorrent(ctx, params, opts.Torrent); err != nil {
+			return "", fmt.Errorf("failed to add task: %w", err)
+		}
+
+		if tasks, err = s.listTasks(ctx); err != nil {
+			return "", err
+		}
+		for i := range tasks {
+			if !existing[tasks[i].ID] {
+				found = &tasks[i]
+				break
+			}
+		}
+	} else {
+		if opts != nil && opts.Torrent != nil {
+			params["uri"] = []string{opts.Torrent.URL}
+			url = opts.Torrent.URL
+		}
+		if _, err := s.task(ctx, "create", params); err != nil {
+			return "", fmt.Errorf("failed to add task: %w", err)
+		}
+
+		tasks, err := s.listTasks(ctx)
+		if err != nil {
+			return "", err
+		}
+		for i := range tasks {
+			if tasks[i].Additional.Detail.URI != url {
+				continue
+			}
+			if found == nil || tasks[i].Additional.Detail.CreateTime > found.Additional.Detail.CreateTime {
+				found = &tasks[i]
+			}
+		}
+	}
+	if found == nil {
+		return "", fmt.Errorf("task was added but could not be found")
+	}
+
+	if opts != nil && opts.Paused {
+		s.PauseDownload(ctx, found.ID)
+	}
+
+	return found.ID, nil
+}
+
+// uploadTorrent creates a task from a torrent file
+func (s *SynologyClient) uploadTorrent(ctx context.Context, params url.Values, torrent *TorrentFile) error {
+	if s.sid == "" {
+		if err := s.Login(ctx); err != nil {
+			return err
+		}
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range map[string]string{
+		"api":     "SYNO.DownloadStation.Task",
+		"version": "1",
+		"method":  "create",
+		"_sid":    s.sid,
+	} {
+		writer.WriteField(key, value)
+	}
+	for key := range params {
+		writer.WriteField(key, params.Get(key))
+	}
+	// The file must be the last field
+	part, err := writer.CreateFormFile("file", torrent.InfoHash+".torrent")
+	if err != nil {
+		return err
+	}
+	part.Write(torrent.Data)
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/webapi/DownloadStation/task.cgi", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var synoResp synologyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&synoResp); err != nil {
+		return fmt.Errorf("failed to parse response: %w (status: %d)", err, resp.StatusCode)
+	}
+	if !synoResp.Success {
+		return synologyError(synoResp)
+	}
+	return nil
+}
+
+// listTasks returns every task with its details and transfer info
+func (s *SynologyClient) listTasks(ctx context.Context) ([]SynologyTask, error) {
+	data, err := s.task(ctx, "list", url.Values{"additional": {"detail,transfer"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Tasks []SynologyTask `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse tasks: %w", err)
+	}
+	return list.Tasks, nil
+}
+
+// GetDownload implements the Client interface
+func (s *SynologyClient) GetDownload(ctx context.Context, id string) (*DownloadInfo, error) {
+	data, err := s.task(ctx, "getinfo", url.Values{"id": {id}, "additional": {"detail,transfer"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Tasks []SynologyTask `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse task: %w", err)
+	}
+	if len(info.Tasks) == 0 {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+
+	download := s.toDownloadInfo(&info.Tasks[0])
+	return &download, nil
+}
+
+func (s *SynologyClient) toDownloadInfo(task *SynologyTask) DownloadInfo {
+	info := DownloadInfo{
+		ID:            task.ID,
+		Name:          task.Title,
+		Size:          task.Size,
+		Downloaded:    task.Additional.Transfer.SizeDownloaded,
+		Status:        s.mapStatus(task.Status),
+		DownloadSpeed: task.Additional.Transfer.SpeedDownload,
+		SavePath:      task.Additional.Detail.Destination,
+	}
+	if task.Size > 0 {
+		info.Progress = float64(info.Downloaded) / float64(task.Size)
+	}
+	if info.Downloaded > 0 {
+		info.Ratio = float64(task.Additional.Transfer.SizeUploaded) / float64(info.Downloaded)
+	}
+	if info.Status == StatusCompleted {
+		info.Progress = 1.0
+	}
+	if info.DownloadSpeed > 0 && task.Size > info.Downloaded {
+		info.ETA = (task.Size - info.Downloaded) / info.DownloadSpeed
+	}
+	if info.Status == StatusFailed && task.StatusExtra != nil {
+		info.Error = task.StatusExtra.ErrorDetail
+	}
+	return info
+}
+
+func (s *SynologyClient) mapStatus(status string) DownloadStatus {
+	switch status {
+	case "waiting", "filehosting_waiting":
+		return StatusQueued
+	case "downloading", "hash_checking", "finishing", "extracting":
+		return StatusDownloading
+	case "paused":
+		return StatusPaused
+	case "finished", "seeding":
+		return StatusCompleted
+	case "error":
+		return StatusFailed
+	default:
+		return StatusDownloading
+	}
+}
+
+// GetAllDownloads implements the Client interface. Download Station has no
+// categories, so every task is returned.
+func (s *SynologyClient) GetAllDownloads(ctx context.Context, category string) ([]DownloadInfo, error) {
+	tasks, err := s.listTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	downloads := make([]DownloadInfo, 0, len(tasks))
+	for i := range tasks {
+		downloads = append(downloads, s.toDownloadInfo(&tasks[i]))
+	}
+	return downloads, nil
+}
+
+// RemoveDownload implements the Client interface. Download Station only removes the
+// task; downloaded files stay in the destination folder either way.
+func (s *SynologyClient) RemoveDownload(ctx context.Context, id string, deleteFiles bool) error {
+	_, err := s.task(ctx, "delete", url.Values{"id": {id}, "force_complete": {"false"}})
+	return err
+}
+
+// PauseDownload implements the Client interface
+func (s *SynologyClient) PauseDownload(ctx context.Context, id string) error {
+	_, err := s.task(ctx, "pause", url.Values{"id": {id}})
+	return err
+}
+
+// ResumeDownload implements the Client interface
+func (s *SynologyClient) ResumeDownload(ctx context.Context, id string) error {
+	_, err := s.task(ctx, "resume", url.Values{"id": {id}})
+	return err
+}
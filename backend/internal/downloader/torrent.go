@@ -0,0 +1,90 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/torrent"
+)
+
+// TorrentFile is a torrent resolved from an indexer's download URL, so its info hash is
+// known before it's handed to a download client
+type TorrentFile struct {
+	URL      string // Magnet link, or the URL the torrent file was fetched from
+	Data     []byte // Torrent file contents; nil for magnets
+	InfoHash string // Lowercase hex
+}
+
+// IsMagnet reports whether the torrent is only known by its magnet link
+func (t *TorrentFile) IsMagnet() bool {
+	return t.Data == nil
+}
+
+// torrentHTTPClient fetches torrent files. Indexers sometimes redirect torrent URLs to
+// magnet links, which it stops at rather than following.
+var torrentHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if torrent.IsMagnet(req.URL.String()) {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	},
+}
+
+// ResolveTorrent parses a magnet link, or downloads a torrent file, and works out its
+// info hash. authenticate, if set, adds the indexer's credentials to the download.
+func ResolveTorrent(ctx context.Context, downloadURL string, authenticate Authenticate) (*TorrentFile, error) {
+	if torrent.IsMagnet(downloadURL) {
+		magnet, err := torrent.ParseMagnet(downloadURL)
+		if err != nil {
+			return nil, err
+		}
+		return &TorrentFile{URL: downloadURL, InfoHash: magnet.InfoHash}, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authenticate != nil {
+		authenticate(req)
+	}
+
+	resp, err := torrentHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download torrent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); torrent.IsMagnet(location) {
+		return ResolveTorrent(ctx, location, nil)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download torrent: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 20<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download torrent: %w", err)
+	}
+	meta, err := torrent.ParseTorrent(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid torrent file: %w", err)
+	}
+	return &TorrentFile{URL: downloadURL, Data: data, InfoHash: meta.InfoHash}, nil
+}
+
+// resolveTorrent returns the torrent the caller already resolved, or resolves it
+func resolveTorrent(ctx context.Context, downloadURL string, opts *DownloadOptions) (*TorrentFile, error) {
+	if opts != nil && opts.Torrent != nil {
+		return opts.Torrent, nil
+	}
+	return ResolveTorrent(ctx, downloadURL, nil)
+}
@@ -3,53 +3,127 @@ package hardcover
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/logging"
+	"github.com/shelfarr/shelfarr/internal/retry"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	"golang.org/x/time/rate"
 )
 
-// Client handles communication with the Hardcover.app GraphQL API
+var logger = logging.Component("metadata")
+
+// Client handles communication with the Hardcover.app GraphQL API.
+// A single client should be shared so its rate limiter covers every request.
 type Client struct {
 	baseURL     string
 	apiKey      string
 	httpClient  *http.Client
 	rateLimiter *rate.Limiter
+	cache       Cache
+	bypassCache bool
+	circuit     *health.Circuit
+	mutex       sync.RWMutex // Guards apiKey and httpClient, which change with settings
+}
+
+// Cache stores raw query responses. Implementations handle expiry.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
 }
 
+// CacheKeyPrefix prefixes every cache key written by the client
+const CacheKeyPrefix = "hardcover:"
+
+// Defaults match Hardcover's documented limits
+const (
+	DefaultRequestsPerMinute = 60
+	DefaultBurst             = 1
+	DefaultTimeout           = 30 * time.Second
+)
+
 // NewClient creates a new Hardcover API client
 func NewClient(baseURL string) *Client {
 	return &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: DefaultTimeout,
 		},
 		// Rate limit: 60 requests per minute = 1 request per second
-		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 1),
+		rateLimiter: rate.NewLimiter(rate.Every(time.Minute/DefaultRequestsPerMinute), DefaultBurst),
 	}
 }
 
 // NewClientWithAPIKey creates a new Hardcover API client with API key authentication
 func NewClientWithAPIKey(baseURL, apiKey string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		// Rate limit: 60 requests per minute = 1 request per second
-		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 1),
-	}
+	client := NewClient(baseURL)
+	client.apiKey = apiKey
+	return client
 }
 
 // SetAPIKey sets the API key for authenticated requests
 func (c *Client) SetAPIKey(apiKey string) {
+	c.mutex.Lock()
 	c.apiKey = apiKey
+	c.mutex.Unlock()
+}
+
+// Configure sets the rate limit and request timeout. Zero values keep the defaults.
+func (c *Client) Configure(requestsPerMinute, burst int, timeout time.Duration) {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = DefaultRequestsPerMinute
+	}
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	c.rateLimiter.SetLimit(rate.Every(time.Minute / time.Duration(requestsPerMinute)))
+	c.rateLimiter.SetBurst(burst)
+
+	c.mutex.Lock()
+	c.httpClient = &http.Client{Timeout: timeout}
+	c.mutex.Unlock()
+}
+
+// SetCache enables response caching, cutting repeat queries against the rate limit
+func (c *Client) SetCache(cache Cache) {
+	c.cache = cache
+}
+
+// SetCircuit stops calls to Hardcover while it keeps failing
+func (c *Client) SetCircuit(circuit *health.Circuit) {
+	c.circuit = circuit
+}
+
+// BypassCache returns a client that skips cached responses, for explicit refreshes.
+// Fresh responses still replace what was cached. The rate limiter stays shared.
+func (c *Client) BypassCache() *Client {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return &Client{
+		baseURL:     c.baseURL,
+		apiKey:      c.apiKey,
+		httpClient:  c.httpClient,
+		rateLimiter: c.rateLimiter,
+		cache:       c.cache,
+		bypassCache: true,
+		circuit:     c.circuit,
+	}
 }
 
 // Digital format constants for reading_format.format values
@@ -211,8 +285,12 @@ type UnifiedSearchResults struct {
 	Authors []AuthorData
 	Series  []SeriesData
 	Lists   []ListData
+	Errors  map[string]error // Failed searches by type: books, authors, series, lists
 }
 
+// maxConcurrentSearches bounds how many of SearchAll's queries run at once
+const maxConcurrentSearches = 2
+
 // graphQLRequest represents a GraphQL request
 type graphQLRequest struct {
 	Query     string                 `json:"query"`
@@ -223,19 +301,46 @@ type graphQLRequest struct {
 type graphQLResponse struct {
 	Data   json.RawMessage `json:"data"`
 	Errors []struct {
-		Message string `json:"message"`
-	} `errors,omitempty"`
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors,omitempty"`
 }
 
-// execute sends a GraphQL query and returns the response
-func (c *Client) execute(query string, variables map[string]interface{}) (json.RawMessage, error) {
-	// Wait for rate limiter (respects 60 requests/minute limit)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit wait failed: %w", err)
+// execute returns the response to a GraphQL query, from the cache when possible
+func (c *Client) execute(ctx context.Context, query string, variables map[string]interface{}) (json.RawMessage, error) {
+	if c.cache == nil {
+		return c.send(ctx, query, variables)
+	}
+
+	key := cacheKey(query, variables)
+	if !c.bypassCache {
+		if data, ok := c.cache.Get(key); ok {
+			return data, nil
+		}
+	}
+
+	data, err := c.send(ctx, query, variables)
+	if err != nil {
+		return nil, err
 	}
+	c.cache.Set(key, data)
+	return data, nil
+}
+
+// cacheKey identifies a query by a hash of its text and variables
+func cacheKey(query string, variables map[string]interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(query))
+	vars, _ := json.Marshal(variables) // map keys are sorted, so this is stable
+	h.Write(vars)
+	return CacheKeyPrefix + hex.EncodeToString(h.Sum(nil))
+}
 
+// send sends a GraphQL query and returns the response. Rate limits (429) and server
+// errors are retried with backoff.
+func (c *Client) send(ctx context.Context, query string, variables map[string]interface{}) (json.RawMessage, error) {
 	reqBody := graphQLRequest{
 		Query:     query,
 		Variables: variables,
@@ -246,31 +351,49 @@ func (c *Client) execute(query string, variables map[string]interface{}) (json.R
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if err := c.circuit.Allow(); err != nil {
+		return nil, fmt.Errorf("hardcover: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Shelfarr/1.0")
+	c.mutex.RLock()
+	apiKey, httpClient := c.apiKey, c.httpClient
+	c.mutex.RUnlock()
 
-	// Add authorization header if API key is configured
-	if c.apiKey != "" {
-		authValue := c.apiKey
-		if !strings.HasPrefix(strings.ToLower(c.apiKey), "bearer") {
-			authValue = "Bearer " + c.apiKey
+	resp, err := retry.Default.Do(ctx, httpClient, func() (*http.Request, error) {
+		// Wait for rate limiter (respects 60 requests/minute limit); retries count too
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limit wait failed: %w", err)
 		}
-		req.Header.Set("Authorization", authValue)
-	}
 
-	resp, err := c.httpClient.Do(req)
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Shelfarr/1.0")
+
+		// Add authorization header if API key is configured
+		if apiKey != "" {
+			authValue := apiKey
+			if !strings.HasPrefix(strings.ToLower(apiKey), "bearer") {
+				authValue = "Bearer " + apiKey
+			}
+			req.Header.Set("Authorization", authValue)
+		}
+		return req, nil
+	})
+	c.circuit.Record(health.ResponseError(resp, err))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("authentication failed - check API key")
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return nil, ErrRateLimited
 	}
 
 	// Handle non-2xx status codes
@@ -296,14 +419,14 @@ func (c *Client) execute(query string, variables map[string]interface{}) (json.R
 	}
 
 	if len(gqlResp.Errors) > 0 {
-		return nil, fmt.Errorf("GraphQL error: %s", gqlResp.Errors[0].Message)
+		return nil, newGraphQLError(gqlResp)
 	}
 
 	return gqlResp.Data, nil
 }
 
 // SearchBooks searches for books by title/author and filters by language if provided
-func (c *Client) SearchBooks(query string, languages []string) ([]BookData, error) {
+func (c *Client) SearchBooks(ctx context.Context, query string, languages []string) ([]BookData, error) {
 	gqlQuery := `
 		query SearchBooks($query: String!) {
 			search(query: $query, query_type: "Book", per_page: 20, page: 1) {
@@ -316,7 +439,7 @@ func (c *Client) SearchBooks(query string, languages []string) ([]BookData, erro
 		"query": query,
 	}
 
-	data, err := c.execute(gqlQuery, variables)
+	data, err := c.execute(ctx, gqlQuery, variables)
 	if err != nil {
 		return nil, err
 	}
@@ -404,7 +527,7 @@ func (c *Client) SearchBooks(query string, languages []string) ([]BookData, erro
 }
 
 // SearchAuthors searches for authors
-func (c *Client) SearchAuthors(query string) ([]AuthorData, error) {
+func (c *Client) SearchAuthors(ctx context.Context, query string) ([]AuthorData, error) {
 	gqlQuery := `
 		query SearchAuthors($query: String!) {
 			search(query: $query, query_type: "Author", per_page: 20, page: 1) {
@@ -417,7 +540,7 @@ func (c *Client) SearchAuthors(query string) ([]AuthorData, error) {
 		"query": query,
 	}
 
-	data, err := c.execute(gqlQuery, variables)
+	data, err := c.execute(ctx, gqlQuery, variables)
 	if err != nil {
 		return nil, err
 	}
@@ -470,7 +593,7 @@ func (c *Client) SearchAuthors(query string) ([]AuthorData, error) {
 }
 
 // SearchSeries searches for series
-func (c *Client) SearchSeries(query string) ([]SeriesData, error) {
+func (c *Client) SearchSeries(ctx context.Context, query string) ([]SeriesData, error) {
 	gqlQuery := `
 		query SearchSeries($query: String!) {
 			search(query: $query, query_type: "Series", per_page: 20, page: 1) {
@@ -483,7 +606,7 @@ func (c *Client) SearchSeries(query string) ([]SeriesData, error) {
 		"query": query,
 	}
 
-	data, err := c.execute(gqlQuery, variables)
+	data, err := c.execute(ctx, gqlQuery, variables)
 	if err != nil {
 		return nil, err
 	}
@@ -535,7 +658,7 @@ func (c *Client) SearchSeries(query string) ([]SeriesData, error) {
 }
 
 // SearchLists searches for lists
-func (c *Client) SearchLists(query string) ([]ListData, error) {
+func (c *Client) SearchLists(ctx context.Context, query string) ([]ListData, error) {
 	gqlQuery := `
 		query SearchLists($query: String!) {
 			search(query: $query, query_type: "List", per_page: 20, page: 1) {
@@ -548,7 +671,7 @@ func (c *Client) SearchLists(query string) ([]ListData, error) {
 		"query": query,
 	}
 
-	data, err := c.execute(gqlQuery, variables)
+	data, err := c.execute(ctx, gqlQuery, variables)
 	if err != nil {
 		return nil, err
 	}
@@ -599,26 +722,67 @@ func (c *Client) SearchLists(query string) ([]ListData, error) {
 	return lists, nil
 }
 
-// SearchAll performs a unified search
-func (c *Client) SearchAll(query string, languages []string) (*UnifiedSearchResults, error) {
-	results := &UnifiedSearchResults{}
-
-	books, _ := c.SearchBooks(query, languages)
-	results.Books = books
-
-	authors, _ := c.SearchAuthors(query)
-	results.Authors = authors
-
-	series, _ := c.SearchSeries(query)
-	results.Series = series
-
-	lists, _ := c.SearchLists(query)
-	results.Lists = lists
+// SearchAll searches books, authors, series and lists concurrently. A failed search
+// leaves its results empty and is reported in Errors; an error is returned only when
+// every search fails.
+func (c *Client) SearchAll(ctx context.Context, query string, languages []string) (*UnifiedSearchResults, error) {
+	results := &UnifiedSearchResults{Errors: make(map[string]error)}
+
+	searches := []struct {
+		name string
+		run  func() error
+	}{
+		{"books", func() (err error) {
+			results.Books, err = c.SearchBooks(ctx, query, languages)
+			return err
+		}},
+		{"authors", func() (err error) {
+			results.Authors, err = c.SearchAuthors(ctx, query)
+			return err
+		}},
+		{"series", func() (err error) {
+			results.Series, err = c.SearchSeries(ctx, query)
+			return err
+		}},
+		{"lists", func() (err error) {
+			results.Lists, err = c.SearchLists(ctx, query)
+			return err
+		}},
+	}
+
+	// The rate limiter still paces requests; this keeps a unified search from
+	// queueing all four at once ahead of other callers
+	sem := semaphore.NewWeighted(maxConcurrentSearches)
+	var group errgroup.Group
+	var mutex sync.Mutex
+	for _, search := range searches {
+		group.Go(func() error {
+			err := sem.Acquire(ctx, 1)
+			if err == nil {
+				err = search.run()
+				sem.Release(1)
+			}
+			if err != nil {
+				mutex.Lock()
+				results.Errors[search.name] = err
+				mutex.Unlock()
+			}
+			return nil
+		})
+	}
+	group.Wait()
 
+	if len(results.Errors) == len(searches) {
+		errs := make([]error, 0, len(searches))
+		for _, search := range searches {
+			errs = append(errs, fmt.Errorf("%s: %w", search.name, results.Errors[search.name]))
+		}
+		return nil, errors.Join(errs...)
+	}
 	return results, nil
 }
 
-func (c *Client) GetBook(id string) (*BookData, error) {
+func (c *Client) GetBook(ctx context.Context, id string) (*BookData, error) {
 	idInt, _ := strconv.Atoi(id)
 	gqlQuery := `
 		query GetBook($id: Int!) {
@@ -691,7 +855,7 @@ func (c *Client) GetBook(id string) (*BookData, error) {
 		}
 	`
 
-	data, err := c.execute(gqlQuery, map[string]any{"id": idInt})
+	data, err := c.execute(ctx, gqlQuery, map[string]any{"id": idInt})
 	if err != nil {
 		return nil, err
 	}
@@ -777,8 +941,11 @@ func (c *Client) GetBook(id string) (*BookData, error) {
 		} `json:"books_by_pk"`
 	}
 
-	if err := json.Unmarshal(data, &result); err != nil || result.Book == nil {
-		return nil, fmt.Errorf("book not found or parse error")
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse book: %w", err)
+	}
+	if result.Book == nil {
+		return nil, fmt.Errorf("book %w", ErrNotFound)
 	}
 
 	b := result.Book
@@ -998,7 +1165,7 @@ func (c *Client) GetBook(id string) (*BookData, error) {
 }
 
 // GetAuthor fetches author details
-func (c *Client) GetAuthor(id string) (*AuthorData, error) {
+func (c *Client) GetAuthor(ctx context.Context, id string) (*AuthorData, error) {
 	idInt, _ := strconv.Atoi(id)
 	gqlQuery := `
 		query GetAuthor($id: Int!) {
@@ -1007,7 +1174,7 @@ func (c *Client) GetAuthor(id string) (*AuthorData, error) {
 			}
 		}
 	`
-	data, err := c.execute(gqlQuery, map[string]interface{}{"id": idInt})
+	data, err := c.execute(ctx, gqlQuery, map[string]interface{}{"id": idInt})
 	if err != nil {
 		return nil, err
 	}
@@ -1019,8 +1186,11 @@ func (c *Client) GetAuthor(id string) (*AuthorData, error) {
 			Image *struct{ URL string } `json:"image"`
 		} `json:"authors_by_pk"`
 	}
-	if err := json.Unmarshal(data, &result); err != nil || result.Author == nil {
-		return nil, fmt.Errorf("author not found")
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse author: %w", err)
+	}
+	if result.Author == nil {
+		return nil, fmt.Errorf("author %w", ErrNotFound)
 	}
 	a := result.Author
 	author := &AuthorData{ID: a.ID.String(), Name: a.Name, Biography: a.Bio}
@@ -1030,8 +1200,8 @@ func (c *Client) GetAuthor(id string) (*AuthorData, error) {
 	return author, nil
 }
 
-func (c *Client) GetBooksByAuthorWithCounts(authorID string, languages []string) (*FilteredBooksResult, error) {
-	return c.GetBooksByAuthor(authorID, languages)
+func (c *Client) GetBooksByAuthorWithCounts(ctx context.Context, authorID string, languages []string) (*FilteredBooksResult, error) {
+	return c.GetBooksByAuthor(ctx, authorID, languages)
 }
 
 type FilteredSeriesResult struct {
@@ -1042,7 +1212,7 @@ type FilteredSeriesResult struct {
 	PhysicalOnlyCount int
 }
 
-func (c *Client) GetSeries(seriesID string, languages []string) (*FilteredSeriesResult, error) {
+func (c *Client) GetSeries(ctx context.Context, seriesID string, languages []string) (*FilteredSeriesResult, error) {
 	idInt, _ := strconv.Atoi(seriesID)
 	gqlQuery := `
 		query GetSeries($seriesId: Int!) {
@@ -1061,7 +1231,7 @@ func (c *Client) GetSeries(seriesID string, languages []string) (*FilteredSeries
 			}
 		}
 	`
-	data, err := c.execute(gqlQuery, map[string]interface{}{"seriesId": idInt})
+	data, err := c.execute(ctx, gqlQuery, map[string]interface{}{"seriesId": idInt})
 	if err != nil {
 		return nil, err
 	}
@@ -1105,8 +1275,11 @@ func (c *Client) GetSeries(seriesID string, languages []string) (*FilteredSeries
 			} `json:"book_series"`
 		} `json:"series_by_pk"`
 	}
-	if err := json.Unmarshal(data, &result); err != nil || result.Series == nil {
-		return nil, fmt.Errorf("series not found")
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse series: %w", err)
+	}
+	if result.Series == nil {
+		return nil, fmt.Errorf("series %w", ErrNotFound)
 	}
 
 	seriesData := &SeriesData{
@@ -1205,14 +1378,16 @@ func (c *Client) GetSeries(seriesID string, languages []string) (*FilteredSeries
 	return filteredResult, nil
 }
 
-func (c *Client) GetBooksByAuthor(authorID string, languages []string) (*FilteredBooksResult, error) {
+// GetBooksByAuthor returns an author's books, fetched a page at a time so prolific
+// authors aren't truncated or timed out
+func (c *Client) GetBooksByAuthor(ctx context.Context, authorID string, languages []string) (*FilteredBooksResult, error) {
 	idInt, _ := strconv.Atoi(authorID)
 	gqlQuery := `
-		query GetBooksByAuthor($authorId: Int!) {
+		query GetBooksByAuthor($authorId: Int!, $limit: Int!, $offset: Int!) {
 			books(where: {
 				contributions: {author_id: {_eq: $authorId}},
 				compilation: {_eq: false}
-			}) {
+			}, order_by: {id: asc}, limit: $limit, offset: $offset) {
 				id, title, slug, description, compilation, image { url }, release_date, pages, rating
 				book_series { series { id, name }, position }
 				editions { 
@@ -1221,128 +1396,167 @@ func (c *Client) GetBooksByAuthor(authorID string, languages []string) (*Filtere
 			}
 		}
 	`
-	data, err := c.execute(gqlQuery, map[string]interface{}{"authorId": idInt})
-	if err != nil {
-		return nil, err
-	}
-	var result struct {
-		Books []struct {
-			ID          json.Number
-			Title       string `json:"title"`
-			Description string `json:"description"`
-			ReleaseDate string `json:"release_date"`
-			Compilation bool   `json:"compilation"`
-			Image       *struct{ URL string }
-			Pages       int
-			Rating      float32
-			BookSeries  []struct {
-				Series struct {
-					ID   json.Number
-					Name string
-				}
-				Position float32
-			} `json:"book_series"`
-			Editions []struct {
-				ISBN10        string `json:"isbn_10"`
-				ISBN13        string `json:"isbn_13"`
-				Asin          string `json:"asin"`
-				Compilation   bool   `json:"compilation"`
-				ReadingFormat *struct {
-					Format string `json:"format"`
-				} `json:"reading_format"`
-				Language *struct {
-					Code2    string `json:"code2"`
-					Language string `json:"language"`
-				} `json:"language"`
-			}
-		}
-	}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
 
 	filteredResult := &FilteredBooksResult{}
-	for _, b := range result.Books {
-		editionLangs := make([]EditionLanguageInfo, 0, len(b.Editions))
-		editionFormats := make([]EditionFormatInfo, 0, len(b.Editions))
-
-		for _, ed := range b.Editions {
-			var langInfo *EditionLanguageInfo
-			if ed.Language != nil {
-				langInfo = &EditionLanguageInfo{Code2: ed.Language.Code2, Language: ed.Language.Language}
-				editionLangs = append(editionLangs, *langInfo)
-			}
-			var formatInfo *ReadingFormatInfo
-			if ed.ReadingFormat != nil {
-				formatInfo = &ReadingFormatInfo{Format: ed.ReadingFormat.Format}
-			}
-			editionFormats = append(editionFormats, EditionFormatInfo{
-				ReadingFormat: formatInfo,
-				Language:      langInfo,
-				ISBN10:        ed.ISBN10,
-				ISBN13:        ed.ISBN13,
-			})
+	for page := 0; page < maxAuthorBookPages; page++ {
+		variables := map[string]interface{}{
+			"authorId": idInt,
+			"limit":    authorBooksPageSize,
+			"offset":   page * authorBooksPageSize,
 		}
-
-		if !bookHasPreferredLanguage(editionLangs, languages) {
-			continue
+		data, err := c.execute(ctx, gqlQuery, variables)
+		if err != nil {
+			return nil, err
 		}
-
-		if len(b.Editions) == 0 {
-			continue
+		var result struct {
+			Books []bookRecord
 		}
-
-		filteredResult.TotalCount++
-		hasEbook, hasAudiobook := getEditionFormats(editionFormats)
-		hasDigital := hasEbook || hasAudiobook
-		digitalCount, physicalCount := countEditionsByFormat(editionFormats)
-
-		isCompilation := b.Compilation
-		if !isCompilation {
-			for _, ed := range b.Editions {
-				if ed.Compilation {
-					isCompilation = true
-					break
-				}
-			}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
 		}
 
-		book := BookData{
-			ID: b.ID.String(), Title: b.Title, Description: b.Description,
-			Rating: b.Rating, PageCount: b.Pages, AuthorID: authorID,
-			HasDigitalEdition: hasDigital, HasEbook: hasEbook, HasAudiobook: hasAudiobook,
-			DigitalEditionCount: digitalCount, PhysicalEditionCount: physicalCount,
-			Compilation: isCompilation,
+		for _, b := range result.Books {
+			filteredResult.addBook(b, authorID, languages)
 		}
-		if b.Image != nil {
-			book.CoverURL = b.Image.URL
+		if len(result.Books) < authorBooksPageSize {
+			return filteredResult, nil
 		}
-		if len(b.BookSeries) > 0 {
-			book.SeriesID = b.BookSeries[0].Series.ID.String()
-			book.SeriesName = b.BookSeries[0].Series.Name
-			pos := b.BookSeries[0].Position
-			book.SeriesIndex = &pos
+	}
+
+	logger.Warn("Only the first books by author were fetched", "fetched", maxAuthorBookPages*authorBooksPageSize, "authorId", authorID)
+	return filteredResult, nil
+}
+
+// Pagination for GetBooksByAuthor
+const (
+	authorBooksPageSize = 100
+	maxAuthorBookPages  = 50 // Bounds the request count for authors with thousands of works
+)
+
+// bookRecord is one book from a books query, as used by GetBooksByAuthor and the
+// discovery lists
+type bookRecord struct {
+	ID            json.Number
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	ReleaseDate   string `json:"release_date"`
+	Compilation   bool   `json:"compilation"`
+	Image         *struct{ URL string }
+	Pages         int
+	Rating        float32
+	Contributions []struct {
+		Author struct {
+			ID   json.Number
+			Name string
+		}
+	}
+	BookSeries []struct {
+		Series struct {
+			ID   json.Number
+			Name string
+		}
+		Position float32
+	} `json:"book_series"`
+	Editions []struct {
+		ISBN10        string `json:"isbn_10"`
+		ISBN13        string `json:"isbn_13"`
+		Asin          string `json:"asin"`
+		Compilation   bool   `json:"compilation"`
+		ReadingFormat *struct {
+			Format string `json:"format"`
+		} `json:"reading_format"`
+		Language *struct {
+			Code2    string `json:"code2"`
+			Language string `json:"language"`
+		} `json:"language"`
+	}
+}
+
+// addBook filters a fetched book by language and edition, then adds it and its counts.
+// authorID may be empty, in which case the first contributor is used.
+func (r *FilteredBooksResult) addBook(b bookRecord, authorID string, languages []string) {
+	editionLangs := make([]EditionLanguageInfo, 0, len(b.Editions))
+	editionFormats := make([]EditionFormatInfo, 0, len(b.Editions))
+
+	for _, ed := range b.Editions {
+		var langInfo *EditionLanguageInfo
+		if ed.Language != nil {
+			langInfo = &EditionLanguageInfo{Code2: ed.Language.Code2, Language: ed.Language.Language}
+			editionLangs = append(editionLangs, *langInfo)
 		}
-		if len(b.Editions) > 0 {
-			book.ISBN = b.Editions[0].ISBN10
-			book.ISBN13 = b.Editions[0].ISBN13
+		var formatInfo *ReadingFormatInfo
+		if ed.ReadingFormat != nil {
+			formatInfo = &ReadingFormatInfo{Format: ed.ReadingFormat.Format}
 		}
-		if b.ReleaseDate != "" {
-			if t, err := time.Parse("2006-01-02", b.ReleaseDate); err == nil {
-				book.ReleaseDate = &t
+		editionFormats = append(editionFormats, EditionFormatInfo{
+			ReadingFormat: formatInfo,
+			Language:      langInfo,
+			ISBN10:        ed.ISBN10,
+			ISBN13:        ed.ISBN13,
+		})
+	}
+
+	if !bookHasPreferredLanguage(editionLangs, languages) {
+		return
+	}
+
+	if len(b.Editions) == 0 {
+		return
+	}
+
+	r.TotalCount++
+	hasEbook, hasAudiobook := getEditionFormats(editionFormats)
+	hasDigital := hasEbook || hasAudiobook
+	digitalCount, physicalCount := countEditionsByFormat(editionFormats)
+
+	isCompilation := b.Compilation
+	if !isCompilation {
+		for _, ed := range b.Editions {
+			if ed.Compilation {
+				isCompilation = true
+				break
 			}
 		}
-		book.LanguageCode = getPreferredLanguageCode(editionLangs, languages)
+	}
 
-		if hasDigital {
-			filteredResult.DigitalCount++
-		} else {
-			filteredResult.PhysicalOnlyCount++
+	book := BookData{
+		ID: b.ID.String(), Title: b.Title, Description: b.Description,
+		Rating: b.Rating, PageCount: b.Pages, AuthorID: authorID,
+		HasDigitalEdition: hasDigital, HasEbook: hasEbook, HasAudiobook: hasAudiobook,
+		DigitalEditionCount: digitalCount, PhysicalEditionCount: physicalCount,
+		Compilation: isCompilation,
+	}
+	if b.Image != nil {
+		book.CoverURL = b.Image.URL
+	}
+	if book.AuthorID == "" && len(b.Contributions) > 0 {
+		book.AuthorID = b.Contributions[0].Author.ID.String()
+		book.AuthorName = b.Contributions[0].Author.Name
+	}
+	if len(b.BookSeries) > 0 {
+		book.SeriesID = b.BookSeries[0].Series.ID.String()
+		book.SeriesName = b.BookSeries[0].Series.Name
+		pos := b.BookSeries[0].Position
+		book.SeriesIndex = &pos
+	}
+	if len(b.Editions) > 0 {
+		book.ISBN = b.Editions[0].ISBN10
+		book.ISBN13 = b.Editions[0].ISBN13
+	}
+	if b.ReleaseDate != "" {
+		if t, err := time.Parse("2006-01-02", b.ReleaseDate); err == nil {
+			book.ReleaseDate = &t
+			book.ReleaseYear = t.Year()
 		}
-		filteredResult.Books = append(filteredResult.Books, book)
 	}
+	book.LanguageCode = getPreferredLanguageCode(editionLangs, languages)
 
-	return filteredResult, nil
+	if hasDigital {
+		r.DigitalCount++
+	} else {
+		r.PhysicalOnlyCount++
+	}
+	r.Books = append(r.Books, book)
 }
 
 // EditionLanguageInfo represents language info from an edition
@@ -1486,9 +1700,9 @@ func getLanguageNameFromCode(code string) string {
 }
 
 // Test checks API key
-func (c *Client) Test() error {
+func (c *Client) Test(ctx context.Context) error {
 	gqlQuery := `query Test { me { username } }`
-	data, err := c.execute(gqlQuery, nil)
+	data, err := c.send(ctx, gqlQuery, nil)
 	if err != nil {
 		return err
 	}
@@ -1501,7 +1715,7 @@ func (c *Client) Test() error {
 	return nil
 }
 
-func (c *Client) GetListBooks(listID string) (*FilteredBooksResult, error) {
+func (c *Client) GetListBooks(ctx context.Context, listID string) (*FilteredBooksResult, error) {
 	idInt, _ := strconv.Atoi(listID)
 	gqlQuery := `
 		query GetListBooks($listId: Int!) {
@@ -1517,7 +1731,7 @@ func (c *Client) GetListBooks(listID string) (*FilteredBooksResult, error) {
 			}
 		}
 	`
-	data, err := c.execute(gqlQuery, map[string]interface{}{"listId": idInt})
+	data, err := c.execute(ctx, gqlQuery, map[string]interface{}{"listId": idInt})
 	if err != nil {
 		return nil, err
 	}
@@ -1561,8 +1775,11 @@ func (c *Client) GetListBooks(listID string) (*FilteredBooksResult, error) {
 			} `json:"list_books"`
 		} `json:"lists_by_pk"`
 	}
-	if err := json.Unmarshal(data, &result); err != nil || result.List == nil {
-		return nil, fmt.Errorf("list not found")
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse list: %w", err)
+	}
+	if result.List == nil {
+		return nil, fmt.Errorf("list %w", ErrNotFound)
 	}
 
 	filteredResult := &FilteredBooksResult{}
@@ -0,0 +1,128 @@
+package hardcover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DiscoverLimit is how many books a discovery list returns before language filtering
+const DiscoverLimit = 40
+
+// discoverBookFields selects what bookRecord expects
+const discoverBookFields = `
+	id, title, description, compilation, image { url }, release_date, pages, rating
+	contributions { author { id, name } }
+	book_series { series { id, name }, position }
+	editions { isbn_10, isbn_13, asin, compilation, reading_format { format }, language { code2 language } }
+`
+
+// GetTrendingBooks returns the books most added by Hardcover users over the last month
+func (c *Client) GetTrendingBooks(ctx context.Context, languages []string) (*FilteredBooksResult, error) {
+	return c.getTrending(ctx, time.Now().AddDate(0, -1, 0), languages)
+}
+
+// GetPopularThisWeek returns the books most added by Hardcover users over the last week
+func (c *Client) GetPopularThisWeek(ctx context.Context, languages []string) (*FilteredBooksResult, error) {
+	return c.getTrending(ctx, time.Now().AddDate(0, 0, -7), languages)
+}
+
+// GetRecentReleases returns the most read books released in the last month
+func (c *Client) GetRecentReleases(ctx context.Context, languages []string) (*FilteredBooksResult, error) {
+	gqlQuery := `
+		query RecentReleases($from: date!, $to: date!, $limit: Int!) {
+			books(where: {
+				release_date: {_gte: $from, _lte: $to},
+				compilation: {_eq: false}
+			}, order_by: {users_count: desc}, limit: $limit) {` + discoverBookFields + `}
+		}
+	`
+	now := time.Now()
+	variables := map[string]interface{}{
+		"from":  now.AddDate(0, -1, 0).Format("2006-01-02"),
+		"to":    now.Format("2006-01-02"),
+		"limit": DiscoverLimit,
+	}
+
+	data, err := c.execute(ctx, gqlQuery, variables)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Books []bookRecord
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse recent releases: %w", err)
+	}
+
+	filteredResult := &FilteredBooksResult{}
+	for _, b := range result.Books {
+		filteredResult.addBook(b, "", languages)
+	}
+	return filteredResult, nil
+}
+
+// getTrending returns the books trending since from, in trending order
+func (c *Client) getTrending(ctx context.Context, from time.Time, languages []string) (*FilteredBooksResult, error) {
+	idsQuery := `
+		query TrendingBooks($from: date!, $to: date!, $limit: Int!) {
+			books_trending(from: $from, to: $to, limit: $limit, offset: 0) {
+				ids
+			}
+		}
+	`
+	// Trending data is bucketed by day, so dates keep the cache key stable
+	variables := map[string]interface{}{
+		"from":  from.Format("2006-01-02"),
+		"to":    time.Now().Format("2006-01-02"),
+		"limit": DiscoverLimit,
+	}
+
+	data, err := c.execute(ctx, idsQuery, variables)
+	if err != nil {
+		return nil, err
+	}
+	var trending struct {
+		BooksTrending struct {
+			IDs []int `json:"ids"`
+		} `json:"books_trending"`
+	}
+	if err := json.Unmarshal(data, &trending); err != nil {
+		return nil, fmt.Errorf("failed to parse trending books: %w", err)
+	}
+
+	filteredResult := &FilteredBooksResult{}
+	ids := trending.BooksTrending.IDs
+	if len(ids) == 0 {
+		return filteredResult, nil
+	}
+
+	booksQuery := `
+		query BooksByIDs($ids: [Int!]!) {
+			books(where: {id: {_in: $ids}}) {` + discoverBookFields + `}
+		}
+	`
+	data, err = c.execute(ctx, booksQuery, map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Books []bookRecord
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse trending books: %w", err)
+	}
+
+	// The books query doesn't keep the trending order
+	byID := make(map[string]bookRecord, len(result.Books))
+	for _, b := range result.Books {
+		byID[b.ID.String()] = b
+	}
+	for _, id := range ids {
+		if b, ok := byID[fmt.Sprint(id)]; ok {
+			filteredResult.addBook(b, "", languages)
+		}
+	}
+	return filteredResult, nil
+}
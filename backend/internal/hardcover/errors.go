@@ -0,0 +1,47 @@
+package hardcover
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors callers can match with errors.Is to pick a response status
+var (
+	// ErrNotFound is returned when a book, author, series or list doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is returned when the API key is missing, invalid or expired
+	ErrUnauthorized = errors.New("authentication failed - check API key")
+	// ErrRateLimited is returned when Hardcover still answers 429 after retries
+	ErrRateLimited = errors.New("rate limited by Hardcover")
+)
+
+// GraphQLError carries every error message from a GraphQL response
+type GraphQLError struct {
+	Messages []string
+	Codes    []string // Hasura extension codes, e.g. "invalid-jwt"
+}
+
+func (e *GraphQLError) Error() string {
+	return "GraphQL error: " + strings.Join(e.Messages, "; ")
+}
+
+// Unwrap maps authentication failures reported inside a 200 response to ErrUnauthorized
+func (e *GraphQLError) Unwrap() error {
+	for _, code := range e.Codes {
+		if code == "invalid-jwt" || code == "invalid-headers" || code == "access-denied" {
+			return ErrUnauthorized
+		}
+	}
+	return nil
+}
+
+func newGraphQLError(resp graphQLResponse) *GraphQLError {
+	gqlErr := &GraphQLError{}
+	for _, e := range resp.Errors {
+		gqlErr.Messages = append(gqlErr.Messages, e.Message)
+		if e.Extensions.Code != "" {
+			gqlErr.Codes = append(gqlErr.Codes, e.Extensions.Code)
+		}
+	}
+	return gqlErr
+}
@@ -0,0 +1,192 @@
+package hardcover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Hardcover reading status IDs for user_books.status_id
+const (
+	StatusWantToRead       = 1
+	StatusCurrentlyReading = 2
+	StatusRead             = 3
+	StatusDidNotFinish     = 5
+)
+
+// UserData is the Hardcover user the API key belongs to
+type UserData struct {
+	ID       string
+	Username string
+}
+
+// userBooksPageSize is how many shelf entries GetWantToReadBooks fetches per request
+const userBooksPageSize = 100
+
+// GetMe returns the user the API key belongs to
+func (c *Client) GetMe(ctx context.Context) (*UserData, error) {
+	gqlQuery := `query Me { me { id, username } }`
+	data, err := c.send(ctx, gqlQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Me []struct {
+			ID       json.Number `json:"id"`
+			Username string      `json:"username"`
+		} `json:"me"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse user: %w", err)
+	}
+	if len(result.Me) == 0 {
+		return nil, ErrUnauthorized
+	}
+	return &UserData{ID: result.Me[0].ID.String(), Username: result.Me[0].Username}, nil
+}
+
+// GetWantToReadBooks returns the books on the API key owner's Want to Read shelf.
+// Every book is included whatever its language; the user chose them.
+func (c *Client) GetWantToReadBooks(ctx context.Context) (*FilteredBooksResult, error) {
+	gqlQuery := `
+		query WantToRead($status: Int!, $limit: Int!, $offset: Int!) {
+			me {
+				user_books(where: {status_id: {_eq: $status}}, order_by: {id: asc}, limit: $limit, offset: $offset) {
+					book {` + discoverBookFields + `}
+				}
+			}
+		}
+	`
+
+	filteredResult := &FilteredBooksResult{}
+	for page := 0; page < maxAuthorBookPages; page++ {
+		variables := map[string]interface{}{
+			"status": StatusWantToRead,
+			"limit":  userBooksPageSize,
+			"offset": page * userBooksPageSize,
+		}
+		// The shelf changes as the user edits it, so skip the response cache
+		data, err := c.send(ctx, gqlQuery, variables)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Me []struct {
+				UserBooks []struct {
+					Book bookRecord `json:"book"`
+				} `json:"user_books"`
+			} `json:"me"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse want to read shelf: %w", err)
+		}
+		if len(result.Me) == 0 {
+			return nil, ErrUnauthorized
+		}
+
+		userBooks := result.Me[0].UserBooks
+		for _, ub := range userBooks {
+			filteredResult.addBook(ub.Book, "", nil)
+		}
+		if len(userBooks) < userBooksPageSize {
+			return filteredResult, nil
+		}
+	}
+
+	logger.Warn("Only the first Want to Read books were fetched", "fetched", maxAuthorBookPages*userBooksPageSize)
+	return filteredResult, nil
+}
+
+// UserBookUpdate is a change to the API key owner's record of a book
+type UserBookUpdate struct {
+	StatusID int  // One of the Status constants; 0 leaves the status alone
+	Owned    bool // Marks the book as owned; false leaves ownership alone
+}
+
+// UpdateUserBook applies update to the API key owner's record of a book, adding the
+// book to their library if it isn't there yet
+func (c *Client) UpdateUserBook(ctx context.Context, bookID string, update UserBookUpdate) error {
+	idInt, err := strconv.Atoi(bookID)
+	if err != nil {
+		return fmt.Errorf("invalid Hardcover book ID %q", bookID)
+	}
+
+	lookup := `
+		query UserBook($bookId: Int!) {
+			me {
+				user_books(where: {book_id: {_eq: $bookId}}, limit: 1) { id, status_id, owned }
+			}
+		}
+	`
+	data, err := c.send(ctx, lookup, map[string]interface{}{"bookId": idInt})
+	if err != nil {
+		return err
+	}
+	var existing struct {
+		Me []struct {
+			UserBooks []struct {
+				ID       int  `json:"id"`
+				StatusID int  `json:"status_id"`
+				Owned    bool `json:"owned"`
+			} `json:"user_books"`
+		} `json:"me"`
+	}
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return fmt.Errorf("failed to parse user book: %w", err)
+	}
+	if len(existing.Me) == 0 {
+		return ErrUnauthorized
+	}
+
+	object := map[string]interface{}{}
+	if len(existing.Me[0].UserBooks) == 0 {
+		object["book_id"] = idInt
+		if update.StatusID != 0 {
+			object["status_id"] = update.StatusID
+		}
+		if update.Owned {
+			object["owned"] = true
+		}
+		return c.mutateUserBook(ctx, "insert_user_book", `
+			mutation InsertUserBook($object: UserBookCreateInput!) {
+				insert_user_book(object: $object) { id, error }
+			}
+		`, map[string]interface{}{"object": object})
+	}
+
+	userBook := existing.Me[0].UserBooks[0]
+	if update.StatusID != 0 && update.StatusID != userBook.StatusID {
+		object["status_id"] = update.StatusID
+	}
+	if update.Owned && !userBook.Owned {
+		object["owned"] = true
+	}
+	if len(object) == 0 {
+		return nil // Already up to date
+	}
+	return c.mutateUserBook(ctx, "update_user_book", `
+		mutation UpdateUserBook($id: Int!, $object: UserBookUpdateInput!) {
+			update_user_book(id: $id, object: $object) { id, error }
+		}
+	`, map[string]interface{}{"id": userBook.ID, "object": object})
+}
+
+// mutateUserBook runs a user book mutation, surfacing the error Hardcover reports in its result
+func (c *Client) mutateUserBook(ctx context.Context, field, mutation string, variables map[string]interface{}) error {
+	data, err := c.send(ctx, mutation, variables)
+	if err != nil {
+		return err
+	}
+	var result map[string]struct {
+		Error *string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", field, err)
+	}
+	if r := result[field]; r.Error != nil && *r.Error != "" {
+		return fmt.Errorf("%s failed: %s", field, *r.Error)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a provider that keeps failing
+var ErrCircuitOpen = errors.New("provider unavailable: too many consecutive failures")
+
+// Defaults for how quickly a circuit opens and how long it stays open
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 5 * time.Minute
+)
+
+// Circuit states
+const (
+	StateClosed   = "closed"    // Calls go through
+	StateOpen     = "open"      // Calls are short-circuited until the cooldown passes
+	StateHalfOpen = "half-open" // One trial call is allowed to test recovery
+)
+
+// Tracker holds a circuit breaker for each external provider
+type Tracker struct {
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*Circuit
+	onChange  func(Status)
+	mutex     sync.Mutex
+}
+
+// Circuit tracks consecutive failures for one provider. A nil circuit allows every call.
+type Circuit struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	tracker   *Tracker
+	mutex     sync.Mutex
+
+	failures      int
+	openUntil     time.Time
+	trialInFlight bool
+	lastError     string
+	lastFailureAt time.Time
+	lastSuccessAt time.Time
+}
+
+// Status describes a provider's health
+type Status struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+}
+
+// NewTracker creates a tracker whose circuits open after threshold consecutive failures
+// and stay open for cooldown. Zero values use the defaults.
+func NewTracker(threshold int, cooldown time.Duration) *Tracker {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	return &Tracker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*Circuit),
+	}
+}
+
+// Circuit returns the named provider's circuit, creating it on first use.
+// A nil tracker returns a nil circuit.
+func (t *Tracker) Circuit(name string) *Circuit {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	c, ok := t.circuits[name]
+	if !ok {
+		c = &Circuit{name: name, threshold: t.threshold, cooldown: t.cooldown, tracker: t}
+		t.circuits[name] = c
+	}
+	return c
+}
+
+// OnChange sets a function that is called when a circuit opens or closes
+func (t *Tracker) OnChange(fn func(Status)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.onChange = fn
+}
+
+// Status returns the health of every provider seen so far, sorted by name
+func (t *Tracker) Status() []Status {
+	t.mutex.Lock()
+	circuits := make([]*Circuit, 0, len(t.circuits))
+	for _, c := range t.circuits {
+		circuits = append(circuits, c)
+	}
+	t.mutex.Unlock()
+
+	statuses := make([]Status, 0, len(circuits))
+	for _, c := range circuits {
+		statuses = append(statuses, c.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Allow reports whether a call may go ahead. Once the cooldown has passed a single
+// trial call is let through; its result decides whether the circuit closes again.
+func (c *Circuit) Allow() error {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.failures < c.threshold {
+		return nil
+	}
+	if time.Now().Before(c.openUntil) || c.trialInFlight {
+		return ErrCircuitOpen
+	}
+	c.trialInFlight = true
+	return nil
+}
+
+// Record notes the outcome of a call. Cancelled calls say nothing about the provider
+// and are ignored; timeouts count as failures.
+func (c *Circuit) Record(err error) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	c.trialInFlight = false
+	if errors.Is(err, context.Canceled) {
+		c.mutex.Unlock()
+		return
+	}
+
+	wasOpen := c.failures >= c.threshold
+	now := time.Now()
+	if err == nil {
+		c.failures = 0
+		c.lastSuccessAt = now
+	} else {
+		c.failures++
+		c.lastError = err.Error()
+		c.lastFailureAt = now
+		if c.failures >= c.threshold {
+			c.openUntil = now.Add(c.cooldown)
+		}
+	}
+	changed := wasOpen != (c.failures >= c.threshold)
+	c.mutex.Unlock()
+
+	if changed {
+		c.notify()
+	}
+}
+
+// notify tells the tracker's listener about the circuit's new state
+func (c *Circuit) notify() {
+	if c.tracker == nil {
+		return
+	}
+	c.tracker.mutex.Lock()
+	onChange := c.tracker.onChange
+	c.tracker.mutex.Unlock()
+
+	if onChange != nil {
+		onChange(c.Status())
+	}
+}
+
+// Status returns the circuit's current health
+func (c *Circuit) Status() Status {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	status := Status{
+		Name:                c.name,
+		State:               StateClosed,
+		ConsecutiveFailures: c.failures,
+		LastError:           c.lastError,
+	}
+	if !c.lastFailureAt.IsZero() {
+		at := c.lastFailureAt
+		status.LastFailureAt = &at
+	}
+	if !c.lastSuccessAt.IsZero() {
+		at := c.lastSuccessAt
+		status.LastSuccessAt = &at
+	}
+	if c.failures >= c.threshold {
+		status.State = StateHalfOpen
+		if time.Now().Before(c.openUntil) {
+			status.State = StateOpen
+			retryAt := c.openUntil
+			status.RetryAt = &retryAt
+		}
+	}
+	return status
+}
+
+// ResponseError turns an HTTP call's outcome into the error to record: transport
+// errors, rate limiting and server errors count against the provider, other
+// responses mean it is up
+func ResponseError(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
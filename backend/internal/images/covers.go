@@ -0,0 +1,284 @@
+package images
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/logging"
+	"github.com/shelfarr/shelfarr/internal/media"
+)
+
+var logger = logging.Component("metadata")
+
+// maxCoverSize bounds a downloaded cover, in bytes
+const maxCoverSize = 20 << 20
+
+// coverWidthStep rounds requested widths up, so a few sizes are cached per cover
+// rather than one for every width asked for
+const coverWidthStep = 100
+
+// MaxCoverWidth is the widest resized cover served; wider requests get the original
+const MaxCoverWidth = 1200
+
+// MaxUploadSize bounds a cover uploaded by a user, in bytes
+const MaxUploadSize = 10 << 20
+
+// uploadScheme marks the cover "URL" of an uploaded image, which only exists in the cache
+const uploadScheme = "upload:"
+
+// CoverCache keeps copies of book covers on disk, so they are served locally instead
+// of from the metadata providers' CDNs. Covers are named by book and a hash of their
+// URL, so a book whose cover changes gets the new one. Author photos are kept in a
+// cache of their own, keyed by author.
+type CoverCache struct {
+	dir    string
+	client *http.Client
+	queue  chan coverRequest
+
+	mutex    sync.Mutex
+	fetching map[string]*sync.WaitGroup
+}
+
+type coverRequest struct {
+	bookID uint
+	url    string
+}
+
+// NewCoverCache creates a cover cache storing covers in dir, and starts the worker that
+// downloads queued covers
+func NewCoverCache(dir string) *CoverCache {
+	c := &CoverCache{
+		dir:      dir,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		queue:    make(chan coverRequest, 1000),
+		fetching: make(map[string]*sync.WaitGroup),
+	}
+	go c.work()
+	return c
+}
+
+// Queue downloads a book's cover in the background. Covers already cached are skipped;
+// if the queue is full the cover is downloaded when first served instead.
+func (c *CoverCache) Queue(bookID uint, url string) {
+	if url == "" {
+		return
+	}
+	select {
+	case c.queue <- coverRequest{bookID: bookID, url: url}:
+	default:
+	}
+}
+
+func (c *CoverCache) work() {
+	for req := range c.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if _, err := c.Fetch(ctx, req.bookID, req.url); err != nil {
+			logger.Warn("Could not cache cover", "bookId", req.bookID, "error", err)
+		}
+		cancel()
+	}
+}
+
+// Version identifies a cover URL, for cache-busting the URL it is served at
+func Version(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// IsUpload reports whether a cover URL refers to an uploaded image rather than a
+// remote one
+func IsUpload(url string) bool {
+	return strings.HasPrefix(url, uploadScheme)
+}
+
+// Store saves an uploaded cover for a book, returning the URL to record as its cover
+func (c *CoverCache) Store(bookID uint, data []byte) (string, error) {
+	if len(data) > MaxUploadSize {
+		return "", fmt.Errorf("image is larger than %d MB", MaxUploadSize>>20)
+	}
+	ext, ok := imageExt(data)
+	if !ok {
+		return "", fmt.Errorf("file is not a JPEG, PNG, GIF or WebP image")
+	}
+
+	sum := sha1.Sum(data)
+	url := uploadScheme + hex.EncodeToString(sum[:])
+	prefix := fmt.Sprintf("%d-%s", bookID, Version(url))
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", err
+	}
+	if err := writeFile(filepath.Join(c.dir, prefix+ext), data); err != nil {
+		return "", err
+	}
+	c.removeStale(bookID, prefix)
+	return url, nil
+}
+
+// Fetch returns the path of a book's cached cover, downloading it if needed
+func (c *CoverCache) Fetch(ctx context.Context, bookID uint, url string) (string, error) {
+	if url == "" {
+		return "", fmt.Errorf("book has no cover")
+	}
+	prefix := fmt.Sprintf("%d-%s", bookID, Version(url))
+	if path, ok := c.original(prefix); ok {
+		return path, nil
+	}
+	if IsUpload(url) {
+		return "", fmt.Errorf("uploaded cover is missing from %s", c.dir)
+	}
+
+	// One download per cover, however many requests want it
+	c.mutex.Lock()
+	if wg, ok := c.fetching[prefix]; ok {
+		c.mutex.Unlock()
+		wg.Wait()
+		if path, ok := c.original(prefix); ok {
+			return path, nil
+		}
+		return "", fmt.Errorf("cover download failed")
+	}
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	c.fetching[prefix] = wg
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		delete(c.fetching, prefix)
+		c.mutex.Unlock()
+		wg.Done()
+	}()
+
+	data, err := c.download(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	ext, _ := imageExt(data)
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(c.dir, prefix+ext)
+	if err := writeFile(path, data); err != nil {
+		return "", err
+	}
+	c.removeStale(bookID, prefix)
+	return path, nil
+}
+
+// Resized returns the path of a book's cover scaled down to a width, rounded up to the
+// next of the cached sizes. Covers that can't be resized, such as WebP ones, are
+// returned as they are.
+func (c *CoverCache) Resized(ctx context.Context, bookID uint, url string, width int) (string, error) {
+	original, err := c.Fetch(ctx, bookID, url)
+	if err != nil || width <= 0 || width >= MaxCoverWidth {
+		return original, err
+	}
+	width = (width + coverWidthStep - 1) / coverWidthStep * coverWidthStep
+
+	prefix := strings.TrimSuffix(filepath.Base(original), filepath.Ext(original))
+	path := filepath.Join(c.dir, prefix+"-"+strconv.Itoa(width)+".jpg")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(original)
+	if err != nil {
+		return "", err
+	}
+	resized, err := media.ResizeImage(data, width, 0)
+	if err != nil {
+		return original, nil
+	}
+	if err := writeFile(path, resized); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Remove deletes a book's cached covers
+func (c *CoverCache) Remove(bookID uint) {
+	c.removeStale(bookID, "")
+}
+
+// original finds a cached cover by its name without extension
+func (c *CoverCache) original(prefix string) (string, bool) {
+	matches, _ := filepath.Glob(filepath.Join(c.dir, prefix+".*"))
+	for _, match := range matches {
+		if !strings.HasSuffix(match, ".tmp") {
+			return match, true
+		}
+	}
+	return "", false
+}
+
+// removeStale deletes a book's covers other than the current one and its sizes
+func (c *CoverCache) removeStale(bookID uint, current string) {
+	matches, _ := filepath.Glob(filepath.Join(c.dir, fmt.Sprintf("%d-*", bookID)))
+	for _, match := range matches {
+		if current == "" || !strings.HasPrefix(filepath.Base(match), current) {
+			os.Remove(match)
+		}
+	}
+}
+
+func (c *CoverCache) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cover download failed: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCoverSize {
+		return nil, fmt.Errorf("cover is larger than %d MB", maxCoverSize>>20)
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil, fmt.Errorf("cover URL did not return an image")
+	}
+	return data, nil
+}
+
+// imageExt returns the file extension for an image's type, and whether it is a type
+// covers are kept as. Unknown image types are saved as JPEGs.
+func imageExt(data []byte) (string, bool) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return ".jpg", true
+	case "image/png":
+		return ".png", true
+	case "image/gif":
+		return ".gif", true
+	case "image/webp":
+		return ".webp", true
+	}
+	return ".jpg", false
+}
+
+// writeFile writes a file through a temporary file, so a cover is never served half written
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
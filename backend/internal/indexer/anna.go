@@ -2,25 +2,41 @@ package indexer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// AnnaIndexer implements the Anna's Archive indexer (scraper)
+// annaDefaultURL is used when no mirror is configured
+const annaDefaultURL = "https://annas-archive.org"
+
+// AnnaIndexer implements the Anna's Archive indexer (scraper). Results are direct
+// downloads: a member API key gets fast download links, otherwise the IPFS links on
+// the file's page are used.
 type AnnaIndexer struct {
 	name       string
+	baseURL    string
+	apiKey     string
 	httpClient *http.Client
 }
 
-// NewAnnaIndexer creates a new Anna's Archive indexer
-func NewAnnaIndexer(name string) *AnnaIndexer {
+// NewAnnaIndexer creates a new Anna's Archive indexer. baseURL selects a mirror and
+// apiKey is a member's secret key; both are optional.
+func NewAnnaIndexer(name, baseURL, apiKey string) *AnnaIndexer {
+	if baseURL == "" {
+		baseURL = annaDefaultURL
+	}
 	return &AnnaIndexer{
-		name: name,
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -36,16 +52,13 @@ func (a *AnnaIndexer) Type() string {
 }
 
 func (a *AnnaIndexer) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
-	// Anna's Archive search URL
-	baseURL := "https://annas-archive.org/search"
-	
-	u, err := url.Parse(baseURL)
+	u, err := url.Parse(a.baseURL + "/search")
 	if err != nil {
 		return nil, err
 	}
 
 	params := url.Values{}
-	
+
 	// Build search query
 	searchTerms := query.Title
 	if query.Author != "" {
@@ -55,55 +68,75 @@ func (a *AnnaIndexer) Search(ctx context.Context, query SearchQuery) ([]SearchRe
 		searchTerms = query.ISBN
 	}
 	params.Set("q", searchTerms)
+	if query.MediaType == "audiobook" {
+		params.Set("content", "book_unknown")
+	}
 
 	u.RawQuery = params.Encode()
 
-	// Make request
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-
-	resp, err := a.httpClient.Do(req)
+	body, err := a.get(ctx, u.String())
 	if err != nil {
 		return nil, fmt.Errorf("Anna's Archive request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
 
 	// Parse HTML response (basic scraping)
-	results := a.parseSearchResults(string(body))
-	
-	return results, nil
+	return a.parseSearchResults(body), nil
 }
 
-// parseSearchResults extracts search results from Anna's Archive HTML
-func (a *AnnaIndexer) parseSearchResults(html string) []SearchResult {
+var (
+	annaTitlePattern  = regexp.MustCompile(`(?s)<h3[^>]*>(.*?)</h3>`)
+	annaInfoPattern   = regexp.MustCompile(`(?s)<div[^>]*text-gray-500[^>]*>(.*?)</div>`)
+	annaAuthorPattern = regexp.MustCompile(`(?s)<div[^>]*italic[^>]*>(.*?)</div>`)
+	annaTagPattern    = regexp.MustCompile(`<[^>]+>`)
+	annaSizePattern   = regexp.MustCompile(`^([\d.]+)\s*(KB|MB|GB)$`)
+)
+
+// parseSearchResults extracts search results from Anna's Archive HTML. Each result
+// links to /md5/<hash>, followed by a line of file info ("English [en], .epub, 2.1MB, ..."),
+// the title in an <h3>, and the publisher and author.
+func (a *AnnaIndexer) parseSearchResults(page string) []SearchResult {
 	var results []SearchResult
 
-	// Basic regex patterns for scraping
-	// Note: This is a simplified implementation - real scraping would need more robust parsing
-	
-	// Find result blocks
-	resultPattern := regexp.MustCompile(`<a[^>]+href="(/md5/[^"]+)"[^>]*>.*?<div[^>]*>([^<]+)</div>`)
-	matches := resultPattern.FindAllStringSubmatch(html, -1)
-	
-	for _, match := range matches {
-		if len(match) >= 3 {
-			result := SearchResult{
-				Title:       strings.TrimSpace(match[2]),
-				InfoURL:     "https://annas-archive.org" + match[1],
-				DownloadURL: "https://annas-archive.org" + match[1],
-				Indexer:     a.name,
-				Format:      detectFormat(match[2]),
-			}
-			results = append(results, result)
+	// Results below the fold are sent inside comments and rendered on scroll
+	page = strings.NewReplacer("<!--", "", "-->", "").Replace(page)
+
+	seen := make(map[string]bool)
+	blocks := strings.Split(page, `href="/md5/`)
+	for _, block := range blocks[1:] {
+		end := strings.IndexByte(block, '"')
+		if end <= 0 {
+			continue
+		}
+		md5 := block[:end]
+		if seen[md5] {
+			continue
+		}
+
+		// Stop at the end of this result's link
+		if linkEnd := strings.Index(block, "</a>"); linkEnd > 0 {
+			block = block[:linkEnd]
+		}
+
+		title := annaText(annaTitlePattern, block)
+		if title == "" {
+			continue
+		}
+		seen[md5] = true
+
+		result := SearchResult{
+			Title:       title,
+			InfoURL:     a.baseURL + "/md5/" + md5,
+			DownloadURL: a.baseURL + "/md5/" + md5,
+			Indexer:     a.name,
+			Protocol:    ProtocolDirect,
+			Format:      detectFormat(title),
 		}
+		if authors := annaAuthorPattern.FindAllStringSubmatch(block, -1); len(authors) > 0 {
+			result.Author = annaClean(authors[len(authors)-1][1])
+		}
+		a.applyFileInfo(&result, annaText(annaInfoPattern, block))
+
+		results = append(results, result)
 	}
 
 	// Limit results
@@ -114,30 +147,127 @@ func (a *AnnaIndexer) parseSearchResults(html string) []SearchResult {
 	return results
 }
 
+// applyFileInfo reads the format and size from a result's info line
+func (a *AnnaIndexer) applyFileInfo(result *SearchResult, info string) {
+	for _, part := range strings.Split(info, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(part, ".") && len(part) <= 6:
+			result.Format = strings.ToUpper(strings.TrimPrefix(part, "."))
+		case annaSizePattern.MatchString(part):
+			match := annaSizePattern.FindStringSubmatch(part)
+			size, _ := strconv.ParseFloat(match[1], 64)
+			switch match[2] {
+			case "KB":
+				size *= 1024
+			case "MB":
+				size *= 1024 * 1024
+			case "GB":
+				size *= 1024 * 1024 * 1024
+			}
+			result.Size = int64(size)
+		}
+	}
+}
+
 func (a *AnnaIndexer) Test(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://annas-archive.org/", nil)
+	if _, err := a.get(ctx, a.baseURL+"/"); err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	return nil
+}
+
+var annaIPFSPattern = regexp.MustCompile(`href="(https?://[^"]+/ipfs/[^"]+)"`)
+
+// Download resolves a result's page into a URL the file can be fetched from directly.
+// Members' fast downloads are used when an API key is set.
+func (a *AnnaIndexer) Download(ctx context.Context, result SearchResult) (string, error) {
+	md5 := result.DownloadURL[strings.LastIndex(result.DownloadURL, "/")+1:]
+	if md5 == "" {
+		return "", fmt.Errorf("invalid download URL: %s", result.DownloadURL)
+	}
+
+	if a.apiKey != "" {
+		return a.fastDownloadURL(ctx, md5)
+	}
+
+	page, err := a.get(ctx, a.baseURL+"/md5/"+md5)
+	if err != nil {
+		return "", fmt.Errorf("failed to load file page: %w", err)
+	}
+	// Other mirrors sit behind captchas and countdowns; IPFS gateways serve the file
+	match := annaIPFSPattern.FindStringSubmatch(page)
+	if match == nil {
+		return "", fmt.Errorf("no IPFS download found; set a member API key for fast downloads")
+	}
+	return html.UnescapeString(match[1]), nil
+}
+
+// fastDownloadURL gets a download link from the members' fast download API
+func (a *AnnaIndexer) fastDownloadURL(ctx context.Context, md5 string) (string, error) {
+	params := url.Values{}
+	params.Set("md5", md5)
+	params.Set("key", a.apiKey)
+
+	body, err := a.get(ctx, a.baseURL+"/dyn/api/fast_download.json?"+params.Encode())
+	if err != nil && body == "" {
+		return "", fmt.Errorf("fast download request failed: %w", err)
+	}
+
+	var response struct {
+		DownloadURL string `json:"download_url"`
+		Error       string `json:"error"`
+	}
+	if jsonErr := json.Unmarshal([]byte(body), &response); jsonErr != nil {
+		if err != nil {
+			return "", fmt.Errorf("fast download request failed: %w", err)
+		}
+		return "", fmt.Errorf("failed to parse fast download response: %w", jsonErr)
+	}
+	if response.DownloadURL == "" {
+		if response.Error == "" {
+			response.Error = "no download URL returned"
+		}
+		return "", fmt.Errorf("fast download failed: %s", response.Error)
+	}
+	return response.DownloadURL, nil
+}
+
+// get fetches a page, returning its body along with an error for non-200 statuses
+func (a *AnnaIndexer) get(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("connection failed: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return "", err
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("server returned status %d", resp.StatusCode)
+		return string(body), fmt.Errorf("server returned status %d", resp.StatusCode)
 	}
-
-	return nil
+	return string(body), nil
 }
 
-func (a *AnnaIndexer) Download(ctx context.Context, result SearchResult) (string, error) {
-	// For Anna's Archive, we need to fetch the detail page and extract download links
-	// This would involve scraping the MD5 page for actual download URLs
-	// For now, return the info URL
-	return result.InfoURL, nil
+// annaText returns the first match of pattern in s as plain text
+func annaText(pattern *regexp.Regexp, s string) string {
+	match := pattern.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	return annaClean(match[1])
 }
 
+// annaClean strips tags and entities from an HTML fragment
+func annaClean(fragment string) string {
+	text := html.UnescapeString(annaTagPattern.ReplaceAllString(fragment, ""))
+	return strings.Join(strings.Fields(text), " ")
+}
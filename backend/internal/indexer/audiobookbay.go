@@ -0,0 +1,246 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// abbDefaultURL is used when no mirror is configured
+const abbDefaultURL = "https://audiobookbay.lu"
+
+// abbTrackers are added to magnets when a post doesn't list its own
+var abbTrackers = []string{
+	"udp://tracker.opentrackr.org:1337/announce",
+	"udp://open.demonii.com:1337/announce",
+	"udp://tracker.torrent.eu.org:451/announce",
+}
+
+// AudioBookBayIndexer implements the AudioBookBay indexer (scraper). It only has
+// audiobooks; results link to the post, which Download turns into a magnet.
+type AudioBookBayIndexer struct {
+	name       string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewAudioBookBayIndexer creates a new AudioBookBay indexer. baseURL selects a mirror
+// and is optional.
+func NewAudioBookBayIndexer(name, baseURL string) *AudioBookBayIndexer {
+	if baseURL == "" {
+		baseURL = abbDefaultURL
+	}
+	return &AudioBookBayIndexer{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (a *AudioBookBayIndexer) Name() string {
+	return a.name
+}
+
+func (a *AudioBookBayIndexer) Type() string {
+	return "audiobookbay"
+}
+
+func (a *AudioBookBayIndexer) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	if query.MediaType != "" && query.MediaType != "audiobook" {
+		return nil, nil
+	}
+
+	// ABB doesn't index ISBNs
+	searchTerms := query.Title
+	if query.Author != "" {
+		searchTerms = query.Author + " " + searchTerms
+	}
+	if searchTerms == "" {
+		return nil, nil
+	}
+
+	params := url.Values{}
+	params.Set("s", strings.ToLower(searchTerms))
+	params.Set("tt", "1") // Search titles
+
+	page, err := a.get(ctx, a.baseURL+"/?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("AudioBookBay request failed: %w", err)
+	}
+	return a.parseSearchResults(page), nil
+}
+
+var (
+	abbPostPattern     = regexp.MustCompile(`(?s)<div class="post">(.*?)<div class="postMeta">`)
+	abbTitlePattern    = regexp.MustCompile(`(?s)<div class="postTitle">\s*<h2>\s*<a href="([^"]+)"[^>]*>(.*?)</a>`)
+	abbFormatPattern   = regexp.MustCompile(`Format:\s*<span[^>]*>([^<]+)</span>`)
+	abbBitratePattern  = regexp.MustCompile(`Bitrate:\s*<span[^>]*>\s*(\d+)\s*K?bps`)
+	abbSizePattern     = regexp.MustCompile(`File Size:\s*<span[^>]*>([\d.]+)</span>\s*([KMGT]B)s?`)
+	abbPostedPattern   = regexp.MustCompile(`Posted:\s*([^<]+)<`)
+	abbCategoryPattern = regexp.MustCompile(`(?s)Category:\s*(.*?)(?:Language:|</div>)`)
+	abbLanguagePattern = regexp.MustCompile(`Language:\s*(?:<span[^>]*>)?([^<]+)`)
+	abbSeedersPattern  = regexp.MustCompile(`Seeders?:\s*(?:<[^>]+>)*\s*(\d+)`)
+	abbLeechersPattern = regexp.MustCompile(`Leechers?:\s*(?:<[^>]+>)*\s*(\d+)`)
+	abbTagPattern      = regexp.MustCompile(`<[^>]+>`)
+)
+
+// parseSearchResults extracts search results from an AudioBookBay results page. Each
+// post has its title and link, then "Format: M4B / Bitrate: 64 Kbps" and "File Size: 245.6 MBs".
+func (a *AudioBookBayIndexer) parseSearchResults(page string) []SearchResult {
+	var results []SearchResult
+	for _, post := range abbPostPattern.FindAllStringSubmatch(page, -1) {
+		title := abbTitlePattern.FindStringSubmatch(post[1])
+		if title == nil {
+			continue
+		}
+
+		link, err := url.Parse(html.UnescapeString(title[1]))
+		if err != nil {
+			continue
+		}
+		base, _ := url.Parse(a.baseURL + "/")
+		postURL := base.ResolveReference(link).String()
+
+		result := SearchResult{
+			Title:       abbText(title[2]),
+			InfoURL:     postURL,
+			DownloadURL: postURL,
+			Indexer:     a.name,
+			Protocol:    ProtocolTorrent,
+		}
+		if match := abbFormatPattern.FindStringSubmatch(post[1]); match != nil {
+			result.Format = detectFormatFromFiletype(match[1])
+		} else {
+			result.Format = detectFormat(result.Title)
+		}
+		if match := abbBitratePattern.FindStringSubmatch(post[1]); match != nil {
+			result.Bitrate, _ = strconv.Atoi(match[1])
+		}
+		if match := abbSizePattern.FindStringSubmatch(post[1]); match != nil {
+			result.Size = abbParseSize(match[1], match[2])
+		}
+		if match := abbPostedPattern.FindStringSubmatch(post[1]); match != nil {
+			result.PublishDate = strings.TrimSpace(match[1])
+		}
+		if match := abbCategoryPattern.FindStringSubmatch(post[1]); match != nil {
+			result.Category = abbText(match[1])
+		}
+		if match := abbLanguagePattern.FindStringSubmatch(post[1]); match != nil {
+			result.LangCode = languageCode(match[1])
+		}
+		// Some mirrors show peers on the results page
+		if match := abbSeedersPattern.FindStringSubmatch(post[1]); match != nil {
+			result.Seeders, _ = strconv.Atoi(match[1])
+		}
+		if match := abbLeechersPattern.FindStringSubmatch(post[1]); match != nil {
+			result.Leechers, _ = strconv.Atoi(match[1])
+		}
+		if strings.Contains(strings.ToLower(post[1]), "freeleech") {
+			result.Freeleech = true
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+func (a *AudioBookBayIndexer) Test(ctx context.Context) error {
+	if _, err := a.get(ctx, a.baseURL+"/"); err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	return nil
+}
+
+var (
+	abbHashPattern    = regexp.MustCompile(`(?s)Info Hash:\s*</td>\s*<td[^>]*>\s*([A-Fa-f0-9]{40})`)
+	abbTrackerPattern = regexp.MustCompile(`<td[^>]*>\s*((?:udp|https?)://[^<\s]+/announce[^<\s]*)\s*</td>`)
+)
+
+// Download builds a magnet link from the info hash and trackers on a result's post
+func (a *AudioBookBayIndexer) Download(ctx context.Context, result SearchResult) (string, error) {
+	if strings.HasPrefix(result.DownloadURL, "magnet:") {
+		return result.DownloadURL, nil
+	}
+
+	page, err := a.get(ctx, result.DownloadURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to load post: %w", err)
+	}
+
+	hash := abbHashPattern.FindStringSubmatch(page)
+	if hash == nil {
+		return "", fmt.Errorf("no info hash found on %s", result.DownloadURL)
+	}
+
+	trackers := []string{}
+	for _, match := range abbTrackerPattern.FindAllStringSubmatch(page, -1) {
+		trackers = append(trackers, html.UnescapeString(match[1]))
+	}
+	if len(trackers) == 0 {
+		trackers = abbTrackers
+	}
+
+	magnet := "magnet:?xt=urn:btih:" + strings.ToLower(hash[1])
+	if result.Title != "" {
+		magnet += "&dn=" + url.QueryEscape(result.Title)
+	}
+	for _, tracker := range trackers {
+		magnet += "&tr=" + url.QueryEscape(tracker)
+	}
+	return magnet, nil
+}
+
+// get fetches a page, returning an error for non-200 statuses
+func (a *AudioBookBayIndexer) get(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// abbText strips tags and entities from an HTML fragment
+func abbText(fragment string) string {
+	text := html.UnescapeString(abbTagPattern.ReplaceAllString(fragment, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// abbParseSize converts a size such as 245.6 MB to bytes
+func abbParseSize(value, unit string) int64 {
+	size, _ := strconv.ParseFloat(value, 64)
+	switch strings.ToUpper(unit) {
+	case "KB":
+		size *= 1024
+	case "MB":
+		size *= 1024 * 1024
+	case "GB":
+		size *= 1024 * 1024 * 1024
+	case "TB":
+		size *= 1024 * 1024 * 1024 * 1024
+	}
+	return int64(size)
+}
@@ -2,7 +2,19 @@ package indexer
 
 import (
 	"context"
-	"log"
+	"net/http"
+
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/logging"
+)
+
+var logger = logging.Component("indexers")
+
+// Download protocols, which decide the download client a result is sent to
+const (
+	ProtocolTorrent = "torrent"
+	ProtocolUsenet  = "usenet"
+	ProtocolDirect  = "direct" // Fetched by Shelfarr over HTTP, without a download client
 )
 
 // SearchResult represents a search result from an indexer
@@ -10,6 +22,7 @@ type SearchResult struct {
 	Title       string
 	Size        int64
 	Format      string // epub, pdf, m4b, mp3, etc.
+	Protocol    string // torrent, usenet or direct
 	Seeders     int
 	Leechers    int
 	DownloadURL string
@@ -51,7 +64,7 @@ type Indexer interface {
 	// Name returns the display name of the indexer
 	Name() string
 
-	// Type returns the indexer type (torznab, mam, anna)
+	// Type returns the indexer type (torznab, newznab, mam, anna, libgen, audiobookbay)
 	Type() string
 
 	// Search performs a search and returns results
@@ -64,9 +77,17 @@ type Indexer interface {
 	Download(ctx context.Context, result SearchResult) (string, error)
 }
 
+// Authenticator is implemented by indexers whose download links only work with the
+// indexer's credentials, such as MAM's session cookie. Shelfarr fetches those files
+// itself and gives the download client the file instead of the link.
+type Authenticator interface {
+	Authenticate(req *http.Request)
+}
+
 // Manager handles multiple indexers and orchestrates searches
 type Manager struct {
 	indexers []Indexer
+	health   *health.Tracker
 }
 
 // NewManager creates a new indexer manager
@@ -81,12 +102,22 @@ func (m *Manager) AddIndexer(indexer Indexer) {
 	m.indexers = append(m.indexers, indexer)
 }
 
+// SetHealth tracks indexer failures so one that keeps failing is skipped for a while
+func (m *Manager) SetHealth(tracker *health.Tracker) {
+	m.health = tracker
+}
+
+// CircuitName returns the health tracker name for an indexer
+func CircuitName(name string) string {
+	return "indexer:" + name
+}
+
 // SearchAll searches all enabled indexers using the waterfall method
 func (m *Manager) SearchAll(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
 	var allResults []SearchResult
 
-	log.Printf("[DEBUG] SearchAll: starting waterfall search with Title='%s', Author='%s', ISBN='%s', MediaType='%s'",
-		query.Title, query.Author, query.ISBN, query.MediaType)
+	logger.Debug("SearchAll: starting waterfall search",
+		"title", query.Title, "author", query.Author, "isbn", query.ISBN, "mediaType", query.MediaType)
 
 	// Waterfall search: Author+Title -> Title only -> ISBN (if available)
 	// Changed order: Author+Title first since it's most specific with usable text
@@ -100,24 +131,34 @@ func (m *Manager) SearchAll(ctx context.Context, query SearchQuery) ([]SearchRes
 	}
 
 	for _, indexer := range m.indexers {
-		log.Printf("[DEBUG] SearchAll: searching indexer '%s'", indexer.Name())
+		circuit := m.health.Circuit(CircuitName(indexer.Name()))
+		if err := circuit.Allow(); err != nil {
+			logger.Warn("SearchAll: skipping indexer", "indexer", indexer.Name(), "error", err)
+			continue
+		}
+
+		logger.Debug("SearchAll: searching indexer", "indexer", indexer.Name())
 
 		for i, search := range searches {
 			if search.ISBN == "" && search.Title == "" {
-				log.Printf("[DEBUG] SearchAll: skipping search #%d (empty)", i+1)
+				logger.Debug("SearchAll: skipping empty search", "search", i+1)
 				continue // Skip empty searches
 			}
 
-			log.Printf("[DEBUG] SearchAll: trying search #%d: Title='%s', Author='%s', ISBN='%s'",
-				i+1, search.Title, search.Author, search.ISBN)
+			logger.Debug("SearchAll: trying search",
+				"search", i+1, "title", search.Title, "author", search.Author, "isbn", search.ISBN)
 
 			results, err := indexer.Search(ctx, search)
+			circuit.Record(err)
 			if err != nil {
-				log.Printf("[DEBUG] SearchAll: search #%d failed: %v", i+1, err)
+				logger.Debug("SearchAll: search failed", "search", i+1, "indexer", indexer.Name(), "error", err)
+				if circuit.Allow() != nil {
+					break // That failure opened the circuit; stop hammering this indexer
+				}
 				continue // Try next search strategy on error
 			}
 
-			log.Printf("[DEBUG] SearchAll: search #%d returned %d results", i+1, len(results))
+			logger.Debug("SearchAll: search returned results", "search", i+1, "indexer", indexer.Name(), "results", len(results))
 
 			if len(results) > 0 {
 				allResults = append(allResults, results...)
@@ -126,7 +167,7 @@ func (m *Manager) SearchAll(ctx context.Context, query SearchQuery) ([]SearchRes
 		}
 	}
 
-	log.Printf("[DEBUG] SearchAll: completed with %d total results", len(allResults))
+	logger.Debug("SearchAll: completed", "results", len(allResults))
 
 	// Sort by quality score
 	// TODO: Implement quality scoring based on profiles
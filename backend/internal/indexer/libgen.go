@@ -0,0 +1,383 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// libgenDefaultMirrors are searched in turn when no mirrors are configured
+var libgenDefaultMirrors = []string{
+	"https://libgen.is",
+	"https://libgen.rs",
+	"https://libgen.st",
+}
+
+// libgenDownloadMirrors serve the files of both collections by MD5
+var libgenDownloadMirrors = []string{
+	"https://library.lol",
+	"https://libgen.li",
+}
+
+// LibGenIndexer implements the Library Genesis indexer. Non-fiction and fiction are
+// searched on the first mirror that responds, and results are identified by their MD5
+// and downloaded directly.
+type LibGenIndexer struct {
+	name       string
+	mirrors    []string
+	current    int // Mirror that last responded
+	mutex      sync.Mutex
+	httpClient *http.Client
+}
+
+// NewLibGenIndexer creates a new Library Genesis indexer. mirrors is a comma-separated
+// list of search mirrors, tried in order; the well-known ones are used when empty.
+func NewLibGenIndexer(name, mirrors string) *LibGenIndexer {
+	l := &LibGenIndexer{
+		name: name,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+	for _, mirror := range strings.Split(mirrors, ",") {
+		if mirror = strings.TrimRight(strings.TrimSpace(mirror), "/"); mirror != "" {
+			l.mirrors = append(l.mirrors, mirror)
+		}
+	}
+	if len(l.mirrors) == 0 {
+		l.mirrors = libgenDefaultMirrors
+	}
+	return l
+}
+
+func (l *LibGenIndexer) Name() string {
+	return l.name
+}
+
+func (l *LibGenIndexer) Type() string {
+	return "libgen"
+}
+
+func (l *LibGenIndexer) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	// Library Genesis has no audiobooks
+	if query.MediaType == "audiobook" {
+		return nil, nil
+	}
+
+	searchTerms := query.Title
+	if query.Author != "" {
+		searchTerms = query.Author + " " + searchTerms
+	}
+	if query.ISBN != "" {
+		searchTerms = query.ISBN
+	}
+	if len(searchTerms) < 3 {
+		return nil, nil // LibGen rejects shorter queries
+	}
+
+	var results []SearchResult
+	err := l.withMirror(func(mirror string) error {
+		fiction, err := l.searchFiction(ctx, mirror, searchTerms)
+		if err != nil {
+			return err
+		}
+		nonFiction, err := l.searchNonFiction(ctx, mirror, searchTerms)
+		if err != nil {
+			return err
+		}
+		results = append(fiction, nonFiction...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit results
+	if len(results) > 50 {
+		results = results[:50]
+	}
+	return results, nil
+}
+
+// libgenBook is a non-fiction book from the JSON API
+type libgenBook struct {
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Series    string `json:"series"`
+	Extension string `json:"extension"`
+	Filesize  string `json:"filesize"`
+	Language  string `json:"language"`
+	MD5       string `json:"md5"`
+	Year      string `json:"year"`
+}
+
+var libgenIDPattern = regexp.MustCompile(`<tr[^>]*valign=["']?top["']?[^>]*>\s*<td[^>]*>(\d+)</td>`)
+
+// searchNonFiction searches the non-fiction collection. The search page gives the IDs
+// of matches, whose details come from the JSON API.
+func (l *LibGenIndexer) searchNonFiction(ctx context.Context, mirror, searchTerms string) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("req", searchTerms)
+	params.Set("res", "25")
+	params.Set("column", "def")
+
+	page, err := l.get(ctx, mirror+"/search.php?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, match := range libgenIDPattern.FindAllStringSubmatch(page, -1) {
+		ids = append(ids, match[1])
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	params = url.Values{}
+	params.Set("ids", strings.Join(ids, ","))
+	params.Set("fields", "title,author,series,extension,filesize,language,md5,year")
+	body, err := l.get(ctx, mirror+"/json.php?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var books []libgenBook
+	if err := json.Unmarshal([]byte(body), &books); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(books))
+	for _, book := range books {
+		size, _ := strconv.ParseInt(book.Filesize, 10, 64)
+		results = append(results, l.newResult(mirror, "main", book.MD5, book.Title, book.Author, book.Series, book.Extension, book.Language, size))
+		results[len(results)-1].PublishDate = book.Year
+	}
+	return results, nil
+}
+
+var (
+	libgenFictionRowPattern   = regexp.MustCompile(`(?s)<tr[^>]*>(.*?)</tr>`)
+	libgenFictionCellPattern  = regexp.MustCompile(`(?s)<td[^>]*>(.*?)</td>`)
+	libgenFictionMD5Pattern   = regexp.MustCompile(`/fiction/([A-Fa-f0-9]{32})`)
+	libgenFictionFilePattern  = regexp.MustCompile(`^(\w+)\s*/\s*([\d.]+)\s*([KMG]b)`)
+	libgenFictionTagPattern   = regexp.MustCompile(`<[^>]+>`)
+	libgenFictionTablePattern = regexp.MustCompile(`(?s)<table[^>]*class="catalog"[^>]*>(.*?)</table>`)
+)
+
+// searchFiction searches the fiction collection, which has no JSON API. Its results are
+// a table of author, series, title, language, "EPUB / 1.2 Mb" and mirror links.
+func (l *LibGenIndexer) searchFiction(ctx context.Context, mirror, searchTerms string) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", searchTerms)
+
+	page, err := l.get(ctx, mirror+"/fiction/?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	table := libgenFictionTablePattern.FindStringSubmatch(page)
+	if table == nil {
+		return nil, nil
+	}
+
+	var results []SearchResult
+	for _, row := range libgenFictionRowPattern.FindAllStringSubmatch(table[1], -1) {
+		md5 := libgenFictionMD5Pattern.FindStringSubmatch(row[1])
+		cells := libgenFictionCellPattern.FindAllStringSubmatch(row[1], -1)
+		if md5 == nil || len(cells) < 5 {
+			continue
+		}
+
+		text := make([]string, len(cells))
+		for i, cell := range cells {
+			text[i] = strings.Join(strings.Fields(html.UnescapeString(libgenFictionTagPattern.ReplaceAllString(cell[1], " "))), " ")
+		}
+
+		var format string
+		var size int64
+		if file := libgenFictionFilePattern.FindStringSubmatch(text[4]); file != nil {
+			format = file[1]
+			value, _ := strconv.ParseFloat(file[2], 64)
+			switch strings.ToUpper(file[3]) {
+			case "KB":
+				value *= 1024
+			case "MB":
+				value *= 1024 * 1024
+			case "GB":
+				value *= 1024 * 1024 * 1024
+			}
+			size = int64(value)
+		}
+
+		// Titles also list the ISBNs, after the link text
+		title := text[2]
+		if i := strings.Index(title, "ISBN:"); i > 0 {
+			title = strings.TrimSpace(title[:i])
+		}
+		results = append(results, l.newResult(mirror, "fiction", md5[1], title, text[0], text[1], format, text[3], size))
+	}
+	return results, nil
+}
+
+// newResult builds a result identified by its MD5. The download URL is the file's page
+// on the first download mirror, which Download resolves into the file's URL.
+func (l *LibGenIndexer) newResult(mirror, collection, md5, title, author, series, extension, language string, size int64) SearchResult {
+	md5 = strings.ToLower(md5)
+	infoURL := mirror + "/book/index.php?md5=" + md5
+	if collection == "fiction" {
+		infoURL = mirror + "/fiction/" + md5
+	}
+
+	format := strings.ToUpper(extension)
+	if format == "" {
+		format = detectFormat(title)
+	}
+
+	return SearchResult{
+		Title:       title,
+		Author:      author,
+		SeriesName:  series,
+		Size:        size,
+		Format:      format,
+		LangCode:    languageCode(language),
+		InfoURL:     infoURL,
+		DownloadURL: libgenDownloadMirrors[0] + "/" + collection + "/" + md5,
+		Indexer:     l.name,
+		Protocol:    ProtocolDirect,
+	}
+}
+
+func (l *LibGenIndexer) Test(ctx context.Context) error {
+	return l.withMirror(func(mirror string) error {
+		_, err := l.get(ctx, mirror+"/")
+		return err
+	})
+}
+
+var libgenGetPattern = regexp.MustCompile(`(?i)<a[^>]+href="([^"]+)"[^>]*>\s*(?:<h2>)?\s*GET\s*(?:</h2>)?\s*</a>`)
+
+// Download resolves a result into the file's URL from the GET link on its page, trying
+// each download mirror
+func (l *LibGenIndexer) Download(ctx context.Context, result SearchResult) (string, error) {
+	parts := strings.Split(strings.TrimRight(result.DownloadURL, "/"), "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("invalid download URL: %s", result.DownloadURL)
+	}
+	collection, md5 := parts[len(parts)-2], parts[len(parts)-1]
+
+	var lastErr error
+	for _, mirror := range libgenDownloadMirrors {
+		pageURL := mirror + "/" + collection + "/" + md5
+		if strings.Contains(mirror, "libgen.li") {
+			pageURL = mirror + "/ads.php?md5=" + md5
+		}
+
+		page, err := l.get(ctx, pageURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		match := libgenGetPattern.FindStringSubmatch(page)
+		if match == nil {
+			lastErr = fmt.Errorf("no download link on %s", mirror)
+			continue
+		}
+
+		link, err := url.Parse(html.UnescapeString(match[1]))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		base, _ := url.Parse(pageURL)
+		return base.ResolveReference(link).String(), nil
+	}
+	return "", fmt.Errorf("no download mirror available: %w", lastErr)
+}
+
+// withMirror runs fn against the mirror that last worked, moving on to the next one
+// when it fails so later searches start from a mirror that's up
+func (l *LibGenIndexer) withMirror(fn func(mirror string) error) error {
+	l.mutex.Lock()
+	start := l.current
+	l.mutex.Unlock()
+
+	var err error
+	for i := range l.mirrors {
+		index := (start + i) % len(l.mirrors)
+		if err = fn(l.mirrors[index]); err == nil {
+			l.mutex.Lock()
+			l.current = index
+			l.mutex.Unlock()
+			return nil
+		}
+	}
+	return fmt.Errorf("all mirrors failed, last error: %w", err)
+}
+
+// get fetches a page, returning an error for non-200 statuses
+func (l *LibGenIndexer) get(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// languageCodes maps language names to the 3-letter codes results carry
+var languageCodes = map[string]string{
+	"english":    "ENG",
+	"spanish":    "SPA",
+	"french":     "FRA",
+	"german":     "DEU",
+	"italian":    "ITA",
+	"portuguese": "POR",
+	"russian":    "RUS",
+	"dutch":      "NLD",
+	"polish":     "POL",
+	"japanese":   "JPN",
+	"chinese":    "ZHO",
+	"ukrainian":  "UKR",
+	"swedish":    "SWE",
+	"turkish":    "TUR",
+	"arabic":     "ARA",
+}
+
+// languageCode returns the 3-letter code for a language name such as "English". Of
+// several languages ("English, French") the first is used.
+func languageCode(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.Split(name, ",")[0]))
+	if name == "" {
+		return ""
+	}
+	if code, ok := languageCodes[name]; ok {
+		return code
+	}
+	if len(name) >= 3 {
+		return strings.ToUpper(name[:3])
+	}
+	return strings.ToUpper(name)
+}
@@ -287,6 +287,7 @@ func (m *MAMIndexer) Search(ctx context.Context, query SearchQuery) ([]SearchRes
 			Freeleech:   isFree,
 			VIP:         isVIP,
 			Indexer:     m.name,
+			Protocol:    ProtocolTorrent,
 			LangCode:    toString(item.LangCode),
 		}
 
@@ -366,6 +367,11 @@ func (m *MAMIndexer) Test(ctx context.Context) error {
 	return nil
 }
 
+// Authenticate adds the session cookie, which MAM's download links require
+func (m *MAMIndexer) Authenticate(req *http.Request) {
+	req.Header.Set("Cookie", m.normalizeCookie())
+}
+
 func (m *MAMIndexer) Download(ctx context.Context, result SearchResult) (string, error) {
 	// For MAM, the download URL should already contain the hash
 	if result.DownloadURL != "" && strings.Contains(result.DownloadURL, "download.php") {
@@ -421,6 +427,12 @@ func detectFormatFromFiletype(filetype string) string {
 	if strings.Contains(filetype, "mobi") {
 		return "MOBI"
 	}
+	if strings.Contains(filetype, "cbz") {
+		return "CBZ"
+	}
+	if strings.Contains(filetype, "cbr") {
+		return "CBR"
+	}
 	if strings.Contains(filetype, "pdf") {
 		return "PDF"
 	}
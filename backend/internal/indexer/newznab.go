@@ -0,0 +1,31 @@
+package indexer
+
+import (
+	"net/http"
+	"time"
+)
+
+// NewznabIndexer implements the Newznab protocol for usenet indexers. Torznab is
+// Newznab extended for torrents, so the same API is used and results are NZBs.
+type NewznabIndexer struct {
+	*TorznabIndexer
+}
+
+// NewNewznabIndexer creates a new Newznab indexer
+func NewNewznabIndexer(name, baseURL, apiKey string) *NewznabIndexer {
+	return &NewznabIndexer{
+		TorznabIndexer: &TorznabIndexer{
+			name:    name,
+			baseURL: baseURL,
+			apiKey:  apiKey,
+			httpClient: &http.Client{
+				Timeout: 30 * time.Second,
+			},
+			protocol: ProtocolUsenet,
+		},
+	}
+}
+
+func (n *NewznabIndexer) Type() string {
+	return "newznab"
+}
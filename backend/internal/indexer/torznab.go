@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,6 +18,12 @@ type TorznabIndexer struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	protocol   string // ProtocolTorrent, or ProtocolUsenet for Newznab
+
+	// From the indexer's capabilities; the standard categories are used when empty
+	ebookCategories     []int
+	audiobookCategories []int
+	bookSearch          bool
 }
 
 // NewTorznabIndexer creates a new Torznab indexer
@@ -28,9 +35,21 @@ func NewTorznabIndexer(name, baseURL, apiKey string) *TorznabIndexer {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		protocol: ProtocolTorrent,
 	}
 }
 
+// SetCategories sets the categories searched for ebooks and audiobooks
+func (t *TorznabIndexer) SetCategories(ebook, audiobook []int) {
+	t.ebookCategories = ebook
+	t.audiobookCategories = audiobook
+}
+
+// SetBookSearch enables t=book searches by title and author, for indexers that support them
+func (t *TorznabIndexer) SetBookSearch(enabled bool) {
+	t.bookSearch = enabled
+}
+
 func (t *TorznabIndexer) Name() string {
 	return t.name
 }
@@ -75,23 +94,26 @@ func (t *TorznabIndexer) Search(ctx context.Context, query SearchQuery) ([]Searc
 	params := url.Values{}
 	params.Set("apikey", t.apiKey)
 	params.Set("t", "search")
-	
-	// Category: 7000 = Books, 3030 = Audiobooks
-	if query.MediaType == "audiobook" {
-		params.Set("cat", "3030")
+	params.Set("cat", joinCategories(t.searchCategories(query.MediaType)))
+
+	// Book search matches title and author separately, which is more precise
+	if t.bookSearch && query.ISBN == "" && query.Title != "" {
+		params.Set("t", "book")
+		params.Set("title", query.Title)
+		if query.Author != "" {
+			params.Set("author", query.Author)
+		}
 	} else {
-		params.Set("cat", "7000")
-	}
-
-	// Build search query
-	searchTerms := query.Title
-	if query.Author != "" {
-		searchTerms = query.Author + " " + searchTerms
-	}
-	if query.ISBN != "" {
-		searchTerms = query.ISBN
+		// Build search query
+		searchTerms := query.Title
+		if query.Author != "" {
+			searchTerms = query.Author + " " + searchTerms
+		}
+		if query.ISBN != "" {
+			searchTerms = query.ISBN
+		}
+		params.Set("q", searchTerms)
 	}
-	params.Set("q", searchTerms)
 
 	u.RawQuery = params.Encode()
 
@@ -127,6 +149,10 @@ func (t *TorznabIndexer) Search(ctx context.Context, query SearchQuery) ([]Searc
 			DownloadURL: item.Enclosure.URL,
 			PublishDate: item.PubDate,
 			Indexer:     t.name,
+			Protocol:    t.protocol,
+		}
+		if result.Size == 0 {
+			result.Size = item.Enclosure.Length
 		}
 
 		// Parse attributes for additional info
@@ -148,6 +174,28 @@ func (t *TorznabIndexer) Search(ctx context.Context, query SearchQuery) ([]Searc
 	return results, nil
 }
 
+// searchCategories returns the categories to search for a media type
+func (t *TorznabIndexer) searchCategories(mediaType string) []int {
+	if mediaType == "audiobook" {
+		if len(t.audiobookCategories) > 0 {
+			return t.audiobookCategories
+		}
+		return []int{CategoryAudiobook}
+	}
+	if len(t.ebookCategories) > 0 {
+		return t.ebookCategories
+	}
+	return []int{CategoryBooks}
+}
+
+func joinCategories(categories []int) string {
+	parts := make([]string, len(categories))
+	for i, id := range categories {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
 func (t *TorznabIndexer) Test(ctx context.Context) error {
 	// Test with caps endpoint
 	u, err := url.Parse(t.baseURL)
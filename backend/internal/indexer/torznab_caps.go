@@ -0,0 +1,140 @@
+package indexer
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Standard Newznab categories searched when an indexer's own aren't known
+const (
+	CategoryBooks      = 7000
+	CategoryAudiobook  = 3030
+	CategoryMagazines  = 7010
+	CategoryComics     = 7030
+	CategoryOtherMisc  = 8010
+	categoryBooksRange = 1000 // Subcategories of 7000 are 7001-7999
+)
+
+// Category is a Newznab category an indexer supports
+type Category struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Capabilities is what a Torznab indexer reports from t=caps
+type Capabilities struct {
+	SearchModes []string   // Available search functions: search, book-search, ...
+	BookParams  []string   // Parameters book-search supports: q, title, author
+	Categories  []Category // Categories and subcategories, flattened
+}
+
+// SupportsBookSearch reports whether the indexer can search by title and author
+func (c *Capabilities) SupportsBookSearch() bool {
+	for _, mode := range c.SearchModes {
+		if mode == "book-search" {
+			for _, param := range c.BookParams {
+				if param == "title" || param == "author" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+type capsResponse struct {
+	Searching struct {
+		Modes []struct {
+			XMLName         xml.Name
+			Available       string `xml:"available,attr"`
+			SupportedParams string `xml:"supportedParams,attr"`
+		} `xml:",any"`
+	} `xml:"searching"`
+	Categories []struct {
+		ID      int    `xml:"id,attr"`
+		Name    string `xml:"name,attr"`
+		Subcats []struct {
+			ID   int    `xml:"id,attr"`
+			Name string `xml:"name,attr"`
+		} `xml:"subcat"`
+	} `xml:"categories>category"`
+}
+
+// Capabilities queries the indexer's supported search modes and categories
+func (t *TorznabIndexer) Capabilities(ctx context.Context) (*Capabilities, error) {
+	u, err := url.Parse(t.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("apikey", t.apiKey)
+	params.Set("t", "caps")
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	var response capsResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse capabilities: %w", err)
+	}
+
+	caps := &Capabilities{}
+	for _, mode := range response.Searching.Modes {
+		if mode.Available != "yes" {
+			continue
+		}
+		caps.SearchModes = append(caps.SearchModes, mode.XMLName.Local)
+		if mode.XMLName.Local == "book-search" {
+			caps.BookParams = strings.Split(mode.SupportedParams, ",")
+		}
+	}
+	for _, category := range response.Categories {
+		caps.Categories = append(caps.Categories, Category{ID: category.ID, Name: category.Name})
+		for _, subcat := range category.Subcats {
+			caps.Categories = append(caps.Categories, Category{ID: subcat.ID, Name: subcat.Name})
+		}
+	}
+	return caps, nil
+}
+
+// MapCategories splits an indexer's categories into those to search for ebooks and for
+// audiobooks. Standard Newznab IDs are recognized, as are custom categories (IDs of
+// 100000 and up, or 8010 Other/Misc on some trackers) by name. Magazines and comics
+// aren't books Shelfarr tracks, so they are left out.
+func MapCategories(categories []Category) (ebook, audiobook []int) {
+	for _, category := range categories {
+		name := strings.ToLower(category.Name)
+		switch {
+		case category.ID == CategoryAudiobook:
+			audiobook = append(audiobook, category.ID)
+		case category.ID == CategoryMagazines || category.ID == CategoryComics:
+		case category.ID >= CategoryBooks && category.ID < CategoryBooks+categoryBooksRange:
+			ebook = append(ebook, category.ID)
+		case category.ID >= 100000 || category.ID == CategoryOtherMisc:
+			if strings.Contains(name, "audiobook") || strings.Contains(name, "audio book") {
+				audiobook = append(audiobook, category.ID)
+			} else if strings.Contains(name, "book") {
+				ebook = append(ebook, category.ID)
+			}
+		}
+	}
+	return ebook, audiobook
+}
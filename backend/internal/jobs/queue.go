@@ -0,0 +1,389 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/db"
+	"github.com/shelfarr/shelfarr/internal/logging"
+	"gorm.io/gorm"
+)
+
+var logger = logging.Component("jobs")
+
+// Job status values
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// ProgressFunc reports job progress (0-100) with an optional message describing the current step
+type ProgressFunc func(percent float64, message string)
+
+// Handler runs a job and returns a JSON-serialisable result.
+// Handlers must stop promptly when ctx is cancelled.
+type Handler func(ctx context.Context, job *db.Job, progress ProgressFunc) (interface{}, error)
+
+// Queue runs persisted jobs on a fixed pool of workers
+type Queue struct {
+	db       *gorm.DB
+	workers  int
+	handlers map[string]Handler
+	running  map[uint]context.CancelFunc
+	onUpdate func(job db.Job)
+	mutex    sync.Mutex
+	wake     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	active   sync.WaitGroup // Jobs running now
+	draining bool           // Shutting down, so no more jobs start
+}
+
+// NewQueue creates a new job queue with the given number of workers
+func NewQueue(gdb *gorm.DB, workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		db:       gdb,
+		workers:  workers,
+		handlers: make(map[string]Handler),
+		running:  make(map[uint]context.CancelFunc),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register sets the handler for a job type
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// OnUpdate sets a callback invoked whenever a job's status or progress changes
+func (q *Queue) OnUpdate(fn func(job db.Job)) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.onUpdate = fn
+}
+
+// Start requeues jobs interrupted by a restart and starts the workers
+func (q *Queue) Start() {
+	q.db.Model(&db.Job{}).Where("status = ?", StatusRunning).
+		Updates(map[string]interface{}{"status": StatusQueued, "progress": 0, "message": "Requeued after restart"})
+
+	for i := 0; i < q.workers; i++ {
+		go q.worker()
+	}
+	q.notify()
+
+	logger.Info("Job queue started", "workers", q.workers)
+}
+
+// Stop cancels running jobs and stops the workers
+func (q *Queue) Stop() {
+	q.cancel()
+}
+
+// Shutdown stops starting jobs and waits for those running to finish. If ctx ends
+// first they're cancelled and requeued, to run again from the start next time the
+// queue starts; Shutdown waits for them to be saved.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mutex.Lock()
+	q.draining = true
+	q.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.active.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		q.mutex.Lock()
+		logger.Warn("Requeuing jobs still running at shutdown", "jobs", len(q.running))
+		q.mutex.Unlock()
+		q.cancel()
+		<-done
+	}
+	q.cancel()
+	return err
+}
+
+// Enqueue adds a job to the queue. payload is stored as JSON
+func (q *Queue) Enqueue(jobType string, payload interface{}) (*db.Job, error) {
+	q.mutex.Lock()
+	_, ok := q.handlers[jobType]
+	q.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	job := &db.Job{
+		Type:    jobType,
+		Status:  StatusQueued,
+		Payload: string(data),
+	}
+	if err := q.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	q.emit(*job)
+	q.notify()
+	return job, nil
+}
+
+// Cancel cancels a queued or running job, failing if there's nothing to cancel
+func (q *Queue) Cancel(id uint) error {
+	cancelled, err := q.cancelJob(id)
+	if err != nil {
+		return err
+	}
+	if cancelled != nil {
+		q.emit(*cancelled)
+	}
+	return nil
+}
+
+// cancelJob cancels a job, returning it if it was still queued. It holds the mutex, as
+// claim does, so a job is either cancelled before a worker claims it or stopped once
+// it's running.
+func (q *Queue) cancelJob(id uint) (*db.Job, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	update := q.db.Model(&db.Job{}).Where("id = ? AND status = ?", id, StatusQueued).
+		Updates(map[string]interface{}{"status": StatusCancelled, "completed_at": time.Now()})
+	if update.Error != nil {
+		return nil, update.Error
+	}
+	if update.RowsAffected > 0 {
+		var job db.Job
+		if err := q.db.First(&job, id).Error; err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+
+	if cancel, ok := q.running[id]; ok {
+		cancel()
+		return nil, nil
+	}
+
+	var job db.Job
+	if err := q.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	if job.Status == StatusRunning {
+		return nil, fmt.Errorf("job isn't running in this process")
+	}
+	return nil, fmt.Errorf("job is already %s", job.Status)
+}
+
+// Retry requeues a failed or cancelled job
+func (q *Queue) Retry(id uint) (*db.Job, error) {
+	var job db.Job
+	if err := q.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	if job.Status != StatusFailed && job.Status != StatusCancelled {
+		return nil, fmt.Errorf("only failed or cancelled jobs can be retried")
+	}
+
+	job.Status = StatusQueued
+	job.Progress = 0
+	job.Message = ""
+	job.Error = ""
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	if err := q.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+
+	q.emit(job)
+	q.notify()
+	return &job, nil
+}
+
+// DecodePayload unmarshals a job's payload into v
+func DecodePayload(job *db.Job, v interface{}) error {
+	if err := json.Unmarshal([]byte(job.Payload), v); err != nil {
+		return fmt.Errorf("invalid job payload: %w", err)
+	}
+	return nil
+}
+
+// notify wakes one idle worker
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) worker() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		for {
+			job, ctx, ok := q.claim()
+			if !ok {
+				break
+			}
+			q.run(ctx, job)
+		}
+
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim marks the oldest queued job as running and returns it, with the context it
+// runs in. The job can be cancelled from the moment it's claimed.
+func (q *Queue) claim() (*db.Job, context.Context, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.ctx.Err() != nil || q.draining {
+		return nil, nil, false
+	}
+
+	var job db.Job
+	if err := q.db.Where("status = ?", StatusQueued).Order("id ASC").First(&job).Error; err != nil {
+		return nil, nil, false
+	}
+
+	now := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &now
+	job.Attempts++
+	if err := q.db.Save(&job).Error; err != nil {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	q.running[job.ID] = cancel
+	q.active.Add(1)
+
+	// Let other workers pick up anything else that's waiting
+	q.notify()
+	return &job, ctx, true
+}
+
+func (q *Queue) run(ctx context.Context, job *db.Job) {
+	defer q.active.Done()
+
+	q.mutex.Lock()
+	handler := q.handlers[job.Type]
+	q.mutex.Unlock()
+
+	defer func() {
+		q.mutex.Lock()
+		cancel := q.running[job.ID]
+		delete(q.running, job.ID)
+		q.mutex.Unlock()
+		cancel()
+	}()
+
+	q.emit(*job)
+
+	var result interface{}
+	var err error
+	if handler == nil {
+		err = fmt.Errorf("no handler registered for job type %s", job.Type)
+	} else {
+		logger.Info("Running job", "job", job.ID, "type", job.Type)
+		result, err = q.safeRun(ctx, handler, job)
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+
+	switch {
+	case ctx.Err() != nil && q.ctx.Err() == nil:
+		job.Status = StatusCancelled
+		job.Message = "Cancelled"
+	case ctx.Err() != nil:
+		// Shutting down; requeue on next start
+		job.Status = StatusQueued
+		job.CompletedAt = nil
+		job.Progress = 0
+		job.Message = "Interrupted by shutdown; runs again on restart"
+	case err != nil:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+		logger.Warn("Job failed", "job", job.ID, "type", job.Type, "error", err)
+	default:
+		job.Status = StatusCompleted
+		job.Progress = 100
+		if result != nil {
+			if data, marshalErr := json.Marshal(result); marshalErr == nil {
+				job.Result = string(data)
+			}
+		}
+		logger.Info("Job completed", "job", job.ID, "type", job.Type, "duration", now.Sub(*job.StartedAt))
+	}
+
+	q.db.Save(job)
+	q.emit(*job)
+}
+
+// safeRun runs a handler, turning panics into job failures
+func (q *Queue) safeRun(ctx context.Context, handler Handler, job *db.Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	lastSaved := -1.0
+	progress := func(percent float64, message string) {
+		percent = math.Max(0, math.Min(100, percent))
+		if math.Abs(percent-lastSaved) < 1 && message == job.Message {
+			return
+		}
+		lastSaved = percent
+		job.Progress = percent
+		if message != "" {
+			job.Message = message
+		}
+		q.db.Model(&db.Job{}).Where("id = ?", job.ID).
+			Updates(map[string]interface{}{"progress": job.Progress, "message": job.Message})
+		q.emit(*job)
+	}
+
+	return handler(ctx, job, progress)
+}
+
+func (q *Queue) emit(job db.Job) {
+	q.mutex.Lock()
+	fn := q.onUpdate
+	q.mutex.Unlock()
+	if fn != nil {
+		fn(job)
+	}
+}
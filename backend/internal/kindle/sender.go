@@ -2,8 +2,10 @@ package kindle
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -12,11 +14,18 @@ import (
 	"net/textproto"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/shelfarr/shelfarr/internal/media"
 )
 
-// SupportedFormats lists formats that Kindle supports
-var SupportedFormats = []string{".mobi", ".azw", ".azw3", ".pdf", ".epub", ".doc", ".docx", ".txt", ".rtf", ".html"}
+// SupportedFormats lists formats that Send to Kindle accepts by email.
+// Amazon no longer accepts MOBI/AZW attachments; those must be converted to EPUB first.
+var SupportedFormats = []string{".epub", ".pdf", ".doc", ".docx", ".txt", ".rtf", ".htm", ".html"}
+
+// MaxAttachmentSize is the largest attachment Send to Kindle accepts by email (50 MB)
+const MaxAttachmentSize int64 = 50 * 1024 * 1024
 
 // KindleSender handles sending books to Kindle devices
 type KindleSender struct {
@@ -52,102 +61,157 @@ func NewKindleSender(config SMTPConfig) *KindleSender {
 
 // SendResult represents the result of a send operation
 type SendResult struct {
-	Success  bool
-	Error    string
-	FilePath string
-	ToEmail  string
+	Success   bool
+	Error     string
+	FilePath  string
+	ToEmail   string
+	Format    string // Format of the attachment actually sent (after any conversion)
+	Size      int64  // Attachment size in bytes
+	Converted bool   // True if the file was converted before sending
+}
+
+// IsSupportedFormat reports whether a file extension can be sent without conversion
+func IsSupportedFormat(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, f := range SupportedFormats {
+		if ext == f {
+			return true
+		}
+	}
+	return false
 }
 
 // Send sends a book file to a Kindle email address
 func (k *KindleSender) Send(filePath, kindleEmail string) (*SendResult, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
 	result := &SendResult{
 		FilePath: filePath,
 		ToEmail:  kindleEmail,
+		Format:   strings.TrimPrefix(ext, "."),
 	}
 
 	// Validate file exists
-	if _, err := os.Stat(filePath); err != nil {
+	info, err := os.Stat(filePath)
+	if err != nil {
 		result.Error = fmt.Sprintf("file not found: %s", filePath)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
+	result.Size = info.Size()
 
 	// Validate format
-	ext := strings.ToLower(filepath.Ext(filePath))
-	supported := false
-	for _, f := range SupportedFormats {
-		if ext == f {
-			supported = true
-			break
-		}
-	}
-	if !supported {
+	if !IsSupportedFormat(ext) {
 		result.Error = fmt.Sprintf("unsupported format: %s", ext)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
+	}
+
+	return k.sendFile(result)
+}
+
+// SendFile emails a file as an attachment without Kindle format checks.
+// Used for other e-readers (Kobo, PocketBook) that accept any ebook format by email.
+func (k *KindleSender) SendFile(filePath, toEmail string) (*SendResult, error) {
+	result := &SendResult{
+		FilePath: filePath,
+		ToEmail:  toEmail,
+		Format:   strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), "."),
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		result.Error = fmt.Sprintf("file not found: %s", filePath)
+		return result, errors.New(result.Error)
+	}
+	result.Size = info.Size()
+
+	return k.sendFile(result)
+}
+
+// sendFile validates the attachment size, then builds and sends the email described by result
+func (k *KindleSender) sendFile(result *SendResult) (*SendResult, error) {
+	filePath := result.FilePath
+	toEmail := result.ToEmail
+	ext := filepath.Ext(filePath)
+
+	// Validate size before reading the whole file into memory
+	if result.Size > MaxAttachmentSize {
+		result.Error = fmt.Sprintf("file is too large to send by email: %d MB (limit %d MB)", result.Size/(1024*1024), MaxAttachmentSize/(1024*1024))
+		return result, errors.New(result.Error)
 	}
 
 	// Read file
 	fileData, err := os.ReadFile(filePath)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to read file: %v", err)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	// Build email
 	fileName := filepath.Base(filePath)
 	subject := "Kindle Document: " + strings.TrimSuffix(fileName, ext)
-	
-	message, err := k.buildEmailWithAttachment(kindleEmail, subject, fileName, fileData)
+
+	message, err := k.buildEmailWithAttachment(toEmail, subject, fileName, fileData)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to build email: %v", err)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	// Send email
-	if err := k.sendEmail(kindleEmail, message); err != nil {
+	if err := k.sendEmail(toEmail, message); err != nil {
 		result.Error = fmt.Sprintf("failed to send email: %v", err)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	result.Success = true
 	return result, nil
 }
 
-// SendWithConversion sends a book, converting if necessary
-func (k *KindleSender) SendWithConversion(filePath, kindleEmail string, converter EbookConverter) (*SendResult, error) {
+// SendWithConversion sends a book, converting it to EPUB first if Kindle won't accept the format
+func (k *KindleSender) SendWithConversion(ctx context.Context, filePath, kindleEmail string, converter EbookConverter) (*SendResult, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
-	
-	// Check if conversion is needed
-	// EPUB needs to be converted to MOBI for older Kindles, but modern Kindles support EPUB
-	// For best compatibility, we'll allow EPUB to be sent directly since newer Kindles support it
-	if ext == ".epub" || ext == ".mobi" || ext == ".azw3" || ext == ".pdf" {
+	if IsSupportedFormat(ext) {
 		return k.Send(filePath, kindleEmail)
 	}
 
-	// Convert other formats to MOBI
-	if converter != nil && converter.IsAvailable() {
-		outputPath := strings.TrimSuffix(filePath, ext) + ".mobi"
-		
-		// Perform conversion
-		ctx := converter.Context()
-		if _, err := converter.ConvertToFormat(ctx, filePath, "mobi", nil); err == nil {
-			// Send converted file
-			result, sendErr := k.Send(outputPath, kindleEmail)
-			
-			// Clean up converted file
-			os.Remove(outputPath)
-			
-			return result, sendErr
+	if converter == nil || !converter.IsAvailable() {
+		result := &SendResult{
+			FilePath: filePath,
+			ToEmail:  kindleEmail,
+			Format:   strings.TrimPrefix(ext, "."),
+			Error:    fmt.Sprintf("%s files must be converted to EPUB but ebook-convert is not available", ext),
+		}
+		return result, errors.New(result.Error)
+	}
+
+	// Convert into a temp dir so the library folder is never touched
+	tmpDir, err := os.MkdirTemp("", "shelfarr-kindle-")
+	if err != nil {
+		return &SendResult{FilePath: filePath, ToEmail: kindleEmail, Error: err.Error()}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	outputPath := filepath.Join(tmpDir, baseName+".epub")
+
+	if _, err := converter.Convert(ctx, filePath, outputPath, &media.ConversionOptions{OutputFormat: "epub"}); err != nil {
+		result := &SendResult{
+			FilePath: filePath,
+			ToEmail:  kindleEmail,
+			Format:   strings.TrimPrefix(ext, "."),
+			Error:    fmt.Sprintf("conversion to EPUB failed: %v", err),
 		}
+		return result, errors.New(result.Error)
 	}
 
-	// Fall back to sending original
-	return k.Send(filePath, kindleEmail)
+	result, err := k.Send(outputPath, kindleEmail)
+	result.FilePath = filePath
+	result.Converted = true
+	return result, err
 }
 
 func (k *KindleSender) buildEmailWithAttachment(to, subject, filename string, data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
-	
+
 	// Headers
 	headers := make(textproto.MIMEHeader)
 	headers.Set("From", k.fromEmail)
@@ -155,7 +219,7 @@ func (k *KindleSender) buildEmailWithAttachment(to, subject, filename string, da
 	headers.Set("Subject", subject)
 	headers.Set("MIME-Version", "1.0")
 	headers.Set("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%s", writer.Boundary()))
-	
+
 	// Write headers
 	for key, values := range headers {
 		for _, value := range values {
@@ -163,13 +227,13 @@ func (k *KindleSender) buildEmailWithAttachment(to, subject, filename string, da
 		}
 	}
 	buf.WriteString("\r\n")
-	
+
 	// Empty body part
 	textPart, _ := writer.CreatePart(textproto.MIMEHeader{
 		"Content-Type": []string{"text/plain; charset=utf-8"},
 	})
 	textPart.Write([]byte("Sent from Shelfarr\r\n"))
-	
+
 	// Attachment part
 	contentType := k.getContentType(filename)
 	attachmentHeader := textproto.MIMEHeader{
@@ -177,12 +241,12 @@ func (k *KindleSender) buildEmailWithAttachment(to, subject, filename string, da
 		"Content-Transfer-Encoding": []string{"base64"},
 		"Content-Disposition":       []string{"attachment; filename=\"" + filename + "\""},
 	}
-	
+
 	attachmentPart, err := writer.CreatePart(attachmentHeader)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Write base64 encoded data with line breaks
 	encoded := base64.StdEncoding.EncodeToString(data)
 	for i := 0; i < len(encoded); i += 76 {
@@ -192,20 +256,21 @@ func (k *KindleSender) buildEmailWithAttachment(to, subject, filename string, da
 		}
 		attachmentPart.Write([]byte(encoded[i:end] + "\r\n"))
 	}
-	
+
 	writer.Close()
-	
+
 	return buf.Bytes(), nil
 }
 
 func (k *KindleSender) getContentType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
-	
+
 	contentTypes := map[string]string{
 		".mobi": "application/x-mobipocket-ebook",
 		".azw":  "application/vnd.amazon.ebook",
 		".azw3": "application/vnd.amazon.ebook",
 		".epub": "application/epub+zip",
+		".htm":  "text/html",
 		".pdf":  "application/pdf",
 		".doc":  "application/msword",
 		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
@@ -213,7 +278,7 @@ func (k *KindleSender) getContentType(filename string) string {
 		".rtf":  "application/rtf",
 		".html": "text/html",
 	}
-	
+
 	if ct, ok := contentTypes[ext]; ok {
 		return ct
 	}
@@ -221,22 +286,22 @@ func (k *KindleSender) getContentType(filename string) string {
 }
 
 func (k *KindleSender) sendEmail(to string, message []byte) error {
-	addr := fmt.Sprintf("%s:%d", k.smtpHost, k.smtpPort)
-	
+	addr := net.JoinHostPort(k.smtpHost, strconv.Itoa(k.smtpPort))
+
 	var client *smtp.Client
 	var err error
-	
+
 	if k.useTLS {
 		// Connect with TLS
 		tlsConfig := &tls.Config{
 			ServerName: k.smtpHost,
 		}
-		
+
 		conn, err := tls.Dial("tcp", addr, tlsConfig)
 		if err != nil {
 			return fmt.Errorf("TLS connection failed: %w", err)
 		}
-		
+
 		client, err = smtp.NewClient(conn, k.smtpHost)
 		if err != nil {
 			conn.Close()
@@ -248,13 +313,13 @@ func (k *KindleSender) sendEmail(to string, message []byte) error {
 		if err != nil {
 			return fmt.Errorf("connection failed: %w", err)
 		}
-		
+
 		client, err = smtp.NewClient(conn, k.smtpHost)
 		if err != nil {
 			conn.Close()
 			return fmt.Errorf("SMTP client creation failed: %w", err)
 		}
-		
+
 		// Try STARTTLS
 		if ok, _ := client.Extension("STARTTLS"); ok {
 			tlsConfig := &tls.Config{
@@ -266,7 +331,7 @@ func (k *KindleSender) sendEmail(to string, message []byte) error {
 		}
 	}
 	defer client.Close()
-	
+
 	// Authenticate if credentials provided
 	if k.smtpUsername != "" && k.smtpPassword != "" {
 		auth := smtp.PlainAuth("", k.smtpUsername, k.smtpPassword, k.smtpHost)
@@ -274,41 +339,41 @@ func (k *KindleSender) sendEmail(to string, message []byte) error {
 			return fmt.Errorf("authentication failed: %w", err)
 		}
 	}
-	
+
 	// Set sender
 	if err := client.Mail(k.fromEmail); err != nil {
 		return fmt.Errorf("MAIL FROM failed: %w", err)
 	}
-	
+
 	// Set recipient
 	if err := client.Rcpt(to); err != nil {
 		return fmt.Errorf("RCPT TO failed: %w", err)
 	}
-	
+
 	// Send data
 	writer, err := client.Data()
 	if err != nil {
 		return fmt.Errorf("DATA command failed: %w", err)
 	}
-	
+
 	if _, err := writer.Write(message); err != nil {
 		return fmt.Errorf("write failed: %w", err)
 	}
-	
+
 	if err := writer.Close(); err != nil {
 		return fmt.Errorf("close failed: %w", err)
 	}
-	
+
 	return client.Quit()
 }
 
 // Test tests the SMTP connection
 func (k *KindleSender) Test() error {
-	addr := fmt.Sprintf("%s:%d", k.smtpHost, k.smtpPort)
-	
+	addr := net.JoinHostPort(k.smtpHost, strconv.Itoa(k.smtpPort))
+
 	var conn net.Conn
 	var err error
-	
+
 	if k.useTLS {
 		tlsConfig := &tls.Config{
 			ServerName: k.smtpHost,
@@ -317,18 +382,18 @@ func (k *KindleSender) Test() error {
 	} else {
 		conn, err = net.Dial("tcp", addr)
 	}
-	
+
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
 	defer conn.Close()
-	
+
 	client, err := smtp.NewClient(conn, k.smtpHost)
 	if err != nil {
 		return fmt.Errorf("SMTP client creation failed: %w", err)
 	}
 	defer client.Close()
-	
+
 	// Try STARTTLS if not using TLS
 	if !k.useTLS {
 		if ok, _ := client.Extension("STARTTLS"); ok {
@@ -338,7 +403,7 @@ func (k *KindleSender) Test() error {
 			client.StartTLS(tlsConfig)
 		}
 	}
-	
+
 	// Test authentication
 	if k.smtpUsername != "" && k.smtpPassword != "" {
 		auth := smtp.PlainAuth("", k.smtpUsername, k.smtpPassword, k.smtpHost)
@@ -346,24 +411,23 @@ func (k *KindleSender) Test() error {
 			return fmt.Errorf("authentication failed: %w", err)
 		}
 	}
-	
+
 	return client.Quit()
 }
 
-// EbookConverter interface for optional format conversion
+// EbookConverter converts ebooks into a format Kindle accepts (satisfied by media.EbookConverter)
 type EbookConverter interface {
 	IsAvailable() bool
-	Context() interface{}
-	ConvertToFormat(ctx interface{}, inputPath, outputFormat string, opts interface{}) (interface{}, error)
+	Convert(ctx context.Context, inputPath, outputPath string, opts *media.ConversionOptions) (*media.ConversionResult, error)
 }
 
 // ValidateKindleEmail validates a Kindle email address
 func ValidateKindleEmail(email string) bool {
 	// Kindle emails must end with @kindle.com or @free.kindle.com
 	email = strings.ToLower(email)
-	return strings.HasSuffix(email, "@kindle.com") || 
-		   strings.HasSuffix(email, "@free.kindle.com") ||
-		   strings.HasSuffix(email, "@kindle.cn")
+	return strings.HasSuffix(email, "@kindle.com") ||
+		strings.HasSuffix(email, "@free.kindle.com") ||
+		strings.HasSuffix(email, "@kindle.cn")
 }
 
 // SendQueue handles queuing and batch sending
@@ -395,15 +459,15 @@ func (q *SendQueue) Add(req SendRequest) {
 // Process processes all queued sends
 func (q *SendQueue) Process() []SendResult {
 	results := make([]SendResult, 0, len(q.queue))
-	
+
 	for _, req := range q.queue {
 		result, _ := q.sender.Send(req.FilePath, req.KindleEmail)
 		results = append(results, *result)
 	}
-	
+
 	// Clear queue
 	q.queue = q.queue[:0]
-	
+
 	return results
 }
 
@@ -414,4 +478,3 @@ func (q *SendQueue) Len() int {
 
 // Compile-time check that we implement io.Writer
 var _ io.Writer = (*bytes.Buffer)(nil)
-
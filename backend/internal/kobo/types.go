@@ -0,0 +1,166 @@
+package kobo
+
+// SyncItem is one change in a library sync response; exactly one field is set
+type SyncItem struct {
+	NewEntitlement      *Entitlement         `json:"NewEntitlement,omitempty"`
+	ChangedEntitlement  *Entitlement         `json:"ChangedEntitlement,omitempty"`
+	ChangedReadingState *ChangedReadingState `json:"ChangedReadingState,omitempty"`
+}
+
+// Entitlement is a book in the device's library
+type Entitlement struct {
+	BookEntitlement BookEntitlement `json:"BookEntitlement"`
+	BookMetadata    *BookMetadata   `json:"BookMetadata,omitempty"`
+	ReadingState    *ReadingState   `json:"ReadingState,omitempty"`
+}
+
+// ChangedReadingState wraps a reading state changed since the last sync
+type ChangedReadingState struct {
+	ReadingState ReadingState `json:"ReadingState"`
+}
+
+// BookEntitlement is the device's right to a book
+type BookEntitlement struct {
+	Accessibility       string       `json:"Accessibility"`
+	ActivePeriod        ActivePeriod `json:"ActivePeriod"`
+	Created             string       `json:"Created"`
+	CrossRevisionID     string       `json:"CrossRevisionId"`
+	ID                  string       `json:"Id"`
+	IsHiddenFromArchive bool         `json:"IsHiddenFromArchive"`
+	IsLocked            bool         `json:"IsLocked"`
+	IsRemoved           bool         `json:"IsRemoved"`
+	LastModified        string       `json:"LastModified"`
+	OriginCategory      string       `json:"OriginCategory"`
+	RevisionID          string       `json:"RevisionId"`
+	Status              string       `json:"Status"`
+}
+
+// ActivePeriod is when an entitlement applies from
+type ActivePeriod struct {
+	From string `json:"From"`
+}
+
+// BookMetadata describes a book and where to download it
+type BookMetadata struct {
+	Categories              []string       `json:"Categories"`
+	ContributorRoles        []Contributor  `json:"ContributorRoles"`
+	Contributors            []string       `json:"Contributors"`
+	CoverImageID            string         `json:"CoverImageId"`
+	CrossRevisionID         string         `json:"CrossRevisionId"`
+	CurrentDisplayPrice     Price          `json:"CurrentDisplayPrice"`
+	CurrentLoveDisplayPrice Price          `json:"CurrentLoveDisplayPrice"`
+	Description             string         `json:"Description"`
+	DownloadUrls            []DownloadURL  `json:"DownloadUrls"`
+	EntitlementID           string         `json:"EntitlementId"`
+	ExternalIDs             []string       `json:"ExternalIds"`
+	Genre                   string         `json:"Genre"`
+	IsEligibleForKoboLove   bool           `json:"IsEligibleForKoboLove"`
+	IsInternetArchive       bool           `json:"IsInternetArchive"`
+	IsPreOrder              bool           `json:"IsPreOrder"`
+	IsSocialEnabled         bool           `json:"IsSocialEnabled"`
+	Language                string         `json:"Language"`
+	PhoneticPronunciations  map[string]any `json:"PhoneticPronunciations"`
+	PublicationDate         string         `json:"PublicationDate,omitempty"`
+	Publisher               Publisher      `json:"Publisher"`
+	RevisionID              string         `json:"RevisionId"`
+	Series                  *Series        `json:"Series,omitempty"`
+	Title                   string         `json:"Title"`
+	WorkID                  string         `json:"WorkId"`
+}
+
+// Contributor is a book's author
+type Contributor struct {
+	Name string `json:"Name"`
+}
+
+// Price is a store price; library books are free
+type Price struct {
+	CurrencyCode string  `json:"CurrencyCode,omitempty"`
+	TotalAmount  float64 `json:"TotalAmount"`
+}
+
+// DownloadURL is where the device downloads one format of a book
+type DownloadURL struct {
+	Format   string `json:"Format"` // KEPUB, EPUB3, EPUB
+	Size     int64  `json:"Size"`
+	URL      string `json:"Url"`
+	Platform string `json:"Platform"`
+}
+
+// Publisher is a book's publisher
+type Publisher struct {
+	Imprint string `json:"Imprint"`
+	Name    string `json:"Name"`
+}
+
+// Series is the series a book belongs to
+type Series struct {
+	Name        string  `json:"Name"`
+	Number      int     `json:"Number"`
+	NumberFloat float32 `json:"NumberFloat"`
+	ID          string  `json:"Id"`
+}
+
+// ReadingState is how far the user is through a book
+type ReadingState struct {
+	EntitlementID     string      `json:"EntitlementId"`
+	Created           string      `json:"Created,omitempty"`
+	LastModified      string      `json:"LastModified"`
+	PriorityTimestamp string      `json:"PriorityTimestamp,omitempty"`
+	StatusInfo        *StatusInfo `json:"StatusInfo,omitempty"`
+	Statistics        *Statistics `json:"Statistics,omitempty"`
+	CurrentBookmark   *Bookmark   `json:"CurrentBookmark,omitempty"`
+}
+
+// StatusInfo is whether the book is unread, being read or finished
+type StatusInfo struct {
+	LastModified        string `json:"LastModified"`
+	Status              string `json:"Status"`
+	TimesStartedReading int    `json:"TimesStartedReading"`
+}
+
+// Statistics is the device's reading time for a book
+type Statistics struct {
+	LastModified         string `json:"LastModified"`
+	SpentReadingMinutes  int    `json:"SpentReadingMinutes,omitempty"`
+	RemainingTimeMinutes int    `json:"RemainingTimeMinutes,omitempty"`
+}
+
+// Bookmark is the reading position in a book
+type Bookmark struct {
+	LastModified                 string    `json:"LastModified"`
+	ProgressPercent              *float32  `json:"ProgressPercent,omitempty"`
+	ContentSourceProgressPercent *float32  `json:"ContentSourceProgressPercent,omitempty"`
+	Location                     *Location `json:"Location,omitempty"`
+}
+
+// Location is a position within the book, in the device's own format
+type Location struct {
+	Value  string `json:"Value"`
+	Type   string `json:"Type"`
+	Source string `json:"Source"`
+}
+
+// ReadingStatesRequest is the body of a reading state update from the device
+type ReadingStatesRequest struct {
+	ReadingStates []ReadingState `json:"ReadingStates"`
+}
+
+// ReadingStatesResponse acknowledges a reading state update
+type ReadingStatesResponse struct {
+	RequestResult string         `json:"RequestResult"`
+	UpdateResults []UpdateResult `json:"UpdateResults"`
+}
+
+// UpdateResult acknowledges each part of one book's reading state update
+type UpdateResult struct {
+	EntitlementID         string `json:"EntitlementId"`
+	CurrentBookmarkResult Result `json:"CurrentBookmarkResult"`
+	StatisticsResult      Result `json:"StatisticsResult"`
+	StatusInfoResult      Result `json:"StatusInfoResult"`
+}
+
+// Result is the outcome of one part of an update
+type Result struct {
+	Result string `json:"Result"`
+}
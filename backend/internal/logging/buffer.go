@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bufferSize is how many recent entries are kept
+const bufferSize = 2000
+
+// Entry is a logged record
+type Entry struct {
+	ID      uint64            `json:"id"` // Increases with each entry, for fetching only newer ones
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"` // debug, info, warn or error
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// Query picks entries from the recent ones
+type Query struct {
+	After  uint64 // Only entries with a higher ID; 0 for all
+	Level  string // Only entries at this level or above; "" for all
+	Search string // Only entries whose message or attributes contain this, ignoring case
+	Limit  int    // At most this many of the newest matches; 0 for all
+}
+
+// ring holds the most recent entries, oldest first once it wraps
+type ring struct {
+	mutex   sync.Mutex
+	entries []Entry
+	start   int // Index of the oldest entry once the ring is full
+	lastID  uint64
+}
+
+var recent = &ring{entries: make([]Entry, 0, bufferSize)}
+
+func (r *ring) add(t time.Time, level, message string, attrs map[string]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastID++
+	entry := Entry{ID: r.lastID, Time: t, Level: level, Message: message, Attrs: attrs}
+	if len(r.entries) < bufferSize {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % bufferSize
+}
+
+// Recent returns the recent entries matching q, oldest first
+func Recent(q Query) []Entry {
+	minLevel, filterLevel := slog.LevelDebug, false
+	if q.Level != "" {
+		if l, err := ParseLevel(q.Level); err == nil {
+			minLevel, filterLevel = l, true
+		}
+	}
+	search := strings.ToLower(q.Search)
+
+	recent.mutex.Lock()
+	defer recent.mutex.Unlock()
+
+	matches := []Entry{}
+	for i := range recent.entries {
+		entry := recent.entries[(recent.start+i)%len(recent.entries)]
+		if entry.ID <= q.After {
+			continue
+		}
+		if filterLevel {
+			if l, _ := ParseLevel(entry.Level); l < minLevel {
+				continue
+			}
+		}
+		if search != "" && !entryContains(entry, search) {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[len(matches)-q.Limit:]
+	}
+	return matches
+}
+
+// Clear empties the recent entries
+func Clear() {
+	recent.mutex.Lock()
+	defer recent.mutex.Unlock()
+	recent.entries = recent.entries[:0]
+	recent.start = 0
+}
+
+// entryContains reports whether an entry's message or attributes contain the
+// lowercase text
+func entryContains(entry Entry, text string) bool {
+	if strings.Contains(strings.ToLower(entry.Message), text) {
+		return true
+	}
+	for key, value := range entry.Attrs {
+		if strings.Contains(strings.ToLower(key+"="+value), text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileOptions configures the log file
+type FileOptions struct {
+	Path       string // Where the current log file is written, such as /config/logs/shelfarr.log
+	MaxSizeMB  int    // The file is rotated when it would grow past this; 0 for 10 MB
+	MaxFiles   int    // Rotated files kept besides the current one; 0 keeps all that aren't too old
+	MaxAgeDays int    // Rotated files older than this are deleted; 0 keeps them regardless of age
+}
+
+// output writes log lines to the console and, when set, to the log file
+type output struct {
+	mutex   sync.Mutex
+	console io.Writer
+	file    *rotatingFile
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.file != nil {
+		// A full disk shouldn't stop logging to the console
+		o.file.Write(p)
+	}
+	return o.console.Write(p)
+}
+
+// SetFile starts copying log lines to a file, rotating it as configured, or stops when
+// opts is nil. The file's folder is created if needed.
+func SetFile(opts *FileOptions) error {
+	var file *rotatingFile
+	if opts != nil {
+		if opts.Path == "" {
+			return fmt.Errorf("log file path is required")
+		}
+		file = &rotatingFile{
+			path:     opts.Path,
+			maxSize:  int64(opts.MaxSizeMB) * 1024 * 1024,
+			maxFiles: opts.MaxFiles,
+			maxAge:   time.Duration(opts.MaxAgeDays) * 24 * time.Hour,
+		}
+		if file.maxSize <= 0 {
+			file.maxSize = 10 * 1024 * 1024
+		}
+		if err := file.open(); err != nil {
+			return err
+		}
+		file.prune()
+	}
+
+	out.mutex.Lock()
+	previous := out.file
+	out.file = file
+	out.mutex.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+	return nil
+}
+
+// rotatingFile is a log file that's renamed with a timestamp once it reaches its
+// maximum size, with a new one started in its place
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	maxAge   time.Duration
+	f        *os.File
+	size     int64
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log folder: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.f == nil {
+		return 0, fmt.Errorf("log file is closed")
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file, such as shelfarr.log to
+// shelfarr.20240101-150405.log, starts a new one and deletes old ones
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+
+	ext := filepath.Ext(r.path)
+	stamp := time.Now().Format("20060102-150405")
+	rotated := strings.TrimSuffix(r.path, ext) + "." + stamp + ext
+	for i := 1; fileExists(rotated); i++ {
+		// Rotated more than once within a second
+		rotated = fmt.Sprintf("%s.%s-%d%s", strings.TrimSuffix(r.path, ext), stamp, i, ext)
+	}
+	if err := os.Rename(r.path, rotated); err != nil {
+		// Keep writing to the current file rather than losing lines
+		r.open()
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// prune deletes rotated files beyond the number kept or older than the maximum age
+func (r *rotatingFile) prune() {
+	ext := filepath.Ext(r.path)
+	matches, err := filepath.Glob(strings.TrimSuffix(r.path, ext) + ".*" + ext)
+	if err != nil {
+		return
+	}
+
+	// The timestamps in the names sort oldest first
+	sort.Strings(matches)
+	for i, path := range matches {
+		tooMany := r.maxFiles > 0 && i < len(matches)-r.maxFiles
+		tooOld := false
+		if r.maxAge > 0 {
+			if info, err := os.Stat(path); err == nil {
+				tooOld = time.Since(info.ModTime()) > r.maxAge
+			}
+		}
+		if tooMany || tooOld {
+			os.Remove(path)
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (r *rotatingFile) close() {
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+}
@@ -0,0 +1,247 @@
+// Package logging sets up Shelfarr's structured logger. Records go to a text handler,
+// optionally copied to a rotating log file, and into an in-memory buffer of recent
+// entries, which the UI's log page reads. The level can be changed while running,
+// for everything or for single components.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// level is the minimum level logged; Info until Setup or SetLevel says otherwise
+var level slog.LevelVar
+
+// Components whose level can be set apart from the others. Loggers for them come from
+// Component.
+var Components = []string{"scheduler", "jobs", "indexers", "downloads", "library", "metadata", "notifications", "realtime"}
+
+// componentLevels are the minimum levels of components that don't use level
+var componentLevels = struct {
+	mutex  sync.RWMutex
+	levels map[string]slog.Level
+}{levels: map[string]slog.Level{}}
+
+// out is where the text handler writes; root is the handler every logger uses
+var (
+	out  = &output{console: os.Stderr}
+	root = &handler{out: slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug})}
+)
+
+// Levels, by name, that can be set
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Setup makes a logger writing text to w, and keeping recent entries, the default
+// for slog and for the log package. Lines from the log package that start with a
+// level such as "[WARN] " are logged at that level.
+func Setup(w io.Writer) {
+	out.mutex.Lock()
+	out.console = w
+	out.mutex.Unlock()
+
+	logger := slog.New(root)
+	slog.SetDefault(logger)
+
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{logger: logger})
+}
+
+// SetLevel changes the minimum level logged: debug, info, warn or error
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level returns the name of the minimum level logged
+func Level() string {
+	return LevelName(level.Level())
+}
+
+// Component returns a logger for one of the Components. Its records carry a
+// "component" attribute, and are logged at the component's own level if it has one.
+// It can be made before Setup is called.
+func Component(name string) *slog.Logger {
+	return slog.New(root).With("component", name)
+}
+
+// SetComponentLevels replaces the levels of components, by component name. Components
+// left out use the level set with SetLevel.
+func SetComponentLevels(names map[string]string) error {
+	levels := make(map[string]slog.Level, len(names))
+	for component, name := range names {
+		if !isComponent(component) {
+			return fmt.Errorf("unknown log component %q", component)
+		}
+		l, err := ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		levels[component] = l
+	}
+
+	componentLevels.mutex.Lock()
+	componentLevels.levels = levels
+	componentLevels.mutex.Unlock()
+	return nil
+}
+
+// ComponentLevels returns the names of the levels set for components
+func ComponentLevels() map[string]string {
+	componentLevels.mutex.RLock()
+	defer componentLevels.mutex.RUnlock()
+
+	names := make(map[string]string, len(componentLevels.levels))
+	for component, l := range componentLevels.levels {
+		names[component] = LevelName(l)
+	}
+	return names
+}
+
+func isComponent(name string) bool {
+	for _, component := range Components {
+		if component == name {
+			return true
+		}
+	}
+	return false
+}
+
+// minLevel returns the minimum level logged for a component, or for everything when
+// component is ""
+func minLevel(component string) slog.Level {
+	if component != "" {
+		componentLevels.mutex.RLock()
+		l, ok := componentLevels.levels[component]
+		componentLevels.mutex.RUnlock()
+		if ok {
+			return l
+		}
+	}
+	return level.Level()
+}
+
+// ParseLevel returns the level with the given name
+func ParseLevel(name string) (slog.Level, error) {
+	l, ok := levels[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q; use debug, info, warn or error", name)
+	}
+	return l, nil
+}
+
+// LevelName returns the lowercase name of a level
+func LevelName(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "debug"
+	case l < slog.LevelWarn:
+		return "info"
+	case l < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// handler passes records to another handler after adding them to the recent entries
+type handler struct {
+	out       slog.Handler
+	attrs     []slog.Attr
+	groups    string // Prefix for attribute keys, such as "request."
+	component string // From a "component" attribute, for picking the level
+}
+
+func (h *handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= minLevel(h.component)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make(map[string]string, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		addAttr(attrs, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(attrs, h.groups, a)
+		return true
+	})
+	recent.add(r.Time, LevelName(r.Level), r.Message, attrs)
+
+	return h.out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+	prefixed := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	prefixed = append(prefixed, h.attrs...)
+	for _, a := range attrs {
+		if h.groups == "" && a.Key == "component" {
+			component = a.Value.String()
+		}
+		a.Key = h.groups + a.Key
+		prefixed = append(prefixed, a)
+	}
+	return &handler{out: h.out.WithAttrs(attrs), attrs: prefixed, groups: h.groups, component: component}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{out: h.out.WithGroup(name), attrs: h.attrs, groups: h.groups + name + ".", component: h.component}
+}
+
+// addAttr adds an attribute to attrs as text, flattening groups into dotted keys
+func addAttr(attrs map[string]string, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			addAttr(attrs, prefix+a.Key+".", ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	attrs[prefix+a.Key] = a.Value.String()
+}
+
+// stdWriter logs lines written by the log package
+type stdWriter struct {
+	logger *slog.Logger
+}
+
+// Level prefixes used by lines from the log package
+var stdPrefixes = []struct {
+	prefix string
+	level  slog.Level
+}{
+	{"[DEBUG] ", slog.LevelDebug},
+	{"[INFO] ", slog.LevelInfo},
+	{"[WARN] ", slog.LevelWarn},
+	{"[ERROR] ", slog.LevelError},
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	l := slog.LevelInfo
+	for _, sp := range stdPrefixes {
+		if strings.HasPrefix(msg, sp.prefix) {
+			msg, l = strings.TrimPrefix(msg, sp.prefix), sp.level
+			break
+		}
+	}
+	w.logger.Log(context.Background(), l, msg)
+	return len(p), nil
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -59,12 +60,12 @@ func (a *AudiobookProcessor) GetVersion(ctx context.Context) (string, error) {
 
 // AudioFileInfo contains information about an audio file
 type AudioFileInfo struct {
-	Path     string
-	Duration float64 // seconds
-	Bitrate  int     // kbps
-	Format   string
-	Codec    string
-	Channels int
+	Path       string
+	Duration   float64 // seconds
+	Bitrate    int     // kbps
+	Format     string
+	Codec      string
+	Channels   int
 	SampleRate int
 }
 
@@ -139,18 +140,25 @@ func (a *AudiobookProcessor) GetAudioInfo(ctx context.Context, path string) (*Au
 
 // M4BConversionOptions holds options for M4B conversion
 type M4BConversionOptions struct {
-	Title       string
-	Author      string
-	Album       string
-	Genre       string
-	Year        string
-	CoverPath   string
-	Chapters    []Chapter
-	Bitrate     int  // kbps, 0 for auto
-	SampleRate  int  // Hz, 0 for auto
-	Normalize   bool
+	Title      string
+	Author     string
+	Album      string
+	Genre      string
+	Year       string
+	CoverPath  string
+	Chapters   []Chapter
+	Bitrate    int // kbps, 0 for auto
+	SampleRate int // Hz, 0 for auto
+	Normalize  bool
+
+	// Progress, if set, is called as ffmpeg encodes with the percent complete
+	// (0-100) and the estimated time remaining
+	Progress ProgressFunc
 }
 
+// ProgressFunc receives encoding progress updates
+type ProgressFunc func(percent float64, eta time.Duration)
+
 // ConvertToM4B converts audio files to a single M4B audiobook
 func (a *AudiobookProcessor) ConvertToM4B(ctx context.Context, inputPaths []string, outputPath string, opts *M4BConversionOptions) (*ConversionResult, error) {
 	if !a.IsAvailable() {
@@ -166,7 +174,7 @@ func (a *AudiobookProcessor) ConvertToM4B(ctx context.Context, inputPaths []stri
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		result.Error = fmt.Sprintf("failed to create output directory: %v", err)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	// Sort input files
@@ -176,7 +184,7 @@ func (a *AudiobookProcessor) ConvertToM4B(ctx context.Context, inputPaths []stri
 	concatFile, err := os.CreateTemp("", "audiobook-concat-*.txt")
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to create concat file: %v", err)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 	defer os.Remove(concatFile.Name())
 
@@ -206,7 +214,7 @@ func (a *AudiobookProcessor) ConvertToM4B(ctx context.Context, inputPaths []stri
 
 	// Audio encoding settings
 	args = append(args, "-c:a", "aac")
-	
+
 	if opts != nil && opts.Bitrate > 0 {
 		args = append(args, "-b:a", fmt.Sprintf("%dk", opts.Bitrate))
 	} else {
@@ -244,18 +252,35 @@ func (a *AudiobookProcessor) ConvertToM4B(ctx context.Context, inputPaths []stri
 		}
 	}
 
+	// Machine-readable progress on stdout
+	var totalSeconds float64
+	if opts != nil && opts.Progress != nil {
+		args = append(args, "-progress", "pipe:1", "-nostats")
+		for _, path := range inputPaths {
+			if info, err := a.GetAudioInfo(ctx, path); err == nil {
+				totalSeconds += info.Duration
+			}
+		}
+	}
+
 	// Output
 	args = append(args, "-y", outputPath)
 
 	// Run FFmpeg
 	cmd := exec.CommandContext(ctx, a.ffmpegPath, args...)
-	
+
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		result.Error = fmt.Sprintf("conversion failed: %v - %s", err, stderr.String())
-		return result, fmt.Errorf(result.Error)
+	var runErr error
+	if opts != nil && opts.Progress != nil {
+		runErr = runWithProgress(cmd, totalSeconds, opts.Progress)
+	} else {
+		runErr = cmd.Run()
+	}
+	if runErr != nil {
+		result.Error = fmt.Sprintf("conversion failed: %v - %s", runErr, stderr.String())
+		return result, errors.New(result.Error)
 	}
 
 	// Add chapters if provided
@@ -278,6 +303,52 @@ func (a *AudiobookProcessor) ConvertToM4B(ctx context.Context, inputPaths []stri
 	return result, nil
 }
 
+// runWithProgress runs an ffmpeg command started with "-progress pipe:1" and reports
+// progress from its out_time against the expected total duration
+func runWithProgress(cmd *exec.Cmd, totalSeconds float64, progress ProgressFunc) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "out_time_us", "out_time_ms": // Both are microseconds, despite the name
+			if totalSeconds <= 0 {
+				continue
+			}
+			us, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || us <= 0 {
+				continue
+			}
+			percent := float64(us) / 1e6 / totalSeconds * 100
+			if percent > 99.9 {
+				percent = 99.9
+			}
+			// ETA from the average speed so far
+			elapsed := time.Since(start)
+			eta := time.Duration(float64(elapsed) * (100 - percent) / percent)
+			progress(percent, eta)
+		case "progress":
+			if value == "end" {
+				progress(100, 0)
+			}
+		}
+	}
+
+	return cmd.Wait()
+}
+
 // generateChaptersFromFiles creates chapters based on input file names and durations
 func (a *AudiobookProcessor) generateChaptersFromFiles(ctx context.Context, paths []string) ([]Chapter, error) {
 	var chapters []Chapter
@@ -298,7 +369,7 @@ func (a *AudiobookProcessor) generateChaptersFromFiles(ctx context.Context, path
 		name = strings.TrimPrefix(name, "- ")
 		name = strings.TrimPrefix(name, "_")
 		name = strings.TrimSpace(name)
-		
+
 		if name == "" {
 			name = fmt.Sprintf("Chapter %d", i+1)
 		}
@@ -325,7 +396,7 @@ func (a *AudiobookProcessor) addChapters(ctx context.Context, m4bPath string, ch
 	defer os.Remove(chaptersFile.Name())
 
 	fmt.Fprintln(chaptersFile, ";FFMETADATA1")
-	
+
 	for _, ch := range chapters {
 		fmt.Fprintln(chaptersFile, "[CHAPTER]")
 		fmt.Fprintf(chaptersFile, "TIMEBASE=1/1000\n")
@@ -463,13 +534,13 @@ func ParseCueSheet(path string) ([]Chapter, error) {
 
 	var chapters []Chapter
 	scanner := bufio.NewScanner(file)
-	
+
 	var currentTitle string
 	var currentIndex float64
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
-		
+
 		if strings.HasPrefix(line, "TITLE") {
 			// Extract title (remove quotes)
 			parts := strings.SplitN(line, " ", 2)
@@ -485,13 +556,13 @@ func ParseCueSheet(path string) ([]Chapter, error) {
 					min, _ := strconv.Atoi(timeParts[0])
 					sec, _ := strconv.Atoi(timeParts[1])
 					frames, _ := strconv.Atoi(timeParts[2])
-					
+
 					startTime := float64(min*60) + float64(sec) + float64(frames)/75.0
-					
+
 					if len(chapters) > 0 {
 						chapters[len(chapters)-1].EndTime = startTime
 					}
-					
+
 					chapters = append(chapters, Chapter{
 						Title:     currentTitle,
 						StartTime: startTime,
@@ -504,4 +575,3 @@ func ParseCueSheet(path string) ([]Chapter, error) {
 
 	return chapters, scanner.Err()
 }
-
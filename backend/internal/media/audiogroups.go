@@ -0,0 +1,145 @@
+package media
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// audiobookProbeTimeout bounds reading the tags of a downloads folder's audiobooks
+const audiobookProbeTimeout = 30 * time.Second
+
+// discSuffixPattern matches the disc or part a folder of a book's files holds, as
+// albums name it ("Title (Disc 2)", "Title - CD 02")
+var discSuffixPattern = regexp.MustCompile(`(?i)[\s\-_,:(\[]*\b(disc|disk|cd|part)\s*\d+\s*(of\s*\d+)?[)\]]?\s*$`)
+
+// audiobookGroup is one book's audio files in a downloaded folder
+type audiobookGroup struct {
+	path string
+	tags *AudioTags // Nil when the files have no readable tags
+}
+
+// groupAudiobookFolder splits a downloaded folder of audio files into the books it
+// holds, by their tags. Each folder of audio files is probed once, by its first file,
+// and folders whose tags name the same album and author are one book, such as a book
+// split into CD folders. Each book is then the folder holding all of its files. When
+// the books can't be told apart that way the whole folder is one book.
+func (i *Importer) groupAudiobookFolder(ctx context.Context, folder string) []audiobookGroup {
+	whole := []audiobookGroup{{path: folder}}
+	processor := NewAudiobookProcessor()
+	if processor.ffprobePath == "" {
+		return whole
+	}
+
+	// The first audio file of each folder that has any
+	var dirs []string
+	firstFiles := make(map[string]string)
+	filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !i.scanner.isAudiobook(filepath.Ext(path)) {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		if _, seen := firstFiles[dir]; !seen {
+			firstFiles[dir] = path
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+
+	type book struct {
+		dirs []string
+		tags *AudioTags
+	}
+	var books []*book
+	byAlbum := make(map[string]*book)
+	for _, dir := range dirs {
+		tags, err := processor.ReadTags(ctx, firstFiles[dir])
+		if err != nil || tags.Album == "" {
+			// Files without an album can't be told apart from the other books
+			return whole
+		}
+		key := strings.ToLower(discSuffixPattern.ReplaceAllString(tags.Album, "") + "\x00" + tags.Author)
+		b := byAlbum[key]
+		if b == nil {
+			b = &book{tags: tags}
+			byAlbum[key] = b
+			books = append(books, b)
+		}
+		b.dirs = append(b.dirs, dir)
+	}
+	if len(books) == 0 {
+		return whole
+	}
+	if len(books) == 1 {
+		return []audiobookGroup{{path: folder, tags: books[0].tags}}
+	}
+
+	groups := make([]audiobookGroup, 0, len(books))
+	for _, b := range books {
+		path := commonDir(b.dirs)
+		// A book's folder must not hold another book's files
+		for _, other := range books {
+			if other == b {
+				continue
+			}
+			for _, dir := range other.dirs {
+				if isWithin(dir, path) {
+					return whole
+				}
+			}
+		}
+		groups = append(groups, audiobookGroup{path: path, tags: b.tags})
+	}
+	return groups
+}
+
+// ReadAudiobookTags reads the tags of a downloaded audiobook, a single file or a book's
+// folder, from its first audio file. Returns nil without ffprobe or when the file
+// can't be read.
+func ReadAudiobookTags(ctx context.Context, path string) *AudioTags {
+	processor := NewAudiobookProcessor()
+	if processor.ffprobePath == "" {
+		return nil
+	}
+	files := AudioFilesIn(path)
+	if len(files) == 0 {
+		return nil
+	}
+	tags, err := processor.ReadTags(ctx, files[0])
+	if err != nil {
+		return nil
+	}
+	return tags
+}
+
+// commonDir returns the deepest folder that holds all of dirs
+func commonDir(dirs []string) string {
+	common := dirs[0]
+	for _, dir := range dirs[1:] {
+		for !isWithin(dir, common) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				return common
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,220 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// taggableAudioFormats are the audio formats tags are written to
+var taggableAudioFormats = map[string]bool{".m4b": true, ".m4a": true, ".mp3": true, ".flac": true}
+
+// AudioTags are the tags written to an audiobook's files. Empty fields leave the
+// file's own tags alone.
+type AudioTags struct {
+	Title       string // Left alone on books split over several files, whose titles name the parts
+	Album       string
+	Author      string // Written as artist and album artist
+	Narrator    string // Written as composer, where most players show it
+	Series      string
+	SeriesIndex float64
+	Year        int
+	Genre       string
+	Description string
+	Track       int // Position in a book split over several files
+	TrackTotal  int
+	ASIN        string
+}
+
+// AudioFilesIn returns the taggable audio files at a path, a single file or a book's
+// folder, in track order
+func AudioFilesIn(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		if taggableAudioFormats[strings.ToLower(filepath.Ext(path))] {
+			return []string{path}
+		}
+		return nil
+	}
+
+	var files []string
+	filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() && taggableAudioFormats[strings.ToLower(filepath.Ext(p))] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+// WriteTags rewrites an audio file's tags, and its cover art when coverPath is set.
+// The audio is copied, not re-encoded, and chapters are kept. The file is rewritten
+// to a new file that replaces the original, so a hardlinked download is never changed.
+func (a *AudiobookProcessor) WriteTags(ctx context.Context, path string, tags AudioTags, coverPath string) error {
+	if !a.IsAvailable() {
+		return fmt.Errorf("ffmpeg not found")
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	args := []string{"-i", path}
+	if coverPath != "" {
+		args = append(args, "-i", coverPath, "-map", "0:a", "-map", "1:v", "-disposition:v:0", "attached_pic")
+		if ext == ".mp3" {
+			args = append(args, "-metadata:s:v", "title=Album cover", "-metadata:s:v", "comment=Cover (front)")
+		}
+	} else {
+		args = append(args, "-map", "0:a", "-map", "0:v?") // Keep any cover art
+	}
+	args = append(args, "-map_metadata", "0", "-map_chapters", "0", "-c", "copy")
+
+	metadata := func(key, value string) {
+		if value != "" {
+			args = append(args, "-metadata", key+"="+value)
+		}
+	}
+	metadata("title", tags.Title)
+	metadata("album", tags.Album)
+	metadata("artist", tags.Author)
+	metadata("album_artist", tags.Author)
+	metadata("composer", tags.Narrator)
+	metadata("genre", tags.Genre)
+	metadata("series", tags.Series)
+	if tags.SeriesIndex > 0 {
+		metadata("series-part", strconv.FormatFloat(tags.SeriesIndex, 'f', -1, 64))
+	}
+	if tags.Year > 0 {
+		metadata("date", strconv.Itoa(tags.Year))
+	}
+	if tags.Description != "" {
+		metadata("description", tags.Description)
+		metadata("comment", tags.Description)
+	}
+	if tags.Track > 0 {
+		track := strconv.Itoa(tags.Track)
+		if tags.TrackTotal > 0 {
+			track += "/" + strconv.Itoa(tags.TrackTotal)
+		}
+		metadata("track", track)
+	}
+	metadata("asin", tags.ASIN)
+
+	switch ext {
+	case ".m4b", ".m4a":
+		// Keep tags MP4 has no atom for, such as series, as custom tags
+		args = append(args, "-movflags", "use_metadata_tags")
+	case ".mp3":
+		args = append(args, "-id3v2_version", "3")
+	}
+
+	tmpPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".tagging" + filepath.Ext(path)
+	args = append(args, "-y", tmpPath)
+
+	cmd := exec.CommandContext(ctx, a.ffmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write tags: %v - %s", err, lastLines(stderr.String(), 3))
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmpPath, info.Mode().Perm())
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ReadTags reads an audio file's tags with ffprobe. Besides the common tags, the names
+// Audible, Audiobookshelf and Mp3tag use for the series and ASIN are understood.
+func (a *AudiobookProcessor) ReadTags(ctx context.Context, path string) (*AudioTags, error) {
+	if a.ffprobePath == "" {
+		return nil, fmt.Errorf("ffprobe not found")
+	}
+
+	cmd := exec.CommandContext(ctx, a.ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_entries", "format_tags:stream=codec_type:stream_tags",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe file: %w", err)
+	}
+
+	var result struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string            `json:"codec_type"`
+			Tags      map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse probe output: %w", err)
+	}
+
+	// Ogg files keep their tags on the audio stream; tag names vary in case by format
+	raw := make(map[string]string)
+	for _, stream := range result.Streams {
+		if stream.CodecType != "audio" {
+			continue
+		}
+		for key, value := range stream.Tags {
+			raw[strings.ToLower(key)] = strings.TrimSpace(value)
+		}
+	}
+	for key, value := range result.Format.Tags {
+		raw[strings.ToLower(key)] = strings.TrimSpace(value)
+	}
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := raw[key]; value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	tags := &AudioTags{
+		Title:       first("title"),
+		Album:       first("album"),
+		Author:      first("artist", "album_artist", "author"),
+		Narrator:    first("composer", "narrator", "narratedby"),
+		Series:      first("series", "movementname", "mvnm"),
+		Genre:       first("genre"),
+		Description: first("description", "comment", "synopsis"),
+		ASIN:        strings.ToUpper(first("asin", "audible_asin", "cdek")),
+	}
+	tags.SeriesIndex, _ = strconv.ParseFloat(first("series-part", "series_part", "seriespart", "movement", "mvin"), 64)
+	if year := first("date", "year"); len(year) >= 4 {
+		tags.Year, _ = strconv.Atoi(year[:4])
+	}
+	track, total, _ := strings.Cut(first("track", "tracknumber"), "/")
+	tags.Track, _ = strconv.Atoi(strings.TrimSpace(track))
+	if total == "" {
+		total = first("tracktotal", "totaltracks")
+	}
+	tags.TrackTotal, _ = strconv.Atoi(strings.TrimSpace(total))
+	return tags, nil
+}
+
+// lastLines returns the last n lines of ffmpeg's output, where its errors are
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
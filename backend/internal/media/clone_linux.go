@@ -0,0 +1,38 @@
+//go:build linux
+
+package media
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl, which makes a file share another's data blocks until
+// either is written to
+const ficlone = 0x40049409
+
+// cloneFile makes dst a copy-on-write clone of src
+func cloneFile(src, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	dest, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dest.Fd(), ficlone, source.Fd())
+	dest.Close()
+	if errno != 0 {
+		os.Remove(dst)
+		return &os.LinkError{Op: "clone", Old: src, New: dst, Err: errno}
+	}
+	return nil
+}
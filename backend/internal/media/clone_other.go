@@ -0,0 +1,13 @@
+//go:build !linux
+
+package media
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile makes dst a copy-on-write clone of src, which is only supported on Linux
+func cloneFile(src, dst string) error {
+	return &os.LinkError{Op: "clone", Old: src, New: dst, Err: errors.ErrUnsupported}
+}
@@ -0,0 +1,198 @@
+package media
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pageToolTimeout bounds the external tools that read RAR archives and PDFs
+const pageToolTimeout = 30 * time.Second
+
+// comicPageExts are the image types a comic archive's pages are stored as
+var comicPageExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true}
+
+// IsPagedFormat reports whether a format is a fixed layout read page by page, a PDF or
+// a comic archive, rather than reflowable text
+func IsPagedFormat(format string) bool {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "pdf", "cbz", "cbr":
+		return true
+	}
+	return false
+}
+
+// PageCount returns the number of pages of a PDF or comic archive
+func PageCount(filePath string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pageToolTimeout)
+	defer cancel()
+
+	if strings.EqualFold(filepath.Ext(filePath), ".pdf") {
+		return pdfPageCount(ctx, filePath)
+	}
+	pages, err := comicPages(ctx, filePath)
+	if err != nil {
+		return 0, err
+	}
+	return len(pages), nil
+}
+
+// ExtractCover returns the cover of a PDF or comic archive, its first page, as an image
+func ExtractCover(ctx context.Context, filePath string) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".pdf") {
+		return pdfCover(ctx, filePath)
+	}
+	pages, err := comicPages(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("comic has no pages")
+	}
+
+	if reader, err := zip.OpenReader(filePath); err == nil {
+		defer reader.Close()
+		return readZipEntry(&reader.Reader, pages[0])
+	}
+	return rarExtract(ctx, filePath, pages[0])
+}
+
+// comicPages lists the page images of a CBZ or CBR in reading order. Many CBRs are
+// really zip files, so every comic is tried as a zip first; RAR archives need unrar or
+// bsdtar installed.
+func comicPages(ctx context.Context, filePath string) ([]string, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".cbz" && ext != ".cbr" {
+		return nil, fmt.Errorf("%s is not a comic archive", filepath.Base(filePath))
+	}
+
+	reader, err := zip.OpenReader(filePath)
+	if err == nil {
+		defer reader.Close()
+		names := make([]string, 0, len(reader.File))
+		for _, f := range reader.File {
+			names = append(names, f.Name)
+		}
+		return comicPageNames(names), nil
+	}
+	if ext == ".cbz" {
+		return nil, fmt.Errorf("failed to open comic: %w", err)
+	}
+
+	names, err := rarList(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return comicPageNames(names), nil
+}
+
+// comicPageNames returns the images among an archive's entries in reading order,
+// skipping macOS metadata and hidden files
+func comicPageNames(names []string) []string {
+	var pages []string
+	for _, name := range names {
+		base := path.Base(name)
+		if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") || strings.HasSuffix(name, "/") {
+			continue
+		}
+		if comicPageExts[strings.ToLower(path.Ext(name))] {
+			pages = append(pages, name)
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return naturalLess(strings.ToLower(pages[i]), strings.ToLower(pages[j]))
+	})
+	return pages
+}
+
+// naturalLess orders names with their numbers compared by value, so page 10 comes
+// after page 9 even without leading zeros
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return s[:end]
+}
+
+// rarTool returns the installed tool RAR archives are read with
+func rarTool() (string, error) {
+	for _, tool := range []string{"unrar", "bsdtar"} {
+		if toolPath, err := exec.LookPath(tool); err == nil {
+			return toolPath, nil
+		}
+	}
+	return "", fmt.Errorf("reading CBR files needs unrar or bsdtar installed")
+}
+
+// rarList lists the files in a RAR archive
+func rarList(ctx context.Context, filePath string) ([]string, error) {
+	tool, err := rarTool()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-tf", filePath}
+	if filepath.Base(tool) == "unrar" {
+		args = []string{"lb", filePath}
+	}
+	output, err := exec.CommandContext(ctx, tool, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CBR: %w", err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			names = append(names, filepath.ToSlash(line))
+		}
+	}
+	return names, nil
+}
+
+// rarExtract reads one file from a RAR archive
+func rarExtract(ctx context.Context, filePath, name string) ([]byte, error) {
+	tool, err := rarTool()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-xOf", filePath, name}
+	if filepath.Base(tool) == "unrar" {
+		args = []string{"p", "-inul", filePath, name}
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to extract %s from CBR: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package media
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -29,8 +30,8 @@ type EbookConverter struct {
 func NewEbookConverter() *EbookConverter {
 	// Try to find ebook-convert in common locations
 	paths := []string{
-		"ebook-convert",                           // In PATH
-		"/usr/bin/ebook-convert",                  // Linux
+		"ebook-convert",          // In PATH
+		"/usr/bin/ebook-convert", // Linux
 		"/Applications/calibre.app/Contents/MacOS/ebook-convert", // macOS
 	}
 
@@ -71,23 +72,23 @@ func (e *EbookConverter) GetVersion(ctx context.Context) (string, error) {
 type ConversionOptions struct {
 	// Output format (epub, mobi, azw3, pdf)
 	OutputFormat string
-	
+
 	// Metadata options
-	Title  string
-	Author string
-	Series string
+	Title       string
+	Author      string
+	Series      string
 	SeriesIndex float64
-	
+
 	// EPUB-specific
 	EPUBFlatten bool
-	
+
 	// MOBI/AZW3-specific
 	MobiFileType string // "old" or "both"
-	
+
 	// PDF-specific
-	PaperSize string
+	PaperSize  string
 	PageMargin float64
-	
+
 	// General
 	PreserveCovers bool
 }
@@ -107,13 +108,13 @@ func (e *EbookConverter) Convert(ctx context.Context, inputPath string, outputPa
 	// Validate input exists
 	if _, err := os.Stat(inputPath); err != nil {
 		result.Error = fmt.Sprintf("input file not found: %s", inputPath)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		result.Error = fmt.Sprintf("failed to create output directory: %v", err)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	// Build command arguments
@@ -136,7 +137,7 @@ func (e *EbookConverter) Convert(ctx context.Context, inputPath string, outputPa
 
 		// Format-specific options
 		outputExt := strings.ToLower(filepath.Ext(outputPath))
-		
+
 		if outputExt == ".epub" {
 			if opts.EPUBFlatten {
 				args = append(args, "--epub-flatten")
@@ -171,19 +172,19 @@ func (e *EbookConverter) Convert(ctx context.Context, inputPath string, outputPa
 
 	// Run conversion
 	cmd := exec.CommandContext(ctx, e.calibrePath, args...)
-	
+
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
 		result.Error = fmt.Sprintf("conversion failed: %v - %s", err, stderr.String())
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	// Verify output was created
 	if _, err := os.Stat(outputPath); err != nil {
 		result.Error = "conversion completed but output file not found"
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
 	}
 
 	result.Success = true
@@ -255,9 +256,9 @@ func (e *EbookConverter) ExtractCover(ctx context.Context, inputPath, outputPath
 
 	// Use ebook-meta to extract cover
 	metaPath := strings.Replace(e.calibrePath, "ebook-convert", "ebook-meta", 1)
-	
+
 	cmd := exec.CommandContext(ctx, metaPath, inputPath, "--get-cover", outputPath)
-	
+
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to extract cover: %w", err)
 	}
@@ -272,7 +273,7 @@ func (e *EbookConverter) GetMetadata(ctx context.Context, inputPath string) (map
 	}
 
 	metaPath := strings.Replace(e.calibrePath, "ebook-convert", "ebook-meta", 1)
-	
+
 	cmd := exec.CommandContext(ctx, metaPath, inputPath)
 	output, err := cmd.Output()
 	if err != nil {
@@ -282,7 +283,7 @@ func (e *EbookConverter) GetMetadata(ctx context.Context, inputPath string) (map
 	// Parse output
 	metadata := make(map[string]string)
 	lines := strings.Split(string(output), "\n")
-	
+
 	for _, line := range lines {
 		parts := strings.SplitN(line, ":", 2)
 		if len(parts) == 2 {
@@ -296,4 +297,3 @@ func (e *EbookConverter) GetMetadata(ctx context.Context, inputPath string) (map
 
 	return metadata, nil
 }
-
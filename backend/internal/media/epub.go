@@ -0,0 +1,573 @@
+package media
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// epubContainerPath is where an EPUB names its package document
+const epubContainerPath = "META-INF/container.xml"
+
+// epubPackagePath returns the path of the OPF package document inside an EPUB
+func epubPackagePath(reader *zip.Reader) (string, error) {
+	data, err := readZipEntry(reader, epubContainerPath)
+	if err != nil {
+		return "", fmt.Errorf("not an EPUB: %w", err)
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return "", fmt.Errorf("invalid container.xml: %w", err)
+	}
+	for _, rootfile := range container.Rootfiles {
+		if rootfile.MediaType == "" || rootfile.MediaType == "application/oebps-package+xml" {
+			return rootfile.FullPath, nil
+		}
+	}
+	return "", fmt.Errorf("EPUB has no package document")
+}
+
+// readZipEntry reads a whole entry of a zip file
+func readZipEntry(reader *zip.Reader, name string) ([]byte, error) {
+	for _, f := range reader.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+var (
+	opfMetadataPattern = regexp.MustCompile(`(?s)(<(?:\w+:)?metadata\b[^>]*>)(.*?)(</(?:\w+:)?metadata>)`)
+	opfManifestPattern = regexp.MustCompile(`(?s)</(?:\w+:)?manifest>`)
+	opfPackagePattern  = regexp.MustCompile(`<(?:\w+:)?package\b[^>]*>`)
+	opfItemPattern     = regexp.MustCompile(`<(?:\w+:)?item\b[^>]*>`)
+	xmlAttrPattern     = regexp.MustCompile(`([\w:-]+)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// opfElements matches the metadata elements Shelfarr replaces, with their content
+func opfElements(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)\s*<` + name + `\b[^>]*?(?:/>|>.*?</` + name + `>)`)
+}
+
+var (
+	opfTitles      = opfElements(`dc:title`)
+	opfCreators    = opfElements(`dc:creator`)
+	opfIdentifiers = opfElements(`dc:identifier`)
+	opfMetas       = opfElements(`meta`)
+)
+
+// xmlAttrs returns the attributes of an element's start tag
+func xmlAttrs(tag string) map[string]string {
+	if end := strings.IndexByte(tag, '>'); end >= 0 {
+		tag = tag[:end]
+	}
+	attrs := make(map[string]string)
+	for _, match := range xmlAttrPattern.FindAllStringSubmatch(tag, -1) {
+		attrs[match[1]] = match[2][1 : len(match[2])-1]
+	}
+	return attrs
+}
+
+// EmbedEPUBMetadata updates the package document inside an EPUB so its title, authors,
+// series and ISBN match meta, and replaces its cover image when one is given. Other
+// metadata, like the publisher and the book's own identifier, is left alone. The book
+// is rewritten to a new file that replaces the original, so a hardlinked download
+// being seeded is never changed.
+func EmbedEPUBMetadata(epubPath string, meta BookMetadata, cover []byte) error {
+	reader, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer reader.Close()
+
+	opfPath, err := epubPackagePath(&reader.Reader)
+	if err != nil {
+		return err
+	}
+	opf, err := readZipEntry(&reader.Reader, opfPath)
+	if err != nil {
+		return err
+	}
+
+	updated, err := updateOPFMetadata(string(opf), meta)
+	if err != nil {
+		return err
+	}
+	replaced := map[string][]byte{opfPath: nil}
+	if len(cover) > 0 {
+		var coverPath string
+		updated, coverPath = setOPFCover(updated, opfPath, cover)
+		if coverPath != "" {
+			replaced[coverPath] = cover
+		}
+	}
+	replaced[opfPath] = []byte(updated)
+
+	info, err := os.Stat(epubPath)
+	if err != nil {
+		return err
+	}
+	tmpPath := epubPath + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if err := rewriteZip(&reader.Reader, out, replaced); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, epubPath)
+}
+
+// updateOPFMetadata replaces the title, creators, ISBN and series in a package document
+func updateOPFMetadata(opf string, meta BookMetadata) (string, error) {
+	match := opfMetadataPattern.FindStringSubmatchIndex(opf)
+	if match == nil {
+		return "", fmt.Errorf("package document has no metadata")
+	}
+	openTag, inner := opf[match[2]:match[3]], opf[match[4]:match[5]]
+
+	packageTag := opfPackagePattern.FindString(opf)
+	epub3 := strings.HasPrefix(xmlAttrs(packageTag)["version"], "3")
+	uniqueID := xmlAttrs(packageTag)["unique-identifier"]
+
+	// Remove what's being replaced, along with EPUB 3 refinements of it
+	removedIDs := make(map[string]bool)
+	remove := func(pattern *regexp.Regexp, keep func(element string) bool) {
+		inner = pattern.ReplaceAllStringFunc(inner, func(element string) string {
+			if keep != nil && keep(element) {
+				return element
+			}
+			if id := xmlAttrs(strings.TrimSpace(element))["id"]; id != "" {
+				removedIDs["#"+id] = true
+			}
+			return ""
+		})
+	}
+	// Creators other than authors (translators, illustrators) are kept. EPUB 3 gives
+	// their roles in refinements.
+	refinedRoles := make(map[string]string)
+	for _, element := range opfMetas.FindAllString(inner, -1) {
+		element = strings.TrimSpace(element)
+		if attrs := xmlAttrs(element); attrs["property"] == "role" {
+			refinedRoles[strings.TrimPrefix(attrs["refines"], "#")] = strings.TrimSpace(elementText(element))
+		}
+	}
+	remove(opfTitles, nil)
+	remove(opfCreators, func(element string) bool {
+		attrs := xmlAttrs(strings.TrimSpace(element))
+		role := attrs["opf:role"]
+		if role == "" {
+			role = refinedRoles[attrs["id"]]
+		}
+		return role != "" && role != "aut"
+	})
+	if meta.ISBN != "" {
+		remove(opfIdentifiers, func(element string) bool {
+			attrs := xmlAttrs(strings.TrimSpace(element))
+			return (uniqueID != "" && attrs["id"] == uniqueID) || !isISBNIdentifier(element, attrs)
+		})
+	}
+	remove(opfMetas, func(element string) bool {
+		attrs := xmlAttrs(strings.TrimSpace(element))
+		switch {
+		case attrs["name"] == "calibre:series" || attrs["name"] == "calibre:series_index":
+			return false
+		case attrs["property"] == "belongs-to-collection":
+			return false
+		}
+		return !removedIDs[attrs["refines"]]
+	})
+	// Refinements can come before what they refine
+	remove(opfMetas, func(element string) bool {
+		return !removedIDs[xmlAttrs(strings.TrimSpace(element))["refines"]]
+	})
+
+	indent := "\n    "
+	var added strings.Builder
+	added.WriteString(indent + "<dc:title>" + xmlEscape(meta.FullTitle()) + "</dc:title>")
+	for i, author := range meta.Authors {
+		if epub3 {
+			id := "shelfarr-creator" + strconv.Itoa(i+1)
+			added.WriteString(indent + `<dc:creator id="` + id + `">` + xmlEscape(author) + "</dc:creator>")
+			added.WriteString(indent + `<meta refines="#` + id + `" property="role" scheme="marc:relators">aut</meta>`)
+		} else {
+			added.WriteString(indent + `<dc:creator opf:role="aut">` + xmlEscape(author) + "</dc:creator>")
+		}
+	}
+	if meta.ISBN != "" {
+		if epub3 {
+			added.WriteString(indent + "<dc:identifier>urn:isbn:" + xmlEscape(meta.ISBN) + "</dc:identifier>")
+		} else {
+			added.WriteString(indent + `<dc:identifier opf:scheme="ISBN">` + xmlEscape(meta.ISBN) + "</dc:identifier>")
+		}
+	}
+	if meta.Series != "" {
+		added.WriteString(indent + `<meta name="calibre:series" content="` + xmlEscape(meta.Series) + `"/>`)
+		if index := meta.SeriesIndexText(); index != "" {
+			added.WriteString(indent + `<meta name="calibre:series_index" content="` + index + `"/>`)
+		}
+		if epub3 {
+			added.WriteString(indent + `<meta property="belongs-to-collection" id="shelfarr-series">` + xmlEscape(meta.Series) + "</meta>")
+			added.WriteString(indent + `<meta refines="#shelfarr-series" property="collection-type">series</meta>`)
+			if index := meta.SeriesIndexText(); index != "" {
+				added.WriteString(indent + `<meta refines="#shelfarr-series" property="group-position">` + index + "</meta>")
+			}
+		}
+	}
+
+	// EPUB 2 roles need the opf namespace, which isn't always declared
+	if !epub3 && !strings.Contains(opf, `xmlns:opf=`) {
+		openTag = strings.TrimSuffix(openTag, ">") + ` xmlns:opf="http://www.idpf.org/2007/opf">`
+	}
+	inner = strings.TrimRight(inner, " \t\r\n") + added.String() + "\n  "
+	return opf[:match[2]] + openTag + inner + opf[match[5]:], nil
+}
+
+// isISBNIdentifier reports whether a dc:identifier element holds an ISBN
+func isISBNIdentifier(element string, attrs map[string]string) bool {
+	if strings.EqualFold(attrs["opf:scheme"], "ISBN") {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(elementText(element))), "urn:isbn:")
+}
+
+// elementText returns the text of an element without children
+func elementText(element string) string {
+	start := strings.IndexByte(element, '>')
+	if start < 0 || strings.HasSuffix(element[:start+1], "/>") {
+		return ""
+	}
+	text := element[start+1:]
+	if end := strings.IndexByte(text, '<'); end >= 0 {
+		text = text[:end]
+	}
+	return text
+}
+
+// setOPFCover finds the cover image a package document names, returning its path in the
+// EPUB when the new cover can replace it. A book without a cover gets one added to its
+// manifest. A cover of another image type is left alone.
+func setOPFCover(opf, opfPath string, cover []byte) (string, string) {
+	mediaType := "image/jpeg"
+	if bytes.HasPrefix(cover, []byte("\x89PNG")) {
+		mediaType = "image/png"
+	}
+	resolve := func(href string) string {
+		return path.Join(path.Dir(opfPath), href)
+	}
+
+	coverID := ""
+	for _, element := range opfMetas.FindAllString(opf, -1) {
+		if attrs := xmlAttrs(strings.TrimSpace(element)); attrs["name"] == "cover" {
+			coverID = attrs["content"]
+		}
+	}
+	for _, item := range opfItemPattern.FindAllString(opf, -1) {
+		attrs := xmlAttrs(item)
+		isCover := strings.Contains(" "+attrs["properties"]+" ", " cover-image ") || (coverID != "" && attrs["id"] == coverID)
+		if !isCover {
+			continue
+		}
+		if attrs["media-type"] != mediaType {
+			return opf, ""
+		}
+		return opf, resolve(attrs["href"])
+	}
+
+	// No cover yet: add one next to the package document
+	href := "shelfarr-cover.jpg"
+	if mediaType == "image/png" {
+		href = "shelfarr-cover.png"
+	}
+	manifest := opfManifestPattern.FindStringIndex(opf)
+	metadata := opfMetadataPattern.FindStringSubmatchIndex(opf)
+	if manifest == nil || metadata == nil || manifest[0] < metadata[1] {
+		return opf, ""
+	}
+	properties := ""
+	if strings.HasPrefix(xmlAttrs(opfPackagePattern.FindString(opf))["version"], "3") {
+		properties = ` properties="cover-image"`
+	}
+	item := `  <item id="shelfarr-cover" href="` + href + `" media-type="` + mediaType + `"` + properties + `/>` + "\n  "
+	opf = opf[:manifest[0]] + item + opf[manifest[0]:]
+	opf = opf[:metadata[6]] + `  <meta name="cover" content="shelfarr-cover"/>` + "\n  " + opf[metadata[6]:]
+	return opf, resolve(href)
+}
+
+// rewriteZip copies an EPUB, swapping in replaced entries and adding new ones. The
+// mimetype entry stays first and uncompressed.
+func rewriteZip(reader *zip.Reader, w io.Writer, replaced map[string][]byte) error {
+	writer := zip.NewWriter(w)
+	written := make(map[string]bool)
+
+	ordered := make([]*zip.File, 0, len(reader.File))
+	for _, f := range reader.File {
+		if f.Name == "mimetype" {
+			ordered = append([]*zip.File{f}, ordered...)
+		} else {
+			ordered = append(ordered, f)
+		}
+	}
+
+	for _, f := range ordered {
+		data, replace := replaced[f.Name]
+		if !replace {
+			if err := copyZipEntry(writer, f); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", f.Name, err)
+			}
+			continue
+		}
+		fw, err := writer.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+		written[f.Name] = true
+	}
+
+	for name, data := range replaced {
+		if written[name] {
+			continue
+		}
+		fw, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// EPUBMetadata is what an EPUB's package document says about the book
+type EPUBMetadata struct {
+	Title       string
+	Authors     []string
+	ISBN        string // Normalized, without hyphens
+	Series      string
+	SeriesIndex float64 // 0 when the book isn't in a series or has no index
+	CoverPath   string  // Path of the cover image inside the EPUB, if it names one
+}
+
+// ReadEPUBMetadata reads the title, authors, ISBN and series from an EPUB's package
+// document. Series are read from Calibre's meta tags and EPUB 3 collections.
+func ReadEPUBMetadata(epubPath string) (*EPUBMetadata, error) {
+	reader, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer reader.Close()
+
+	opfPath, err := epubPackagePath(&reader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	opf, err := readZipEntry(&reader.Reader, opfPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseOPFMetadata(string(opf), opfPath)
+}
+
+// ReadEPUBCover returns the cover image embedded in an EPUB and its media type
+func ReadEPUBCover(epubPath string) ([]byte, string, error) {
+	meta, err := ReadEPUBMetadata(epubPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if meta.CoverPath == "" {
+		return nil, "", fmt.Errorf("EPUB has no cover")
+	}
+
+	reader, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer reader.Close()
+	cover, err := readZipEntry(&reader.Reader, meta.CoverPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return cover, http.DetectContentType(cover), nil
+}
+
+// parseOPFMetadata reads an EPUB's metadata from its package document
+func parseOPFMetadata(opf, opfPath string) (*EPUBMetadata, error) {
+	match := opfMetadataPattern.FindStringSubmatch(opf)
+	if match == nil {
+		return nil, fmt.Errorf("package document has no metadata")
+	}
+	inner := match[2]
+	meta := &EPUBMetadata{}
+
+	// EPUB 3 refines creators, titles and collections with separate meta elements
+	refined := make(map[string]map[string]string)
+	var metas []map[string]string
+	for _, element := range opfMetas.FindAllString(inner, -1) {
+		element = strings.TrimSpace(element)
+		attrs := xmlAttrs(element)
+		attrs[""] = opfText(element)
+		metas = append(metas, attrs)
+		if id := strings.TrimPrefix(attrs["refines"], "#"); id != "" && attrs["property"] != "" {
+			if refined[id] == nil {
+				refined[id] = make(map[string]string)
+			}
+			refined[id][attrs["property"]] = attrs[""]
+		}
+	}
+
+	for _, element := range opfTitles.FindAllString(inner, -1) {
+		element = strings.TrimSpace(element)
+		title := opfText(element)
+		if title == "" {
+			continue
+		}
+		titleType := refined[xmlAttrs(element)["id"]]["title-type"]
+		if meta.Title == "" || titleType == "main" {
+			meta.Title = title
+		}
+		if titleType == "main" {
+			break
+		}
+	}
+
+	for _, element := range opfCreators.FindAllString(inner, -1) {
+		element = strings.TrimSpace(element)
+		attrs := xmlAttrs(element)
+		role := attrs["opf:role"]
+		if role == "" {
+			role = refined[attrs["id"]]["role"]
+		}
+		if name := opfText(element); name != "" && (role == "" || role == "aut") {
+			meta.Authors = append(meta.Authors, name)
+		}
+	}
+
+	for _, element := range opfIdentifiers.FindAllString(inner, -1) {
+		element = strings.TrimSpace(element)
+		text := opfText(element)
+		if !isISBNIdentifier(element, xmlAttrs(element)) && !strings.HasPrefix(text, "97") {
+			continue
+		}
+		if isbn := normalizeISBN(text); isbn != "" {
+			meta.ISBN = isbn
+			break
+		}
+	}
+
+	for _, attrs := range metas {
+		switch {
+		case attrs["name"] == "calibre:series":
+			meta.Series = strings.TrimSpace(html.UnescapeString(attrs["content"]))
+		case attrs["name"] == "calibre:series_index":
+			meta.SeriesIndex, _ = strconv.ParseFloat(strings.TrimSpace(attrs["content"]), 64)
+		}
+	}
+	if meta.Series == "" {
+		for _, attrs := range metas {
+			if attrs["property"] != "belongs-to-collection" || attrs[""] == "" {
+				continue
+			}
+			collection := refined[attrs["id"]]
+			if collectionType := collection["collection-type"]; collectionType != "" && collectionType != "series" {
+				continue
+			}
+			meta.Series = attrs[""]
+			meta.SeriesIndex, _ = strconv.ParseFloat(collection["group-position"], 64)
+			break
+		}
+	}
+
+	meta.CoverPath = opfCoverPath(opf, opfPath, metas)
+	return meta, nil
+}
+
+// opfCoverPath returns the path inside the EPUB of the cover image a package document
+// names, by an EPUB 3 cover-image property or an EPUB 2 cover meta
+func opfCoverPath(opf, opfPath string, metas []map[string]string) string {
+	coverID := ""
+	for _, attrs := range metas {
+		if attrs["name"] == "cover" {
+			coverID = attrs["content"]
+		}
+	}
+
+	var byID, byHref string
+	for _, item := range opfItemPattern.FindAllString(opf, -1) {
+		attrs := xmlAttrs(item)
+		href := html.UnescapeString(attrs["href"])
+		if strings.Contains(" "+attrs["properties"]+" ", " cover-image ") {
+			return path.Join(path.Dir(opfPath), href)
+		}
+		if coverID == "" || !strings.HasPrefix(attrs["media-type"], "image/") {
+			continue
+		}
+		// Some books name the cover by its file rather than its id
+		if attrs["id"] == coverID {
+			byID = href
+		} else if href == coverID {
+			byHref = href
+		}
+	}
+	if byID == "" {
+		byID = byHref
+	}
+	if byID == "" {
+		return ""
+	}
+	return path.Join(path.Dir(opfPath), byID)
+}
+
+// opfText returns the unescaped, trimmed text of a metadata element
+func opfText(element string) string {
+	return strings.TrimSpace(html.UnescapeString(elementText(element)))
+}
+
+// normalizeISBN strips an ISBN of hyphens, spaces and a "urn:isbn:" or "isbn:" prefix,
+// returning "" for anything that isn't an ISBN-10 or ISBN-13
+func normalizeISBN(s string) string {
+	isbn := strings.ToUpper(strings.TrimSpace(s))
+	isbn = strings.TrimPrefix(strings.TrimPrefix(isbn, "URN:"), "ISBN:")
+	isbn = strings.NewReplacer("-", "", " ", "").Replace(isbn)
+	if len(isbn) != 10 && len(isbn) != 13 {
+		return ""
+	}
+	for i, r := range isbn {
+		// Only an ISBN-10 check digit may be X
+		if (r < '0' || r > '9') && !(r == 'X' && len(isbn) == 10 && i == 9) {
+			return ""
+		}
+	}
+	return isbn
+}
@@ -0,0 +1,224 @@
+package media
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	opfSpinePattern   = regexp.MustCompile(`(?s)<(?:\w+:)?spine\b[^>]*>`)
+	opfItemrefPattern = regexp.MustCompile(`<(?:\w+:)?itemref\b[^>]*>`)
+)
+
+// epubMediaTypes are the types of files an EPUB holds, by extension, for entries its
+// manifest doesn't list. The system's MIME table may not know them.
+var epubMediaTypes = map[string]string{
+	".xhtml": "application/xhtml+xml",
+	".xht":   "application/xhtml+xml",
+	".html":  "text/html",
+	".htm":   "text/html",
+	".opf":   "application/oebps-package+xml",
+	".ncx":   "application/x-dtbncx+xml",
+	".xml":   "application/xml",
+	".css":   "text/css",
+	".js":    "text/javascript",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".png":   "image/png",
+	".gif":   "image/gif",
+	".svg":   "image/svg+xml",
+	".webp":  "image/webp",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".mp3":   "audio/mpeg",
+	".m4a":   "audio/mp4",
+	".mp4":   "video/mp4",
+	".smil":  "application/smil+xml",
+}
+
+// EPUBContents is what's inside an EPUB, as a reader needs it to render the book a
+// resource at a time
+type EPUBContents struct {
+	PackagePath string         // The OPF package document
+	Version     string         // Of the package document, such as "3.0"
+	Title       string         // From the package document
+	Resources   []EPUBResource // The manifest
+	Spine       []EPUBSpineItem
+	NavPath     string // The EPUB 3 navigation document, if any
+	NCXPath     string // The EPUB 2 table of contents, if any
+	CoverPath   string // The cover image, if the package document names one
+}
+
+// EPUBResource is a file the manifest lists
+type EPUBResource struct {
+	ID         string
+	Path       string // Inside the EPUB
+	MediaType  string
+	Properties string
+}
+
+// EPUBSpineItem is a document in the book's reading order
+type EPUBSpineItem struct {
+	ID     string
+	Path   string
+	Linear bool // Part of the main reading order, rather than auxiliary like notes
+}
+
+// ReadEPUBContents reads an EPUB's manifest and reading order from its package document
+func ReadEPUBContents(epubPath string) (*EPUBContents, error) {
+	reader, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer reader.Close()
+
+	opfPath, err := epubPackagePath(&reader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readZipEntry(&reader.Reader, opfPath)
+	if err != nil {
+		return nil, err
+	}
+	opf := string(data)
+
+	contents := &EPUBContents{
+		PackagePath: opfPath,
+		Version:     xmlAttrs(opfPackagePattern.FindString(opf))["version"],
+		Resources:   []EPUBResource{},
+		Spine:       []EPUBSpineItem{},
+	}
+	if meta, err := parseOPFMetadata(opf, opfPath); err == nil {
+		contents.Title = meta.Title
+		contents.CoverPath = meta.CoverPath
+	}
+
+	spine := xmlAttrs(opfSpinePattern.FindString(opf))
+	paths := make(map[string]string)
+	for _, item := range opfItemPattern.FindAllString(opf, -1) {
+		attrs := xmlAttrs(item)
+		resource := EPUBResource{
+			ID:         attrs["id"],
+			Path:       epubHrefPath(opfPath, attrs["href"]),
+			MediaType:  attrs["media-type"],
+			Properties: attrs["properties"],
+		}
+		if resource.Path == "" {
+			continue
+		}
+		contents.Resources = append(contents.Resources, resource)
+		paths[resource.ID] = resource.Path
+
+		switch {
+		case strings.Contains(" "+resource.Properties+" ", " nav "):
+			contents.NavPath = resource.Path
+		case resource.ID == spine["toc"] || resource.MediaType == "application/x-dtbncx+xml":
+			contents.NCXPath = resource.Path
+		}
+	}
+
+	for _, itemref := range opfItemrefPattern.FindAllString(opf, -1) {
+		attrs := xmlAttrs(itemref)
+		if p, ok := paths[attrs["idref"]]; ok {
+			contents.Spine = append(contents.Spine, EPUBSpineItem{ID: attrs["idref"], Path: p, Linear: attrs["linear"] != "no"})
+		}
+	}
+	return contents, nil
+}
+
+// epubHrefPath resolves a manifest href against the package document to a path inside
+// the EPUB, or "" if it points outside it
+func epubHrefPath(opfPath, href string) string {
+	href = html.UnescapeString(href)
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href = href[:i]
+	}
+	if href == "" || strings.Contains(href, "://") {
+		return ""
+	}
+	p := path.Join(path.Dir(opfPath), href)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return ""
+	}
+	return p
+}
+
+// EPUBEntry is one file inside an EPUB, open for reading. Close it to close the EPUB.
+type EPUBEntry struct {
+	io.ReadCloser
+	MediaType string
+	Size      int64
+	Modified  time.Time
+
+	archive *zip.ReadCloser
+}
+
+// Close closes the entry and the EPUB
+func (e *EPUBEntry) Close() error {
+	e.ReadCloser.Close()
+	return e.archive.Close()
+}
+
+// OpenEPUBEntry opens a file inside an EPUB by its path, typed by the manifest or else by
+// its extension, so a reader can fetch the book a resource at a time
+func OpenEPUBEntry(epubPath, name string) (*EPUBEntry, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	archive, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	for _, f := range archive.File {
+		if f.Name != name || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			archive.Close()
+			return nil, err
+		}
+		return &EPUBEntry{
+			ReadCloser: rc,
+			MediaType:  epubEntryMediaType(&archive.Reader, name),
+			Size:       int64(f.UncompressedSize64),
+			Modified:   f.Modified,
+			archive:    archive,
+		}, nil
+	}
+	archive.Close()
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+// epubEntryMediaType returns the type the manifest gives a file inside an EPUB, or else
+// the type of its extension
+func epubEntryMediaType(reader *zip.Reader, name string) string {
+	if opfPath, err := epubPackagePath(reader); err == nil {
+		if name == opfPath {
+			return epubMediaTypes[".opf"]
+		}
+		if opf, err := readZipEntry(reader, opfPath); err == nil {
+			for _, item := range opfItemPattern.FindAllString(string(opf), -1) {
+				attrs := xmlAttrs(item)
+				if attrs["media-type"] != "" && epubHrefPath(opfPath, attrs["href"]) == name {
+					return attrs["media-type"]
+				}
+			}
+		}
+	}
+	if mediaType, ok := epubMediaTypes[strings.ToLower(path.Ext(name))]; ok {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
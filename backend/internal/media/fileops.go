@@ -1,12 +1,14 @@
 package media
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"syscall"
 )
 
 // FileOperation represents the type of file operation to perform
@@ -16,8 +18,18 @@ const (
 	OpMove     FileOperation = "move"
 	OpCopy     FileOperation = "copy"
 	OpHardlink FileOperation = "hardlink"
+	OpReflink  FileOperation = "reflink" // Copy-on-write clone, on Btrfs, XFS and similar filesystems
 )
 
+// ParseFileOperation checks the name of a configured file operation
+func ParseFileOperation(name string) (FileOperation, error) {
+	switch op := FileOperation(name); op {
+	case OpMove, OpCopy, OpHardlink, OpReflink:
+		return op, nil
+	}
+	return "", fmt.Errorf("unknown file operation %q: must be move, copy, hardlink or reflink", name)
+}
+
 // FileOperator handles file system operations
 type FileOperator struct {
 	defaultOperation FileOperation
@@ -57,6 +69,8 @@ func (f *FileOperator) ImportFile(sourcePath, destPath string, operation FileOpe
 		return f.copyFile(sourcePath, destPath)
 	case OpHardlink:
 		return f.hardlinkFile(sourcePath, destPath)
+	case OpReflink:
+		return f.reflinkFile(sourcePath, destPath)
 	default:
 		return fmt.Errorf("unknown operation: %s", operation)
 	}
@@ -136,11 +150,31 @@ func (f *FileOperator) copyFile(src, dst string) error {
 
 func (f *FileOperator) hardlinkFile(src, dst string) error {
 	err := os.Link(src, dst)
-	if err != nil {
-		// Hardlinks may fail across filesystems, fall back to copy
+	if err != nil && canCopyInstead(err) {
+		// Hardlinks fail across filesystems, fall back to copy
 		return f.copyFile(src, dst)
 	}
-	return nil
+	return err
+}
+
+func (f *FileOperator) reflinkFile(src, dst string) error {
+	err := cloneFile(src, dst)
+	if err != nil && canCopyInstead(err) {
+		// Clones need both files on the same filesystem, and one that supports them
+		return f.copyFile(src, dst)
+	}
+	return err
+}
+
+// canCopyInstead reports whether a link or clone failed because of where the files are
+// rather than what they are, so a plain copy will work
+func canCopyInstead(err error) bool {
+	return errors.Is(err, syscall.EXDEV) || // Different filesystems
+		errors.Is(err, errors.ErrUnsupported) ||
+		errors.Is(err, syscall.EPERM) || // Filesystems without hardlinks, such as FAT
+		errors.Is(err, syscall.EMLINK) ||
+		errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.ENOTTY) // Filesystems without the clone ioctl
 }
 
 // DeleteFile removes a file (moves to recycle bin if configured)
@@ -167,6 +201,7 @@ func (f *FileOperator) DeleteFolder(path string, recycleBinPath string) error {
 type PathBuilder struct {
 	booksRoot      string
 	audiobooksRoot string
+	naming         NamingOptions
 }
 
 // NewPathBuilder creates a new path builder
@@ -174,7 +209,46 @@ func NewPathBuilder(booksRoot, audiobooksRoot string) *PathBuilder {
 	return &PathBuilder{
 		booksRoot:      booksRoot,
 		audiobooksRoot: audiobooksRoot,
+		naming:         DefaultNamingOptions(),
+	}
+}
+
+// SetNaming sets the naming templates and options paths are built with. Empty
+// templates keep the defaults.
+func (p *PathBuilder) SetNaming(opts NamingOptions) {
+	if opts.EbookTemplate == "" {
+		opts.EbookTemplate = DefaultEbookTemplate
+	}
+	if opts.AudiobookTemplate == "" {
+		opts.AudiobookTemplate = DefaultAudiobookTemplate
+	}
+	p.naming = opts
+}
+
+// BuildPath generates the path for a book's file from the naming template for its
+// media type. Audiobooks always get a folder of their own, named by the template, as
+// Audiobookshelf and most players expect; an empty format returns that folder.
+// Pattern: /books/{template}.epub or /audiobooks/{template}/{last part of template}.m4b
+func (p *PathBuilder) BuildPath(mediaType string, values NamingValues) string {
+	root, template := p.booksRoot, p.naming.EbookTemplate
+	if mediaType == "audiobook" {
+		root, template = p.audiobooksRoot, p.naming.AudiobookTemplate
+	}
+
+	rel := RenderNamingTemplate(template, values, p.naming)
+	if rel == "" {
+		rel = sanitizeFilename(values.Title)
+	}
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if values.Format == "" {
+		return path
+	}
+
+	filename := fmt.Sprintf("%s.%s", filepath.Base(path), strings.ToLower(values.Format))
+	if mediaType == "audiobook" {
+		return filepath.Join(path, filename)
 	}
+	return filepath.Join(filepath.Dir(path), filename)
 }
 
 // BuildBookPath generates the path for a book file
@@ -1,6 +1,8 @@
 package media
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -39,6 +41,11 @@ func NewImporter(db *gorm.DB, booksPath, audiobooksPath string, operation FileOp
 	}
 }
 
+// SetNaming sets the naming templates and options imported files are named with
+func (i *Importer) SetNaming(opts NamingOptions) {
+	i.pathBuilder.SetNaming(opts)
+}
+
 // ImportRequest represents a request to import a file
 type ImportRequest struct {
 	SourcePath  string
@@ -46,7 +53,8 @@ type ImportRequest struct {
 	AuthorName  string
 	BookTitle   string
 	SeriesName  string
-	SeriesIndex int
+	SeriesIndex float64
+	Year        int
 	MediaType   string // "ebook" or "audiobook"
 	Format      string
 	EditionName string
@@ -62,7 +70,16 @@ func (i *Importer) Import(req ImportRequest) (*ImportResult, error) {
 	info, err := os.Stat(req.SourcePath)
 	if err != nil {
 		result.Error = fmt.Sprintf("source not found: %s", req.SourcePath)
-		return result, fmt.Errorf(result.Error)
+		return result, errors.New(result.Error)
+	}
+
+	values := NamingValues{
+		Author:      req.AuthorName,
+		Title:       req.BookTitle,
+		Series:      req.SeriesName,
+		SeriesIndex: req.SeriesIndex,
+		Year:        req.Year,
+		Format:      req.Format,
 	}
 
 	var destPath string
@@ -70,19 +87,12 @@ func (i *Importer) Import(req ImportRequest) (*ImportResult, error) {
 
 	if info.IsDir() {
 		// Import folder (typically audiobook)
-		destPath = i.pathBuilder.BuildAudiobookPath(req.AuthorName, req.BookTitle)
+		values.Format = ""
+		destPath = i.pathBuilder.BuildPath("audiobook", values)
 		importErr = i.fileOps.ImportFolder(req.SourcePath, destPath, i.operation)
 	} else {
 		// Import single file
-		if req.MediaType == "audiobook" {
-			destPath = i.pathBuilder.BuildAudiobookFilePath(req.AuthorName, req.BookTitle, req.Format)
-		} else {
-			if req.SeriesName != "" && req.SeriesIndex > 0 {
-				destPath = i.pathBuilder.BuildSeriesBookPath(req.AuthorName, req.SeriesName, req.SeriesIndex, req.BookTitle, req.Format)
-			} else {
-				destPath = i.pathBuilder.BuildBookPath(req.AuthorName, req.BookTitle, req.Format)
-			}
-		}
+		destPath = i.pathBuilder.BuildPath(req.MediaType, values)
 		importErr = i.fileOps.ImportFile(req.SourcePath, destPath, i.operation)
 	}
 
@@ -106,6 +116,9 @@ func (i *Importer) Import(req ImportRequest) (*ImportResult, error) {
 	if fileInfo, err := os.Stat(destPath); err == nil {
 		mediaFile.FileSize = fileInfo.Size()
 	}
+	if !info.IsDir() && IsPagedFormat(req.Format) {
+		mediaFile.PageCount, _ = PageCount(destPath)
+	}
 
 	if err := i.db.Create(mediaFile).Error; err != nil {
 		result.Error = fmt.Sprintf("failed to create database record: %v", err)
@@ -127,7 +140,9 @@ func (i *Importer) Import(req ImportRequest) (*ImportResult, error) {
 	return result, nil
 }
 
-// ScanDownloadsFolder scans the downloads folder for pending imports
+// ScanDownloadsFolder scans the downloads folder for pending imports. EPUBs and audio
+// files are described by their own metadata where they have it, and a folder of audio
+// files is split into the books its tags name.
 func (i *Importer) ScanDownloadsFolder(downloadsPath string) ([]PendingImport, error) {
 	var pending []PendingImport
 
@@ -137,10 +152,20 @@ func (i *Importer) ScanDownloadsFolder(downloadsPath string) ([]PendingImport, e
 		return nil, err
 	}
 
+	// Audiobook folders are found first, as their files are imported with them
+	folders, _ := i.scanner.ScanForAudiobookFolders(downloadsPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), audiobookProbeTimeout)
+	defer cancel()
+
 	for _, file := range files {
+		if file.MediaType == "audiobook" && inAnyFolder(file.Path, folders) {
+			continue
+		}
+
 		author, title, series, seriesNum := i.scanner.ExtractMetadataFromFilename(file.Name)
 		
-		pending = append(pending, PendingImport{
+		p := PendingImport{
 			Path:           file.Path,
 			Name:           file.Name,
 			Size:           file.Size,
@@ -151,34 +176,61 @@ func (i *Importer) ScanDownloadsFolder(downloadsPath string) ([]PendingImport, e
 			ExtractedTitle:  title,
 			ExtractedSeries: series,
 			ExtractedSeriesNum: seriesNum,
-		})
+		}
+
+		// A file's own metadata is more reliable than its name
+		if file.Format == "epub" {
+			if meta, err := ReadEPUBMetadata(file.Path); err == nil {
+				p.applyEPUBMetadata(meta)
+			}
+		} else if file.MediaType == "audiobook" {
+			if tags := ReadAudiobookTags(ctx, file.Path); tags != nil {
+				p.applyAudioTags(tags)
+			}
+		}
+
+		pending = append(pending, p)
 	}
 
 	// Scan for audiobook folders
-	folders, err := i.scanner.ScanForAudiobookFolders(downloadsPath)
-	if err == nil {
-		for _, folder := range folders {
-			author, title, series, seriesNum := i.scanner.ExtractMetadataFromFilename(folder.Name)
-			size, _ := i.scanner.CalculateFolderSize(folder.Path)
-			
-			pending = append(pending, PendingImport{
-				Path:           folder.Path,
-				Name:           folder.Name,
-				Size:           size,
-				Format:         "folder",
-				MediaType:      "audiobook",
-				IsFolder:       true,
-				ExtractedAuthor: author,
-				ExtractedTitle:  title,
-				ExtractedSeries: series,
+	for _, folder := range folders {
+		for _, group := range i.groupAudiobookFolder(ctx, folder.Path) {
+			name := filepath.Base(group.path)
+			author, title, series, seriesNum := i.scanner.ExtractMetadataFromFilename(name)
+			size, _ := i.scanner.CalculateFolderSize(group.path)
+
+			p := PendingImport{
+				Path:               group.path,
+				Name:               name,
+				Size:               size,
+				Format:             "folder",
+				MediaType:          "audiobook",
+				IsFolder:           true,
+				ExtractedAuthor:    author,
+				ExtractedTitle:     title,
+				ExtractedSeries:    series,
 				ExtractedSeriesNum: seriesNum,
-			})
+			}
+			if group.tags != nil {
+				p.applyAudioTags(group.tags)
+			}
+			pending = append(pending, p)
 		}
 	}
 
 	return pending, nil
 }
 
+// inAnyFolder reports whether a file is inside one of the folders
+func inAnyFolder(path string, folders []FileInfo) bool {
+	for _, folder := range folders {
+		if isWithin(path, folder.Path) {
+			return true
+		}
+	}
+	return false
+}
+
 // PendingImport represents a file awaiting import
 type PendingImport struct {
 	Path               string `json:"path"`
@@ -191,6 +243,45 @@ type PendingImport struct {
 	ExtractedTitle     string `json:"extractedTitle,omitempty"`
 	ExtractedSeries    string `json:"extractedSeries,omitempty"`
 	ExtractedSeriesNum int    `json:"extractedSeriesNum,omitempty"`
+	ExtractedISBN      string `json:"extractedIsbn,omitempty"`
+	ExtractedASIN      string `json:"extractedAsin,omitempty"`
+	HasEmbeddedCover   bool   `json:"hasEmbeddedCover,omitempty"`
+}
+
+// applyEPUBMetadata replaces what was taken from the file name with what the EPUB says
+// about itself, where it says anything
+func (p *PendingImport) applyEPUBMetadata(meta *EPUBMetadata) {
+	if meta.Title != "" {
+		p.ExtractedTitle = meta.Title
+	}
+	if len(meta.Authors) > 0 {
+		p.ExtractedAuthor = meta.Authors[0]
+	}
+	if meta.Series != "" {
+		p.ExtractedSeries = meta.Series
+		p.ExtractedSeriesNum = int(meta.SeriesIndex)
+	}
+	p.ExtractedISBN = meta.ISBN
+	p.HasEmbeddedCover = meta.CoverPath != ""
+}
+
+// applyAudioTags replaces what was taken from the name with what an audiobook's tags
+// say. The album names the book; the title only does for a book in a single file, as
+// the titles of a book's several files name its chapters or parts.
+func (p *PendingImport) applyAudioTags(tags *AudioTags) {
+	if tags.Album != "" {
+		p.ExtractedTitle = discSuffixPattern.ReplaceAllString(tags.Album, "")
+	} else if tags.Title != "" && !p.IsFolder {
+		p.ExtractedTitle = tags.Title
+	}
+	if tags.Author != "" {
+		p.ExtractedAuthor = tags.Author
+	}
+	if tags.Series != "" {
+		p.ExtractedSeries = tags.Series
+		p.ExtractedSeriesNum = int(tags.SeriesIndex)
+	}
+	p.ExtractedASIN = tags.ASIN
 }
 
 // MediaFileRecord for database operations
@@ -204,6 +295,7 @@ type MediaFileRecord struct {
 	MediaType   string
 	Bitrate     int
 	Duration    int
+	PageCount   int
 	EditionName string
 	ImportedAt  time.Time
 }
@@ -222,25 +314,16 @@ func (BookRecord) TableName() string {
 	return "books"
 }
 
-// RenameFile renames a media file following the naming conventions
-func (i *Importer) RenameFile(mediaFileID uint, authorName, bookTitle, seriesName string, seriesIndex int) error {
+// RenameFile renames a media file following the naming templates. The values' format
+// is taken from the file.
+func (i *Importer) RenameFile(mediaFileID uint, values NamingValues) error {
 	var mediaFile MediaFileRecord
 	if err := i.db.First(&mediaFile, mediaFileID).Error; err != nil {
 		return fmt.Errorf("media file not found: %w", err)
 	}
 
-	var newPath string
-	format := strings.ToLower(mediaFile.Format)
-
-	if mediaFile.MediaType == "audiobook" {
-		newPath = i.pathBuilder.BuildAudiobookFilePath(authorName, bookTitle, format)
-	} else {
-		if seriesName != "" && seriesIndex > 0 {
-			newPath = i.pathBuilder.BuildSeriesBookPath(authorName, seriesName, seriesIndex, bookTitle, format)
-		} else {
-			newPath = i.pathBuilder.BuildBookPath(authorName, bookTitle, format)
-		}
-	}
+	values.Format = strings.ToLower(mediaFile.Format)
+	newPath := i.pathBuilder.BuildPath(mediaFile.MediaType, values)
 
 	// Move file to new location
 	if err := i.fileOps.ImportFile(mediaFile.FilePath, newPath, OpMove); err != nil {
@@ -0,0 +1,368 @@
+package media
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KepubConverter converts EPUBs to Kobo's KEPUB flavour without Calibre.
+// Kobo firmware uses koboSpan elements around each sentence to track reading
+// position and page statistics; everything else in the book is copied as-is.
+type KepubConverter struct{}
+
+// NewKepubConverter creates a new KEPUB converter
+func NewKepubConverter() *KepubConverter {
+	return &KepubConverter{}
+}
+
+// KepubFileName returns the conventional file name for a converted book (book.kepub.epub)
+func KepubFileName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".kepub.epub"
+}
+
+// Convert writes a KEPUB version of the EPUB at inputPath to outputPath
+func (k *KepubConverter) Convert(inputPath, outputPath string) error {
+	reader, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Write to a temp file of its own first so a failed conversion never leaves a partial
+	// book behind, and conversions of the same book at once don't write over each other
+	out, err := os.CreateTemp(filepath.Dir(outputPath), filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	tmpPath := out.Name()
+
+	if err := k.convertZip(&reader.Reader, out); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// convertZip copies every entry of the EPUB, rewriting content documents
+func (k *KepubConverter) convertZip(reader *zip.Reader, w io.Writer) error {
+	writer := zip.NewWriter(w)
+
+	// The mimetype entry must come first and be stored uncompressed
+	for _, f := range reader.File {
+		if f.Name != "mimetype" {
+			continue
+		}
+		mw, err := writer.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(mw, "application/epub+zip"); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range reader.File {
+		if f.Name == "mimetype" {
+			continue
+		}
+
+		if !isContentDocument(f.Name) {
+			if err := copyZipEntry(writer, f); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", f.Name, err)
+			}
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+
+		fw, err := writer.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, kepubifyDocument(string(data))); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// copyZipEntry copies an entry without recompressing it
+func copyZipEntry(writer *zip.Writer, f *zip.File) error {
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return err
+	}
+	header := f.FileHeader
+	w, err := writer.CreateRaw(&header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, raw)
+	return err
+}
+
+func isContentDocument(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xhtml", ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+// Elements whose text must not be wrapped in spans
+var kepubSkipElements = map[string]bool{
+	"script": true, "style": true, "svg": true, "math": true, "pre": true,
+	"code": true, "title": true, "head": true, "textarea": true,
+}
+
+// Elements that start a new Kobo "paragraph" (the first number in kobo.P.S)
+var kepubBlockElements = map[string]bool{
+	"p": true, "div": true, "li": true, "blockquote": true, "dd": true, "dt": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"td": true, "th": true, "figcaption": true, "caption": true,
+}
+
+// kepubifyDocument wraps each sentence inside <body> in a koboSpan and wraps the
+// body contents in the book-columns/book-inner divs Kobo uses for pagination.
+// The document is treated as a token stream so the original markup is preserved byte for byte.
+func kepubifyDocument(doc string) string {
+	if strings.Contains(doc, "koboSpan") {
+		return doc // Already a KEPUB
+	}
+
+	var out strings.Builder
+	out.Grow(len(doc) + len(doc)/4)
+
+	inBody := false
+	skipDepth := 0
+	paragraph := 0
+	segment := 0
+
+	nextID := func() string {
+		segment++
+		return fmt.Sprintf("kobo.%d.%d", paragraph, segment)
+	}
+	newParagraph := func() {
+		paragraph++
+		segment = 0
+	}
+
+	for pos := 0; pos < len(doc); {
+		if doc[pos] != '<' {
+			end := strings.IndexByte(doc[pos:], '<')
+			if end < 0 {
+				end = len(doc) - pos
+			}
+			text := doc[pos : pos+end]
+			pos += end
+
+			if !inBody || skipDepth > 0 || strings.TrimSpace(text) == "" {
+				out.WriteString(text)
+				continue
+			}
+			if paragraph == 0 {
+				newParagraph()
+			}
+			for _, sentence := range splitSentences(text) {
+				if strings.TrimSpace(sentence) == "" {
+					out.WriteString(sentence)
+					continue
+				}
+				out.WriteString(`<span class="koboSpan" id="` + nextID() + `">`)
+				out.WriteString(sentence)
+				out.WriteString("</span>")
+			}
+			continue
+		}
+
+		tag, next := readMarkup(doc, pos)
+		pos = next
+
+		name, closing, selfClosing := parseTagName(tag)
+		switch {
+		case name == "":
+			// Comment, doctype, CDATA or processing instruction
+			out.WriteString(tag)
+
+		case name == "body" && !closing:
+			out.WriteString(tag)
+			out.WriteString(`<div id="book-columns"><div id="book-inner">`)
+			inBody = true
+
+		case name == "body" && closing:
+			out.WriteString(`</div></div>`)
+			out.WriteString(tag)
+			inBody = false
+
+		case kepubSkipElements[name]:
+			if !selfClosing {
+				if closing {
+					if skipDepth > 0 {
+						skipDepth--
+					}
+				} else {
+					skipDepth++
+				}
+			}
+			out.WriteString(tag)
+
+		case name == "img" && inBody && skipDepth == 0:
+			// Images get their own span so image-only pages still count toward progress
+			newParagraph()
+			out.WriteString(`<span class="koboSpan" id="` + nextID() + `">`)
+			out.WriteString(tag)
+			out.WriteString("</span>")
+
+		default:
+			if kepubBlockElements[name] && !closing && inBody && skipDepth == 0 {
+				newParagraph()
+			}
+			out.WriteString(tag)
+		}
+	}
+
+	return out.String()
+}
+
+// readMarkup returns the markup starting at pos (a tag, comment, CDATA section, etc.)
+// and the position just after it
+func readMarkup(doc string, pos int) (string, int) {
+	terminator := ">"
+	switch {
+	case strings.HasPrefix(doc[pos:], "<!--"):
+		terminator = "-->"
+	case strings.HasPrefix(doc[pos:], "<![CDATA["):
+		terminator = "]]>"
+	case strings.HasPrefix(doc[pos:], "<?"):
+		terminator = "?>"
+	}
+
+	if terminator != ">" {
+		end := strings.Index(doc[pos:], terminator)
+		if end < 0 {
+			return doc[pos:], len(doc)
+		}
+		end += pos + len(terminator)
+		return doc[pos:end], end
+	}
+
+	// Regular tag: find the closing '>' outside of quoted attribute values
+	var quote byte
+	for i := pos + 1; i < len(doc); i++ {
+		c := doc[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return doc[pos : i+1], i + 1
+		}
+	}
+	return doc[pos:], len(doc)
+}
+
+// parseTagName returns the lowercase local name of an element tag.
+// The name is empty for comments, doctypes and processing instructions.
+func parseTagName(tag string) (name string, closing, selfClosing bool) {
+	if len(tag) < 2 || tag[1] == '!' || tag[1] == '?' {
+		return "", false, false
+	}
+
+	inner := tag[1:]
+	if strings.HasPrefix(inner, "/") {
+		closing = true
+		inner = inner[1:]
+	}
+	selfClosing = strings.HasSuffix(tag, "/>")
+
+	end := strings.IndexFunc(inner, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '/' || r == '>'
+	})
+	if end < 0 {
+		end = len(inner)
+	}
+	name = strings.ToLower(inner[:end])
+	if idx := strings.LastIndexByte(name, ':'); idx >= 0 {
+		name = name[idx+1:] // Drop namespace prefixes (svg:svg)
+	}
+	return name, closing, selfClosing
+}
+
+// splitSentences splits text after sentence-ending punctuation (plus any closing
+// quotes and the following whitespace). Joining the pieces gives back the original text.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if r != '.' && r != '!' && r != '?' && r != '…' {
+			continue
+		}
+
+		// Absorb repeated punctuation and closing quotes/brackets
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+			if !strings.ContainsRune(".!?…\"'”’»)]", r) {
+				break
+			}
+			i += size
+		}
+
+		// Only a break if followed by whitespace
+		ws := i
+		for ws < len(text) {
+			r, size = utf8.DecodeRuneInString(text[ws:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			ws += size
+		}
+		if ws == i || ws == len(text) {
+			continue
+		}
+
+		sentences = append(sentences, text[start:ws])
+		start = ws
+		i = ws
+	}
+
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
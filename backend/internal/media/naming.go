@@ -0,0 +1,192 @@
+package media
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Default naming templates, which keep a book's files under its author
+const (
+	DefaultEbookTemplate     = "{Author}/{Title}"
+	DefaultAudiobookTemplate = "{Author}/{Title}"
+)
+
+// NamingTokens are the tokens naming templates can use
+var NamingTokens = []string{"Author", "Title", "Series", "SeriesIndex", "Year", "Format"}
+
+// NamingOptions control how library paths are built from naming templates
+type NamingOptions struct {
+	EbookTemplate     string
+	AudiobookTemplate string
+	ASCIIOnly         bool // Transliterate accented letters and drop other non-ASCII characters
+	ReplaceIllegal    bool // Replace characters that aren't allowed in file names rather than removing them
+}
+
+// DefaultNamingOptions returns the naming used when none is configured
+func DefaultNamingOptions() NamingOptions {
+	return NamingOptions{
+		EbookTemplate:     DefaultEbookTemplate,
+		AudiobookTemplate: DefaultAudiobookTemplate,
+		ReplaceIllegal:    true,
+	}
+}
+
+// NamingValues are the values of a book's naming tokens
+type NamingValues struct {
+	Author      string
+	Title       string
+	Series      string
+	SeriesIndex float64 // 0 when the book isn't in a series
+	Year        int
+	Format      string
+}
+
+var (
+	namingTokenPattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+	emptyGroupPattern  = regexp.MustCompile(`\(\s*\)|\[\s*\]`)
+	repeatedDashes     = regexp.MustCompile(`\s*(-\s*){2,}`)
+	edgeDashes         = regexp.MustCompile(`^[\s\-_]+|[\s\-_]+$`)
+)
+
+// ValidateNamingTemplate checks that a template only uses known tokens, names the book's
+// title and stays inside its root folder
+func ValidateNamingTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	if filepath.IsAbs(template) || strings.HasPrefix(template, "/") {
+		return fmt.Errorf("template must be relative to the root folder")
+	}
+	for _, segment := range strings.Split(template, "/") {
+		if strings.TrimSpace(segment) == ".." {
+			return fmt.Errorf("template can't leave the root folder")
+		}
+	}
+
+	hasTitle := false
+	for _, match := range namingTokenPattern.FindAllStringSubmatch(template, -1) {
+		if !isNamingToken(match[1]) {
+			return fmt.Errorf("unknown token {%s}", match[1])
+		}
+		if match[1] == "Title" {
+			hasTitle = true
+		}
+	}
+	if !hasTitle {
+		return fmt.Errorf("template must include {Title}")
+	}
+	return nil
+}
+
+func isNamingToken(name string) bool {
+	for _, token := range NamingTokens {
+		if token == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderNamingTemplate fills in a template's tokens and returns the relative path it
+// names, using / between folders. Folders whose tokens are all empty are dropped, as are
+// the brackets and dashes around empty tokens, so one template suits books with and
+// without a series.
+func RenderNamingTemplate(template string, values NamingValues, opts NamingOptions) string {
+	var segments []string
+	for _, segment := range strings.Split(template, "/") {
+		rendered := namingTokenPattern.ReplaceAllStringFunc(segment, func(token string) string {
+			return cleanNamingValue(tokenValue(token[1:len(token)-1], values), opts)
+		})
+		rendered = emptyGroupPattern.ReplaceAllString(rendered, "")
+		rendered = repeatedDashes.ReplaceAllString(rendered, " - ")
+		rendered = edgeDashes.ReplaceAllString(rendered, "")
+		rendered = strings.Join(strings.Fields(rendered), " ")
+		if rendered != "" {
+			segments = append(segments, rendered)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func tokenValue(token string, values NamingValues) string {
+	switch token {
+	case "Author":
+		return values.Author
+	case "Title":
+		return values.Title
+	case "Series":
+		return values.Series
+	case "SeriesIndex":
+		return formatSeriesIndex(values.SeriesIndex)
+	case "Year":
+		if values.Year > 0 {
+			return strconv.Itoa(values.Year)
+		}
+	case "Format":
+		return strings.ToLower(values.Format)
+	}
+	return ""
+}
+
+// formatSeriesIndex pads whole numbers to two digits, so files sort in series order,
+// and keeps the fraction of novellas numbered between books (01, 02.5)
+func formatSeriesIndex(index float64) string {
+	if index <= 0 {
+		return ""
+	}
+	whole := int(index)
+	if float64(whole) == index {
+		return fmt.Sprintf("%02d", whole)
+	}
+	return fmt.Sprintf("%02d", whole) + strings.TrimPrefix(strconv.FormatFloat(index-float64(whole), 'f', -1, 64), "0")
+}
+
+// cleanNamingValue makes a token's value safe to use in a file name
+func cleanNamingValue(value string, opts NamingOptions) string {
+	if opts.ASCIIOnly {
+		value = toASCII(value)
+	}
+	if !opts.ReplaceIllegal {
+		value = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`/\:*?"<>|`, r) {
+				return -1
+			}
+			return r
+		}, value)
+	}
+	return sanitizeFilename(value)
+}
+
+// asciiReplacements transliterates the accented letters common in author names and titles
+var asciiReplacements = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ý': "Y", 'Þ': "Th", 'ß': "ss",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ð': "d", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'þ': "th", 'ÿ': "y",
+	'Ł': "L", 'ł': "l", 'Œ': "OE", 'œ': "oe", 'Š': "S", 'š': "s", 'Ž': "Z", 'ž': "z",
+	'Č': "C", 'č': "c", 'Ć': "C", 'ć': "c", 'Ř': "R", 'ř': "r", 'Ś': "S", 'ś': "s",
+	'Ź': "Z", 'ź': "z", 'Ż': "Z", 'ż': "z", 'Ń': "N", 'ń': "n", 'Ę': "E", 'ę': "e",
+	'Ą': "A", 'ą': "a", 'Ğ': "G", 'ğ': "g", 'İ': "I", 'ı': "i", 'Ş': "S", 'ş': "s",
+	'‘': "'", '’': "'", '“': "\"", '”': "\"", '–': "-", '—': "-", '…': "...",
+}
+
+// toASCII transliterates a string to ASCII, dropping characters it can't
+func toASCII(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 128:
+			b.WriteRune(r)
+		case asciiReplacements[r] != "":
+			b.WriteString(asciiReplacements[r])
+		}
+	}
+	return b.String()
+}
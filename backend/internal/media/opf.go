@@ -0,0 +1,152 @@
+package media
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Sidecar file names written next to a book's files
+const (
+	OPFFileName         = "metadata.opf"
+	DescriptionFileName = "desc.txt"
+	NarratorsFileName   = "reader.txt"
+)
+
+// BookMetadata is the metadata written to a book's sidecar files
+type BookMetadata struct {
+	BookID      uint
+	Title       string
+	Subtitle    string
+	Authors     []string
+	Narrators   []string
+	Series      string
+	SeriesIndex float64 // 0 when the book isn't in a series
+	Description string
+	Publisher   string
+	Published   string // YYYY-MM-DD, or just the year
+	Language    string // ISO 639-1 code
+	ISBN        string
+	ASIN        string
+	HardcoverID string
+	Genres      []string
+}
+
+// opfTemplate is an OPF 2.0 package document in the form Calibre writes, which
+// Audiobookshelf, Kavita and most readers also understand
+var opfTemplate = template.Must(template.New("opf").Funcs(template.FuncMap{"esc": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="shelfarr_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:identifier opf:scheme="shelfarr" id="shelfarr_id">{{.BookID}}</dc:identifier>
+    <dc:title>{{esc .FullTitle}}</dc:title>
+{{- range .Authors}}
+    <dc:creator opf:role="aut">{{esc .}}</dc:creator>
+{{- end}}
+{{- range .Narrators}}
+    <dc:contributor opf:role="nrt">{{esc .}}</dc:contributor>
+{{- end}}
+{{- with .Description}}
+    <dc:description>{{esc .}}</dc:description>
+{{- end}}
+{{- with .Publisher}}
+    <dc:publisher>{{esc .}}</dc:publisher>
+{{- end}}
+{{- with .Published}}
+    <dc:date>{{esc .}}</dc:date>
+{{- end}}
+{{- with .Language}}
+    <dc:language>{{esc .}}</dc:language>
+{{- end}}
+{{- with .ISBN}}
+    <dc:identifier opf:scheme="ISBN">{{esc .}}</dc:identifier>
+{{- end}}
+{{- with .ASIN}}
+    <dc:identifier opf:scheme="ASIN">{{esc .}}</dc:identifier>
+{{- end}}
+{{- with .HardcoverID}}
+    <dc:identifier opf:scheme="HARDCOVER">{{esc .}}</dc:identifier>
+{{- end}}
+{{- range .Genres}}
+    <dc:subject>{{esc .}}</dc:subject>
+{{- end}}
+{{- if .Series}}
+    <meta name="calibre:series" content="{{esc .Series}}"/>
+{{- with .SeriesIndexText}}
+    <meta name="calibre:series_index" content="{{.}}"/>
+{{- end}}
+{{- end}}
+  </metadata>
+</package>
+`))
+
+// FullTitle is the title with its subtitle, as Calibre stores it
+func (m BookMetadata) FullTitle() string {
+	if m.Subtitle == "" {
+		return m.Title
+	}
+	return m.Title + ": " + m.Subtitle
+}
+
+// SeriesIndexText is the series index without trailing zeros (2, 2.5)
+func (m BookMetadata) SeriesIndexText() string {
+	if m.SeriesIndex <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(m.SeriesIndex, 'f', -1, 64)
+}
+
+// RenderOPF returns a book's metadata.opf
+func RenderOPF(meta BookMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := opfTemplate.Execute(&buf, meta); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMetadataFiles writes metadata.opf into a book's folder, with desc.txt for the
+// description and reader.txt for the narrators, which Audiobookshelf reads. A cover is
+// written as cover.jpg or cover.png, by its content, replacing any earlier cover.
+func WriteMetadataFiles(dir string, meta BookMetadata, cover []byte) error {
+	opf, err := RenderOPF(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, OPFFileName), opf, 0644); err != nil {
+		return err
+	}
+
+	if meta.Description != "" {
+		if err := os.WriteFile(filepath.Join(dir, DescriptionFileName), []byte(meta.Description+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	if len(meta.Narrators) > 0 {
+		if err := os.WriteFile(filepath.Join(dir, NarratorsFileName), []byte(strings.Join(meta.Narrators, ", ")+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	if len(cover) > 0 {
+		name, stale := "cover.jpg", "cover.png"
+		if bytes.HasPrefix(cover, []byte("\x89PNG")) {
+			name, stale = stale, name
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), cover, 0644); err != nil {
+			return err
+		}
+		os.Remove(filepath.Join(dir, stale))
+	}
+	return nil
+}
+
+// xmlEscape escapes text for use in XML content and attributes
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
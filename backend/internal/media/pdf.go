@@ -0,0 +1,78 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pdfPageTreePattern matches the page tree nodes of a PDF whose objects aren't
+// compressed, with their page counts, in either order
+var pdfPageTreePattern = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+
+// pdfPageCount returns the number of pages of a PDF. pdfinfo, from Poppler, is used when
+// installed; otherwise the page tree is read directly, which doesn't work for PDFs that
+// compress their objects.
+func pdfPageCount(ctx context.Context, filePath string) (int, error) {
+	if pdfinfo, err := exec.LookPath("pdfinfo"); err == nil {
+		output, err := exec.CommandContext(ctx, pdfinfo, filePath).Output()
+		if err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(output))
+			for scanner.Scan() {
+				if value, ok := strings.CutPrefix(scanner.Text(), "Pages:"); ok {
+					return strconv.Atoi(strings.TrimSpace(value))
+				}
+			}
+		}
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	// The root of the page tree counts every page, so it has the largest count
+	pages := 0
+	for _, match := range pdfPageTreePattern.FindAllSubmatch(data, -1) {
+		count := match[1]
+		if count == nil {
+			count = match[2]
+		}
+		if n, err := strconv.Atoi(string(count)); err == nil && n > pages {
+			pages = n
+		}
+	}
+	if pages == 0 {
+		return 0, fmt.Errorf("could not find the pages of %s; install pdfinfo to count them", filepath.Base(filePath))
+	}
+	return pages, nil
+}
+
+// pdfCover renders the first page of a PDF as a JPEG, with pdftoppm from Poppler
+func pdfCover(ctx context.Context, filePath string) ([]byte, error) {
+	pdftoppm, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil, fmt.Errorf("rendering PDF covers needs pdftoppm installed")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "shelfarr-pdf-cover-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	prefix := filepath.Join(tmpDir, "cover")
+	cmd := exec.CommandContext(ctx, pdftoppm, "-f", "1", "-l", "1", "-jpeg", "-scale-to", strconv.Itoa(ThumbnailHeight*2), "-singlefile", filePath, prefix)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to render PDF cover: %v - %s", err, lastLines(stderr.String(), 3))
+	}
+	return os.ReadFile(prefix + ".jpg")
+}
@@ -140,6 +140,12 @@ func (s *Scanner) countAudioFiles(path string) (int, int64) {
 	return count, totalSize
 }
 
+// DetectMediaType returns "ebook" or "audiobook" for a file extension (".epub"), or ""
+// for anything else
+func (s *Scanner) DetectMediaType(ext string) string {
+	return s.detectMediaType(ext)
+}
+
 func (s *Scanner) detectMediaType(ext string) string {
 	ext = strings.ToLower(ext)
 	
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif" // Comic pages may be GIFs
+	"image/jpeg"
+	_ "image/png"
+)
+
+// ThumbnailHeight is the height cover thumbnails are scaled down to
+const ThumbnailHeight = 600
+
+// Thumbnail scales a JPEG, PNG or GIF image down to at most maxHeight pixels high and
+// returns it as a JPEG. Images of other types, such as WebP, return an error.
+func Thumbnail(data []byte, maxHeight int) ([]byte, error) {
+	return ResizeImage(data, 0, maxHeight)
+}
+
+// ResizeImage scales a JPEG, PNG or GIF image down to fit within maxWidth by maxHeight
+// pixels, keeping its aspect ratio, and returns it as a JPEG. A zero bound leaves that
+// side unbounded. Images are never scaled up.
+func ResizeImage(data []byte, maxWidth, maxHeight int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxHeight > 0 && height > maxHeight {
+		width, height = max(width*maxHeight/height, 1), maxHeight
+	}
+	if maxWidth > 0 && width > maxWidth {
+		width, height = maxWidth, max(height*maxWidth/width, 1)
+	}
+	if width != bounds.Dx() || height != bounds.Dy() {
+		src = scaleDown(src, width, height)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown resizes an image by averaging the source pixels each new pixel covers
+func scaleDown(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
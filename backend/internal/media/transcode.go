@@ -0,0 +1,80 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// TranscodeFormats are the formats audio can be transcoded to for players that can't
+// play the original, such as browsers without M4B support, with their MIME types
+var TranscodeFormats = map[string]string{
+	"mp3": "audio/mpeg",
+	"aac": "audio/aac",
+}
+
+// transcodeCodecs are the ffmpeg encoder and muxer for each transcode format. AAC goes
+// out as ADTS, which plays while it streams, unlike MP4.
+var transcodeCodecs = map[string][2]string{
+	"mp3": {"libmp3lame", "mp3"},
+	"aac": {"aac", "adts"},
+}
+
+// DefaultTranscodeBitrate suits speech, in kbps
+const DefaultTranscodeBitrate = 64
+
+// TranscodeOptions choose the format and part of the audio to transcode
+type TranscodeOptions struct {
+	Format   string  // mp3 or aac
+	Start    float64 // Seconds into the audio to start from
+	Duration float64 // Seconds to transcode; 0 for the rest
+	Bitrate  int     // kbps; 0 for DefaultTranscodeBitrate
+}
+
+// Transcode streams part of an audio file, transcoded, to w as ffmpeg produces it, so
+// playback starts before the whole file is converted. Seeking starts a new transcode
+// from another point.
+func (a *AudiobookProcessor) Transcode(ctx context.Context, inputPath string, w io.Writer, opts TranscodeOptions) error {
+	if !a.IsAvailable() {
+		return fmt.Errorf("ffmpeg not found")
+	}
+	codec, ok := transcodeCodecs[opts.Format]
+	if !ok {
+		return fmt.Errorf("unsupported transcode format: %s", opts.Format)
+	}
+	bitrate := opts.Bitrate
+	if bitrate <= 0 {
+		bitrate = DefaultTranscodeBitrate
+	}
+
+	// Seeking before the input is fast, and accurate when transcoding
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin"}
+	if opts.Start > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(opts.Start, 'f', 3, 64))
+	}
+	args = append(args, "-i", inputPath)
+	if opts.Duration > 0 {
+		args = append(args, "-t", strconv.FormatFloat(opts.Duration, 'f', 3, 64))
+	}
+	args = append(args,
+		"-map", "0:a:0", "-vn", "-sn",
+		"-c:a", codec[0], "-b:a", strconv.Itoa(bitrate)+"k",
+		"-f", codec[1], "pipe:1",
+	)
+
+	cmd := exec.CommandContext(ctx, a.ffmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("transcode failed: %v - %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
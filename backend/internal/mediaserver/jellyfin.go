@@ -0,0 +1,32 @@
+package mediaserver
+
+import (
+	"context"
+	"net/http"
+)
+
+// Jellyfin reports imported folders to Jellyfin (or Emby), which scans just those paths
+type Jellyfin struct {
+	client
+}
+
+func (j *Jellyfin) Type() string {
+	return "jellyfin"
+}
+
+// Test fetches the server's system info, which requires a valid API key
+func (j *Jellyfin) Test(ctx context.Context) error {
+	return j.do(ctx, "Jellyfin", http.MethodGet, "/System/Info", nil, j.headers(), nil)
+}
+
+// Refresh reports the folder as changed
+func (j *Jellyfin) Refresh(ctx context.Context, folder string) error {
+	body := map[string]interface{}{
+		"Updates": []map[string]string{{"Path": folder, "UpdateType": "Created"}},
+	}
+	return j.do(ctx, "Jellyfin", http.MethodPost, "/Library/Media/Updated", body, j.headers(), nil)
+}
+
+func (j *Jellyfin) headers() map[string]string {
+	return map[string]string{"X-Emby-Token": j.token}
+}
@@ -0,0 +1,28 @@
+package mediaserver
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Kavita asks Kavita to scan the imported folder, authenticating with a user's API key
+type Kavita struct {
+	client
+}
+
+func (k *Kavita) Type() string {
+	return "kavita"
+}
+
+// Test exchanges the API key for a session, which fails for an unknown key
+func (k *Kavita) Test(ctx context.Context) error {
+	path := "/api/Plugin/authenticate?apiKey=" + url.QueryEscape(k.token) + "&pluginName=Shelfarr"
+	return k.do(ctx, "Kavita", http.MethodPost, path, nil, nil, nil)
+}
+
+// Refresh scans the folder in whichever library contains it
+func (k *Kavita) Refresh(ctx context.Context, folder string) error {
+	body := map[string]string{"apiKey": k.token, "folderPath": folder}
+	return k.do(ctx, "Kavita", http.MethodPost, "/api/Library/scan-folder", body, nil, nil)
+}
@@ -0,0 +1,63 @@
+package mediaserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Komga scans the Komga libraries whose root contains the imported folder. Komga can't
+// scan part of a library, but its scans skip unchanged files.
+type Komga struct {
+	client
+}
+
+func (k *Komga) Type() string {
+	return "komga"
+}
+
+type komgaLibrary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Root string `json:"root"`
+}
+
+func (k *Komga) libraries(ctx context.Context) ([]komgaLibrary, error) {
+	var libraries []komgaLibrary
+	err := k.do(ctx, "Komga", http.MethodGet, "/api/v1/libraries", nil, k.headers(), &libraries)
+	return libraries, err
+}
+
+// Test lists the libraries
+func (k *Komga) Test(ctx context.Context) error {
+	_, err := k.libraries(ctx)
+	return err
+}
+
+// Refresh scans each library containing the folder
+func (k *Komga) Refresh(ctx context.Context, folder string) error {
+	libraries, err := k.libraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	refreshed := false
+	for _, library := range libraries {
+		if !inFolder(folder, library.Root) {
+			continue
+		}
+		if err := k.do(ctx, "Komga", http.MethodPost, "/api/v1/libraries/"+url.PathEscape(library.ID)+"/scan", nil, k.headers(), nil); err != nil {
+			return err
+		}
+		refreshed = true
+	}
+	if !refreshed {
+		return fmt.Errorf("no Komga library contains %s", folder)
+	}
+	return nil
+}
+
+func (k *Komga) headers() map[string]string {
+	return map[string]string{"X-API-Key": k.token}
+}
@@ -0,0 +1,100 @@
+// Package mediaserver triggers library scans on media servers (Plex, Jellyfin, Kavita,
+// Komga) so files Shelfarr imports appear there without waiting for a scheduled scan.
+package mediaserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MediaServer is the interface that all media server connections must implement
+type MediaServer interface {
+	// Type returns the server type (plex, jellyfin, kavita, komga)
+	Type() string
+
+	// Refresh scans the server's library for changes under folder, or the whole library
+	// containing it when the server can't scan part of a library
+	Refresh(ctx context.Context, folder string) error
+
+	// Test checks the URL and token
+	Test(ctx context.Context) error
+}
+
+// New creates the connection for a server type, or nil if the type is unknown
+func New(serverType, url, token string) MediaServer {
+	c := client{
+		baseURL:    strings.TrimRight(url, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	switch serverType {
+	case "plex":
+		return &Plex{c}
+	case "jellyfin":
+		return &Jellyfin{c}
+	case "kavita":
+		return &Kavita{c}
+	case "komga":
+		return &Komga{c}
+	default:
+		return nil
+	}
+}
+
+// client holds what every server connection needs
+type client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// do sends a request with the given headers and decodes a JSON response into v, if given
+func (c client) do(ctx context.Context, provider, method, path string, body interface{}, headers map[string]string, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned status %d", provider, resp.StatusCode)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// inFolder reports whether path is root or inside it
+func inFolder(path, root string) bool {
+	path, root = filepath.Clean(path), filepath.Clean(root)
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,69 @@
+package mediaserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Plex refreshes the Plex library section whose folders contain the imported files
+type Plex struct {
+	client
+}
+
+func (p *Plex) Type() string {
+	return "plex"
+}
+
+// plexSection is a Plex library and the folders it is stored in
+type plexSection struct {
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	Location []struct {
+		Path string `json:"path"`
+	} `json:"Location"`
+}
+
+func (p *Plex) sections(ctx context.Context) ([]plexSection, error) {
+	var result struct {
+		MediaContainer struct {
+			Directory []plexSection `json:"Directory"`
+		} `json:"MediaContainer"`
+	}
+	err := p.do(ctx, "Plex", http.MethodGet, "/library/sections", nil, map[string]string{"X-Plex-Token": p.token}, &result)
+	return result.MediaContainer.Directory, err
+}
+
+// Test lists the library sections
+func (p *Plex) Test(ctx context.Context) error {
+	_, err := p.sections(ctx)
+	return err
+}
+
+// Refresh scans just the folder in each section containing it
+func (p *Plex) Refresh(ctx context.Context, folder string) error {
+	sections, err := p.sections(ctx)
+	if err != nil {
+		return err
+	}
+
+	refreshed := false
+	for _, section := range sections {
+		for _, location := range section.Location {
+			if !inFolder(folder, location.Path) {
+				continue
+			}
+			path := fmt.Sprintf("/library/sections/%s/refresh?path=%s", url.PathEscape(section.Key), url.QueryEscape(folder))
+			if err := p.do(ctx, "Plex", http.MethodGet, path, nil, map[string]string{"X-Plex-Token": p.token}, nil); err != nil {
+				return err
+			}
+			refreshed = true
+			break
+		}
+	}
+	if !refreshed {
+		return fmt.Errorf("no Plex library contains %s", folder)
+	}
+	return nil
+}
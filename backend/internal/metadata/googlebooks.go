@@ -0,0 +1,169 @@
+package metadata
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/health"
+)
+
+// GoogleBooksBaseURL is the public Google Books API
+const GoogleBooksBaseURL = "https://www.googleapis.com/books/v1"
+
+// GoogleBooksProvider looks up books on Google Books. IDs are volume IDs. Google Books
+// has no author or series records, so those lookups return ErrNotSupported.
+type GoogleBooksProvider struct {
+	baseURL string
+	apiKey  func() string // Optional; raises the anonymous quota
+	client  *jsonClient
+}
+
+// NewGoogleBooksProvider creates a Google Books provider. apiKey may be nil or return "".
+func NewGoogleBooksProvider(apiKey func() string) *GoogleBooksProvider {
+	return &GoogleBooksProvider{
+		baseURL: GoogleBooksBaseURL,
+		apiKey:  apiKey,
+		client:  newJSONClient("google books", 500*time.Millisecond),
+	}
+}
+
+// SetCircuit stops calls to Google Books while it keeps failing
+func (g *GoogleBooksProvider) SetCircuit(circuit *health.Circuit) {
+	g.client.circuit = circuit
+}
+
+func (g *GoogleBooksProvider) Name() string {
+	return "googlebooks"
+}
+
+type gbVolumes struct {
+	Items []gbVolume `json:"items"`
+}
+
+type gbVolume struct {
+	ID         string `json:"id"`
+	VolumeInfo struct {
+		Title               string   `json:"title"`
+		Subtitle            string   `json:"subtitle"`
+		Authors             []string `json:"authors"`
+		PublishedDate       string   `json:"publishedDate"`
+		Description         string   `json:"description"`
+		IndustryIdentifiers []struct {
+			Type       string `json:"type"`
+			Identifier string `json:"identifier"`
+		} `json:"industryIdentifiers"`
+		PageCount     int      `json:"pageCount"`
+		Categories    []string `json:"categories"`
+		AverageRating float32  `json:"averageRating"`
+		RatingsCount  int      `json:"ratingsCount"`
+		ImageLinks    struct {
+			Thumbnail      string `json:"thumbnail"`
+			SmallThumbnail string `json:"smallThumbnail"`
+		} `json:"imageLinks"`
+		Language string `json:"language"`
+	} `json:"volumeInfo"`
+	AccessInfo struct {
+		Epub struct {
+			IsAvailable bool `json:"isAvailable"`
+		} `json:"epub"`
+		PDF struct {
+			IsAvailable bool `json:"isAvailable"`
+		} `json:"pdf"`
+	} `json:"accessInfo"`
+}
+
+func (g *GoogleBooksProvider) SearchBooks(ctx context.Context, query string, languages []string) ([]Book, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("maxResults", "20")
+	params.Set("printType", "books")
+	if len(languages) == 1 {
+		params.Set("langRestrict", languages[0])
+	}
+
+	var resp gbVolumes
+	if err := g.client.get(ctx, g.url("/volumes", params), &resp); err != nil {
+		return nil, err
+	}
+
+	books := make([]Book, 0, len(resp.Items))
+	for _, v := range resp.Items {
+		books = append(books, g.toBook(v))
+	}
+	return books, nil
+}
+
+func (g *GoogleBooksProvider) GetBook(ctx context.Context, id string) (*Book, error) {
+	var v gbVolume
+	if err := g.client.get(ctx, g.url("/volumes/"+url.PathEscape(id), url.Values{}), &v); err != nil {
+		return nil, err
+	}
+	book := g.toBook(v)
+	return &book, nil
+}
+
+func (g *GoogleBooksProvider) GetAuthor(ctx context.Context, id string) (*Author, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *GoogleBooksProvider) GetSeries(ctx context.Context, id string, languages []string) (*Series, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *GoogleBooksProvider) url(path string, params url.Values) string {
+	if g.apiKey != nil {
+		if key := g.apiKey(); key != "" {
+			params.Set("key", key)
+		}
+	}
+	if len(params) == 0 {
+		return g.baseURL + path
+	}
+	return g.baseURL + path + "?" + params.Encode()
+}
+
+func (g *GoogleBooksProvider) toBook(v gbVolume) Book {
+	info := v.VolumeInfo
+	data := hardcover.BookData{
+		ID:           v.ID,
+		Title:        info.Title,
+		Subtitle:     info.Subtitle,
+		Description:  info.Description,
+		Rating:       info.AverageRating,
+		RatingsCount: info.RatingsCount,
+		ReleaseYear:  parseYear(info.PublishedDate),
+		PageCount:    info.PageCount,
+		Authors:      info.Authors,
+		Genres:       info.Categories,
+		LanguageCode: LanguageCode(info.Language),
+		HasEbook:     v.AccessInfo.Epub.IsAvailable || v.AccessInfo.PDF.IsAvailable,
+		HasPhysical:  true,
+	}
+	if len(info.Authors) > 0 {
+		data.AuthorName = info.Authors[0]
+	}
+	if t, err := time.Parse("2006-01-02", info.PublishedDate); err == nil {
+		data.ReleaseDate = &t
+	}
+	for _, ident := range info.IndustryIdentifiers {
+		switch ident.Type {
+		case "ISBN_10":
+			data.ISBN = ident.Identifier
+		case "ISBN_13":
+			data.ISBN13 = ident.Identifier
+		}
+	}
+
+	cover := info.ImageLinks.Thumbnail
+	if cover == "" {
+		cover = info.ImageLinks.SmallThumbnail
+	}
+	// Thumbnails come back as http with a page-curl overlay
+	cover = strings.Replace(cover, "http://", "https://", 1)
+	data.CoverURL = strings.Replace(cover, "&edge=curl", "", 1)
+
+	return Book{BookData: data, Provider: g.Name()}
+}
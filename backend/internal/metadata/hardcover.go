@@ -0,0 +1,88 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+)
+
+// HardcoverProvider adapts the Hardcover.app client to the Provider interface
+type HardcoverProvider struct {
+	// newClient returns a client with the current API key; settings can change at runtime
+	newClient func() (*hardcover.Client, error)
+}
+
+// NewHardcoverProvider creates a Hardcover provider. newClient is called for each lookup
+func NewHardcoverProvider(newClient func() (*hardcover.Client, error)) *HardcoverProvider {
+	return &HardcoverProvider{newClient: newClient}
+}
+
+func (h *HardcoverProvider) Name() string {
+	return "hardcover"
+}
+
+func (h *HardcoverProvider) SearchBooks(ctx context.Context, query string, languages []string) ([]Book, error) {
+	client, err := h.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := client.SearchBooks(ctx, query, languages)
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]Book, len(results))
+	for i, r := range results {
+		books[i] = Book{BookData: r, Provider: h.Name()}
+	}
+	return books, nil
+}
+
+func (h *HardcoverProvider) GetBook(ctx context.Context, id string) (*Book, error) {
+	client, err := h.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := client.GetBook(ctx, id)
+	if err != nil {
+		return nil, mapHardcoverError(err)
+	}
+	return &Book{BookData: *data, Provider: h.Name()}, nil
+}
+
+func (h *HardcoverProvider) GetAuthor(ctx context.Context, id string) (*Author, error) {
+	client, err := h.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := client.GetAuthor(ctx, id)
+	if err != nil {
+		return nil, mapHardcoverError(err)
+	}
+	return &Author{AuthorData: *data, Provider: h.Name()}, nil
+}
+
+func (h *HardcoverProvider) GetSeries(ctx context.Context, id string, languages []string) (*Series, error) {
+	client, err := h.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := client.GetSeries(ctx, id, languages)
+	if err != nil {
+		return nil, mapHardcoverError(err)
+	}
+	return &Series{FilteredSeriesResult: *data, Provider: h.Name()}, nil
+}
+
+// mapHardcoverError turns the client's not found errors into ErrNotFound
+func mapHardcoverError(err error) error {
+	if errors.Is(err, hardcover.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
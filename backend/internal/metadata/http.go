@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/health"
+	"github.com/shelfarr/shelfarr/internal/retry"
+	"golang.org/x/time/rate"
+)
+
+// jsonClient is a rate-limited HTTP client for the public JSON metadata APIs
+type jsonClient struct {
+	name        string
+	httpClient  *http.Client
+	rateLimiter *rate.Limiter
+	circuit     *health.Circuit
+}
+
+func newJSONClient(name string, interval time.Duration) *jsonClient {
+	return &jsonClient{
+		name: name,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		rateLimiter: rate.NewLimiter(rate.Every(interval), 1),
+	}
+}
+
+// get fetches reqURL and decodes the JSON body into out, retrying rate limits and
+// server errors. A 404 returns ErrNotFound.
+func (c *jsonClient) get(ctx context.Context, reqURL string, out interface{}) error {
+	if err := c.circuit.Allow(); err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	resp, err := retry.Default.Do(ctx, c.httpClient, func() (*http.Request, error) {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", "Shelfarr/1.0")
+		return req, nil
+	})
+	c.circuit.Record(health.ResponseError(resp, err))
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s returned status %d", c.name, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", c.name, err)
+	}
+	return nil
+}
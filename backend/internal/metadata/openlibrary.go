@@ -0,0 +1,388 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+	"github.com/shelfarr/shelfarr/internal/health"
+)
+
+// OpenLibraryBaseURL is the public Open Library API
+const OpenLibraryBaseURL = "https://openlibrary.org"
+
+// OpenLibraryProvider looks up books on Open Library. IDs are work keys ("OL45804W")
+// for books and author keys ("OL23919A") for authors. Open Library has no series data.
+type OpenLibraryProvider struct {
+	baseURL string
+	client  *jsonClient
+}
+
+// NewOpenLibraryProvider creates an Open Library provider. No API key is needed.
+func NewOpenLibraryProvider() *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		baseURL: OpenLibraryBaseURL,
+		// Open Library asks clients to stay around one request per second
+		client: newJSONClient("open library", time.Second),
+	}
+}
+
+// SetCircuit stops calls to Open Library while it keeps failing
+func (o *OpenLibraryProvider) SetCircuit(circuit *health.Circuit) {
+	o.client.circuit = circuit
+}
+
+func (o *OpenLibraryProvider) Name() string {
+	return "openlibrary"
+}
+
+type olSearchResponse struct {
+	Docs []olSearchDoc `json:"docs"`
+}
+
+type olSearchDoc struct {
+	Key              string   `json:"key"`
+	Title            string   `json:"title"`
+	Subtitle         string   `json:"subtitle"`
+	AuthorName       []string `json:"author_name"`
+	AuthorKey        []string `json:"author_key"`
+	FirstPublishYear int      `json:"first_publish_year"`
+	ISBN             []string `json:"isbn"`
+	CoverID          int      `json:"cover_i"`
+	PagesMedian      int      `json:"number_of_pages_median"`
+	RatingsAverage   float32  `json:"ratings_average"`
+	RatingsCount     int      `json:"ratings_count"`
+	Language         []string `json:"language"`
+	Subject          []string `json:"subject"`
+	EditionCount     int      `json:"edition_count"`
+	EbookAccess      string   `json:"ebook_access"`
+	CoverEditionKey  string   `json:"cover_edition_key"`
+	GoodreadsIDs     []string `json:"id_goodreads"`
+	AmazonIDs        []string `json:"id_amazon"`
+}
+
+const olSearchFields = "key,title,subtitle,author_name,author_key,first_publish_year,isbn,cover_i," +
+	"number_of_pages_median,ratings_average,ratings_count,language,subject,edition_count,ebook_access," +
+	"cover_edition_key,id_goodreads,id_amazon"
+
+func (o *OpenLibraryProvider) SearchBooks(ctx context.Context, query string, languages []string) ([]Book, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", "20")
+	params.Set("fields", olSearchFields)
+	if len(languages) > 0 {
+		params.Set("lang", languages[0])
+	}
+
+	var resp olSearchResponse
+	if err := o.client.get(ctx, o.baseURL+"/search.json?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	books := make([]Book, 0, len(resp.Docs))
+	for _, doc := range resp.Docs {
+		data := hardcover.BookData{
+			ID:           strings.TrimPrefix(doc.Key, "/works/"),
+			Title:        doc.Title,
+			Subtitle:     doc.Subtitle,
+			Rating:       doc.RatingsAverage,
+			RatingsCount: doc.RatingsCount,
+			ReleaseYear:  doc.FirstPublishYear,
+			PageCount:    doc.PagesMedian,
+			Authors:      doc.AuthorName,
+			Genres:       limitStrings(doc.Subject, 5),
+			EditionCount: doc.EditionCount,
+			HasPhysical:  doc.EditionCount > 0,
+			HasEbook:     doc.EbookAccess == "public" || doc.EbookAccess == "borrowable",
+		}
+		if len(doc.AuthorName) > 0 {
+			data.AuthorName = doc.AuthorName[0]
+		}
+		if len(doc.AuthorKey) > 0 {
+			data.AuthorID = doc.AuthorKey[0]
+		}
+		if doc.CoverID > 0 {
+			data.CoverURL = olCoverURL("b", doc.CoverID)
+		}
+		data.ISBN, data.ISBN13 = splitISBNs(doc.ISBN)
+		if len(doc.Language) > 0 {
+			data.LanguageCode = LanguageCode(doc.Language[0])
+		}
+
+		ids := make(map[string]string)
+		if doc.CoverEditionKey != "" {
+			ids["ol_edition_id"] = doc.CoverEditionKey
+		}
+		if len(doc.GoodreadsIDs) > 0 {
+			ids["goodreads_id"] = doc.GoodreadsIDs[0]
+		}
+		if asin := firstASIN(doc.AmazonIDs); asin != "" {
+			ids["asin"] = asin
+		}
+
+		books = append(books, Book{BookData: data, Provider: o.Name(), Identifiers: ids})
+	}
+	return books, nil
+}
+
+type olKey struct {
+	Key string `json:"key"`
+}
+
+type olWork struct {
+	Title            string          `json:"title"`
+	Subtitle         string          `json:"subtitle"`
+	Description      json.RawMessage `json:"description"`
+	Covers           []int           `json:"covers"`
+	Subjects         []string        `json:"subjects"`
+	FirstPublishDate string          `json:"first_publish_date"`
+	Authors          []struct {
+		Author olKey `json:"author"`
+	} `json:"authors"`
+}
+
+type olEditions struct {
+	Entries []struct {
+		Key           string   `json:"key"`
+		ISBN10        []string `json:"isbn_10"`
+		ISBN13        []string `json:"isbn_13"`
+		NumberOfPages int      `json:"number_of_pages"`
+		Languages     []olKey  `json:"languages"`
+		Covers        []int    `json:"covers"`
+		Identifiers   struct {
+			Goodreads []string `json:"goodreads"`
+			Amazon    []string `json:"amazon"`
+		} `json:"identifiers"`
+	} `json:"entries"`
+}
+
+type olRatings struct {
+	Summary struct {
+		Average float32 `json:"average"`
+		Count   int     `json:"count"`
+	} `json:"summary"`
+}
+
+type olAuthor struct {
+	Key       string          `json:"key"`
+	Name      string          `json:"name"`
+	Bio       json.RawMessage `json:"bio"`
+	Photos    []int           `json:"photos"`
+	BirthDate string          `json:"birth_date"`
+	DeathDate string          `json:"death_date"`
+}
+
+func (o *OpenLibraryProvider) GetBook(ctx context.Context, id string) (*Book, error) {
+	id = strings.TrimPrefix(id, "/works/")
+
+	var work olWork
+	if err := o.client.get(ctx, o.baseURL+"/works/"+url.PathEscape(id)+".json", &work); err != nil {
+		return nil, err
+	}
+
+	data := hardcover.BookData{
+		ID:          id,
+		Title:       work.Title,
+		Subtitle:    work.Subtitle,
+		Description: olText(work.Description),
+		ReleaseYear: parseYear(work.FirstPublishDate),
+		Genres:      limitStrings(work.Subjects, 5),
+		HasPhysical: true,
+	}
+	if len(work.Covers) > 0 && work.Covers[0] > 0 {
+		data.CoverURL = olCoverURL("b", work.Covers[0])
+	}
+
+	for i, a := range work.Authors {
+		var author olAuthor
+		if err := o.client.get(ctx, o.baseURL+a.Author.Key+".json", &author); err != nil {
+			continue
+		}
+		data.Authors = append(data.Authors, author.Name)
+		if i == 0 {
+			data.AuthorID = strings.TrimPrefix(author.Key, "/authors/")
+			data.AuthorName = author.Name
+			if len(author.Photos) > 0 && author.Photos[0] > 0 {
+				data.AuthorImage = olCoverURL("a", author.Photos[0])
+			}
+		}
+	}
+
+	ids := make(map[string]string)
+
+	// Editions fill in the identifiers, page count and language the work record lacks
+	var editions olEditions
+	if err := o.client.get(ctx, o.baseURL+"/works/"+url.PathEscape(id)+"/editions.json?limit=50", &editions); err == nil {
+		data.EditionCount = len(editions.Entries)
+		for _, e := range editions.Entries {
+			if data.ISBN13 == "" && len(e.ISBN13) > 0 {
+				data.ISBN13 = e.ISBN13[0]
+			}
+			if data.ISBN == "" && len(e.ISBN10) > 0 {
+				data.ISBN = e.ISBN10[0]
+			}
+			if data.PageCount == 0 {
+				data.PageCount = e.NumberOfPages
+			}
+			if data.LanguageCode == "" && len(e.Languages) > 0 {
+				data.LanguageCode = LanguageCode(strings.TrimPrefix(e.Languages[0].Key, "/languages/"))
+			}
+			if data.CoverURL == "" && len(e.Covers) > 0 && e.Covers[0] > 0 {
+				data.CoverURL = olCoverURL("b", e.Covers[0])
+			}
+			if _, ok := ids["ol_edition_id"]; !ok && e.Key != "" {
+				ids["ol_edition_id"] = strings.TrimPrefix(e.Key, "/books/")
+			}
+			if _, ok := ids["goodreads_id"]; !ok && len(e.Identifiers.Goodreads) > 0 {
+				ids["goodreads_id"] = e.Identifiers.Goodreads[0]
+			}
+			if _, ok := ids["asin"]; !ok {
+				if asin := firstASIN(e.Identifiers.Amazon); asin != "" {
+					ids["asin"] = asin
+				}
+			}
+		}
+	}
+
+	var ratings olRatings
+	if err := o.client.get(ctx, o.baseURL+"/works/"+url.PathEscape(id)+"/ratings.json", &ratings); err == nil {
+		data.Rating = ratings.Summary.Average
+		data.RatingsCount = ratings.Summary.Count
+	}
+
+	return &Book{BookData: data, Provider: o.Name(), Identifiers: ids}, nil
+}
+
+func (o *OpenLibraryProvider) GetAuthor(ctx context.Context, id string) (*Author, error) {
+	id = strings.TrimPrefix(id, "/authors/")
+
+	var author olAuthor
+	if err := o.client.get(ctx, o.baseURL+"/authors/"+url.PathEscape(id)+".json", &author); err != nil {
+		return nil, err
+	}
+
+	data := hardcover.AuthorData{
+		ID:        id,
+		Name:      author.Name,
+		Biography: olText(author.Bio),
+	}
+	if len(author.Photos) > 0 && author.Photos[0] > 0 {
+		data.ImageURL = olCoverURL("a", author.Photos[0])
+	}
+	if year := parseYear(author.BirthDate); year > 0 {
+		data.BornYear = &year
+	}
+	if year := parseYear(author.DeathDate); year > 0 {
+		data.DeathYear = &year
+	}
+
+	var works struct {
+		Size int `json:"size"`
+	}
+	if err := o.client.get(ctx, o.baseURL+"/authors/"+url.PathEscape(id)+"/works.json?limit=1", &works); err == nil {
+		data.BooksCount = works.Size
+		data.TotalBooksAll = works.Size
+	}
+
+	return &Author{AuthorData: data, Provider: o.Name()}, nil
+}
+
+func (o *OpenLibraryProvider) GetSeries(ctx context.Context, id string, languages []string) (*Series, error) {
+	return nil, ErrNotSupported
+}
+
+func olCoverURL(kind string, id int) string {
+	return fmt.Sprintf("https://covers.openlibrary.org/%s/id/%d-L.jpg", kind, id)
+}
+
+// olText reads Open Library text fields, which are either a string or {"type", "value"}
+func olText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var typed struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &typed); err == nil {
+		return typed.Value
+	}
+	return ""
+}
+
+var yearPattern = regexp.MustCompile(`\b(\d{4})\b`)
+
+// parseYear extracts the year from free-form dates like "1954", "July 29, 1954" or "2001-05-01"
+func parseYear(date string) int {
+	m := yearPattern.FindStringSubmatch(date)
+	if m == nil {
+		return 0
+	}
+	year, _ := strconv.Atoi(m[1])
+	return year
+}
+
+// splitISBNs returns the first ISBN-10 and ISBN-13 from a mixed list
+func splitISBNs(isbns []string) (isbn10, isbn13 string) {
+	for _, isbn := range isbns {
+		switch len(isbn) {
+		case 10:
+			if isbn10 == "" {
+				isbn10 = isbn
+			}
+		case 13:
+			if isbn13 == "" {
+				isbn13 = isbn
+			}
+		}
+	}
+	return isbn10, isbn13
+}
+
+// firstASIN returns the first Amazon-native ID. Open Library's Amazon IDs are often
+// ISBN-10s, which are already covered by the ISBN columns.
+func firstASIN(ids []string) string {
+	for _, id := range ids {
+		if len(id) == 10 && strings.HasPrefix(id, "B") {
+			return id
+		}
+	}
+	return ""
+}
+
+func limitStrings(values []string, n int) []string {
+	if len(values) > n {
+		return values[:n]
+	}
+	return values
+}
+
+// marcLanguages maps the MARC language codes used by Open Library to ISO 639-1, along
+// with the ISO 639-2 terminology codes Calibre uses where they differ
+var marcLanguages = map[string]string{
+	"eng": "en", "spa": "es", "fre": "fr", "ger": "de", "ita": "it", "por": "pt",
+	"dut": "nl", "swe": "sv", "nor": "no", "dan": "da", "fin": "fi", "pol": "pl",
+	"rus": "ru", "jpn": "ja", "chi": "zh", "kor": "ko", "ara": "ar", "tur": "tr",
+	"fra": "fr", "deu": "de", "nld": "nl", "zho": "zh",
+}
+
+// LanguageCode converts a two- or three-letter language code to ISO 639-1, returning
+// "" for codes it doesn't know
+func LanguageCode(code string) string {
+	if iso, ok := marcLanguages[code]; ok {
+		return iso
+	}
+	if len(code) == 2 {
+		return code
+	}
+	return ""
+}
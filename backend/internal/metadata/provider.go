@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shelfarr/shelfarr/internal/hardcover"
+)
+
+var (
+	// ErrNotFound is returned when a provider has no record for an ID
+	ErrNotFound = errors.New("not found")
+	// ErrNotSupported is returned when a provider can't perform a lookup (e.g. no series data)
+	ErrNotSupported = errors.New("not supported by provider")
+	// ErrNoProviders is returned when every provider is disabled
+	ErrNoProviders = errors.New("no metadata providers enabled")
+)
+
+// Book is a book record from a provider. The fields reuse Hardcover's shape, the richest
+// of the providers, so results from any provider flow through the existing sync code.
+type Book struct {
+	hardcover.BookData
+	Provider string // Provider that supplied the record; ID is in its namespace
+
+	// Identifiers are other IDs the provider knows for the book, keyed by
+	// book_identifiers column ("goodreads_id", "ol_edition_id")
+	Identifiers map[string]string
+}
+
+// Author is an author record from a provider
+type Author struct {
+	hardcover.AuthorData
+	Provider string
+}
+
+// Series is a series with its books from a provider
+type Series struct {
+	hardcover.FilteredSeriesResult
+	Provider string
+}
+
+// Provider is a source of book metadata
+type Provider interface {
+	// Name returns the provider's key, used in settings ("hardcover", "openlibrary")
+	Name() string
+	SearchBooks(ctx context.Context, query string, languages []string) ([]Book, error)
+	GetBook(ctx context.Context, id string) (*Book, error)
+	GetAuthor(ctx context.Context, id string) (*Author, error)
+	GetSeries(ctx context.Context, id string, languages []string) (*Series, error)
+}
+
+// ProviderSettings controls whether and in what order a provider is used
+type ProviderSettings struct {
+	Enabled  bool
+	Priority int // Lower runs first and wins field conflicts when merging
+}
+
+// IdentifierColumn returns the book_identifiers column holding a provider's book IDs
+func IdentifierColumn(provider string) string {
+	switch provider {
+	case "hardcover":
+		return "hardcover_id"
+	case "openlibrary":
+		return "ol_work_id"
+	case "googlebooks":
+		return "google_volume_id"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,356 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shelfarr/shelfarr/internal/logging"
+)
+
+var logger = logging.Component("metadata")
+
+// Registry holds the registered providers and their settings
+type Registry struct {
+	providers map[string]Provider
+	settings  map[string]ProviderSettings
+	order     []string // Registration order, used to break priority ties
+	mutex     sync.RWMutex
+}
+
+// ProviderInfo describes a registered provider and its settings
+type ProviderInfo struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Priority int    `json:"priority"`
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[string]Provider),
+		settings:  make(map[string]ProviderSettings),
+	}
+}
+
+// Register adds a provider with its default settings
+func (r *Registry) Register(p Provider, defaults ProviderSettings) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.providers[p.Name()]; !exists {
+		r.order = append(r.order, p.Name())
+	}
+	r.providers[p.Name()] = p
+	r.settings[p.Name()] = defaults
+}
+
+// Configure updates a registered provider's settings
+func (r *Registry) Configure(name string, settings ProviderSettings) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("unknown metadata provider: %s", name)
+	}
+	r.settings[name] = settings
+	return nil
+}
+
+// Get returns a provider by name, whether or not it is enabled
+func (r *Registry) Get(name string) (Provider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Info returns every registered provider with its settings, in priority order
+func (r *Registry) Info() []ProviderInfo {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	infos := make([]ProviderInfo, 0, len(r.order))
+	for _, name := range r.sortedNames() {
+		s := r.settings[name]
+		infos = append(infos, ProviderInfo{Name: name, Enabled: s.Enabled, Priority: s.Priority})
+	}
+	return infos
+}
+
+// Enabled returns the enabled providers in priority order
+func (r *Registry) Enabled() []Provider {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var providers []Provider
+	for _, name := range r.sortedNames() {
+		if r.settings[name].Enabled {
+			providers = append(providers, r.providers[name])
+		}
+	}
+	return providers
+}
+
+// sortedNames returns provider names by priority, then registration order. Callers hold the lock.
+func (r *Registry) sortedNames() []string {
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	sort.SliceStable(names, func(i, j int) bool {
+		return r.settings[names[i]].Priority < r.settings[names[j]].Priority
+	})
+	return names
+}
+
+// SearchBooks walks the enabled providers in priority order and returns the results of
+// the first one that finds anything. A provider that fails (missing API key, outage) or
+// comes back empty falls through to the next. Providers named in exclude are skipped.
+// Fails only if every provider fails.
+func (r *Registry) SearchBooks(ctx context.Context, query string, languages []string, exclude ...string) ([]Book, error) {
+	var providers []Provider
+	for _, p := range r.Enabled() {
+		if !slices.Contains(exclude, p.Name()) {
+			providers = append(providers, p)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var errs []error
+	succeeded := 0
+	for _, p := range providers {
+		books, err := p.SearchBooks(ctx, query, languages)
+		if err != nil {
+			logger.Debug("Metadata provider search failed", "provider", p.Name(), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		succeeded++
+		if len(books) > 0 {
+			return dedupeBooks(books), nil
+		}
+		logger.Debug("Metadata provider found nothing, trying next", "provider", p.Name(), "query", query)
+	}
+
+	if succeeded == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return []Book{}, nil
+}
+
+// GetBook fetches a book from a specific provider, since IDs are provider-specific
+func (r *Registry) GetBook(ctx context.Context, provider, id string) (*Book, error) {
+	p, ok := r.Get(provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown metadata provider: %s", provider)
+	}
+	return p.GetBook(ctx, id)
+}
+
+// Enrich fills fields missing from book using the other enabled providers, in priority
+// order. Matches are found by ISBN, or by title and author when there is none.
+func (r *Registry) Enrich(ctx context.Context, book *Book) {
+	query := book.ISBN13
+	if query == "" {
+		query = book.ISBN
+	}
+	if query != "" {
+		query = "isbn:" + query
+	} else {
+		query = strings.TrimSpace(book.Title + " " + book.AuthorName)
+	}
+	if query == "" {
+		return
+	}
+
+	wanted := make(map[string]bool)
+	for _, key := range bookKeys(book) {
+		wanted[key] = true
+	}
+
+	for _, p := range r.Enabled() {
+		if p.Name() == book.Provider || !missingFields(book) {
+			continue
+		}
+		results, err := p.SearchBooks(ctx, query, nil)
+		if err != nil {
+			logger.Debug("Metadata provider enrich lookup failed", "provider", p.Name(), "error", err)
+			continue
+		}
+		for i := range results {
+			if matchesAny(&results[i], wanted) {
+				MergeBook(book, &results[i])
+				break
+			}
+		}
+	}
+}
+
+// missingFields reports whether a book lacks any of the fields worth a fallback lookup
+func missingFields(b *Book) bool {
+	return b.Description == "" || b.CoverURL == "" || b.PageCount == 0 ||
+		b.ISBN13 == "" || b.ReleaseYear == 0 || len(b.Genres) == 0
+}
+
+// FindMatch returns the first result that is the book with the given title, author and
+// ISBN, matched the same way results are merged across providers. Returns nil if none is.
+func FindMatch(results []Book, title, author, isbn string) *Book {
+	want := Book{}
+	want.Title = title
+	want.AuthorName = author
+	if len(isbn) == 13 {
+		want.ISBN13 = isbn
+	} else {
+		want.ISBN = isbn
+	}
+
+	wanted := make(map[string]bool)
+	for _, key := range bookKeys(&want) {
+		wanted[key] = true
+	}
+	for i := range results {
+		if matchesAny(&results[i], wanted) {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+func matchesAny(b *Book, keys map[string]bool) bool {
+	for _, key := range bookKeys(b) {
+		if keys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeBooks merges results that describe the same book (same ISBN, or same title
+// and author), keeping the first occurrence's ID
+func dedupeBooks(books []Book) []Book {
+	var merged []Book
+	index := make(map[string]int)
+	for _, book := range books {
+		keys := bookKeys(&book)
+		existing := -1
+		for _, key := range keys {
+			if i, ok := index[key]; ok {
+				existing = i
+				break
+			}
+		}
+
+		if existing >= 0 {
+			MergeBook(&merged[existing], &book)
+		} else {
+			existing = len(merged)
+			merged = append(merged, book)
+		}
+		for _, key := range keys {
+			if _, ok := index[key]; !ok {
+				index[key] = existing
+			}
+		}
+	}
+	return merged
+}
+
+// bookKeys returns the identity keys used to match the same book across providers
+func bookKeys(b *Book) []string {
+	var keys []string
+	if b.ISBN13 != "" {
+		keys = append(keys, "isbn13:"+b.ISBN13)
+	}
+	if b.ISBN != "" {
+		keys = append(keys, "isbn:"+b.ISBN)
+	}
+	if title := normalizeKey(b.Title); title != "" {
+		keys = append(keys, "title:"+title+"|"+normalizeKey(b.AuthorName))
+	}
+	return keys
+}
+
+func normalizeKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r > 127 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// MergeBook fills fields that are empty in dst from src. dst keeps its provider and ID;
+// call it with the higher-priority record as dst.
+func MergeBook(dst, src *Book) {
+	d, s := &dst.BookData, &src.BookData
+
+	fillString := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fillInt := func(dst *int, src int) {
+		if *dst == 0 {
+			*dst = src
+		}
+	}
+
+	fillString(&d.Title, s.Title)
+	fillString(&d.SortTitle, s.SortTitle)
+	fillString(&d.Subtitle, s.Subtitle)
+	fillString(&d.ISBN, s.ISBN)
+	fillString(&d.ISBN13, s.ISBN13)
+	fillString(&d.Description, s.Description)
+	fillString(&d.CoverURL, s.CoverURL)
+	fillString(&d.AuthorName, s.AuthorName)
+	fillString(&d.AuthorImage, s.AuthorImage)
+	fillString(&d.SeriesName, s.SeriesName)
+	fillString(&d.LanguageCode, s.LanguageCode)
+	fillString(&d.Language, s.Language)
+	fillInt(&d.PageCount, s.PageCount)
+	fillInt(&d.ReleaseYear, s.ReleaseYear)
+	fillInt(&d.AudioDuration, s.AudioDuration)
+
+	if d.Rating == 0 {
+		d.Rating = s.Rating
+		d.RatingsCount = s.RatingsCount
+	}
+	if d.ReleaseDate == nil {
+		d.ReleaseDate = s.ReleaseDate
+	}
+	if d.SeriesIndex == nil {
+		d.SeriesIndex = s.SeriesIndex
+	}
+	if len(d.Authors) == 0 {
+		d.Authors = s.Authors
+	}
+	if len(d.Genres) == 0 {
+		d.Genres = s.Genres
+	}
+
+	for column, value := range src.Identifiers {
+		if dst.Identifiers == nil {
+			dst.Identifiers = make(map[string]string)
+		}
+		if _, ok := dst.Identifiers[column]; !ok {
+			dst.Identifiers[column] = value
+		}
+	}
+	// A merged record from another provider brings its own ID along
+	if column := IdentifierColumn(src.Provider); column != "" && src.Provider != dst.Provider {
+		if dst.Identifiers == nil {
+			dst.Identifiers = make(map[string]string)
+		}
+		dst.Identifiers[column] = src.ID
+	}
+
+	d.HasEbook = d.HasEbook || s.HasEbook
+	d.HasAudiobook = d.HasAudiobook || s.HasAudiobook
+	d.HasPhysical = d.HasPhysical || s.HasPhysical
+}
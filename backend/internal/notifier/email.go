@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the outgoing mail server settings
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	UseTLS   bool // Implicit TLS (port 465); otherwise STARTTLS is used when offered
+}
+
+// Valid reports whether enough of the config is set to attempt delivery
+func (c SMTPConfig) Valid() bool {
+	return c.Host != "" && c.Port > 0 && c.From != ""
+}
+
+// EmailNotifier sends notifications as plain-text emails over SMTP
+type EmailNotifier struct {
+	config SMTPConfig
+	to     []string
+}
+
+// NewEmailNotifier creates a new email notifier. to may contain several comma-separated addresses
+func NewEmailNotifier(config SMTPConfig, to string) *EmailNotifier {
+	var recipients []string
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	return &EmailNotifier{config: config, to: recipients}
+}
+
+func (e *EmailNotifier) Type() string {
+	return "email"
+}
+
+// Test sends a test email
+func (e *EmailNotifier) Test(ctx context.Context) error {
+	return e.Send(ctx, Message{Event: EventTest})
+}
+
+// Send delivers the message to every recipient
+func (e *EmailNotifier) Send(ctx context.Context, msg Message) error {
+	if len(e.to) == 0 {
+		return fmt.Errorf("no email recipients configured")
+	}
+
+	subject, body := plainText(msg)
+	return SendMail(ctx, e.config, e.to, subject, body)
+}
+
+// SendMail sends a plain-text email through the configured SMTP server
+func SendMail(ctx context.Context, config SMTPConfig, to []string, subject, body string) error {
+	if !config.Valid() {
+		return fmt.Errorf("SMTP server is not configured")
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + config.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	return deliver(ctx, config, to, []byte(msg.String()))
+}
+
+// deliver opens an SMTP session, authenticates and submits a prepared message
+func deliver(ctx context.Context, config SMTPConfig, to []string, message []byte) error {
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if config.UseTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: config.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP client creation failed: %w", err)
+	}
+	defer client.Close()
+
+	if !config.UseTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: config.Host}); err != nil {
+				return fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		}
+	}
+
+	if config.Username != "" && config.Password != "" {
+		auth := smtp.PlainAuth("", config.Username, config.Password, config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(config.From); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA command failed: %w", err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close failed: %w", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,119 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+// EventType identifies what happened to trigger a notification
+type EventType string
+
+const (
+	EventTest     EventType = "test"
+	EventGrab     EventType = "grab"
+	EventDownload EventType = "download"
+	EventUpgrade  EventType = "upgrade"
+	EventImport   EventType = "import"
+	EventDelete   EventType = "delete"
+	EventHealth   EventType = "health"
+	EventRelease  EventType = "release"
+	EventFailure  EventType = "failure"
+	EventDigest   EventType = "digest"
+	EventUpdate   EventType = "update"
+	EventRequest  EventType = "request"
+)
+
+// Message is a provider-agnostic notification payload
+type Message struct {
+	Event   EventType
+	Title   string // Book title or short subject line
+	Author  string
+	Message string // Optional free-form text, overrides templated bodies
+	Data    map[string]interface{}
+}
+
+// Notifier is the interface that all notification providers must implement
+type Notifier interface {
+	// Type returns the provider type (webhook, discord, telegram, ...)
+	Type() string
+
+	// Send delivers a message
+	Send(ctx context.Context, msg Message) error
+
+	// Test sends a test message to verify the configuration
+	Test(ctx context.Context) error
+}
+
+// MessageFromData builds a Message from the loosely typed event data used by callers
+func MessageFromData(event EventType, data map[string]interface{}) Message {
+	msg := Message{Event: event, Data: data}
+	if title, ok := data["title"].(string); ok {
+		msg.Title = title
+	}
+	if author, ok := data["author"].(string); ok {
+		msg.Author = author
+	}
+	if text, ok := data["message"].(string); ok {
+		msg.Message = text
+	}
+	return msg
+}
+
+// checkStatus converts an HTTP status code into an error for a provider
+func checkStatus(provider string, statusCode int) error {
+	if statusCode >= 400 {
+		return fmt.Errorf("%s returned status %d", provider, statusCode)
+	}
+	return nil
+}
+
+// plainText returns a title and body for providers without rich formatting
+func plainText(msg Message) (string, string) {
+	if msg.Event == EventTest {
+		return "Shelfarr Test", "If you see this message, notifications are working!"
+	}
+
+	var heading string
+	switch msg.Event {
+	case EventGrab:
+		heading = "Download started"
+	case EventDownload:
+		heading = "Download completed"
+	case EventUpgrade:
+		heading = "Upgraded"
+	case EventImport:
+		heading = "Imported to library"
+	case EventDelete:
+		heading = "Deleted"
+	case EventHealth:
+		heading = "Health issue"
+	case EventRelease:
+		heading = "Now available"
+	case EventFailure:
+		heading = "Failed"
+	case EventDigest:
+		heading = "New in your library"
+	case EventUpdate:
+		heading = "Update available"
+	case EventRequest:
+		heading = "New request"
+	default:
+		heading = "Shelfarr"
+	}
+
+	body := msg.Title
+	if msg.Author != "" {
+		body += " by " + msg.Author
+	}
+	if msg.Message != "" {
+		if body != "" {
+			body += "\n\n"
+		}
+		body += msg.Message
+	}
+	if body == "" {
+		body = fmt.Sprintf("Event: %s", msg.Event)
+	}
+
+	return "Shelfarr: " + heading, body
+}
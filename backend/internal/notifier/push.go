@@ -0,0 +1,175 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NtfyNotifier publishes messages to an ntfy topic
+type NtfyNotifier struct {
+	serverURL  string
+	topic      string
+	token      string
+	httpClient *http.Client
+}
+
+// NewNtfyNotifier creates a new ntfy notifier. serverURL defaults to https://ntfy.sh
+func NewNtfyNotifier(serverURL, topic, token string) *NtfyNotifier {
+	if serverURL == "" {
+		serverURL = "https://ntfy.sh"
+	}
+	return &NtfyNotifier{
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		topic:     topic,
+		token:     token,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (n *NtfyNotifier) Type() string {
+	return "ntfy"
+}
+
+// Test sends a test message to the topic
+func (n *NtfyNotifier) Test(ctx context.Context) error {
+	return n.Send(ctx, Message{Event: EventTest})
+}
+
+// Send publishes the message as a JSON object to the server root
+func (n *NtfyNotifier) Send(ctx context.Context, msg Message) error {
+	if n.topic == "" {
+		return fmt.Errorf("ntfy topic is required")
+	}
+
+	title, body := plainText(msg)
+	payload := map[string]interface{}{
+		"topic":   n.topic,
+		"title":   title,
+		"message": body,
+		"tags":    []string{"books"},
+	}
+	if msg.Event == EventHealth {
+		payload["priority"] = 4
+	}
+
+	headers := map[string]string{}
+	if n.token != "" {
+		headers["Authorization"] = "Bearer " + n.token
+	}
+
+	return postJSONWithHeaders(ctx, n.httpClient, "ntfy", n.serverURL, payload, headers)
+}
+
+// GotifyNotifier sends messages to a Gotify server
+type GotifyNotifier struct {
+	serverURL  string
+	appToken   string
+	httpClient *http.Client
+}
+
+// NewGotifyNotifier creates a new Gotify notifier
+func NewGotifyNotifier(serverURL, appToken string) *GotifyNotifier {
+	return &GotifyNotifier{
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		appToken:  appToken,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (g *GotifyNotifier) Type() string {
+	return "gotify"
+}
+
+// Test sends a test message
+func (g *GotifyNotifier) Test(ctx context.Context) error {
+	return g.Send(ctx, Message{Event: EventTest})
+}
+
+// Send posts the message using the application token
+func (g *GotifyNotifier) Send(ctx context.Context, msg Message) error {
+	if g.serverURL == "" || g.appToken == "" {
+		return fmt.Errorf("gotify server URL and application token are required")
+	}
+
+	title, body := plainText(msg)
+	priority := 5
+	if msg.Event == EventHealth {
+		priority = 8
+	}
+	payload := map[string]interface{}{
+		"title":    title,
+		"message":  body,
+		"priority": priority,
+	}
+
+	headers := map[string]string{"X-Gotify-Key": g.appToken}
+	return postJSONWithHeaders(ctx, g.httpClient, "Gotify", g.serverURL+"/message", payload, headers)
+}
+
+// PushoverNotifier sends messages through the Pushover API
+type PushoverNotifier struct {
+	apiURL     string
+	userKey    string
+	appToken   string
+	httpClient *http.Client
+}
+
+// NewPushoverNotifier creates a new Pushover notifier
+func NewPushoverNotifier(userKey, appToken string) *PushoverNotifier {
+	return &PushoverNotifier{
+		apiURL:   "https://api.pushover.net/1/messages.json",
+		userKey:  userKey,
+		appToken: appToken,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (p *PushoverNotifier) Type() string {
+	return "pushover"
+}
+
+// Test sends a test message
+func (p *PushoverNotifier) Test(ctx context.Context) error {
+	return p.Send(ctx, Message{Event: EventTest})
+}
+
+// Send posts the message as a form to the Pushover API
+func (p *PushoverNotifier) Send(ctx context.Context, msg Message) error {
+	if p.userKey == "" || p.appToken == "" {
+		return fmt.Errorf("pushover user key and application token are required")
+	}
+
+	title, body := plainText(msg)
+	form := url.Values{}
+	form.Set("token", p.appToken)
+	form.Set("user", p.userKey)
+	form.Set("title", title)
+	form.Set("message", body)
+	if msg.Event == EventHealth {
+		form.Set("priority", "1")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Pushover notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return checkStatus("Pushover", resp.StatusCode)
+}
@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ScriptTimeout is how long a custom script may run before it's stopped
+const ScriptTimeout = 5 * time.Minute
+
+// ScriptNotifier runs a custom script for each event, so users can plug in their own
+// post-processing. The event is passed in environment variables: SHELFARR_EVENT_TYPE,
+// SHELFARR_MESSAGE and one variable per event data key, such as SHELFARR_TITLE,
+// SHELFARR_AUTHOR, SHELFARR_BOOK_ID, SHELFARR_PATH, SHELFARR_SOURCE_PATH,
+// SHELFARR_FORMAT and SHELFARR_MEDIA_TYPE.
+//
+// Scripts run as the server, so only those in the scripts folder, which whoever runs
+// Shelfarr sets up, are run.
+type ScriptNotifier struct {
+	path   string
+	folder string
+}
+
+// NewScriptNotifier creates a new custom script notifier for a script in folder
+func NewScriptNotifier(path, folder string) *ScriptNotifier {
+	return &ScriptNotifier{path: path, folder: folder}
+}
+
+// ResolveScript returns where a script is, following symlinks, failing unless it's in
+// folder. Relative paths are taken from folder.
+func ResolveScript(path, folder string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("script path is not set")
+	}
+	if folder == "" {
+		return "", fmt.Errorf("scripts folder is not set")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(folder, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("script not found: %w", err)
+	}
+	root, err := filepath.EvalSymlinks(folder)
+	if err != nil {
+		return "", fmt.Errorf("scripts folder not found: %w", err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("script must be in %s", folder)
+	}
+	return resolved, nil
+}
+
+func (s *ScriptNotifier) Type() string {
+	return "script"
+}
+
+// Test runs the script with the test event
+func (s *ScriptNotifier) Test(ctx context.Context) error {
+	return s.Send(ctx, Message{Event: EventTest, Message: "Test notification from Shelfarr"})
+}
+
+// Send runs the script with the event in its environment, failing if it exits non-zero
+func (s *ScriptNotifier) Send(ctx context.Context, msg Message) error {
+	path, err := ResolveScript(s.path, s.folder)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = append(os.Environ(), scriptEnv(msg)...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		text := strings.TrimSpace(output.String())
+		if len(text) > 500 {
+			text = "..." + text[len(text)-500:]
+		}
+		if text != "" {
+			return fmt.Errorf("script failed: %w: %s", err, text)
+		}
+		return fmt.Errorf("script failed: %w", err)
+	}
+	return nil
+}
+
+// scriptEnv returns the environment variables describing an event
+func scriptEnv(msg Message) []string {
+	env := []string{"SHELFARR_EVENT_TYPE=" + string(msg.Event)}
+	if msg.Message != "" {
+		env = append(env, "SHELFARR_MESSAGE="+msg.Message)
+	}
+
+	keys := make([]string, 0, len(msg.Data))
+	for key := range msg.Data {
+		if key != "message" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value := msg.Data[key]; value != nil {
+			env = append(env, fmt.Sprintf("SHELFARR_%s=%v", envName(key), value))
+		}
+	}
+	return env
+}
+
+// envName turns a camelCase data key into an environment variable name (bookId becomes
+// BOOK_ID)
+func envName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
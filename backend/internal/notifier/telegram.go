@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// telegramTemplates holds the message body for each event type.
+// Messages are sent with parse_mode=HTML, so all values must pass through "esc".
+var telegramTemplates = map[EventType]string{
+	EventTest: "🧪 <b>Test notification from Shelfarr</b>\n\nIf you see this message, notifications are working!",
+	EventGrab: "⬇️ <b>Download started</b>\n\n<b>{{esc .Title}}</b>" +
+		"{{if .Author}}\nby {{esc .Author}}{{end}}" +
+		"{{with index .Data \"release\"}}\n\nRelease: <code>{{esc (print .)}}</code>{{end}}" +
+		"{{with index .Data \"indexer\"}}\nIndexer: {{esc (print .)}}{{end}}",
+	EventDownload: "✅ <b>Download completed</b>\n\n<b>{{esc .Title}}</b>" +
+		"{{if .Author}}\nby {{esc .Author}}{{end}}",
+	EventImport: "📚 <b>Imported to library</b>\n\n<b>{{esc .Title}}</b>" +
+		"{{if .Author}}\nby {{esc .Author}}{{end}}" +
+		"{{with index .Data \"format\"}}\nFormat: {{esc (print .)}}{{end}}",
+	EventRelease: "🆕 <b>Now available</b>\n\n<b>{{esc .Title}}</b>" +
+		"{{if .Author}}\nby {{esc .Author}}{{end}}" +
+		"{{with index .Data \"releaseDate\"}}\nReleased: {{esc (print .)}}{{end}}" +
+		"\n\nThis monitored book has been released and will be searched for.",
+}
+
+var telegramTemplateSet = template.Must(parseTelegramTemplates())
+
+func parseTelegramTemplates() (*template.Template, error) {
+	root := template.New("telegram").Funcs(template.FuncMap{"esc": html.EscapeString})
+	for event, body := range telegramTemplates {
+		if _, err := root.New(string(event)).Parse(body); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// TelegramNotifier sends notifications through a Telegram bot
+type TelegramNotifier struct {
+	apiURL     string
+	botToken   string
+	chatID     string
+	httpClient *http.Client
+}
+
+// NewTelegramNotifier creates a new Telegram notifier
+func NewTelegramNotifier(botToken, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		apiURL:   "https://api.telegram.org",
+		botToken: botToken,
+		chatID:   chatID,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (t *TelegramNotifier) Type() string {
+	return "telegram"
+}
+
+// Test sends a test message to the configured chat
+func (t *TelegramNotifier) Test(ctx context.Context) error {
+	return t.Send(ctx, Message{Event: EventTest})
+}
+
+// Send renders the template for the event and posts it to the chat
+func (t *TelegramNotifier) Send(ctx context.Context, msg Message) error {
+	if t.botToken == "" || t.chatID == "" {
+		return fmt.Errorf("telegram bot token and chat ID are required")
+	}
+
+	text, err := RenderTelegramMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.botToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Telegram notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		// Telegram explains failures (bad chat id, bot blocked) in the description field
+		var apiErr struct {
+			Description string `json:"description"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Description != "" {
+			return fmt.Errorf("Telegram returned status %d: %s", resp.StatusCode, apiErr.Description)
+		}
+	}
+	return checkStatus("Telegram", resp.StatusCode)
+}
+
+// RenderTelegramMessage renders the HTML message body for an event.
+// A free-form Message overrides the template; unknown events fall back to a generic body.
+func RenderTelegramMessage(msg Message) (string, error) {
+	if msg.Message != "" {
+		return fmt.Sprintf("📚 <b>%s</b>\n\n%s", html.EscapeString(msg.Title), html.EscapeString(msg.Message)), nil
+	}
+
+	if msg.Data == nil {
+		msg.Data = map[string]interface{}{}
+	}
+
+	tmpl := telegramTemplateSet.Lookup(string(msg.Event))
+	if tmpl == nil {
+		return fmt.Sprintf("📚 <b>%s</b>\n\nEvent: %s", html.EscapeString(msg.Title), html.EscapeString(string(msg.Event))), nil
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("failed to render Telegram message: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier posts a JSON payload to an arbitrary URL
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a new webhook notifier
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (w *WebhookNotifier) Type() string {
+	return "webhook"
+}
+
+// Test sends a test payload
+func (w *WebhookNotifier) Test(ctx context.Context) error {
+	return w.Send(ctx, Message{Event: EventTest, Message: "Test notification from Shelfarr"})
+}
+
+// Send posts the event and its data as JSON
+func (w *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	payload := map[string]interface{}{
+		"event": msg.Event,
+	}
+	if msg.Data != nil {
+		payload["data"] = msg.Data
+	}
+	if msg.Message != "" {
+		payload["message"] = msg.Message
+	}
+
+	return postJSON(ctx, w.httpClient, "webhook", w.url, payload)
+}
+
+// DiscordNotifier posts embeds to a Discord webhook
+type DiscordNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewDiscordNotifier creates a new Discord notifier
+func NewDiscordNotifier(webhookURL string) *DiscordNotifier {
+	return &DiscordNotifier{
+		webhookURL: webhookURL,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (d *DiscordNotifier) Type() string {
+	return "discord"
+}
+
+// Test sends a test embed
+func (d *DiscordNotifier) Test(ctx context.Context) error {
+	return d.Send(ctx, Message{
+		Event:   EventTest,
+		Title:   "Shelfarr Test",
+		Message: "This is a test notification from Shelfarr",
+	})
+}
+
+// Send posts the message as a single embed
+func (d *DiscordNotifier) Send(ctx context.Context, msg Message) error {
+	description := fmt.Sprintf("Event: %s", msg.Event)
+	if msg.Message != "" {
+		description = msg.Message
+	}
+
+	payload := map[string]interface{}{
+		"embeds": []map[string]interface{}{
+			{
+				"title":       msg.Title,
+				"description": description,
+				"color":       3447003, // Blue
+				"footer": map[string]string{
+					"text": "Shelfarr",
+				},
+			},
+		},
+	}
+
+	return postJSON(ctx, d.httpClient, "Discord", d.webhookURL, payload)
+}
+
+// postJSON marshals payload and posts it, mapping error statuses to errors
+func postJSON(ctx context.Context, client *http.Client, provider, url string, payload interface{}) error {
+	return postJSONWithHeaders(ctx, client, provider, url, payload, nil)
+}
+
+// postJSONWithHeaders is postJSON with extra request headers (auth tokens, etc.)
+func postJSONWithHeaders(ctx context.Context, client *http.Client, provider, url string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(provider, resp.StatusCode)
+}
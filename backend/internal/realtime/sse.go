@@ -0,0 +1,61 @@
+package realtime
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// EventStreamHandler streams events as server-sent events. It carries the same events
+// as the WebSocket, for clients that only need to listen.
+func (h *Hub) EventStreamHandler(c echo.Context) error {
+	client := &Client{
+		hub:  h,
+		send: make(chan []byte, 256),
+	}
+	if id, ok := c.Get("userId").(uint); ok {
+		client.userID = id
+	}
+	if admin, ok := c.Get("isAdmin").(bool); ok {
+		client.isAdmin = admin
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	h.register <- client
+	defer func() {
+		h.unregister <- client
+	}()
+
+	// Comments keep proxies from closing an idle stream
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case message, ok := <-client.send:
+			if !ok {
+				return nil // Fell too far behind; the browser reconnects
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", message); err != nil {
+				return nil
+			}
+			w.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
@@ -2,15 +2,17 @@ package realtime
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"github.com/shelfarr/shelfarr/internal/logging"
 )
 
+var logger = logging.Component("realtime")
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -36,6 +38,9 @@ const (
 	EventScanStarted       EventType = "scan.started"
 	EventScanCompleted     EventType = "scan.completed"
 	EventSystemStatus      EventType = "system.status"
+	EventJobUpdated        EventType = "job.updated"
+	EventTaskUpdated       EventType = "task.updated"
+	EventHealthChanged     EventType = "health.changed"
 )
 
 // Event represents a real-time event
@@ -81,7 +86,7 @@ func (h *Hub) Run() {
 			h.mutex.Lock()
 			h.clients[client] = true
 			h.mutex.Unlock()
-			log.Printf("WebSocket client connected, total: %d", len(h.clients))
+			logger.Debug("WebSocket client connected", "clients", len(h.clients))
 
 		case client := <-h.unregister:
 			h.mutex.Lock()
@@ -90,7 +95,7 @@ func (h *Hub) Run() {
 				close(client.send)
 			}
 			h.mutex.Unlock()
-			log.Printf("WebSocket client disconnected, total: %d", len(h.clients))
+			logger.Debug("WebSocket client disconnected", "clients", len(h.clients))
 
 		case message := <-h.broadcast:
 			h.mutex.RLock()
@@ -113,7 +118,7 @@ func (h *Hub) Broadcast(event Event) {
 	
 	data, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("Failed to marshal event: %v", err)
+		logger.Error("Failed to marshal event", "error", err)
 		return
 	}
 
@@ -126,7 +131,7 @@ func (h *Hub) BroadcastToUser(userID uint, event Event) {
 	
 	data, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("Failed to marshal event: %v", err)
+		logger.Error("Failed to marshal event", "error", err)
 		return
 	}
 
@@ -145,6 +150,17 @@ func (h *Hub) BroadcastToUser(userID uint, event Event) {
 	}
 }
 
+// CloseAll disconnects every WebSocket and event stream client, such as when shutting
+// down, since their connections never finish on their own
+func (h *Hub) CloseAll() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for client := range h.clients {
+		close(client.send)
+		delete(h.clients, client)
+	}
+}
+
 // ClientCount returns the number of connected clients
 func (h *Hub) ClientCount() int {
 	h.mutex.RLock()
@@ -204,7 +220,7 @@ func (c *Client) readPump() {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+				logger.Warn("WebSocket error", "error", err)
 			}
 			break
 		}
@@ -296,48 +312,53 @@ func NewEventEmitter(hub *Hub) *EventEmitter {
 }
 
 // DownloadStarted emits a download started event
-func (e *EventEmitter) DownloadStarted(bookID uint, title string) {
+func (e *EventEmitter) DownloadStarted(downloadID uint, bookID uint, title string) {
 	e.hub.Broadcast(Event{
 		Type: EventDownloadStarted,
 		Data: map[string]interface{}{
-			"bookId": bookID,
-			"title":  title,
+			"downloadId": downloadID,
+			"bookId":     bookID,
+			"title":      title,
 		},
 	})
 }
 
-// DownloadProgress emits a download progress event
-func (e *EventEmitter) DownloadProgress(bookID uint, progress float64, speed int64) {
+// DownloadProgress emits a download's status and progress (0-100)
+func (e *EventEmitter) DownloadProgress(downloadID uint, bookID uint, status string, progress float64, speed int64) {
 	e.hub.Broadcast(Event{
 		Type: EventDownloadProgress,
 		Data: map[string]interface{}{
-			"bookId":   bookID,
-			"progress": progress,
-			"speed":    speed,
+			"downloadId": downloadID,
+			"bookId":     bookID,
+			"status":     status,
+			"progress":   progress,
+			"speed":      speed,
 		},
 	})
 }
 
 // DownloadCompleted emits a download completed event
-func (e *EventEmitter) DownloadCompleted(bookID uint, title string, path string) {
+func (e *EventEmitter) DownloadCompleted(downloadID uint, bookID uint, title string, path string) {
 	e.hub.Broadcast(Event{
 		Type: EventDownloadCompleted,
 		Data: map[string]interface{}{
-			"bookId": bookID,
-			"title":  title,
-			"path":   path,
+			"downloadId": downloadID,
+			"bookId":     bookID,
+			"title":      title,
+			"path":       path,
 		},
 	})
 }
 
 // DownloadFailed emits a download failed event
-func (e *EventEmitter) DownloadFailed(bookID uint, title string, error string) {
+func (e *EventEmitter) DownloadFailed(downloadID uint, bookID uint, title string, error string) {
 	e.hub.Broadcast(Event{
 		Type: EventDownloadFailed,
 		Data: map[string]interface{}{
-			"bookId": bookID,
-			"title":  title,
-			"error":  error,
+			"downloadId": downloadID,
+			"bookId":     bookID,
+			"title":      title,
+			"error":      error,
 		},
 	})
 }
@@ -354,6 +375,18 @@ func (e *EventEmitter) ImportCompleted(bookID uint, mediaFileID uint, path strin
 	})
 }
 
+// ImportFailed emits an import failed event
+func (e *EventEmitter) ImportFailed(bookID uint, title string, error string) {
+	e.hub.Broadcast(Event{
+		Type: EventImportFailed,
+		Data: map[string]interface{}{
+			"bookId": bookID,
+			"title":  title,
+			"error":  error,
+		},
+	})
+}
+
 // BookAdded emits a book added event
 func (e *EventEmitter) BookAdded(bookID uint, title string, authorName string) {
 	e.hub.Broadcast(Event{
@@ -389,6 +422,41 @@ func (e *EventEmitter) ScanCompleted(filesFound int, booksImported int) {
 	})
 }
 
+// JobUpdated emits a background job status or progress change
+func (e *EventEmitter) JobUpdated(jobID uint, jobType string, status string, progress float64, message string) {
+	e.hub.Broadcast(Event{
+		Type: EventJobUpdated,
+		Data: map[string]interface{}{
+			"jobId":    jobID,
+			"type":     jobType,
+			"status":   status,
+			"progress": progress,
+			"message":  message,
+		},
+	})
+}
+
+// TaskUpdated emits a scheduled task starting or finishing. status is running, success
+// or failed.
+func (e *EventEmitter) TaskUpdated(name string, status string, message string) {
+	e.hub.Broadcast(Event{
+		Type: EventTaskUpdated,
+		Data: map[string]interface{}{
+			"name":    name,
+			"status":  status,
+			"message": message,
+		},
+	})
+}
+
+// HealthChanged emits a provider's circuit opening or closing
+func (e *EventEmitter) HealthChanged(status interface{}) {
+	e.hub.Broadcast(Event{
+		Type: EventHealthChanged,
+		Data: status,
+	})
+}
+
 // SystemStatus emits a system status event
 func (e *EventEmitter) SystemStatus(status map[string]interface{}) {
 	e.hub.Broadcast(Event{
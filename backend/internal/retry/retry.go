@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy controls how many times a request is attempted and how long to wait between tries
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration // Delay before the first retry; doubles each attempt
+	MaxDelay    time.Duration // Cap on any single delay, including Retry-After
+}
+
+// Default suits the public metadata APIs: four attempts over roughly 4-8 seconds
+var Default = Policy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Do sends the request built by newRequest, retrying transport errors and 429/5xx
+// responses with exponential backoff and jitter. A Retry-After header overrides the
+// backoff. newRequest is called once per attempt, so request bodies are fresh and any
+// rate limiting it does covers every attempt.
+//
+// The last response is returned as-is once attempts run out; callers still check the status.
+func (p Policy) Do(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if attempt >= attempts || !retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := p.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(after, p.MaxDelay)
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			slog.Debug("Request returned a retryable status", "method", req.Method, "host", req.URL.Host, "status", resp.StatusCode, "retryIn", delay.Round(time.Millisecond), "attempt", attempt+1, "attempts", attempts)
+		} else {
+			slog.Debug("Request failed", "method", req.Method, "host", req.URL.Host, "error", err, "retryIn", delay.Round(time.Millisecond), "attempt", attempt+1, "attempts", attempts)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the given retry: BaseDelay doubled per attempt,
+// capped at MaxDelay, with the upper half randomised so clients don't retry in lockstep
+func (p Policy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// Don't retry once the caller has given up
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter parses a Retry-After header, which is either seconds or an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
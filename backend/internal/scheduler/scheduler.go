@@ -2,11 +2,21 @@ package scheduler
 
 import (
 	"context"
-	"log"
+	"errors"
 	"sync"
 	"time"
+
+	"github.com/shelfarr/shelfarr/internal/logging"
 )
 
+var logger = logging.Component("scheduler")
+
+// ErrShuttingDown is returned for tasks run once shutdown has begun
+var ErrShuttingDown = errors.New("scheduler is shutting down")
+
+// shutdownGrace is how long Shutdown waits for cancelled tasks to return
+const shutdownGrace = 5 * time.Second
+
 // TaskFunc represents a scheduled task function
 type TaskFunc func(ctx context.Context) error
 
@@ -21,6 +31,10 @@ type Task struct {
 	Enabled  bool
 }
 
+// TaskListener is told when a task starts running, and when it finishes with the
+// error it returned
+type TaskListener func(name string, running bool, err error)
+
 // Scheduler manages scheduled tasks
 type Scheduler struct {
 	tasks    map[string]*Task
@@ -28,6 +42,9 @@ type Scheduler struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	running  bool
+	listener TaskListener
+	active   sync.WaitGroup // Tasks running now
+	stopping bool           // Shutting down, so no more tasks start
 }
 
 // NewScheduler creates a new scheduler
@@ -54,6 +71,13 @@ func (s *Scheduler) AddTask(name string, interval time.Duration, fn TaskFunc) {
 	}
 }
 
+// SetListener sets the function told about task runs
+func (s *Scheduler) SetListener(listener TaskListener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listener = listener
+}
+
 // RemoveTask removes a scheduled task
 func (s *Scheduler) RemoveTask(name string) {
 	s.mutex.Lock()
@@ -114,14 +138,56 @@ func (s *Scheduler) Start() {
 		}
 	}()
 
-	log.Println("Scheduler started")
+	logger.Info("Scheduler started")
+}
+
+// Shutdown stops starting tasks and waits for those running to finish. If ctx ends
+// first they're cancelled, and Shutdown gives them a few seconds to return.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	s.stopping = true
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		logger.Warn("Cancelling tasks still running at shutdown", "tasks", s.runningTasks())
+		s.cancel()
+		select {
+		case <-done:
+		case <-time.After(shutdownGrace):
+		}
+	}
+	s.Stop()
+	return err
+}
+
+// runningTasks returns the names of the tasks running now
+func (s *Scheduler) runningTasks() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var names []string
+	for _, task := range s.tasks {
+		if task.Running {
+			names = append(names, task.Name)
+		}
+	}
+	return names
 }
 
 // Stop stops the scheduler
 func (s *Scheduler) Stop() {
 	s.cancel()
 	s.running = false
-	log.Println("Scheduler stopped")
+	logger.Info("Scheduler stopped")
 }
 
 func (s *Scheduler) checkTasks() {
@@ -141,31 +207,46 @@ func (s *Scheduler) checkTasks() {
 	}
 }
 
-func (s *Scheduler) runTask(task *Task) error {
+func (s *Scheduler) runTask(task *Task) (err error) {
 	s.mutex.Lock()
+	if s.stopping {
+		s.mutex.Unlock()
+		return ErrShuttingDown
+	}
+	s.active.Add(1)
+	defer s.active.Done()
 	task.Running = true
+	listener := s.listener
 	s.mutex.Unlock()
 
+	if listener != nil {
+		listener(task.Name, true, nil)
+	}
+
 	defer func() {
 		s.mutex.Lock()
 		task.Running = false
 		task.LastRun = time.Now()
 		task.NextRun = time.Now().Add(task.Interval)
 		s.mutex.Unlock()
+
+		if listener != nil {
+			listener(task.Name, false, err)
+		}
 	}()
 
 	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Minute)
 	defer cancel()
 
-	log.Printf("Running task: %s", task.Name)
+	logger.Info("Running task", "task", task.Name)
 	start := time.Now()
 
 	if err := task.Func(ctx); err != nil {
-		log.Printf("Task %s failed: %v", task.Name, err)
+		logger.Warn("Task failed", "task", task.Name, "error", err)
 		return err
 	}
 
-	log.Printf("Task %s completed in %v", task.Name, time.Since(start))
+	logger.Info("Task completed", "task", task.Name, "duration", time.Since(start))
 	return nil
 }
 
@@ -205,6 +286,8 @@ func (s *Scheduler) SetupDefaultTasks(
 	downloadSync func(ctx context.Context) error,
 	libraryScan func(ctx context.Context) error,
 	cleanupRecycleBin func(ctx context.Context) error,
+	libraryDigest func(ctx context.Context) error,
+	releaseCheck func(ctx context.Context) error,
 ) {
 	// Sync metadata from Hardcover every 24 hours
 	if metadataSync != nil {
@@ -226,9 +309,19 @@ func (s *Scheduler) SetupDefaultTasks(
 		s.AddTask("library_scan", 1*time.Hour, libraryScan)
 	}
 
-	// Clean recycle bin every week
+	// Clean recycle bin every day
 	if cleanupRecycleBin != nil {
-		s.AddTask("recycle_cleanup", 7*24*time.Hour, cleanupRecycleBin)
+		s.AddTask("recycle_cleanup", 24*time.Hour, cleanupRecycleBin)
+	}
+
+	// Email the "new in your library" digest every week
+	if libraryDigest != nil {
+		s.AddTask("library_digest", 7*24*time.Hour, libraryDigest)
+	}
+
+	// Mark monitored books released once their release date passes, every hour
+	if releaseCheck != nil {
+		s.AddTask("release_check", 1*time.Hour, releaseCheck)
 	}
 }
 
@@ -249,7 +342,7 @@ func MetadataSyncTask(hardcoverClient interface{}, db interface{}) TaskFunc {
 		// 1. Get all books that haven't been synced recently
 		// 2. Fetch updated metadata from Hardcover
 		// 3. Update database records
-		log.Println("Running metadata sync...")
+		logger.Info("Running metadata sync")
 		return nil
 	}
 }
@@ -261,7 +354,7 @@ func ListSyncTask(hardcoverClient interface{}, db interface{}) TaskFunc {
 		// 1. Get all monitored Hardcover lists
 		// 2. Fetch list contents from Hardcover
 		// 3. Add new books from lists
-		log.Println("Running list sync...")
+		logger.Info("Running list sync")
 		return nil
 	}
 }
@@ -273,7 +366,7 @@ func DownloadSyncTask(downloadManager interface{}) TaskFunc {
 		// 1. Get all active downloads
 		// 2. Check status with download clients
 		// 3. Update database and trigger imports for completed downloads
-		log.Println("Running download sync...")
+		logger.Info("Running download sync")
 		return nil
 	}
 }
@@ -285,7 +378,7 @@ func LibraryScanTask(scanner interface{}, db interface{}) TaskFunc {
 		// 1. Scan library directories
 		// 2. Find new files not in database
 		// 3. Try to match with existing books or flag for manual import
-		log.Println("Running library scan...")
+		logger.Info("Running library scan")
 		return nil
 	}
 }
@@ -296,7 +389,7 @@ func RecycleBinCleanupTask(recycleBinPath string, maxAge time.Duration) TaskFunc
 		// Implementation would:
 		// 1. Scan recycle bin folder
 		// 2. Delete files older than maxAge
-		log.Println("Running recycle bin cleanup...")
+		logger.Info("Running recycle bin cleanup")
 		return nil
 	}
 }
@@ -313,8 +406,7 @@ func SearchAndDownloadTask(
 		// 2. Search indexers for each book
 		// 3. Select best result based on quality profile
 		// 4. Add to download client
-		log.Println("Running search and download...")
+		logger.Info("Running search and download")
 		return nil
 	}
 }
-
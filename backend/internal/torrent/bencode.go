@@ -0,0 +1,190 @@
+// Package torrent parses .torrent files and magnet links, mainly to learn a torrent's
+// info hash before any download client has seen it.
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Decode parses a bencoded value. Integers decode to int64, byte strings to string,
+// lists to []interface{} and dictionaries to map[string]interface{}.
+func Decode(data []byte) (interface{}, error) {
+	d := &decoder{data: data}
+	return d.value(0)
+}
+
+// MetaInfo is the part of a .torrent file Shelfarr uses
+type MetaInfo struct {
+	InfoHash string   // Lowercase hex SHA-1 of the info dictionary
+	Name     string   // Suggested file or folder name
+	Length   int64    // Total size of all files
+	Files    []string // Paths relative to Name; empty for single-file torrents
+	Trackers []string
+}
+
+// ParseTorrent parses a .torrent file
+func ParseTorrent(data []byte) (*MetaInfo, error) {
+	d := &decoder{data: data}
+	value, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("not a torrent file")
+	}
+	info, ok := root["info"].(map[string]interface{})
+	if !ok || d.infoEnd == 0 {
+		return nil, errors.New("torrent file has no info dictionary")
+	}
+
+	sum := sha1.Sum(data[d.infoStart:d.infoEnd])
+	meta := &MetaInfo{InfoHash: hex.EncodeToString(sum[:])}
+	meta.Name, _ = info["name"].(string)
+
+	if length, ok := info["length"].(int64); ok {
+		meta.Length = length
+	}
+	files, _ := info["files"].([]interface{})
+	for _, f := range files {
+		file, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if length, ok := file["length"].(int64); ok {
+			meta.Length += length
+		}
+		var path string
+		parts, _ := file["path"].([]interface{})
+		for _, part := range parts {
+			if s, ok := part.(string); ok {
+				if path != "" {
+					path += "/"
+				}
+				path += s
+			}
+		}
+		if path != "" {
+			meta.Files = append(meta.Files, path)
+		}
+	}
+
+	// announce-list is a list of tiers, each a list of trackers
+	seen := make(map[string]bool)
+	addTracker := func(v interface{}) {
+		if tracker, ok := v.(string); ok && tracker != "" && !seen[tracker] {
+			seen[tracker] = true
+			meta.Trackers = append(meta.Trackers, tracker)
+		}
+	}
+	addTracker(root["announce"])
+	tiers, _ := root["announce-list"].([]interface{})
+	for _, tier := range tiers {
+		trackers, _ := tier.([]interface{})
+		for _, tracker := range trackers {
+			addTracker(tracker)
+		}
+	}
+
+	return meta, nil
+}
+
+// decoder reads bencoded values, remembering where the top-level info dictionary
+// starts and ends so its hash can be taken over the original bytes
+type decoder struct {
+	data      []byte
+	pos       int
+	infoStart int
+	infoEnd   int
+}
+
+// maxDepth caps how deeply lists and dictionaries nest, so a crafted file can't exhaust
+// the stack. Real torrents nest a few levels.
+const maxDepth = 64
+
+func (d *decoder) value(depth int) (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, errors.New("invalid bencode: unexpected end")
+	}
+	if depth > maxDepth {
+		return nil, errors.New("invalid bencode: nested too deeply")
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		end := bytes.IndexByte(d.data[d.pos:], 'e')
+		if end < 0 {
+			return nil, errors.New("invalid bencode: unterminated integer")
+		}
+		n, err := strconv.ParseInt(string(d.data[d.pos+1:d.pos+end]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bencode: bad integer: %w", err)
+		}
+		d.pos += end + 1
+		return n, nil
+	case c == 'l':
+		d.pos++
+		list := []interface{}{}
+		for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		if d.pos >= len(d.data) {
+			return nil, errors.New("invalid bencode: unterminated list")
+		}
+		d.pos++
+		return list, nil
+	case c == 'd':
+		d.pos++
+		dict := make(map[string]interface{})
+		for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+			key, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			start := d.pos
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if depth == 0 && key == "info" {
+				d.infoStart, d.infoEnd = start, d.pos
+			}
+			dict[key] = item
+		}
+		if d.pos >= len(d.data) {
+			return nil, errors.New("invalid bencode: unterminated dictionary")
+		}
+		d.pos++
+		return dict, nil
+	case c >= '0' && c <= '9':
+		return d.str()
+	default:
+		return nil, fmt.Errorf("invalid bencode: unexpected %q at %d", c, d.pos)
+	}
+}
+
+func (d *decoder) str() (string, error) {
+	colon := bytes.IndexByte(d.data[d.pos:], ':')
+	if colon < 0 {
+		return "", errors.New("invalid bencode: bad string")
+	}
+	length, err := strconv.Atoi(string(d.data[d.pos : d.pos+colon]))
+	if err != nil || length < 0 {
+		return "", errors.New("invalid bencode: bad string length")
+	}
+	start := d.pos + colon + 1
+	if length > len(d.data)-start {
+		return "", errors.New("invalid bencode: string too long")
+	}
+	d.pos = start + length
+	return string(d.data[start:d.pos]), nil
+}
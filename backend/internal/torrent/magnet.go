@@ -0,0 +1,67 @@
+package torrent
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Magnet is a parsed magnet link
+type Magnet struct {
+	InfoHash string // Lowercase hex
+	Name     string // Display name (dn), if given
+	Length   int64  // Exact length (xl), if given
+	Trackers []string
+}
+
+// IsMagnet reports whether a URI is a magnet link
+func IsMagnet(uri string) bool {
+	return strings.HasPrefix(strings.ToLower(uri), "magnet:")
+}
+
+// ParseMagnet parses a magnet link. Only BitTorrent v1 (btih) links are supported.
+func ParseMagnet(uri string) (*Magnet, error) {
+	if !IsMagnet(uri) {
+		return nil, errors.New("not a magnet link")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+
+	magnet := &Magnet{
+		Name:     query.Get("dn"),
+		Trackers: query["tr"],
+	}
+	magnet.Length, _ = strconv.ParseInt(query.Get("xl"), 10, 64)
+
+	for _, xt := range query["xt"] {
+		if len(xt) > 9 && strings.EqualFold(xt[:9], "urn:btih:") {
+			magnet.InfoHash = NormalizeInfoHash(xt[9:])
+			if magnet.InfoHash != "" {
+				return magnet, nil
+			}
+		}
+	}
+	return nil, errors.New("magnet link has no info hash")
+}
+
+// NormalizeInfoHash converts a hex or base32 info hash to lowercase hex. It returns ""
+// for anything else.
+func NormalizeInfoHash(hash string) string {
+	switch len(hash) {
+	case 40:
+		if _, err := hex.DecodeString(hash); err == nil {
+			return strings.ToLower(hash)
+		}
+	case 32:
+		if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+			return hex.EncodeToString(decoded)
+		}
+	}
+	return ""
+}
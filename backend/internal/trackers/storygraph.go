@@ -0,0 +1,42 @@
+package trackers
+
+import (
+	"io"
+	"strings"
+)
+
+// storyGraphShelves maps StoryGraph's "Read Status" values to shelves
+var storyGraphShelves = map[string]string{
+	"to-read":           ShelfToRead,
+	"currently-reading": ShelfReading,
+	"read":              ShelfRead,
+	"did-not-finish":    ShelfDidNotFinish,
+	"paused":            ShelfReading,
+}
+
+// ParseStoryGraph reads a StoryGraph library export (Manage Account > Export StoryGraph Library)
+func ParseStoryGraph(r io.Reader) ([]Entry, error) {
+	records, err := csvRecords(r, "Title", "Authors", "ISBN/UID", "Read Status")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, record := range records {
+		if record["Title"] == "" {
+			continue
+		}
+		shelf, ok := storyGraphShelves[strings.ToLower(record["Read Status"])]
+		if !ok {
+			shelf = ShelfToRead
+		}
+		entries = append(entries, Entry{
+			Title:  record["Title"],
+			Author: firstAuthor(record["Authors"]),
+			ISBN:   normalizeISBN(record["ISBN/UID"]),
+			Shelf:  shelf,
+			Owned:  strings.EqualFold(record["Owned?"], "yes"),
+		})
+	}
+	return entries, nil
+}
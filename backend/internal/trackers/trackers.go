@@ -0,0 +1,103 @@
+// Package trackers reads library exports from other reading trackers so their books
+// can be matched against metadata providers and added to the library
+package trackers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Shelves an exported book can be on, normalized across trackers
+const (
+	ShelfToRead       = "to-read"
+	ShelfReading      = "currently-reading"
+	ShelfRead         = "read"
+	ShelfDidNotFinish = "did-not-finish"
+)
+
+// ErrUnknownFormat is returned when a file lacks the columns a tracker's export has
+var ErrUnknownFormat = errors.New("not a recognized export file")
+
+// Entry is a book from a tracker export
+type Entry struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	ISBN   string `json:"isbn,omitempty"` // ISBN-10 or ISBN-13, empty when the tracker had none
+	Shelf  string `json:"shelf"`
+	Owned  bool   `json:"owned,omitempty"`
+}
+
+// Parser reads a tracker's export into entries
+type Parser func(r io.Reader) ([]Entry, error)
+
+// Parsers maps a tracker name to the parser for its export
+var Parsers = map[string]Parser{
+	"storygraph": ParseStoryGraph,
+}
+
+// csvRecords reads a CSV export with a header row, returning each row keyed by column
+// name. Fails with ErrUnknownFormat if any required column is missing.
+func csvRecords(r io.Reader, required ...string) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Trailing empty columns are sometimes dropped
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i, name := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	for _, name := range required {
+		if !slices.Contains(header, name) {
+			return nil, fmt.Errorf("%w: missing %q column", ErrUnknownFormat, name)
+		}
+	}
+
+	var records []map[string]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		record := make(map[string]string, len(header))
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+// normalizeISBN strips hyphens and spaces from an ISBN, returning "" for anything that
+// isn't an ISBN-10 or ISBN-13 (trackers put their own IDs in the column for books without one)
+func normalizeISBN(s string) string {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+	if len(isbn) != 10 && len(isbn) != 13 {
+		return ""
+	}
+	for i, r := range isbn {
+		// Only an ISBN-10 check digit may be X
+		if (r < '0' || r > '9') && !(r == 'X' && len(isbn) == 10 && i == 9) {
+			return ""
+		}
+	}
+	return isbn
+}
+
+// firstAuthor returns the first name from a comma-separated author list
+func firstAuthor(authors string) string {
+	name, _, _ := strings.Cut(authors, ",")
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,258 @@
+// Package validate checks request bodies against the rules in their validate struct
+// tags, such as `validate:"required,max=200"`, reporting every invalid field by its
+// JSON name. The rules are:
+//
+//   - required: not empty or zero; for pointers, present
+//   - required_if=field value: required if the field with that JSON name in the same
+//     struct has that value
+//   - required_unless=field value: required unless it has that value
+//   - min=N, max=N: at least or at most N, or for strings and lists, that many
+//     characters or entries
+//   - oneof=a b c: one of the words given
+//   - url: an http or https URL
+//   - email: an email address
+//
+// Rules other than required only apply to values that are set: numbers behind
+// pointers that are present, and other values that aren't empty or zero. Nested
+// structs, and lists and maps of them, are checked too.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is a field that broke a rule
+type FieldError struct {
+	Field   string // JSON path, such as "url" or "items[2].name"
+	Message string // Such as "is required"
+}
+
+// Errors are the invalid fields of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + " " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields returns the message for each invalid field, by its JSON path
+func (e Errors) Fields() map[string]string {
+	fields := make(map[string]string, len(e))
+	for _, err := range e {
+		fields[err.Field] = err.Message
+	}
+	return fields
+}
+
+// Struct checks v, a struct or pointer to one, returning Errors if any field is
+// invalid. Anything else, such as a map, has no rules and passes.
+func Struct(v any) error {
+	var errs Errors
+	check(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// check walks value, checking the fields of any structs in it
+func check(value reflect.Value, path string, errs *Errors) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Anonymous {
+				check(value.Field(i), path, errs)
+				continue
+			}
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			if tag := field.Tag.Get("validate"); tag != "" {
+				if message := checkRules(value.Field(i), value, tag); message != "" {
+					*errs = append(*errs, FieldError{Field: name, Message: message})
+					continue
+				}
+			}
+			check(value.Field(i), name, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			check(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			check(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), errs)
+		}
+	}
+}
+
+// jsonName returns the name a field has in JSON, or "" if it's left out
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// checkRules returns why value, a field of parent, breaks the rules in tag, or "" if
+// it doesn't
+func checkRules(value, parent reflect.Value, tag string) string {
+	rules := strings.Split(tag, ",")
+	required := false
+	for i := range rules {
+		rules[i] = strings.TrimSpace(rules[i])
+		if rules[i] == "required" {
+			required = true
+		} else if condition, ok := strings.CutPrefix(rules[i], "required_if="); ok {
+			field, want, _ := strings.Cut(condition, " ")
+			required = fieldEquals(parent, field, want)
+		} else if condition, ok := strings.CutPrefix(rules[i], "required_unless="); ok {
+			field, want, _ := strings.Cut(condition, " ")
+			required = !fieldEquals(parent, field, want)
+		}
+	}
+
+	present := false
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if required {
+				return "is required"
+			}
+			return ""
+		}
+		value, present = value.Elem(), true
+	}
+	if value.IsZero() || (isList(value) && value.Len() == 0) {
+		if required && value.Kind() != reflect.Bool {
+			return "is required"
+		}
+		// An empty string or list clears a setting; a zero number is still checked
+		if !present || !(value.CanInt() || value.CanUint() || value.CanFloat()) {
+			return ""
+		}
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		var message string
+		switch name {
+		case "min":
+			message = checkBound(value, arg, func(n, bound float64) bool { return n >= bound }, "at least")
+		case "max":
+			message = checkBound(value, arg, func(n, bound float64) bool { return n <= bound }, "at most")
+		case "oneof":
+			options := strings.Fields(arg)
+			if value.Kind() == reflect.String && !slices.Contains(options, value.String()) {
+				message = "must be one of: " + strings.Join(options, ", ")
+			}
+		case "url":
+			if value.Kind() == reflect.String && !isURL(value.String()) {
+				message = "must be an http or https URL"
+			}
+		case "email":
+			if value.Kind() == reflect.String {
+				if address, err := mail.ParseAddress(value.String()); err != nil || address.Address != value.String() {
+					message = "must be an email address"
+				}
+			}
+		}
+		if message != "" {
+			return message
+		}
+	}
+	return ""
+}
+
+// checkBound compares a number, or a string's or list's length, with bound
+func checkBound(value reflect.Value, arg string, ok func(n, bound float64) bool, relation string) string {
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validate: invalid bound %q", arg))
+	}
+
+	var n float64
+	var unit string
+	switch {
+	case value.Kind() == reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+		if bound == 1 {
+			unit = " character"
+		}
+	case isList(value):
+		n, unit = float64(value.Len()), " entries"
+		if bound == 1 {
+			unit = " entry"
+		}
+	case value.CanInt():
+		n = float64(value.Int())
+	case value.CanUint():
+		n = float64(value.Uint())
+	case value.CanFloat():
+		n = value.Float()
+	default:
+		return ""
+	}
+	if ok(n, bound) {
+		return ""
+	}
+	return fmt.Sprintf("must be %s %s%s", relation, arg, unit)
+}
+
+// fieldEquals reports whether the field of parent with the JSON name given is want
+func fieldEquals(parent reflect.Value, name, want string) bool {
+	t := parent.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			field := parent.Field(i)
+			for field.Kind() == reflect.Pointer {
+				if field.IsNil() {
+					return false
+				}
+				field = field.Elem()
+			}
+			return fmt.Sprint(field.Interface()) == want
+		}
+	}
+	panic(fmt.Sprintf("validate: no field %q in %s", name, t))
+}
+
+func isList(value reflect.Value) bool {
+	kind := value.Kind()
+	return kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
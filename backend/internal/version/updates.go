@@ -0,0 +1,109 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatestReleaseURL is the GitHub API endpoint for Shelfarr's newest published release.
+// Drafts and pre-releases are left out by GitHub.
+var LatestReleaseURL = "https://api.github.com/repos/shelfarr/shelfarr/releases/latest"
+
+// Release is a published Shelfarr release
+type Release struct {
+	Version     string    `json:"version"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Notes       string    `json:"notes"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// LatestRelease fetches the newest published release from GitHub
+func LatestRelease(ctx context.Context, client *http.Client) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", LatestReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Shelfarr/"+Version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("release check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release check returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		HTMLURL     string    `json:"html_url"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	if body.TagName == "" {
+		return nil, fmt.Errorf("release has no tag")
+	}
+
+	return &Release{
+		Version:     strings.TrimPrefix(body.TagName, "v"),
+		Name:        body.Name,
+		URL:         body.HTMLURL,
+		Notes:       body.Body,
+		PublishedAt: body.PublishedAt,
+	}, nil
+}
+
+// IsNewer reports whether version a is newer than version b. Versions are compared as
+// dot-separated numbers, with an optional leading "v"; a pre-release such as
+// "1.2.0-beta.1" is older than "1.2.0".
+func IsNewer(a, b string) bool {
+	return compare(a, b) > 0
+}
+
+// compare returns -1, 0 or 1 as a is older than, the same as or newer than b
+func compare(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			if aNum > bNum {
+				return 1
+			}
+			return -1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre > bPre:
+		return 1
+	default:
+		return -1
+	}
+}
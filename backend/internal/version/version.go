@@ -0,0 +1,56 @@
+// Package version describes the running build of Shelfarr
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags "-X github.com/shelfarr/shelfarr/internal/version.Version=..."
+var (
+	Version   = "1.0.0"
+	Commit    = "" // Falls back to the VCS revision Go records in the binary
+	BuildDate = "" // RFC 3339; falls back to the VCS commit time
+	Branch    = ""
+)
+
+// Info describes a build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // Built from a checkout with uncommitted changes
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the running build's details
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		Branch:    Branch,
+		GoVersion: runtime.Version(),
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
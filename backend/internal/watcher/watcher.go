@@ -0,0 +1,163 @@
+// Package watcher reports files and folders that appear in a directory once they have
+// finished being written. It polls rather than relying on filesystem notifications,
+// which aren't delivered for network shares or for bind mounts changed outside a
+// container.
+package watcher
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shelfarr/shelfarr/internal/logging"
+)
+
+var logger = logging.Component("library")
+
+// DefaultInterval is how often the directory is checked
+const DefaultInterval = 15 * time.Second
+
+// partialSuffixes mark files that download clients and browsers are still writing
+var partialSuffixes = []string{".part", ".!qb", ".tmp", ".crdownload", ".aria2", ".incomplete"}
+
+// Watcher watches the entries at the top level of a directory. An entry is ready once
+// its size and modification time have stayed the same for a whole interval, and is
+// reported once; it's reported again only if it changes.
+type Watcher struct {
+	root     string
+	interval time.Duration
+	enabled  func() bool
+	onReady  func(path string)
+
+	entries  map[string]*entry
+	baseline bool // Whether the entries present when watching started have been recorded
+}
+
+type entry struct {
+	size     int64
+	modTime  time.Time
+	reported bool // Already reported, or present before watching started
+}
+
+// New creates a watcher for root that calls onReady for each new entry once it's
+// complete. enabled, if set, is checked before each poll; entries that appear while
+// watching is disabled are treated as already present when it's enabled again.
+func New(root string, interval time.Duration, enabled func() bool, onReady func(path string)) *Watcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Watcher{
+		root:     root,
+		interval: interval,
+		enabled:  enabled,
+		onReady:  onReady,
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Run polls the directory until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.enabled != nil && !w.enabled() {
+				w.entries = make(map[string]*entry)
+				w.baseline = false
+				continue
+			}
+			w.poll()
+		}
+	}
+}
+
+func (w *Watcher) poll() {
+	dirEntries, err := os.ReadDir(w.root)
+	if err != nil {
+		logger.Warn("Watcher: could not read folder", "path", w.root, "error", err)
+		return
+	}
+
+	present := make(map[string]bool, len(dirEntries))
+	var ready []string
+	for _, d := range dirEntries {
+		if strings.HasPrefix(d.Name(), ".") || isPartial(d.Name()) {
+			continue
+		}
+		path := filepath.Join(w.root, d.Name())
+		present[path] = true
+
+		size, modTime, partial := measure(path, d)
+		e, ok := w.entries[path]
+		if !ok {
+			w.entries[path] = &entry{size: size, modTime: modTime, reported: !w.baseline}
+			continue
+		}
+		if partial || size != e.size || !modTime.Equal(e.modTime) {
+			e.size, e.modTime, e.reported = size, modTime, false
+			continue
+		}
+		if !e.reported {
+			e.reported = true
+			ready = append(ready, path)
+		}
+	}
+
+	for path := range w.entries {
+		if !present[path] {
+			delete(w.entries, path)
+		}
+	}
+	w.baseline = true
+
+	for _, path := range ready {
+		w.onReady(path)
+	}
+}
+
+// measure returns an entry's total size and latest modification time. Folders that
+// still hold partial files report partial.
+func measure(path string, d fs.DirEntry) (size int64, modTime time.Time, partial bool) {
+	if !d.IsDir() {
+		if info, err := d.Info(); err == nil {
+			return info.Size(), info.ModTime(), false
+		}
+		return 0, time.Time{}, true
+	}
+
+	filepath.WalkDir(path, func(_ string, child fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if isPartial(child.Name()) {
+			partial = true
+		}
+		if info, err := child.Info(); err == nil {
+			if !child.IsDir() {
+				size += info.Size()
+			}
+			if info.ModTime().After(modTime) {
+				modTime = info.ModTime()
+			}
+		}
+		return nil
+	})
+	return size, modTime, partial
+}
+
+func isPartial(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range partialSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
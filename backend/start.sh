@@ -6,7 +6,7 @@ export GOPATH="$HOME/go"
 cd "$(dirname "$0")"
 
 echo "Building shelfarr..."
-go build ./cmd/shelfarr || exit 1
+go build -tags sqlite_fts5 ./cmd/shelfarr || exit 1
 
 echo "Starting shelfarr with AUTH_DISABLED=true..."
 AUTH_DISABLED=true ./shelfarr
@@ -10,20 +10,28 @@ import { ManualImportPage } from '@/pages/ManualImportPage'
 import { IndexersSettingsPage } from '@/pages/IndexersSettingsPage'
 import { QualityProfilesSettingsPage } from '@/pages/QualityProfilesSettingsPage'
 import { MediaManagementSettingsPage } from '@/pages/MediaManagementSettingsPage'
+import { TagsSettingsPage } from '@/pages/TagsSettingsPage'
 import { BookDetailPage } from '@/pages/BookDetailPage'
 import ActivityPage from '@/pages/ActivityPage'
 import WantedPage from '@/pages/WantedPage'
 import AuthorDetailPage from '@/pages/AuthorDetailPage'
 import SeriesDetailPage from '@/pages/SeriesDetailPage'
+import { CollectionsPage } from '@/pages/CollectionsPage'
+import CollectionDetailPage from '@/pages/CollectionDetailPage'
 import HardcoverAuthorPage from '@/pages/HardcoverAuthorPage'
 import HardcoverSeriesPage from '@/pages/HardcoverSeriesPage'
 import HardcoverBookPage from '@/pages/HardcoverBookPage'
 import SystemStatusPage from '@/pages/SystemStatusPage'
+import SystemLogsPage from '@/pages/SystemLogsPage'
+import AuditLogPage from '@/pages/AuditLogPage'
+import RequestsPage from '@/pages/RequestsPage'
+import ReadingGoalsPage from '@/pages/ReadingGoalsPage'
 import DownloadClientsSettingsPage from '@/pages/DownloadClientsSettingsPage'
 import NotificationsSettingsPage from '@/pages/NotificationsSettingsPage'
 import ListsSettingsPage from '@/pages/ListsSettingsPage'
 import LibrarySearchSettingsPage from '@/pages/LibrarySearchSettingsPage'
 import GeneralSettingsPage from '@/pages/GeneralSettingsPage'
+import { URL_BASE } from '@/lib/utils'
 
 const queryClient = new QueryClient({
   defaultOptions: {
@@ -37,16 +45,20 @@ const queryClient = new QueryClient({
 function App() {
   return (
     <QueryClientProvider client={queryClient}>
-      <BrowserRouter>
+      <BrowserRouter basename={URL_BASE}>
         <Routes>
           <Route path="/" element={<AppLayout />}>
             <Route index element={<LibraryPage />} />
             <Route path="series" element={<SeriesPage />} />
             <Route path="authors" element={<AuthorsPage />} />
+            <Route path="collections" element={<CollectionsPage />} />
+            <Route path="collections/:id" element={<CollectionDetailPage />} />
             <Route path="search" element={<SearchPage />} />
             <Route path="add" element={<Navigate to="/search" replace />} />
             <Route path="activity" element={<ActivityPage />} />
             <Route path="wanted" element={<WantedPage />} />
+            <Route path="requests" element={<RequestsPage />} />
+            <Route path="goals" element={<ReadingGoalsPage />} />
             <Route path="import" element={<ManualImportPage />} />
             <Route path="settings" element={<SettingsPage />} />
             <Route path="settings/general" element={<GeneralSettingsPage />} />
@@ -57,6 +69,7 @@ function App() {
             <Route path="settings/lists" element={<ListsSettingsPage />} />
             <Route path="settings/profiles" element={<QualityProfilesSettingsPage />} />
             <Route path="settings/media" element={<MediaManagementSettingsPage />} />
+            <Route path="settings/tags" element={<TagsSettingsPage />} />
             <Route path="settings/*" element={<ComingSoon title="Settings" />} />
             <Route path="books/:id" element={<BookDetailPage />} />
             <Route path="authors/:id" element={<AuthorDetailPage />} />
@@ -65,6 +78,8 @@ function App() {
             <Route path="hardcover/series/:id" element={<HardcoverSeriesPage />} />
             <Route path="hardcover/book/:id" element={<HardcoverBookPage />} />
             <Route path="system/status" element={<SystemStatusPage />} />
+            <Route path="system/logs" element={<SystemLogsPage />} />
+            <Route path="system/audit" element={<AuditLogPage />} />
           </Route>
         </Routes>
       </BrowserRouter>
@@ -1,5 +1,6 @@
 import axios from 'axios'
 import type { QueryClient } from '@tanstack/react-query'
+import { URL_BASE } from '@/lib/utils'
 import type { 
   Book, 
   LibraryResponse, 
@@ -19,17 +20,23 @@ import type {
   DownloadClient,
   User,
   ReadProgress,
+  ResumeProgress,
+  ProgressStrategy,
   QualityProfile,
   AuthorWithBooks,
   Edition,
   Contributor,
-  Genre
+  Genre,
+  AuthorAlias,
+  Tag,
+  Collection,
+  SavedFilter
 } from '@/types'
 
 // Re-export types for use in pages
-export type { Author, Book, SeriesDetail, AuthorDetail, DownloadClient, AuthorWithBooks }
+export type { Author, Book, SeriesDetail, AuthorDetail, DownloadClient, AuthorWithBooks, AuthorAlias, Tag, Collection, SavedFilter }
 
-const API_BASE = import.meta.env.VITE_API_URL || ''
+const API_BASE = (import.meta.env.VITE_API_URL || '') + URL_BASE
 
 const api = axios.create({
   baseURL: `${API_BASE}/api/v1`,
@@ -48,18 +55,76 @@ export const getLibrary = async (params?: {
   mediaType?: string
   sortBy?: string
   sortOrder?: string
+  filterId?: number
 }): Promise<LibraryResponse> => {
   const { data } = await api.get('/library', { params })
   return data
 }
 
+// Full-text search of titles, subtitles, descriptions, authors and series, most
+// relevant first. Words match as prefixes; "quoted words" match as a phrase.
+export const searchLibrary = async (q: string, params?: { page?: number; pageSize?: number }): Promise<LibraryResponse> => {
+  const { data } = await api.get('/library/search', { params: { q, ...params } })
+  return data
+}
+
 export const getLibraryStats = async (): Promise<LibraryStats> => {
   const { data } = await api.get('/library/stats')
   return data
 }
 
+// Result of rescanning the root folders for added, moved and deleted files
+export interface LibraryScanResult {
+  added: number
+  moved: number
+  removed: number
+  booksMissingFiles: number
+  unmatched: string[] // Files that couldn't be matched to a book
+}
+
+export const scanLibrary = async (): Promise<LibraryScanResult> => {
+  const { data } = await api.post('/library/scan')
+  return data
+}
+
+// A library file whose path doesn't follow the naming templates
+export interface LibraryRename {
+  mediaFileId: number
+  bookId: number
+  bookTitle: string
+  currentPath: string
+  newPath: string
+  conflict?: string // Why the file can't be renamed
+}
+
+export interface LibraryRenameResult {
+  renamed: number
+  skipped: LibraryRename[]
+}
+
+export const getLibraryRenames = async (bookId?: number): Promise<LibraryRename[]> => {
+  const { data } = await api.get('/library/rename', { params: { bookId } })
+  return data
+}
+
+export const renameLibraryFiles = async (mediaFileIds?: number[]): Promise<LibraryRenameResult> => {
+  const { data } = await api.post('/library/rename', { mediaFileIds })
+  return data
+}
+
 // Book endpoints
-export const getBooks = async (params?: { monitored?: boolean; status?: string }): Promise<Book[]> => {
+// getBooks pages with limit/offset (the total is in the X-Total-Count header); light
+// leaves out descriptions and media files
+export const getBooks = async (params?: {
+  monitored?: boolean
+  status?: string
+  filterId?: number
+  limit?: number
+  offset?: number
+  sortBy?: string
+  sortOrder?: 'asc' | 'desc'
+  light?: boolean
+}): Promise<Book[]> => {
   const { data } = await api.get('/books', { params })
   return data
 }
@@ -74,20 +139,23 @@ export const addBook = async (hardcoverId: string, monitored: boolean = true): P
   return data
 }
 
-export const updateBook = async (id: number, updates: { monitored?: boolean; status?: string }): Promise<Book> => {
+export const updateBook = async (
+  id: number,
+  updates: { monitored?: boolean; status?: string; ebookRootFolderId?: number; audiobookRootFolderId?: number }
+): Promise<Book> => {
   const { data } = await api.put(`/books/${id}`, updates)
   return data
 }
 
 
-export const deleteBook = async (id: number): Promise<{
+export const deleteBook = async (id: number, deleteFiles: boolean = false): Promise<{
   message: string;
   bookId: number;
   hardcoverId: string;
   authorId?: number;
   seriesId?: number | null;
 }> => {
-  const { data } = await api.delete(`/books/${id}`)
+  const { data } = await api.delete(`/books/${id}`, { params: deleteFiles ? { deleteFiles } : undefined })
   return data
 }
 
@@ -119,6 +187,49 @@ export const refreshBookMetadata = async (id: number): Promise<{
   return data
 }
 
+// MetadataUpdate edits a book's metadata; only the fields present change, and each is
+// locked against refreshes unless locked says otherwise
+export interface MetadataUpdate {
+  title?: string
+  sortTitle?: string
+  subtitle?: string
+  description?: string
+  releaseDate?: string
+  pageCount?: number
+  isbn?: string
+  isbn13?: string
+  language?: string
+  series?: string
+  seriesIndex?: number
+  genres?: string[]
+  locked?: Record<string, boolean>
+}
+
+export const updateBookMetadata = async (id: number, update: MetadataUpdate): Promise<Book> => {
+  const { data } = await api.put(`/books/${id}/metadata`, update)
+  return data
+}
+
+// Custom covers are preferred over the metadata provider's and kept on refresh
+export const uploadBookCover = async (id: number, file: File): Promise<Book> => {
+  const form = new FormData()
+  form.append('file', file)
+  const { data } = await api.post(`/books/${id}/cover`, form, {
+    headers: { 'Content-Type': 'multipart/form-data' },
+  })
+  return data
+}
+
+export const setBookCover = async (id: number, cover: { editionId?: number; url?: string }): Promise<Book> => {
+  const { data } = await api.put(`/books/${id}/cover`, cover)
+  return data
+}
+
+export const resetBookCover = async (id: number): Promise<Book> => {
+  const { data } = await api.delete(`/books/${id}/cover`)
+  return data
+}
+
 export const getGenres = async (): Promise<Genre[]> => {
   const { data } = await api.get('/genres')
   return data
@@ -159,7 +270,7 @@ export function invalidateAllBookQueries(queryClient: QueryClient) {
 // Bulk book operations
 export const bulkUpdateBooks = async (
   bookIds: number[], 
-  updates: { monitored?: boolean; status?: string }
+  updates: { monitored?: boolean; status?: string; addTags?: number[]; removeTags?: number[] }
 ): Promise<{ updated: number }> => {
   const { data } = await api.put('/books/bulk', { bookIds, ...updates })
   return data
@@ -186,7 +297,7 @@ export const getAuthor = async (id: number): Promise<AuthorDetail> => {
   return data
 }
 
-export const addAuthor = async (hardcoverId: string, monitored: boolean = false, addAllBooks: boolean = false): Promise<Author> => {
+export const addAuthor = async (hardcoverId: string, monitored: boolean = false, addAllBooks: boolean = false): Promise<Author | AddRequestedResponse> => {
   const { data } = await api.post('/authors', { hardcoverId, monitored, addAllBooks })
   return data
 }
@@ -196,10 +307,45 @@ export const updateAuthor = async (id: number, updates: { monitored: boolean }):
   return data
 }
 
+export const uploadAuthorImage = async (id: number, file: File): Promise<Author> => {
+  const form = new FormData()
+  form.append('file', file)
+  const { data } = await api.post(`/authors/${id}/image`, form, {
+    headers: { 'Content-Type': 'multipart/form-data' },
+  })
+  return data
+}
+
+export const resetAuthorImage = async (id: number): Promise<Author> => {
+  const { data } = await api.delete(`/authors/${id}/image`)
+  return data
+}
+
 export const deleteAuthor = async (id: number): Promise<void> => {
   await api.delete(`/authors/${id}`)
 }
 
+export const getAuthorAliases = async (id: number): Promise<AuthorAlias[]> => {
+  const { data } = await api.get(`/authors/${id}/aliases`)
+  return data
+}
+
+export const addAuthorAlias = async (id: number, name: string): Promise<AuthorAlias[]> => {
+  const { data } = await api.post(`/authors/${id}/aliases`, { name })
+  return data
+}
+
+export const removeAuthorAlias = async (id: number, aliasId: number): Promise<void> => {
+  await api.delete(`/authors/${id}/aliases/${aliasId}`)
+}
+
+// Merges duplicate authors into another; their books move over and their names
+// become its aliases
+export const mergeAuthors = async (id: number, authorIds: number[]): Promise<{ merged: number; aliases: AuthorAlias[] }> => {
+  const { data } = await api.post(`/authors/${id}/merge`, { authorIds })
+  return data
+}
+
 // Series endpoints
 export const getSeries = async (): Promise<Series[]> => {
   const { data } = await api.get('/series')
@@ -211,6 +357,27 @@ export const getSeriesDetail = async (id: number): Promise<SeriesDetail> => {
   return data
 }
 
+// Merges duplicate series into another; their books move over
+export const mergeSeries = async (id: number, seriesIds: number[]): Promise<{ merged: number; books: number }> => {
+  const { data } = await api.post(`/series/${id}/merge`, { seriesIds })
+  return data
+}
+
+// Places a book in an existing series by ID, or in one found or created by name. The
+// assignment is locked against metadata refreshes.
+export const setBookSeries = async (
+  bookId: number,
+  series: { seriesId?: number; seriesName?: string; seriesIndex?: number }
+): Promise<Book> => {
+  const { data } = await api.put(`/books/${bookId}/series`, series)
+  return data
+}
+
+export const removeBookSeries = async (bookId: number): Promise<Book> => {
+  const { data } = await api.delete(`/books/${bookId}/series`)
+  return data
+}
+
 export interface AddSeriesBooksResponse {
   message: string
   addedCount: number
@@ -338,7 +505,8 @@ export const addHardcoverBook = async (
     forceAuthorId?: number;
     forceSeriesId?: number;
   }
-): Promise<{ message: string; bookId: number }> => {
+): Promise<{ message: string; bookId?: number; requestId?: number }> => {
+  // Users who aren't admins get a request, with requestId, instead of the book
   const { data } = await api.post(`/hardcover/book/${id}`, options)
   return data
 }
@@ -348,6 +516,100 @@ export const searchIndexers = async (params: { bookId?: number; q?: string; medi
   return data
 }
 
+// Tag endpoints
+export const getTags = async (): Promise<Tag[]> => {
+  const { data } = await api.get('/tags')
+  return data
+}
+
+export const createTag = async (name: string): Promise<Tag> => {
+  const { data } = await api.post('/tags', { name })
+  return data
+}
+
+export const updateTag = async (id: number, name: string): Promise<Tag> => {
+  const { data } = await api.put(`/tags/${id}`, { name })
+  return data
+}
+
+export const deleteTag = async (id: number): Promise<void> => {
+  await api.delete(`/tags/${id}`)
+}
+
+export const setBookTags = async (id: number, tags: number[]): Promise<{ tags: number[] }> => {
+  const { data } = await api.put(`/books/${id}/tags`, { tags })
+  return data
+}
+
+export const setAuthorTags = async (id: number, tags: number[]): Promise<{ tags: number[] }> => {
+  const { data } = await api.put(`/authors/${id}/tags`, { tags })
+  return data
+}
+
+export const setProfileTags = async (id: number, tags: number[]): Promise<{ tags: number[] }> => {
+  const { data } = await api.put(`/profiles/${id}/tags`, { tags })
+  return data
+}
+
+// Saved filter endpoints
+export const getSavedFilters = async (): Promise<SavedFilter[]> => {
+  const { data } = await api.get('/filters')
+  return data
+}
+
+export const createSavedFilter = async (filter: Omit<SavedFilter, 'id'>): Promise<SavedFilter> => {
+  const { data } = await api.post('/filters', filter)
+  return data
+}
+
+export const updateSavedFilter = async (id: number, filter: Omit<SavedFilter, 'id'>): Promise<SavedFilter> => {
+  const { data } = await api.put(`/filters/${id}`, filter)
+  return data
+}
+
+export const deleteSavedFilter = async (id: number): Promise<void> => {
+  await api.delete(`/filters/${id}`)
+}
+
+// Collection endpoints
+export const getCollections = async (): Promise<Collection[]> => {
+  const { data } = await api.get('/collections')
+  return data
+}
+
+export const getCollection = async (id: number): Promise<Collection> => {
+  const { data } = await api.get(`/collections/${id}`)
+  return data
+}
+
+export const createCollection = async (collection: { name: string; description?: string; opds?: boolean }): Promise<Collection> => {
+  const { data } = await api.post('/collections', collection)
+  return data
+}
+
+export const updateCollection = async (id: number, collection: { name: string; description?: string; opds?: boolean }): Promise<Collection> => {
+  const { data } = await api.put(`/collections/${id}`, collection)
+  return data
+}
+
+export const deleteCollection = async (id: number): Promise<void> => {
+  await api.delete(`/collections/${id}`)
+}
+
+export const addCollectionBooks = async (id: number, bookIds: number[]): Promise<Collection> => {
+  const { data } = await api.post(`/collections/${id}/books`, { bookIds })
+  return data
+}
+
+export const reorderCollectionBooks = async (id: number, bookIds: number[]): Promise<Collection> => {
+  const { data } = await api.put(`/collections/${id}/books`, { bookIds })
+  return data
+}
+
+export const removeCollectionBook = async (id: number, bookId: number): Promise<void> => {
+  await api.delete(`/collections/${id}/books/${bookId}`)
+}
+
 // Indexer endpoints
 export const getIndexers = async (): Promise<Indexer[]> => {
   const { data } = await api.get('/indexers')
@@ -415,8 +677,55 @@ export const createDownloadClient = async (client: Omit<DownloadClient, 'id'>):
 }
 
 // Media file endpoints
-export const streamMediaFile = (id: number): string => {
-  return `${API_BASE}/api/v1/mediafiles/${id}/stream`
+// For players that can't play an audiobook's format, such as browsers without M4B support.
+// A transcoded stream seeks by starting another from start seconds.
+export interface TranscodeOptions {
+  format: 'mp3' | 'aac'
+  start?: number
+  duration?: number // Seconds; the rest of the audio if left out
+  bitrate?: number // kbps
+}
+
+export const streamMediaFile = (id: number, transcode?: TranscodeOptions): string => {
+  const url = `${API_BASE}/api/v1/mediafiles/${id}/stream`
+  if (!transcode) return url
+  const params = new URLSearchParams({ transcode: transcode.format })
+  if (transcode.start) params.set('start', transcode.start.toString())
+  if (transcode.duration) params.set('duration', transcode.duration.toString())
+  if (transcode.bitrate) params.set('bitrate', transcode.bitrate.toString())
+  return `${url}?${params}`
+}
+
+// What's inside an EPUB, for the web reader to fetch a resource at a time
+export interface EPUBResource {
+  id: string
+  path: string // Inside the EPUB
+  url: string
+  mediaType: string
+  properties?: string
+}
+
+export interface EPUBContents {
+  mediaFileId: number
+  baseUrl: string // Resources are at baseUrl + their path
+  packagePath: string
+  version?: string
+  title?: string
+  navPath?: string // EPUB 3 table of contents
+  ncxPath?: string // EPUB 2 table of contents
+  coverPath?: string
+  resources: EPUBResource[]
+  spine: { id: string; path: string; url: string; linear: boolean }[] // Reading order
+}
+
+export const getEPUBContents = async (id: number): Promise<EPUBContents> => {
+  const { data } = await api.get(`/mediafiles/${id}/epub`)
+  return data
+}
+
+// The URL of a file inside an EPUB, by its path
+export const epubResourceUrl = (id: number, path: string): string => {
+  return `${API_BASE}/api/v1/mediafiles/${id}/epub/${path.split('/').map(encodeURIComponent).join('/')}`
 }
 
 export const sendToKindle = async (id: number): Promise<{ message: string }> => {
@@ -445,14 +754,107 @@ export const updateUser = async (id: number, user: Partial<User>): Promise<User>
   return data
 }
 
+// What a user can see of the library (admins only). A restricted user sees only books
+// sharing one of their tags and books in collections shared with them.
+export interface UserLibrary {
+  restricted: boolean
+  tags: number[]
+  collectionIds: number[]
+  bookCount: number // Books the user can see
+}
+
+export const getUserLibrary = async (id: number): Promise<UserLibrary> => {
+  const { data } = await api.get(`/users/${id}/library`)
+  return data
+}
+
+export const setUserLibrary = async (
+  id: number,
+  library: { restricted: boolean; tags: number[]; collectionIds: number[] }
+): Promise<UserLibrary> => {
+  const { data } = await api.put(`/users/${id}/library`, library)
+  return data
+}
+
 // Progress tracking
 export const getProgress = async (mediaFileId: number): Promise<ReadProgress> => {
   const { data } = await api.get(`/progress/${mediaFileId}`)
   return data
 }
 
-export const updateProgress = async (mediaFileId: number, progress: number, position: number): Promise<void> => {
-  await api.put(`/progress/${mediaFileId}`, { progress, position })
+export const updateProgress = async (
+  mediaFileId: number,
+  progress: number,
+  position: number,
+  device?: string
+): Promise<ResumeProgress> => {
+  const { data } = await api.put(`/progress/${mediaFileId}`, { progress, position, device })
+  return data
+}
+
+export const getResume = async (
+  mediaFileId: number,
+  params?: { device?: string; strategy?: ProgressStrategy }
+): Promise<ResumeProgress> => {
+  const { data } = await api.get(`/progress/${mediaFileId}/resume`, { params })
+  return data
+}
+
+// Bookmarks and highlights
+export type AnnotationType = 'bookmark' | 'highlight'
+
+export interface Annotation {
+  id: number
+  mediaFileId: number
+  bookId: number
+  mediaType: 'ebook' | 'audiobook'
+  type: AnnotationType
+  locator?: string // Where it is in an ebook: a CFI, or the reader's locator
+  position: number // Seconds into an audiobook, or the page
+  endPosition?: number // Where an audiobook highlight ends
+  chapter?: string
+  text?: string
+  note?: string
+  color?: string
+  createdAt: string
+  updatedAt: string
+}
+
+export interface AnnotationFilter {
+  bookId?: number
+  mediaFileId?: number
+  type?: AnnotationType
+}
+
+export const getAnnotations = async (params?: AnnotationFilter): Promise<Annotation[]> => {
+  const { data } = await api.get('/annotations', { params })
+  return data
+}
+
+export const createAnnotation = async (
+  annotation: Omit<Annotation, 'id' | 'bookId' | 'mediaType' | 'createdAt' | 'updatedAt'>
+): Promise<Annotation> => {
+  const { data } = await api.post('/annotations', annotation)
+  return data
+}
+
+export const updateAnnotation = async (
+  id: number,
+  changes: Partial<Pick<Annotation, 'text' | 'note' | 'color'>>
+): Promise<Annotation> => {
+  const { data } = await api.put(`/annotations/${id}`, changes)
+  return data
+}
+
+export const deleteAnnotation = async (id: number): Promise<void> => {
+  await api.delete(`/annotations/${id}`)
+}
+
+export const exportAnnotations = async (
+  params?: AnnotationFilter & { format?: 'markdown' | 'json' }
+): Promise<Blob> => {
+  const { data } = await api.get('/annotations/export', { params, responseType: 'blob' })
+  return data
 }
 
 // Settings
@@ -466,16 +868,28 @@ export const updateSettings = async (settings: Record<string, unknown>): Promise
 }
 
 // Media Management Settings
+
+// How downloaded files are brought into the library. Hardlinks and reflinks fall back to
+// copying when the download and the library are on different filesystems.
+export type ImportOperation = 'move' | 'copy' | 'hardlink' | 'reflink'
+
 export interface MediaSettings {
   ebookRootFolder: string
   audiobookRootFolder: string
   fileNamingEbook: string
   fileNamingAudiobook: string
   folderNaming: string
-  useHardlinks: boolean
+  asciiFileNames: boolean // Transliterate accented letters in file names
+  replaceIllegalCharacters: boolean // Replace characters not allowed in file names rather than removing them
+  importOperation: ImportOperation
   recycleBinEnabled: boolean
   recycleBinPath: string
+  recycleBinRetentionDays: number // 0 keeps files until the bin is emptied
   rescanAfterImport: boolean
+  watchDownloads: boolean // Import files as soon as they appear in the downloads folder
+  writeMetadataFiles: boolean // Write metadata.opf, desc.txt and cover.jpg into book folders
+  tagAudioFiles: boolean // Rewrite imported audiobooks' tags and cover art with ffmpeg
+  minimumFreeSpaceMb: number // Space a grab must leave free in the downloads folder; 0 turns the check off
 }
 
 export interface RootFolder {
@@ -483,6 +897,8 @@ export interface RootFolder {
   path: string
   mediaType: 'ebook' | 'audiobook'
   name: string
+  isDefault: boolean // Books without a root folder of their own are imported here
+  importOperation: ImportOperation | '' // Empty uses the media management setting
   freeSpace: number
   totalSpace: number
   accessible: boolean
@@ -497,6 +913,22 @@ export const updateMediaSettings = async (settings: Partial<MediaSettings>): Pro
   await api.put('/settings/media', settings)
 }
 
+// Free and total space on the disks holding the root folders and the books, audiobooks
+// and downloads folders
+export interface DiskSpace {
+  path: string
+  label: string
+  type: 'rootFolder' | 'books' | 'audiobooks' | 'downloads'
+  freeSpace: number
+  totalSpace: number
+  accessible: boolean
+}
+
+export const getDiskSpace = async (): Promise<DiskSpace[]> => {
+  const { data } = await api.get('/diskspace')
+  return data
+}
+
 // General settings
 export interface GeneralSettings {
   instanceName: string
@@ -504,6 +936,28 @@ export interface GeneralSettings {
   preferredLanguages: string[]
   startPage: string
   dateFormat: string
+  checkForUpdates: boolean // Check GitHub for new releases twice a day
+  urlBase: string // Path Shelfarr is served under, such as /shelfarr
+  urlBaseFromEnv: boolean // Set by SHELFARR_URL_BASE, so it can't be changed in settings
+  logLevel: LogLevel
+  logComponentLevels: Record<string, LogLevel> // Components logged at their own level
+  logToFile: boolean
+  logFilePath: string // Read-only
+  logMaxSizeMb: number // The log file is rotated when it reaches this size
+  logMaxFiles: number // Rotated files kept; 0 keeps all
+  logMaxAgeDays: number // Rotated files older than this are deleted; 0 keeps them
+  httpsEnabled: boolean // HTTPS settings take effect on restart
+  httpsListenAddr: string
+  httpsCertPath: string // Empty, with httpsKeyPath, for a self-signed certificate
+  httpsKeyPath: string
+  httpsRedirect: boolean // Redirect plain HTTP to HTTPS
+  rateLimitEnabled: boolean
+  rateLimitPerMinute: number // Sustained API requests per client
+  rateLimitBurst: number
+  loginMaxFailures: number // Failed logins from an address before it's locked out; 0 never
+  loginLockoutMinutes: number // First lockout, doubled for each further failure
+  corsAllowedOrigins: string[] // Origins, such as https://books.example.com, allowed to call the API; * for any
+  corsAllowCredentials: boolean // Can't be used with *
 }
 
 export interface LanguageOption {
@@ -525,8 +979,17 @@ export const getAvailableLanguages = async (): Promise<LanguageOption[]> => {
   return data
 }
 
-export const getNamingPreview = async (template: string): Promise<{ template: string; preview: string }> => {
-  const { data } = await api.get('/settings/media/naming-preview', { params: { template } })
+export interface NamingPreview {
+  template: string
+  preview: string // A book in a series
+  standalone: string // A book outside a series
+}
+
+export const getNamingPreview = async (
+  template: string,
+  options: { mediaType?: 'ebook' | 'audiobook'; ascii?: boolean; replaceIllegal?: boolean } = {}
+): Promise<NamingPreview> => {
+  const { data } = await api.get('/settings/media/naming-preview', { params: { template, ...options } })
   return data
 }
 
@@ -553,15 +1016,52 @@ export const getRootFolders = async (): Promise<RootFolder[]> => {
   return data
 }
 
-export const addRootFolder = async (folder: { path: string; mediaType: string; name?: string }): Promise<RootFolder> => {
+export const addRootFolder = async (folder: { path: string; mediaType: string; name?: string; isDefault?: boolean }): Promise<RootFolder> => {
   const { data } = await api.post('/rootfolders', folder)
   return data
 }
 
+export const updateRootFolder = async (id: number, folder: Partial<RootFolder>): Promise<RootFolder> => {
+  const { data } = await api.put(`/rootfolders/${id}`, folder)
+  return data
+}
+
 export const deleteRootFolder = async (id: number): Promise<void> => {
   await api.delete(`/rootfolders/${id}`)
 }
 
+// Recycle bin
+export interface RecycledFile {
+  id: number
+  bookId: number
+  bookTitle: string
+  fileName: string
+  originalPath: string
+  fileSize: number
+  format: string
+  mediaType: 'ebook' | 'audiobook'
+  deletedAt: number
+  expiresAt?: number // Unset when files are kept until the bin is emptied
+}
+
+export const getRecycleBin = async (): Promise<RecycledFile[]> => {
+  const { data } = await api.get('/recyclebin')
+  return data
+}
+
+export const restoreRecycledFile = async (id: number): Promise<void> => {
+  await api.post(`/recyclebin/${id}/restore`)
+}
+
+export const deleteRecycledFile = async (id: number): Promise<void> => {
+  await api.delete(`/recyclebin/${id}`)
+}
+
+export const emptyRecycleBin = async (): Promise<{ deleted: number }> => {
+  const { data } = await api.delete('/recyclebin')
+  return data
+}
+
 export const getProfiles = async (): Promise<QualityProfile[]> => {
   const { data } = await api.get('/profiles')
   return data
@@ -587,11 +1087,62 @@ export const deleteProfile = async (id: number): Promise<void> => {
 }
 
 // Import endpoints
-export const getPendingImports = async (): Promise<unknown[]> => {
+// A book a pending import may be. Books not yet in the library come from a metadata provider.
+export interface ImportCandidate {
+  score: number // 0-100
+  bookId?: number
+  inLibrary: boolean
+  provider?: string
+  providerId?: string
+  title: string
+  author: string
+  seriesName?: string
+  seriesIndex?: number
+  coverUrl?: string
+  releaseYear?: number
+}
+
+export interface PendingImport {
+  path: string
+  name: string
+  size: number
+  format: string
+  mediaType: 'ebook' | 'audiobook'
+  isFolder: boolean
+  extractedAuthor?: string
+  extractedTitle?: string
+  extractedSeries?: string
+  extractedSeriesNum?: number
+  extractedIsbn?: string
+  extractedAsin?: string
+  hasEmbeddedCover?: boolean // An EPUB with a cover, from getImportCover
+  candidates: ImportCandidate[] // Likely library books, best first
+}
+
+export const getPendingImports = async (): Promise<PendingImport[]> => {
   const { data } = await api.get('/import/pending')
   return data
 }
 
+export const getImportSuggestions = async (params: {
+  path?: string
+  title?: string
+  author?: string
+}): Promise<ImportCandidate[]> => {
+  const { data } = await api.get('/import/suggestions', { params })
+  return data
+}
+
+export const getMediaFileCover = async (id: number): Promise<Blob> => {
+  const { data } = await api.get(`/mediafiles/${id}/cover`, { responseType: 'blob' })
+  return data
+}
+
+export const getImportCover = async (path: string): Promise<Blob> => {
+  const { data } = await api.get('/import/cover', { params: { path }, responseType: 'blob' })
+  return data
+}
+
 export const manualImport = async (filePath: string, bookId: number, mediaType: string, editionName?: string): Promise<void> => {
   await api.post('/import/manual', { filePath, bookId, mediaType, editionName })
 }
@@ -614,6 +1165,27 @@ export const getDownloads = async (status?: string): Promise<Download[]> => {
   return data
 }
 
+// Real-time events (server-sent events)
+export interface ServerEvent {
+  type: string // e.g. download.progress, import.completed, task.updated, health.changed
+  timestamp: number
+  data: Record<string, unknown>
+}
+
+// subscribeToEvents opens the event stream and returns a function that closes it.
+// The browser reconnects on its own if the connection drops.
+export const subscribeToEvents = (onEvent: (event: ServerEvent) => void): (() => void) => {
+  const source = new EventSource(`${API_BASE}/api/v1/events`)
+  source.onmessage = (message) => {
+    try {
+      onEvent(JSON.parse(message.data))
+    } catch {
+      // Ignore malformed events
+    }
+  }
+  return () => source.close()
+}
+
 export const getDownload = async (id: number): Promise<Download> => {
   const { data } = await api.get(`/downloads/${id}`)
   return data
@@ -626,14 +1198,55 @@ export const triggerDownload = async (params: {
   title: string
   size: number
   format: string
+  protocol?: string
   mediaType: string
 }): Promise<Download> => {
   const { data } = await api.post('/downloads', params)
   return data
 }
 
-export const deleteDownload = async (id: number): Promise<void> => {
-  await api.delete(`/downloads/${id}`)
+export const deleteDownload = async (id: number, options?: {
+  removeFromClient?: boolean
+  blocklist?: boolean
+  reason?: string
+}): Promise<void> => {
+  await api.delete(`/downloads/${id}`, { params: options })
+}
+
+// Queue actions for stuck or mis-matched downloads
+export const importQueueItem = async (id: number, options?: {
+  bookId?: number
+  mediaType?: 'ebook' | 'audiobook'
+  path?: string
+}): Promise<Download> => {
+  const { data } = await api.post(`/downloads/${id}/import`, options || {})
+  return data
+}
+
+export const ignoreQueueItem = async (id: number): Promise<void> => {
+  await api.post(`/downloads/${id}/ignore`)
+}
+
+// Blocklist endpoints
+export interface BlocklistItem {
+  id: number
+  bookId: number
+  bookTitle?: string
+  title: string
+  indexer: string
+  downloadUrl: string
+  infoHash?: string
+  reason: string
+  createdAt: number
+}
+
+export const getBlocklist = async (bookId?: number): Promise<BlocklistItem[]> => {
+  const { data } = await api.get('/blocklist', { params: { bookId } })
+  return data
+}
+
+export const deleteBlocklistItem = async (id: number): Promise<void> => {
+  await api.delete(`/blocklist/${id}`)
 }
 
 export const automaticSearch = async (bookId: number, mediaType?: string): Promise<{
@@ -723,17 +1336,54 @@ export const getWantedCutoff = async (page?: number, pageSize?: number): Promise
 // System endpoints
 export interface SystemStatus {
   version: string
+  build: {
+    version: string
+    commit?: string
+    buildDate?: string
+    branch?: string
+    modified?: boolean // Built from a checkout with uncommitted changes
+    goVersion: string
+  }
   startTime: string
   uptime: string
   os: string
   arch: string
   goVersion: string
+  timezone: string
+  update: {
+    enabled: boolean
+    available: boolean // latestVersion is newer than the running version
+    currentVersion: string
+    latestVersion?: string
+    releaseName?: string
+    releaseUrl?: string
+    publishedAt?: string
+    checkedAt?: string
+    error?: string // Why the last check failed
+  }
+  runtime: {
+    numCpu: number
+    gomaxprocs: number
+    goroutines: number
+    memoryAlloc: number
+    memorySys: number
+    numGc: number
+  }
   database: {
     type: string
+    version: string
     path: string
     size: number
+    schemaVersion: number
     status: string
   }
+  paths: {
+    config: string
+    database: string
+    books: string
+    audiobooks: string
+    downloads: string
+  }
   disk: {
     booksPath: { path: string; exists: boolean; writable: boolean; usedBytes?: number }
     audiobooksPath: { path: string; exists: boolean; writable: boolean; usedBytes?: number }
@@ -759,6 +1409,33 @@ export const getSystemStatus = async (): Promise<SystemStatus> => {
   return data
 }
 
+// A problem found by the health checks. message says what's wrong and help how to fix it.
+export interface HealthCheck {
+  source: string // e.g. "Indexer MAM"
+  severity: 'notice' | 'warning' | 'error'
+  message: string
+  help: string
+}
+
+export interface HealthReport {
+  status: 'healthy' | 'degraded' | 'unhealthy'
+  providers: {
+    name: string
+    state: 'closed' | 'open' | 'half-open'
+    consecutiveFailures: number
+    lastError?: string
+    lastFailureAt?: string
+    lastSuccessAt?: string
+    retryAt?: string
+  }[]
+  checks: HealthCheck[]
+}
+
+export const getHealth = async (): Promise<HealthReport> => {
+  const { data } = await api.get('/health')
+  return data
+}
+
 export interface TaskInfo {
   name: string
   interval: string
@@ -779,15 +1456,251 @@ export const runSystemTask = async (name: string): Promise<{ message: string }>
   return data
 }
 
-export const getSystemLogs = async (limit?: number): Promise<Array<{
-  timestamp: string
-  level: string
+// Log endpoints
+export type LogLevel = 'debug' | 'info' | 'warn' | 'error'
+
+export interface LogEntry {
+  id: number // Increases with each entry; pass the last one as `after` to follow the log
+  time: string
+  level: LogLevel
   message: string
-}>> => {
-  const { data } = await api.get('/system/logs', { params: { limit } })
+  attrs?: Record<string, string>
+}
+
+export interface LogResponse {
+  entries: LogEntry[]
+  level: LogLevel // Minimum level currently logged
+}
+
+export const getLogs = async (params?: {
+  limit?: number
+  level?: LogLevel
+  search?: string
+  after?: number
+}): Promise<LogResponse> => {
+  const { data } = await api.get('/log', { params })
   return data
 }
 
+export const clearLogs = async (): Promise<void> => {
+  await api.delete('/log')
+}
+
+// Audit log endpoints (admins only)
+export interface AuditEntry {
+  id: number
+  time: string
+  userId: number // 0 when unknown, as for failed logins
+  username: string
+  action: string // Such as book.add, settings.update or download.grab
+  summary: string
+  method: string
+  path: string
+  status: number
+  ip: string
+}
+
+export interface AuditLogResponse {
+  entries: AuditEntry[]
+  total: number
+}
+
+export const getAuditLog = async (params?: {
+  userId?: number
+  action?: string
+  search?: string
+  since?: string
+  limit?: number
+  offset?: number
+}): Promise<AuditLogResponse> => {
+  const { data } = await api.get('/audit', { params })
+  return data
+}
+
+// Effective configuration (admins only)
+export interface ConfigValue {
+  name: string
+  env: string // Environment variable that sets it
+  value: string // Secrets are masked
+  source: 'env' | 'file' | 'default'
+}
+
+export interface EffectiveConfig {
+  configFile: string // Empty when there's no config.yml
+  configFileError?: string
+  values: ConfigValue[]
+}
+
+export const getConfig = async (): Promise<EffectiveConfig> => {
+  const { data } = await api.get('/config')
+  return data
+}
+
+// Request endpoints: users ask for books and authors, admins approve them
+export type RequestStatus = 'pending' | 'approved' | 'declined' | 'failed'
+
+export interface MediaRequest {
+  id: number
+  type: 'book' | 'author'
+  provider: string
+  foreignId: string
+  title: string
+  authorName?: string
+  coverUrl?: string
+  mediaType: 'ebook' | 'audiobook' | 'both'
+  note?: string
+  status: RequestStatus
+  reason?: string // Why it was declined, or how adding it went
+  autoApproved: boolean
+  userId: number
+  username: string
+  decidedBy?: string
+  decidedAt?: string
+  bookId?: number
+  authorId?: number
+  bookStatus?: string
+  createdAt: string
+}
+
+// What adding a book or author returns for users who aren't admins, who request it instead
+export interface AddRequestedResponse {
+  message: string
+  requestId: number
+  status: RequestStatus
+}
+
+export interface RequestsResponse {
+  requests: MediaRequest[]
+  total: number
+  canApprove: boolean // Whether the user is an admin
+}
+
+export interface RequestQuota {
+  autoApprove: boolean
+  limit: number // 0 for no limit
+  days: number
+  used: number
+  remaining: number // -1 for no limit
+}
+
+export interface RequestSettings {
+  autoApprove: boolean
+  quota: number // Per user every quotaDays; 0 for no limit
+  quotaDays: number
+}
+
+export const getRequests = async (params?: {
+  status?: RequestStatus
+  userId?: number
+  limit?: number
+  offset?: number
+}): Promise<RequestsResponse> => {
+  const { data } = await api.get('/requests', { params })
+  return data
+}
+
+export const createRequest = async (request: {
+  type: 'book' | 'author'
+  foreignId: string
+  provider?: string
+  mediaType?: string
+  note?: string
+}): Promise<MediaRequest> => {
+  const { data } = await api.post('/requests', request)
+  return data
+}
+
+export const getRequestQuota = async (): Promise<RequestQuota> => {
+  const { data } = await api.get('/requests/quota')
+  return data
+}
+
+export const approveRequest = async (id: number): Promise<MediaRequest> => {
+  const { data } = await api.post(`/requests/${id}/approve`)
+  return data
+}
+
+export const declineRequest = async (id: number, reason?: string): Promise<MediaRequest> => {
+  const { data } = await api.post(`/requests/${id}/decline`, { reason })
+  return data
+}
+
+export const deleteRequest = async (id: number): Promise<void> => {
+  await api.delete(`/requests/${id}`)
+}
+
+export const getRequestSettings = async (): Promise<RequestSettings> => {
+  const { data } = await api.get('/settings/requests')
+  return data
+}
+
+export const updateRequestSettings = async (settings: Partial<RequestSettings>): Promise<RequestSettings> => {
+  const { data } = await api.put('/settings/requests', settings)
+  return data
+}
+
+// Reading goal endpoints
+export type ReadingGoalUnit = 'books' | 'hours'
+
+export interface ReadingGoalBook {
+  bookId: number
+  title: string
+  authorName?: string
+  coverUrl?: string
+  finishedAt?: string
+  hours?: number // For goals in hours
+}
+
+export interface ReadingGoal {
+  id: number
+  year: number
+  month: number // 0 for the whole year
+  unit: ReadingGoalUnit
+  target: number
+  start: string
+  end: string // Exclusive
+  current: number // Books finished, or hours listened, so far
+  percent: number // Of the target; over 100 once it's beaten
+  expected: number // Where you'd be by now at a steady pace
+  onTrack: boolean
+  completed: boolean
+  books?: ReadingGoalBook[] // Only for a single goal
+}
+
+export const getReadingGoals = async (year?: number): Promise<ReadingGoal[]> => {
+  const { data } = await api.get('/goals', { params: { year } })
+  return data
+}
+
+export const getCurrentReadingGoals = async (): Promise<ReadingGoal[]> => {
+  const { data } = await api.get('/goals/current')
+  return data
+}
+
+export const getReadingGoal = async (id: number): Promise<ReadingGoal> => {
+  const { data } = await api.get(`/goals/${id}`)
+  return data
+}
+
+export const createReadingGoal = async (goal: {
+  year: number
+  month?: number
+  unit: ReadingGoalUnit
+  target: number
+}): Promise<ReadingGoal> => {
+  const { data } = await api.post('/goals', goal)
+  return data
+}
+
+export const updateReadingGoal = async (id: number, target: number): Promise<ReadingGoal> => {
+  const { data } = await api.put(`/goals/${id}`, { target })
+  return data
+}
+
+export const deleteReadingGoal = async (id: number): Promise<void> => {
+  await api.delete(`/goals/${id}`)
+}
+
 // Notification endpoints
 export interface Notification {
   id: number
@@ -799,12 +1712,15 @@ export interface Notification {
   telegramBotToken?: string
   telegramChatId?: string
   emailTo?: string
+  scriptPath?: string // Custom script run with the event in SHELFARR_* environment variables
   onGrab: boolean
   onDownload: boolean
   onUpgrade: boolean
   onImport: boolean
   onDelete: boolean
   onHealthIssue: boolean
+  onUpdate: boolean // A new Shelfarr release is available
+  onRequest: boolean // A user requested a book or author that needs approval
 }
 
 export const getNotifications = async (): Promise<Notification[]> => {
@@ -873,7 +1789,11 @@ export const syncList = async (id: number): Promise<{ message: string; booksAdde
 export const apiClient = {
   // Library
   getLibrary,
+  searchLibrary,
   getLibraryStats,
+  scanLibrary,
+  getLibraryRenames,
+  renameLibraryFiles,
   // Books
   getBooks,
   getBook,
@@ -883,6 +1803,10 @@ export const apiClient = {
   getBookEditions,
   getBookContributors,
   refreshBookMetadata,
+  updateBookMetadata,
+  uploadBookCover,
+  setBookCover,
+  resetBookCover,
   // Genres
   getGenres,
   // Metadata refresh
@@ -892,11 +1816,20 @@ export const apiClient = {
   getAuthor,
   addAuthor,
   updateAuthor,
+  uploadAuthorImage,
+  resetAuthorImage,
   deleteAuthor,
+  getAuthorAliases,
+  addAuthorAlias,
+  removeAuthorAlias,
+  mergeAuthors,
   // Series
   getSeries,
   getSeriesDetail,
   addSeriesBooks,
+  mergeSeries,
+  setBookSeries,
+  removeBookSeries,
   // Search
   searchHardcover,
   searchHardcoverAuthors,
@@ -910,6 +1843,28 @@ export const apiClient = {
   getHardcoverAuthor,
   getHardcoverSeries,
   addHardcoverBook,
+  // Tags
+  getTags,
+  createTag,
+  updateTag,
+  deleteTag,
+  setBookTags,
+  setAuthorTags,
+  setProfileTags,
+  // Saved filters
+  getSavedFilters,
+  createSavedFilter,
+  updateSavedFilter,
+  deleteSavedFilter,
+  // Collections
+  getCollections,
+  getCollection,
+  createCollection,
+  updateCollection,
+  deleteCollection,
+  addCollectionBooks,
+  reorderCollectionBooks,
+  removeCollectionBook,
   // Indexers
   getIndexers,
   addIndexer,
@@ -926,24 +1881,41 @@ export const apiClient = {
   testDownloadClientConfig,
   // Media
   streamMediaFile,
+  getEPUBContents,
+  epubResourceUrl,
   sendToKindle,
   // Users
   getUsers,
   getCurrentUser,
   createUser,
   updateUser,
+  getUserLibrary,
+  setUserLibrary,
   // Progress
   getProgress,
   updateProgress,
+  getResume,
+  // Bookmarks and highlights
+  getAnnotations,
+  createAnnotation,
+  updateAnnotation,
+  deleteAnnotation,
+  exportAnnotations,
   // Settings
   getSettings,
   updateSettings,
   getMediaSettings,
   updateMediaSettings,
+  getDiskSpace,
   getNamingPreview,
   getRootFolders,
   addRootFolder,
+  updateRootFolder,
   deleteRootFolder,
+  getRecycleBin,
+  restoreRecycledFile,
+  deleteRecycledFile,
+  emptyRecycleBin,
   getProfiles,
   getProfile,
   createProfile,
@@ -951,13 +1923,22 @@ export const apiClient = {
   deleteProfile,
   // Imports
   getPendingImports,
+  getImportSuggestions,
+  getMediaFileCover,
+  getImportCover,
   manualImport,
   // Downloads
   getDownloads,
   getDownload,
+  subscribeToEvents,
   triggerDownload,
   deleteDownload,
+  importQueueItem,
+  ignoreQueueItem,
   automaticSearch,
+  // Blocklist
+  getBlocklist,
+  deleteBlocklistItem,
   // Activity
   getActivity,
   getActivityHistory,
@@ -967,9 +1948,29 @@ export const apiClient = {
   getWantedCutoff,
   // System
   getSystemStatus,
+  getHealth,
   getSystemTasks,
   runSystemTask,
-  getSystemLogs,
+  getLogs,
+  clearLogs,
+  getAuditLog,
+  getConfig,
+  // Requests
+  getRequests,
+  createRequest,
+  getRequestQuota,
+  approveRequest,
+  declineRequest,
+  deleteRequest,
+  getRequestSettings,
+  updateRequestSettings,
+  // Reading goals
+  getReadingGoals,
+  getCurrentReadingGoals,
+  getReadingGoal,
+  createReadingGoal,
+  updateReadingGoal,
+  deleteReadingGoal,
   // Notifications
   getNotifications,
   createNotification,
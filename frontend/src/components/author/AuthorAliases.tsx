@@ -0,0 +1,79 @@
+import { useState } from 'react'
+import { useMutation, useQueryClient } from '@tanstack/react-query'
+import { Loader2, Plus, X } from 'lucide-react'
+import { Badge } from '@/components/ui/badge'
+import { Input } from '@/components/ui/input'
+import { Button } from '@/components/ui/button'
+import { addAuthorAlias, removeAuthorAlias, type AuthorAlias } from '@/api/client'
+
+interface AuthorAliasesProps {
+  authorId: number
+  aliases: AuthorAlias[]
+}
+
+// AuthorAliases lists the other names an author is matched by, such as pen names or
+// spellings like "J.R.R. Tolkien", so books credited to them join this author
+export function AuthorAliases({ authorId, aliases }: AuthorAliasesProps) {
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+
+  const onSuccess = () => {
+    queryClient.invalidateQueries({ queryKey: ['author', String(authorId)] })
+  }
+
+  const addMutation = useMutation({
+    mutationFn: (alias: string) => addAuthorAlias(authorId, alias),
+    onSuccess: () => {
+      setName('')
+      onSuccess()
+    },
+  })
+
+  const removeMutation = useMutation({
+    mutationFn: (aliasId: number) => removeAuthorAlias(authorId, aliasId),
+    onSuccess,
+  })
+
+  const handleAdd = (e: React.FormEvent) => {
+    e.preventDefault()
+    if (name.trim()) addMutation.mutate(name.trim())
+  }
+
+  return (
+    <div className="mt-4">
+      <div className="flex flex-wrap items-center gap-2">
+        <span className="text-sm text-neutral-400">Also known as</span>
+        {aliases.length === 0 && <span className="text-sm text-neutral-500">No other names</span>}
+        {aliases.map((alias) => (
+          <Badge key={alias.id} variant="secondary" className="gap-1">
+            {alias.name}
+            <button
+              onClick={() => removeMutation.mutate(alias.id)}
+              disabled={removeMutation.isPending}
+              className="hover:opacity-70"
+              title="Remove name"
+            >
+              <X className="h-3 w-3" />
+            </button>
+          </Badge>
+        ))}
+        <form onSubmit={handleAdd} className="flex items-center gap-1">
+          <Input
+            value={name}
+            onChange={(e) => setName(e.target.value)}
+            placeholder="Add a name"
+            className="h-7 w-40 text-sm"
+          />
+          <Button type="submit" variant="ghost" size="icon" className="h-7 w-7" disabled={addMutation.isPending || !name.trim()}>
+            {addMutation.isPending ? <Loader2 className="h-4 w-4 animate-spin" /> : <Plus className="h-4 w-4" />}
+          </Button>
+        </form>
+      </div>
+      {addMutation.isError && (
+        <p className="text-sm text-destructive mt-1">
+          {(addMutation.error as any)?.response?.data?.error || 'Failed to add name'}
+        </p>
+      )}
+    </div>
+  )
+}
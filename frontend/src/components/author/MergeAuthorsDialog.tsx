@@ -0,0 +1,117 @@
+import { useEffect, useMemo, useState } from 'react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import { Loader2 } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { getAuthors, mergeAuthors, invalidateAllBookQueries, type AuthorDetail } from '@/api/client'
+
+interface MergeAuthorsDialogProps {
+  author: AuthorDetail
+  open: boolean
+  onOpenChange: (open: boolean) => void
+}
+
+// MergeAuthorsDialog folds duplicate authors into this one. Their books and series
+// move over and their names become aliases, so later matches find this author.
+export function MergeAuthorsDialog({ author, open, onOpenChange }: MergeAuthorsDialogProps) {
+  const queryClient = useQueryClient()
+  const [filter, setFilter] = useState('')
+  const [selected, setSelected] = useState<Set<number>>(new Set())
+
+  useEffect(() => {
+    if (open) {
+      setFilter('')
+      setSelected(new Set())
+    }
+  }, [open])
+
+  const { data: authors = [], isLoading } = useQuery({
+    queryKey: ['authors'],
+    queryFn: () => getAuthors(),
+    enabled: open,
+  })
+
+  const candidates = useMemo(() => {
+    const query = filter.trim().toLowerCase()
+    return authors
+      .filter((a) => a.id !== author.id)
+      .filter((a) => !query || a.name.toLowerCase().includes(query))
+      .sort((a, b) => a.name.localeCompare(b.name))
+  }, [authors, author.id, filter])
+
+  const mergeMutation = useMutation({
+    mutationFn: () => mergeAuthors(author.id, Array.from(selected)),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['author', String(author.id)] })
+      queryClient.invalidateQueries({ queryKey: ['authors'] })
+      invalidateAllBookQueries(queryClient)
+      onOpenChange(false)
+    },
+  })
+
+  const toggle = (id: number) => {
+    setSelected((s) => {
+      const next = new Set(s)
+      if (next.has(id)) {
+        next.delete(id)
+      } else {
+        next.add(id)
+      }
+      return next
+    })
+  }
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-lg">
+        <DialogHeader>
+          <DialogTitle>Merge Authors into {author.name}</DialogTitle>
+          <DialogDescription>
+            The selected authors are removed; their books and series move to {author.name}, and their
+            names are kept as aliases.
+          </DialogDescription>
+        </DialogHeader>
+
+        <Input value={filter} onChange={(e) => setFilter(e.target.value)} placeholder="Filter authors" />
+
+        <div className="max-h-72 overflow-y-auto space-y-1">
+          {isLoading && <Loader2 className="h-5 w-5 animate-spin mx-auto" />}
+          {!isLoading && candidates.length === 0 && (
+            <p className="text-sm text-muted-foreground text-center py-4">No other authors</p>
+          )}
+          {candidates.map((a) => (
+            <label key={a.id} className="flex items-center gap-3 px-2 py-1.5 rounded hover:bg-accent cursor-pointer">
+              <input type="checkbox" checked={selected.has(a.id)} onChange={() => toggle(a.id)} />
+              <span className="flex-1 truncate">{a.name}</span>
+              {a.bookCount ? <span className="text-xs text-muted-foreground">{a.bookCount} books</span> : null}
+            </label>
+          ))}
+        </div>
+
+        {mergeMutation.isError && (
+          <p className="text-sm text-destructive">
+            {(mergeMutation.error as any)?.response?.data?.error || 'Failed to merge authors'}
+          </p>
+        )}
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => onOpenChange(false)}>
+            Cancel
+          </Button>
+          <Button onClick={() => mergeMutation.mutate()} disabled={mergeMutation.isPending || selected.size === 0}>
+            {mergeMutation.isPending && <Loader2 className="h-4 w-4 animate-spin" />}
+            Merge {selected.size > 0 ? selected.size : ''}
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -0,0 +1,33 @@
+import { useEffect, useState } from 'react'
+import { useQuery } from '@tanstack/react-query'
+import { cn } from '@/lib/utils'
+
+interface BlobImageProps {
+  queryKey: unknown[]
+  fetchImage: () => Promise<Blob>
+  className?: string
+}
+
+// BlobImage shows an image fetched through the API client, for images behind
+// authentication that an <img> can't load by URL
+export function BlobImage({ queryKey, fetchImage, className }: BlobImageProps) {
+  const { data: image } = useQuery({
+    queryKey,
+    queryFn: fetchImage,
+    staleTime: Infinity,
+    retry: false,
+  })
+  const [url, setUrl] = useState<string>()
+
+  useEffect(() => {
+    if (!image) return
+    const objectUrl = URL.createObjectURL(image)
+    setUrl(objectUrl)
+    return () => URL.revokeObjectURL(objectUrl)
+  }, [image])
+
+  if (!url) {
+    return <div className={cn('rounded-md bg-muted flex-shrink-0', className)} />
+  }
+  return <img src={url} alt="" className={cn('rounded-md object-cover flex-shrink-0', className)} />
+}
@@ -0,0 +1,48 @@
+import { useRef } from 'react'
+import { ImageUp, Loader2, Undo2 } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+
+interface CoverActionsProps {
+  isCustom?: boolean
+  isPending?: boolean
+  onUpload: (file: File) => void
+  onReset: () => void
+}
+
+// CoverActions uploads a custom cover or photo, or goes back to the metadata
+// provider's one
+export function CoverActions({ isCustom, isPending, onUpload, onReset }: CoverActionsProps) {
+  const inputRef = useRef<HTMLInputElement>(null)
+
+  return (
+    <div className="flex gap-2 mt-2">
+      <input
+        ref={inputRef}
+        type="file"
+        accept="image/jpeg,image/png,image/gif,image/webp"
+        className="hidden"
+        onChange={(e) => {
+          const file = e.target.files?.[0]
+          if (file) onUpload(file)
+          e.target.value = ''
+        }}
+      />
+      <Button
+        variant="outline"
+        size="sm"
+        className="flex-1"
+        onClick={() => inputRef.current?.click()}
+        disabled={isPending}
+        title="Upload a custom image"
+      >
+        {isPending ? <Loader2 className="h-4 w-4 animate-spin" /> : <ImageUp className="h-4 w-4" />}
+        Upload
+      </Button>
+      {isCustom && (
+        <Button variant="outline" size="sm" onClick={onReset} disabled={isPending} title="Use the metadata provider's image">
+          <Undo2 className="h-4 w-4" />
+        </Button>
+      )}
+    </div>
+  )
+}
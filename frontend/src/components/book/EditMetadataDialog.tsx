@@ -0,0 +1,216 @@
+import { useEffect, useState, type ComponentProps } from 'react'
+import { useMutation, useQueryClient } from '@tanstack/react-query'
+import { Loader2, Lock, LockOpen } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { updateBookMetadata, invalidateAllBookQueries, type MetadataUpdate } from '@/api/client'
+import type { Book } from '@/types'
+
+interface EditMetadataDialogProps {
+  book: Book
+  open: boolean
+  onOpenChange: (open: boolean) => void
+}
+
+type FormField = 'title' | 'sortTitle' | 'subtitle' | 'description' | 'releaseDate' | 'pageCount' | 'isbn' | 'series' | 'seriesIndex' | 'genres'
+
+// The lock each form field falls under; the series and its index share one
+const lockFor: Record<FormField, string> = {
+  title: 'title',
+  sortTitle: 'sortTitle',
+  subtitle: 'subtitle',
+  description: 'description',
+  releaseDate: 'releaseDate',
+  pageCount: 'pageCount',
+  isbn: 'isbn',
+  series: 'series',
+  seriesIndex: 'series',
+  genres: 'genres',
+}
+
+function formValues(book: Book): Record<FormField, string> {
+  return {
+    title: book.title,
+    sortTitle: book.sortTitle || '',
+    subtitle: book.subtitle || '',
+    description: book.description || '',
+    releaseDate: book.releaseDate || '',
+    pageCount: book.pageCount ? String(book.pageCount) : '',
+    isbn: book.isbn || '',
+    series: book.series?.name || '',
+    seriesIndex: book.seriesIndex != null ? String(book.seriesIndex) : '',
+    genres: (book.genres || []).join(', '),
+  }
+}
+
+// EditMetadataDialog corrects a book's metadata by hand. Edited fields are locked so
+// metadata refreshes keep the corrections; locks can be toggled on any field.
+export function EditMetadataDialog({ book, open, onOpenChange }: EditMetadataDialogProps) {
+  const queryClient = useQueryClient()
+  const [values, setValues] = useState(() => formValues(book))
+  const [locked, setLocked] = useState<Set<string>>(() => new Set(book.lockedFields))
+
+  useEffect(() => {
+    if (open) {
+      setValues(formValues(book))
+      setLocked(new Set(book.lockedFields))
+    }
+  }, [open, book])
+
+  const saveMutation = useMutation({
+    mutationFn: (update: MetadataUpdate) => updateBookMetadata(book.id, update),
+    onSuccess: () => {
+      invalidateAllBookQueries(queryClient)
+      onOpenChange(false)
+    },
+  })
+
+  const original = formValues(book)
+  const changed = (field: FormField) => values[field] !== original[field]
+
+  const setValue = (field: FormField, value: string) => {
+    setValues((v) => ({ ...v, [field]: value }))
+    setLocked((l) => new Set(l).add(lockFor[field]))
+  }
+
+  const toggleLock = (lock: string) => {
+    setLocked((l) => {
+      const next = new Set(l)
+      if (next.has(lock)) {
+        next.delete(lock)
+      } else {
+        next.add(lock)
+      }
+      return next
+    })
+  }
+
+  const handleSave = () => {
+    const update: MetadataUpdate = {}
+    if (changed('title')) update.title = values.title
+    if (changed('sortTitle')) update.sortTitle = values.sortTitle
+    if (changed('subtitle')) update.subtitle = values.subtitle
+    if (changed('description')) update.description = values.description
+    if (changed('releaseDate')) update.releaseDate = values.releaseDate
+    if (changed('pageCount')) update.pageCount = Number(values.pageCount) || 0
+    if (changed('isbn')) update.isbn = values.isbn
+    if (changed('series')) update.series = values.series
+    if (changed('seriesIndex') && values.seriesIndex !== '') update.seriesIndex = Number(values.seriesIndex)
+    if (changed('genres')) {
+      update.genres = values.genres.split(',').map((g) => g.trim()).filter(Boolean)
+    }
+
+    const wasLocked = new Set(book.lockedFields)
+    const lockChanges: Record<string, boolean> = {}
+    for (const lock of new Set(Object.values(lockFor))) {
+      if (locked.has(lock) !== wasLocked.has(lock)) lockChanges[lock] = locked.has(lock)
+    }
+    if (Object.keys(lockChanges).length > 0) update.locked = lockChanges
+
+    saveMutation.mutate(update)
+  }
+
+  const field = (name: FormField, label: string, props: ComponentProps<typeof Input> = {}) => {
+    const lock = lockFor[name]
+    const isLocked = locked.has(lock)
+    return (
+      <div className="space-y-1">
+        <Label htmlFor={`metadata-${name}`}>{label}</Label>
+        <div className="flex gap-2">
+          <Input
+            id={`metadata-${name}`}
+            value={values[name]}
+            onChange={(e) => setValue(name, e.target.value)}
+            {...props}
+          />
+          <Button
+            type="button"
+            variant="ghost"
+            size="icon"
+            onClick={() => toggleLock(lock)}
+            title={isLocked ? 'Locked: refreshes keep this value' : 'Unlocked: refreshes may change this value'}
+          >
+            {isLocked ? <Lock className="h-4 w-4" /> : <LockOpen className="h-4 w-4 text-muted-foreground" />}
+          </Button>
+        </div>
+      </div>
+    )
+  }
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-2xl max-h-[90vh] overflow-y-auto">
+        <DialogHeader>
+          <DialogTitle>Edit Metadata</DialogTitle>
+          <DialogDescription>
+            Edited fields are locked, so refreshing metadata won't overwrite them.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="grid gap-4">
+          {field('title', 'Title')}
+          <div className="grid grid-cols-2 gap-4">
+            {field('sortTitle', 'Sort Title')}
+            {field('subtitle', 'Subtitle')}
+          </div>
+          <div className="space-y-1">
+            <Label htmlFor="metadata-description">Description</Label>
+            <div className="flex gap-2">
+              <textarea
+                id="metadata-description"
+                rows={5}
+                value={values.description}
+                onChange={(e) => setValue('description', e.target.value)}
+                className="flex w-full rounded-md border border-input bg-background px-3 py-2 text-sm placeholder:text-muted-foreground focus-visible:outline-none focus-visible:ring-2 focus-visible:ring-ring"
+              />
+              <Button
+                type="button"
+                variant="ghost"
+                size="icon"
+                onClick={() => toggleLock('description')}
+                title={locked.has('description') ? 'Locked: refreshes keep this value' : 'Unlocked: refreshes may change this value'}
+              >
+                {locked.has('description') ? <Lock className="h-4 w-4" /> : <LockOpen className="h-4 w-4 text-muted-foreground" />}
+              </Button>
+            </div>
+          </div>
+          <div className="grid grid-cols-3 gap-4">
+            {field('releaseDate', 'Release Date', { type: 'date' })}
+            {field('pageCount', 'Pages', { type: 'number', min: 0 })}
+            {field('isbn', 'ISBN')}
+          </div>
+          <div className="grid grid-cols-3 gap-4">
+            <div className="col-span-2">{field('series', 'Series')}</div>
+            {field('seriesIndex', 'Number', { type: 'number', step: 'any' })}
+          </div>
+          {field('genres', 'Genres', { placeholder: 'Fantasy, Adventure' })}
+        </div>
+
+        {saveMutation.isError && (
+          <p className="text-sm text-destructive">
+            {(saveMutation.error as any)?.response?.data?.error || 'Failed to save metadata'}
+          </p>
+        )}
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => onOpenChange(false)}>
+            Cancel
+          </Button>
+          <Button onClick={handleSave} disabled={saveMutation.isPending || !values.title.trim()}>
+            {saveMutation.isPending && <Loader2 className="h-4 w-4 animate-spin" />}
+            Save
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -5,6 +5,7 @@ import { Skeleton } from '@/components/ui/skeleton'
 
 interface EditionsTableProps {
   bookId: number
+  onSelectCover?: (editionId: number) => void // Makes edition covers clickable to use as the book's
 }
 
 function formatDuration(seconds: number): string {
@@ -23,7 +24,7 @@ function formatDate(dateString?: string): string {
   })
 }
 
-export function EditionsTable({ bookId }: EditionsTableProps) {
+export function EditionsTable({ bookId, onSelectCover }: EditionsTableProps) {
   const { data, isLoading } = useQuery({
     queryKey: ['editions', bookId],
     queryFn: () => getBookEditions(bookId),
@@ -65,7 +66,16 @@ export function EditionsTable({ bookId }: EditionsTableProps) {
               <tr key={edition.id} className="border-b border-border last:border-0 hover:bg-muted/30 transition-colors">
                 <td className="p-2">
                   <div className="h-12 w-8 bg-muted rounded overflow-hidden shadow-sm">
-                    {edition.coverUrl ? (
+                    {edition.coverUrl && onSelectCover ? (
+                      <button
+                        type="button"
+                        className="h-full w-full hover:opacity-80 transition-opacity"
+                        onClick={() => onSelectCover(edition.id)}
+                        title="Use this cover"
+                      >
+                        <img src={edition.coverUrl} alt="" className="h-full w-full object-cover" loading="lazy" />
+                      </button>
+                    ) : edition.coverUrl ? (
                       <img src={edition.coverUrl} alt="" className="h-full w-full object-cover" loading="lazy" />
                     ) : (
                       <div className="h-full w-full flex items-center justify-center">
@@ -0,0 +1,108 @@
+import { useEffect, useState } from 'react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import { Loader2, Plus } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { getCollections, createCollection, addCollectionBooks } from '@/api/client'
+
+interface AddToCollectionDialogProps {
+  bookIds: number[]
+  open: boolean
+  onOpenChange: (open: boolean) => void
+}
+
+// AddToCollectionDialog puts books on one of the user's collections, or a new one
+export function AddToCollectionDialog({ bookIds, open, onOpenChange }: AddToCollectionDialogProps) {
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+
+  useEffect(() => {
+    if (open) setName('')
+  }, [open])
+
+  const { data: allCollections = [], isLoading } = useQuery({
+    queryKey: ['collections'],
+    queryFn: getCollections,
+    enabled: open,
+  })
+  // Collections shared with the user are read-only
+  const collections = allCollections.filter((collection) => !collection.shared)
+
+  const addMutation = useMutation({
+    mutationFn: async (collectionId: number | null) => {
+      const id = collectionId ?? (await createCollection({ name: name.trim() })).id
+      return addCollectionBooks(id, bookIds)
+    },
+    onSuccess: (collection) => {
+      queryClient.invalidateQueries({ queryKey: ['collections'] })
+      queryClient.invalidateQueries({ queryKey: ['collection', collection.id] })
+      onOpenChange(false)
+    },
+  })
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-md">
+        <DialogHeader>
+          <DialogTitle>Add to Collection</DialogTitle>
+          <DialogDescription>
+            {bookIds.length === 1 ? 'Add this book to a collection.' : `Add ${bookIds.length} books to a collection.`}
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="max-h-72 overflow-y-auto space-y-1">
+          {isLoading && <Loader2 className="h-5 w-5 animate-spin mx-auto" />}
+          {!isLoading && collections.length === 0 && (
+            <p className="text-sm text-muted-foreground text-center py-4">No collections yet</p>
+          )}
+          {collections.map((collection) => (
+            <button
+              key={collection.id}
+              type="button"
+              onClick={() => addMutation.mutate(collection.id)}
+              disabled={addMutation.isPending}
+              className="flex w-full items-center gap-3 px-2 py-1.5 rounded hover:bg-accent text-left"
+            >
+              <span className="flex-1 truncate">{collection.name}</span>
+              <span className="text-xs text-muted-foreground">{collection.bookCount} books</span>
+            </button>
+          ))}
+        </div>
+
+        <form
+          onSubmit={(e) => {
+            e.preventDefault()
+            if (name.trim()) addMutation.mutate(null)
+          }}
+          className="flex items-center gap-2"
+        >
+          <Input value={name} onChange={(e) => setName(e.target.value)} placeholder="New collection" />
+          <Button type="submit" variant="outline" disabled={!name.trim() || addMutation.isPending}>
+            <Plus className="h-4 w-4" />
+            Create
+          </Button>
+        </form>
+
+        {addMutation.isError && (
+          <p className="text-sm text-destructive">
+            {(addMutation.error as any)?.response?.data?.error || 'Failed to add to collection'}
+          </p>
+        )}
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => onOpenChange(false)}>
+            Cancel
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
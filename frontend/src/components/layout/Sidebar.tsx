@@ -10,16 +10,22 @@ import {
   Import,
   Activity,
   Bell,
-  Library
+  Library,
+  BookMarked,
+  Inbox,
+  Target
 } from 'lucide-react'
 
 const navigation = [
   { name: 'Library', href: '/', icon: Library },
   { name: 'Series', href: '/series', icon: Layers },
   { name: 'Authors', href: '/authors', icon: Users },
+  { name: 'Collections', href: '/collections', icon: BookMarked },
   { name: 'Search & Add', href: '/search', icon: Search },
   { name: 'Activity', href: '/activity', icon: Activity },
   { name: 'Wanted', href: '/wanted', icon: Download },
+  { name: 'Requests', href: '/requests', icon: Inbox },
+  { name: 'Goals', href: '/goals', icon: Target },
   { name: 'Manual Import', href: '/import', icon: Import },
 ]
 
@@ -1,5 +1,5 @@
 import { Book } from '@/types'
-import { cn, getStatusColor } from '@/lib/utils'
+import { cn, coverSrc, getStatusColor } from '@/lib/utils'
 import { Badge } from '@/components/ui/badge'
 import { BookOpen, Star } from 'lucide-react'
 import { Link } from 'react-router-dom'
@@ -33,8 +33,9 @@ export function BookCard({ book, selected, onSelect, selectionMode }: BookCardPr
       <div className="aspect-[2/3] overflow-hidden">
         {book.coverUrl ? (
           <img
-            src={book.coverUrl}
+            src={coverSrc(book.coverUrl, 300)}
             alt={book.title}
+            loading="lazy"
             className="h-full w-full object-cover transition-transform duration-300 group-hover:scale-105"
           />
         ) : (
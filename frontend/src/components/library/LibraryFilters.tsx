@@ -1,6 +1,7 @@
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
-import { Search, Filter, Grid, List, SortAsc } from 'lucide-react'
+import { Search, Filter, Grid, List, SortAsc, Bookmark } from 'lucide-react'
+import type { SavedFilter } from '@/types'
 
 interface LibraryFiltersProps {
   searchQuery: string
@@ -14,6 +15,9 @@ interface LibraryFiltersProps {
   // Optional additional filters
   monitoredFilter?: string
   onMonitoredChange?: (value: string) => void
+  savedFilters?: SavedFilter[]
+  savedFilterId?: string
+  onSavedFilterChange?: (id: string) => void
 }
 
 const statusOptions = [
@@ -49,6 +53,9 @@ export function LibraryFilters({
   onViewModeChange,
   monitoredFilter,
   onMonitoredChange,
+  savedFilters,
+  savedFilterId,
+  onSavedFilterChange,
 }: LibraryFiltersProps) {
   return (
     <div className="flex flex-wrap items-center gap-4">
@@ -95,6 +102,25 @@ export function LibraryFilters({
         </select>
       )}
 
+      {/* Saved Filter */}
+      {onSavedFilterChange && savedFilters && savedFilters.length > 0 && (
+        <div className="flex items-center gap-2">
+          <Bookmark className="h-4 w-4 text-muted-foreground" />
+          <select
+            value={savedFilterId || ''}
+            onChange={(e) => onSavedFilterChange(e.target.value)}
+            className="h-10 rounded-md border border-input bg-background px-3 text-sm"
+          >
+            <option value="">No Saved Filter</option>
+            {savedFilters.map((filter) => (
+              <option key={filter.id} value={filter.id}>
+                {filter.name}
+              </option>
+            ))}
+          </select>
+        </div>
+      )}
+
       {/* Sort */}
       <div className="flex items-center gap-2">
         <SortAsc className="h-4 w-4 text-muted-foreground" />
@@ -23,6 +23,7 @@ import {
   DialogTitle,
 } from '@/components/ui/dialog'
 import { getHardcoverBook, addHardcoverBook } from '@/api/client'
+import { URL_BASE } from '@/lib/utils'
 
 export type MediaTypeOption = 'ebook' | 'audiobook' | 'both'
 export type DownloadMode = 'auto' | 'manual' | 'none'
@@ -31,7 +32,8 @@ interface AddBookModalProps {
   bookId: string | null
   isOpen: boolean
   onClose: () => void
-  onSuccess: (bookId: number, downloadMode: DownloadMode, mediaType: MediaTypeOption) => void
+  // bookId is undefined when the book was requested for an admin to approve
+  onSuccess: (bookId: number | undefined, downloadMode: DownloadMode, mediaType: MediaTypeOption, message: string) => void
 }
 
 export function AddBookModal({ bookId, isOpen, onClose, onSuccess }: AddBookModalProps) {
@@ -47,7 +49,7 @@ export function AddBookModal({ bookId, isOpen, onClose, onSuccess }: AddBookModa
   const addMutation = useMutation({
     mutationFn: () => addHardcoverBook(bookId!, { monitored: true, mediaType }),
     onSuccess: (result) => {
-      onSuccess(result.bookId, downloadMode, mediaType)
+      onSuccess(result.bookId, downloadMode, mediaType, result.message)
     },
     onError: (error) => {
       console.error('Failed to add book:', error)
@@ -95,7 +97,7 @@ export function AddBookModal({ bookId, isOpen, onClose, onSuccess }: AddBookModa
               <Button 
                 variant="link" 
                 size="sm"
-                onClick={() => window.location.href = '/settings'}
+                onClick={() => window.location.href = `${URL_BASE}/settings`}
               >
                 Go to Settings
               </Button>
@@ -0,0 +1,116 @@
+import { useEffect, useMemo, useState } from 'react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import { Loader2 } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { getSeries, mergeSeries, invalidateAllBookQueries } from '@/api/client'
+
+interface MergeSeriesDialogProps {
+  seriesId: number
+  seriesName: string
+  open: boolean
+  onOpenChange: (open: boolean) => void
+}
+
+// MergeSeriesDialog folds duplicate series, such as one imported from Calibre under a
+// slightly different name, into this one
+export function MergeSeriesDialog({ seriesId, seriesName, open, onOpenChange }: MergeSeriesDialogProps) {
+  const queryClient = useQueryClient()
+  const [filter, setFilter] = useState('')
+  const [selected, setSelected] = useState<Set<number>>(new Set())
+
+  useEffect(() => {
+    if (open) {
+      setFilter('')
+      setSelected(new Set())
+    }
+  }, [open])
+
+  const { data: seriesList = [], isLoading } = useQuery({
+    queryKey: ['series'],
+    queryFn: getSeries,
+    enabled: open,
+  })
+
+  const candidates = useMemo(() => {
+    const query = filter.trim().toLowerCase()
+    return seriesList
+      .filter((s) => s.id !== seriesId)
+      .filter((s) => !query || s.name.toLowerCase().includes(query))
+      .sort((a, b) => a.name.localeCompare(b.name))
+  }, [seriesList, seriesId, filter])
+
+  const mergeMutation = useMutation({
+    mutationFn: () => mergeSeries(seriesId, Array.from(selected)),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['series'] })
+      invalidateAllBookQueries(queryClient)
+      onOpenChange(false)
+    },
+  })
+
+  const toggle = (id: number) => {
+    setSelected((s) => {
+      const next = new Set(s)
+      if (next.has(id)) {
+        next.delete(id)
+      } else {
+        next.add(id)
+      }
+      return next
+    })
+  }
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-lg">
+        <DialogHeader>
+          <DialogTitle>Merge Series into {seriesName}</DialogTitle>
+          <DialogDescription>
+            The selected series are removed and their books move to {seriesName}.
+          </DialogDescription>
+        </DialogHeader>
+
+        <Input value={filter} onChange={(e) => setFilter(e.target.value)} placeholder="Filter series" />
+
+        <div className="max-h-72 overflow-y-auto space-y-1">
+          {isLoading && <Loader2 className="h-5 w-5 animate-spin mx-auto" />}
+          {!isLoading && candidates.length === 0 && (
+            <p className="text-sm text-muted-foreground text-center py-4">No other series</p>
+          )}
+          {candidates.map((s) => (
+            <label key={s.id} className="flex items-center gap-3 px-2 py-1.5 rounded hover:bg-accent cursor-pointer">
+              <input type="checkbox" checked={selected.has(s.id)} onChange={() => toggle(s.id)} />
+              <span className="flex-1 truncate">{s.name}</span>
+              {s.bookCount ? <span className="text-xs text-muted-foreground">{s.bookCount} books</span> : null}
+            </label>
+          ))}
+        </div>
+
+        {mergeMutation.isError && (
+          <p className="text-sm text-destructive">
+            {(mergeMutation.error as any)?.response?.data?.error || 'Failed to merge series'}
+          </p>
+        )}
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => onOpenChange(false)}>
+            Cancel
+          </Button>
+          <Button onClick={() => mergeMutation.mutate()} disabled={mergeMutation.isPending || selected.size === 0}>
+            {mergeMutation.isPending && <Loader2 className="h-4 w-4 animate-spin" />}
+            Merge {selected.size > 0 ? selected.size : ''}
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -0,0 +1,80 @@
+import { useState } from 'react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import { Plus, Tag as TagIcon } from 'lucide-react'
+import { Badge } from '@/components/ui/badge'
+import { Input } from '@/components/ui/input'
+import { getTags, createTag } from '@/api/client'
+
+interface TagPickerProps {
+  value: number[]
+  onChange: (tags: number[]) => void
+  disabled?: boolean
+}
+
+// TagPicker toggles tags on an item and can create new ones inline
+export function TagPicker({ value, onChange, disabled }: TagPickerProps) {
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+
+  const { data: tags = [] } = useQuery({
+    queryKey: ['tags'],
+    queryFn: getTags,
+  })
+
+  const createMutation = useMutation({
+    mutationFn: createTag,
+    onSuccess: (tag) => {
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
+      setName('')
+      onChange([...value, tag.id])
+    },
+  })
+
+  const toggle = (id: number) => {
+    onChange(value.includes(id) ? value.filter((t) => t !== id) : [...value, id])
+  }
+
+  const handleCreate = (e: React.SyntheticEvent) => {
+    e.preventDefault()
+    e.stopPropagation()
+    const existing = tags.find((t) => t.name.toLowerCase() === name.trim().toLowerCase())
+    if (existing) {
+      if (!value.includes(existing.id)) onChange([...value, existing.id])
+      setName('')
+    } else if (name.trim()) {
+      createMutation.mutate(name.trim())
+    }
+  }
+
+  return (
+    <div className="flex flex-wrap items-center gap-2">
+      <TagIcon className="h-4 w-4 text-muted-foreground" />
+      {tags.map((tag) => (
+        <button key={tag.id} type="button" onClick={() => toggle(tag.id)} disabled={disabled}>
+          <Badge variant={value.includes(tag.id) ? 'default' : 'outline'}>{tag.name}</Badge>
+        </button>
+      ))}
+      <div className="flex items-center gap-1">
+        <Input
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          onKeyDown={(e) => {
+            if (e.key === 'Enter') handleCreate(e)
+          }}
+          placeholder="New tag"
+          className="h-7 w-28 text-xs"
+          disabled={disabled}
+        />
+        <button
+          type="button"
+          onClick={handleCreate}
+          disabled={disabled || !name.trim() || createMutation.isPending}
+          className="text-muted-foreground hover:text-foreground"
+          title="Add tag"
+        >
+          <Plus className="h-4 w-4" />
+        </button>
+      </div>
+    </div>
+  )
+}
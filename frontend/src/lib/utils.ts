@@ -1,10 +1,21 @@
 import { type ClassValue, clsx } from "clsx"
 import { twMerge } from "tailwind-merge"
 
+// URL_BASE is the path Shelfarr is served under behind a reverse proxy, such as
+// "/shelfarr", or "" at the root. The server sets it in index.html.
+export const URL_BASE = window.__SHELFARR_URL_BASE__ || ''
+
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs))
 }
 
+// coverSrc asks for a library cover scaled down to a width. Covers served from the
+// local cache are resized on the server; remote covers are returned unchanged.
+export function coverSrc(url: string, width: number): string {
+  if (!url.startsWith(`${URL_BASE}/api/images/covers/`)) return url
+  return `${url}${url.includes('?') ? '&' : '?'}width=${width}`
+}
+
 export function formatFileSize(bytes: number): string {
   if (bytes === 0) return '0 B'
   const k = 1024
@@ -26,6 +26,15 @@ export default function ActivityPage() {
     loadActivities();
   }, [page]);
 
+  // Refresh as downloads start, finish and import
+  useEffect(() => {
+    return apiClient.subscribeToEvents((event) => {
+      if (event.type !== 'download.progress' && (event.type.startsWith('download.') || event.type.startsWith('import.'))) {
+        loadActivities();
+      }
+    });
+  }, [page]);
+
   const loadActivities = async () => {
     setLoading(true);
     try {
@@ -0,0 +1,183 @@
+import { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import { ArrowLeft, Loader2, RefreshCw, Search, ChevronLeft, ChevronRight } from 'lucide-react';
+import { apiClient, AuditEntry } from '../api/client';
+
+const PAGE_SIZE = 50;
+
+const ACTION_FILTERS = [
+  { value: '', label: 'All actions' },
+  { value: 'book.', label: 'Books' },
+  { value: 'download.', label: 'Downloads' },
+  { value: 'settings.', label: 'Settings' },
+  { value: 'user.', label: 'Users' },
+  { value: 'auth.', label: 'Logins' },
+];
+
+export default function AuditLogPage() {
+  const [entries, setEntries] = useState<AuditEntry[]>([]);
+  const [total, setTotal] = useState(0);
+  const [page, setPage] = useState(0);
+  const [action, setAction] = useState('');
+  const [search, setSearch] = useState('');
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+
+  const loadEntries = async () => {
+    try {
+      setLoading(true);
+      const data = await apiClient.getAuditLog({
+        action: action || undefined,
+        search: search || undefined,
+        limit: PAGE_SIZE,
+        offset: page * PAGE_SIZE,
+      });
+      setEntries(data.entries);
+      setTotal(data.total);
+      setError(null);
+    } catch (err) {
+      setError('Failed to load the audit log. Only admins can view it.');
+      console.error(err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    const timer = setTimeout(loadEntries, 300);
+    return () => clearTimeout(timer);
+  }, [action, search, page]);
+
+  const pageCount = Math.max(1, Math.ceil(total / PAGE_SIZE));
+
+  return (
+    <div className="space-y-6">
+      {/* Header */}
+      <div className="flex items-center justify-between">
+        <div className="flex items-center gap-4">
+          <Link to="/system/status" className="text-neutral-400 hover:text-neutral-200 transition-colors">
+            <ArrowLeft className="w-5 h-5" />
+          </Link>
+          <div>
+            <h1 className="text-2xl font-bold text-neutral-100">Audit Log</h1>
+            <p className="text-neutral-400 mt-1">Who changed what, and when</p>
+          </div>
+        </div>
+        <button
+          onClick={loadEntries}
+          className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+        >
+          <RefreshCw className="w-4 h-4" />
+          Refresh
+        </button>
+      </div>
+
+      {/* Filters */}
+      <div className="flex flex-col sm:flex-row gap-3">
+        <div className="relative flex-1">
+          <Search className="absolute left-3 top-1/2 -translate-y-1/2 w-4 h-4 text-neutral-500" />
+          <input
+            type="text"
+            value={search}
+            onChange={(e) => {
+              setSearch(e.target.value);
+              setPage(0);
+            }}
+            placeholder="Search by user, description or path..."
+            className="w-full pl-9 pr-3 py-2 bg-neutral-800/50 border border-neutral-700 rounded-lg text-neutral-200 placeholder-neutral-500 focus:outline-none focus:border-sky-500"
+          />
+        </div>
+        <select
+          value={action}
+          onChange={(e) => {
+            setAction(e.target.value);
+            setPage(0);
+          }}
+          className="px-3 py-2 bg-neutral-800/50 border border-neutral-700 rounded-lg text-neutral-200 focus:outline-none focus:border-sky-500"
+        >
+          {ACTION_FILTERS.map((filter) => (
+            <option key={filter.value} value={filter.value}>
+              {filter.label}
+            </option>
+          ))}
+        </select>
+      </div>
+
+      {/* Entries */}
+      <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl overflow-x-auto">
+        {loading && entries.length === 0 ? (
+          <div className="flex items-center justify-center py-12">
+            <Loader2 className="w-6 h-6 text-sky-500 animate-spin" />
+          </div>
+        ) : error ? (
+          <p className="text-red-400 py-6 text-center">{error}</p>
+        ) : entries.length === 0 ? (
+          <p className="text-neutral-500 py-6 text-center">No audit entries</p>
+        ) : (
+          <table className="w-full text-sm">
+            <thead>
+              <tr className="text-left text-neutral-400 border-b border-neutral-700">
+                <th className="px-4 py-3 font-medium">Time</th>
+                <th className="px-4 py-3 font-medium">User</th>
+                <th className="px-4 py-3 font-medium">Action</th>
+                <th className="px-4 py-3 font-medium">Details</th>
+                <th className="px-4 py-3 font-medium">IP</th>
+              </tr>
+            </thead>
+            <tbody>
+              {entries.map((entry) => (
+                <tr key={entry.id} className="border-b border-neutral-700/50 last:border-0">
+                  <td className="px-4 py-3 text-neutral-400 whitespace-nowrap">
+                    {new Date(entry.time).toLocaleString()}
+                  </td>
+                  <td className="px-4 py-3 text-neutral-200">{entry.username || 'Unknown'}</td>
+                  <td className="px-4 py-3">
+                    <span
+                      className={`font-mono text-xs ${
+                        entry.action === 'auth.login_failed' ? 'text-red-400' : 'text-sky-400'
+                      }`}
+                    >
+                      {entry.action}
+                    </span>
+                  </td>
+                  <td className="px-4 py-3">
+                    <p className="text-neutral-200">{entry.summary}</p>
+                    <p className="text-xs text-neutral-500 font-mono">
+                      {entry.method} {entry.path}
+                    </p>
+                  </td>
+                  <td className="px-4 py-3 text-neutral-400 font-mono text-xs">{entry.ip}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+      </div>
+
+      {/* Paging */}
+      {total > PAGE_SIZE && (
+        <div className="flex items-center justify-between text-sm text-neutral-400">
+          <span>
+            {total} entries, page {page + 1} of {pageCount}
+          </span>
+          <div className="flex items-center gap-2">
+            <button
+              onClick={() => setPage(page - 1)}
+              disabled={page === 0}
+              className="p-2 hover:text-neutral-200 disabled:opacity-40 transition-colors"
+            >
+              <ChevronLeft className="w-4 h-4" />
+            </button>
+            <button
+              onClick={() => setPage(page + 1)}
+              disabled={page + 1 >= pageCount}
+              className="p-2 hover:text-neutral-200 disabled:opacity-40 transition-colors"
+            >
+              <ChevronRight className="w-4 h-4" />
+            </button>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
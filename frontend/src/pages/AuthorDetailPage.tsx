@@ -12,11 +12,16 @@ import {
   CheckCircle2,
   Library,
   AlertCircle,
+  Merge,
   X
 } from 'lucide-react';
-import { getAuthor, updateAuthor, addHardcoverBook, deleteBook, invalidateAllBookQueries, type AuthorDetail, type Book } from '@/api/client';
+import { getAuthor, updateAuthor, uploadAuthorImage, resetAuthorImage, setAuthorTags, addHardcoverBook, deleteBook, invalidateAllBookQueries, type AuthorDetail, type Book } from '@/api/client';
 import { Button } from '@/components/ui/button';
 import { CatalogBookCard } from '@/components/library/CatalogBookCard';
+import { CoverActions } from '@/components/book/CoverActions';
+import { AuthorAliases } from '@/components/author/AuthorAliases';
+import { MergeAuthorsDialog } from '@/components/author/MergeAuthorsDialog';
+import { TagPicker } from '@/components/tags/TagPicker';
 import { 
   BookSortFilter, 
   sortBooks, 
@@ -30,6 +35,7 @@ export default function AuthorDetailPage() {
   const queryClient = useQueryClient();
   const [addingBooks, setAddingBooks] = useState<Set<string>>(new Set());
   const [deletingBooks, setDeletingBooks] = useState<Set<number>>(new Set());
+  const [mergeOpen, setMergeOpen] = useState(false);
   const [notifications, setNotifications] = useState<Array<{
     id: string;
     type: 'success' | 'error' | 'info';
@@ -54,6 +60,22 @@ export default function AuthorDetailPage() {
     },
   });
 
+  const imageMutation = useMutation({
+    mutationFn: (file?: File) => file ? uploadAuthorImage(Number(id), file) : resetAuthorImage(Number(id)),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['author', id] });
+      queryClient.invalidateQueries({ queryKey: ['authors'] });
+    },
+  });
+
+  const tagsMutation = useMutation({
+    mutationFn: (tags: number[]) => setAuthorTags(Number(id), tags),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['author', id] });
+      queryClient.invalidateQueries({ queryKey: ['tags'] });
+    },
+  });
+
   const addBookMutation = useMutation({
     mutationFn: (hardcoverId: string) => addHardcoverBook(hardcoverId, { 
       monitored: true,
@@ -66,6 +88,11 @@ export default function AuthorDetailPage() {
         return next;
       });
 
+      if (response.bookId === undefined) {
+        addNotification('success', response.message);
+        return;
+      }
+
       queryClient.setQueryData<AuthorDetail>(['author', id], (old) => {
         if (!old) return old;
         return {
@@ -335,6 +362,14 @@ export default function AuthorDetailPage() {
                 <User className="w-16 h-16 text-sky-400" />
               </div>
             )}
+            <div className="w-32">
+              <CoverActions
+                isCustom={author.customImage}
+                isPending={imageMutation.isPending}
+                onUpload={(file) => imageMutation.mutate(file)}
+                onReset={() => imageMutation.mutate(undefined)}
+              />
+            </div>
           </div>
 
           {/* Author Info */}
@@ -345,10 +380,23 @@ export default function AuthorDetailPage() {
                 {author.sortName && author.sortName !== author.name && (
                   <p className="text-neutral-500 text-sm mt-1">Sort: {author.sortName}</p>
                 )}
+                <AuthorAliases authorId={author.id} aliases={author.aliases || []} />
+                <div className="mt-3">
+                  <TagPicker
+                    value={author.tags || []}
+                    onChange={(tags) => tagsMutation.mutate(tags)}
+                    disabled={tagsMutation.isPending}
+                  />
+                </div>
               </div>
 
               {/* Actions */}
               <div className="flex items-center gap-2">
+                <Button variant="outline" size="sm" onClick={() => setMergeOpen(true)} title="Merge duplicate authors into this one">
+                  <Merge className="w-4 h-4 mr-2" />
+                  Merge
+                </Button>
+
                 {/* Monitor Toggle */}
                 <Button
                   variant="outline"
@@ -548,6 +596,8 @@ export default function AuthorDetailPage() {
           <span>Unreleased</span>
         </div>
       </div>
+
+      <MergeAuthorsDialog author={author} open={mergeOpen} onOpenChange={setMergeOpen} />
     </div>
   );
 }
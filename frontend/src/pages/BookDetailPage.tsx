@@ -20,7 +20,9 @@ import {
   SortDesc,
   X,
   RefreshCw,
-  Globe
+  Globe,
+  Pencil,
+  BookMarked
 } from 'lucide-react'
 import { Topbar } from '@/components/layout/Topbar'
 import { Button } from '@/components/ui/button'
@@ -51,6 +53,11 @@ import { FormatAvailability } from '@/components/ui/format-availability'
 import { EditionsTable } from '@/components/book/EditionsTable'
 import { ContributorsList } from '@/components/book/ContributorsList'
 import { GenreBadges } from '@/components/book/GenreBadges'
+import { BlobImage } from '@/components/book/BlobImage'
+import { CoverActions } from '@/components/book/CoverActions'
+import { EditMetadataDialog } from '@/components/book/EditMetadataDialog'
+import { TagPicker } from '@/components/tags/TagPicker'
+import { AddToCollectionDialog } from '@/components/collections/AddToCollectionDialog'
 import { 
   getBook, 
   searchIndexers, 
@@ -59,7 +66,14 @@ import {
   deleteBook, 
   invalidateAllBookQueries,
   refreshBookMetadata,
-  getHardcoverBook
+  getHardcoverBook,
+  getRootFolders,
+  getMediaFileCover,
+  uploadBookCover,
+  setBookCover,
+  resetBookCover,
+  removeBookSeries,
+  setBookTags
 } from '@/api/client'
 import type { IndexerSearchResult } from '@/types'
 
@@ -108,6 +122,8 @@ const defaultFilters: FilterState = {
 
 // Available formats for filtering
 const EBOOK_FORMATS = ['epub', 'azw3', 'mobi', 'pdf', 'cbz', 'cbr']
+// Fixed-layout formats, shown with a thumbnail of their first page
+const PAGED_FORMATS = ['pdf', 'cbz', 'cbr']
 const AUDIOBOOK_FORMATS = ['m4b', 'mp3', 'flac', 'm4a']
 
 export function BookDetailPage() {
@@ -119,6 +135,8 @@ export function BookDetailPage() {
   const [isSearching, setIsSearching] = useState(false)
   const hasAutoSearched = useRef(false)
   const [showDeleteDialog, setShowDeleteDialog] = useState(false)
+  const [showEditDialog, setShowEditDialog] = useState(false)
+  const [showCollectionDialog, setShowCollectionDialog] = useState(false)
   
   // Sort and filter state
   const [sortOption, setSortOption] = useState<SortOption>('seeders-desc')
@@ -131,6 +149,11 @@ export function BookDetailPage() {
     enabled: !!id,
   })
 
+  const { data: rootFolders } = useQuery({
+    queryKey: ['rootFolders'],
+    queryFn: getRootFolders,
+  })
+
   const { data: hardcoverData } = useQuery({
     queryKey: ['hardcoverBook', book?.hardcoverId],
     queryFn: () => getHardcoverBook(book!.hardcoverId),
@@ -215,7 +238,7 @@ export function BookDetailPage() {
   }
 
   const updateMutation = useMutation({
-    mutationFn: (updates: { monitored?: boolean; status?: string }) =>
+    mutationFn: (updates: { monitored?: boolean; status?: string; ebookRootFolderId?: number; audiobookRootFolderId?: number }) =>
       updateBook(Number(id), updates),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['book', id] })
@@ -231,6 +254,33 @@ export function BookDetailPage() {
     },
   })
 
+  const coverMutation = useMutation({
+    mutationFn: (change: { file?: File; editionId?: number }) => {
+      if (change.file) return uploadBookCover(Number(id), change.file)
+      if (change.editionId) return setBookCover(Number(id), { editionId: change.editionId })
+      return resetBookCover(Number(id))
+    },
+    onSuccess: () => {
+      invalidateAllBookQueries(queryClient)
+    },
+  })
+
+  const tagsMutation = useMutation({
+    mutationFn: (tags: number[]) => setBookTags(Number(id), tags),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['book', id] })
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
+    },
+  })
+
+  const removeSeriesMutation = useMutation({
+    mutationFn: () => removeBookSeries(Number(id)),
+    onSuccess: () => {
+      invalidateAllBookQueries(queryClient)
+      queryClient.invalidateQueries({ queryKey: ['series'] })
+    },
+  })
+
   const deleteMutation = useMutation({
     mutationFn: () => deleteBook(Number(id)),
     onSuccess: () => {
@@ -332,6 +382,12 @@ export function BookDetailPage() {
                     }`}
                   />
                 </div>
+                <CoverActions
+                  isCustom={book.customCover}
+                  isPending={coverMutation.isPending}
+                  onUpload={(file) => coverMutation.mutate({ file })}
+                  onReset={() => coverMutation.mutate({})}
+                />
               </div>
 
               {/* Info */}
@@ -370,6 +426,22 @@ export function BookDetailPage() {
                     >
                       <RefreshCw className={`h-4 w-4 ${refreshMutation.isPending ? 'animate-spin' : ''}`} />
                     </Button>
+                    <Button
+                      variant="outline"
+                      size="icon"
+                      onClick={() => setShowEditDialog(true)}
+                      title="Edit Metadata"
+                    >
+                      <Pencil className="h-4 w-4" />
+                    </Button>
+                    <Button
+                      variant="outline"
+                      size="icon"
+                      onClick={() => setShowCollectionDialog(true)}
+                      title="Add to Collection"
+                    >
+                      <BookMarked className="h-4 w-4" />
+                    </Button>
                     <Button
                       variant={book.monitored ? 'default' : 'outline'}
                       onClick={handleToggleMonitored}
@@ -430,12 +502,22 @@ export function BookDetailPage() {
                   )}
 
                   {book.series && (
-                    <Link
-                      to={`/series/${book.series.id}`}
-                      className="flex items-center gap-1 text-sm text-primary hover:underline"
-                    >
-                      #{book.seriesIndex} in {book.series.name}
-                    </Link>
+                    <div className="flex items-center gap-1 text-sm">
+                      <Link
+                        to={`/series/${book.series.id}`}
+                        className="text-primary hover:underline"
+                      >
+                        #{book.seriesIndex} in {book.series.name}
+                      </Link>
+                      <button
+                        onClick={() => removeSeriesMutation.mutate()}
+                        disabled={removeSeriesMutation.isPending}
+                        className="text-muted-foreground hover:text-foreground"
+                        title="Remove from series"
+                      >
+                        <X className="h-3 w-3" />
+                      </button>
+                    </div>
                   )}
                 </div>
 
@@ -501,6 +583,12 @@ export function BookDetailPage() {
                     <div className="flex items-center gap-3">
                       {file.mediaType === 'audiobook' ? (
                         <Headphones className="h-5 w-5 text-primary" />
+                      ) : PAGED_FORMATS.includes(file.format) ? (
+                        <BlobImage
+                          queryKey={['mediafile-cover', file.id]}
+                          fetchImage={() => getMediaFileCover(file.id)}
+                          className="h-12 w-9"
+                        />
                       ) : (
                         <Book className="h-5 w-5 text-primary" />
                       )}
@@ -508,6 +596,7 @@ export function BookDetailPage() {
                         <div className="font-medium">{file.fileName}</div>
                         <div className="text-sm text-muted-foreground">
                           {file.format.toUpperCase()} • {formatBytes(file.fileSize)}
+                          {file.pageCount ? ` • ${file.pageCount} pages` : ''}
                           {file.editionName && ` • ${file.editionName}`}
                         </div>
                       </div>
@@ -533,7 +622,10 @@ export function BookDetailPage() {
 
           <section>
             <h2 className="text-xl font-semibold mb-4">Editions</h2>
-            <EditionsTable bookId={book.id} />
+            <EditionsTable
+              bookId={book.id}
+              onSelectCover={(editionId) => coverMutation.mutate({ editionId })}
+            />
           </section>
 
           {hardcoverData?.genres && hardcoverData.genres.length > 0 && (
@@ -543,6 +635,53 @@ export function BookDetailPage() {
             </section>
           )}
 
+          <section>
+            <h2 className="text-xl font-semibold mb-4">Tags</h2>
+            <TagPicker
+              value={book.tags || []}
+              onChange={(tags) => tagsMutation.mutate(tags)}
+              disabled={tagsMutation.isPending}
+            />
+            <p className="text-xs text-muted-foreground mt-2">
+              Tagged indexers are only searched for books sharing one of their tags. Books also have their author's tags.
+            </p>
+          </section>
+
+          {/* Root Folders */}
+          {rootFolders && rootFolders.length > 1 && (
+            <section>
+              <h2 className="text-xl font-semibold mb-4">Root Folders</h2>
+              <div className="grid grid-cols-2 gap-4 max-w-xl">
+                {(['ebook', 'audiobook'] as const).map((mediaType) => {
+                  const key = mediaType === 'ebook' ? 'ebookRootFolderId' : 'audiobookRootFolderId'
+                  return (
+                    <div key={mediaType} className="space-y-2">
+                      <Label>{mediaType === 'ebook' ? 'Ebooks' : 'Audiobooks'}</Label>
+                      <Select
+                        value={String(book[key] ?? 0)}
+                        onValueChange={(v) => updateMutation.mutate({ monitored: book.monitored, [key]: Number(v) })}
+                      >
+                        <SelectTrigger>
+                          <SelectValue />
+                        </SelectTrigger>
+                        <SelectContent>
+                          <SelectItem value="0">Default</SelectItem>
+                          {rootFolders
+                            .filter((folder) => folder.mediaType === mediaType)
+                            .map((folder) => (
+                              <SelectItem key={folder.id} value={String(folder.id)}>
+                                {folder.name || folder.path}
+                              </SelectItem>
+                            ))}
+                        </SelectContent>
+                      </Select>
+                    </div>
+                  )
+                })}
+              </div>
+            </section>
+          )}
+
           {/* Search Section */}
           <section>
             <div className="flex items-center justify-between mb-4">
@@ -723,6 +862,9 @@ export function BookDetailPage() {
         </div>
       </div>
 
+      <EditMetadataDialog book={book} open={showEditDialog} onOpenChange={setShowEditDialog} />
+      <AddToCollectionDialog bookIds={[book.id]} open={showCollectionDialog} onOpenChange={setShowCollectionDialog} />
+
       <Dialog open={showDeleteDialog} onOpenChange={setShowDeleteDialog}>
         <DialogContent>
           <DialogHeader>
@@ -772,6 +914,7 @@ function SearchResultCard({
         title: result.title,
         size: result.size,
         format: result.format || '',
+        protocol: result.protocol,
         mediaType
       })
       setDownloadSuccess(true)
@@ -0,0 +1,230 @@
+import { useEffect, useState } from 'react'
+import { useParams, Link, useNavigate } from 'react-router-dom'
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { ArrowLeft, ArrowDown, ArrowUp, Book, Loader2, Trash2, X } from 'lucide-react'
+import { Topbar } from '@/components/layout/Topbar'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Switch } from '@/components/ui/switch'
+import {
+  getCollection,
+  updateCollection,
+  deleteCollection,
+  reorderCollectionBooks,
+  removeCollectionBook,
+} from '@/api/client'
+
+export default function CollectionDetailPage() {
+  const { id } = useParams<{ id: string }>()
+  const collectionId = Number(id)
+  const navigate = useNavigate()
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+  const [description, setDescription] = useState('')
+
+  const { data: collection, isLoading } = useQuery({
+    queryKey: ['collection', collectionId],
+    queryFn: () => getCollection(collectionId),
+    enabled: !!collectionId,
+  })
+
+  useEffect(() => {
+    if (collection) {
+      setName(collection.name)
+      setDescription(collection.description)
+    }
+  }, [collection])
+
+  const invalidate = () => {
+    queryClient.invalidateQueries({ queryKey: ['collection', collectionId] })
+    queryClient.invalidateQueries({ queryKey: ['collections'] })
+  }
+
+  const updateMutation = useMutation({
+    mutationFn: (data: { name: string; description: string; opds: boolean }) => updateCollection(collectionId, data),
+    onSuccess: invalidate,
+  })
+
+  const deleteMutation = useMutation({
+    mutationFn: () => deleteCollection(collectionId),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['collections'] })
+      navigate('/collections')
+    },
+  })
+
+  const reorderMutation = useMutation({
+    mutationFn: (bookIds: number[]) => reorderCollectionBooks(collectionId, bookIds),
+    onSuccess: (data) => {
+      queryClient.setQueryData(['collection', collectionId], data)
+    },
+  })
+
+  const removeMutation = useMutation({
+    mutationFn: (bookId: number) => removeCollectionBook(collectionId, bookId),
+    onSuccess: invalidate,
+  })
+
+  if (isLoading) {
+    return (
+      <div className="flex items-center justify-center h-full">
+        <Loader2 className="h-8 w-8 animate-spin text-muted-foreground" />
+      </div>
+    )
+  }
+
+  if (!collection) {
+    return (
+      <div className="flex flex-col items-center justify-center h-full">
+        <p className="text-muted-foreground">Collection not found</p>
+        <Link to="/collections" className="text-primary mt-2">
+          Back to Collections
+        </Link>
+      </div>
+    )
+  }
+
+  const books = collection.books || []
+
+  const move = (index: number, offset: number) => {
+    const ids = books.map((b) => b.id)
+    const [moved] = ids.splice(index, 1)
+    ids.splice(index + offset, 0, moved)
+    reorderMutation.mutate(ids)
+  }
+
+  const save = (changes: Partial<{ name: string; description: string; opds: boolean }> = {}) => {
+    updateMutation.mutate({ name, description, opds: collection.opds, ...changes })
+  }
+
+  const handleDelete = () => {
+    if (confirm(`Delete the collection "${collection.name}"? Its books stay in the library.`)) {
+      deleteMutation.mutate()
+    }
+  }
+
+  return (
+    <div className="flex flex-col h-full">
+      <Topbar title={collection.name} subtitle={`${collection.bookCount} books`} />
+
+      <div className="flex-1 overflow-auto p-6">
+        <div className="max-w-4xl mx-auto">
+          <Link
+            to="/collections"
+            className="inline-flex items-center gap-2 text-muted-foreground hover:text-foreground mb-6 transition-colors"
+          >
+            <ArrowLeft className="h-4 w-4" />
+            Back to Collections
+          </Link>
+
+          {/* Details; collections shared with the user are read-only */}
+          {!collection.shared && (
+            <form
+              onSubmit={(e) => {
+                e.preventDefault()
+                if (name.trim()) save()
+              }}
+              className="space-y-4 p-4 rounded-lg bg-card border border-border mb-6"
+            >
+              <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
+                <div className="space-y-2">
+                  <Label htmlFor="name">Name</Label>
+                  <Input id="name" value={name} onChange={(e) => setName(e.target.value)} />
+                </div>
+                <div className="space-y-2">
+                  <Label htmlFor="description">Description</Label>
+                  <Input id="description" value={description} onChange={(e) => setDescription(e.target.value)} />
+                </div>
+              </div>
+              <div className="flex items-center justify-between">
+                <div className="flex items-center gap-3">
+                  <Switch
+                    id="opds"
+                    checked={collection.opds}
+                    onCheckedChange={(opds) => save({ opds })}
+                    disabled={updateMutation.isPending}
+                  />
+                  <Label htmlFor="opds">Show in OPDS catalog</Label>
+                </div>
+                <div className="flex items-center gap-2">
+                  <Button type="submit" disabled={!name.trim() || updateMutation.isPending}>
+                    {updateMutation.isPending && <Loader2 className="h-4 w-4 animate-spin" />}
+                    Save
+                  </Button>
+                  <Button
+                    type="button"
+                    variant="outline"
+                    onClick={handleDelete}
+                    disabled={deleteMutation.isPending}
+                    className="text-destructive hover:bg-destructive hover:text-destructive-foreground"
+                  >
+                    <Trash2 className="h-4 w-4" />
+                  </Button>
+                </div>
+              </div>
+            </form>
+          )}
+
+          {/* Books */}
+          {books.length === 0 ? (
+            <div className="text-center py-8 border border-dashed border-border rounded-lg">
+              <p className="text-muted-foreground">No books in this collection yet</p>
+            </div>
+          ) : (
+            <div className="space-y-2">
+              {books.map((book, index) => (
+                <div key={book.id} className="flex items-center gap-4 p-3 rounded-lg bg-card border border-border">
+                  <div className="w-10 h-14 rounded bg-muted overflow-hidden shrink-0 flex items-center justify-center">
+                    {book.coverUrl ? (
+                      <img src={book.coverUrl} alt={book.title} className="w-full h-full object-cover" />
+                    ) : (
+                      <Book className="h-5 w-5 text-muted-foreground" />
+                    )}
+                  </div>
+                  <div className="flex-1 min-w-0">
+                    <Link to={`/books/${book.id}`} className="font-medium hover:text-primary truncate block">
+                      {book.title}
+                    </Link>
+                    {book.author && <p className="text-sm text-muted-foreground truncate">{book.author.name}</p>}
+                  </div>
+                  {!collection.shared && (
+                    <div className="flex items-center gap-1 shrink-0">
+                      <Button
+                        variant="ghost"
+                        size="icon"
+                        onClick={() => move(index, -1)}
+                        disabled={index === 0 || reorderMutation.isPending}
+                        title="Move up"
+                      >
+                        <ArrowUp className="h-4 w-4" />
+                      </Button>
+                      <Button
+                        variant="ghost"
+                        size="icon"
+                        onClick={() => move(index, 1)}
+                        disabled={index === books.length - 1 || reorderMutation.isPending}
+                        title="Move down"
+                      >
+                        <ArrowDown className="h-4 w-4" />
+                      </Button>
+                      <Button
+                        variant="ghost"
+                        size="icon"
+                        onClick={() => removeMutation.mutate(book.id)}
+                        disabled={removeMutation.isPending}
+                        title="Remove from collection"
+                      >
+                        <X className="h-4 w-4" />
+                      </Button>
+                    </div>
+                  )}
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  )
+}
@@ -0,0 +1,105 @@
+import { useState } from 'react'
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { Link } from 'react-router-dom'
+import { BookMarked, ChevronRight, Loader2, Plus, Rss } from 'lucide-react'
+import { Topbar } from '@/components/layout/Topbar'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import { getCollections, createCollection } from '@/api/client'
+
+export function CollectionsPage() {
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+
+  const { data: collections, isLoading, refetch } = useQuery({
+    queryKey: ['collections'],
+    queryFn: getCollections,
+  })
+
+  const createMutation = useMutation({
+    mutationFn: createCollection,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['collections'] })
+      setName('')
+    },
+  })
+
+  return (
+    <div className="flex flex-col h-full">
+      <Topbar
+        title="Collections"
+        subtitle={`${collections?.length || 0} collections`}
+        onRefresh={() => refetch()}
+        isRefreshing={isLoading}
+      />
+
+      <div className="flex-1 overflow-auto p-6">
+        <form
+          onSubmit={(e) => {
+            e.preventDefault()
+            if (name.trim()) createMutation.mutate({ name: name.trim() })
+          }}
+          className="flex items-center gap-2 mb-6"
+        >
+          <Input
+            value={name}
+            onChange={(e) => setName(e.target.value)}
+            placeholder="New collection, e.g. Summer reading"
+            className="max-w-xs"
+          />
+          <Button type="submit" disabled={!name.trim() || createMutation.isPending}>
+            {createMutation.isPending ? <Loader2 className="h-4 w-4 animate-spin" /> : <Plus className="h-4 w-4" />}
+            Add Collection
+          </Button>
+        </form>
+
+        {isLoading ? (
+          <div className="space-y-2">
+            {Array.from({ length: 5 }).map((_, i) => (
+              <div key={i} className="h-16 skeleton rounded-lg" />
+            ))}
+          </div>
+        ) : collections?.length === 0 ? (
+          <div className="flex flex-col items-center justify-center py-16">
+            <BookMarked className="h-16 w-16 text-muted-foreground mb-4" />
+            <p className="text-lg text-muted-foreground">No collections yet</p>
+            <p className="text-sm text-muted-foreground mt-1">
+              Create one above, then add books to it from their pages
+            </p>
+          </div>
+        ) : (
+          <div className="space-y-2">
+            {collections?.map((collection) => (
+              <Link
+                key={collection.id}
+                to={`/collections/${collection.id}`}
+                className="flex items-center justify-between p-4 rounded-lg bg-card border border-border hover:border-primary transition-colors"
+              >
+                <div className="flex items-center gap-4 flex-1 min-w-0">
+                  <div className="rounded-md bg-primary/10 p-2 shrink-0">
+                    <BookMarked className="h-5 w-5 text-primary" />
+                  </div>
+                  <div className="flex-1 min-w-0">
+                    <h3 className="font-medium truncate">{collection.name}</h3>
+                    <div className="flex items-center gap-4 mt-1 text-sm text-muted-foreground">
+                      <span>
+                        {collection.bookCount} book{collection.bookCount !== 1 ? 's' : ''}
+                      </span>
+                      {collection.opds && (
+                        <span className="flex items-center gap-1">
+                          <Rss className="h-3.5 w-3.5" />
+                          In OPDS catalog
+                        </span>
+                      )}
+                    </div>
+                  </div>
+                </div>
+                <ChevronRight className="h-5 w-5 text-muted-foreground shrink-0 ml-4" />
+              </Link>
+            ))}
+          </div>
+        )}
+      </div>
+    </div>
+  )
+}
@@ -3,7 +3,7 @@ import { Plus, Trash2, Settings, CheckCircle2, XCircle, Loader2, Server, Refresh
 import { apiClient } from '../api/client';
 import type { DownloadClient as DownloadClientType } from '@/types';
 
-type ClientType = 'qbittorrent' | 'transmission' | 'deluge' | 'sabnzbd' | 'nzbget' | 'rapidseedbox-deluge' | 'rapidseedbox-rutorrent';
+type ClientType = 'qbittorrent' | 'transmission' | 'deluge' | 'rtorrent' | 'aria2' | 'synology' | 'sabnzbd' | 'nzbget' | 'rapidseedbox-deluge' | 'rapidseedbox-rutorrent';
 
 interface ClientTypeInfo {
   value: ClientType;
@@ -21,6 +21,35 @@ const CLIENT_TYPES: ClientTypeInfo[] = [
   { value: 'qbittorrent', label: 'qBittorrent', icon: '🌊', category: 'torrent', defaultPort: 8080, defaultSSL: false },
   { value: 'transmission', label: 'Transmission', icon: '⚡', category: 'torrent', defaultPort: 9091, defaultSSL: false },
   { value: 'deluge', label: 'Deluge', icon: '🔥', category: 'torrent', defaultPort: 8112, defaultSSL: false },
+  {
+    value: 'rtorrent',
+    label: 'rTorrent / ruTorrent',
+    icon: '🧲',
+    category: 'torrent',
+    defaultPort: 80,
+    defaultSSL: false,
+    urlBase: '/RPC2',
+    helpText: 'Use the XML-RPC endpoint: /RPC2 for rTorrent, or /plugins/rpc/rpc.php behind ruTorrent. Labels show up in ruTorrent.'
+  },
+  {
+    value: 'aria2',
+    label: 'aria2',
+    icon: '🚀',
+    category: 'torrent',
+    defaultPort: 6800,
+    defaultSSL: false,
+    urlBase: '/jsonrpc',
+    helpText: 'aria2 also fetches direct downloads (Anna\'s Archive, LibGen). Enter your --rpc-secret as the password.'
+  },
+  {
+    value: 'synology',
+    label: 'Synology Download Station',
+    icon: '🗄️',
+    category: 'torrent',
+    defaultPort: 5000,
+    defaultSSL: false,
+    helpText: 'Use a DSM account with Download Station access. Accounts with 2-step verification can\'t be used.'
+  },
   // Usenet Clients
   { value: 'sabnzbd', label: 'SABnzbd', icon: '📰', category: 'usenet', defaultPort: 8085, defaultSSL: false },
   { value: 'nzbget', label: 'NZBGet', icon: '📥', category: 'usenet', defaultPort: 6789, defaultSSL: false },
@@ -63,8 +92,15 @@ interface ClientFormData {
   username: string;
   password: string;
   category: string;
+  ebookCategory: string;
+  audiobookCategory: string;
+  ebookSavePath: string;
+  audiobookSavePath: string;
   priority: number;
   enabled: boolean;
+  seedRatio: number;
+  seedTime: number;
+  deleteSeededFiles: boolean;
 }
 
 const getDefaultFormData = (type?: ClientType): ClientFormData => {
@@ -79,8 +115,15 @@ const getDefaultFormData = (type?: ClientType): ClientFormData => {
     username: '',
     password: '',
     category: 'books',
+    ebookCategory: '',
+    audiobookCategory: '',
+    ebookSavePath: '',
+    audiobookSavePath: '',
     priority: 50,
     enabled: true,
+    seedRatio: 0,
+    seedTime: 0,
+    deleteSeededFiles: false,
   };
 };
 
@@ -227,7 +270,7 @@ export default function DownloadClientsSettingsPage() {
     setSaving(true);
     try {
       const url = buildUrl(currentFormData.host, currentFormData.port, currentFormData.useSsl, currentFormData.urlBase);
-      const apiType = getApiClientType(currentFormData.type) as 'qbittorrent' | 'transmission' | 'deluge' | 'sabnzbd' | 'nzbget' | 'rtorrent' | 'internal';
+      const apiType = getApiClientType(currentFormData.type) as 'qbittorrent' | 'transmission' | 'deluge' | 'aria2' | 'synology' | 'sabnzbd' | 'nzbget' | 'rtorrent' | 'internal';
       const payload = {
         name: currentFormData.name,
         type: apiType,
@@ -235,8 +278,15 @@ export default function DownloadClientsSettingsPage() {
         username: currentFormData.username,
         password: currentFormData.password,
         category: currentFormData.category,
+        ebookCategory: currentFormData.ebookCategory,
+        audiobookCategory: currentFormData.audiobookCategory,
+        ebookSavePath: currentFormData.ebookSavePath,
+        audiobookSavePath: currentFormData.audiobookSavePath,
         priority: currentFormData.priority,
         enabled: currentFormData.enabled,
+        seedRatio: currentFormData.seedRatio,
+        seedTime: currentFormData.seedTime,
+        deleteSeededFiles: currentFormData.deleteSeededFiles,
         settings: JSON.stringify({
           originalType: currentFormData.type,
           host: currentFormData.host,
@@ -308,8 +358,15 @@ export default function DownloadClientsSettingsPage() {
       username: client.username || '',
       password: client.password || '',
       category: client.category || 'books',
+      ebookCategory: client.ebookCategory || '',
+      audiobookCategory: client.audiobookCategory || '',
+      ebookSavePath: client.ebookSavePath || '',
+      audiobookSavePath: client.audiobookSavePath || '',
       priority: client.priority,
       enabled: client.enabled,
+      seedRatio: client.seedRatio || 0,
+      seedTime: client.seedTime || 0,
+      deleteSeededFiles: client.deleteSeededFiles || false,
     });
     setDialogTestStatus('success'); // Already saved = already tested
     setDialogTestMessage('');
@@ -418,7 +475,7 @@ export default function DownloadClientsSettingsPage() {
   const currentTypeInfo = CLIENT_TYPES.find(c => c.value === formData.type);
   const isSeedbox = currentTypeInfo?.category === 'seedbox';
   const isUsenet = currentTypeInfo?.category === 'usenet';
-  const needsUsername = !['sabnzbd', 'deluge', 'rapidseedbox-deluge'].includes(formData.type);
+  const needsUsername = !['sabnzbd', 'deluge', 'aria2', 'rapidseedbox-deluge'].includes(formData.type);
 
   // Check if any clients are seedboxes (for showing remote path section)
   const hasSeedboxClients = clients.some(c => {
@@ -730,6 +787,10 @@ export default function DownloadClientsSettingsPage() {
                 </select>
               </div>
 
+              {(formData.type === 'rtorrent' || formData.type === 'aria2' || formData.type === 'synology') && (
+                <p className="text-xs text-neutral-500">{currentTypeInfo?.helpText}</p>
+              )}
+
               {/* Seedbox Help Box */}
               {isSeedbox && (
                 <div className="bg-sky-500/10 border border-sky-500/30 rounded-lg p-4">
@@ -844,7 +905,8 @@ export default function DownloadClientsSettingsPage() {
               <div>
                 <label className="block text-sm font-medium text-neutral-300 mb-1">
                   {isUsenet && formData.type === 'sabnzbd' ? 'API Key' : 
-                   formData.type.includes('deluge') ? 'Web UI Password' : 'Password'}
+                   formData.type.includes('deluge') ? 'Web UI Password' :
+                   formData.type === 'aria2' ? 'RPC Secret' : 'Password'}
                 </label>
                 <input
                   type="password"
@@ -870,6 +932,103 @@ export default function DownloadClientsSettingsPage() {
                 </p>
               </div>
 
+              {/* Per-media-type categories and save paths */}
+              <div>
+                <div className="grid grid-cols-2 gap-4">
+                  <div>
+                    <label className="block text-sm font-medium text-neutral-300 mb-1">Ebook Category</label>
+                    <input
+                      type="text"
+                      value={formData.ebookCategory}
+                      onChange={(e) => handleFormChange({ ebookCategory: e.target.value })}
+                      className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                      placeholder={formData.category || 'books'}
+                    />
+                  </div>
+                  <div>
+                    <label className="block text-sm font-medium text-neutral-300 mb-1">Audiobook Category</label>
+                    <input
+                      type="text"
+                      value={formData.audiobookCategory}
+                      onChange={(e) => handleFormChange({ audiobookCategory: e.target.value })}
+                      className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                      placeholder={formData.category || 'books'}
+                    />
+                  </div>
+                  {!isUsenet && (
+                    <>
+                      <div>
+                        <label className="block text-sm font-medium text-neutral-300 mb-1">Ebook Save Path</label>
+                        <input
+                          type="text"
+                          value={formData.ebookSavePath}
+                          onChange={(e) => handleFormChange({ ebookSavePath: e.target.value })}
+                          className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                          placeholder="Client default"
+                        />
+                      </div>
+                      <div>
+                        <label className="block text-sm font-medium text-neutral-300 mb-1">Audiobook Save Path</label>
+                        <input
+                          type="text"
+                          value={formData.audiobookSavePath}
+                          onChange={(e) => handleFormChange({ audiobookSavePath: e.target.value })}
+                          className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                          placeholder="Client default"
+                        />
+                      </div>
+                    </>
+                  )}
+                </div>
+                <p className="text-xs text-neutral-500 mt-1">
+                  Optional. Leave empty to use the category above{!isUsenet ? " and the client's default folder" : ''}
+                </p>
+              </div>
+
+              {/* Seeding goals */}
+              {!isUsenet && (
+                <div>
+                  <div className="grid grid-cols-2 gap-4">
+                    <div>
+                      <label className="block text-sm font-medium text-neutral-300 mb-1">Seed Ratio</label>
+                      <input
+                        type="number"
+                        min="0"
+                        step="0.1"
+                        value={formData.seedRatio}
+                        onChange={(e) => handleFormChange({ seedRatio: parseFloat(e.target.value) || 0 })}
+                        className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                      />
+                    </div>
+                    <div>
+                      <label className="block text-sm font-medium text-neutral-300 mb-1">Seed Time (minutes)</label>
+                      <input
+                        type="number"
+                        min="0"
+                        value={formData.seedTime}
+                        onChange={(e) => handleFormChange({ seedTime: parseInt(e.target.value) || 0 })}
+                        className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                      />
+                    </div>
+                  </div>
+                  <p className="text-xs text-neutral-500 mt-1">
+                    Completed torrents are removed once either goal is met. Leave both at 0 to seed forever; indexers can override these.
+                  </p>
+                  <div className="flex items-center gap-3 mt-3">
+                    <input
+                      type="checkbox"
+                      id="deleteSeededFiles"
+                      checked={formData.deleteSeededFiles}
+                      onChange={(e) => handleFormChange({ deleteSeededFiles: e.target.checked })}
+                      className="w-4 h-4 rounded border-neutral-600 bg-neutral-900 text-sky-500 focus:ring-sky-500"
+                    />
+                    <label htmlFor="deleteSeededFiles" className="text-sm text-neutral-300">
+                      Delete downloaded files when removing seeded torrents
+                    </label>
+                  </div>
+                </div>
+              )}
+
               {/* Priority */}
               <div>
                 <label className="block text-sm font-medium text-neutral-300 mb-1">
@@ -1,11 +1,12 @@
 import { useState, useEffect } from 'react'
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
-import { ArrowLeft, Loader2, Globe, Settings, Calendar, Home, Check, X, RefreshCw, Database } from 'lucide-react'
+import { ArrowLeft, Loader2, Globe, Settings, Calendar, Home, Check, X, RefreshCw, Database, Download, ScrollText, Lock, Shield } from 'lucide-react'
 import { Link } from 'react-router-dom'
 import { Topbar } from '@/components/layout/Topbar'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
+import { Switch } from '@/components/ui/switch'
 import {
   Select,
   SelectContent,
@@ -15,6 +16,7 @@ import {
 } from '@/components/ui/select'
 import { Badge } from '@/components/ui/badge'
 import { getGeneralSettings, updateGeneralSettings, getAvailableLanguages, refreshAllMetadata } from '@/api/client'
+import { URL_BASE } from '@/lib/utils'
 
 interface GeneralSettings {
   instanceName: string
@@ -22,6 +24,28 @@ interface GeneralSettings {
   preferredLanguages: string[]
   startPage: string
   dateFormat: string
+  checkForUpdates: boolean // Check GitHub for new releases twice a day
+  urlBase: string
+  urlBaseFromEnv: boolean
+  logLevel: string
+  logComponentLevels: Record<string, string>
+  logToFile: boolean
+  logFilePath: string
+  logMaxSizeMb: number
+  logMaxFiles: number
+  logMaxAgeDays: number
+  httpsEnabled: boolean
+  httpsListenAddr: string
+  httpsCertPath: string
+  httpsKeyPath: string
+  httpsRedirect: boolean
+  rateLimitEnabled: boolean
+  rateLimitPerMinute: number
+  rateLimitBurst: number
+  loginMaxFailures: number
+  loginLockoutMinutes: number
+  corsAllowedOrigins: string[]
+  corsAllowCredentials: boolean
 }
 
 interface LanguageOption {
@@ -37,6 +61,25 @@ const START_PAGE_OPTIONS = [
   { value: 'wanted', label: 'Wanted' },
 ]
 
+const LOG_LEVEL_OPTIONS = [
+  { value: 'debug', label: 'Debug' },
+  { value: 'info', label: 'Info' },
+  { value: 'warn', label: 'Warning' },
+  { value: 'error', label: 'Error' },
+]
+
+// Parts of Shelfarr that can be logged at their own level
+const LOG_COMPONENTS = [
+  { value: 'scheduler', label: 'Scheduler' },
+  { value: 'jobs', label: 'Background Jobs' },
+  { value: 'indexers', label: 'Indexers & Search' },
+  { value: 'downloads', label: 'Downloads' },
+  { value: 'library', label: 'Library & Imports' },
+  { value: 'metadata', label: 'Metadata' },
+  { value: 'notifications', label: 'Notifications' },
+  { value: 'realtime', label: 'Live Updates' },
+]
+
 const DATE_FORMAT_OPTIONS = [
   { value: 'MMMM d, yyyy', label: 'January 1, 2024' },
   { value: 'MMM d, yyyy', label: 'Jan 1, 2024' },
@@ -69,7 +112,14 @@ export function GeneralSettingsPage() {
 
   const updateSettingsMutation = useMutation({
     mutationFn: updateGeneralSettings,
-    onSuccess: () => {
+    onSuccess: (_, saved) => {
+      // Shelfarr is now served under the new URL base, so reload there
+      const trimmed = (saved.urlBase ?? URL_BASE).replace(/^\/+|\/+$/g, '')
+      const urlBase = trimmed ? `/${trimmed}` : ''
+      if (!settings?.urlBaseFromEnv && urlBase !== URL_BASE) {
+        window.location.href = `${urlBase}/settings/general`
+        return
+      }
       queryClient.invalidateQueries({ queryKey: ['generalSettings'] })
       setHasChanges(false)
     },
@@ -161,6 +211,22 @@ export function GeneralSettingsPage() {
                 </p>
               </div>
 
+              <div className="space-y-2">
+                <Label htmlFor="urlBase">URL Base</Label>
+                <Input
+                  id="urlBase"
+                  value={localSettings.urlBase || ''}
+                  onChange={(e) => handleChange('urlBase', e.target.value)}
+                  placeholder="/shelfarr"
+                  disabled={settings?.urlBaseFromEnv}
+                />
+                <p className="text-xs text-muted-foreground">
+                  {settings?.urlBaseFromEnv
+                    ? 'Set by SHELFARR_URL_BASE or config.yml'
+                    : 'For reverse proxies that serve Shelfarr under a path, such as example.com/shelfarr. Leave empty to serve from the root. The page reloads at the new address.'}
+                </p>
+              </div>
+
               <div className="space-y-2">
                 <Label htmlFor="startPage">Start Page</Label>
                 <Select
@@ -277,6 +343,335 @@ export function GeneralSettingsPage() {
             </div>
           </section>
 
+          {/* Updates */}
+          <section className="space-y-4">
+            <div className="flex items-center gap-2 text-lg font-semibold">
+              <Download className="h-5 w-5 text-primary" />
+              <h2>Updates</h2>
+            </div>
+
+            <div className="bg-card border rounded-lg p-6 space-y-4">
+              <div className="flex items-center justify-between">
+                <div>
+                  <Label htmlFor="checkForUpdates">Check for Updates</Label>
+                  <p className="text-sm text-muted-foreground mt-1">
+                    Check GitHub for new Shelfarr releases twice a day. Turn on "On Update" for a notification to hear about them.
+                  </p>
+                </div>
+                <Switch
+                  id="checkForUpdates"
+                  checked={localSettings.checkForUpdates ?? true}
+                  onCheckedChange={(checked) => handleChange('checkForUpdates', checked)}
+                />
+              </div>
+            </div>
+          </section>
+
+          {/* HTTPS */}
+          <section className="space-y-4">
+            <div className="flex items-center gap-2 text-lg font-semibold">
+              <Lock className="h-5 w-5 text-primary" />
+              <h2>HTTPS</h2>
+            </div>
+
+            <div className="bg-card border rounded-lg p-6 space-y-4">
+              <div className="flex items-center justify-between">
+                <div>
+                  <Label htmlFor="httpsEnabled">Serve HTTPS</Label>
+                  <p className="text-sm text-muted-foreground mt-1">
+                    Serve Shelfarr over HTTPS directly, for setups without a reverse proxy. Takes effect when Shelfarr restarts.
+                  </p>
+                </div>
+                <Switch
+                  id="httpsEnabled"
+                  checked={localSettings.httpsEnabled ?? false}
+                  onCheckedChange={(checked) => handleChange('httpsEnabled', checked)}
+                />
+              </div>
+
+              {localSettings.httpsEnabled && (
+                <>
+                  <div className="space-y-2">
+                    <Label htmlFor="httpsListenAddr">HTTPS Address</Label>
+                    <Input
+                      id="httpsListenAddr"
+                      value={localSettings.httpsListenAddr || ''}
+                      onChange={(e) => handleChange('httpsListenAddr', e.target.value)}
+                      placeholder=":8443"
+                    />
+                    <p className="text-xs text-muted-foreground">
+                      Port to serve HTTPS on, besides the HTTP port. Publish it too when running in Docker.
+                    </p>
+                  </div>
+
+                  <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+                    <div className="space-y-2">
+                      <Label htmlFor="httpsCertPath">Certificate</Label>
+                      <Input
+                        id="httpsCertPath"
+                        value={localSettings.httpsCertPath || ''}
+                        onChange={(e) => handleChange('httpsCertPath', e.target.value)}
+                        placeholder="/config/certs/fullchain.pem"
+                      />
+                    </div>
+                    <div className="space-y-2">
+                      <Label htmlFor="httpsKeyPath">Private Key</Label>
+                      <Input
+                        id="httpsKeyPath"
+                        value={localSettings.httpsKeyPath || ''}
+                        onChange={(e) => handleChange('httpsKeyPath', e.target.value)}
+                        placeholder="/config/certs/privkey.pem"
+                      />
+                    </div>
+                  </div>
+                  <p className="text-xs text-muted-foreground">
+                    PEM files. Leave both empty to use a self-signed certificate, which browsers warn about until it's trusted.
+                  </p>
+
+                  <div className="flex items-center justify-between">
+                    <div>
+                      <Label htmlFor="httpsRedirect">Redirect HTTP to HTTPS</Label>
+                      <p className="text-sm text-muted-foreground mt-1">
+                        Send requests on the HTTP port to HTTPS instead of answering them
+                      </p>
+                    </div>
+                    <Switch
+                      id="httpsRedirect"
+                      checked={localSettings.httpsRedirect ?? false}
+                      onCheckedChange={(checked) => handleChange('httpsRedirect', checked)}
+                    />
+                  </div>
+                </>
+              )}
+            </div>
+          </section>
+
+          {/* Security */}
+          <section className="space-y-4">
+            <div className="flex items-center gap-2 text-lg font-semibold">
+              <Shield className="h-5 w-5 text-primary" />
+              <h2>Security</h2>
+            </div>
+
+            <div className="bg-card border rounded-lg p-6 space-y-4">
+              <div className="flex items-center justify-between">
+                <div>
+                  <Label htmlFor="rateLimitEnabled">Rate Limit API Requests</Label>
+                  <p className="text-sm text-muted-foreground mt-1">
+                    Slow down clients, by API key or address, that make requests faster than this
+                  </p>
+                </div>
+                <Switch
+                  id="rateLimitEnabled"
+                  checked={localSettings.rateLimitEnabled ?? true}
+                  onCheckedChange={(checked) => handleChange('rateLimitEnabled', checked)}
+                />
+              </div>
+
+              {(localSettings.rateLimitEnabled ?? true) && (
+                <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+                  <div className="space-y-2">
+                    <Label htmlFor="rateLimitPerMinute">Requests per Minute</Label>
+                    <Input
+                      id="rateLimitPerMinute"
+                      type="number"
+                      min={1}
+                      value={localSettings.rateLimitPerMinute ?? 600}
+                      onChange={(e) => handleChange('rateLimitPerMinute', parseInt(e.target.value) || 0)}
+                    />
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="rateLimitBurst">Burst</Label>
+                    <Input
+                      id="rateLimitBurst"
+                      type="number"
+                      min={1}
+                      value={localSettings.rateLimitBurst ?? 120}
+                      onChange={(e) => handleChange('rateLimitBurst', parseInt(e.target.value) || 0)}
+                    />
+                    <p className="text-xs text-muted-foreground">Requests allowed at once, such as when a page loads</p>
+                  </div>
+                </div>
+              )}
+
+              <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+                <div className="space-y-2">
+                  <Label htmlFor="loginMaxFailures">Failed Logins Before Lockout</Label>
+                  <Input
+                    id="loginMaxFailures"
+                    type="number"
+                    min={0}
+                    value={localSettings.loginMaxFailures ?? 5}
+                    onChange={(e) => handleChange('loginMaxFailures', parseInt(e.target.value) || 0)}
+                  />
+                  <p className="text-xs text-muted-foreground">From one address; 0 never locks out</p>
+                </div>
+                <div className="space-y-2">
+                  <Label htmlFor="loginLockoutMinutes">Lockout (minutes)</Label>
+                  <Input
+                    id="loginLockoutMinutes"
+                    type="number"
+                    min={1}
+                    value={localSettings.loginLockoutMinutes ?? 1}
+                    onChange={(e) => handleChange('loginLockoutMinutes', parseInt(e.target.value) || 0)}
+                  />
+                  <p className="text-xs text-muted-foreground">Doubles with each further failure, up to a day</p>
+                </div>
+              </div>
+
+              <div className="space-y-2">
+                <Label htmlFor="corsAllowedOrigins">Allowed Origins (CORS)</Label>
+                <textarea
+                  id="corsAllowedOrigins"
+                  rows={3}
+                  value={(localSettings.corsAllowedOrigins ?? ['*']).join('\n')}
+                  onChange={(e) => handleChange('corsAllowedOrigins', e.target.value.split('\n'))}
+                  placeholder="https://books.example.com"
+                  className="flex w-full rounded-md border border-input bg-background px-3 py-2 text-sm font-mono placeholder:text-muted-foreground focus-visible:outline-none focus-visible:ring-2 focus-visible:ring-ring"
+                />
+                <p className="text-xs text-muted-foreground">
+                  Sites hosted elsewhere that may call the API from the browser, one per line. Use * for any, https://*.example.com for subdomains, or leave empty for none.
+                </p>
+              </div>
+
+              <div className="flex items-center justify-between">
+                <div>
+                  <Label htmlFor="corsAllowCredentials">Allow Credentials</Label>
+                  <p className="text-sm text-muted-foreground mt-1">
+                    Let those sites send cookies and authorization headers; needs the origins listed rather than *
+                  </p>
+                </div>
+                <Switch
+                  id="corsAllowCredentials"
+                  checked={localSettings.corsAllowCredentials ?? false}
+                  onCheckedChange={(checked) => handleChange('corsAllowCredentials', checked)}
+                />
+              </div>
+            </div>
+          </section>
+
+          {/* Logging */}
+          <section className="space-y-4">
+            <div className="flex items-center gap-2 text-lg font-semibold">
+              <ScrollText className="h-5 w-5 text-primary" />
+              <h2>Logging</h2>
+            </div>
+
+            <div className="bg-card border rounded-lg p-6 space-y-4">
+              <div className="space-y-2">
+                <Label htmlFor="logLevel">Log Level</Label>
+                <Select
+                  value={localSettings.logLevel || 'info'}
+                  onValueChange={(value) => handleChange('logLevel', value)}
+                >
+                  <SelectTrigger id="logLevel">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    {LOG_LEVEL_OPTIONS.map((option) => (
+                      <SelectItem key={option.value} value={option.value}>
+                        {option.label}
+                      </SelectItem>
+                    ))}
+                  </SelectContent>
+                </Select>
+                <p className="text-xs text-muted-foreground">
+                  Least severe entries to log. Takes effect immediately; use Debug while tracking down a problem.
+                </p>
+              </div>
+
+              <div className="space-y-2">
+                <Label>Component Log Levels</Label>
+                <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
+                  {LOG_COMPONENTS.map((component) => (
+                    <div key={component.value} className="flex items-center justify-between gap-3">
+                      <span className="text-sm">{component.label}</span>
+                      <Select
+                        value={localSettings.logComponentLevels?.[component.value] || 'default'}
+                        onValueChange={(value) => {
+                          const levels = { ...(localSettings.logComponentLevels || {}) }
+                          if (value === 'default') {
+                            delete levels[component.value]
+                          } else {
+                            levels[component.value] = value
+                          }
+                          handleChange('logComponentLevels', levels)
+                        }}
+                      >
+                        <SelectTrigger className="w-36">
+                          <SelectValue />
+                        </SelectTrigger>
+                        <SelectContent>
+                          <SelectItem value="default">Default</SelectItem>
+                          {LOG_LEVEL_OPTIONS.map((option) => (
+                            <SelectItem key={option.value} value={option.value}>
+                              {option.label}
+                            </SelectItem>
+                          ))}
+                        </SelectContent>
+                      </Select>
+                    </div>
+                  ))}
+                </div>
+                <p className="text-xs text-muted-foreground">
+                  Log one part of Shelfarr in more or less detail than the rest
+                </p>
+              </div>
+
+              <div className="flex items-center justify-between pt-2">
+                <div>
+                  <Label htmlFor="logToFile">Log to File</Label>
+                  <p className="text-sm text-muted-foreground mt-1">
+                    Also write the log to {localSettings.logFilePath || 'the logs folder in the config folder'}
+                  </p>
+                </div>
+                <Switch
+                  id="logToFile"
+                  checked={localSettings.logToFile ?? false}
+                  onCheckedChange={(checked) => handleChange('logToFile', checked)}
+                />
+              </div>
+
+              {localSettings.logToFile && (
+                <div className="grid grid-cols-1 sm:grid-cols-3 gap-4">
+                  <div className="space-y-2">
+                    <Label htmlFor="logMaxSizeMb">Rotate at (MB)</Label>
+                    <Input
+                      id="logMaxSizeMb"
+                      type="number"
+                      min={1}
+                      value={localSettings.logMaxSizeMb ?? 10}
+                      onChange={(e) => handleChange('logMaxSizeMb', Math.max(1, Number(e.target.value)))}
+                    />
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="logMaxFiles">Files to Keep</Label>
+                    <Input
+                      id="logMaxFiles"
+                      type="number"
+                      min={0}
+                      value={localSettings.logMaxFiles ?? 5}
+                      onChange={(e) => handleChange('logMaxFiles', Math.max(0, Number(e.target.value)))}
+                    />
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="logMaxAgeDays">Keep for (days)</Label>
+                    <Input
+                      id="logMaxAgeDays"
+                      type="number"
+                      min={0}
+                      value={localSettings.logMaxAgeDays ?? 14}
+                      onChange={(e) => handleChange('logMaxAgeDays', Math.max(0, Number(e.target.value)))}
+                    />
+                  </div>
+                  <p className="sm:col-span-3 text-xs text-muted-foreground">
+                    A new file is started when the current one reaches the size. 0 keeps rotated files regardless of count or age.
+                  </p>
+                </div>
+              )}
+            </div>
+          </section>
+
           {/* Metadata Maintenance */}
           <section className="space-y-4">
             <div className="flex items-center gap-2 text-lg font-semibold">
@@ -337,6 +732,11 @@ export function GeneralSettingsPage() {
           {/* Save/Cancel Buttons */}
           {hasChanges && (
             <div className="flex items-center justify-end gap-4 py-4 border-t">
+              {updateSettingsMutation.isError && (
+                <p className="text-sm text-destructive mr-auto">
+                  {(updateSettingsMutation.error as { response?: { data?: { error?: string } } }).response?.data?.error || 'Failed to save settings'}
+                </p>
+              )}
               <Button
                 variant="outline"
                 onClick={handleCancel}
@@ -51,10 +51,10 @@ export function HardcoverAuthorPage() {
 
   const addAuthorMutation = useMutation({
     mutationFn: () => addAuthor(id!, true, false),
-    onSuccess: () => {
+    onSuccess: (result) => {
       queryClient.invalidateQueries({ queryKey: ['hardcoverAuthor', id] })
       queryClient.invalidateQueries({ queryKey: ['authors'] })
-      addNotification('success', `Now following ${author?.name || 'author'}`)
+      addNotification('success', 'requestId' in result ? result.message : `Now following ${author?.name || 'author'}`)
     },
     onError: (error: Error) => {
       const message = error.message.includes('409') || error.message.includes('Conflict')
@@ -89,6 +89,11 @@ export function HardcoverAuthorPage() {
         return next
       })
 
+      if (response.bookId === undefined) {
+        addNotification('success', response.message)
+        return
+      }
+
       queryClient.setQueryData<HardcoverAuthorDetail>(['hardcoverAuthor', id], (old) => {
         if (!old) return old
         return {
@@ -42,8 +42,8 @@ export function HardcoverBookPage() {
     onSuccess: (result) => {
       setIsAdding(false)
       invalidateAllBookQueries(queryClient)
-      // Navigate to the library book page
-      navigate(`/books/${result.bookId}`)
+      // Navigate to the library book page, or to the request made instead
+      navigate(result.bookId === undefined ? '/requests' : `/books/${result.bookId}`)
     },
     onError: (error: Error) => {
       setIsAdding(false)
@@ -48,6 +48,11 @@ export function HardcoverSeriesPage() {
         return next
       })
 
+      // Requested rather than added, so it isn't in the library yet
+      if (response.bookId === undefined) {
+        return
+      }
+
       queryClient.setQueryData<HardcoverSeriesDetail>(['hardcoverSeries', id], (old) => {
         if (!old) return old
         return {
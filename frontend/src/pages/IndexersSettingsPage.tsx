@@ -28,8 +28,9 @@ import {
 } from '@/components/ui/collapsible'
 import { getIndexers, addIndexer, updateIndexer, deleteIndexer, testIndexer } from '@/api/client'
 import type { Indexer } from '@/types'
+import { TagPicker } from '@/components/tags/TagPicker'
 
-type IndexerType = 'torznab' | 'mam' | 'anna'
+type IndexerType = 'torznab' | 'newznab' | 'mam' | 'anna' | 'libgen' | 'audiobookbay'
 
 interface IndexerFormData {
   name: string
@@ -41,6 +42,9 @@ interface IndexerFormData {
   enabled: boolean
   vipOnly: boolean
   freeleechOnly: boolean
+  seedRatio: number
+  seedTime: number
+  tags: number[]
 }
 
 const defaultFormData: IndexerFormData = {
@@ -53,23 +57,41 @@ const defaultFormData: IndexerFormData = {
   enabled: true,
   vipOnly: false,
   freeleechOnly: false,
+  seedRatio: 0,
+  seedTime: 0,
+  tags: [],
 }
 
 const indexerTypeInfo: Record<IndexerType, { name: string; description: string; fields: string[] }> = {
   mam: {
     name: 'MyAnonamouse',
     description: 'Native MAM integration with cookie authentication',
-    fields: ['cookie', 'vipOnly', 'freeleechOnly'],
+    fields: ['cookie', 'vipOnly', 'freeleechOnly', 'seedGoals'],
   },
   torznab: {
     name: 'Torznab',
     description: 'Generic Torznab API (Prowlarr, Jackett)',
+    fields: ['url', 'apiKey', 'seedGoals'],
+  },
+  newznab: {
+    name: 'Newznab',
+    description: 'Usenet indexer API, downloaded with SABnzbd or NZBGet',
     fields: ['url', 'apiKey'],
   },
   anna: {
     name: "Anna's Archive",
-    description: 'Web scraper for direct downloads',
-    fields: [],
+    description: 'Web scraper for direct downloads, with an optional member key for fast downloads',
+    fields: ['url', 'apiKey'],
+  },
+  libgen: {
+    name: 'Library Genesis',
+    description: 'Ebook search across LibGen mirrors, for direct downloads',
+    fields: ['url'],
+  },
+  audiobookbay: {
+    name: 'AudioBookBay',
+    description: 'Audiobook-only web scraper, downloaded as magnets with your torrent client',
+    fields: ['url', 'seedGoals'],
   },
 }
 
@@ -137,6 +159,9 @@ export function IndexersSettingsPage() {
       enabled: indexer.enabled,
       vipOnly: indexer.vipOnly || false,
       freeleechOnly: indexer.freeleechOnly || false,
+      seedRatio: indexer.seedRatio || 0,
+      seedTime: indexer.seedTime || 0,
+      tags: indexer.tags || [],
     })
     setIsDialogOpen(true)
   }
@@ -153,13 +178,24 @@ export function IndexersSettingsPage() {
     const data = {
       name: formData.name,
       type: formData.type,
-      url: formData.type === 'mam' ? 'https://www.myanonamouse.net' : formData.url,
+      url: formData.type === 'mam'
+        ? 'https://www.myanonamouse.net'
+        : formData.type === 'anna' && !formData.url
+          ? 'https://annas-archive.org'
+          : formData.type === 'libgen' && !formData.url
+            ? 'https://libgen.is,https://libgen.rs,https://libgen.st'
+            : formData.type === 'audiobookbay' && !formData.url
+              ? 'https://audiobookbay.lu'
+              : formData.url,
       apiKey: formData.apiKey,
       cookie: formData.cookie,
       priority: formData.priority,
       enabled: formData.enabled,
       vipOnly: formData.vipOnly,
       freeleechOnly: formData.freeleechOnly,
+      seedRatio: formData.seedRatio,
+      seedTime: formData.seedTime,
+      tags: formData.tags,
     }
 
     if (editingIndexer) {
@@ -357,30 +393,41 @@ export function IndexersSettingsPage() {
                 <SelectContent>
                   <SelectItem value="mam">MyAnonamouse (MAM)</SelectItem>
                   <SelectItem value="torznab">Torznab (Prowlarr/Jackett)</SelectItem>
+                  <SelectItem value="newznab">Newznab (Usenet)</SelectItem>
                   <SelectItem value="anna">Anna's Archive</SelectItem>
+                  <SelectItem value="libgen">Library Genesis</SelectItem>
+                  <SelectItem value="audiobookbay">AudioBookBay</SelectItem>
                 </SelectContent>
               </Select>
             </div>
 
-            {/* URL (for torznab) */}
+            {/* URL (for torznab and newznab, or Anna's Archive, LibGen and AudioBookBay mirrors) */}
             {typeFields.includes('url') && (
               <div className="space-y-2">
                 <Label htmlFor="url">URL</Label>
                 <Input
                   id="url"
-                  type="url"
+                  type={formData.type === 'libgen' ? 'text' : 'url'}
                   value={formData.url}
                   onChange={(e) => setFormData({ ...formData, url: e.target.value })}
-                  placeholder="https://indexer.example.com/api"
-                  required={formData.type === 'torznab'}
+                  placeholder={
+                    formData.type === 'anna'
+                      ? 'https://annas-archive.org'
+                      : formData.type === 'libgen'
+                        ? 'https://libgen.is, https://libgen.rs (optional)'
+                        : formData.type === 'audiobookbay'
+                          ? 'https://audiobookbay.lu'
+                          : 'https://indexer.example.com/api'
+                  }
+                  required={formData.type !== 'anna' && formData.type !== 'libgen' && formData.type !== 'audiobookbay'}
                 />
               </div>
             )}
 
-            {/* API Key (for torznab) */}
+            {/* API Key (for torznab and newznab, or an Anna's Archive member key) */}
             {typeFields.includes('apiKey') && (
               <div className="space-y-2">
-                <Label htmlFor="apiKey">API Key</Label>
+                <Label htmlFor="apiKey">{formData.type === 'anna' ? 'Member Key (optional)' : 'API Key'}</Label>
                 <Input
                   id="apiKey"
                   type="password"
@@ -526,6 +573,38 @@ export function IndexersSettingsPage() {
               </div>
             )}
 
+            {/* Seeding goals */}
+            {typeFields.includes('seedGoals') && (
+              <div className="space-y-2">
+                <div className="grid grid-cols-2 gap-4">
+                  <div className="space-y-2">
+                    <Label htmlFor="seedRatio">Seed Ratio</Label>
+                    <Input
+                      id="seedRatio"
+                      type="number"
+                      min="0"
+                      step="0.1"
+                      value={formData.seedRatio}
+                      onChange={(e) => setFormData({ ...formData, seedRatio: parseFloat(e.target.value) || 0 })}
+                    />
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="seedTime">Seed Time (minutes)</Label>
+                    <Input
+                      id="seedTime"
+                      type="number"
+                      min="0"
+                      value={formData.seedTime}
+                      onChange={(e) => setFormData({ ...formData, seedTime: parseInt(e.target.value) || 0 })}
+                    />
+                  </div>
+                </div>
+                <p className="text-xs text-muted-foreground">
+                  Torrents are removed from the download client once either goal is met. Leave at 0 to use the download client's goals.
+                </p>
+              </div>
+            )}
+
             {/* Priority */}
             <div className="space-y-2">
               <Label htmlFor="priority">Priority</Label>
@@ -541,6 +620,15 @@ export function IndexersSettingsPage() {
               </p>
             </div>
 
+            {/* Tags */}
+            <div className="space-y-2">
+              <Label>Tags</Label>
+              <TagPicker value={formData.tags} onChange={(tags) => setFormData({ ...formData, tags })} />
+              <p className="text-xs text-muted-foreground">
+                Only search this indexer for books or authors sharing a tag. Leave empty to search it for everything.
+              </p>
+            </div>
+
             {/* Enabled */}
             <div className="flex items-center justify-between">
               <Label htmlFor="enabled">Enabled</Label>
@@ -1,4 +1,4 @@
-import { useState, useMemo } from 'react'
+import { useState, useMemo, useEffect } from 'react'
 import { useNavigate } from 'react-router-dom'
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
 import { Topbar } from '@/components/layout/Topbar'
@@ -6,9 +6,9 @@ import { LibraryGrid } from '@/components/library/LibraryGrid'
 import { LibraryStats } from '@/components/library/LibraryStats'
 import { LibraryFilters } from '@/components/library/LibraryFilters'
 import { LibraryToolbar } from '@/components/library/LibraryToolbar'
-import { getLibrary, getLibraryStats, bulkUpdateBooks, bulkDeleteBooks, invalidateAllBookQueries } from '@/api/client'
+import { getLibrary, searchLibrary, getLibraryStats, getSavedFilters, scanLibrary, bulkUpdateBooks, bulkDeleteBooks, invalidateAllBookQueries } from '@/api/client'
 import { Button } from '@/components/ui/button'
-import { CheckSquare } from 'lucide-react'
+import { CheckSquare, FolderSearch } from 'lucide-react'
 import type { Book } from '@/types'
 
 export function LibraryPage() {
@@ -21,15 +21,36 @@ export function LibraryPage() {
   const [selectionMode, setSelectionMode] = useState(false)
   const [selectedBooks, setSelectedBooks] = useState<Set<number>>(new Set())
   const [monitoredFilter, setMonitoredFilter] = useState('')
+  const [savedFilterId, setSavedFilterId] = useState('')
+  const [debouncedSearch, setDebouncedSearch] = useState('')
+
+  // Search the library on the server once typing pauses
+  useEffect(() => {
+    const timer = setTimeout(() => setDebouncedSearch(searchQuery.trim()), 300)
+    return () => clearTimeout(timer)
+  }, [searchQuery])
 
   // Fetch library data
   const { data: libraryData, isLoading: libraryLoading, refetch } = useQuery({
-    queryKey: ['library', statusFilter, sortBy],
+    queryKey: ['library', statusFilter, sortBy, savedFilterId],
     queryFn: () => getLibrary({ 
       status: statusFilter || undefined,
+      filterId: savedFilterId ? Number(savedFilterId) : undefined,
       sortBy,
       pageSize: 100 
     }),
+    enabled: !debouncedSearch,
+  })
+
+  const { data: searchData, isLoading: searchLoading } = useQuery({
+    queryKey: ['library-search', debouncedSearch],
+    queryFn: () => searchLibrary(debouncedSearch, { pageSize: 100 }),
+    enabled: !!debouncedSearch,
+  })
+
+  const { data: savedFilters } = useQuery({
+    queryKey: ['saved-filters'],
+    queryFn: getSavedFilters,
   })
 
   // Fetch stats
@@ -38,6 +59,14 @@ export function LibraryPage() {
     queryFn: getLibraryStats,
   })
 
+  // Rescan root folders for files added, moved or deleted outside Shelfarr
+  const scanMutation = useMutation({
+    mutationFn: scanLibrary,
+    onSuccess: () => {
+      invalidateAllBookQueries(queryClient)
+    },
+  })
+
   // Bulk update mutation
   const bulkUpdateMutation = useMutation({
     mutationFn: ({ bookIds, monitored }: { bookIds: number[], monitored: boolean }) =>
@@ -62,6 +91,14 @@ export function LibraryPage() {
 
   // Client-side filtering for search and monitored status
   const filteredBooks = useMemo(() => {
+    // Search results come ranked by relevance, so only the other filters apply to them
+    if (debouncedSearch) {
+      let books = searchData?.books || []
+      if (statusFilter) books = books.filter((book: Book) => book.status === statusFilter)
+      if (monitoredFilter) books = books.filter((book: Book) => book.monitored === (monitoredFilter === 'monitored'))
+      return books
+    }
+
     if (!libraryData?.books) return []
     
     let books = libraryData.books
@@ -73,18 +110,8 @@ export function LibraryPage() {
       books = books.filter((book: Book) => !book.monitored)
     }
 
-    // Filter by search query
-    if (searchQuery) {
-      const query = searchQuery.toLowerCase()
-      books = books.filter((book: Book) => 
-        book.title.toLowerCase().includes(query) ||
-        book.author?.name.toLowerCase().includes(query) ||
-        book.series?.name.toLowerCase().includes(query)
-      )
-    }
-
     return books
-  }, [libraryData?.books, searchQuery, monitoredFilter])
+  }, [libraryData?.books, searchData?.books, debouncedSearch, statusFilter, monitoredFilter])
 
   const handleSelectBook = (book: Book) => {
     setSelectedBooks(prev => {
@@ -165,8 +192,23 @@ export function LibraryPage() {
               onViewModeChange={setViewMode}
               monitoredFilter={monitoredFilter}
               onMonitoredChange={setMonitoredFilter}
+              savedFilters={savedFilters}
+              savedFilterId={savedFilterId}
+              onSavedFilterChange={setSavedFilterId}
             />
           </div>
+          <Button
+            variant="outline"
+            size="sm"
+            onClick={() => scanMutation.mutate()}
+            disabled={scanMutation.isPending}
+            title={scanMutation.data
+              ? `Last scan: ${scanMutation.data.added} added, ${scanMutation.data.moved} moved, ${scanMutation.data.removed} removed, ${scanMutation.data.unmatched.length} unmatched`
+              : 'Scan root folders for added, moved or deleted files'}
+          >
+            <FolderSearch className="h-4 w-4 mr-2" />
+            {scanMutation.isPending ? 'Scanning...' : 'Rescan Folders'}
+          </Button>
           <Button
             variant={selectionMode ? 'secondary' : 'outline'}
             size="sm"
@@ -194,7 +236,7 @@ export function LibraryPage() {
         {/* Grid */}
         <LibraryGrid
           books={filteredBooks}
-          isLoading={libraryLoading}
+          isLoading={libraryLoading || searchLoading}
           selectedBooks={selectedBooks}
           selectionMode={selectionMode}
           onSelectBook={handleSelectBook}
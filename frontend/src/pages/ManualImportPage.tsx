@@ -4,11 +4,15 @@ import { Topbar } from '@/components/layout/Topbar'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Badge } from '@/components/ui/badge'
+import { BlobImage } from '@/components/book/BlobImage'
 import { 
   getPendingImports, 
+  getImportSuggestions,
+  getImportCover,
   searchHardcover, 
   manualImport 
 } from '@/api/client'
+import type { PendingImport, ImportCandidate } from '@/api/client'
 import { 
   File, 
   Search, 
@@ -19,23 +23,19 @@ import {
   GripVertical,
   ChevronRight,
   Check,
-  X
+  X,
+  Sparkles
 } from 'lucide-react'
 import { cn, formatFileSize } from '@/lib/utils'
 import type { SearchResult } from '@/types'
 
-interface PendingFile {
-  path: string
-  name: string
-  size: number
-  extension: string
-  modified: string
-}
+type PendingFile = PendingImport
 
 export function ManualImportPage() {
   const [selectedFile, setSelectedFile] = useState<PendingFile | null>(null)
   const [searchQuery, setSearchQuery] = useState('')
   const [selectedBook, setSelectedBook] = useState<SearchResult | null>(null)
+  const [selectedCandidate, setSelectedCandidate] = useState<ImportCandidate | null>(null)
   const [mediaType, setMediaType] = useState<'ebook' | 'audiobook'>('ebook')
   const queryClient = useQueryClient()
 
@@ -45,6 +45,13 @@ export function ManualImportPage() {
     queryFn: getPendingImports,
   })
 
+  // Ranked suggestions for the selected file, from the library and metadata providers
+  const { data: suggestions = [], isLoading: suggestionsLoading } = useQuery({
+    queryKey: ['import-suggestions', selectedFile?.path],
+    queryFn: () => getImportSuggestions({ path: selectedFile!.path }),
+    enabled: !!selectedFile,
+  })
+
   // Search Hardcover
   const { data: searchResults = [], isLoading: searchLoading } = useQuery({
     queryKey: ['search', searchQuery],
@@ -55,7 +62,11 @@ export function ManualImportPage() {
   // Import mutation
   const importMutation = useMutation({
     mutationFn: () => {
-      if (!selectedFile || !selectedBook) throw new Error('Missing selection')
+      if (!selectedFile) throw new Error('Missing selection')
+      if (selectedCandidate?.bookId) {
+        return manualImport(selectedFile.path, selectedCandidate.bookId, mediaType)
+      }
+      if (!selectedBook) throw new Error('Missing selection')
       return manualImport(selectedFile.path, parseInt(selectedBook.id), mediaType)
     },
     onSuccess: () => {
@@ -63,6 +74,7 @@ export function ManualImportPage() {
       queryClient.invalidateQueries({ queryKey: ['library'] })
       setSelectedFile(null)
       setSelectedBook(null)
+      setSelectedCandidate(null)
       setSearchQuery('')
     },
   })
@@ -73,13 +85,21 @@ export function ManualImportPage() {
   }
 
   const detectMediaType = (file: PendingFile): 'ebook' | 'audiobook' => {
-    const audioExtensions = ['mp3', 'm4b', 'm4a', 'flac', 'ogg', 'aac']
-    return audioExtensions.includes(file.extension.toLowerCase()) ? 'audiobook' : 'ebook'
+    return file.mediaType === 'audiobook' ? 'audiobook' : 'ebook'
   }
 
   const handleFileSelect = (file: PendingFile) => {
     setSelectedFile(file)
     setMediaType(detectMediaType(file))
+    // Preselect the best library match when it's a confident one
+    const best = file.candidates?.[0]
+    setSelectedCandidate(best && best.inLibrary && best.score >= 90 ? best : null)
+    setSelectedBook(null)
+    // An EPUB's metadata or an audiobook's tags make a better search than its file name
+    if ((file.format === 'epub' || file.mediaType === 'audiobook') && file.extractedTitle) {
+      setSearchQuery([file.extractedTitle, file.extractedAuthor].filter(Boolean).join(' '))
+      return
+    }
     // Pre-fill search with filename (cleaned up)
     const cleanName = file.name
       .replace(/\.[^.]+$/, '') // Remove extension
@@ -89,7 +109,8 @@ export function ManualImportPage() {
     setSearchQuery(cleanName)
   }
 
-  const canImport = selectedFile && selectedBook
+  const canImport = selectedFile && (selectedBook || selectedCandidate?.bookId)
+  const selectedTitle = selectedCandidate?.title ?? selectedBook?.title
 
   return (
     <div className="flex flex-col h-full">
@@ -120,7 +141,7 @@ export function ManualImportPage() {
                   <div key={i} className="h-16 skeleton rounded-lg" />
                 ))}
               </div>
-            ) : (pendingFiles as PendingFile[]).length === 0 ? (
+            ) : pendingFiles.length === 0 ? (
               <div className="flex flex-col items-center justify-center py-16 text-center">
                 <Import className="h-12 w-12 text-muted-foreground mb-4" />
                 <p className="text-muted-foreground">No files pending import</p>
@@ -130,7 +151,7 @@ export function ManualImportPage() {
               </div>
             ) : (
               <div className="space-y-2">
-                {(pendingFiles as PendingFile[]).map((file, idx) => (
+                {pendingFiles.map((file, idx) => (
                   <button
                     key={idx}
                     onClick={() => handleFileSelect(file)}
@@ -141,22 +162,37 @@ export function ManualImportPage() {
                         : 'border-border hover:border-primary/50'
                     )}
                   >
-                    <div className="rounded-md bg-muted p-2">
-                      {detectMediaType(file) === 'audiobook' ? (
-                        <Headphones className="h-5 w-5 text-muted-foreground" />
-                      ) : (
-                        <BookOpen className="h-5 w-5 text-muted-foreground" />
-                      )}
-                    </div>
+                    {file.hasEmbeddedCover ? (
+                      <BlobImage
+                        queryKey={['import-cover', file.path]}
+                        fetchImage={() => getImportCover(file.path)}
+                        className="h-12 w-9"
+                      />
+                    ) : (
+                      <div className="rounded-md bg-muted p-2">
+                        {detectMediaType(file) === 'audiobook' ? (
+                          <Headphones className="h-5 w-5 text-muted-foreground" />
+                        ) : (
+                          <BookOpen className="h-5 w-5 text-muted-foreground" />
+                        )}
+                      </div>
+                    )}
                     <div className="flex-1 min-w-0">
                       <p className="font-medium truncate">{file.name}</p>
                       <div className="flex items-center gap-2 text-xs text-muted-foreground">
                         <span>{formatFileSize(file.size)}</span>
                         <span>•</span>
                         <Badge variant="outline" className="text-[10px]">
-                          {file.extension.toUpperCase()}
+                          {file.format.toUpperCase()}
                         </Badge>
+                        {file.extractedIsbn && <span>ISBN {file.extractedIsbn}</span>}
+                        {file.extractedAsin && <span>ASIN {file.extractedAsin}</span>}
                       </div>
+                      {file.candidates?.[0] && (
+                        <p className="text-xs text-muted-foreground truncate mt-1">
+                          Likely: {file.candidates[0].title} ({file.candidates[0].score}%)
+                        </p>
+                      )}
                     </div>
                     <Button variant="ghost" size="icon" className="flex-shrink-0">
                       <Eye className="h-4 w-4" />
@@ -198,6 +234,51 @@ export function ManualImportPage() {
           </div>
 
           <div className="flex-1 overflow-y-auto p-4">
+            {/* Suggested matches */}
+            {selectedFile && (suggestionsLoading || suggestions.length > 0) && (
+              <div className="mb-4">
+                <h3 className="text-sm font-medium flex items-center gap-2 mb-2">
+                  <Sparkles className="h-4 w-4" />
+                  Suggested matches
+                </h3>
+                {suggestionsLoading ? (
+                  <div className="h-16 skeleton rounded-lg" />
+                ) : (
+                  <div className="space-y-2">
+                    {suggestions.map((candidate) => (
+                      <button
+                        key={candidate.bookId ? `book-${candidate.bookId}` : `${candidate.provider}-${candidate.providerId}`}
+                        onClick={() => {
+                          setSelectedCandidate(candidate)
+                          setSelectedBook(null)
+                        }}
+                        disabled={!candidate.inLibrary}
+                        title={candidate.inLibrary ? undefined : 'Add this book to your library to import to it'}
+                        className={cn(
+                          'w-full flex items-center gap-3 p-3 rounded-lg border transition-colors text-left disabled:opacity-60 disabled:cursor-not-allowed',
+                          selectedCandidate === candidate
+                            ? 'border-primary bg-primary/10'
+                            : 'border-border hover:border-primary/50'
+                        )}
+                      >
+                        <div className="flex-1 min-w-0">
+                          <p className="font-medium truncate">{candidate.title}</p>
+                          <p className="text-sm text-muted-foreground truncate">
+                            {candidate.author}
+                            {candidate.seriesName && ` • ${candidate.seriesName}${candidate.seriesIndex ? ` #${candidate.seriesIndex}` : ''}`}
+                          </p>
+                        </div>
+                        <Badge variant={candidate.inLibrary ? 'secondary' : 'outline'} className="flex-shrink-0">
+                          {candidate.inLibrary ? 'In Library' : candidate.provider}
+                        </Badge>
+                        <span className="text-sm font-medium w-10 text-right flex-shrink-0">{candidate.score}%</span>
+                      </button>
+                    ))}
+                  </div>
+                )}
+              </div>
+            )}
+
             {searchLoading ? (
               <div className="space-y-2">
                 {Array.from({ length: 5 }).map((_, i) => (
@@ -227,7 +308,10 @@ export function ManualImportPage() {
                 {searchResults.map((result) => (
                   <button
                     key={result.id}
-                    onClick={() => setSelectedBook(result)}
+                    onClick={() => {
+                      setSelectedBook(result)
+                      setSelectedCandidate(null)
+                    }}
                     className={cn(
                       'w-full flex items-center gap-3 p-3 rounded-lg border transition-colors text-left',
                       selectedBook?.id === result.id
@@ -278,7 +362,7 @@ export function ManualImportPage() {
                 <div>
                   <p className="text-sm font-medium">Ready to import</p>
                   <p className="text-xs text-muted-foreground">
-                    {selectedFile.name} → {selectedBook.title}
+                    {selectedFile.name} → {selectedTitle}
                   </p>
                 </div>
                 <div className="flex items-center gap-2">
@@ -294,6 +378,7 @@ export function ManualImportPage() {
                   onClick={() => {
                     setSelectedFile(null)
                     setSelectedBook(null)
+                    setSelectedCandidate(null)
                   }}
                 >
                   <X className="h-4 w-4 mr-2" />
@@ -315,4 +400,3 @@ export function ManualImportPage() {
     </div>
   )
 }
-
@@ -17,6 +17,7 @@ import {
 import { Link } from 'react-router-dom'
 import { Topbar } from '@/components/layout/Topbar'
 import { Button } from '@/components/ui/button'
+import { Badge } from '@/components/ui/badge'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
 import { Switch } from '@/components/ui/switch'
@@ -41,11 +42,27 @@ import {
   updateMediaSettings, 
   getRootFolders, 
   addRootFolder, 
+  updateRootFolder,
   deleteRootFolder,
+  getNamingPreview,
+  getLibraryRenames,
+  renameLibraryFiles,
+  getRecycleBin,
+  restoreRecycledFile,
+  deleteRecycledFile,
+  emptyRecycleBin,
   type MediaSettings,
-  type RootFolder
+  type RootFolder,
+  type ImportOperation
 } from '@/api/client'
 
+const importOperations: { value: ImportOperation; label: string }[] = [
+  { value: 'hardlink', label: 'Hardlink' },
+  { value: 'reflink', label: 'Reflink (copy-on-write)' },
+  { value: 'copy', label: 'Copy' },
+  { value: 'move', label: 'Move' },
+]
+
 // Format bytes to human-readable string
 function formatBytes(bytes: number): string {
   if (bytes === 0) return '0 B'
@@ -65,12 +82,45 @@ const NAMING_TOKENS = [
   { token: '{Format}', description: 'File format (epub, m4b, etc.)' },
 ]
 
+// Shows how a naming template names a sample book, or why the template is invalid
+function NamingPreviewText({
+  template,
+  mediaType,
+  ascii,
+  replaceIllegal,
+}: {
+  template: string
+  mediaType: 'ebook' | 'audiobook'
+  ascii: boolean
+  replaceIllegal: boolean
+}) {
+  const { data, error } = useQuery({
+    queryKey: ['namingPreview', template, mediaType, ascii, replaceIllegal],
+    queryFn: () => getNamingPreview(template, { mediaType, ascii, replaceIllegal }),
+    enabled: template !== '',
+    retry: false,
+  })
+
+  if (error) {
+    const message = (error as { response?: { data?: { error?: string } } }).response?.data?.error
+    return <p className="text-xs text-destructive">{message || 'Invalid template'}</p>
+  }
+  if (!data) return null
+  return (
+    <div className="text-xs text-muted-foreground space-y-0.5">
+      <p>Series: <code>{data.preview}</code></p>
+      <p>Standalone: <code>{data.standalone}</code></p>
+    </div>
+  )
+}
+
 export function MediaManagementSettingsPage() {
   const queryClient = useQueryClient()
   const [isAddFolderOpen, setIsAddFolderOpen] = useState(false)
   const [newFolderPath, setNewFolderPath] = useState('')
   const [newFolderType, setNewFolderType] = useState<'ebook' | 'audiobook'>('ebook')
   const [newFolderName, setNewFolderName] = useState('')
+  const [isRenameOpen, setIsRenameOpen] = useState(false)
   const [localSettings, setLocalSettings] = useState<Partial<MediaSettings>>({})
   const [hasChanges, setHasChanges] = useState(false)
 
@@ -109,6 +159,20 @@ export function MediaManagementSettingsPage() {
     },
   })
 
+  const { data: renames, isFetching: renamesLoading } = useQuery({
+    queryKey: ['libraryRenames'],
+    queryFn: () => getLibraryRenames(),
+    enabled: isRenameOpen,
+  })
+
+  const renameMutation = useMutation({
+    mutationFn: () => renameLibraryFiles(),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['libraryRenames'] })
+      setIsRenameOpen(false)
+    },
+  })
+
   const deleteFolderMutation = useMutation({
     mutationFn: deleteRootFolder,
     onSuccess: () => {
@@ -116,6 +180,20 @@ export function MediaManagementSettingsPage() {
     },
   })
 
+  const defaultFolderMutation = useMutation({
+    mutationFn: (folder: RootFolder) => updateRootFolder(folder.id, { ...folder, isDefault: true }),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['rootFolders'] })
+    },
+  })
+
+  const updateFolderMutation = useMutation({
+    mutationFn: (folder: RootFolder) => updateRootFolder(folder.id, folder),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['rootFolders'] })
+    },
+  })
+
   const handleSettingChange = <K extends keyof MediaSettings>(key: K, value: MediaSettings[K]) => {
     setLocalSettings(prev => ({ ...prev, [key]: value }))
     setHasChanges(true)
@@ -197,6 +275,8 @@ export function MediaManagementSettingsPage() {
                           key={folder.id} 
                           folder={folder} 
                           onDelete={handleDeleteFolder}
+                          onMakeDefault={(folder) => defaultFolderMutation.mutate(folder)}
+                          onChangeOperation={(folder, importOperation) => updateFolderMutation.mutate({ ...folder, importOperation })}
                           isDeleting={deleteFolderMutation.isPending}
                         />
                       ))}
@@ -221,6 +301,8 @@ export function MediaManagementSettingsPage() {
                           key={folder.id} 
                           folder={folder} 
                           onDelete={handleDeleteFolder}
+                          onMakeDefault={(folder) => defaultFolderMutation.mutate(folder)}
+                          onChangeOperation={(folder, importOperation) => updateFolderMutation.mutate({ ...folder, importOperation })}
                           isDeleting={deleteFolderMutation.isPending}
                         />
                       ))}
@@ -262,7 +344,13 @@ export function MediaManagementSettingsPage() {
                       id="fileNamingEbook"
                       value={localSettings.fileNamingEbook || '{Author}/{Title}'}
                       onChange={(e) => handleSettingChange('fileNamingEbook', e.target.value)}
-                      placeholder="{Author}/{Title}"
+                      placeholder="{Author}/{Series}/{SeriesIndex} - {Title} ({Year})"
+                    />
+                    <NamingPreviewText
+                      template={localSettings.fileNamingEbook || '{Author}/{Title}'}
+                      mediaType="ebook"
+                      ascii={localSettings.asciiFileNames === true}
+                      replaceIllegal={localSettings.replaceIllegalCharacters !== false}
                     />
                   </div>
 
@@ -273,7 +361,38 @@ export function MediaManagementSettingsPage() {
                       id="fileNamingAudiobook"
                       value={localSettings.fileNamingAudiobook || '{Author}/{Title}'}
                       onChange={(e) => handleSettingChange('fileNamingAudiobook', e.target.value)}
-                      placeholder="{Author}/{Title}"
+                      placeholder="{Author}/{Series}/{SeriesIndex} - {Title}"
+                    />
+                    <NamingPreviewText
+                      template={localSettings.fileNamingAudiobook || '{Author}/{Title}'}
+                      mediaType="audiobook"
+                      ascii={localSettings.asciiFileNames === true}
+                      replaceIllegal={localSettings.replaceIllegalCharacters !== false}
+                    />
+                  </div>
+
+                  <div className="flex items-center justify-between">
+                    <div>
+                      <Label>ASCII File Names</Label>
+                      <p className="text-xs text-muted-foreground">
+                        Replace accented letters (é → e) and drop other non-ASCII characters
+                      </p>
+                    </div>
+                    <Switch
+                      checked={localSettings.asciiFileNames === true}
+                      onCheckedChange={(checked) => handleSettingChange('asciiFileNames', checked)}
+                    />
+                  </div>
+                  <div className="flex items-center justify-between">
+                    <div>
+                      <Label>Replace Illegal Characters</Label>
+                      <p className="text-xs text-muted-foreground">
+                        Replace characters like : and ? that aren't allowed in file names, rather than removing them
+                      </p>
+                    </div>
+                    <Switch
+                      checked={localSettings.replaceIllegalCharacters !== false}
+                      onCheckedChange={(checked) => handleSettingChange('replaceIllegalCharacters', checked)}
                     />
                   </div>
 
@@ -292,6 +411,15 @@ export function MediaManagementSettingsPage() {
                       ))}
                     </div>
                   </div>
+
+                  <div className="flex items-center justify-between pt-2 border-t border-border">
+                    <p className="text-xs text-muted-foreground">
+                      Move existing library files to match the saved templates
+                    </p>
+                    <Button variant="outline" size="sm" onClick={() => setIsRenameOpen(true)} disabled={hasChanges}>
+                      Rename Library Files
+                    </Button>
+                  </div>
                 </div>
               </section>
 
@@ -313,6 +441,44 @@ export function MediaManagementSettingsPage() {
                       onCheckedChange={(checked) => handleSettingChange('rescanAfterImport', checked)}
                     />
                   </div>
+                  <div className="flex items-center justify-between">
+                    <div>
+                      <Label>Watch Downloads Folder</Label>
+                      <p className="text-xs text-muted-foreground">
+                        Import books as soon as they appear in the downloads folder, when they clearly match a library book
+                      </p>
+                    </div>
+                    <Switch
+                      checked={localSettings.watchDownloads === true}
+                      onCheckedChange={(checked) => handleSettingChange('watchDownloads', checked)}
+                    />
+                  </div>
+                  <div className="flex items-center justify-between">
+                    <div>
+                      <Label>Write Metadata Files</Label>
+                      <p className="text-xs text-muted-foreground">
+                        Write metadata.opf, desc.txt and the cover into each book's folder on import and metadata
+                        refresh, for Calibre, Audiobookshelf and other readers
+                      </p>
+                    </div>
+                    <Switch
+                      checked={localSettings.writeMetadataFiles === true}
+                      onCheckedChange={(checked) => handleSettingChange('writeMetadataFiles', checked)}
+                    />
+                  </div>
+                  <div className="flex items-center justify-between">
+                    <div>
+                      <Label>Tag Audiobook Files</Label>
+                      <p className="text-xs text-muted-foreground">
+                        Write the title, author, narrator, series, year and cover into imported audiobook files (requires
+                        FFmpeg)
+                      </p>
+                    </div>
+                    <Switch
+                      checked={localSettings.tagAudioFiles === true}
+                      onCheckedChange={(checked) => handleSettingChange('tagAudioFiles', checked)}
+                    />
+                  </div>
                 </div>
               </section>
 
@@ -324,20 +490,51 @@ export function MediaManagementSettingsPage() {
                 </h2>
 
                 <div className="space-y-4 p-4 rounded-lg bg-card border border-border">
-                  {/* Use Hardlinks */}
-                  <div className="flex items-center justify-between">
+                  {/* Import Operation */}
+                  <div className="flex items-center justify-between gap-4">
                     <div>
                       <Label className="flex items-center gap-2">
                         <Link2 className="h-4 w-4" />
-                        Use Hardlinks
+                        Import Method
                       </Label>
                       <p className="text-xs text-muted-foreground">
-                        Use hardlinks instead of copying files (saves disk space, requires same filesystem)
+                        How downloads are brought into the library. Hardlinks and reflinks save disk space and
+                        fall back to copying when the download is on another filesystem.
                       </p>
                     </div>
-                    <Switch
-                      checked={localSettings.useHardlinks || false}
-                      onCheckedChange={(checked) => handleSettingChange('useHardlinks', checked)}
+                    <Select
+                      value={localSettings.importOperation || 'hardlink'}
+                      onValueChange={(value) => handleSettingChange('importOperation', value as ImportOperation)}
+                    >
+                      <SelectTrigger className="w-56 shrink-0">
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent>
+                        {importOperations.map((op) => (
+                          <SelectItem key={op.value} value={op.value}>{op.label}</SelectItem>
+                        ))}
+                      </SelectContent>
+                    </Select>
+                  </div>
+
+                  {/* Minimum Free Space */}
+                  <div className="flex items-center justify-between gap-4">
+                    <div>
+                      <Label htmlFor="minimumFreeSpaceMb" className="flex items-center gap-2">
+                        <HardDrive className="h-4 w-4" />
+                        Minimum Free Space (MB)
+                      </Label>
+                      <p className="text-xs text-muted-foreground">
+                        Don't grab a release that would leave less than this free in the downloads folder. 0 turns the check off.
+                      </p>
+                    </div>
+                    <Input
+                      id="minimumFreeSpaceMb"
+                      type="number"
+                      min={0}
+                      value={localSettings.minimumFreeSpaceMb ?? 100}
+                      onChange={(e) => handleSettingChange('minimumFreeSpaceMb', Math.max(0, Number(e.target.value)))}
+                      className="w-56 shrink-0"
                     />
                   </div>
 
@@ -366,11 +563,31 @@ export function MediaManagementSettingsPage() {
                         id="recycleBinPath"
                         value={localSettings.recycleBinPath || ''}
                         onChange={(e) => handleSettingChange('recycleBinPath', e.target.value)}
-                        placeholder="/path/to/recycle-bin"
+                        placeholder="/config/recyclebin"
+                      />
+                      <p className="text-xs text-muted-foreground">
+                        Deleted files go to recyclebin in the config folder unless another folder is set
+                      </p>
+                    </div>
+                  )}
+
+                  {/* Recycle Bin Retention */}
+                  {localSettings.recycleBinEnabled && (
+                    <div className="space-y-2">
+                      <Label htmlFor="recycleBinRetentionDays">Keep Deleted Files (days)</Label>
+                      <Input
+                        id="recycleBinRetentionDays"
+                        type="number"
+                        min={0}
+                        value={localSettings.recycleBinRetentionDays ?? 7}
+                        onChange={(e) => handleSettingChange('recycleBinRetentionDays', Math.max(0, Number(e.target.value)))}
                       />
+                      <p className="text-xs text-muted-foreground">0 keeps files until the recycle bin is emptied</p>
                     </div>
                   )}
                 </div>
+
+                {settings?.recycleBinEnabled && <RecycleBinList />}
               </section>
 
               {/* Save Button */}
@@ -461,6 +678,119 @@ export function MediaManagementSettingsPage() {
           </form>
         </DialogContent>
       </Dialog>
+
+      {/* Rename Library Files Dialog */}
+      <Dialog open={isRenameOpen} onOpenChange={setIsRenameOpen}>
+        <DialogContent className="max-w-3xl">
+          <DialogHeader>
+            <DialogTitle>Rename Library Files</DialogTitle>
+            <DialogDescription>
+              These files will be moved to follow the naming templates. Files with a conflict are skipped.
+            </DialogDescription>
+          </DialogHeader>
+
+          {renamesLoading ? (
+            <div className="flex justify-center py-6">
+              <Loader2 className="h-6 w-6 animate-spin text-muted-foreground" />
+            </div>
+          ) : renames && renames.length > 0 ? (
+            <div className="max-h-96 overflow-y-auto space-y-2">
+              {renames.map((rename) => (
+                <div key={rename.mediaFileId} className="p-2 rounded border border-border text-xs space-y-0.5">
+                  <p className="font-medium text-sm">{rename.bookTitle}</p>
+                  <p className="text-muted-foreground break-all">{rename.currentPath}</p>
+                  <p className="break-all">→ {rename.newPath}</p>
+                  {rename.conflict && <p className="text-destructive">{rename.conflict}</p>}
+                </div>
+              ))}
+            </div>
+          ) : (
+            <p className="text-sm text-muted-foreground py-6 text-center">All files already follow the naming templates</p>
+          )}
+
+          {renameMutation.isError && (
+            <p className="text-sm text-destructive">
+              {(renameMutation.error as Error)?.message || 'Failed to rename files'}
+            </p>
+          )}
+
+          <DialogFooter>
+            <Button type="button" variant="outline" onClick={() => setIsRenameOpen(false)}>
+              Cancel
+            </Button>
+            <Button
+              onClick={() => renameMutation.mutate()}
+              disabled={renameMutation.isPending || !renames || renames.every((rename) => rename.conflict)}
+            >
+              {renameMutation.isPending && <Loader2 className="h-4 w-4 animate-spin" />}
+              Rename {renames ? renames.filter((rename) => !rename.conflict).length : 0} Files
+            </Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+    </div>
+  )
+}
+
+// Files in the recycle bin, which can be restored or deleted for good
+function RecycleBinList() {
+  const queryClient = useQueryClient()
+  const { data: files } = useQuery({
+    queryKey: ['recycleBin'],
+    queryFn: getRecycleBin,
+  })
+
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: ['recycleBin'] })
+  const restoreMutation = useMutation({ mutationFn: restoreRecycledFile, onSuccess: invalidate })
+  const deleteMutation = useMutation({ mutationFn: deleteRecycledFile, onSuccess: invalidate })
+  const emptyMutation = useMutation({ mutationFn: emptyRecycleBin, onSuccess: invalidate })
+
+  if (!files || files.length === 0) {
+    return <p className="text-sm text-muted-foreground mt-4">The recycle bin is empty</p>
+  }
+
+  return (
+    <div className="mt-4 space-y-2">
+      <div className="flex items-center justify-between">
+        <h3 className="text-sm font-medium text-muted-foreground">
+          {files.length} deleted {files.length === 1 ? 'file' : 'files'}
+        </h3>
+        <Button
+          variant="outline"
+          size="sm"
+          className="text-destructive hover:text-destructive"
+          disabled={emptyMutation.isPending}
+          onClick={() => confirm('Delete every file in the recycle bin for good?') && emptyMutation.mutate()}
+        >
+          Empty Recycle Bin
+        </Button>
+      </div>
+      {files.map((file) => (
+        <div key={file.id} className="flex items-center justify-between p-3 rounded-lg bg-card border border-border">
+          <div className="min-w-0">
+            <p className="text-sm font-medium truncate">{file.bookTitle || file.fileName}</p>
+            <p className="text-xs text-muted-foreground truncate">{file.originalPath}</p>
+            <p className="text-xs text-muted-foreground">
+              {formatBytes(file.fileSize)} · deleted {new Date(file.deletedAt * 1000).toLocaleDateString()}
+              {file.expiresAt && ` · removed ${new Date(file.expiresAt * 1000).toLocaleDateString()}`}
+            </p>
+          </div>
+          <div className="flex gap-2 shrink-0">
+            <Button variant="ghost" size="sm" disabled={restoreMutation.isPending} onClick={() => restoreMutation.mutate(file.id)}>
+              Restore
+            </Button>
+            <Button
+              variant="outline"
+              size="sm"
+              className="text-destructive hover:text-destructive"
+              disabled={deleteMutation.isPending}
+              onClick={() => deleteMutation.mutate(file.id)}
+            >
+              <Trash2 className="h-4 w-4" />
+            </Button>
+          </div>
+        </div>
+      ))}
     </div>
   )
 }
@@ -469,10 +799,14 @@ export function MediaManagementSettingsPage() {
 function RootFolderCard({
   folder,
   onDelete,
+  onMakeDefault,
+  onChangeOperation,
   isDeleting,
 }: {
   folder: RootFolder
   onDelete: (id: number) => void
+  onMakeDefault: (folder: RootFolder) => void
+  onChangeOperation: (folder: RootFolder, importOperation: ImportOperation | '') => void
   isDeleting: boolean
 }) {
   const usedSpace = folder.totalSpace - folder.freeSpace
@@ -496,6 +830,7 @@ function RootFolderCard({
             <span className={`text-sm ${folder.name ? 'text-muted-foreground' : 'font-medium'} truncate`}>
               {folder.path}
             </span>
+            {folder.isDefault && <Badge variant="secondary">Default</Badge>}
           </div>
           {folder.accessible ? (
             <div className="mt-1">
@@ -517,6 +852,25 @@ function RootFolderCard({
         </div>
       </div>
 
+      <Select
+        value={folder.importOperation || 'default'}
+        onValueChange={(value) => onChangeOperation(folder, value === 'default' ? '' : value as ImportOperation)}
+      >
+        <SelectTrigger className="w-44 shrink-0 mr-2" title="Import method">
+          <SelectValue />
+        </SelectTrigger>
+        <SelectContent>
+          <SelectItem value="default">Default import method</SelectItem>
+          {importOperations.map((op) => (
+            <SelectItem key={op.value} value={op.value}>{op.label}</SelectItem>
+          ))}
+        </SelectContent>
+      </Select>
+      {!folder.isDefault && (
+        <Button variant="ghost" size="sm" onClick={() => onMakeDefault(folder)} className="shrink-0 mr-2">
+          Make Default
+        </Button>
+      )}
       <Button
         variant="outline"
         size="sm"
@@ -2,12 +2,13 @@ import { useState, useEffect } from 'react';
 import { Plus, Trash2, Settings, CheckCircle2, XCircle, Loader2, Bell, RefreshCw } from 'lucide-react';
 import { apiClient, Notification } from '../api/client';
 
-type NotificationType = 'webhook' | 'discord' | 'telegram';
+type NotificationType = 'webhook' | 'discord' | 'telegram' | 'script';
 
 const NOTIFICATION_TYPES: { value: NotificationType; label: string; icon: string }[] = [
   { value: 'webhook', label: 'Webhook', icon: '🔗' },
   { value: 'discord', label: 'Discord', icon: '💬' },
   { value: 'telegram', label: 'Telegram', icon: '📱' },
+  { value: 'script', label: 'Custom Script', icon: '📜' },
 ];
 
 interface NotificationFormData {
@@ -18,12 +19,15 @@ interface NotificationFormData {
   discordWebhook: string;
   telegramBotToken: string;
   telegramChatId: string;
+  scriptPath: string;
   onGrab: boolean;
   onDownload: boolean;
   onUpgrade: boolean;
   onImport: boolean;
   onDelete: boolean;
   onHealthIssue: boolean;
+  onUpdate: boolean;
+  onRequest: boolean;
 }
 
 const defaultFormData: NotificationFormData = {
@@ -34,12 +38,15 @@ const defaultFormData: NotificationFormData = {
   discordWebhook: '',
   telegramBotToken: '',
   telegramChatId: '',
+  scriptPath: '',
   onGrab: false,
   onDownload: true,
   onUpgrade: false,
   onImport: true,
   onDelete: false,
   onHealthIssue: true,
+  onUpdate: false,
+  onRequest: false,
 };
 
 export default function NotificationsSettingsPage() {
@@ -92,12 +99,15 @@ export default function NotificationsSettingsPage() {
       discordWebhook: notification.discordWebhook || '',
       telegramBotToken: notification.telegramBotToken || '',
       telegramChatId: notification.telegramChatId || '',
+      scriptPath: notification.scriptPath || '',
       onGrab: notification.onGrab,
       onDownload: notification.onDownload,
       onUpgrade: notification.onUpgrade,
       onImport: notification.onImport,
       onDelete: notification.onDelete,
       onHealthIssue: notification.onHealthIssue,
+      onUpdate: notification.onUpdate,
+      onRequest: notification.onRequest,
     });
     setShowDialog(true);
   };
@@ -143,6 +153,8 @@ export default function NotificationsSettingsPage() {
     if (notification.onImport) triggers.push('Import');
     if (notification.onDelete) triggers.push('Delete');
     if (notification.onHealthIssue) triggers.push('Health');
+    if (notification.onUpdate) triggers.push('Update');
+    if (notification.onRequest) triggers.push('Request');
     return triggers;
   };
 
@@ -359,6 +371,26 @@ export default function NotificationsSettingsPage() {
                 </>
               )}
 
+              {formData.type === 'script' && (
+                <div>
+                  <label className="block text-sm font-medium text-neutral-300 mb-1">Script Path</label>
+                  <input
+                    type="text"
+                    value={formData.scriptPath}
+                    onChange={(e) => setFormData({ ...formData, scriptPath: e.target.value })}
+                    className="w-full px-3 py-2 bg-neutral-900 border border-neutral-700 rounded-lg text-neutral-100 focus:outline-none focus:border-sky-500"
+                    placeholder="/config/scripts/post-import.sh"
+                    required
+                  />
+                  <p className="mt-1 text-xs text-neutral-500">
+                    Runs on each selected event with details in environment variables: SHELFARR_EVENT_TYPE,
+                    SHELFARR_TITLE, SHELFARR_AUTHOR, SHELFARR_BOOK_ID, SHELFARR_PATH, SHELFARR_SOURCE_PATH,
+                    SHELFARR_FORMAT and SHELFARR_MEDIA_TYPE. The script must be in the scripts folder,
+                    /config/scripts unless SHELFARR_SCRIPTS_PATH says otherwise.
+                  </p>
+                </div>
+              )}
+
               {/* Triggers */}
               <div>
                 <label className="block text-sm font-medium text-neutral-300 mb-2">Notification Triggers</label>
@@ -370,6 +402,8 @@ export default function NotificationsSettingsPage() {
                     { key: 'onImport', label: 'On Import' },
                     { key: 'onDelete', label: 'On Delete' },
                     { key: 'onHealthIssue', label: 'On Health Issue' },
+                    { key: 'onUpdate', label: 'On Update' },
+                    { key: 'onRequest', label: 'On Request' },
                   ].map(({ key, label }) => (
                     <label key={key} className="flex items-center gap-2 text-sm text-neutral-300">
                       <input
@@ -7,6 +7,7 @@ import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
 import { Slider } from '@/components/ui/slider'
+import { Switch } from '@/components/ui/switch'
 import {
   Select,
   SelectContent,
@@ -22,8 +23,9 @@ import {
   DialogHeader,
   DialogTitle,
 } from '@/components/ui/dialog'
-import { getProfiles, createProfile, updateProfile, deleteProfile } from '@/api/client'
+import { getProfiles, createProfile, updateProfile, deleteProfile, getTags, setProfileTags } from '@/api/client'
 import type { QualityProfile, MediaType } from '@/types'
+import { TagPicker } from '@/components/tags/TagPicker'
 
 // Available formats by media type
 const EBOOK_FORMATS = ['epub', 'azw3', 'mobi', 'pdf', 'cbz', 'cbr']
@@ -34,6 +36,8 @@ interface ProfileFormData {
   mediaType: MediaType
   formatRanking: string[]
   minBitrate: number
+  embedMetadata: boolean
+  tags: number[]
 }
 
 const defaultFormData: ProfileFormData = {
@@ -41,6 +45,8 @@ const defaultFormData: ProfileFormData = {
   mediaType: 'ebook',
   formatRanking: [...EBOOK_FORMATS],
   minBitrate: 64,
+  embedMetadata: false,
+  tags: [],
 }
 
 export function QualityProfilesSettingsPage() {
@@ -55,18 +61,33 @@ export function QualityProfilesSettingsPage() {
     queryFn: getProfiles,
   })
 
+  const { data: tags = [] } = useQuery({
+    queryKey: ['tags'],
+    queryFn: getTags,
+  })
+
   const createMutation = useMutation({
-    mutationFn: createProfile,
+    mutationFn: async ({ data, tags }: { data: Omit<QualityProfile, 'id'>; tags: number[] }) => {
+      const profile = await createProfile(data)
+      if (tags.length > 0) await setProfileTags(profile.id, tags)
+      return profile
+    },
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['profiles'] })
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
       closeDialog()
     },
   })
 
   const updateMutation = useMutation({
-    mutationFn: ({ id, data }: { id: number; data: Partial<Omit<QualityProfile, 'id'>> }) => updateProfile(id, data),
+    mutationFn: async ({ id, data, tags }: { id: number; data: Partial<Omit<QualityProfile, 'id'>>; tags: number[] }) => {
+      const profile = await updateProfile(id, data)
+      await setProfileTags(id, tags)
+      return profile
+    },
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['profiles'] })
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
       closeDialog()
     },
   })
@@ -95,6 +116,8 @@ export function QualityProfilesSettingsPage() {
       mediaType: profile.mediaType,
       formatRanking: [...formats, ...missingFormats],
       minBitrate: profile.minBitrate || 64,
+      embedMetadata: profile.embedMetadata === true,
+      tags: tags.filter((t) => t.qualityProfileIds.includes(profile.id)).map((t) => t.id),
     })
     setIsDialogOpen(true)
   }
@@ -139,12 +162,13 @@ export function QualityProfilesSettingsPage() {
       mediaType: formData.mediaType,
       formatRanking: formData.formatRanking.join(','),
       minBitrate: formData.mediaType === 'audiobook' ? formData.minBitrate : 0,
+      embedMetadata: formData.mediaType === 'ebook' && formData.embedMetadata,
     }
 
     if (editingProfile) {
-      updateMutation.mutate({ id: editingProfile.id, data })
+      updateMutation.mutate({ id: editingProfile.id, data, tags: formData.tags })
     } else {
-      createMutation.mutate(data)
+      createMutation.mutate({ data, tags: formData.tags })
     }
   }
 
@@ -317,6 +341,22 @@ export function QualityProfilesSettingsPage() {
               </div>
             </div>
 
+            {/* Embed Metadata (for ebooks) */}
+            {formData.mediaType === 'ebook' && (
+              <div className="flex items-center justify-between">
+                <div>
+                  <Label>Embed Metadata</Label>
+                  <p className="text-xs text-muted-foreground">
+                    Update the title, authors, series, ISBN and cover inside imported EPUBs to match the library
+                  </p>
+                </div>
+                <Switch
+                  checked={formData.embedMetadata}
+                  onCheckedChange={(checked) => setFormData({ ...formData, embedMetadata: checked })}
+                />
+              </div>
+            )}
+
             {/* Min Bitrate (for audiobooks) */}
             {formData.mediaType === 'audiobook' && (
               <div className="space-y-2">
@@ -338,6 +378,15 @@ export function QualityProfilesSettingsPage() {
               </div>
             )}
 
+            {/* Tags */}
+            <div className="space-y-2">
+              <Label>Tags</Label>
+              <TagPicker value={formData.tags} onChange={(tags) => setFormData({ ...formData, tags })} />
+              <p className="text-xs text-muted-foreground">
+                Used ahead of the default profile for books or authors sharing a tag
+              </p>
+            </div>
+
             <DialogFooter className="mt-6">
               <Button type="button" variant="outline" onClick={closeDialog}>
                 Cancel
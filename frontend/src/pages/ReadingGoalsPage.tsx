@@ -0,0 +1,276 @@
+import { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import { Loader2, Plus, Trash2, ChevronLeft, ChevronRight, ChevronDown, ChevronUp, BookOpen } from 'lucide-react';
+import { apiClient, ReadingGoal, ReadingGoalUnit } from '../api/client';
+
+const MONTHS = [
+  'January', 'February', 'March', 'April', 'May', 'June',
+  'July', 'August', 'September', 'October', 'November', 'December',
+];
+
+const UNIT_LABELS: Record<ReadingGoalUnit, string> = {
+  books: 'books',
+  hours: 'hours listened',
+};
+
+const formatAmount = (value: number, unit: ReadingGoalUnit) =>
+  unit === 'hours' ? value.toFixed(1) : Math.floor(value).toString();
+
+export default function ReadingGoalsPage() {
+  const [year, setYear] = useState(new Date().getFullYear());
+  const [goals, setGoals] = useState<ReadingGoal[]>([]);
+  const [expanded, setExpanded] = useState<ReadingGoal | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+  const [month, setMonth] = useState(0);
+  const [unit, setUnit] = useState<ReadingGoalUnit>('books');
+  const [target, setTarget] = useState(12);
+  const [saving, setSaving] = useState(false);
+
+  const loadGoals = async () => {
+    try {
+      setLoading(true);
+      setGoals(await apiClient.getReadingGoals(year));
+      setError(null);
+    } catch (err) {
+      setError('Failed to load reading goals');
+      console.error(err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    setExpanded(null);
+    loadGoals();
+  }, [year]);
+
+  const handleCreate = async (e: React.FormEvent) => {
+    e.preventDefault();
+    try {
+      setSaving(true);
+      await apiClient.createReadingGoal({ year, month, unit, target });
+      await loadGoals();
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to add the goal');
+      console.error(err);
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleTarget = async (goal: ReadingGoal) => {
+    const value = prompt('New target', goal.target.toString());
+    if (value === null) return;
+    const newTarget = parseInt(value);
+    if (!newTarget || newTarget < 1) return;
+    try {
+      await apiClient.updateReadingGoal(goal.id, newTarget);
+      await loadGoals();
+    } catch (err) {
+      setError('Failed to update the goal');
+      console.error(err);
+    }
+  };
+
+  const handleDelete = async (goal: ReadingGoal) => {
+    if (!confirm('Remove this goal?')) return;
+    try {
+      await apiClient.deleteReadingGoal(goal.id);
+      if (expanded?.id === goal.id) setExpanded(null);
+      await loadGoals();
+    } catch (err) {
+      setError('Failed to remove the goal');
+      console.error(err);
+    }
+  };
+
+  const toggleBooks = async (goal: ReadingGoal) => {
+    if (expanded?.id === goal.id) {
+      setExpanded(null);
+      return;
+    }
+    try {
+      setExpanded(await apiClient.getReadingGoal(goal.id));
+    } catch (err) {
+      setError('Failed to load the goal');
+      console.error(err);
+    }
+  };
+
+  return (
+    <div className="space-y-6">
+      {/* Header */}
+      <div className="flex items-center justify-between">
+        <div>
+          <h1 className="text-2xl font-bold text-neutral-100">Reading Goals</h1>
+          <p className="text-neutral-400 mt-1">Books you finish and hours you listen count toward your goals</p>
+        </div>
+        <div className="flex items-center gap-2 text-neutral-300">
+          <button
+            onClick={() => setYear(year - 1)}
+            className="p-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            <ChevronLeft className="w-4 h-4" />
+          </button>
+          <span className="font-medium w-12 text-center">{year}</span>
+          <button
+            onClick={() => setYear(year + 1)}
+            className="p-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            <ChevronRight className="w-4 h-4" />
+          </button>
+        </div>
+      </div>
+
+      {/* New goal */}
+      <form
+        onSubmit={handleCreate}
+        className="flex flex-wrap items-center gap-3 bg-neutral-800/50 border border-neutral-700 rounded-xl px-4 py-3 text-sm text-neutral-400"
+      >
+        <input
+          type="number"
+          min={1}
+          value={target}
+          onChange={(e) => setTarget(Math.max(1, parseInt(e.target.value) || 1))}
+          className="w-20 px-2 py-1 bg-neutral-800 border border-neutral-700 rounded text-neutral-200 focus:outline-none focus:border-sky-500"
+        />
+        <select
+          value={unit}
+          onChange={(e) => setUnit(e.target.value as ReadingGoalUnit)}
+          className="px-2 py-1 bg-neutral-800 border border-neutral-700 rounded text-neutral-200 focus:outline-none focus:border-sky-500"
+        >
+          <option value="books">books</option>
+          <option value="hours">hours listened</option>
+        </select>
+        in
+        <select
+          value={month}
+          onChange={(e) => setMonth(parseInt(e.target.value))}
+          className="px-2 py-1 bg-neutral-800 border border-neutral-700 rounded text-neutral-200 focus:outline-none focus:border-sky-500"
+        >
+          <option value={0}>all of {year}</option>
+          {MONTHS.map((name, i) => (
+            <option key={name} value={i + 1}>
+              {name} {year}
+            </option>
+          ))}
+        </select>
+        <button
+          type="submit"
+          disabled={saving}
+          className="flex items-center gap-2 px-3 py-1.5 bg-sky-600 hover:bg-sky-500 disabled:opacity-50 text-white rounded-lg transition-colors"
+        >
+          {saving ? <Loader2 className="w-4 h-4 animate-spin" /> : <Plus className="w-4 h-4" />}
+          Add goal
+        </button>
+      </form>
+
+      {error && <p className="text-red-400 text-sm">{error}</p>}
+
+      {/* Goals */}
+      <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl">
+        {loading && goals.length === 0 ? (
+          <div className="flex items-center justify-center py-12">
+            <Loader2 className="w-6 h-6 text-sky-500 animate-spin" />
+          </div>
+        ) : goals.length === 0 ? (
+          <p className="text-neutral-500 py-6 text-center">No goals for {year}.</p>
+        ) : (
+          <ul className="divide-y divide-neutral-700/50">
+            {goals.map((goal) => (
+              <li key={goal.id} className="px-4 py-3">
+                <div className="flex items-center gap-4">
+                  <div className="flex-1 min-w-0">
+                    <div className="flex items-center gap-2 flex-wrap">
+                      <span className="font-medium text-neutral-100">
+                        {goal.month ? `${MONTHS[goal.month - 1]} ${goal.year}` : goal.year}
+                      </span>
+                      <span className="text-sm text-neutral-400">
+                        {formatAmount(goal.current, goal.unit)} of {goal.target} {UNIT_LABELS[goal.unit]}
+                      </span>
+                      {goal.completed ? (
+                        <span className="px-2 py-0.5 rounded text-xs bg-green-500/20 text-green-400">Done</span>
+                      ) : (
+                        new Date(goal.start) <= new Date() && (
+                          <span
+                            className={`px-2 py-0.5 rounded text-xs ${
+                              goal.onTrack ? 'bg-sky-500/20 text-sky-400' : 'bg-amber-500/20 text-amber-400'
+                            }`}
+                            title={`${formatAmount(goal.expected, goal.unit)} expected by now`}
+                          >
+                            {goal.onTrack ? 'On track' : 'Behind'}
+                          </span>
+                        )
+                      )}
+                    </div>
+                    <div className="mt-2 h-2 bg-neutral-700 rounded-full overflow-hidden">
+                      <div
+                        className={`h-full ${goal.completed ? 'bg-green-500' : 'bg-sky-500'}`}
+                        style={{ width: `${Math.min(100, goal.percent)}%` }}
+                      />
+                    </div>
+                  </div>
+
+                  <div className="flex items-center gap-1 flex-shrink-0">
+                    <button
+                      onClick={() => toggleBooks(goal)}
+                      title="Books"
+                      className="p-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+                    >
+                      {expanded?.id === goal.id ? <ChevronUp className="w-4 h-4" /> : <ChevronDown className="w-4 h-4" />}
+                    </button>
+                    <button
+                      onClick={() => handleTarget(goal)}
+                      className="px-2 py-1 text-sm text-neutral-400 hover:text-neutral-200 transition-colors"
+                    >
+                      Edit
+                    </button>
+                    <button
+                      onClick={() => handleDelete(goal)}
+                      title="Remove"
+                      className="p-2 text-neutral-400 hover:text-red-400 transition-colors"
+                    >
+                      <Trash2 className="w-4 h-4" />
+                    </button>
+                  </div>
+                </div>
+
+                {expanded?.id === goal.id && (
+                  <ul className="mt-3 space-y-2">
+                    {!expanded.books?.length ? (
+                      <li className="text-sm text-neutral-500">Nothing counted yet.</li>
+                    ) : (
+                      expanded.books.map((book) => (
+                        <li key={book.bookId} className="flex items-center gap-3">
+                          {book.coverUrl ? (
+                            <img src={book.coverUrl} alt="" className="w-8 h-11 object-cover rounded flex-shrink-0" />
+                          ) : (
+                            <div className="w-8 h-11 bg-neutral-700 rounded flex items-center justify-center flex-shrink-0">
+                              <BookOpen className="w-3 h-3 text-neutral-500" />
+                            </div>
+                          )}
+                          <div className="flex-1 min-w-0 text-sm">
+                            <Link to={`/books/${book.bookId}`} className="text-neutral-100 hover:text-sky-400">
+                              {book.title}
+                            </Link>
+                            {book.authorName && <span className="text-neutral-400"> · {book.authorName}</span>}
+                          </div>
+                          <span className="text-xs text-neutral-500 flex-shrink-0">
+                            {book.hours !== undefined
+                              ? `${book.hours.toFixed(1)} h`
+                              : book.finishedAt && new Date(book.finishedAt).toLocaleDateString()}
+                          </span>
+                        </li>
+                      ))
+                    )}
+                  </ul>
+                )}
+              </li>
+            ))}
+          </ul>
+        )}
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,313 @@
+import { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import { Loader2, RefreshCw, Check, X, Trash2, ChevronLeft, ChevronRight, BookOpen } from 'lucide-react';
+import { apiClient, MediaRequest, RequestQuota, RequestSettings, RequestStatus } from '../api/client';
+
+const PAGE_SIZE = 50;
+
+const STATUS_FILTERS: { value: RequestStatus | ''; label: string }[] = [
+  { value: '', label: 'All requests' },
+  { value: 'pending', label: 'Pending' },
+  { value: 'approved', label: 'Approved' },
+  { value: 'declined', label: 'Declined' },
+  { value: 'failed', label: 'Failed' },
+];
+
+const STATUS_STYLES: Record<RequestStatus, string> = {
+  pending: 'bg-amber-500/20 text-amber-400',
+  approved: 'bg-green-500/20 text-green-400',
+  declined: 'bg-neutral-600/40 text-neutral-300',
+  failed: 'bg-red-500/20 text-red-400',
+};
+
+const MEDIA_TYPE_LABELS: Record<MediaRequest['mediaType'], string> = {
+  ebook: 'Ebook',
+  audiobook: 'Audiobook',
+  both: 'Ebook & audiobook',
+};
+
+export default function RequestsPage() {
+  const [requests, setRequests] = useState<MediaRequest[]>([]);
+  const [total, setTotal] = useState(0);
+  const [canApprove, setCanApprove] = useState(false);
+  const [quota, setQuota] = useState<RequestQuota | null>(null);
+  const [settings, setSettings] = useState<RequestSettings | null>(null);
+  const [page, setPage] = useState(0);
+  const [status, setStatus] = useState<RequestStatus | ''>('');
+  const [loading, setLoading] = useState(true);
+  const [busyId, setBusyId] = useState<number | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  const loadRequests = async () => {
+    try {
+      setLoading(true);
+      const [data, quotaData] = await Promise.all([
+        apiClient.getRequests({
+          status: status || undefined,
+          limit: PAGE_SIZE,
+          offset: page * PAGE_SIZE,
+        }),
+        apiClient.getRequestQuota(),
+      ]);
+      setRequests(data.requests);
+      setTotal(data.total);
+      setCanApprove(data.canApprove);
+      setQuota(quotaData);
+      if (data.canApprove && !settings) {
+        setSettings(await apiClient.getRequestSettings());
+      }
+      setError(null);
+    } catch (err) {
+      setError('Failed to load requests');
+      console.error(err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    loadRequests();
+  }, [status, page]);
+
+  const act = async (id: number, action: () => Promise<unknown>) => {
+    try {
+      setBusyId(id);
+      await action();
+      await loadRequests();
+    } catch (err) {
+      setError('Failed to update the request');
+      console.error(err);
+    } finally {
+      setBusyId(null);
+    }
+  };
+
+  const handleDecline = (request: MediaRequest) => {
+    const reason = prompt(`Decline the request for "${request.title}"? You can say why.`);
+    if (reason === null) return;
+    act(request.id, () => apiClient.declineRequest(request.id, reason || undefined));
+  };
+
+  const handleDelete = (request: MediaRequest) => {
+    if (!confirm(`Remove the request for "${request.title}"?`)) return;
+    act(request.id, () => apiClient.deleteRequest(request.id));
+  };
+
+  const saveSettings = async (changes: Partial<RequestSettings>) => {
+    try {
+      setSettings(await apiClient.updateRequestSettings(changes));
+    } catch (err) {
+      setError('Failed to save the request settings');
+      console.error(err);
+    }
+  };
+
+  const pageCount = Math.max(1, Math.ceil(total / PAGE_SIZE));
+
+  return (
+    <div className="space-y-6">
+      {/* Header */}
+      <div className="flex items-center justify-between">
+        <div>
+          <h1 className="text-2xl font-bold text-neutral-100">Requests</h1>
+          <p className="text-neutral-400 mt-1">
+            {canApprove ? "Books and authors users have asked for" : "Books and authors you've asked for"}
+          </p>
+        </div>
+        <button
+          onClick={loadRequests}
+          className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+        >
+          <RefreshCw className="w-4 h-4" />
+          Refresh
+        </button>
+      </div>
+
+      {/* Quota, or for admins, the approval settings */}
+      {canApprove && settings ? (
+        <div className="flex flex-wrap items-center gap-4 bg-neutral-800/50 border border-neutral-700 rounded-xl px-4 py-3 text-sm">
+          <label className="flex items-center gap-2 text-neutral-300">
+            <input
+              type="checkbox"
+              checked={settings.autoApprove}
+              onChange={(e) => saveSettings({ autoApprove: e.target.checked })}
+              className="rounded border-neutral-600 bg-neutral-700 text-sky-500 focus:ring-sky-500"
+            />
+            Approve everyone's requests automatically
+          </label>
+          <label className="flex items-center gap-2 text-neutral-400">
+            up to
+            <input
+              type="number"
+              min={0}
+              value={settings.quota}
+              onChange={(e) => saveSettings({ quota: Math.max(0, parseInt(e.target.value) || 0) })}
+              className="w-16 px-2 py-1 bg-neutral-800 border border-neutral-700 rounded text-neutral-200 focus:outline-none focus:border-sky-500"
+            />
+            per user every
+            <input
+              type="number"
+              min={1}
+              value={settings.quotaDays}
+              onChange={(e) => saveSettings({ quotaDays: Math.max(1, parseInt(e.target.value) || 1) })}
+              className="w-16 px-2 py-1 bg-neutral-800 border border-neutral-700 rounded text-neutral-200 focus:outline-none focus:border-sky-500"
+            />
+            days (0 for no limit)
+          </label>
+        </div>
+      ) : (
+        quota && (
+          <p className="text-sm text-neutral-400">
+            {!quota.autoApprove
+              ? 'An admin reviews each of your requests.'
+              : quota.remaining < 0
+                ? 'Your requests are approved automatically.'
+                : `${quota.remaining} of your ${quota.limit} requests every ${quota.days} days left to approve automatically; an admin reviews the rest.`}
+          </p>
+        )
+      )}
+
+      {/* Filters */}
+      <select
+        value={status}
+        onChange={(e) => {
+          setStatus(e.target.value as RequestStatus | '');
+          setPage(0);
+        }}
+        className="px-3 py-2 bg-neutral-800/50 border border-neutral-700 rounded-lg text-neutral-200 focus:outline-none focus:border-sky-500"
+      >
+        {STATUS_FILTERS.map((filter) => (
+          <option key={filter.value} value={filter.value}>
+            {filter.label}
+          </option>
+        ))}
+      </select>
+
+      {/* Requests */}
+      <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl">
+        {loading && requests.length === 0 ? (
+          <div className="flex items-center justify-center py-12">
+            <Loader2 className="w-6 h-6 text-sky-500 animate-spin" />
+          </div>
+        ) : error ? (
+          <p className="text-red-400 py-6 text-center">{error}</p>
+        ) : requests.length === 0 ? (
+          <p className="text-neutral-500 py-6 text-center">
+            No requests. <Link to="/search" className="text-sky-400 hover:text-sky-300">Search</Link> for a book
+            to request it.
+          </p>
+        ) : (
+          <ul className="divide-y divide-neutral-700/50">
+            {requests.map((request) => (
+              <li key={request.id} className="flex items-start gap-4 px-4 py-3">
+                {request.coverUrl ? (
+                  <img src={request.coverUrl} alt="" className="w-10 h-14 object-cover rounded flex-shrink-0" />
+                ) : (
+                  <div className="w-10 h-14 bg-neutral-700 rounded flex items-center justify-center flex-shrink-0">
+                    <BookOpen className="w-4 h-4 text-neutral-500" />
+                  </div>
+                )}
+
+                <div className="flex-1 min-w-0">
+                  <div className="flex items-center gap-2 flex-wrap">
+                    {request.bookId ? (
+                      <Link to={`/books/${request.bookId}`} className="font-medium text-neutral-100 hover:text-sky-400">
+                        {request.title}
+                      </Link>
+                    ) : request.authorId ? (
+                      <Link to={`/authors/${request.authorId}`} className="font-medium text-neutral-100 hover:text-sky-400">
+                        {request.title}
+                      </Link>
+                    ) : (
+                      <span className="font-medium text-neutral-100">{request.title}</span>
+                    )}
+                    <span className={`px-2 py-0.5 rounded text-xs capitalize ${STATUS_STYLES[request.status]}`}>
+                      {request.status}
+                    </span>
+                    {request.bookStatus === 'downloaded' && (
+                      <span className="px-2 py-0.5 rounded text-xs bg-sky-500/20 text-sky-400">Available</span>
+                    )}
+                  </div>
+                  <p className="text-sm text-neutral-400">
+                    {request.type === 'author' ? 'Author, all books' : request.authorName || 'Book'} ·{' '}
+                    {MEDIA_TYPE_LABELS[request.mediaType]}
+                  </p>
+                  {request.note && <p className="text-sm text-neutral-300 mt-1">“{request.note}”</p>}
+                  {request.reason && <p className="text-xs text-neutral-500 mt-1">{request.reason}</p>}
+                  <p className="text-xs text-neutral-500 mt-1">
+                    {canApprove && <>{request.username} · </>}
+                    {new Date(request.createdAt).toLocaleString()}
+                    {request.autoApproved
+                      ? ' · approved automatically'
+                      : request.decidedBy && ` · ${request.status} by ${request.decidedBy}`}
+                  </p>
+                </div>
+
+                <div className="flex items-center gap-1 flex-shrink-0">
+                  {busyId === request.id ? (
+                    <Loader2 className="w-4 h-4 text-sky-500 animate-spin" />
+                  ) : (
+                    <>
+                      {canApprove && (request.status === 'pending' || request.status === 'failed') && (
+                        <button
+                          onClick={() => act(request.id, () => apiClient.approveRequest(request.id))}
+                          title={request.status === 'failed' ? 'Retry' : 'Approve'}
+                          className="p-2 text-neutral-400 hover:text-green-400 transition-colors"
+                        >
+                          <Check className="w-4 h-4" />
+                        </button>
+                      )}
+                      {canApprove && request.status === 'pending' && (
+                        <button
+                          onClick={() => handleDecline(request)}
+                          title="Decline"
+                          className="p-2 text-neutral-400 hover:text-red-400 transition-colors"
+                        >
+                          <X className="w-4 h-4" />
+                        </button>
+                      )}
+                      {(canApprove || request.status === 'pending') && (
+                        <button
+                          onClick={() => handleDelete(request)}
+                          title={canApprove ? 'Remove' : 'Cancel'}
+                          className="p-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+                        >
+                          <Trash2 className="w-4 h-4" />
+                        </button>
+                      )}
+                    </>
+                  )}
+                </div>
+              </li>
+            ))}
+          </ul>
+        )}
+      </div>
+
+      {/* Paging */}
+      {total > PAGE_SIZE && (
+        <div className="flex items-center justify-between text-sm text-neutral-400">
+          <span>
+            {total} requests, page {page + 1} of {pageCount}
+          </span>
+          <div className="flex items-center gap-2">
+            <button
+              onClick={() => setPage(page - 1)}
+              disabled={page === 0}
+              className="p-2 hover:text-neutral-200 disabled:opacity-40 transition-colors"
+            >
+              <ChevronLeft className="w-4 h-4" />
+            </button>
+            <button
+              onClick={() => setPage(page + 1)}
+              disabled={page + 1 >= pageCount}
+              className="p-2 hover:text-neutral-200 disabled:opacity-40 transition-colors"
+            >
+              <ChevronRight className="w-4 h-4" />
+            </button>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
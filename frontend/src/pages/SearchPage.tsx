@@ -18,6 +18,7 @@ import {
   automaticSearch
 } from '@/api/client'
 import { AddBookModal } from '@/components/search/AddBookModal'
+import { URL_BASE } from '@/lib/utils'
 import { 
   Search, 
   Plus, 
@@ -135,11 +136,11 @@ export function SearchPage() {
 
   const addAuthorMutation = useMutation({
     mutationFn: (hardcoverId: string) => addAuthor(hardcoverId, true, false),
-    onSuccess: (_, hardcoverId) => {
+    onSuccess: (result, hardcoverId) => {
       setRecentlyAddedAuthors(prev => new Set(prev).add(hardcoverId))
       queryClient.invalidateQueries({ queryKey: ['authors'] })
       queryClient.invalidateQueries({ queryKey: ['search'] })
-      addNotification('success', 'Author added to library')
+      addNotification('success', 'requestId' in result ? result.message : 'Author added to library')
     },
     onError: (error: Error) => {
       const message = error.message.includes('409') || error.message.includes('Conflict')
@@ -334,7 +335,7 @@ export function SearchPage() {
             <p className="text-sm text-muted-foreground mt-1 max-w-md text-center">
               {searchError instanceof Error ? searchError.message : 'Failed to search. Please check your API key in Settings.'}
             </p>
-            <Button variant="outline" className="mt-4" onClick={() => window.location.href = '/settings/library-search'}>
+            <Button variant="outline" className="mt-4" onClick={() => window.location.href = `${URL_BASE}/settings/library-search`}>
               Go to Settings
             </Button>
           </div>
@@ -551,10 +552,16 @@ export function SearchPage() {
         bookId={selectedBookId}
         isOpen={!!selectedBookId}
         onClose={() => setSelectedBookId(null)}
-        onSuccess={(bookId, downloadMode, mediaType) => {
+        onSuccess={(bookId, downloadMode, mediaType, message) => {
           queryClient.invalidateQueries({ queryKey: ['search'] })
           queryClient.invalidateQueries({ queryKey: ['library'] })
           setSelectedBookId(null)
+
+          // Requested rather than added: approving it searches for it
+          if (bookId === undefined) {
+            addNotification('success', message)
+            return
+          }
           
           // Handle download based on mode
           if (downloadMode === 'auto') {
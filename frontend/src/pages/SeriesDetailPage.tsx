@@ -10,12 +10,14 @@ import {
   Loader2,
   ListPlus,
   AlertCircle,
+  Merge,
   X
 } from 'lucide-react';
 import { getSeriesDetail, addHardcoverBook, deleteBook, invalidateAllBookQueries, type SeriesDetail, type Book } from '@/api/client';
 import { Button } from '@/components/ui/button';
 import { CatalogBookCard } from '@/components/library/CatalogBookCard';
 import { AddSeriesModal } from '@/components/series/AddSeriesModal';
+import { MergeSeriesDialog } from '@/components/series/MergeSeriesDialog';
 import { 
   BookSortFilter, 
   sortBooks, 
@@ -30,6 +32,7 @@ export default function SeriesDetailPage() {
   const [addingBooks, setAddingBooks] = useState<Set<string>>(new Set());
   const [deletingBooks, setDeletingBooks] = useState<Set<number>>(new Set());
   const [isAddSeriesModalOpen, setIsAddSeriesModalOpen] = useState(false);
+  const [mergeOpen, setMergeOpen] = useState(false);
   const [notifications, setNotifications] = useState<Array<{
     id: string;
     type: 'success' | 'error' | 'info';
@@ -57,6 +60,11 @@ export default function SeriesDetailPage() {
         return next;
       });
 
+      if (response.bookId === undefined) {
+        addNotification('success', response.message);
+        return;
+      }
+
       queryClient.setQueryData<SeriesDetail>(['series', id], (old) => {
         if (!old) return old;
         return {
@@ -306,31 +314,37 @@ export default function SeriesDetailPage() {
           <div className="flex-1 min-w-0">
             <div className="flex items-center justify-between">
               <h1 className="text-2xl font-bold text-neutral-100">{series.name}</h1>
-              {missingFromLibrary > 0 && (
-                <div className="flex items-center gap-2">
-                  <Button
-                    variant="outline"
-                    onClick={() => setIsAddSeriesModalOpen(true)}
-                    disabled={addingBooks.size > 0}
-                    size="sm"
-                  >
-                    <ListPlus className="w-4 h-4 mr-2" />
-                    Add Selected
-                  </Button>
-                  <Button
-                    onClick={handleAddAllMissing}
-                    disabled={addingBooks.size > 0}
-                    size="sm"
-                  >
-                    {addingBooks.size > 0 ? (
-                      <Loader2 className="w-4 h-4 animate-spin mr-2" />
-                    ) : (
-                      <Plus className="w-4 h-4 mr-2" />
-                    )}
-                    Add All Missing ({missingFromLibrary})
-                  </Button>
-                </div>
-              )}
+              <div className="flex items-center gap-2">
+                <Button variant="outline" size="sm" onClick={() => setMergeOpen(true)} title="Merge duplicate series into this one">
+                  <Merge className="w-4 h-4 mr-2" />
+                  Merge
+                </Button>
+                {missingFromLibrary > 0 && (
+                  <>
+                    <Button
+                      variant="outline"
+                      onClick={() => setIsAddSeriesModalOpen(true)}
+                      disabled={addingBooks.size > 0}
+                      size="sm"
+                    >
+                      <ListPlus className="w-4 h-4 mr-2" />
+                      Add Selected
+                    </Button>
+                    <Button
+                      onClick={handleAddAllMissing}
+                      disabled={addingBooks.size > 0}
+                      size="sm"
+                    >
+                      {addingBooks.size > 0 ? (
+                        <Loader2 className="w-4 h-4 animate-spin mr-2" />
+                      ) : (
+                        <Plus className="w-4 h-4 mr-2" />
+                      )}
+                      Add All Missing ({missingFromLibrary})
+                    </Button>
+                  </>
+                )}
+              </div>
             </div>
             
             {/* Stats */}
@@ -455,6 +469,13 @@ export default function SeriesDetailPage() {
         seriesName={series.name}
         books={books}
       />
+
+      <MergeSeriesDialog
+        seriesId={parseInt(id!)}
+        seriesName={series.name}
+        open={mergeOpen}
+        onOpenChange={setMergeOpen}
+      />
     </div>
   );
 }
@@ -1,5 +1,5 @@
 import { Topbar } from '@/components/layout/Topbar'
-import { Settings, Database, Download, Bell, Users, Palette, BookSearch, SlidersHorizontal } from 'lucide-react'
+import { Settings, Database, Download, Bell, Users, Palette, BookSearch, SlidersHorizontal, Tags } from 'lucide-react'
 import { Link } from 'react-router-dom'
 
 const settingsSections = [
@@ -27,6 +27,12 @@ const settingsSections = [
     icon: SlidersHorizontal,
     href: '/settings/profiles',
   },
+  {
+    title: 'Tags',
+    description: 'Route books and authors to indexers and profiles',
+    icon: Tags,
+    href: '/settings/tags',
+  },
   {
     title: 'Indexers',
     description: 'Configure search providers (MAM, Torznab, Anna)',
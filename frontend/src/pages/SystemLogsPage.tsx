@@ -0,0 +1,187 @@
+import { useState, useEffect, useRef } from 'react';
+import { Link } from 'react-router-dom';
+import { ArrowLeft, Loader2, RefreshCw, Search, Trash2, Pause, Play } from 'lucide-react';
+import { apiClient, LogEntry, LogLevel } from '../api/client';
+
+// How many entries are shown at most, newest kept
+const MAX_ENTRIES = 1000;
+
+const LEVEL_STYLES: Record<LogLevel, string> = {
+  debug: 'text-neutral-500',
+  info: 'text-sky-400',
+  warn: 'text-amber-400',
+  error: 'text-red-400',
+};
+
+export default function SystemLogsPage() {
+  const [entries, setEntries] = useState<LogEntry[]>([]);
+  const [currentLevel, setCurrentLevel] = useState<LogLevel>('info');
+  const [level, setLevel] = useState<LogLevel | ''>('');
+  const [search, setSearch] = useState('');
+  const [follow, setFollow] = useState(true);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+  const lastId = useRef(0);
+  const bottomRef = useRef<HTMLDivElement>(null);
+
+  const loadLogs = async () => {
+    try {
+      setLoading(true);
+      const data = await apiClient.getLogs({
+        limit: MAX_ENTRIES,
+        level: level || undefined,
+        search: search || undefined,
+      });
+      setEntries(data.entries);
+      setCurrentLevel(data.level);
+      lastId.current = data.entries.length ? data.entries[data.entries.length - 1].id : 0;
+      setError(null);
+    } catch (err) {
+      setError('Failed to load logs');
+      console.error(err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // Reload whenever the filters change
+  useEffect(() => {
+    const timer = setTimeout(loadLogs, 300);
+    return () => clearTimeout(timer);
+  }, [level, search]);
+
+  // While following, fetch only the entries logged since the last one shown
+  useEffect(() => {
+    if (!follow) return;
+    const interval = setInterval(async () => {
+      try {
+        const data = await apiClient.getLogs({
+          after: lastId.current,
+          level: level || undefined,
+          search: search || undefined,
+        });
+        setCurrentLevel(data.level);
+        if (data.entries.length === 0) return;
+        lastId.current = data.entries[data.entries.length - 1].id;
+        setEntries((prev) => [...prev, ...data.entries].slice(-MAX_ENTRIES));
+      } catch (err) {
+        console.error(err);
+      }
+    }, 2000);
+    return () => clearInterval(interval);
+  }, [follow, level, search]);
+
+  useEffect(() => {
+    if (follow) {
+      bottomRef.current?.scrollIntoView({ block: 'end' });
+    }
+  }, [entries, follow]);
+
+  const handleClear = async () => {
+    try {
+      await apiClient.clearLogs();
+      setEntries([]);
+    } catch (err) {
+      console.error(err);
+    }
+  };
+
+  return (
+    <div className="space-y-6">
+      {/* Header */}
+      <div className="flex items-center justify-between">
+        <div className="flex items-center gap-4">
+          <Link to="/system/status" className="text-neutral-400 hover:text-neutral-200 transition-colors">
+            <ArrowLeft className="w-5 h-5" />
+          </Link>
+          <div>
+            <h1 className="text-2xl font-bold text-neutral-100">Logs</h1>
+            <p className="text-neutral-400 mt-1">
+              Recent log entries. Logging at <span className="text-neutral-200">{currentLevel}</span> and
+              above; change it in Settings &gt; General.
+            </p>
+          </div>
+        </div>
+        <div className="flex items-center gap-2">
+          <button
+            onClick={() => setFollow(!follow)}
+            className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            {follow ? <Pause className="w-4 h-4" /> : <Play className="w-4 h-4" />}
+            {follow ? 'Pause' : 'Follow'}
+          </button>
+          <button
+            onClick={loadLogs}
+            className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            <RefreshCw className="w-4 h-4" />
+            Refresh
+          </button>
+          <button
+            onClick={handleClear}
+            className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-red-400 transition-colors"
+          >
+            <Trash2 className="w-4 h-4" />
+            Clear
+          </button>
+        </div>
+      </div>
+
+      {/* Filters */}
+      <div className="flex flex-col sm:flex-row gap-3">
+        <div className="relative flex-1">
+          <Search className="absolute left-3 top-1/2 -translate-y-1/2 w-4 h-4 text-neutral-500" />
+          <input
+            type="text"
+            value={search}
+            onChange={(e) => setSearch(e.target.value)}
+            placeholder="Search messages and fields..."
+            className="w-full pl-9 pr-3 py-2 bg-neutral-800/50 border border-neutral-700 rounded-lg text-neutral-200 placeholder-neutral-500 focus:outline-none focus:border-sky-500"
+          />
+        </div>
+        <select
+          value={level}
+          onChange={(e) => setLevel(e.target.value as LogLevel | '')}
+          className="px-3 py-2 bg-neutral-800/50 border border-neutral-700 rounded-lg text-neutral-200 focus:outline-none focus:border-sky-500"
+        >
+          <option value="">All levels</option>
+          <option value="debug">Debug and above</option>
+          <option value="info">Info and above</option>
+          <option value="warn">Warnings and errors</option>
+          <option value="error">Errors only</option>
+        </select>
+      </div>
+
+      {/* Entries */}
+      <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-4 font-mono text-xs overflow-x-auto">
+        {loading && entries.length === 0 ? (
+          <div className="flex items-center justify-center py-12">
+            <Loader2 className="w-6 h-6 text-sky-500 animate-spin" />
+          </div>
+        ) : error ? (
+          <p className="text-red-400 py-6 text-center">{error}</p>
+        ) : entries.length === 0 ? (
+          <p className="text-neutral-500 py-6 text-center">No log entries</p>
+        ) : (
+          <div className="space-y-1">
+            {entries.map((entry) => (
+              <div key={entry.id} className="flex gap-3 whitespace-nowrap">
+                <span className="text-neutral-500">{new Date(entry.time).toLocaleString()}</span>
+                <span className={`w-12 uppercase ${LEVEL_STYLES[entry.level]}`}>{entry.level}</span>
+                <span className="text-neutral-200">{entry.message}</span>
+                {entry.attrs && (
+                  <span className="text-neutral-500">
+                    {Object.entries(entry.attrs)
+                      .map(([key, value]) => `${key}=${value}`)
+                      .join(' ')}
+                  </span>
+                )}
+              </div>
+            ))}
+            <div ref={bottomRef} />
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
@@ -1,4 +1,5 @@
 import { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
 import { 
   Server, 
   Database, 
@@ -13,9 +14,20 @@ import {
   Calendar,
   Wifi,
   Activity,
-  FileArchive
+  FileArchive,
+  AlertTriangle,
+  Info,
+  ScrollText,
+  ShieldCheck,
+  Settings2
 } from 'lucide-react';
-import { apiClient, SystemStatus, TaskInfo } from '../api/client';
+import { apiClient, SystemStatus, TaskInfo, DiskSpace, HealthCheck, EffectiveConfig } from '../api/client';
+
+const CONFIG_SOURCE_STYLES: Record<string, string> = {
+  env: 'bg-purple-500/20 text-purple-400',
+  file: 'bg-sky-500/20 text-sky-400',
+  default: 'bg-neutral-700 text-neutral-400',
+};
 
 function formatBytes(bytes: number): string {
   if (bytes === 0) return '0 B';
@@ -28,24 +40,36 @@ function formatBytes(bytes: number): string {
 export default function SystemStatusPage() {
   const [status, setStatus] = useState<SystemStatus | null>(null);
   const [tasks, setTasks] = useState<TaskInfo[]>([]);
+  const [diskSpace, setDiskSpace] = useState<DiskSpace[]>([]);
+  const [healthChecks, setHealthChecks] = useState<HealthCheck[]>([]);
+  const [config, setConfig] = useState<EffectiveConfig | null>(null);
   const [loading, setLoading] = useState(true);
   const [runningTask, setRunningTask] = useState<string | null>(null);
 
   useEffect(() => {
     loadData();
-    // Refresh every 30 seconds
-    const interval = setInterval(loadData, 30000);
-    return () => clearInterval(interval);
+    // Refresh when a task finishes or a provider's health changes
+    return apiClient.subscribeToEvents((event) => {
+      if (event.type === 'health.changed' || (event.type === 'task.updated' && event.data.status !== 'running')) {
+        loadData();
+      }
+    });
   }, []);
 
   const loadData = async () => {
     try {
-      const [statusData, tasksData] = await Promise.all([
+      const [statusData, tasksData, diskSpaceData, healthData] = await Promise.all([
         apiClient.getSystemStatus(),
         apiClient.getSystemTasks(),
+        apiClient.getDiskSpace(),
+        apiClient.getHealth(),
       ]);
       setStatus(statusData);
       setTasks(tasksData);
+      setDiskSpace(diskSpaceData);
+      setHealthChecks(healthData.checks);
+      // Only admins can see the configuration
+      setConfig(await apiClient.getConfig().catch(() => null));
     } catch (error) {
       console.error('Failed to load system status:', error);
     } finally {
@@ -94,13 +118,86 @@ export default function SystemStatusPage() {
           <h1 className="text-2xl font-bold text-neutral-100">System Status</h1>
           <p className="text-neutral-400 mt-1">Monitor system health, tasks, and resource usage</p>
         </div>
-        <button
-          onClick={loadData}
-          className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
-        >
-          <RefreshCw className="w-4 h-4" />
-          Refresh
-        </button>
+        <div className="flex items-center gap-2">
+          <Link
+            to="/system/logs"
+            className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            <ScrollText className="w-4 h-4" />
+            Logs
+          </Link>
+          <Link
+            to="/system/audit"
+            className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            <ShieldCheck className="w-4 h-4" />
+            Audit Log
+          </Link>
+          <button
+            onClick={loadData}
+            className="flex items-center gap-2 px-4 py-2 text-neutral-400 hover:text-neutral-200 transition-colors"
+          >
+            <RefreshCw className="w-4 h-4" />
+            Refresh
+          </button>
+        </div>
+      </div>
+
+      {/* Update */}
+      {status.update.available && (
+        <div className="flex items-center justify-between gap-4 bg-sky-500/10 border border-sky-500/40 rounded-xl p-4">
+          <div>
+            <p className="font-medium text-neutral-100">
+              Shelfarr {status.update.latestVersion} is available
+            </p>
+            <p className="text-sm text-neutral-400 mt-1">
+              You're running {status.update.currentVersion}
+              {status.update.publishedAt && ` · released ${new Date(status.update.publishedAt).toLocaleDateString()}`}
+            </p>
+          </div>
+          {status.update.releaseUrl && (
+            <a
+              href={status.update.releaseUrl}
+              target="_blank"
+              rel="noopener noreferrer"
+              className="text-sm text-sky-400 hover:text-sky-300 shrink-0"
+            >
+              Release notes
+            </a>
+          )}
+        </div>
+      )}
+
+      {/* Health */}
+      <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
+        <h2 className="text-lg font-semibold text-neutral-100 mb-4 flex items-center gap-2">
+          <Activity className="w-5 h-5 text-rose-400" />
+          Health
+        </h2>
+        {healthChecks.length === 0 ? (
+          <div className="flex items-center gap-2 text-sm text-green-400">
+            <CheckCircle2 className="w-4 h-4" />
+            No issues found
+          </div>
+        ) : (
+          <div className="space-y-3">
+            {healthChecks.map((check) => (
+              <div key={check.source} className="flex items-start gap-3 p-3 bg-neutral-900/50 rounded-lg">
+                {check.severity === 'error' ? (
+                  <XCircle className="w-5 h-5 text-red-400 shrink-0 mt-0.5" />
+                ) : check.severity === 'warning' ? (
+                  <AlertTriangle className="w-5 h-5 text-amber-400 shrink-0 mt-0.5" />
+                ) : (
+                  <Info className="w-5 h-5 text-sky-400 shrink-0 mt-0.5" />
+                )}
+                <div>
+                  <p className="font-medium text-neutral-200">{check.message}</p>
+                  <p className="text-sm text-neutral-500 mt-1">{check.help}</p>
+                </div>
+              </div>
+            ))}
+          </div>
+        )}
       </div>
 
       {/* Overview Cards */}
@@ -114,7 +211,10 @@ export default function SystemStatusPage() {
             <span className="text-sm text-neutral-400">Version</span>
           </div>
           <p className="text-2xl font-bold text-neutral-100">{status.version}</p>
-          <p className="text-sm text-neutral-500 mt-1">{status.os}/{status.arch}</p>
+          <p className="text-sm text-neutral-500 mt-1">
+            {status.os}/{status.arch}
+            {status.build.commit && ` · ${status.build.commit.slice(0, 7)}${status.build.modified ? '+' : ''}`}
+          </p>
         </div>
 
         {/* Uptime */}
@@ -195,6 +295,43 @@ export default function SystemStatusPage() {
         </div>
       </div>
 
+      {/* About */}
+      <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
+        <div className="flex items-center justify-between mb-4">
+          <h2 className="text-lg font-semibold text-neutral-100 flex items-center gap-2">
+            <Cpu className="w-5 h-5 text-emerald-400" />
+            About
+          </h2>
+          <button
+            onClick={() => navigator.clipboard.writeText(JSON.stringify(status, null, 2))}
+            className="text-sm text-sky-400 hover:text-sky-300"
+          >
+            Copy for support
+          </button>
+        </div>
+        <dl className="grid grid-cols-1 md:grid-cols-2 gap-x-8 gap-y-2 text-sm">
+          {[
+            ['Version', status.build.version],
+            ['Commit', status.build.commit ? status.build.commit + (status.build.modified ? ' (modified)' : '') : 'Unknown'],
+            ['Build date', status.build.buildDate ? new Date(status.build.buildDate).toLocaleString() : 'Unknown'],
+            ['Go', `${status.goVersion} · ${status.runtime.goroutines} goroutines · ${formatBytes(status.runtime.memoryAlloc)} heap`],
+            ['CPUs', `${status.runtime.numCpu} (GOMAXPROCS ${status.runtime.gomaxprocs})`],
+            ['Timezone', status.timezone],
+            ['Database', `${status.database.type} ${status.database.version} · schema v${status.database.schemaVersion}`],
+            ['Config folder', status.paths.config],
+            ['Database file', status.paths.database],
+            ['Books folder', status.paths.books],
+            ['Audiobooks folder', status.paths.audiobooks],
+            ['Downloads folder', status.paths.downloads],
+          ].map(([label, value]) => (
+            <div key={label} className="flex justify-between gap-4 py-1 border-b border-neutral-700/50">
+              <dt className="text-neutral-400">{label}</dt>
+              <dd className="text-neutral-200 font-mono truncate">{value}</dd>
+            </div>
+          ))}
+        </dl>
+      </div>
+
       {/* Disk Status */}
       <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
         <h2 className="text-lg font-semibold text-neutral-100 mb-4 flex items-center gap-2">
@@ -237,6 +374,46 @@ export default function SystemStatusPage() {
         </div>
       </div>
 
+      {/* Disk Space */}
+      {diskSpace.length > 0 && (
+        <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
+          <h2 className="text-lg font-semibold text-neutral-100 mb-4 flex items-center gap-2">
+            <HardDrive className="w-5 h-5 text-cyan-400" />
+            Disk Space
+          </h2>
+          <div className="space-y-3">
+            {diskSpace.map((disk) => {
+              const usedPercent = disk.totalSpace > 0 ? ((disk.totalSpace - disk.freeSpace) / disk.totalSpace) * 100 : 0;
+              return (
+                <div key={disk.path} className="p-3 bg-neutral-900/50 rounded-lg">
+                  <div className="flex items-center justify-between mb-2">
+                    <div>
+                      <p className="font-medium text-neutral-200">{disk.label}</p>
+                      <p className="text-sm text-neutral-500 font-mono">{disk.path}</p>
+                    </div>
+                    {disk.accessible ? (
+                      <span className="text-sm text-neutral-400">
+                        {formatBytes(disk.freeSpace)} free of {formatBytes(disk.totalSpace)}
+                      </span>
+                    ) : (
+                      <span className="text-xs text-red-400">Not accessible</span>
+                    )}
+                  </div>
+                  {disk.accessible && (
+                    <div className="h-2 bg-neutral-700 rounded-full overflow-hidden">
+                      <div
+                        className={`h-full ${usedPercent > 90 ? 'bg-red-500' : usedPercent > 75 ? 'bg-amber-500' : 'bg-cyan-500'}`}
+                        style={{ width: `${usedPercent}%` }}
+                      />
+                    </div>
+                  )}
+                </div>
+              );
+            })}
+          </div>
+        </div>
+      )}
+
       {/* Scheduled Tasks */}
       <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
         <h2 className="text-lg font-semibold text-neutral-100 mb-4 flex items-center gap-2">
@@ -291,6 +468,42 @@ export default function SystemStatusPage() {
         </div>
       </div>
 
+      {/* Configuration */}
+      {config && (
+        <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
+          <h2 className="text-lg font-semibold text-neutral-100 mb-1 flex items-center gap-2">
+            <Settings2 className="w-5 h-5 text-sky-400" />
+            Configuration
+          </h2>
+          <p className="text-sm text-neutral-500 mb-4">
+            {config.configFile ? (
+              <>Read from <span className="font-mono">{config.configFile}</span>; environment variables override it</>
+            ) : (
+              'No config.yml found; set from environment variables and defaults'
+            )}
+          </p>
+          {config.configFileError && (
+            <p className="text-sm text-red-400 mb-4">{config.configFileError}</p>
+          )}
+          <div className="divide-y divide-neutral-700/50 text-sm">
+            {config.values.map((value) => (
+              <div key={value.env} className="flex items-center justify-between gap-4 py-2">
+                <div>
+                  <p className="text-neutral-300">{value.name}</p>
+                  <p className="text-xs text-neutral-500 font-mono">{value.env}</p>
+                </div>
+                <div className="flex items-center gap-3 min-w-0">
+                  <span className="font-mono text-neutral-200 truncate">{value.value || '—'}</span>
+                  <span className={`px-2 py-0.5 text-xs rounded ${CONFIG_SOURCE_STYLES[value.source]}`}>
+                    {value.source}
+                  </span>
+                </div>
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+
       {/* Runtime Info */}
       <div className="bg-neutral-800/50 border border-neutral-700 rounded-xl p-6">
         <h2 className="text-lg font-semibold text-neutral-100 mb-4 flex items-center gap-2">
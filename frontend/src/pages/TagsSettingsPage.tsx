@@ -0,0 +1,190 @@
+import { useState } from 'react'
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { Plus, Pencil, Trash2, Loader2, ArrowLeft, Tag as TagIcon, Check, X } from 'lucide-react'
+import { Link } from 'react-router-dom'
+import { Topbar } from '@/components/layout/Topbar'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import { getTags, createTag, updateTag, deleteTag } from '@/api/client'
+import type { Tag } from '@/types'
+
+export function TagsSettingsPage() {
+  const queryClient = useQueryClient()
+  const [newName, setNewName] = useState('')
+  const [editingId, setEditingId] = useState<number | null>(null)
+  const [editName, setEditName] = useState('')
+  const [error, setError] = useState<string | null>(null)
+
+  const { data: tags, isLoading } = useQuery({
+    queryKey: ['tags'],
+    queryFn: getTags,
+  })
+
+  const onError = (err: unknown) => {
+    setError((err as any)?.response?.data?.error || 'Failed to save tag')
+  }
+
+  const createMutation = useMutation({
+    mutationFn: createTag,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
+      setNewName('')
+      setError(null)
+    },
+    onError,
+  })
+
+  const updateMutation = useMutation({
+    mutationFn: ({ id, name }: { id: number; name: string }) => updateTag(id, name),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
+      setEditingId(null)
+      setError(null)
+    },
+    onError,
+  })
+
+  const deleteMutation = useMutation({
+    mutationFn: deleteTag,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['tags'] })
+      queryClient.invalidateQueries({ queryKey: ['indexers'] })
+    },
+  })
+
+  const handleCreate = (e: React.FormEvent) => {
+    e.preventDefault()
+    if (newName.trim()) {
+      createMutation.mutate(newName.trim())
+    }
+  }
+
+  const startEdit = (tag: Tag) => {
+    setEditingId(tag.id)
+    setEditName(tag.name)
+  }
+
+  const handleDelete = (tag: Tag) => {
+    if (confirm(`Delete the tag "${tag.name}"? It will be removed from everything it's on.`)) {
+      deleteMutation.mutate(tag.id)
+    }
+  }
+
+  const usage = (tag: Tag) => {
+    const parts = []
+    if (tag.bookCount > 0) parts.push(`${tag.bookCount} book${tag.bookCount === 1 ? '' : 's'}`)
+    if (tag.authorCount > 0) parts.push(`${tag.authorCount} author${tag.authorCount === 1 ? '' : 's'}`)
+    if (tag.indexerIds.length > 0) parts.push(`${tag.indexerIds.length} indexer${tag.indexerIds.length === 1 ? '' : 's'}`)
+    if (tag.qualityProfileIds.length > 0) {
+      parts.push(`${tag.qualityProfileIds.length} profile${tag.qualityProfileIds.length === 1 ? '' : 's'}`)
+    }
+    return parts.length > 0 ? parts.join(' • ') : 'Unused'
+  }
+
+  return (
+    <div className="flex flex-col h-full">
+      <Topbar title="Tags" subtitle="Label books and authors to route them to indexers and profiles" />
+
+      <div className="flex-1 overflow-auto p-6">
+        <div className="max-w-4xl mx-auto">
+          {/* Back link */}
+          <Link
+            to="/settings"
+            className="inline-flex items-center gap-2 text-muted-foreground hover:text-foreground mb-6 transition-colors"
+          >
+            <ArrowLeft className="h-4 w-4" />
+            Back to Settings
+          </Link>
+
+          {/* Header */}
+          <div className="mb-6">
+            <h2 className="text-xl font-semibold">Tags</h2>
+            <p className="text-sm text-muted-foreground mt-1">
+              Tagged indexers are only searched for books sharing one of their tags, and tagged quality
+              profiles are used for them ahead of the default. Books share their author's tags.
+            </p>
+          </div>
+
+          <form onSubmit={handleCreate} className="flex items-center gap-2 mb-6">
+            <Input
+              value={newName}
+              onChange={(e) => setNewName(e.target.value)}
+              placeholder="New tag name"
+              className="max-w-xs"
+            />
+            <Button type="submit" disabled={!newName.trim() || createMutation.isPending}>
+              {createMutation.isPending ? <Loader2 className="h-4 w-4 animate-spin" /> : <Plus className="h-4 w-4" />}
+              Add Tag
+            </Button>
+          </form>
+
+          {error && <p className="text-sm text-destructive mb-4">{error}</p>}
+
+          {isLoading ? (
+            <div className="flex items-center justify-center py-12">
+              <Loader2 className="h-8 w-8 animate-spin text-muted-foreground" />
+            </div>
+          ) : tags && tags.length > 0 ? (
+            <div className="space-y-3">
+              {tags.map((tag) => (
+                <div
+                  key={tag.id}
+                  className="flex items-center justify-between p-4 rounded-lg bg-card border border-border"
+                >
+                  <div className="flex items-center gap-4">
+                    <div className="w-10 h-10 rounded-lg bg-primary/10 flex items-center justify-center">
+                      <TagIcon className="h-5 w-5 text-primary" />
+                    </div>
+                    {editingId === tag.id ? (
+                      <form
+                        onSubmit={(e) => {
+                          e.preventDefault()
+                          if (editName.trim()) updateMutation.mutate({ id: tag.id, name: editName.trim() })
+                        }}
+                        className="flex items-center gap-2"
+                      >
+                        <Input value={editName} onChange={(e) => setEditName(e.target.value)} className="h-8 w-48" autoFocus />
+                        <Button type="submit" variant="outline" size="sm" disabled={updateMutation.isPending}>
+                          <Check className="h-4 w-4" />
+                        </Button>
+                        <Button type="button" variant="outline" size="sm" onClick={() => setEditingId(null)}>
+                          <X className="h-4 w-4" />
+                        </Button>
+                      </form>
+                    ) : (
+                      <div>
+                        <div className="font-medium">{tag.name}</div>
+                        <div className="text-xs text-muted-foreground mt-1">{usage(tag)}</div>
+                      </div>
+                    )}
+                  </div>
+
+                  {editingId !== tag.id && (
+                    <div className="flex items-center gap-2">
+                      <Button variant="outline" size="sm" onClick={() => startEdit(tag)}>
+                        <Pencil className="h-4 w-4" />
+                      </Button>
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        onClick={() => handleDelete(tag)}
+                        disabled={deleteMutation.isPending}
+                        className="text-destructive hover:text-destructive"
+                      >
+                        <Trash2 className="h-4 w-4" />
+                      </Button>
+                    </div>
+                  )}
+                </div>
+              ))}
+            </div>
+          ) : (
+            <div className="text-center py-8 border border-dashed border-border rounded-lg">
+              <p className="text-muted-foreground">No tags yet</p>
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  )
+}
@@ -13,6 +13,7 @@ import {
   Headphones
 } from 'lucide-react';
 import { apiClient, WantedBook } from '../api/client';
+import { coverSrc } from '../lib/utils';
 
 type TabType = 'missing' | 'cutoff';
 
@@ -161,7 +162,7 @@ export default function WantedPage() {
                   <Link to={`/books/${book.id}`} className="flex-shrink-0">
                     {book.coverUrl ? (
                       <img
-                        src={book.coverUrl}
+                        src={coverSrc(book.coverUrl, 100)}
                         alt={book.title}
                         className="w-16 h-24 object-cover rounded-lg"
                       />
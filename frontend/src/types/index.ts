@@ -39,6 +39,44 @@ export interface Genre {
   bookCount?: number
 }
 
+// Tags label books, authors, indexers and quality profiles. Tagged indexers are only
+// searched for books sharing one of their tags; a book shares its author's tags.
+export interface Tag {
+  id: number
+  name: string
+  bookCount: number
+  authorCount: number
+  indexerIds: number[]
+  qualityProfileIds: number[]
+}
+
+// A saved library view whose rules the server applies (?filterId= on /books and /library)
+export interface FilterRule {
+  field: 'status' | 'monitored' | 'mediaType' | 'format' | 'genre' | 'tag' | 'language' | 'rating'
+  operator: 'is' | 'isNot' | 'has' | 'lacks' | 'gte' | 'lte'
+  value: string
+}
+
+export interface SavedFilter {
+  id: number
+  name: string
+  rules: {
+    match: '' | 'all' | 'any'
+    rules: FilterRule[]
+  }
+}
+
+// A user's own shelf of books, kept in the order they arrange it
+export interface Collection {
+  id: number
+  name: string
+  description: string
+  opds: boolean             // Listed in the user's OPDS catalog
+  shared: boolean           // Shared with the user by an admin, so read-only
+  bookCount: number
+  books?: Book[]
+}
+
 export interface Author {
   id: number
   hardcoverId: string
@@ -46,10 +84,12 @@ export interface Author {
   sortName: string
   biography?: string
   imageUrl: string
+  customImage?: boolean     // Photo uploaded by the user
   monitored: boolean
   bookCount?: number        // Books in library
   totalBooksCount?: number  // Total books from Hardcover (cached)
   downloadedCount?: number  // Books with files
+  tags?: number[]
 }
 
 export interface Series {
@@ -70,6 +110,7 @@ export interface MediaFile {
   mediaType: MediaType
   bitrate?: number
   duration?: number
+  pageCount?: number // PDFs and comics
   editionName?: string
 }
 
@@ -78,9 +119,11 @@ export interface Book {
   hardcoverId: string
   title: string
   sortTitle: string
+  subtitle?: string
   isbn: string
   description: string
   coverUrl: string
+  customCover?: boolean // Cover picked or uploaded by the user
   rating: number
   releaseDate?: string
   pageCount: number
@@ -93,6 +136,11 @@ export interface Book {
   hasEbook: boolean
   hasAudiobook: boolean
   format?: string
+  genres?: string[]
+  lockedFields?: string[] // Edited by hand, left alone by metadata refreshes
+  tags?: number[] // The book's own tags, not its author's
+  ebookRootFolderId?: number // Unset imports into the default root folder
+  audiobookRootFolderId?: number
 }
 
 export interface LibraryResponse {
@@ -167,6 +215,7 @@ export interface IndexerSearchResult {
   title: string
   size: number
   format: string
+  protocol?: 'torrent' | 'usenet' | 'direct'
   seeders?: number
   leechers?: number
   downloadUrl: string
@@ -184,7 +233,7 @@ export interface IndexerSearchResult {
 export interface Indexer {
   id: number
   name: string
-  type: 'torznab' | 'mam' | 'anna'
+  type: 'torznab' | 'newznab' | 'mam' | 'anna' | 'libgen' | 'audiobookbay'
   url: string
   apiKey?: string
   cookie?: string
@@ -192,12 +241,15 @@ export interface Indexer {
   enabled: boolean
   vipOnly?: boolean
   freeleechOnly?: boolean
+  seedRatio?: number
+  seedTime?: number // Minutes
+  tags?: number[] // Only searched for books sharing one of these tags
 }
 
 export interface DownloadClient {
   id: number
   name: string
-  type: 'qbittorrent' | 'transmission' | 'deluge' | 'sabnzbd' | 'nzbget' | 'rtorrent' | 'internal'
+  type: 'qbittorrent' | 'transmission' | 'deluge' | 'aria2' | 'synology' | 'sabnzbd' | 'nzbget' | 'rtorrent' | 'internal'
   url: string
   username?: string
   password?: string
@@ -205,6 +257,13 @@ export interface DownloadClient {
   priority: number
   enabled: boolean
   settings?: string // JSON for extra config (seedbox type, path mappings, etc.)
+  ebookCategory?: string // Empty uses category
+  audiobookCategory?: string
+  ebookSavePath?: string // Empty uses the client's default folder
+  audiobookSavePath?: string
+  seedRatio: number
+  seedTime: number // Minutes
+  deleteSeededFiles: boolean
 }
 
 export interface User {
@@ -222,6 +281,27 @@ export interface ReadProgress {
   lastReadAt: string
 }
 
+export type ProgressStrategy = 'latest' | 'furthest'
+
+// Where the user last was on one of their devices
+export interface DeviceProgress {
+  device: string
+  progress: number
+  position: number
+  recordedAt: string
+}
+
+// Where to resume from across the user's devices
+export interface ResumeProgress {
+  progress: number
+  position: number
+  device?: string // Whose position it is
+  lastReadAt?: string
+  strategy: ProgressStrategy
+  conflict: boolean // The asking device was somewhere else
+  devices: DeviceProgress[]
+}
+
 export interface SeriesBookEntry {
   index: number
   book?: Book
@@ -260,11 +340,17 @@ export interface AuthorBookEntry {
   book?: Book
 }
 
+export interface AuthorAlias {
+  id: number
+  name: string
+}
+
 export interface AuthorDetail extends Author {
   books: AuthorBookEntry[]
   totalBooks: number
   inLibrary: number
   downloadedCount: number
+  aliases: AuthorAlias[] // Other names the author is matched by
 }
 
 // Legacy interface for backwards compatibility
@@ -278,6 +364,7 @@ export interface QualityProfile {
   mediaType: MediaType
   formatRanking: string
   minBitrate?: number
+  embedMetadata?: boolean // Rewrite imported EPUBs' metadata and cover to match the library
 }
 
 export interface HardcoverBookResult {
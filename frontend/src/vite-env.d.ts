@@ -8,3 +8,7 @@ interface ImportMeta {
   readonly env: ImportMetaEnv
 }
 
+interface Window {
+  __SHELFARR_URL_BASE__?: string
+}
+
@@ -4,6 +4,8 @@ import path from 'path'
 
 // https://vite.dev/config/
 export default defineConfig({
+  // Relative asset paths, so the build works under any URL base
+  base: './',
   plugins: [react()],
   resolve: {
     alias: {